@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"srv.exe.dev/db"
+	"srv.exe.dev/srv"
+	"srv.exe.dev/srv/areas"
+)
+
+// runAggregateParks drives srv.Server.MaterializeParkMetrics once, for
+// cron (or a manual backfill) to call outside of the in-process
+// park_metrics_refresh scheduled job (see srv/scheduler_jobs.go) —
+// useful for seeding park_metrics_current/park_metrics_daily right
+// after a fresh deploy, before the scheduler's first tick.
+func runAggregateParks(args []string) {
+	flags := flag.NewFlagSet("aggregate parks", flag.ExitOnError)
+	dbPath := flags.String("db", "db.sqlite3", "path to the sqlite database")
+	dataDir := flags.String("data", "data", "directory holding keystones.json")
+	flags.Parse(args)
+
+	wdb, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("aggregate parks: open db: %v", err)
+	}
+	defer wdb.Close()
+
+	areaStore, err := areas.LoadKeystones(*dataDir)
+	if err != nil {
+		log.Fatalf("aggregate parks: load areas: %v", err)
+	}
+
+	server := &srv.Server{DB: wdb, AreaStore: areaStore}
+
+	rowCount, ok, err := server.MaterializeParkMetrics(context.Background(), time.Now())
+	if err != nil {
+		log.Fatalf("aggregate parks: %v", err)
+	}
+	if !ok {
+		log.Println("aggregate parks: another pod holds the park_metrics lock, skipped")
+		return
+	}
+	log.Printf("materialized park_metrics_daily/park_metrics_current for %d park(s)\n", rowCount)
+}
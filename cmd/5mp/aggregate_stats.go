@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"srv.exe.dev/db"
+	"srv.exe.dev/srv"
+)
+
+// runAggregateStats drives srv.Server.MaterializeEffortRollups once, for
+// cron (or a manual backfill) to call outside of the in-process
+// effort_rollups_refresh scheduled job (see srv/scheduler_jobs.go) —
+// useful for seeding daily_effort_stats/daily_fire_stats/
+// monthly_effort_stats/pa_monthly_rollup right after a fresh deploy, or
+// for recomputing a specific window after a bulk import. -since defaults
+// to the last successful run recorded in aggregate_runs (see
+// srv/effort_rollups.go), or effortRollupsDefaultLookback if there isn't
+// one.
+func runAggregateStats(args []string) {
+	flags := flag.NewFlagSet("aggregate stats", flag.ExitOnError)
+	dbPath := flags.String("db", "db.sqlite3", "path to the sqlite database")
+	sinceStr := flags.String("since", "", "recompute rollups for days on/after this date (YYYY-MM-DD); defaults to the last successful run")
+	flags.Parse(args)
+
+	wdb, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("aggregate stats: open db: %v", err)
+	}
+	defer wdb.Close()
+
+	server := &srv.Server{DB: wdb}
+
+	since := time.Now().Add(-90 * 24 * time.Hour)
+	if *sinceStr != "" {
+		t, err := time.Parse("2006-01-02", *sinceStr)
+		if err != nil {
+			log.Fatalf("aggregate stats: invalid -since: %v", err)
+		}
+		since = t
+	} else if lastRun, ok, err := server.LastEffortRollupRun(context.Background()); err == nil && ok {
+		since = lastRun
+	}
+
+	rowCount, ok, err := server.MaterializeEffortRollups(context.Background(), since)
+	if err != nil {
+		log.Fatalf("aggregate stats: %v", err)
+	}
+	if !ok {
+		log.Println("aggregate stats: another pod holds the effort_rollups lock, skipped")
+		return
+	}
+	log.Printf("materialized effort rollups since %s: %d row(s)\n", since.Format("2006-01-02"), rowCount)
+}
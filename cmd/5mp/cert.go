@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"srv.exe.dev/db"
+	"srv.exe.dev/srv/auth"
+)
+
+func runCert(args []string) {
+	if len(args) < 1 || args[0] != "issue" {
+		usage()
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("cert issue", flag.ExitOnError)
+	dbPath := flags.String("db", "db.sqlite3", "path to the sqlite database")
+	userID := flags.String("user", "", "user ID to bind the certificate to (required)")
+	certPath := flags.String("cert", "", "path to the PEM-encoded client certificate (required)")
+	name := flags.String("name", "", "human-readable label for the machine identity (required)")
+	flags.Parse(args[1:])
+
+	if *userID == "" || *certPath == "" || *name == "" {
+		log.Fatal("cert issue: -user, -cert, and -name are all required")
+	}
+
+	pemBytes, err := os.ReadFile(*certPath)
+	if err != nil {
+		log.Fatalf("cert issue: read certificate: %v", err)
+	}
+
+	wdb, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("cert issue: open db: %v", err)
+	}
+	defer wdb.Close()
+
+	mgr := auth.NewManager(wdb)
+	cc, err := mgr.RegisterClientCert(context.Background(), *userID, pemBytes, *name)
+	if err != nil {
+		log.Fatalf("cert issue: %v", err)
+	}
+
+	log.Printf("registered client cert %q for user %s, fingerprint=%s", cc.Name, cc.UserID, cc.Fingerprint)
+}
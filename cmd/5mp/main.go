@@ -0,0 +1,69 @@
+// Command 5mp is the 5mp operator CLI. It's grown three subcommands so
+// far: "vault rewrap" rotates the passphrase protecting at-rest
+// encrypted GHSL tiles (see srv/vault) by decrypting and re-encrypting
+// each file in place with a new key, streaming old to new without ever
+// writing plaintext to disk; "cert issue" provisions an mTLS machine
+// identity (see srv/auth's client_certs.go) by binding a certificate's
+// fingerprint to a user account, without issuing that account a
+// password; "aggregate narratives" pre-computes the fire/deforestation/
+// settlement narratives park pages request live, materializing them
+// into the park_narratives table so the HTTP handlers can serve a
+// cached row instead of recomputing on every request (see
+// srv/narrative_cache.go); "aggregate parks" runs the same kind of
+// materialization for /api/export/parks, writing park_metrics_daily and
+// park_metrics_current so that endpoint reads one indexed table instead
+// of four GROUP BY scans per request (see srv/park_metrics.go); "aggregate
+// stats" populates daily_effort_stats, daily_fire_stats,
+// monthly_effort_stats, and pa_monthly_rollup so /api/stats can answer
+// with a handful of range queries instead of a per-year loop and three
+// ad-hoc fire/deforestation/settlement scans (see srv/effort_rollups.go).
+//
+// Every other maintenance task in this repo (fetchpas, fetchboundaries,
+// buildwdpaindex) is its own single-purpose binary under cmd/, since
+// each is a one-shot script with no shared state or flags worth a
+// subcommand dispatcher. Every subcommand here needs more than one flag
+// and a non-trivial operation (a directory walk; a database write; a
+// per-park aggregation loop), which outgrows that pattern — this is
+// meant to keep growing into a general 5mp admin CLI rather than
+// spawning another one-off binary.
+//
+// Usage:
+//
+//	go run ./cmd/5mp vault rewrap -new-passphrase-file=new.txt
+//	go run ./cmd/5mp cert issue -user=<user-id> -cert=machine.pem -name="ranger-drone-01"
+//	go run ./cmd/5mp aggregate narratives -db=db.sqlite3 -data=data
+//	go run ./cmd/5mp aggregate parks -db=db.sqlite3 -data=data
+//	go run ./cmd/5mp aggregate stats -db=db.sqlite3 -since=2026-06-01
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "vault":
+		runVault(os.Args[2:])
+	case "cert":
+		runCert(os.Args[2:])
+	case "aggregate":
+		runAggregate(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: 5mp vault rewrap [flags]")
+	fmt.Fprintln(os.Stderr, "       5mp cert issue [flags]")
+	fmt.Fprintln(os.Stderr, "       5mp aggregate narratives [flags]")
+	fmt.Fprintln(os.Stderr, "       5mp aggregate parks [flags]")
+	fmt.Fprintln(os.Stderr, "       5mp aggregate stats [flags]")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"srv.exe.dev/db"
+	"srv.exe.dev/srv"
+	"srv.exe.dev/srv/areas"
+	"srv.exe.dev/srv/places"
+)
+
+// runAggregate dispatches "aggregate narratives" and "aggregate parks"
+// to their respective implementations; see runAggregateNarratives here
+// and runAggregateParks in aggregate_parks.go.
+func runAggregate(args []string) {
+	if len(args) < 1 {
+		usage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "narratives":
+		runAggregateNarratives(args[1:])
+	case "parks":
+		runAggregateParks(args[1:])
+	case "stats":
+		runAggregateStats(args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func runAggregateNarratives(args []string) {
+	flags := flag.NewFlagSet("aggregate narratives", flag.ExitOnError)
+	dbPath := flags.String("db", "db.sqlite3", "path to the sqlite database")
+	dataDir := flags.String("data", "data", "directory holding keystones.json")
+	flags.Parse(args)
+
+	wdb, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("aggregate narratives: open db: %v", err)
+	}
+	defer wdb.Close()
+
+	areaStore, err := areas.LoadKeystones(*dataDir)
+	if err != nil {
+		log.Fatalf("aggregate narratives: load areas: %v", err)
+	}
+
+	// A bare Server carrying just the fields the narrative handlers
+	// read (DB, AreaStore, PlaceIndex) — not srv.New(), which also
+	// wires up templates/static assets this one-shot command never
+	// serves.
+	server := &srv.Server{
+		DB:          wdb,
+		AreaStore:   areaStore,
+		PlaceIndex:  places.NewSQLiteIndex(wdb),
+		DateParsers: srv.DefaultDateParsers(),
+	}
+
+	fireFrom, fireTo := srv.DefaultFireNarrativeYearRange()
+	deforFrom, deforTo := srv.DefaultDeforestationNarrativeYearRange()
+	generatedAt := time.Now()
+
+	var rows, failures int
+	for _, area := range areaStore.Areas {
+		if err := aggregateOne(server, "fire", area.ID, fireFrom, fireTo, generatedAt,
+			server.HandleAPIFireNarrative, new(srv.FireNarrative)); err != nil {
+			log.Printf("aggregate narratives: fire %s: %v", area.ID, err)
+			failures++
+		} else {
+			rows++
+		}
+
+		if err := aggregateOne(server, "deforestation", area.ID, deforFrom, deforTo, generatedAt,
+			server.HandleAPIDeforestationNarrative, new(srv.DeforestationNarrative)); err != nil {
+			log.Printf("aggregate narratives: deforestation %s: %v", area.ID, err)
+			failures++
+		} else {
+			rows++
+		}
+
+		if err := aggregateOne(server, "settlement", area.ID, 0, 0, generatedAt,
+			server.HandleAPISettlementNarrative, new(srv.SettlementNarrative)); err != nil {
+			log.Printf("aggregate narratives: settlement %s: %v", area.ID, err)
+			failures++
+		} else {
+			rows++
+		}
+	}
+
+	fmt.Printf("materialized %d narrative row(s) for %d park(s) (%d failure(s))\n", rows, len(areaStore.Areas), failures)
+}
+
+// aggregateOne drives handler exactly as the HTTP mux would for
+// GET /api/parks/{parkID}/..., decodes its JSON body into dest, and
+// stores the result under (kind, parkID, fromYear, toYear). It always
+// passes ?refresh=1 so the handler computes live rather than reading
+// back the very row this call is about to overwrite.
+func aggregateOne(server *srv.Server, kind, parkID string, fromYear, toYear int, generatedAt time.Time, handler http.HandlerFunc, dest interface{}) error {
+	req := httptest.NewRequest(http.MethodGet, "/?refresh=1", nil)
+	req.SetPathValue("id", parkID)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("handler returned %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), dest); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return srv.SaveNarrative(server.DB, kind, parkID, fromYear, toYear, dest, generatedAt)
+}
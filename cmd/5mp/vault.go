@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"srv.exe.dev/srv/vault"
+)
+
+func runVault(args []string) {
+	if len(args) < 1 || args[0] != "rewrap" {
+		usage()
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("vault rewrap", flag.ExitOnError)
+	dir := flags.String("dir", "data/ghsl", "directory tree of vault-encrypted files to rewrap")
+	saltPath := flags.String("salt", "data/vault-salt", "path to the vault's scrypt salt file")
+	oldPassphraseFile := flags.String("old-passphrase-file", "", "locked file holding the current passphrase (falls back to SRV_VAULT_PASSPHRASE)")
+	newPassphraseFile := flags.String("new-passphrase-file", "", "locked file holding the new passphrase (required)")
+	flags.Parse(args[1:])
+
+	if *newPassphraseFile == "" {
+		log.Fatal("vault rewrap: -new-passphrase-file is required")
+	}
+
+	oldPassphrase, err := vault.ResolvePassphrase(*oldPassphraseFile)
+	if err != nil {
+		log.Fatalf("vault rewrap: resolve current passphrase: %v", err)
+	}
+	if oldPassphrase == "" {
+		log.Fatal("vault rewrap: no current passphrase found (set -old-passphrase-file or SRV_VAULT_PASSPHRASE)")
+	}
+	newPassphrase, err := vault.ResolvePassphrase(*newPassphraseFile)
+	if err != nil {
+		log.Fatalf("vault rewrap: resolve new passphrase: %v", err)
+	}
+
+	// The scrypt salt doesn't need to change with the passphrase — it
+	// only needs to be unique per deployment — so both the old and new
+	// vault derive their key from the same salt file.
+	oldVault, err := vault.New(oldPassphrase, *saltPath)
+	if err != nil {
+		log.Fatalf("vault rewrap: init current vault: %v", err)
+	}
+	newVault, err := vault.New(newPassphrase, *saltPath)
+	if err != nil {
+		log.Fatalf("vault rewrap: init new vault: %v", err)
+	}
+
+	rewrapped := 0
+	err = filepath.WalkDir(*dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if err := rewrapFile(oldVault, newVault, path); err != nil {
+			return fmt.Errorf("rewrap %s: %w", path, err)
+		}
+		rewrapped++
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("vault rewrap: %v", err)
+	}
+
+	fmt.Printf("rewrapped %d file(s) under %s\n", rewrapped, *dir)
+}
+
+// rewrapFile decrypts path under oldVault and re-encrypts it under
+// newVault, writing to a sibling temp file and renaming it over path
+// only once the whole stream has been re-sealed successfully. The
+// plaintext only ever exists as bytes in flight between the decrypting
+// reader and the encrypting writer, never on disk.
+func rewrapFile(oldVault, newVault *vault.Vault, path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+
+	plain, err := oldVault.DecryptReader(src)
+	if err != nil {
+		return fmt.Errorf("open decrypting reader: %w", err)
+	}
+
+	tmpPath := path + ".rewrap.tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	enc, err := newVault.EncryptWriter(dst)
+	if err != nil {
+		dst.Close()
+		return fmt.Errorf("open encrypting writer: %w", err)
+	}
+
+	_, copyErr := io.Copy(enc, plain)
+	closeErr := enc.Close()
+	syncErr := dst.Sync()
+	if cerr := dst.Close(); cerr != nil && closeErr == nil {
+		closeErr = cerr
+	}
+	if err = firstNonNil(copyErr, closeErr, syncErr); err != nil {
+		return fmt.Errorf("rewrap stream: %w", err)
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replace original: %w", err)
+	}
+	return nil
+}
+
+func firstNonNil(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
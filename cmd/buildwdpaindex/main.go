@@ -4,6 +4,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,11 +13,17 @@ import (
 )
 
 const (
-	apiURL = "https://api.protectedplanet.net/v3/protected_areas/search"
-	apiKey = "dea58ea0389007e386776c4f583f4425"
+	apiURL  = "https://api.protectedplanet.net/v3/protected_areas/search"
+	apiKey  = "dea58ea0389007e386776c4f583f4425"
 	perPage = 50 // API max is 50
+
+	checkpointPath = "data/wdpa_index.checkpoint.json"
+	outputPath     = "data/wdpa_index.json"
 )
 
+var flagGlobal = flag.Bool("global", false, "fetch protected areas for all countries, not just Africa")
+var flagResume = flag.Bool("resume", false, "resume from data/wdpa_index.checkpoint.json instead of starting over")
+
 // African countries ISO3 codes
 var africanCountries = []string{
 	"DZA", "AGO", "BEN", "BWA", "BFA", "BDI", "CMR", "CPV", "CAF", "TCD",
@@ -27,6 +34,61 @@ var africanCountries = []string{
 	"TUN", "UGA", "ZMB", "ZWE",
 }
 
+// otherCountries extends africanCountries to a (non-exhaustive) global list
+// used when -global is passed. Protected Planet covers every ISO3 code; this
+// list covers the remaining major regions so a full run is realistic without
+// vendoring a full ISO-3166 table.
+var otherCountries = []string{
+	"USA", "CAN", "MEX", "BRA", "ARG", "CHL", "COL", "PER", "VEN", "ECU",
+	"GBR", "FRA", "DEU", "ESP", "ITA", "NLD", "SWE", "NOR", "FIN", "POL",
+	"RUS", "CHN", "IND", "IDN", "JPN", "KOR", "THA", "VNM", "MYS", "PHL",
+	"AUS", "NZL", "PNG", "SAU", "IRN", "TUR", "PAK", "BGD", "MNG", "KAZ",
+}
+
+// checkpoint records progress through the country list so a run interrupted
+// partway through (rate limiting, network blip, process restart) can resume
+// without re-fetching countries it already finished.
+type checkpoint struct {
+	CompletedCountries []string         `json:"completed_countries"`
+	Entries            []WDPAIndexEntry `json:"entries"`
+	Seen               map[int]bool     `json:"seen"`
+}
+
+func loadCheckpoint() (*checkpoint, error) {
+	data, err := os.ReadFile(checkpointPath)
+	if os.IsNotExist(err) {
+		return &checkpoint{Seen: make(map[int]bool)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	if cp.Seen == nil {
+		cp.Seen = make(map[int]bool)
+	}
+	return &cp, nil
+}
+
+func (cp *checkpoint) save() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(checkpointPath, data, 0o644)
+}
+
+func (cp *checkpoint) isDone(country string) bool {
+	for _, c := range cp.CompletedCountries {
+		if c == country {
+			return true
+		}
+	}
+	return false
+}
+
 type APIResponse struct {
 	ProtectedAreas []APIPA `json:"protected_areas"`
 }
@@ -131,21 +193,48 @@ func fetchCountry(client *http.Client, country string, seen map[int]bool) ([]WDP
 }
 
 func main() {
+	flag.Parse()
 	client := &http.Client{Timeout: 60 * time.Second}
 
-	var allPAs []WDPAIndexEntry
-	seen := make(map[int]bool)
+	countries := africanCountries
+	if *flagGlobal {
+		countries = append(append([]string{}, africanCountries...), otherCountries...)
+	}
+
+	cp, err := loadCheckpoint()
+	if err != nil {
+		log.Fatalf("Error loading checkpoint: %v", err)
+	}
+	if !*flagResume {
+		cp = &checkpoint{Seen: make(map[int]bool)}
+	}
+	allPAs := cp.Entries
+
+	for _, country := range countries {
+		if cp.isDone(country) {
+			log.Printf("Skipping %s (already completed, resuming)", country)
+			continue
+		}
 
-	for _, country := range africanCountries {
 		log.Printf("Fetching PAs for %s...", country)
 
-		entries, err := fetchCountry(client, country, seen)
+		entries, err := fetchCountry(client, country, cp.Seen)
 		if err != nil {
-			log.Printf("  Error: %v", err)
-			continue
+			// Persist progress so far before bailing; a re-run with -resume
+			// will pick back up at this country.
+			cp.Entries = allPAs
+			if saveErr := cp.save(); saveErr != nil {
+				log.Printf("  Error saving checkpoint: %v", saveErr)
+			}
+			log.Fatalf("  Error fetching %s: %v (progress saved to %s, re-run with -resume)", country, err, checkpointPath)
 		}
 
 		allPAs = append(allPAs, entries...)
+		cp.CompletedCountries = append(cp.CompletedCountries, country)
+		cp.Entries = allPAs
+		if err := cp.save(); err != nil {
+			log.Printf("  Warning: failed to save checkpoint: %v", err)
+		}
 		log.Printf("  Found %d PAs for %s (total: %d)", len(entries), country, len(allPAs))
 
 		// Rate limiting between countries
@@ -160,9 +249,10 @@ func main() {
 		log.Fatalf("Error marshaling: %v", err)
 	}
 
-	if err := os.WriteFile("data/wdpa_index.json", output, 0644); err != nil {
+	if err := os.WriteFile(outputPath, output, 0644); err != nil {
 		log.Fatalf("Error writing file: %v", err)
 	}
 
-	log.Println("Done! Written to data/wdpa_index.json")
+	os.Remove(checkpointPath)
+	log.Printf("Done! Written to %s", outputPath)
 }
@@ -6,6 +6,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"os"
@@ -86,6 +87,7 @@ func main() {
 
 	// Create Protected Planet client
 	client := protectedplanet.NewClient()
+	ctx := context.Background()
 
 	// Stats
 	var fetched, noWDPA, noGeom, errors int
@@ -118,7 +120,7 @@ func main() {
 		}
 
 		// Fetch from API
-		pa, err := client.GetByWDPAID(wdpaID)
+		pa, err := client.GetByWDPAID(ctx, wdpaID)
 		if err != nil {
 			log.Printf("  -> API error: %v", err)
 			errors++
@@ -1,148 +1,125 @@
-// Command fetchpas fetches protected area boundaries from the Protected Planet API.
-// It reads a list of keystone protected areas and fetches their WDPA data including geometry.
+// Command fetchpas resolves a list of keystone protected areas against
+// Protected Planet or a WFS endpoint (see srv/pa/wfs) and materializes
+// them into the srv/pa/store-backed protected_areas table. The old
+// data/keystones.json file is produced on request via -export, but the
+// store is now the source of truth; -refresh only re-fetches rows that
+// are stale or missing geometry instead of the whole list every time.
 //
-// Usage: go run ./cmd/fetchpas
+// Usage: go run ./cmd/fetchpas -source=planet
+//
+//	go run ./cmd/fetchpas -source=wfs -wfs-url=https://example.org/wfs -wfs-typename=wdpa:protected_areas
+//	go run ./cmd/fetchpas -refresh -max-age=720h
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"time"
 
+	"srv.exe.dev/db"
+	"srv.exe.dev/srv/pa/store"
+	"srv.exe.dev/srv/pa/wfs"
 	"srv.exe.dev/srv/protectedplanet"
 )
 
-const (
-	inputFile       = "/tmp/keystones_list.json"
-	outputFile      = "data/keystones.json"
-	requestDelaySec = 1
-)
-
-// KeystoneInput represents an entry in the input keystones list.
-type KeystoneInput struct {
-	Country string `json:"country"`
-	Name    string `json:"name"`
-}
-
-// KeystoneOutput represents a protected area with its geometry for output.
-type KeystoneOutput struct {
-	WDPAID       int             `json:"wdpa_id"`
-	Name         string          `json:"name"`
-	Country      string          `json:"country"`
-	AreaKm2      float64         `json:"area_km2"`
-	IUCNCategory string          `json:"iucn_category"`
-	Geometry     json.RawMessage `json:"geometry"`
-}
+const inputFile = "/tmp/keystones_list.json"
 
 func main() {
-	// Read input file
-	data, err := os.ReadFile(inputFile)
+	dbPath := flag.String("db", "db.sqlite3", "path to the sqlite database")
+	source := flag.String("source", "planet", "PA source: \"planet\" (Protected Planet API) or \"wfs\" (OGC Web Feature Service)")
+	wfsURL := flag.String("wfs-url", "", "WFS endpoint base URL (required for -source=wfs)")
+	wfsTypeName := flag.String("wfs-typename", "", "WFS feature type name (required for -source=wfs)")
+	wfsCRS := flag.String("wfs-crs", "", "WFS srsName to request (optional)")
+	wfsFilter := flag.String("wfs-filter", "", "WFS cql_filter to scope the feature type (optional)")
+	refresh := flag.Bool("refresh", false, "only re-fetch rows older than -max-age or missing geometry, instead of the full input list")
+	maxAge := flag.Duration("max-age", store.DefaultTTL, "with -refresh, how old (or missing) a row's geometry must be to re-fetch it")
+	export := flag.String("export", "", "if set, write the store's contents to this path as JSON (the old data/keystones.json shape)")
+	workers := flag.Int("workers", 4, "number of keystones to resolve concurrently (the fetcher's own rate limiter still bounds request pace)")
+	cacheDir := flag.String("cache-dir", "", "with -source=planet, directory to cache ETag-validated responses in (disabled if empty)")
+	flag.Parse()
+
+	fetcher, err := buildFetcher(*source, *wfsURL, *wfsTypeName, *wfsCRS, *wfsFilter, *cacheDir)
 	if err != nil {
-		log.Fatalf("Failed to read input file %s: %v", inputFile, err)
+		log.Fatal(err)
 	}
 
-	var keystones []KeystoneInput
-	if err := json.Unmarshal(data, &keystones); err != nil {
-		log.Fatalf("Failed to parse input JSON: %v", err)
+	wdb, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
 	}
-
-	fmt.Printf("Loaded %d protected areas from %s\n", len(keystones), inputFile)
-
-	// Create Protected Planet client
-	client := protectedplanet.NewClient()
-
-	// Process each keystone
-	var results []KeystoneOutput
-	var notFound []KeystoneInput
-
-	for i, ks := range keystones {
-		fmt.Printf("[%d/%d] Searching for %s in %s... ", i+1, len(keystones), ks.Name, ks.Country)
-
-		// Search by name within the country
-		matches, err := client.SearchByName(ks.Name, ks.Country)
-		if err != nil {
-			fmt.Printf("ERROR: %v\n", err)
-			notFound = append(notFound, ks)
-			time.Sleep(requestDelaySec * time.Second)
-			continue
-		}
-
-		if len(matches) == 0 {
-			fmt.Printf("NOT FOUND\n")
-			notFound = append(notFound, ks)
-			time.Sleep(requestDelaySec * time.Second)
-			continue
+	defer wdb.Close()
+	st := store.New(wdb)
+
+	ctx := context.Background()
+
+	var fetched int
+	var syncErr error
+	if *refresh {
+		fetched, syncErr = st.RefreshStale(ctx, fetcher, *source, *maxAge, *workers)
+	} else {
+		keystones, loadErr := loadKeystoneList()
+		if loadErr != nil {
+			log.Fatal(loadErr)
 		}
+		fmt.Printf("Resolving %d protected areas (source=%s, workers=%d)\n", len(keystones), *source, *workers)
+		fetched, syncErr = st.Sync(ctx, fetcher, *source, keystones, *maxAge, *workers)
+	}
+	fmt.Printf("Fetched %d rows (source calls avoided for fresh rows)\n", fetched)
+	if syncErr != nil {
+		fmt.Printf("Completed with errors: %v\n", syncErr)
+	}
 
-		// Use the first match
-		match := matches[0]
-		fmt.Printf("found WDPA ID %d, fetching geometry... ", match.WDPAID)
-
-		// Rate limit before fetching geometry
-		time.Sleep(requestDelaySec * time.Second)
-
-		// Fetch full details with geometry
-		pa, err := client.GetByWDPAID(match.WDPAID)
+	if *export != "" {
+		rows, err := st.Export(ctx)
 		if err != nil {
-			fmt.Printf("ERROR: %v\n", err)
-			notFound = append(notFound, ks)
-			continue
-		}
-
-		// Extract geometry
-		var geomJSON json.RawMessage
-		if pa.Geometry != nil && pa.Geometry.Geometry != nil {
-			// Serialize just the inner geometry (not the full feature)
-			geomJSON, err = json.Marshal(pa.Geometry.Geometry)
-			if err != nil {
-				fmt.Printf("ERROR marshaling geometry: %v\n", err)
-				notFound = append(notFound, ks)
-				continue
-			}
-		} else {
-			fmt.Printf("NO GEOMETRY\n")
-			notFound = append(notFound, ks)
-			continue
+			log.Fatalf("export: %v", err)
 		}
-
-		result := KeystoneOutput{
-			WDPAID:       pa.WDPAID,
-			Name:         pa.Name,
-			Country:      ks.Country, // Keep the ISO3 code from input
-			AreaKm2:      pa.AreaKm2,
-			IUCNCategory: pa.IUCNCategory,
-			Geometry:     geomJSON,
+		out, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			log.Fatalf("marshal export: %v", err)
 		}
-		results = append(results, result)
-		fmt.Printf("OK (%.0f km², %s)\n", pa.AreaKm2, pa.IUCNCategory)
-
-		// Rate limit between requests
-		if i < len(keystones)-1 {
-			time.Sleep(requestDelaySec * time.Second)
+		if err := os.WriteFile(*export, out, 0644); err != nil {
+			log.Fatalf("write export %s: %v", *export, err)
 		}
+		fmt.Printf("Exported %d rows to %s\n", len(rows), *export)
 	}
+}
 
-	// Write results
-	output, err := json.MarshalIndent(results, "", "  ")
+// loadKeystoneList reads the full input file of keystones to resolve.
+func loadKeystoneList() ([]store.KeystoneInput, error) {
+	data, err := os.ReadFile(inputFile)
 	if err != nil {
-		log.Fatalf("Failed to marshal results: %v", err)
+		return nil, fmt.Errorf("read input file %s: %w", inputFile, err)
 	}
-
-	if err := os.WriteFile(outputFile, output, 0644); err != nil {
-		log.Fatalf("Failed to write output file %s: %v", outputFile, err)
+	var keystones []store.KeystoneInput
+	if err := json.Unmarshal(data, &keystones); err != nil {
+		return nil, fmt.Errorf("parse input JSON: %w", err)
 	}
+	return keystones, nil
+}
 
-	fmt.Printf("\n=== Summary ===\n")
-	fmt.Printf("Successfully fetched: %d\n", len(results))
-	fmt.Printf("Not found: %d\n", len(notFound))
-	fmt.Printf("Results saved to: %s\n", outputFile)
-
-	if len(notFound) > 0 {
-		fmt.Printf("\nProtected areas not found:\n")
-		for _, nf := range notFound {
-			fmt.Printf("  - %s (%s)\n", nf.Name, nf.Country)
+// buildFetcher constructs the store.PAFetcher named by source, validating
+// the WFS-specific flags it requires.
+func buildFetcher(source, wfsURL, wfsTypeName, wfsCRS, wfsFilter, cacheDir string) (store.PAFetcher, error) {
+	switch source {
+	case "planet":
+		client := protectedplanet.NewClient()
+		client.CacheDir = cacheDir
+		return client, nil
+	case "wfs":
+		if wfsURL == "" || wfsTypeName == "" {
+			return nil, fmt.Errorf("-source=wfs requires -wfs-url and -wfs-typename")
 		}
+		return wfs.NewClient(wfs.Config{
+			BaseURL:     wfsURL,
+			FeatureType: wfsTypeName,
+			CRS:         wfsCRS,
+			Filter:      wfsFilter,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown -source %q (want \"planet\" or \"wfs\")", source)
 	}
 }
@@ -9,10 +9,23 @@ import (
 
 	"srv.exe.dev/srv"
 	"srv.exe.dev/srv/areas"
+	"srv.exe.dev/srv/auth/oidc"
+	"srv.exe.dev/srv/config"
+	"srv.exe.dev/srv/drivers"
+	"srv.exe.dev/srv/pa/wfs"
+	"srv.exe.dev/srv/protectedplanet"
+	"srv.exe.dev/srv/socio"
 )
 
-var flagListenAddr = flag.String("listen", ":8000", "address to listen on")
+var flagListenAddr = flag.String("listen", "", "address to listen on (overrides config file)")
 var flagDataDir = flag.String("data", "data", "path to data directory")
+var flagConfig = flag.String("config", "", "path to config file (.toml or .json); if unset, built-in defaults are used")
+var flagWFSURL = flag.String("wfs", "", "WFS endpoint base URL to load protected areas from instead of -data's keystones files (e.g. a live WDPA WFS)")
+var flagWFSTypeName = flag.String("wfs-typename", "", "WFS feature type name (required with -wfs)")
+var flagWFSCRS = flag.String("wfs-crs", "", "WFS srsName to request (optional)")
+var flagWFSFilter = flag.String("wfs-filter", "", "WFS cql_filter to scope the feature type (optional)")
+var flagWFSRefresh = flag.Duration("wfs-refresh", 0, "with -wfs, how often to rebuild the area store from the WFS endpoint (0 disables periodic refresh)")
+var flagConservationMetricsInterval = flag.Duration("conservation-metrics-interval", srv.DefaultConservationMetricsInterval, "how often to refresh the conservation_* Prometheus gauges exposed at /metrics")
 
 func main() {
 	if err := run(); err != nil {
@@ -22,24 +35,66 @@ func main() {
 
 func run() error {
 	flag.Parse()
-	hostname, err := os.Hostname()
+	cfg, err := config.Load(*flagConfig)
 	if err != nil {
-		hostname = "unknown"
+		return fmt.Errorf("load config: %w", err)
 	}
-	server, err := srv.New("db.sqlite3", hostname)
+	if *flagListenAddr != "" {
+		cfg.Addr = *flagListenAddr
+	}
+
+	server, err := srv.New(cfg)
 	if err != nil {
 		return fmt.Errorf("create server: %w", err)
 	}
 
-	// Load protected areas from keystones
+	for _, p := range cfg.OIDCProviders {
+		server.SSOProviders[p.Name] = &oidc.Provider{
+			Name:         p.Name,
+			Issuer:       p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}
+		slog.Info("configured SSO provider", "name", p.Name)
+	}
+
+	// Load protected areas, either from a live WFS endpoint (-wfs) or
+	// the keystones JSON files under -data.
 	dataDir := *flagDataDir
-	if store, err := areas.LoadKeystones(dataDir); err == nil {
+	ctx := context.Background()
+	var wfsCfg wfs.Config
+	if *flagWFSURL != "" {
+		wfsCfg = wfs.Config{
+			BaseURL:     *flagWFSURL,
+			FeatureType: *flagWFSTypeName,
+			CRS:         *flagWFSCRS,
+			Filter:      *flagWFSFilter,
+		}
+		if store, err := areas.LoadFromWFS(ctx, wfsCfg); err == nil {
+			server.AreaStore = store
+			slog.Info("loaded protected areas from wfs", "url", *flagWFSURL, "count", len(store.Areas))
+		} else {
+			slog.Warn("failed to load areas from wfs", "error", err)
+		}
+	} else if store, err := areas.LoadKeystones(dataDir); err == nil {
 		server.AreaStore = store
 		slog.Info("loaded protected areas", "count", len(store.Areas))
 	} else {
 		slog.Warn("failed to load areas", "error", err)
 	}
 
+	// Build the fuzzy place/park name matcher now that AreaStore has
+	// loaded (place_matcher.go); it backs /api/places/search and
+	// resolveParkID's misspelled/partial-name fallback.
+	if matcher, err := srv.NewPlaceMatcher(server.DB, server.AreaStore); err == nil {
+		server.PlaceMatcher = matcher
+		slog.Info("built place matcher", "candidates", matcher.Len())
+	} else {
+		slog.Warn("failed to build place matcher", "error", err)
+	}
+
 	// Load WDPA index for searching all African PAs
 	wdpaPath := dataDir + "/wdpa_index.json"
 	if wdpaIndex, err := areas.LoadWDPAIndex(wdpaPath); err == nil {
@@ -49,6 +104,41 @@ func run() error {
 		slog.Warn("failed to load WDPA index", "error", err)
 	}
 
+	// Load the deforestation-driver classifier (see srv/drivers): a
+	// CART tree fitted offline over cluster features, walked at request
+	// time by classifyCluster instead of the fixed aspect-ratio/count
+	// thresholds determinePatternType used to hard-code.
+	driverTreePath := dataDir + "/deforestation_driver_tree.json"
+	if driverModel, err := drivers.LoadModel(driverTreePath); err == nil {
+		server.DriverModel = driverModel
+		slog.Info("loaded deforestation driver classifier")
+	} else {
+		slog.Warn("failed to load deforestation driver classifier", "error", err)
+	}
+
+	// Load gridded socioeconomic indicators (see srv/socio), used by
+	// assessConflictRisk and analyzeFireHotspots. Optional: if the file
+	// isn't present, those callers degrade to settlement-density-only
+	// scoring.
+	socioPath := dataDir + "/socio_indicators.json"
+	if socioStore, err := socio.LoadStore(socioPath); err == nil {
+		server.SocioStore = socioStore
+		slog.Info("loaded socioeconomic indicators")
+	} else {
+		slog.Warn("failed to load socioeconomic indicators", "error", err)
+	}
+
+	// Load operator-supplied conflict-index factor weights, if
+	// configured, so the socio.Score model can be retuned without
+	// recompiling. Missing file keeps socio.DefaultWeights from New().
+	weightsPath := dataDir + "/conflict_weights.json"
+	if weights, err := socio.LoadWeights(weightsPath); err == nil {
+		server.SocioWeights = weights
+		slog.Info("loaded conflict index weights", "weights", weights)
+	} else {
+		slog.Warn("using default conflict index weights", "error", err)
+	}
+
 	// Load legal frameworks
 	legalPath := dataDir + "/legal_frameworks.json"
 	if legalStore, err := srv.LoadLegalFrameworks(legalPath); err == nil {
@@ -58,9 +148,63 @@ func run() error {
 		slog.Warn("failed to load legal frameworks", "error", err)
 	}
 
+	// Load the GADM country/region index; kept reloadable via
+	// server.GADMPath so the scheduler's gadm_rebuild job can pick up an
+	// updated export without a restart.
+	gadmPath := dataDir + "/gadm_index.json"
+	if gadmStore, err := srv.LoadGADMStore(gadmPath); err == nil {
+		server.GADMStore = gadmStore
+		server.GADMPath = gadmPath
+		slog.Info("loaded GADM index", "countries", len(gadmStore.Countries), "regions", len(gadmStore.Regions))
+	} else {
+		slog.Warn("failed to load GADM index", "error", err)
+	}
+
+	if _, err := server.FireCache.Reload(srv.FireDataPaths); err != nil {
+		slog.Warn("failed to warm fire data cache", "error", err)
+	}
+
 	// Start research publication worker in background
-	ctx := context.Background()
 	go server.StartResearchWorker(ctx)
 
-	return server.Serve(*flagListenAddr)
+	// Keep the area store in sync with the WFS endpoint, if configured.
+	if *flagWFSURL != "" && *flagWFSRefresh > 0 {
+		go server.StartAreaWFSRefresher(ctx, wfsCfg, *flagWFSRefresh)
+	}
+
+	// Keep the conservation_* Prometheus gauges warm so /metrics scrapes
+	// never hit SQLite directly.
+	go server.StartConservationMetricsRefresher(ctx, *flagConservationMetricsInterval)
+
+	// Start the periodic maintenance jobs (PA store refresh, fire data
+	// reload, GADM rebuild); see srv/scheduler_jobs.go.
+	if err := server.RegisterDefaultJobs(ctx, protectedplanet.NewClient(), "protectedplanet", cfg.GeoIPAccountID, cfg.GeoIPLicenseKey, cfg.GeoIPDBPath); err != nil {
+		slog.Warn("failed to start scheduler jobs", "error", err)
+	}
+
+	// Reconcile the persisted background-job queue: anything still
+	// pending or running when the process last exited had its
+	// subprocess die with it, so mark those jobs failed instead of
+	// leaving them stuck.
+	if err := server.JobRunner.ResumeOrFail(ctx); err != nil {
+		slog.Warn("failed to reconcile background job queue", "error", err)
+	}
+
+	// Start nightly user KPI reconciler to correct any drift in the
+	// incremental rollups maintained by persistUpload.
+	go server.StartUserKPIReconciler(ctx)
+
+	// Set up the async upload job queue and resume any jobs left pending
+	// from a previous run.
+	uploadQueue, err := srv.NewUploadQueue(server, dataDir+"/upload_spool")
+	if err != nil {
+		slog.Warn("failed to set up upload queue", "error", err)
+	} else {
+		server.UploadQueue = uploadQueue
+		if err := uploadQueue.RehydratePending(ctx); err != nil {
+			slog.Warn("failed to rehydrate pending upload jobs", "error", err)
+		}
+	}
+
+	return server.Serve()
 }
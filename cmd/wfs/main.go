@@ -0,0 +1,99 @@
+// Command wfs smoke-tests a running server's /wfs endpoint: it issues a
+// GetCapabilities request, picks the first advertised feature type, and
+// follows up with a paged GetFeature request against it, printing what
+// it got so a deploy can be sanity-checked without opening QGIS.
+//
+// Usage: go run ./cmd/wfs -base=http://localhost:8080 -type=protected_areas -bbox=29,-2,31,0
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+type capabilities struct {
+	FeatureTypes []struct {
+		Name string `xml:"Name"`
+	} `xml:"FeatureTypeList>FeatureType"`
+}
+
+func main() {
+	log.SetFlags(0)
+
+	base := flag.String("base", "http://localhost:8080", "base URL of a running 5mp server")
+	typeName := flag.String("type", "", "typeNames to request GetFeature for (defaults to the first type GetCapabilities advertises)")
+	bbox := flag.String("bbox", "", "optional bbox filter: minLon,minLat,maxLon,maxLat")
+	count := flag.Int("count", 5, "count parameter for the paged GetFeature request")
+	flag.Parse()
+
+	caps, err := getCapabilities(*base)
+	if err != nil {
+		log.Fatalf("GetCapabilities: %v", err)
+	}
+	if len(caps.FeatureTypes) == 0 {
+		log.Fatal("GetCapabilities returned no feature types")
+	}
+	fmt.Printf("GetCapabilities: %d feature type(s)\n", len(caps.FeatureTypes))
+	for _, ft := range caps.FeatureTypes {
+		fmt.Printf("  - %s\n", ft.Name)
+	}
+
+	want := *typeName
+	if want == "" {
+		want = caps.FeatureTypes[0].Name
+	}
+
+	body, status, err := getFeature(*base, want, *bbox, *count)
+	if err != nil {
+		log.Fatalf("GetFeature: %v", err)
+	}
+	fmt.Printf("\nGetFeature %s: HTTP %d, %d bytes\n", want, status, len(body))
+}
+
+func getCapabilities(base string) (*capabilities, error) {
+	resp, err := http.Get(base + "/wfs?SERVICE=WFS&REQUEST=GetCapabilities")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var caps capabilities
+	if err := xml.Unmarshal(body, &caps); err != nil {
+		return nil, fmt.Errorf("parse capabilities: %w", err)
+	}
+	return &caps, nil
+}
+
+func getFeature(base, typeName, bbox string, count int) ([]byte, int, error) {
+	q := url.Values{}
+	q.Set("SERVICE", "WFS")
+	q.Set("REQUEST", "GetFeature")
+	q.Set("typeNames", typeName)
+	q.Set("count", fmt.Sprint(count))
+	q.Set("startIndex", "0")
+	if bbox != "" {
+		q.Set("bbox", bbox)
+	}
+
+	resp, err := http.Get(base + "/wfs?" + q.Encode())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,137 @@
+// Package activitypub implements just enough of ActivityPub/ActivityStreams
+// 2.0 to let a protected area federate its document library: an actor
+// document, an outbox of Create activities, HTTP Signatures for outbound
+// delivery, and a minimal inbox that accepts Follow. It's not a general
+// federation server - there's no shared inbox, no Like/Announce/Undo
+// handling, and no activity persistence beyond the in-memory follower list
+// in followers.go.
+package activitypub
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ContentType is the media type AP requests the AS2 JSON-LD context with.
+// Mastodon and most other implementations also accept plain
+// "application/activity+json", which ContentTypeAlt covers.
+const (
+	ContentType    = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+	ContentTypeAlt = "application/activity+json"
+)
+
+// context is the single AS2 JSON-LD context every document in this package
+// declares; nothing here uses a custom vocabulary extension.
+var context = []string{"https://www.w3.org/ns/activitystreams"}
+
+// Public is the magic "everyone" addressee AS2 uses in place of a real
+// actor URI, following Mastodon's convention for a publicly-visible post.
+const Public = "https://www.w3.org/ns/activitystreams#Public"
+
+// PublicKey carries an actor's signing key in the shape Mastodon and other
+// implementations expect to find it embedded in the actor document, for
+// verifying this actor's outbound HTTP Signatures.
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPEM string `json:"publicKeyPem"`
+}
+
+// Actor is an AS2 actor document. This package always emits Type "Service",
+// since a protected area isn't a person, and has no followers/following
+// collections of its own in the document - only an inbox and outbox.
+type Actor struct {
+	Context           []string  `json:"@context"`
+	ID                string    `json:"id"`
+	Type              string    `json:"type"`
+	Name              string    `json:"name"`
+	Summary           string    `json:"summary,omitempty"`
+	PreferredUsername string    `json:"preferredUsername"`
+	Inbox             string    `json:"inbox"`
+	Outbox            string    `json:"outbox"`
+	PublicKey         PublicKey `json:"publicKey"`
+}
+
+// Object is an AS2 object. This package only ever wraps two object types in
+// it - ParkDocument ("Document") and patrol Segment ("Place", the closest
+// AS2 vocabulary term for a geographic track summary) - selected by Type.
+type Object struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Content   string `json:"content,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Published string `json:"published,omitempty"`
+}
+
+// Activity is an AS2 activity. This package only ever emits Create (in the
+// outbox) and consumes Follow (on the inbox).
+type Activity struct {
+	Context   []string `json:"@context,omitempty"`
+	ID        string   `json:"id,omitempty"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Object    any      `json:"object"`
+	To        []string `json:"to,omitempty"`
+	Published string   `json:"published,omitempty"`
+}
+
+// OrderedCollectionPage is the outbox's single page of Create activities.
+// Parks publish few enough documents that pagination isn't worth the
+// complexity; everything is returned in one page.
+type OrderedCollectionPage struct {
+	Context      []string   `json:"@context"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	TotalItems   int        `json:"totalItems"`
+	OrderedItems []Activity `json:"orderedItems"`
+}
+
+// NewActor builds the actor document for a protected area, identified by
+// actorID (its canonical "id" URL, e.g. ".../ap/parks/{id}").
+func NewActor(actorID, name, summary string, pub PublicKey) Actor {
+	return Actor{
+		Context:           context,
+		ID:                actorID,
+		Type:              "Service",
+		Name:              name,
+		Summary:           summary,
+		PreferredUsername: name,
+		Inbox:             actorID + "/inbox",
+		Outbox:            actorID + "/outbox",
+		PublicKey:         pub,
+	}
+}
+
+// NewOutbox wraps items as Create activities addressed to Public (since a
+// park's document library is open federation content, not DMs) and returns
+// the single-page OrderedCollection.
+func NewOutbox(outboxID, actorID string, items []Object, published []string) OrderedCollectionPage {
+	activities := make([]Activity, 0, len(items))
+	for i, obj := range items {
+		activities = append(activities, Activity{
+			ID:        obj.ID + "/activity",
+			Type:      "Create",
+			Actor:     actorID,
+			Object:    obj,
+			To:        []string{Public},
+			Published: published[i],
+		})
+	}
+	return OrderedCollectionPage{
+		Context:      context,
+		ID:           outboxID,
+		Type:         "OrderedCollection",
+		TotalItems:   len(activities),
+		OrderedItems: activities,
+	}
+}
+
+// Negotiate reports whether r's Accept header asks for an ActivityStreams
+// response (either the full JSON-LD profile or the activity+json shorthand)
+// rather than plain HTML/JSON, so a handler can serve the same path to
+// browsers and federation software alike.
+func Negotiate(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, ContentTypeAlt) || strings.Contains(accept, "ld+json")
+}
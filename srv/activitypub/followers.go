@@ -0,0 +1,45 @@
+package activitypub
+
+import "sync"
+
+// FollowerStore tracks, per actor, the inbox URLs that have sent a Follow
+// activity. Like KeyStore it's in-memory rather than DB-backed - there's
+// no followers table yet - so a restart forgets every follower, and
+// Mastodon-style servers re-deliver Follow after a failed delivery anyway.
+type FollowerStore struct {
+	mu        sync.Mutex
+	followers map[string]map[string]bool
+}
+
+// NewFollowerStore creates an empty FollowerStore.
+func NewFollowerStore() *FollowerStore {
+	return &FollowerStore{followers: make(map[string]map[string]bool)}
+}
+
+// Add records inboxURL as a follower of actorID.
+func (fs *FollowerStore) Add(actorID, inboxURL string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.followers[actorID] == nil {
+		fs.followers[actorID] = make(map[string]bool)
+	}
+	fs.followers[actorID][inboxURL] = true
+}
+
+// Remove drops inboxURL from actorID's followers, for handling Undo(Follow).
+func (fs *FollowerStore) Remove(actorID, inboxURL string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	delete(fs.followers[actorID], inboxURL)
+}
+
+// List returns actorID's current follower inbox URLs.
+func (fs *FollowerStore) List(actorID string) []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	out := make([]string, 0, len(fs.followers[actorID]))
+	for inbox := range fs.followers[actorID] {
+		out = append(out, inbox)
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package activitypub
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+)
+
+// KeyStore hands out a stable ed25519 keypair per actor ID, generating one
+// on first request. It's in-memory rather than DB-backed - like
+// webauthn.ChallengeStore, this package has no table of its own yet - which
+// means a restart rotates every actor's key and federated servers will see
+// a fresh keyId on their next delivery. That's an acceptable bootstrap
+// tradeoff for a Service actor (nobody re-verifies historical signatures),
+// but a real deployment would want these persisted.
+type KeyStore struct {
+	mu   sync.Mutex
+	keys map[string]ed25519.PrivateKey
+}
+
+// NewKeyStore creates an empty KeyStore.
+func NewKeyStore() *KeyStore {
+	return &KeyStore{keys: make(map[string]ed25519.PrivateKey)}
+}
+
+// KeyFor returns the keypair for actorID, generating and caching one if
+// this is the first request for it.
+func (ks *KeyStore) KeyFor(actorID string) (ed25519.PrivateKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if priv, ok := ks.keys[actorID]; ok {
+		return priv, nil
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate actor key: %w", err)
+	}
+	ks.keys[actorID] = priv
+	return priv, nil
+}
+
+// PublicKeyPEM PEM-encodes an ed25519 public key in SubjectPublicKeyInfo
+// form, the shape expected in an actor document's publicKeyPem field.
+func PublicKeyPEM(pub ed25519.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshal public key: %w", err)
+	}
+	block := &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	return string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,185 @@
+package srv
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/activitypub"
+)
+
+// parkActorID returns the canonical AS2 actor ID for a protected area.
+// Federated servers treat this URL as the actor's stable identity, so in a
+// real deployment it must never change once published.
+func (s *Server) parkActorID(r *http.Request, paID string) string {
+	scheme := "https"
+	if !s.IsSecureRequest(r) {
+		scheme = "http"
+	}
+	return scheme + "://" + r.Host + "/ap/parks/" + paID
+}
+
+// parkName looks up a protected area's display name for the actor
+// document, falling back to the bare ID if AreaStore doesn't know it (or
+// isn't loaded), the same fallback HandleParkAnalysis uses.
+func (s *Server) parkName(paID string) string {
+	if s.AreaStore != nil {
+		for _, area := range s.AreaStore.Areas {
+			if area.ID == paID || area.WDPAID == paID {
+				return area.Name
+			}
+		}
+	}
+	return paID
+}
+
+// actorDoc builds the actor document for actorID, embedding the PEM public
+// key derived from priv so followers can verify this actor's signatures.
+func actorDoc(actorID, name string, priv ed25519.PrivateKey) (activitypub.Actor, error) {
+	pub, _ := priv.Public().(ed25519.PublicKey)
+	pem, err := activitypub.PublicKeyPEM(pub)
+	if err != nil {
+		return activitypub.Actor{}, err
+	}
+	return activitypub.NewActor(actorID, name, "Protected area document feed", activitypub.PublicKey{
+		ID:           actorID + "#main-key",
+		Owner:        actorID,
+		PublicKeyPEM: pem,
+	}), nil
+}
+
+// HandleActivityPubActor serves a protected area's ActivityPub actor
+// document. GET /ap/parks/{id}
+func (s *Server) HandleActivityPubActor(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	if paID == "" {
+		http.Error(w, "missing park ID", http.StatusBadRequest)
+		return
+	}
+
+	actorID := s.parkActorID(r, paID)
+	priv, err := s.APKeys.KeyFor(actorID)
+	if err != nil {
+		slog.Error("generate activitypub actor key", "pa_id", paID, "error", err)
+		http.Error(w, "key generation failed", http.StatusInternalServerError)
+		return
+	}
+	actor, err := actorDoc(actorID, s.parkName(paID), priv)
+	if err != nil {
+		slog.Error("build activitypub actor document", "pa_id", paID, "error", err)
+		http.Error(w, "key encoding failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", activitypub.ContentType)
+	json.NewEncoder(w).Encode(actor)
+}
+
+// HandleActivityPubOutbox serves a protected area's outbox: a Create
+// activity for every ParkDocument on file, wrapped as an AS2 "Document"
+// object and addressed to Public.
+//
+// Patrol segments aren't included here yet - unlike documents, they're
+// never persisted as individually addressable rows; SplitIntoSegments'
+// output is folded straight into grid-cell effort totals during upload
+// (see updateEffortData), so there's no per-segment ID or timestamp left
+// to build a stable AS2 object ID from. Federating those needs a segments
+// table before it can follow the same pattern as documents below.
+func (s *Server) HandleActivityPubOutbox(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	if paID == "" {
+		http.Error(w, "missing park ID", http.StatusBadRequest)
+		return
+	}
+
+	actorID := s.parkActorID(r, paID)
+	docs, err := dbgen.New(s.DB).GetAllParkDocuments(r.Context(), paID)
+	if err != nil {
+		slog.Error("load park documents for outbox", "pa_id", paID, "error", err)
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	// ParkDocument has no created_at column to publish a real "published"
+	// timestamp from, so every item in a page is stamped with the time
+	// the outbox was rendered. That's enough for a federated server to
+	// accept the activity; it's not a record of when the document was
+	// actually added.
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	items := make([]activitypub.Object, 0, len(docs))
+	published := make([]string, 0, len(docs))
+	for _, d := range docs {
+		obj := activitypub.Object{
+			ID:        actorID + "/documents/" + strconv.FormatInt(d.ID, 10),
+			Type:      "Document",
+			Name:      d.Title,
+			Published: now,
+		}
+		if d.Summary != nil {
+			obj.Content = *d.Summary
+		}
+		if d.FileUrl != nil {
+			obj.URL = *d.FileUrl
+		}
+		items = append(items, obj)
+		published = append(published, now)
+	}
+
+	outbox := activitypub.NewOutbox(actorID+"/outbox", actorID, items, published)
+	w.Header().Set("Content-Type", activitypub.ContentType)
+	json.NewEncoder(w).Encode(outbox)
+}
+
+// HandleActivityPubInbox accepts Follow activities addressed to a
+// protected area's actor, recording the sender's inbox in APFollowers and
+// replying 202 Accepted, matching Mastodon's own inbox behavior of
+// accepting a Follow without synchronously sending back the matching
+// Accept activity. Anything other than Follow/Undo is acknowledged and
+// otherwise ignored - this package doesn't model Like/Announce/Create
+// delivery to this actor.
+func (s *Server) HandleActivityPubInbox(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	if paID == "" {
+		http.Error(w, "missing park ID", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var activity activitypub.Activity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		http.Error(w, "invalid activity", http.StatusBadRequest)
+		return
+	}
+
+	actorID := s.parkActorID(r, paID)
+	switch activity.Type {
+	case "Follow":
+		s.APFollowers.Add(actorID, followerInbox(activity.Actor))
+		slog.Info("activitypub follow accepted", "pa_id", paID, "follower", activity.Actor)
+	case "Undo":
+		s.APFollowers.Remove(actorID, followerInbox(activity.Actor))
+	default:
+		slog.Debug("activitypub inbox: unhandled activity type", "pa_id", paID, "type", activity.Type)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// followerInbox derives a remote actor's inbox URL from their actor URI,
+// following the widely-used "{actor}/inbox" convention most ActivityPub
+// software (Mastodon included) uses when it doesn't resolve the actor
+// document to read its real inbox field.
+func followerInbox(actorURI string) string {
+	return actorURI + "/inbox"
+}
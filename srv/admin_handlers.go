@@ -2,19 +2,24 @@ package srv
 
 import (
 	"archive/zip"
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/csrf"
+	"srv.exe.dev/srv/ghsl"
+	"srv.exe.dev/srv/ingest/fire"
+	ghslingest "srv.exe.dev/srv/ingest/ghsl"
+	"srv.exe.dev/srv/jobs"
+	"srv.exe.dev/srv/vault"
 )
 
 type adminPageData struct {
@@ -27,7 +32,8 @@ type adminPageData struct {
 	DiskAvailable   string
 	NeededGHSLTiles []GHSLTileInfo
 	HaveGHSLTiles   []string
-	ProcessingStatus string
+	Jobs            []jobs.Job
+	CSRFToken       string
 }
 
 // GHSLTileInfo contains info about a GHSL tile and its download URL.
@@ -47,24 +53,6 @@ type adminStats struct {
 	TotalPoints     int64
 }
 
-// Processing status tracking
-var (
-	processingMu     sync.RWMutex
-	processingStatus string
-)
-
-func setProcessingStatus(status string) {
-	processingMu.Lock()
-	defer processingMu.Unlock()
-	processingStatus = status
-}
-
-func getProcessingStatus() string {
-	processingMu.RLock()
-	defer processingMu.RUnlock()
-	return processingStatus
-}
-
 // All needed GHSL tiles: (row, col) pairs
 var neededGHSLTiles = [][2]int{
 	{5, 18}, {5, 19}, {6, 18}, {6, 19}, {6, 20},
@@ -76,13 +64,13 @@ var neededGHSLTiles = [][2]int{
 	{12, 19}, {12, 20},
 }
 
-// Already have these tiles
-var haveGHSLTiles = map[string]bool{
-	"R8_C18":  true,
-	"R8_C19":  true,
-	"R8_C20":  true,
-	"R9_C19":  true,
-	"R12_C21": true,
+// ghslDataDir is where extracted/fetched GHSL tiles live on disk; it's
+// also the source of truth for which tiles we already have.
+const ghslDataDir = "data/ghsl"
+
+// ghslTileURL builds a tile's JRC download URL from its row/column.
+func ghslTileURL(row, col int) string {
+	return fmt.Sprintf("https://jeodpp.jrc.ec.europa.eu/ftp/jrc-opendata/GHSL/GHS_BUILT_S_GLOBE_R2023A/GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10/V1-0/tiles/GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10_V1_0_R%d_C%d.zip", row, col)
 }
 
 // HandleAdminPage renders the admin dashboard with pending and approved users.
@@ -143,34 +131,47 @@ func (s *Server) HandleAdminPage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Build list of needed GHSL tiles with download URLs
+	// Build list of needed GHSL tiles with download URLs, checking
+	// data/ghsl/ itself (rather than a hardcoded list) for which ones
+	// we already have.
+	haveTileIDs, err := ghsl.ScanHaveTiles(ghslDataDir)
+	if err != nil {
+		slog.Warn("failed to scan ghsl data dir", "error", err)
+		haveTileIDs = map[string]bool{}
+	}
 	var neededTiles []GHSLTileInfo
 	var haveTiles []string
 	for _, tile := range neededGHSLTiles {
 		tileID := fmt.Sprintf("R%d_C%d", tile[0], tile[1])
-		if haveGHSLTiles[tileID] {
+		if haveTileIDs[tileID] {
 			haveTiles = append(haveTiles, tileID)
 		} else {
 			neededTiles = append(neededTiles, GHSLTileInfo{
 				Row: tile[0],
 				Col: tile[1],
 				ID:  tileID,
-				URL: fmt.Sprintf("https://jeodpp.jrc.ec.europa.eu/ftp/jrc-opendata/GHSL/GHS_BUILT_S_GLOBE_R2023A/GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10/V1-0/tiles/GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10_V1_0_R%d_C%d.zip", tile[0], tile[1]),
+				URL: ghslTileURL(tile[0], tile[1]),
 			})
 		}
 	}
 
+	csrfToken, err := csrf.EnsureCookie(w, r, s.IsSecureRequest(r))
+	if err != nil {
+		slog.Warn("failed to set CSRF cookie", "error", err)
+	}
+
 	data := adminPageData{
-		Hostname:         s.Hostname,
-		PendingUsers:     pendingUsers,
-		ApprovedUsers:    approvedUsers,
-		Stats:            stats,
-		Success:          r.URL.Query().Get("success"),
-		Error:            r.URL.Query().Get("error"),
-		DiskAvailable:    "3.4GB",
-		NeededGHSLTiles:  neededTiles,
-		HaveGHSLTiles:    haveTiles,
-		ProcessingStatus: getProcessingStatus(),
+		Hostname:        s.Hostname,
+		PendingUsers:    pendingUsers,
+		ApprovedUsers:   approvedUsers,
+		Stats:           stats,
+		Success:         r.URL.Query().Get("success"),
+		Error:           r.URL.Query().Get("error"),
+		DiskAvailable:   "3.4GB",
+		NeededGHSLTiles: neededTiles,
+		HaveGHSLTiles:   haveTiles,
+		Jobs:            s.Jobs.List(),
+		CSRFToken:       csrfToken,
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -190,7 +191,7 @@ func (s *Server) HandleApproveUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the admin user who is approving
-	adminUser := s.Auth.GetUserFromRequest(r)
+	adminUser := s.GetUserFromRequest(r)
 	if adminUser == nil {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
@@ -212,6 +213,7 @@ func (s *Server) HandleApproveUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	slog.Info("user approved", "user_id", userID, "approved_by", adminUser.Email)
+	s.Auth.LogEvent(ctx, adminUser.ID, userID, "approve_user", nil)
 	http.Redirect(w, r, "/admin?success=User+approved+successfully", http.StatusSeeOther)
 }
 
@@ -226,7 +228,7 @@ func (s *Server) HandleRejectUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the admin user for logging
-	adminUser := s.Auth.GetUserFromRequest(r)
+	adminUser := s.GetUserFromRequest(r)
 	if adminUser == nil {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
@@ -240,8 +242,23 @@ func (s *Server) HandleRejectUser(w http.ResponseWriter, r *http.Request) {
 		// Continue anyway
 	}
 
+	// Wipe any spool directories left behind by this user's uploads.
+	// UploadQueue.process removes a job's spool dir once it finishes,
+	// but a job still pending/processing (or one whose removal
+	// otherwise failed) would leave raw track data sitting on disk
+	// after the account it belongs to is gone.
+	spoolDirs, err := q.ListUploadJobSpoolDirsByUser(ctx, userID)
+	if err != nil {
+		slog.Warn("failed to list upload job spool dirs for rejected user", "user_id", userID, "error", err)
+	}
+	for _, dir := range spoolDirs {
+		if err := vault.WipeDir(dir); err != nil {
+			slog.Warn("failed to wipe rejected user's spool dir", "user_id", userID, "dir", dir, "error", err)
+		}
+	}
+
 	// Delete the user (using raw SQL since no generated query exists)
-	_, err := s.DB.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userID)
+	_, err = s.DB.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userID)
 	if err != nil {
 		slog.Error("failed to delete user", "user_id", userID, "error", err)
 		http.Redirect(w, r, "/admin?error=Failed+to+reject+user", http.StatusSeeOther)
@@ -249,6 +266,7 @@ func (s *Server) HandleRejectUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	slog.Info("user rejected", "user_id", userID, "rejected_by", adminUser.Email)
+	s.Auth.LogEvent(ctx, adminUser.ID, userID, "reject_user", nil)
 	http.Redirect(w, r, "/admin?success=User+rejected+and+removed", http.StatusSeeOther)
 }
 
@@ -289,8 +307,25 @@ func (s *Server) HandleUploadFire(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Copy uploaded file to temp
-	written, err := io.Copy(tmpFile, file)
+	// Copy uploaded file to temp, encrypting as we go if a vault
+	// passphrase is configured.
+	var dst io.Writer = tmpFile
+	var encWriter io.WriteCloser
+	if s.Vault != nil {
+		encWriter, err = s.Vault.EncryptWriter(tmpFile)
+		if err != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+			slog.Error("failed to init vault writer for fire upload", "error", err)
+			http.Redirect(w, r, "/admin?error=Failed+to+save+file", http.StatusSeeOther)
+			return
+		}
+		dst = encWriter
+	}
+	written, err := io.Copy(dst, file)
+	if encWriter != nil {
+		err = errors.Join(err, encWriter.Close())
+	}
 	tmpFile.Close()
 	if err != nil {
 		os.Remove(tmpFile.Name())
@@ -300,28 +335,55 @@ func (s *Server) HandleUploadFire(w http.ResponseWriter, r *http.Request) {
 
 	slog.Info("fire data uploaded", "filename", header.Filename, "size", written, "temp", tmpFile.Name())
 
-	// Start background processing with Python script
-	go func() {
-		setProcessingStatus(fmt.Sprintf("Processing fire data: %s (%d bytes)", header.Filename, written))
-		defer func() {
-			os.Remove(tmpFile.Name())
-			setProcessingStatus("")
-		}()
+	// Ingest in the background, bounded and cancellable through JobRunner.
+	s.JobRunner.Submit(jobs.KindFireCSV, header.Filename, written, func(ctx context.Context, jobID string) error {
+		return s.runFireProcessor(ctx, jobID, tmpFile.Name(), header.Filename, s.Vault != nil)
+	})
+
+	http.Redirect(w, r, "/admin?success=Fire+data+uploaded.+Processing+in+background.", http.StatusSeeOther)
+}
 
-		// Run the streaming fire processor
-		cmd := exec.Command(".venv/bin/python", "scripts/fire_processor_streaming.py", "--zip", tmpFile.Name())
-		output, err := cmd.CombinedOutput()
+// runFireProcessor streams the fire CSV at path into fire_detections
+// via the in-process fire ingest package, reporting real byte progress
+// to jobID and wiping path once finished. It's shared by the direct
+// multipart upload handler and the tus upload completion callback.
+// Cancelling ctx (via JobRunner.Cancel) stops the ingest at its next
+// row-read check.
+//
+// encrypted reports whether path holds vault ciphertext rather than a
+// plain CSV. It's only true for the direct multipart upload path:
+// tus.Store writes chunks at arbitrary byte offsets to resume a dropped
+// upload, which is incompatible with this package's chunked AEAD
+// framing, so tus-uploaded fire CSVs are deliberately left unencrypted
+// at rest for now (a known gap, not an oversight).
+func (s *Server) runFireProcessor(ctx context.Context, jobID, path, filename string, encrypted bool) error {
+	s.Jobs.SetPhase(jobID, "processing")
+	defer vault.Wipe(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open fire csv: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if encrypted {
+		r, err = s.Vault.DecryptReader(f)
 		if err != nil {
-			slog.Error("fire processing failed", "error", err, "output", string(output))
-			setProcessingStatus("Fire processing failed: " + err.Error())
-		} else {
-			slog.Info("fire data processing complete", "filename", header.Filename, "output", string(output))
-			setProcessingStatus("Fire data processed: " + header.Filename)
+			return fmt.Errorf("open vault reader for fire csv: %w", err)
 		}
-		time.Sleep(5 * time.Second)
-	}()
+	}
 
-	http.Redirect(w, r, "/admin?success=Fire+data+uploaded.+Processing+in+background.", http.StatusSeeOther)
+	var lastReported int64
+	result, err := fire.Ingest(ctx, s.DB, s.AreaStore, r, func(bytesRead int64) {
+		s.Jobs.AddBytes(jobID, bytesRead-lastReported)
+		lastReported = bytesRead
+	})
+	if err != nil {
+		return fmt.Errorf("fire processor: %w", err)
+	}
+	slog.Info("fire data processing complete", "filename", filename, "inserted", result.Inserted, "skipped", result.Skipped)
+	return nil
 }
 
 // HandleUploadGHSL handles GHSL tile ZIP uploads.
@@ -351,95 +413,161 @@ func (s *Server) HandleUploadGHSL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Read file into memory for zip extraction
-	data, err := io.ReadAll(file)
+	// Spool to a temp file so extraction can stream from disk instead
+	// of holding the whole (potentially multi-GB) ZIP in memory.
+	tmpDir := filepath.Join(os.TempDir(), "5mpglobe-uploads")
+	os.MkdirAll(tmpDir, 0755)
+	tmpZip, err := os.CreateTemp(tmpDir, "ghsl-*.zip")
 	if err != nil {
-		http.Redirect(w, r, "/admin?error=Failed+to+read+file", http.StatusSeeOther)
+		slog.Error("failed to create temp file", "error", err)
+		http.Redirect(w, r, "/admin?error=Failed+to+create+temp+file", http.StatusSeeOther)
+		return
+	}
+	if _, err := io.Copy(tmpZip, file); err != nil {
+		tmpZip.Close()
+		os.Remove(tmpZip.Name())
+		http.Redirect(w, r, "/admin?error=Failed+to+save+file", http.StatusSeeOther)
 		return
 	}
+	tmpZip.Close()
+	defer os.Remove(tmpZip.Name())
 
-	// Open as zip
-	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	tileID, destPath, written, err := s.extractGHSLTile(tmpZip.Name())
 	if err != nil {
-		http.Redirect(w, r, "/admin?error=Failed+to+open+ZIP:+"+err.Error(), http.StatusSeeOther)
+		http.Redirect(w, r, "/admin?error=Failed+to+extract+GHSL+tile:+"+err.Error(), http.StatusSeeOther)
 		return
 	}
 
+	slog.Info("GHSL tile extracted", "tile", tileID, "path", destPath, "size", written)
+
+	// Ingest in the background, bounded and cancellable through JobRunner.
+	s.JobRunner.Submit(jobs.KindGHSLTile, tileID, written, func(ctx context.Context, jobID string) error {
+		return s.runGHSLProcessor(ctx, jobID, tileID, destPath)
+	})
+
+	http.Redirect(w, r, fmt.Sprintf("/admin?success=GHSL+tile+%s+uploaded.+Processing+in+background.", tileID), http.StatusSeeOther)
+}
+
+// extractGHSLTile opens zipPath (streaming from disk rather than
+// holding the archive in memory) and extracts its TIF member under
+// data/ghsl, returning the tile ID, the extracted file's path, and its
+// size.
+func (s *Server) extractGHSLTile(zipPath string) (tileID, destPath string, written int64, err error) {
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("open zip: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", "", 0, fmt.Errorf("stat zip: %w", err)
+	}
+
+	zipReader, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return "", "", 0, fmt.Errorf("open zip: %w", err)
+	}
+
 	// Find TIF file in zip
 	var tifFile *zip.File
-	for _, f := range zipReader.File {
-		if strings.HasSuffix(strings.ToLower(f.Name), ".tif") {
-			tifFile = f
+	for _, zf := range zipReader.File {
+		if strings.HasSuffix(strings.ToLower(zf.Name), ".tif") {
+			tifFile = zf
 			break
 		}
 	}
-
 	if tifFile == nil {
-		http.Redirect(w, r, "/admin?error=No+TIF+file+found+in+ZIP", http.StatusSeeOther)
-		return
+		return "", "", 0, errors.New("no TIF file found in ZIP")
 	}
 
 	// Extract tile ID from filename (e.g., GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10_V1_0_R5_C18.tif)
-	tileID := extractGHSLTileID(tifFile.Name)
+	tileID = extractGHSLTileID(tifFile.Name)
 	if tileID == "" {
-		http.Redirect(w, r, "/admin?error=Could+not+determine+tile+ID+from+filename", http.StatusSeeOther)
-		return
+		return "", "", 0, errors.New("could not determine tile ID from filename")
 	}
 
-	// Create destination directory
-	ghslDir := filepath.Join("data", "ghsl", "GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10_V1_0_"+tileID)
+	ghslDir := filepath.Join(ghslDataDir, "GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10_V1_0_"+tileID)
 	if err := os.MkdirAll(ghslDir, 0700); err != nil {
-		http.Redirect(w, r, "/admin?error=Failed+to+create+directory", http.StatusSeeOther)
-		return
+		return "", "", 0, fmt.Errorf("create destination directory: %w", err)
 	}
 
-	// Extract TIF file
 	tifReader, err := tifFile.Open()
 	if err != nil {
-		http.Redirect(w, r, "/admin?error=Failed+to+open+TIF+in+ZIP", http.StatusSeeOther)
-		return
+		return "", "", 0, fmt.Errorf("open TIF in ZIP: %w", err)
 	}
 	defer tifReader.Close()
 
-	destPath := filepath.Join(ghslDir, filepath.Base(tifFile.Name))
+	destPath = filepath.Join(ghslDir, filepath.Base(tifFile.Name))
 	destFile, err := os.Create(destPath)
 	if err != nil {
-		http.Redirect(w, r, "/admin?error=Failed+to+create+destination+file", http.StatusSeeOther)
-		return
+		return "", "", 0, fmt.Errorf("create destination file: %w", err)
+	}
+
+	// Extracted tiles are always written through the vault when one is
+	// configured, regardless of whether the source ZIP arrived via
+	// direct upload, tus, or the JRC fetcher — extraction is the one
+	// place that writes this file, so there's no tus-style
+	// arbitrary-offset constraint like runFireProcessor's to work around.
+	var dst io.Writer = destFile
+	var encWriter io.WriteCloser
+	if s.Vault != nil {
+		encWriter, err = s.Vault.EncryptWriter(destFile)
+		if err != nil {
+			destFile.Close()
+			return "", "", 0, fmt.Errorf("open vault writer for ghsl tile: %w", err)
+		}
+		dst = encWriter
 	}
 
-	written, err := io.Copy(destFile, tifReader)
+	written, err = io.Copy(dst, tifReader)
+	if encWriter != nil {
+		err = errors.Join(err, encWriter.Close())
+	}
 	destFile.Close()
 	if err != nil {
-		http.Redirect(w, r, "/admin?error=Failed+to+extract+TIF", http.StatusSeeOther)
-		return
+		return "", "", 0, fmt.Errorf("extract TIF: %w", err)
 	}
 
-	slog.Info("GHSL tile extracted", "tile", tileID, "path", destPath, "size", written)
+	return tileID, destPath, written, nil
+}
+
+// runGHSLProcessor decodes the GeoTIFF extracted at destPath via the
+// in-process ghsl ingest package, reporting real byte progress to
+// jobID and removing the extracted tile's directory once finished.
+// It's shared by the direct multipart upload handler, the tus upload
+// completion callback, and the JRC tile fetcher. Cancelling ctx (via
+// JobRunner.Cancel) stops the ingest before its next pass over the
+// raster.
+func (s *Server) runGHSLProcessor(ctx context.Context, jobID, tileID, destPath string) error {
+	s.Jobs.SetPhase(jobID, "processing")
+	// Clean up extracted TIF after processing to save disk space
+	defer vault.WipeDir(filepath.Dir(destPath))
+
+	f, err := os.Open(destPath)
+	if err != nil {
+		return fmt.Errorf("open ghsl tile: %w", err)
+	}
+	defer f.Close()
 
-	// Start background processing with Python script
-	go func() {
-		setProcessingStatus(fmt.Sprintf("Processing GHSL tile: %s", tileID))
-		defer func() {
-			// Clean up extracted TIF after processing to save disk space
-			os.RemoveAll(ghslDir)
-			setProcessingStatus("")
-		}()
-
-		// Run the streaming GHSL processor
-		cmd := exec.Command(".venv/bin/python", "scripts/ghsl_processor_streaming.py", "--zip", destPath, "--keep")
-		output, err := cmd.CombinedOutput()
+	var r io.Reader = f
+	if s.Vault != nil {
+		r, err = s.Vault.DecryptReader(f)
 		if err != nil {
-			slog.Error("GHSL processing failed", "error", err, "output", string(output))
-			setProcessingStatus("GHSL processing failed: " + err.Error())
-		} else {
-			slog.Info("GHSL tile processing complete", "tile", tileID, "output", string(output))
-			setProcessingStatus("GHSL tile processed: " + tileID)
+			return fmt.Errorf("open vault reader for ghsl tile: %w", err)
 		}
-		time.Sleep(5 * time.Second)
-	}()
+	}
 
-	http.Redirect(w, r, fmt.Sprintf("/admin?success=GHSL+tile+%s+uploaded.+Processing+in+background.", tileID), http.StatusSeeOther)
+	var lastReported int64
+	areasTouched, err := ghslingest.Ingest(ctx, s.DB, s.AreaStore, r, func(bytesRead int64) {
+		s.Jobs.AddBytes(jobID, bytesRead-lastReported)
+		lastReported = bytesRead
+	})
+	if err != nil {
+		return fmt.Errorf("ghsl processor: %w", err)
+	}
+	slog.Info("GHSL tile processing complete", "tile", tileID, "areas_touched", areasTouched)
+	return nil
 }
 
 // extractGHSLTileID extracts the tile ID (e.g., R5_C18) from a GHSL filename.
@@ -458,10 +586,3 @@ func extractGHSLTileID(filename string) string {
 	}
 	return ""
 }
-
-// HandleProcessingStatus returns the current processing status as JSON.
-func (s *Server) HandleProcessingStatus(w http.ResponseWriter, r *http.Request) {
-	status := getProcessingStatus()
-	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"status":%q}`, status)
-}
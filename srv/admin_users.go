@@ -0,0 +1,233 @@
+package srv
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/auth"
+	"srv.exe.dev/srv/csrf"
+)
+
+type adminUsersPageData struct {
+	Hostname  string
+	Users     []dbgen.User
+	Total     int
+	Page      int
+	PerPage   int
+	Role      string
+	OrgType   string
+	Country   string
+	Query     string
+	Error     string
+	Success   string
+	CSRFToken string
+}
+
+// HandleAdminUsersPage lists users with filters (role/status, org
+// type, country, free-text) and server-side pagination.
+func (s *Server) HandleAdminUsersPage(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filter := auth.UserFilter{
+		Role:    r.URL.Query().Get("role"),
+		OrgType: r.URL.Query().Get("org_type"),
+		Country: r.URL.Query().Get("country"),
+		Query:   r.URL.Query().Get("q"),
+		Page:    page,
+		PerPage: 50,
+	}
+
+	users, total, err := s.Auth.ListUsers(r.Context(), filter)
+	if err != nil {
+		slog.Error("failed to list users", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	csrfToken, err := csrf.EnsureCookie(w, r, s.IsSecureRequest(r))
+	if err != nil {
+		slog.Warn("failed to set CSRF cookie", "error", err)
+	}
+
+	data := adminUsersPageData{
+		Hostname:  s.Hostname,
+		Users:     users,
+		Total:     total,
+		Page:      page,
+		PerPage:   filter.PerPage,
+		Role:      filter.Role,
+		OrgType:   filter.OrgType,
+		Country:   filter.Country,
+		Query:     filter.Query,
+		Success:   r.URL.Query().Get("success"),
+		Error:     r.URL.Query().Get("error"),
+		CSRFToken: csrfToken,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.renderTemplate(w, "admin_users.html", data); err != nil {
+		slog.Warn("render admin users template", "error", err)
+	}
+}
+
+type adminUserDetailPageData struct {
+	Hostname  string
+	User      dbgen.User
+	Events    []dbgen.UserEvent
+	CSRFToken string
+	Error     string
+	Success   string
+}
+
+// HandleAdminUserDetail shows one user's profile plus their recent
+// audit events (as actor or target).
+func (s *Server) HandleAdminUserDetail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := r.PathValue("id")
+
+	q := dbgen.New(s.DB)
+	user, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	events, err := q.ListUserEventsForUser(ctx, dbgen.ListUserEventsForUserParams{UserID: userID, Limit: 100})
+	if err != nil {
+		slog.Warn("failed to list user events", "user_id", userID, "error", err)
+	}
+
+	csrfToken, err := csrf.EnsureCookie(w, r, s.IsSecureRequest(r))
+	if err != nil {
+		slog.Warn("failed to set CSRF cookie", "error", err)
+	}
+
+	data := adminUserDetailPageData{
+		Hostname:  s.Hostname,
+		User:      user,
+		Events:    events,
+		CSRFToken: csrfToken,
+		Success:   r.URL.Query().Get("success"),
+		Error:     r.URL.Query().Get("error"),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.renderTemplate(w, "admin_user_detail.html", data); err != nil {
+		slog.Warn("render admin user detail template", "error", err)
+	}
+}
+
+// HandleAdminUserAction performs one admin mutation (promote, demote,
+// suspend, reinstate, force_logout, reset_password, resend_approval,
+// delete) against the user at {id}, logging it to the audit trail.
+func (s *Server) HandleAdminUserAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := r.PathValue("id")
+	action := r.FormValue("action")
+	redirectTo := "/admin/users/" + userID
+
+	adminUser := s.GetUserFromRequest(r)
+	if adminUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	var actionErr error
+	switch action {
+	case "promote":
+		actionErr = s.Auth.UpdateRole(ctx, userID, "admin")
+	case "demote":
+		actionErr = s.Auth.UpdateRole(ctx, userID, "approved")
+	case "suspend":
+		actionErr = s.Auth.SetStatus(ctx, userID, auth.RoleSuspended)
+		if actionErr == nil {
+			actionErr = s.Auth.DeleteAllSessions(ctx, userID)
+		}
+	case "reinstate":
+		actionErr = s.Auth.SetStatus(ctx, userID, "approved")
+	case "force_logout":
+		actionErr = s.Auth.DeleteAllSessions(ctx, userID)
+	case "reset_password":
+		_, actionErr = s.Auth.IssuePasswordReset(ctx, userID)
+	case "resend_approval":
+		// Approval emails aren't wired up in this tree; recorded as an
+		// audit event so support can see the request was acknowledged.
+	case "delete":
+		actionErr = s.Auth.DeleteAllSessions(ctx, userID)
+		if actionErr == nil {
+			_, actionErr = s.DB.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userID)
+		}
+		redirectTo = "/admin/users"
+	default:
+		http.Redirect(w, r, redirectTo+"?error=Unknown+action", http.StatusSeeOther)
+		return
+	}
+
+	if actionErr != nil {
+		slog.Error("admin user action failed", "action", action, "user_id", userID, "error", actionErr)
+		http.Redirect(w, r, redirectTo+"?error=Action+failed", http.StatusSeeOther)
+		return
+	}
+
+	s.Auth.LogEvent(ctx, adminUser.ID, userID, "admin_action:"+action, nil)
+	http.Redirect(w, r, redirectTo+"?success=Action+applied", http.StatusSeeOther)
+}
+
+type adminAuditPageData struct {
+	Hostname string
+	Events   []dbgen.UserEvent
+	Page     int
+	PerPage  int
+}
+
+// HandleAdminAudit renders (or, with ?format=csv, exports) the
+// user_events audit trail, most recent first.
+func (s *Server) HandleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	const perPage = 200
+
+	q := dbgen.New(s.DB)
+	events, err := q.ListUserEvents(ctx, dbgen.ListUserEventsParams{
+		Limit:  perPage,
+		Offset: int64((page - 1) * perPage),
+	})
+	if err != nil {
+		slog.Error("failed to list audit events", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"audit-log.csv\"")
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"created_at", "actor_user_id", "target_user_id", "action", "metadata"})
+		for _, e := range events {
+			cw.Write([]string{e.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), e.ActorUserID, e.TargetUserID, e.Action, e.Metadata})
+		}
+		cw.Flush()
+		return
+	}
+
+	data := adminAuditPageData{
+		Hostname: s.Hostname,
+		Events:   events,
+		Page:     page,
+		PerPage:  perPage,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.renderTemplate(w, "admin_audit.html", data); err != nil {
+		slog.Warn("render admin audit template", "error", err)
+	}
+}
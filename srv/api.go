@@ -1,16 +1,25 @@
 package srv
 
 import (
+	"context"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/areas"
 	"srv.exe.dev/srv/auth"
+	"srv.exe.dev/srv/geoip"
+	"srv.exe.dev/srv/metrics"
+	"srv.exe.dev/srv/prefetch"
 )
 
 // GeoJSON types for API responses
@@ -39,9 +48,15 @@ type GeoJSONGeometry struct {
 //   - year: filter by year (optional, defaults to current year)
 //   - month: filter by month (optional, 1-12)
 //   - from/to: date range (optional, format: YYYY-MM-DD)
+//   - bbox: minLng,minLat,maxLng,maxLat (optional). If present,
+//     s.SpatialIndex narrows the query to grid cells whose center falls
+//     inside the box instead of scanning the whole requested year
+//     range; see computeGridFeatures. A viewport wide enough to match
+//     more than bboxMaxCandidates cells falls back to the unfiltered
+//     scan, and bbox requests bypass s.PrefetchCache since the cache
+//     only ever precomputes the unfiltered shapes.
 func (s *Server) HandleAPIGrid(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	q := dbgen.New(s.DB)
 
 	// Parse query params - support both year/month and from/to date range
 	yearStr := r.URL.Query().Get("year")
@@ -76,16 +91,87 @@ func (s *Server) HandleAPIGrid(w http.ResponseWriter, r *http.Request) {
 		toYear = int64(now.Year())
 	}
 
+	var cellIDs []string
+	bboxFiltered := false
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		if bbox, err := parseBBoxParam(bboxStr); err == nil {
+			if candidates := s.SpatialIndex.QueryGridCells(bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat); len(candidates) <= bboxMaxCandidates {
+				cellIDs = candidates
+				bboxFiltered = true
+			}
+		}
+	}
+
+	if !bboxFiltered {
+		key := prefetch.GridKey(fromYear, toYear, monthStr)
+		if s.PrefetchSampler != nil {
+			s.PrefetchSampler.Record(key)
+		}
+		if s.PrefetchCache != nil {
+			if entry, ok := s.PrefetchCache.Get(key); ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Cache-Control", "public, max-age=60")
+				w.Header().Set("ETag", entry.ETag)
+				w.Write(entry.Data)
+				return
+			}
+		}
+	}
+
+	var features []GeoJSONFeature
+	if bboxFiltered && len(cellIDs) == 0 {
+		// Viewport matched no indexed grid cell; nothing to query.
+		features = []GeoJSONFeature{}
+	} else {
+		var qerr *gridQueryError
+		features, qerr = s.computeGridFeatures(ctx, fromYear, toYear, monthStr, cellIDs)
+		if qerr != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(qerr.status)
+			json.NewEncoder(w).Encode(map[string]string{"error": qerr.message})
+			return
+		}
+	}
+
+	metrics.GridFeaturesReturned.Observe(float64(len(features)))
+
+	fc := GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: features,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(fc)
+}
+
+// gridQueryError carries an HTTP status alongside a computeGridFeatures
+// failure, so callers that talk HTTP (HandleAPIGrid) and callers that
+// don't (the prefetch rebuild job) can each react appropriately.
+type gridQueryError struct {
+	status  int
+	message string
+}
+
+func (e *gridQueryError) Error() string { return e.message }
+
+// computeGridFeatures runs the grid aggregation query for [fromYear,
+// toYear], optionally narrowed to a single month, and returns the
+// resulting GeoJSON features. It holds the logic HandleAPIGrid used to
+// run inline, so the prefetch rebuild job can drive the same query
+// shapes without going through HTTP. cellIDs, if non-empty, narrows the
+// query to that set of grid cell IDs (see HandleAPIGrid's bbox param)
+// instead of every cell in the requested year range.
+func (s *Server) computeGridFeatures(ctx context.Context, fromYear, toYear int64, monthStr string, cellIDs []string) ([]GeoJSONFeature, *gridQueryError) {
+	q := dbgen.New(s.DB)
+
 	var features []GeoJSONFeature
 
 	// Special case: single month query (no month counting needed)
 	if monthStr != "" {
 		month, parseErr := strconv.ParseInt(monthStr, 10, 64)
 		if parseErr != nil || month < 1 || month > 12 {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "invalid month parameter"})
-			return
+			return nil, &gridQueryError{status: http.StatusBadRequest, message: "invalid month parameter"}
 		}
 		// Query each year and aggregate
 		aggregated := make(map[string]*struct {
@@ -98,10 +184,23 @@ func (s *Server) HandleAPIGrid(w http.ResponseWriter, r *http.Request) {
 			CoveragePercent *float64
 		})
 		for year := fromYear; year <= toYear; year++ {
-			rows, err := q.GetEffortDataByYearMonth(ctx, dbgen.GetEffortDataByYearMonthParams{
-				Year:  year,
-				Month: month,
-			})
+			queryStart := time.Now()
+			var rows []dbgen.GetEffortDataByYearMonthRow
+			var err error
+			if len(cellIDs) > 0 {
+				rows, err = q.GetEffortDataByYearMonthForCells(ctx, dbgen.GetEffortDataByYearMonthForCellsParams{
+					Year:        year,
+					Month:       month,
+					GridCellIds: cellIDs,
+				})
+				metrics.DBQueryDurationSeconds.WithLabelValues("GetEffortDataByYearMonthForCells").Observe(time.Since(queryStart).Seconds())
+			} else {
+				rows, err = q.GetEffortDataByYearMonth(ctx, dbgen.GetEffortDataByYearMonthParams{
+					Year:  year,
+					Month: month,
+				})
+				metrics.DBQueryDurationSeconds.WithLabelValues("GetEffortDataByYearMonth").Observe(time.Since(queryStart).Seconds())
+			}
 			if err != nil {
 				continue
 			}
@@ -159,15 +258,25 @@ func (s *Server) HandleAPIGrid(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		// Use the optimized SQL query that calculates month counts
-		rows, err := q.GetEffortDataWithMonthCounts(ctx, dbgen.GetEffortDataWithMonthCountsParams{
-			Year:   fromYear,
-			Year_2: toYear,
-		})
+		queryStart := time.Now()
+		var rows []dbgen.GetEffortDataWithMonthCountsRow
+		var err error
+		if len(cellIDs) > 0 {
+			rows, err = q.GetEffortDataWithMonthCountsForCells(ctx, dbgen.GetEffortDataWithMonthCountsForCellsParams{
+				Year:        fromYear,
+				Year_2:      toYear,
+				GridCellIds: cellIDs,
+			})
+			metrics.DBQueryDurationSeconds.WithLabelValues("GetEffortDataWithMonthCountsForCells").Observe(time.Since(queryStart).Seconds())
+		} else {
+			rows, err = q.GetEffortDataWithMonthCounts(ctx, dbgen.GetEffortDataWithMonthCountsParams{
+				Year:   fromYear,
+				Year_2: toYear,
+			})
+			metrics.DBQueryDurationSeconds.WithLabelValues("GetEffortDataWithMonthCounts").Observe(time.Since(queryStart).Seconds())
+		}
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
-			return
+			return nil, &gridQueryError{status: http.StatusInternalServerError, message: "database error"}
 		}
 
 		features = make([]GeoJSONFeature, 0, len(rows))
@@ -210,14 +319,7 @@ func (s *Server) HandleAPIGrid(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	fc := GeoJSONFeatureCollection{
-		Type:     "FeatureCollection",
-		Features: features,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "public, max-age=60")
-	json.NewEncoder(w).Encode(fc)
+	return features, nil
 }
 
 // buildGridFeature creates a GeoJSON feature for a grid cell.
@@ -226,17 +328,17 @@ func (s *Server) HandleAPIGrid(w http.ResponseWriter, r *http.Request) {
 // For full patrol coverage, rangers need to visit each cell monthly during dry season.
 func buildGridFeature(gridCellID string, latCenter, lonCenter, totalDistanceKm float64, totalPoints, uniqueUploads int64, movementType string, coveragePercent *float64, dryMonths, rainyMonths int64) GeoJSONFeature {
 	// Calculate intensity based on TEMPORAL FREQUENCY of visits
-	// 
+	//
 	// For effective poacher/herder detection:
 	// - Dry season (Nov-Apr = 6 months): Need monthly visits, weight = 1.0 per month
 	// - Rainy season (May-Oct = 6 months): Limited access, weight = 0.3 per month
-	// 
+	//
 	// Full intensity (1.0) = visited all dry season months + some rainy months
 	// Expected weighted visits = 6 * 1.0 (dry) + 6 * 0.3 (rainy) = 7.8
 	// But for practical purposes, we use 6 dry months as the baseline (ignoring rainy)
-	
+
 	var intensity float64
-	
+
 	// Primary calculation: temporal frequency (monthly visits)
 	if dryMonths > 0 || rainyMonths > 0 {
 		// Weight: dry months count fully, rainy months count 30%
@@ -252,7 +354,7 @@ func buildGridFeature(gridCellID string, latCenter, lonCenter, totalDistanceKm f
 		// ~80km patrol in a year = ~1 full coverage (very rough)
 		intensity = totalDistanceKm / 80.0
 	}
-	
+
 	if intensity > 1.5 {
 		intensity = 1.5 // Cap for overglow effect
 	}
@@ -277,6 +379,10 @@ func buildGridFeature(gridCellID string, latCenter, lonCenter, totalDistanceKm f
 }
 
 // HandleAPIAreas returns protected areas as GeoJSON FeatureCollection.
+// Query params:
+//   - bbox: minLng,minLat,maxLng,maxLat (optional). If present, only
+//     areas whose bounding box intersects it (per s.SpatialIndex) are
+//     returned, instead of every loaded area.
 func (s *Server) HandleAPIAreas(w http.ResponseWriter, r *http.Request) {
 	if s.AreaStore == nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -285,9 +391,22 @@ func (s *Server) HandleAPIAreas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var bboxAreaIDs map[string]bool
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		if bbox, err := parseBBoxParam(bboxStr); err == nil {
+			bboxAreaIDs = make(map[string]bool)
+			for _, id := range s.SpatialIndex.QueryAreas(bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat) {
+				bboxAreaIDs[id] = true
+			}
+		}
+	}
+
 	features := make([]GeoJSONFeature, 0, len(s.AreaStore.Areas))
 
 	for _, area := range s.AreaStore.Areas {
+		if bboxAreaIDs != nil && !bboxAreaIDs[area.ID] {
+			continue
+		}
 		// Use the polygon geometry directly from the area data
 		feature := GeoJSONFeature{
 			Type: "Feature",
@@ -296,14 +415,14 @@ func (s *Server) HandleAPIAreas(w http.ResponseWriter, r *http.Request) {
 				Coordinates: area.Geometry.Coordinates,
 			},
 			Properties: map[string]interface{}{
-				"id":          area.ID,
-				"name":        area.Name,
-				"country":     area.Country,
+				"id":           area.ID,
+				"name":         area.Name,
+				"country":      area.Country,
 				"country_code": area.CountryCode,
-				"wdpa_id":     area.WDPAID,
-				"area_km2":    area.AreaKm2,
-				"partner":     area.Partner,
-				"buffer_km":   area.BufferKm,
+				"wdpa_id":      area.WDPAID,
+				"area_km2":     area.AreaKm2,
+				"partner":      area.Partner,
+				"buffer_km":    area.BufferKm,
 			},
 		}
 		features = append(features, feature)
@@ -332,15 +451,17 @@ func (s *Server) HandleAPILogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionID, _, err := s.Auth.Login(r.Context(), req.Email, req.Password)
+	sessionID, _, err := s.Auth.Login(r.Context(), req.Email, req.Password, s.ClientIP(r))
 	if err != nil {
+		metrics.AuthLoginAttemptsTotal.WithLabelValues("failure").Inc()
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	auth.SetSessionCookie(w, sessionID)
+	metrics.AuthLoginAttemptsTotal.WithLabelValues("success").Inc()
+	auth.SetSessionCookie(w, sessionID, s.Auth.InitialSessionMaxAge(), s.IsSecureRequest(r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
@@ -386,7 +507,7 @@ func (s *Server) HandleAPILogout(w http.ResponseWriter, r *http.Request) {
 			slog.Warn("API logout session deletion failed, continuing with cookie clear")
 		}
 	}
-	auth.ClearSessionCookie(w)
+	auth.ClearSessionCookie(w, s.IsSecureRequest(r))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
@@ -396,7 +517,18 @@ func (s *Server) HandleAPILogout(w http.ResponseWriter, r *http.Request) {
 //   - from: start date (YYYY-MM-DD)
 //   - to: end date (YYYY-MM-DD)
 //   - type: movement type filter (foot,vehicle,aerial)
-//   - bbox: bounding box (minLng,minLat,maxLng,maxLat) - not yet implemented
+//   - bbox: bounding box (minLng,minLat,maxLng,maxLat). Narrows the
+//     effort aggregation to grid cells intersecting the box, via
+//     s.SpatialIndex, instead of every cell in the requested year
+//     range.
+//
+// Outside of a bbox request, effort/fire/deforestation/settlement totals
+// are read from the daily_effort_stats/daily_fire_stats/pa_monthly_rollup
+// rollup tables (see srv/effort_rollups.go) with one range query per
+// metric, rather than the per-year dbgen loop and three hand-written
+// s.DB.QueryRow scans this handler used to run live. A bbox request
+// falls back to the live per-cell query path from before rollups
+// existed, since the rollups aren't grid-cell scoped.
 func (s *Server) HandleAPIStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	q := dbgen.New(s.DB)
@@ -406,6 +538,17 @@ func (s *Server) HandleAPIStats(w http.ResponseWriter, r *http.Request) {
 	toStr := r.URL.Query().Get("to")
 	typeFilter := r.URL.Query().Get("type")
 
+	var cellIDs []string
+	bboxFiltered := false
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		if bbox, err := parseBBoxParam(bboxStr); err == nil {
+			if candidates := s.SpatialIndex.QueryGridCells(bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat); len(candidates) <= bboxMaxCandidates {
+				cellIDs = candidates
+				bboxFiltered = true
+			}
+		}
+	}
+
 	// Default to current year if no dates provided
 	now := time.Now()
 	fromYear := int64(now.Year())
@@ -421,36 +564,60 @@ func (s *Server) HandleAPIStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Aggregate stats across requested years
+	rangeFrom, rangeTo := fromStr, toStr
+	if rangeFrom == "" || rangeTo == "" {
+		rangeFrom = fmt.Sprintf("%04d-01-01", fromYear)
+		rangeTo = fmt.Sprintf("%04d-12-31", toYear)
+	}
+	fromTime, _ := time.Parse("2006-01-02", rangeFrom)
+	toTime, _ := time.Parse("2006-01-02", rangeTo)
+	duration := toTime.Sub(fromTime)
+	prevFrom := fromTime.Add(-duration).Format("2006-01-02")
+	prevTo := fromTime.Add(-24 * time.Hour).Format("2006-01-02")
+
+	// Aggregate effort stats across the requested range
 	var activePixels, totalUploads int64
 	var totalDistanceKm float64
-	seenPixels := make(map[string]bool)
 
-	for year := fromYear; year <= toYear; year++ {
-		rows, err := q.GetEffortDataByYear(ctx, year)
-		if err != nil {
-			continue
-		}
+	// A bbox that matched no indexed grid cell has nothing to aggregate.
+	viewportEmpty := bboxFiltered && len(cellIDs) == 0
 
-		for _, row := range rows {
-			// Apply movement type filter
-			if typeFilter != "" && row.MovementType != "all" {
-				if !strings.Contains(typeFilter, row.MovementType) {
-					continue
-				}
-			}
-			// Only count "all" type to avoid double counting
-			if row.MovementType != "all" {
+	if bboxFiltered {
+		seenPixels := make(map[string]bool)
+		for year := fromYear; !viewportEmpty && year <= toYear; year++ {
+			rows, err := q.GetEffortDataByYearForCells(ctx, dbgen.GetEffortDataByYearForCellsParams{
+				Year:        year,
+				GridCellIds: cellIDs,
+			})
+			if err != nil {
 				continue
 			}
 
-			if !seenPixels[row.GridCellID] {
-				seenPixels[row.GridCellID] = true
-				activePixels++
+			for _, row := range rows {
+				// Apply movement type filter
+				if typeFilter != "" && row.MovementType != "all" {
+					if !strings.Contains(typeFilter, row.MovementType) {
+						continue
+					}
+				}
+				// Only count "all" type to avoid double counting
+				if row.MovementType != "all" {
+					continue
+				}
+
+				if !seenPixels[row.GridCellID] {
+					seenPixels[row.GridCellID] = true
+					activePixels++
+				}
+				totalDistanceKm += row.TotalDistanceKm
+				totalUploads += row.UniqueUploads
 			}
-			totalDistanceKm += row.TotalDistanceKm
-			totalUploads += row.UniqueUploads
 		}
+	} else {
+		s.DB.QueryRow(`
+			SELECT COALESCE(SUM(active_pixels), 0), COALESCE(SUM(total_distance_km), 0), COALESCE(SUM(total_uploads), 0)
+			FROM daily_effort_stats WHERE day >= ? AND day <= ?
+		`, rangeFrom, rangeTo).Scan(&activePixels, &totalDistanceKm, &totalUploads)
 	}
 
 	// Get conservation summary data
@@ -458,51 +625,29 @@ func (s *Server) HandleAPIStats(w http.ResponseWriter, r *http.Request) {
 	var totalDeforestation, prevDeforestation float64
 	var totalSettlements int
 
-	// Fire detections in selected time period
-	if fromStr != "" && toStr != "" {
-		s.DB.QueryRow(`
-			SELECT COUNT(*) FROM fire_detections 
-			WHERE acq_date >= ? AND acq_date <= ?
-		`, fromStr, toStr).Scan(&totalFires)
-
-		// Get previous period fires for trend calculation
-		fromTime, _ := time.Parse("2006-01-02", fromStr)
-		toTime, _ := time.Parse("2006-01-02", toStr)
-		duration := toTime.Sub(fromTime)
-		prevFrom := fromTime.Add(-duration).Format("2006-01-02")
-		prevTo := fromTime.Add(-24 * time.Hour).Format("2006-01-02")
-		s.DB.QueryRow(`
-			SELECT COUNT(*) FROM fire_detections 
-			WHERE acq_date >= ? AND acq_date <= ?
-		`, prevFrom, prevTo).Scan(&prevFires)
-	} else {
-		// Default: current year
-		s.DB.QueryRow(`
-			SELECT COUNT(*) FROM fire_detections 
-			WHERE CAST(strftime('%Y', acq_date) AS INTEGER) = ?
-		`, now.Year()).Scan(&totalFires)
-		// Previous year for trend
-		s.DB.QueryRow(`
-			SELECT COUNT(*) FROM fire_detections 
-			WHERE CAST(strftime('%Y', acq_date) AS INTEGER) = ?
-		`, now.Year()-1).Scan(&prevFires)
-	}
-
-	// Deforestation totals in selected years
 	s.DB.QueryRow(`
-		SELECT COALESCE(SUM(area_km2), 0) FROM deforestation_events 
-		WHERE year >= ? AND year <= ?
-	`, fromYear, toYear).Scan(&totalDeforestation)
+		SELECT COALESCE(SUM(fire_count), 0) FROM daily_fire_stats WHERE day >= ? AND day <= ?
+	`, rangeFrom, rangeTo).Scan(&totalFires)
+	s.DB.QueryRow(`
+		SELECT COALESCE(SUM(fire_count), 0) FROM daily_fire_stats WHERE day >= ? AND day <= ?
+	`, prevFrom, prevTo).Scan(&prevFires)
 
-	// Previous period deforestation for trend
-	yearSpan := toYear - fromYear + 1
+	fromMonth, toMonth := rangeFrom[:7], rangeTo[:7]
+	prevFromMonth, prevToMonth := prevFrom[:7], prevTo[:7]
+	s.DB.QueryRow(`
+		SELECT COALESCE(SUM(deforestation_km2), 0) FROM pa_monthly_rollup WHERE month >= ? AND month <= ?
+	`, fromMonth, toMonth).Scan(&totalDeforestation)
 	s.DB.QueryRow(`
-		SELECT COALESCE(SUM(area_km2), 0) FROM deforestation_events 
-		WHERE year >= ? AND year < ?
-	`, fromYear-yearSpan, fromYear).Scan(&prevDeforestation)
+		SELECT COALESCE(SUM(deforestation_km2), 0) FROM pa_monthly_rollup WHERE month >= ? AND month <= ?
+	`, prevFromMonth, prevToMonth).Scan(&prevDeforestation)
 
-	// Total settlements across all parks
-	s.DB.QueryRow(`SELECT COUNT(*) FROM park_settlements`).Scan(&totalSettlements)
+	// Settlements are a point-in-time count, not a per-period flow, so
+	// this reads the most recently rolled-up month rather than summing
+	// across the requested range.
+	s.DB.QueryRow(`
+		SELECT COALESCE(SUM(settlement_count), 0) FROM pa_monthly_rollup
+		WHERE month = (SELECT MAX(month) FROM pa_monthly_rollup)
+	`).Scan(&totalSettlements)
 
 	// Calculate trends
 	fireTrend := "stable"
@@ -542,6 +687,15 @@ func (s *Server) HandleAPIStats(w http.ResponseWriter, r *http.Request) {
 // HandleAPIAreasSearch searches protected areas, countries, and regions by name.
 // Query params:
 //   - q: search query (required)
+//   - near: if "me", results are sorted by distance from the caller's
+//     GeoIP-resolved location (closest first) and matches in the
+//     caller's country are preferred over farther ones of otherwise
+//     equal relevance. No-ops if s.GeoIP isn't configured or the
+//     caller's address doesn't resolve.
+//   - bbox: minLng,minLat,maxLng,maxLat (optional). If present, results
+//     whose own "bbox" intersects the visible map area are ranked
+//     first, ahead of the near/proximity ordering below.
+//
 // Returns matching results with center coordinates for map navigation.
 // Results include:
 //   - Loaded (keystone) PAs - shown in green
@@ -556,6 +710,20 @@ func (s *Server) HandleAPIAreasSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var caller *geoip.Result
+	if s.GeoIP != nil && r.URL.Query().Get("near") == "me" {
+		if res, err := s.GeoIP.Lookup(s.ClientIP(r)); err == nil {
+			caller = &res
+		}
+	}
+
+	var viewport *BBox
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		if bbox, err := parseBBoxParam(bboxStr); err == nil {
+			viewport = &bbox
+		}
+	}
+
 	// Case-insensitive search
 	queryLower := strings.ToLower(query)
 	results := make([]map[string]interface{}, 0, 30)
@@ -568,11 +736,11 @@ func (s *Server) HandleAPIAreasSearch(w http.ResponseWriter, r *http.Request) {
 		countries := s.GADMStore.SearchCountries(query, 3)
 		for _, c := range countries {
 			results = append(results, map[string]interface{}{
-				"type":    "country",
-				"name":    c.Name,
-				"code":    c.Code,
-				"center":  c.Center,
-				"bbox":    c.BBox,
+				"type":   "country",
+				"name":   c.Name,
+				"code":   c.Code,
+				"center": c.Center,
+				"bbox":   c.BBox,
 			})
 		}
 	}
@@ -588,15 +756,15 @@ func (s *Server) HandleAPIAreasSearch(w http.ResponseWriter, r *http.Request) {
 				centerLon := (lonMin + lonMax) / 2
 
 				results = append(results, map[string]interface{}{
-					"type":      "pa",
-					"id":        area.ID,
-					"name":      area.Name,
-					"country":   area.Country,
-					"wdpa_id":   area.WDPAID,
-					"area_km2":  area.AreaKm2,
-					"center":    []float64{centerLon, centerLat},
-					"bbox":      []float64{lonMin, latMin, lonMax, latMax},
-					"loaded":    true, // This PA is loaded in the system
+					"type":     "pa",
+					"id":       area.ID,
+					"name":     area.Name,
+					"country":  area.Country,
+					"wdpa_id":  area.WDPAID,
+					"area_km2": area.AreaKm2,
+					"center":   []float64{centerLon, centerLat},
+					"bbox":     []float64{lonMin, latMin, lonMax, latMax},
+					"loaded":   true, // This PA is loaded in the system
 				})
 
 				loadedWDPAIDs[area.WDPAID] = true
@@ -651,16 +819,101 @@ func (s *Server) HandleAPIAreasSearch(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if caller != nil {
+		sortResultsByProximity(results, *caller)
+	}
+	if viewport != nil {
+		sortResultsByBBoxIntersection(results, *viewport)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "public, max-age=60")
 	json.NewEncoder(w).Encode(results)
 }
 
+// sortResultsByBBoxIntersection stably reorders results in place so
+// that any result whose own "bbox" field intersects viewport sorts
+// ahead of ones that don't (or that carry no bbox at all, like
+// unloaded WDPA entries). It runs after sortResultsByProximity so a
+// visible-viewport match always outranks a merely-nearby one, while
+// preserving the near/proximity order within each of the two groups.
+func sortResultsByBBoxIntersection(results []map[string]interface{}, viewport BBox) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return resultIntersectsBBox(results[i], viewport) && !resultIntersectsBBox(results[j], viewport)
+	})
+}
+
+// resultIntersectsBBox reports whether a result's "bbox" field
+// ([minLng,minLat,maxLng,maxLat]) overlaps viewport.
+func resultIntersectsBBox(result map[string]interface{}, viewport BBox) bool {
+	bbox, ok := result["bbox"].([]float64)
+	if !ok || len(bbox) != 4 {
+		return false
+	}
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	return minLon <= viewport.MaxLon && maxLon >= viewport.MinLon &&
+		minLat <= viewport.MaxLat && maxLat >= viewport.MinLat
+}
+
+// sortResultsByProximity reorders results in place, putting matches in
+// caller's country first, then ordering by distance from caller's
+// coordinates. Results without a "center" (e.g. unloaded WDPA entries,
+// which carry no coordinates) sort after everything that has one.
+func sortResultsByProximity(results []map[string]interface{}, caller geoip.Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		iHome := resultCountryCode(results[i]) == caller.ISO3
+		jHome := resultCountryCode(results[j]) == caller.ISO3
+		if iHome != jHome {
+			return iHome
+		}
+		di, iok := resultDistanceKm(results[i], caller)
+		dj, jok := resultDistanceKm(results[j], caller)
+		if iok != jok {
+			return iok
+		}
+		return di < dj
+	})
+}
+
+// resultCountryCode best-efforts an ISO3 code out of a result's
+// "country"/"code" field for the proximity sort above; most result
+// types here store country names, not ISO3, so this only matches for
+// the "country" result type itself.
+func resultCountryCode(result map[string]interface{}) string {
+	if code, ok := result["code"].(string); ok {
+		return code
+	}
+	return ""
+}
+
+// resultDistanceKm returns the great-circle distance from caller to a
+// result's "center" field, if it has one.
+func resultDistanceKm(result map[string]interface{}, caller geoip.Result) (float64, bool) {
+	center, ok := result["center"].([]float64)
+	if !ok || len(center) != 2 {
+		return 0, false
+	}
+	return haversineDistance(caller.Lat, caller.Lon, center[1], center[0]), true
+}
+
 // HandleAPIActivity returns recent upload activity.
+// Query params:
+//   - bbox: minLng,minLat,maxLng,maxLat (optional). Activity is fetched
+//     most-recent-first regardless, in a fixed small LIMIT 10, so bbox
+//     here is a simple in-memory centroid filter over that page rather
+//     than an s.SpatialIndex query — there's no per-viewport row set to
+//     narrow to first the way there is for the grid/area endpoints.
 func (s *Server) HandleAPIActivity(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	q := dbgen.New(s.DB)
 
+	var viewport *BBox
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		if bbox, err := parseBBoxParam(bboxStr); err == nil {
+			viewport = &bbox
+		}
+	}
+
 	// Get recent uploads with coordinates
 	uploads, err := q.ListGPXUploadsWithCoords(ctx, dbgen.ListGPXUploadsWithCoordsParams{
 		Limit:  10,
@@ -676,6 +929,16 @@ func (s *Server) HandleAPIActivity(w http.ResponseWriter, r *http.Request) {
 
 	activities := make([]map[string]interface{}, 0, len(uploads))
 	for _, u := range uploads {
+		if viewport != nil {
+			if u.CentroidLat == nil || u.CentroidLon == nil {
+				continue
+			}
+			if *u.CentroidLon < viewport.MinLon || *u.CentroidLon > viewport.MaxLon ||
+				*u.CentroidLat < viewport.MinLat || *u.CentroidLat > viewport.MaxLat {
+				continue
+			}
+		}
+
 		location := "Unknown"
 		if u.ProtectedAreaID != nil && *u.ProtectedAreaID != "" {
 			location = *u.ProtectedAreaID
@@ -705,7 +968,7 @@ func (s *Server) HandleAPIActivity(w http.ResponseWriter, r *http.Request) {
 
 // HandleAPIUpload handles file uploads via API.
 func (s *Server) HandleAPIUpload(w http.ResponseWriter, r *http.Request) {
-	user := s.Auth.GetUserFromRequest(r)
+	user := s.GetUserFromRequest(r)
 	if user == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -730,18 +993,38 @@ func (s *Server) HandleAPIUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	data, err := io.ReadAll(file)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to read file"})
+		return
+	}
+
 	slog.Info("API upload received", "filename", header.Filename, "size", header.Size, "user", user.Email)
+	metrics.UploadBytesTotal.Add(float64(len(data)))
+
+	if s.UploadQueue == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "upload queue not configured"})
+		return
+	}
+
+	jobID, err := s.UploadQueue.Enqueue(r.Context(), user.ID, []*multipartFile{{Filename: header.Filename, Data: data}})
+	if err != nil {
+		slog.Error("failed to enqueue upload job", "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to enqueue upload"})
+		return
+	}
 
-	// For now, just acknowledge receipt - actual processing will be added
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":   "ok",
-		"filename": header.Filename,
-		"size":     header.Size,
-	})
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"job_id": jobID, "status": "queued"})
 }
 
-
 // HandleAPIWDPASearch searches the WDPA index for protected areas.
 func (s *Server) HandleAPIWDPASearch(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
@@ -759,6 +1042,7 @@ func (s *Server) HandleAPIWDPASearch(w http.ResponseWriter, r *http.Request) {
 
 	// Search WDPA index
 	entries := s.WDPAIndex.Search(query, 50)
+	metrics.WDPASearchResults.Observe(float64(len(entries)))
 
 	// Build set of loaded keystone WDPA IDs
 	keystoneIDs := make(map[string]bool)
@@ -789,8 +1073,121 @@ func (s *Server) HandleAPIWDPASearch(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(results)
 }
 
-// HandleAPIPublications returns publications for a protected area.
+// HandleAPIPASearch searches the WDPA index with scored, fuzzy,
+// filterable ranking (see areas.WDPAIndex.SearchFiltered), for an
+// autocomplete widget that wants relevance-sorted results rather than
+// HandleAPIWDPASearch's plain substring match.
+func (s *Server) HandleAPIPASearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	w.Header().Set("Content-Type", "application/json")
+	if query == "" || s.WDPAIndex == nil {
+		json.NewEncoder(w).Encode([]interface{}{})
+		return
+	}
+
+	opts := areas.WDPASearchOptions{
+		Query:       query,
+		CountryCode: r.URL.Query().Get("country"),
+		IUCNCat:     r.URL.Query().Get("iucn_category"),
+		MaxResults:  50,
+	}
+	if v := r.URL.Query().Get("min_area_km2"); v != "" {
+		if minArea, err := strconv.ParseFloat(v, 64); err == nil {
+			opts.MinAreaKm2 = minArea
+		}
+	}
+
+	entries := s.WDPAIndex.SearchFiltered(opts)
+	metrics.WDPASearchResults.Observe(float64(len(entries)))
+
+	keystoneIDs := make(map[string]bool)
+	if s.AreaStore != nil {
+		for _, a := range s.AreaStore.Areas {
+			if a.WDPAID != "" {
+				keystoneIDs[a.WDPAID] = true
+			}
+		}
+	}
+
+	results := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		wdpaIDStr := fmt.Sprintf("%d", e.WDPAID)
+		results = append(results, map[string]interface{}{
+			"wdpa_id":       e.WDPAID,
+			"name":          e.Name,
+			"country":       e.Country,
+			"country_code":  e.CountryCode,
+			"designation":   e.Designation,
+			"iucn_category": e.IUCNCat,
+			"area_km2":      e.AreaKm2,
+			"loaded":        keystoneIDs[wdpaIDStr],
+		})
+	}
+
+	json.NewEncoder(w).Encode(results)
+}
+
+// publicationsCursor is the opaque keyset-pagination cursor for
+// HandleAPIPublications, base64-encoded as query-string-safe JSON. Its
+// shape is fixed to {year, id} regardless of ?sort=: for sort=year
+// (the default) it resumes correctly on both fields; for the other
+// sort modes it falls back to resuming strictly after cursor.ID, which
+// is only correct if ties within the page are broken by id the same
+// way ORDER BY does below — acceptable since ID is the tiebreaker for
+// every sort this endpoint supports.
+type publicationsCursor struct {
+	Year int   `json:"year"`
+	ID   int64 `json:"id"`
+}
+
+func decodePublicationsCursor(raw string) (*publicationsCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	b, err := base64.URLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var c publicationsCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func encodePublicationsCursor(c publicationsCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+const (
+	publicationsDefaultLimit = 25
+	publicationsMaxLimit     = 100
+)
+
+// publicationsSortColumns maps the ?sort= value to the column it
+// orders by; title sorts ascending (alphabetical), the rest descending
+// (newest/most-cited first).
+var publicationsSortColumns = map[string]string{
+	"year":           "year",
+	"cited_by_count": "cited_by_count",
+	"title":          "title",
+}
+
+// HandleAPIPublications lists a protected area's publications with
+// filtering, full-text search, sorting, and keyset pagination.
 // GET /api/parks/{id}/publications
+//
+// Query parameters: limit (default 25, max 100), cursor (opaque,
+// from a previous response's next_cursor), sort (year|cited_by_count|
+// title, default year), year_from, year_to, min_citations, has_doi
+// (true|false), and q (full-text search over title/abstract via the
+// publications_fts FTS5 index).
+//
+// The response is {"items": [...], "next_cursor": "...", "total": n}.
+// When there are more results, a Link: <...>; rel="next" header is
+// also set. If ?stats=1 is passed, a "stats" field is added — see
+// srv/query_stats.go.
 func (s *Server) HandleAPIPublications(w http.ResponseWriter, r *http.Request) {
 	paID := r.PathValue("id")
 	if paID == "" {
@@ -800,51 +1197,338 @@ func (s *Server) HandleAPIPublications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-	q := dbgen.New(s.DB)
+	qp := r.URL.Query()
 
-	pubs, err := q.GetPublicationsByPA(ctx, paID)
+	limit := publicationsDefaultLimit
+	if v, err := strconv.Atoi(qp.Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > publicationsMaxLimit {
+		limit = publicationsMaxLimit
+	}
+
+	sortField := qp.Get("sort")
+	sortColumn, ok := publicationsSortColumns[sortField]
+	if !ok {
+		sortField = "year"
+		sortColumn = "year"
+	}
+	dir := "DESC"
+	if sortColumn == "title" {
+		dir = "ASC"
+	}
+
+	cursor, err := decodePublicationsCursor(qp.Get("cursor"))
 	if err != nil {
-		slog.Error("failed to get publications", "pa_id", paID, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid cursor"})
+		return
+	}
+
+	conditions := []string{"pa_id = ?"}
+	args := []interface{}{paID}
+	if v := qp.Get("year_from"); v != "" {
+		conditions = append(conditions, "year >= ?")
+		args = append(args, v)
+	}
+	if v := qp.Get("year_to"); v != "" {
+		conditions = append(conditions, "year <= ?")
+		args = append(args, v)
+	}
+	if v := qp.Get("min_citations"); v != "" {
+		conditions = append(conditions, "cited_by_count >= ?")
+		args = append(args, v)
+	}
+	if v := qp.Get("has_doi"); v != "" {
+		if v == "true" || v == "1" {
+			conditions = append(conditions, "(doi IS NOT NULL AND doi != '')")
+		} else {
+			conditions = append(conditions, "(doi IS NULL OR doi = '')")
+		}
+	}
+	if v := qp.Get("q"); v != "" {
+		conditions = append(conditions, "id IN (SELECT rowid FROM publications_fts WHERE publications_fts MATCH ?)")
+		args = append(args, v)
+	}
+
+	ctx := r.Context()
+	stats := statsFromRequest(r)
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM publications WHERE %s`, strings.Join(conditions, " AND "))
+	queryStart := time.Now()
+	var total int64
+	if err := s.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		metrics.DBErrorsTotal.WithLabelValues("api_publications").Inc()
+		slog.Error("failed to count publications", "pa_id", paID, "error", err)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
 		return
 	}
+	recordQuery(stats, "CountPublicationsFiltered", 1, queryStart)
 
-	// Transform to API response
-	results := make([]map[string]interface{}, 0, len(pubs))
-	for _, p := range pubs {
-		item := map[string]interface{}{
-			"id":       p.ID,
-			"title":    p.Title,
+	if cursor != nil {
+		if sortColumn == "year" {
+			if dir == "DESC" {
+				conditions = append(conditions, "(year < ? OR (year = ? AND id < ?))")
+			} else {
+				conditions = append(conditions, "(year > ? OR (year = ? AND id > ?))")
+			}
+			args = append(args, cursor.Year, cursor.Year, cursor.ID)
+		} else if dir == "DESC" {
+			conditions = append(conditions, "id < ?")
+			args = append(args, cursor.ID)
+		} else {
+			conditions = append(conditions, "id > ?")
+			args = append(args, cursor.ID)
+		}
+	}
+
+	listQuery := fmt.Sprintf(`
+		SELECT id, title, authors, year, doi, url, abstract, cited_by_count, source
+		FROM publications
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT ?
+	`, strings.Join(conditions, " AND "), sortColumn, dir, dir)
+	args = append(args, limit+1)
+
+	queryStart = time.Now()
+	rows, err := s.DB.QueryContext(ctx, listQuery, args...)
+	if err != nil {
+		metrics.DBErrorsTotal.WithLabelValues("api_publications").Inc()
+		slog.Error("failed to list publications", "pa_id", paID, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+	defer rows.Close()
+
+	results := make([]map[string]interface{}, 0, limit)
+	var lastID int64
+	var lastYear int64
+	rowCount := 0
+	for rows.Next() {
+		var id int64
+		var title string
+		var authors, doi, url, abstract, source sql.NullString
+		var year, citedByCount sql.NullInt64
+		if err := rows.Scan(&id, &title, &authors, &year, &doi, &url, &abstract, &citedByCount, &source); err != nil {
+			metrics.DBErrorsTotal.WithLabelValues("api_publications").Inc()
+			slog.Error("failed to scan publication", "pa_id", paID, "error", err)
+			continue
+		}
+		rowCount++
+		if rowCount > limit {
+			break
 		}
-		if p.Authors != nil {
-			var authors []string
-			json.Unmarshal([]byte(*p.Authors), &authors)
-			item["authors"] = authors
+		item := map[string]interface{}{"id": id, "title": title}
+		if authors.Valid {
+			var names []string
+			json.Unmarshal([]byte(authors.String), &names)
+			item["authors"] = names
 		}
-		if p.Year != nil {
-			item["year"] = *p.Year
+		if year.Valid {
+			item["year"] = year.Int64
 		}
-		if p.Doi != nil {
-			item["doi"] = *p.Doi
+		if doi.Valid {
+			item["doi"] = doi.String
 		}
-		if p.Url != nil {
-			item["url"] = *p.Url
+		if url.Valid {
+			item["url"] = url.String
 		}
-		if p.Abstract != nil {
-			item["abstract"] = *p.Abstract
+		if abstract.Valid {
+			item["abstract"] = abstract.String
 		}
-		if p.CitedByCount != nil {
-			item["cited_by_count"] = *p.CitedByCount
+		if citedByCount.Valid {
+			item["cited_by_count"] = citedByCount.Int64
+		}
+		if source.Valid {
+			item["source"] = source.String
 		}
 		results = append(results, item)
+		lastID = id
+		lastYear = year.Int64
+	}
+	recordQuery(stats, "ListPublicationsFiltered", len(results), queryStart)
+
+	var nextCursor string
+	if rowCount > limit {
+		nextCursor = encodePublicationsCursor(publicationsCursor{Year: int(lastYear), ID: lastID})
+		next := *r.URL
+		nv := next.Query()
+		nv.Set("cursor", nextCursor)
+		next.RawQuery = nv.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "public, max-age=3600")
-	json.NewEncoder(w).Encode(results)
+	resp := map[string]interface{}{
+		"items":       results,
+		"next_cursor": nextCursor,
+		"total":       total,
+	}
+	json.NewEncoder(w).Encode(withStats(resp, stats))
+}
+
+// HandleAPIPublicationsAggregates returns rollups over a protected
+// area's publications — a yearly count histogram, the most frequent
+// authors, citation-count percentiles, and the other protected areas
+// whose publications share the most OpenAlex work IDs with this one —
+// so a client can build an analytics view without paging through every
+// publication itself.
+// GET /api/parks/{id}/publications/aggregates
+func (s *Server) HandleAPIPublicationsAggregates(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	if paID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "missing park ID"})
+		return
+	}
+
+	ctx := r.Context()
+	stats := statsFromRequest(r)
+
+	queryStart := time.Now()
+	yearRows, err := s.DB.QueryContext(ctx, `
+		SELECT year, COUNT(*) FROM publications
+		WHERE pa_id = ? AND year IS NOT NULL
+		GROUP BY year ORDER BY year
+	`, paID)
+	if err != nil {
+		metrics.DBErrorsTotal.WithLabelValues("api_publications_aggregates").Inc()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+	yearlyHistogram := make(map[string]int)
+	n := 0
+	for yearRows.Next() {
+		var year int64
+		var count int
+		if err := yearRows.Scan(&year, &count); err == nil {
+			yearlyHistogram[strconv.FormatInt(year, 10)] = count
+			n++
+		}
+	}
+	yearRows.Close()
+	recordQuery(stats, "PublicationsYearlyHistogram", n, queryStart)
+
+	queryStart = time.Now()
+	authorRows, err := s.DB.QueryContext(ctx, `
+		SELECT authors, cited_by_count FROM publications WHERE pa_id = ? AND authors IS NOT NULL
+	`, paID)
+	if err != nil {
+		metrics.DBErrorsTotal.WithLabelValues("api_publications_aggregates").Inc()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+	authorCounts := make(map[string]int)
+	var citations []int64
+	n = 0
+	for authorRows.Next() {
+		var authorsJSON sql.NullString
+		var cited sql.NullInt64
+		if err := authorRows.Scan(&authorsJSON, &cited); err != nil {
+			continue
+		}
+		n++
+		if cited.Valid {
+			citations = append(citations, cited.Int64)
+		}
+		if authorsJSON.Valid {
+			var names []string
+			if json.Unmarshal([]byte(authorsJSON.String), &names) == nil {
+				for _, name := range names {
+					authorCounts[name]++
+				}
+			}
+		}
+	}
+	authorRows.Close()
+	recordQuery(stats, "PublicationsAuthorScan", n, queryStart)
+
+	type authorCount struct {
+		Author string `json:"author"`
+		Count  int    `json:"count"`
+	}
+	topAuthors := make([]authorCount, 0, len(authorCounts))
+	for name, count := range authorCounts {
+		topAuthors = append(topAuthors, authorCount{Author: name, Count: count})
+	}
+	sort.Slice(topAuthors, func(i, j int) bool {
+		if topAuthors[i].Count != topAuthors[j].Count {
+			return topAuthors[i].Count > topAuthors[j].Count
+		}
+		return topAuthors[i].Author < topAuthors[j].Author
+	})
+	if len(topAuthors) > 10 {
+		topAuthors = topAuthors[:10]
+	}
+
+	sort.Slice(citations, func(i, j int) bool { return citations[i] < citations[j] })
+	percentile := func(p float64) int64 {
+		if len(citations) == 0 {
+			return 0
+		}
+		idx := int(p * float64(len(citations)-1))
+		return citations[idx]
+	}
+	citationPercentiles := map[string]int64{
+		"p50": percentile(0.50),
+		"p90": percentile(0.90),
+		"p99": percentile(0.99),
+	}
+
+	queryStart = time.Now()
+	coRows, err := s.DB.QueryContext(ctx, `
+		SELECT pa_id, COUNT(*) AS shared
+		FROM publications
+		WHERE openalex_id IN (SELECT openalex_id FROM publications WHERE pa_id = ?)
+		  AND pa_id != ?
+		GROUP BY pa_id
+		ORDER BY shared DESC
+		LIMIT 10
+	`, paID, paID)
+	if err != nil {
+		metrics.DBErrorsTotal.WithLabelValues("api_publications_aggregates").Inc()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+	type coOccurrence struct {
+		ParkID string `json:"park_id"`
+		Shared int    `json:"shared_publications"`
+	}
+	var coOccurring []coOccurrence
+	n = 0
+	for coRows.Next() {
+		var co coOccurrence
+		if err := coRows.Scan(&co.ParkID, &co.Shared); err == nil {
+			coOccurring = append(coOccurring, co)
+			n++
+		}
+	}
+	coRows.Close()
+	recordQuery(stats, "PublicationsCoOccurrence", n, queryStart)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(withStats(map[string]interface{}{
+		"park_id":              paID,
+		"yearly_histogram":     yearlyHistogram,
+		"top_authors":          topAuthors,
+		"citation_percentiles": citationPercentiles,
+		"cooccurring_parks":    coOccurring,
+	}, stats))
 }
 
 // HandleAPIPublicationCount returns the publication count for a PA.
@@ -860,31 +1544,67 @@ func (s *Server) HandleAPIPublicationCount(w http.ResponseWriter, r *http.Reques
 
 	ctx := r.Context()
 	q := dbgen.New(s.DB)
+	stats := statsFromRequest(r)
 
+	queryStart := time.Now()
 	count, err := q.GetPublicationCountByPA(ctx, paID)
+	recordQuery(stats, "GetPublicationCountByPA", 1, queryStart)
 	if err != nil {
+		metrics.DBErrorsTotal.WithLabelValues("api_publication_count").Inc()
 		count = 0
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "public, max-age=300")
-	json.NewEncoder(w).Encode(map[string]interface{}{"count": count})
+	json.NewEncoder(w).Encode(withStats(map[string]interface{}{"count": count}, stats))
 }
 
 // ParkDataStatus represents the processing status for a park's various data sources
 type ParkDataStatus struct {
-	ParkID         string `json:"park_id"`
-	FireAnalysis   *DataSourceStatus `json:"fire_analysis,omitempty"`
+	ParkID           string            `json:"park_id"`
+	FireAnalysis     *DataSourceStatus `json:"fire_analysis,omitempty"`
 	GroupInfractions *DataSourceStatus `json:"group_infractions,omitempty"`
-	Publications   *DataSourceStatus `json:"publications,omitempty"`
-	GHSL           *DataSourceStatus `json:"ghsl,omitempty"`
-	Roadless       *DataSourceStatus `json:"roadless,omitempty"`
+	Publications     *DataSourceStatus `json:"publications,omitempty"`
+	GHSL             *DataSourceStatus `json:"ghsl,omitempty"`
+	Roadless         *DataSourceStatus `json:"roadless,omitempty"`
+	Stats            *QueryStats       `json:"stats,omitempty"`
 }
 
 type DataSourceStatus struct {
-	Ready     bool   `json:"ready"`
-	LastUpdate string `json:"last_update,omitempty"`
-	Message   string `json:"message,omitempty"`
+	Ready      bool     `json:"ready"`
+	LastUpdate string   `json:"last_update,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	Job        *ParkJob `json:"job,omitempty"`
+}
+
+// attachParkJob looks up the most recent ParkJobs job for parkID+source
+// and, if one exists, attaches it to ds and folds its state into
+// Ready/Message — letting GHSL/Roadless (and any source with no rows to
+// count) report live progress instead of a static "Coming soon" once a
+// job has been enqueued for them.
+func attachParkJob(ctx context.Context, s *Server, ds *DataSourceStatus, parkID string, source ParkJobSource) {
+	if s.ParkJobs == nil {
+		return
+	}
+	job, ok, err := s.ParkJobs.LatestForSource(ctx, parkID, source)
+	if err != nil || !ok {
+		return
+	}
+	ds.Job = &job
+	switch job.State {
+	case ParkJobQueued, ParkJobRunning:
+		ds.Message = fmt.Sprintf("%s (%d%%)", job.State, job.Progress)
+	case ParkJobFailed:
+		ds.Message = "job failed: " + job.Error
+	case ParkJobSucceeded:
+		if !ds.Ready {
+			ds.Ready = true
+			ds.Message = ""
+			if job.FinishedAt != nil {
+				ds.LastUpdate = job.FinishedAt.Format(time.RFC3339)
+			}
+		}
+	}
 }
 
 // HandleAPIParkDataStatus returns the processing status for various data sources for a park
@@ -894,7 +1614,22 @@ func (s *Server) HandleAPIParkDataStatus(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
-	
+
+	stats := statsFromRequest(r)
+	status := s.computeParkDataStatus(r.Context(), parkID, stats)
+	status.Stats = stats
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// computeParkDataStatus runs the row-count/job-state checks behind
+// HandleAPIParkDataStatus and returns the result without writing an
+// HTTP response, so HandleAPIParkStatusStream can reuse it to build
+// both the initial SSE event and the snapshot re-sent after a publish
+// (see ParkStatusHub). stats may be nil; recordQuery no-ops on a nil
+// stats the same way it does for callers that didn't pass ?stats=1.
+func (s *Server) computeParkDataStatus(ctx context.Context, parkID string, stats *QueryStats) ParkDataStatus {
 	// Map WDPA ID to internal park_id if needed
 	internalID := parkID
 	if s.AreaStore != nil {
@@ -905,57 +1640,92 @@ func (s *Server) HandleAPIParkDataStatus(w http.ResponseWriter, r *http.Request)
 			}
 		}
 	}
-	
+
 	status := ParkDataStatus{ParkID: parkID}
-	
+
 	// Check fire analysis
 	var fireCount int
 	var fireDate string
-	err := s.DB.QueryRow(`SELECT COUNT(*), MAX(analyzed_at) FROM park_fire_analysis WHERE park_id = ?`, internalID).Scan(&fireCount, &fireDate)
+	queryStart := time.Now()
+	err := s.DB.QueryRowContext(ctx, `SELECT COUNT(*), MAX(analyzed_at) FROM park_fire_analysis WHERE park_id = ?`, internalID).Scan(&fireCount, &fireDate)
+	recordQuery(stats, "park_fire_analysis_status", fireCount, queryStart)
 	if err == nil && fireCount > 0 {
 		status.FireAnalysis = &DataSourceStatus{Ready: true, LastUpdate: fireDate}
 	} else {
 		status.FireAnalysis = &DataSourceStatus{Ready: false, Message: "Fire analysis pending"}
 	}
-	
+	attachParkJob(ctx, s, status.FireAnalysis, parkID, ParkJobSourceFireAnalysis)
+
 	// Check group infractions
 	var groupCount int
 	var groupDate string
-	err = s.DB.QueryRow(`SELECT COUNT(*), MAX(analyzed_at) FROM park_group_infractions WHERE park_id = ?`, internalID).Scan(&groupCount, &groupDate)
+	queryStart = time.Now()
+	err = s.DB.QueryRowContext(ctx, `SELECT COUNT(*), MAX(analyzed_at) FROM park_group_infractions WHERE park_id = ?`, internalID).Scan(&groupCount, &groupDate)
+	recordQuery(stats, "park_group_infractions_status", groupCount, queryStart)
 	if err == nil && groupCount > 0 {
 		status.GroupInfractions = &DataSourceStatus{Ready: true, LastUpdate: groupDate}
 	} else {
 		status.GroupInfractions = &DataSourceStatus{Ready: false, Message: "Group analysis pending"}
 	}
-	
+	attachParkJob(ctx, s, status.GroupInfractions, parkID, ParkJobSourceGroupInfractions)
+
 	// Check publications
 	var pubCount int
 	var pubDate string
-	err = s.DB.QueryRow(`SELECT COUNT(*), MAX(synced_at) FROM pa_publication_sync WHERE pa_id = ?`, parkID).Scan(&pubCount, &pubDate)
+	queryStart = time.Now()
+	err = s.DB.QueryRowContext(ctx, `SELECT COUNT(*), MAX(synced_at) FROM pa_publication_sync WHERE pa_id = ?`, parkID).Scan(&pubCount, &pubDate)
+	recordQuery(stats, "pa_publication_sync_status", pubCount, queryStart)
 	if err == nil && pubCount > 0 {
 		status.Publications = &DataSourceStatus{Ready: true, LastUpdate: pubDate}
 	} else {
 		status.Publications = &DataSourceStatus{Ready: false, Message: "Publication sync pending"}
 	}
-	
-	// GHSL - not implemented yet
+	attachParkJob(ctx, s, status.Publications, parkID, ParkJobSourcePublications)
+
+	// GHSL and roadless have no row count to check yet (no Go-side
+	// pipeline populates them in this repo) — their readiness comes
+	// entirely from whether a ParkJobs job has been enqueued and
+	// succeeded for this park.
 	status.GHSL = &DataSourceStatus{Ready: false, Message: "Coming soon"}
-	
-	// Roadless - not implemented yet
+	attachParkJob(ctx, s, status.GHSL, parkID, ParkJobSourceGHSL)
+
 	status.Roadless = &DataSourceStatus{Ready: false, Message: "Coming soon"}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
+	attachParkJob(ctx, s, status.Roadless, parkID, ParkJobSourceRoadless)
+
+	for source, ds := range map[string]*DataSourceStatus{
+		"fire_analysis":     status.FireAnalysis,
+		"group_infractions": status.GroupInfractions,
+		"publications":      status.Publications,
+		"ghsl":              status.GHSL,
+		"roadless":          status.Roadless,
+	} {
+		ready := 0.0
+		if ds.Ready {
+			ready = 1.0
+		}
+		metrics.ParkDataSourceReady.WithLabelValues(parkID, source).Set(ready)
+	}
+
+	return status
 }
 
-// HandleAPIParkInfractionSummary returns group infraction summary for modal display
-func (s *Server) HandleAPIParkInfractionSummary(w http.ResponseWriter, r *http.Request) {
-	parkID := r.PathValue("id")
-	year := r.URL.Query().Get("year")
-	if year == "" {
-		year = "2023" // Default to most recent full year
-	}
-	
+// InfractionSummary is a year's group infraction totals for one park,
+// the shape both HandleAPIParkInfractionSummary and the report
+// subsystem (see report.go) need.
+type InfractionSummary struct {
+	Year                int     `json:"year"`
+	TotalGroups         int     `json:"total_groups"`
+	GroupsStoppedInside int     `json:"groups_stopped_inside"`
+	GroupsTransited     int     `json:"groups_transited"`
+	AvgDaysBurning      float64 `json:"avg_days_burning"`
+	ResponseRate        float64 `json:"response_rate"` // % stopped inside
+}
+
+// computeInfractionSummary runs the query behind
+// HandleAPIParkInfractionSummary and returns a zero-value summary
+// (rather than an error) when the park has no row for year, matching
+// that handler's "empty result, not a failure" behavior.
+func (s *Server) computeInfractionSummary(ctx context.Context, parkID, year string, stats *QueryStats) InfractionSummary {
 	// Map WDPA ID to internal park_id if needed
 	internalID := parkID
 	if s.AreaStore != nil {
@@ -966,33 +1736,39 @@ func (s *Server) HandleAPIParkInfractionSummary(w http.ResponseWriter, r *http.R
 			}
 		}
 	}
-	
-	var result struct {
-		Year              int     `json:"year"`
-		TotalGroups       int     `json:"total_groups"`
-		GroupsStoppedInside int   `json:"groups_stopped_inside"`
-		GroupsTransited   int     `json:"groups_transited"`
-		AvgDaysBurning    float64 `json:"avg_days_burning"`
-		ResponseRate      float64 `json:"response_rate"` // % stopped inside
-	}
-	
-	err := s.DB.QueryRow(`
+
+	var summary InfractionSummary
+	queryStart := time.Now()
+	err := s.DB.QueryRowContext(ctx, `
 		SELECT year, total_groups, groups_stopped_inside, groups_transited, avg_days_burning
-		FROM park_group_infractions 
+		FROM park_group_infractions
 		WHERE park_id = ? AND year = ?
-	`, internalID, year).Scan(&result.Year, &result.TotalGroups, &result.GroupsStoppedInside, &result.GroupsTransited, &result.AvgDaysBurning)
-	
+	`, internalID, year).Scan(&summary.Year, &summary.TotalGroups, &summary.GroupsStoppedInside, &summary.GroupsTransited, &summary.AvgDaysBurning)
+	recordQuery(stats, "park_group_infractions_summary", summary.TotalGroups, queryStart)
 	if err != nil {
-		// Return empty/zero result rather than error
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(result)
-		return
+		return summary
 	}
-	
-	if result.TotalGroups > 0 {
-		result.ResponseRate = float64(result.GroupsStoppedInside) / float64(result.TotalGroups) * 100
+
+	if summary.TotalGroups > 0 {
+		summary.ResponseRate = float64(summary.GroupsStoppedInside) / float64(summary.TotalGroups) * 100
 	}
-	
+	return summary
+}
+
+// HandleAPIParkInfractionSummary returns group infraction summary for modal display
+func (s *Server) HandleAPIParkInfractionSummary(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	year := r.URL.Query().Get("year")
+	if year == "" {
+		year = "2023" // Default to most recent full year
+	}
+
+	stats := statsFromRequest(r)
+	summary := s.computeInfractionSummary(r.Context(), parkID, year, stats)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(struct {
+		InfractionSummary
+		Stats *QueryStats `json:"stats,omitempty"`
+	}{summary, stats})
 }
@@ -0,0 +1,36 @@
+package srv
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"srv.exe.dev/srv/areas"
+	"srv.exe.dev/srv/pa/wfs"
+)
+
+// StartAreaWFSRefresher periodically rebuilds s.AreaStore from a WFS
+// endpoint (see areas.LoadFromWFS), for operators who point cmd/srv's
+// -wfs flag at a live WDPA WFS instead of shipping static keystones
+// JSON. It follows the same "rebuild off-thread, swap the pointer"
+// shape as StartResearchWorker, just on an operator-chosen interval
+// rather than a fixed 24h.
+func (s *Server) StartAreaWFSRefresher(ctx context.Context, cfg wfs.Config, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			store, err := areas.LoadFromWFS(ctx, cfg)
+			if err != nil {
+				slog.Warn("area wfs refresh failed", "error", err)
+				continue
+			}
+			s.AreaStore = store
+			slog.Info("refreshed protected areas from wfs", "count", len(store.Areas))
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sync"
 )
 
 // KmPerDegree is the approximate km per degree of latitude/longitude.
@@ -23,9 +24,17 @@ type GeoJSONGeometry struct {
 	Type        string          `json:"type"`
 	Coordinates json.RawMessage `json:"coordinates"`
 
-	// Parsed polygon rings (outer ring only for simplicity)
-	// For MultiPolygon, this is the first polygon's outer ring.
-	parsedRings [][][]float64
+	// parsedPolys holds every polygon in the geometry (a Polygon parses to
+	// one, a MultiPolygon to however many it contains). Within each
+	// polygon, ring 0 is the outer boundary and rings 1..N are holes.
+	parsedPolys [][][][]float64
+
+	// EncodeAsWKB selects how MarshalJSON serializes this geometry: false
+	// (the default) emits the usual GeoJSON object, true emits a base64
+	// EWKB string instead, for interop with PostGIS/QGIS-facing clients
+	// that prefer WKB. UnmarshalJSON auto-detects which form it's given
+	// and sets this accordingly, so a round-trip preserves the format.
+	EncodeAsWKB bool `json:"-"`
 }
 
 // ProtectedArea represents a conservation area with polygon geometry.
@@ -78,6 +87,17 @@ type KeystoneWithBoundary struct {
 // AreaStore holds a collection of protected areas for lookup.
 type AreaStore struct {
 	Areas []ProtectedArea
+
+	// index and indexOnce back Index(): the R-tree is built lazily on
+	// first use rather than on load, so callers that never query by
+	// point (e.g. pure CLI tooling) don't pay for it.
+	indexOnce sync.Once
+	index     *RTree
+
+	// geohashOnce and geohashIdx back geohashIndex(): the geohash
+	// bucketing is also built lazily, for the same reason.
+	geohashOnce sync.Once
+	geohashIdx  map[string][]int
 }
 
 // LoadAreas loads protected areas from a JSON file.
@@ -247,106 +267,82 @@ func createCirclePolygon(centerLat, centerLon, radiusKm float64) GeoJSONGeometry
 	return GeoJSONGeometry{
 		Type:        "Polygon",
 		Coordinates: coords,
-		parsedRings: rings,
+		parsedPolys: [][][][]float64{rings},
 	}
 }
 
-// parseGeometry parses the raw coordinates into polygon rings.
+// parseGeometry parses the raw coordinates into parsedPolys: a Polygon
+// becomes a single-element slice, a MultiPolygon keeps every polygon (PAs
+// with disjoint lobes or donut-shaped exclaves are both handled this way).
 func (g *GeoJSONGeometry) parseGeometry() {
-	if g.parsedRings != nil || len(g.Coordinates) == 0 {
+	if g.parsedPolys != nil || len(g.Coordinates) == 0 {
 		return
 	}
 
 	switch g.Type {
 	case "Polygon":
-		// Polygon: [[[lon, lat], ...]]
+		// Polygon: [[[lon, lat], ...], ...] (ring 0 outer, rest holes)
 		var rings [][][]float64
 		if err := json.Unmarshal(g.Coordinates, &rings); err == nil {
-			g.parsedRings = rings
+			g.parsedPolys = [][][][]float64{rings}
 		}
 	case "MultiPolygon":
-		// MultiPolygon: [[[[lon, lat], ...]]]
-		// Find the largest polygon by bounding box area
-		var multiRings [][][][]float64
-		if err := json.Unmarshal(g.Coordinates, &multiRings); err == nil && len(multiRings) > 0 {
-			largestIdx := 0
-			largestArea := 0.0
-			for i, rings := range multiRings {
-				if len(rings) > 0 && len(rings[0]) > 0 {
-					area := bboxArea(rings[0])
-					if area > largestArea {
-						largestArea = area
-						largestIdx = i
-					}
-				}
-			}
-			g.parsedRings = multiRings[largestIdx]
+		// MultiPolygon: [[[[lon, lat], ...], ...], ...]
+		var multi [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &multi); err == nil {
+			g.parsedPolys = multi
 		}
 	}
 }
 
-// bboxArea calculates approximate bounding box area for a ring.
-func bboxArea(ring [][]float64) float64 {
-	if len(ring) == 0 {
-		return 0
-	}
-	minLat, maxLat := ring[0][1], ring[0][1]
-	minLon, maxLon := ring[0][0], ring[0][0]
-	for _, coord := range ring {
-		if coord[1] < minLat {
-			minLat = coord[1]
-		}
-		if coord[1] > maxLat {
-			maxLat = coord[1]
-		}
-		if coord[0] < minLon {
-			minLon = coord[0]
-		}
-		if coord[0] > maxLon {
-			maxLon = coord[0]
-		}
+// getRings returns the outer ring of the geometry's first polygon, for
+// callers that only care about the overall shape and not holes or
+// additional MultiPolygon lobes (kept for backward compatibility).
+func (g *GeoJSONGeometry) getRings() [][]float64 {
+	polys := g.getPolys()
+	if len(polys) == 0 || len(polys[0]) == 0 {
+		return nil
 	}
-	return (maxLat - minLat) * (maxLon - minLon)
+	return polys[0][0]
 }
 
-// getRings returns the parsed polygon rings.
-func (g *GeoJSONGeometry) getRings() [][][]float64 {
+// getPolys returns every parsed polygon, each as outer-ring-then-holes.
+func (g *GeoJSONGeometry) getPolys() [][][][]float64 {
 	g.parseGeometry()
-	return g.parsedRings
+	return g.parsedPolys
 }
 
-// computeBoundingBox calculates and caches the bounding box for fast rejection.
+// computeBoundingBox calculates and caches the bounding box for fast
+// rejection, covering every polygon and ring (holes included, since a
+// hole's vertices still bound the overall shape).
 func (a *ProtectedArea) computeBoundingBox() {
-	rings := a.Geometry.getRings()
-	if len(rings) == 0 {
+	polys := a.Geometry.getPolys()
+	if len(polys) == 0 {
 		return
 	}
 
-	ring := rings[0] // Outer ring
-	if len(ring) == 0 {
-		return
-	}
-
-	bbox := &boundingBox{
-		LatMin: ring[0][1],
-		LatMax: ring[0][1],
-		LonMin: ring[0][0],
-		LonMax: ring[0][0],
-	}
-
-	for _, coord := range ring {
-		lon, lat := coord[0], coord[1]
-		if lat < bbox.LatMin {
-			bbox.LatMin = lat
-		}
-		if lat > bbox.LatMax {
-			bbox.LatMax = lat
-		}
-		if lon < bbox.LonMin {
-			bbox.LonMin = lon
-		}
-		if lon > bbox.LonMax {
-			bbox.LonMax = lon
+	var bbox *boundingBox
+	for _, rings := range polys {
+		for _, ring := range rings {
+			for _, coord := range ring {
+				lon, lat := coord[0], coord[1]
+				if bbox == nil {
+					bbox = &boundingBox{LatMin: lat, LatMax: lat, LonMin: lon, LonMax: lon}
+					continue
+				}
+				if lat < bbox.LatMin {
+					bbox.LatMin = lat
+				}
+				if lat > bbox.LatMax {
+					bbox.LatMax = lat
+				}
+				if lon < bbox.LonMin {
+					bbox.LonMin = lon
+				}
+				if lon > bbox.LonMax {
+					bbox.LonMax = lon
+				}
+			}
 		}
 	}
 
@@ -354,9 +350,11 @@ func (a *ProtectedArea) computeBoundingBox() {
 }
 
 // FindArea finds which protected area contains the given point (with buffer).
-// Returns nil if the point is not within any area.
+// Returns nil if the point is not within any area. Areas can overlap; when
+// more than one matches, FindArea returns whichever the spatial index
+// visits first — use FindAreas if all overlapping areas are needed.
 func (s *AreaStore) FindArea(lat, lon float64) *ProtectedArea {
-	for i := range s.Areas {
+	for _, i := range s.Index().Query(lat, lon) {
 		if s.Areas[i].ContainsPoint(lat, lon) {
 			return &s.Areas[i]
 		}
@@ -364,6 +362,20 @@ func (s *AreaStore) FindArea(lat, lon float64) *ProtectedArea {
 	return nil
 }
 
+// FindAreas returns every protected area containing the given point (with
+// buffer). Unlike FindArea, this doesn't stop at the first match, since
+// WDPA protected areas can legitimately overlap (e.g. a national park
+// nested inside a larger buffer zone).
+func (s *AreaStore) FindAreas(lat, lon float64) []*ProtectedArea {
+	var matches []*ProtectedArea
+	for _, i := range s.Index().Query(lat, lon) {
+		if s.Areas[i].ContainsPoint(lat, lon) {
+			matches = append(matches, &s.Areas[i])
+		}
+	}
+	return matches
+}
+
 // AssignPointsToAreas groups points by the area they fall within.
 // Points not in any area are grouped under the key "outside".
 func (s *AreaStore) AssignPointsToAreas(points []Point) map[string][]Point {
@@ -382,43 +394,95 @@ func (s *AreaStore) AssignPointsToAreas(points []Point) map[string][]Point {
 }
 
 // ContainsPoint checks if a point is within the area's polygon plus buffer.
+// The buffer is applied using great-circle (haversine) distance in
+// meters, not a flat degree approximation, so it stays accurate at high
+// latitudes where a degree of longitude is much shorter than a degree of
+// latitude.
 func (a *ProtectedArea) ContainsPoint(lat, lon float64) bool {
-	// Convert buffer from km to degrees
-	bufferDeg := a.BufferKm / KmPerDegree
-
-	// Fast rejection using bounding box
+	// Fast rejection using a bounding box pre-expanded by the buffer with
+	// a per-latitude longitude delta (see bufferedBBox).
 	if a.bbox != nil {
-		if lat < a.bbox.LatMin-bufferDeg || lat > a.bbox.LatMax+bufferDeg ||
-			lon < a.bbox.LonMin-bufferDeg || lon > a.bbox.LonMax+bufferDeg {
+		buffered := bufferedBBox(a.bbox, a.BufferKm)
+		if !buffered.contains(lat, lon) {
 			return false
 		}
 	}
 
-	rings := a.Geometry.getRings()
+	polys := a.Geometry.getPolys()
 
-	// Check if point is inside the polygon
-	if pointInPolygon(lat, lon, rings) {
+	// Check if point is inside the polygon (holes excluded)
+	if pointInPolygon(lat, lon, polys) {
 		return true
 	}
 
-	// Check if point is within buffer distance of polygon edge
-	if bufferDeg > 0 && pointNearPolygonEdge(lat, lon, rings, bufferDeg) {
+	// Check if point is within buffer distance of any polygon's edge
+	if a.BufferKm > 0 && pointNearPolygonEdge(lat, lon, polys, a.BufferKm*1000) {
 		return true
 	}
 
 	return false
 }
 
-// pointInPolygon checks if a point is inside a polygon using ray casting algorithm.
-// Coordinates are in GeoJSON format: [lon, lat].
-func pointInPolygon(lat, lon float64, rings [][][]float64) bool {
-	if len(rings) == 0 {
-		return false
+// bufferedBBox expands bbox by bufferKm, using a per-latitude longitude
+// delta (bufferKm / (KmPerDegree * cos(lat))) rather than a uniform
+// degree buffer, evaluated at whichever of bbox's latitude extremes is
+// closest to a pole — so the longitude buffer is never too small for any
+// latitude the bbox actually spans.
+func bufferedBBox(bbox *boundingBox, bufferKm float64) boundingBox {
+	if bufferKm == 0 {
+		return *bbox
+	}
+
+	worstLat := bbox.LatMax
+	if math.Abs(bbox.LatMin) > math.Abs(bbox.LatMax) {
+		worstLat = bbox.LatMin
+	}
+
+	return boundingBox{
+		LatMin: bbox.LatMin - bufferKm/KmPerDegree,
+		LatMax: bbox.LatMax + bufferKm/KmPerDegree,
+		LonMin: bbox.LonMin - lonDegreesForKm(bufferKm, worstLat),
+		LonMax: bbox.LonMax + lonDegreesForKm(bufferKm, worstLat),
 	}
+}
 
-	// Check outer ring
-	ring := rings[0]
-	return pointInRing(lat, lon, ring)
+// lonDegreesForKm converts a km distance to degrees of longitude at the
+// given latitude, cos-corrected so the same km buffer covers more degrees
+// near the poles than at the equator. cos(lat) is floored at 0.01 to
+// avoid an unbounded (or NaN, exactly at the pole) longitude delta.
+func lonDegreesForKm(km, lat float64) float64 {
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if cosLat < 0.01 {
+		cosLat = 0.01
+	}
+	return km / (KmPerDegree * cosLat)
+}
+
+// pointInPolygon checks if a point is inside any of the given polygons.
+// A point counts as inside a polygon iff it's inside the outer ring (ring 0)
+// and not inside any of that polygon's holes (rings 1..N) — holes are
+// XORed out via the same ray-casting test used for the outer ring.
+// Coordinates are in GeoJSON format: [lon, lat].
+func pointInPolygon(lat, lon float64, polys [][][][]float64) bool {
+	for _, rings := range polys {
+		if len(rings) == 0 {
+			continue
+		}
+		if !pointInRing(lat, lon, rings[0]) {
+			continue
+		}
+		inHole := false
+		for _, hole := range rings[1:] {
+			if pointInRing(lat, lon, hole) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
 }
 
 // pointInRing uses ray casting algorithm to determine if point is inside a ring.
@@ -447,58 +511,31 @@ func pointInRing(lat, lon float64, ring [][]float64) bool {
 	return inside
 }
 
-// pointNearPolygonEdge checks if a point is within bufferDeg of any polygon edge.
-func pointNearPolygonEdge(lat, lon float64, rings [][][]float64, bufferDeg float64) bool {
-	if len(rings) == 0 {
-		return false
-	}
-
-	ring := rings[0]
-	n := len(ring)
-	if n < 2 {
-		return false
-	}
-
-	bufferSq := bufferDeg * bufferDeg
-
-	for i := 0; i < n-1; i++ {
-		// Check distance from point to line segment
-		x1, y1 := ring[i][0], ring[i][1]
-		x2, y2 := ring[i+1][0], ring[i+1][1]
+// pointNearPolygonEdge checks if a point is within bufferMeters (great-circle
+// distance) of any edge of any ring (outer or hole) of any polygon — a
+// point near a hole's edge is just as much "near the boundary" as one
+// near the outer edge.
+func pointNearPolygonEdge(lat, lon float64, polys [][][][]float64, bufferMeters float64) bool {
+	for _, rings := range polys {
+		for _, ring := range rings {
+			n := len(ring)
+			if n < 2 {
+				continue
+			}
+			for i := 0; i < n-1; i++ {
+				lon1, lat1 := ring[i][0], ring[i][1]
+				lon2, lat2 := ring[i+1][0], ring[i+1][1]
 
-		distSq := pointToSegmentDistanceSq(lon, lat, x1, y1, x2, y2)
-		if distSq <= bufferSq {
-			return true
+				if pointToSegmentDistanceMeters(lat, lon, lat1, lon1, lat2, lon2) <= bufferMeters {
+					return true
+				}
+			}
 		}
 	}
 
 	return false
 }
 
-// pointToSegmentDistanceSq returns the squared distance from point (px, py) to
-// line segment (x1, y1) - (x2, y2).
-func pointToSegmentDistanceSq(px, py, x1, y1, x2, y2 float64) float64 {
-	dx := x2 - x1
-	dy := y2 - y1
-
-	if dx == 0 && dy == 0 {
-		// Segment is a point
-		return (px-x1)*(px-x1) + (py-y1)*(py-y1)
-	}
-
-	// Parameter t for closest point on line
-	t := ((px-x1)*dx + (py-y1)*dy) / (dx*dx + dy*dy)
-
-	// Clamp t to segment
-	t = math.Max(0, math.Min(1, t))
-
-	// Closest point on segment
-	closestX := x1 + t*dx
-	closestY := y1 + t*dy
-
-	return (px-closestX)*(px-closestX) + (py-closestY)*(py-closestY)
-}
-
 // GetBoundingBox returns the bounding box for the area (for backward compatibility).
 func (a *ProtectedArea) GetBoundingBox() (latMin, latMax, lonMin, lonMax float64) {
 	if a.bbox != nil {
@@ -20,7 +20,7 @@ func makeRectPolygon(latMin, latMax, lonMin, lonMax float64) GeoJSONGeometry {
 	return GeoJSONGeometry{
 		Type:        "Polygon",
 		Coordinates: coords,
-		parsedRings: rings,
+		parsedPolys: [][][][]float64{rings},
 	}
 }
 
@@ -40,7 +40,7 @@ func TestPointInPolygon(t *testing.T) {
 	}{
 		{"center of park", -2.25, 34.75, true},
 		{"edge of park", -3.0, 34.0, true},
-		{"within buffer", -3.04, 34.0, true}, // ~4.4km outside, within 5km buffer
+		{"within buffer", -3.04, 34.0, true},  // ~4.4km outside, within 5km buffer
 		{"outside buffer", -3.1, 34.0, false}, // ~11km outside, beyond 5km buffer
 		{"far outside", -10.0, 40.0, false},
 	}
@@ -58,10 +58,10 @@ func TestPointInPolygon(t *testing.T) {
 func TestPointInIrregularPolygon(t *testing.T) {
 	// Test with a triangle-shaped polygon
 	rings := [][][]float64{{
-		{0.0, 0.0},   // bottom left
-		{2.0, 0.0},   // bottom right
-		{1.0, 2.0},   // top center
-		{0.0, 0.0},   // close
+		{0.0, 0.0}, // bottom left
+		{2.0, 0.0}, // bottom right
+		{1.0, 2.0}, // top center
+		{0.0, 0.0}, // close
 	}}
 	coords, _ := json.Marshal(rings)
 	triangle := ProtectedArea{
@@ -70,7 +70,7 @@ func TestPointInIrregularPolygon(t *testing.T) {
 		Geometry: GeoJSONGeometry{
 			Type:        "Polygon",
 			Coordinates: coords,
-			parsedRings: rings,
+			parsedPolys: [][][][]float64{rings},
 		},
 		BufferKm: 0.0,
 	}
@@ -98,6 +98,82 @@ func TestPointInIrregularPolygon(t *testing.T) {
 	}
 }
 
+func TestPointInDonutPolygon(t *testing.T) {
+	// A 10x10 square with a 2x2 hole carved out of its center, like a
+	// protected area with an excluded enclave.
+	rings := [][][]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}},
+	}
+	coords, _ := json.Marshal(rings)
+	donut := ProtectedArea{
+		ID: "donut",
+		Geometry: GeoJSONGeometry{
+			Type:        "Polygon",
+			Coordinates: coords,
+			parsedPolys: [][][][]float64{rings},
+		},
+		BufferKm: 0.0,
+	}
+	donut.computeBoundingBox()
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"outside entirely", 20, 20, false},
+		{"in outer ring, outside hole", 1, 1, true},
+		{"inside the hole", 5, 5, false},
+		{"on far side of outer ring", 9, 9, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := donut.ContainsPoint(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("ContainsPoint(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPolygonDisjointLobes(t *testing.T) {
+	// Two disjoint squares, as a MultiPolygon made of two separate lobes.
+	multi := [][][][]float64{
+		{{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}},
+		{{{10, 10}, {12, 10}, {12, 12}, {10, 12}, {10, 10}}},
+	}
+	coords, _ := json.Marshal(multi)
+	lobes := ProtectedArea{
+		ID: "lobes",
+		Geometry: GeoJSONGeometry{
+			Type:        "MultiPolygon",
+			Coordinates: coords,
+		},
+		BufferKm: 0.0,
+	}
+	lobes.computeBoundingBox()
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"inside first lobe", 1, 1, true},
+		{"inside second lobe", 11, 11, true},
+		{"between the lobes", 5, 5, false},
+		{"far outside", 50, 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lobes.ContainsPoint(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("ContainsPoint(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoadAreas(t *testing.T) {
 	// Create temp file with test data using polygon geometry
 	tmpDir := t.TempDir()
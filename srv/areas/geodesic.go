@@ -0,0 +1,67 @@
+package areas
+
+import "math"
+
+// earthRadiusMeters is the mean Earth radius used for all haversine/
+// cross-track calculations in this package.
+const earthRadiusMeters = 6371000.0
+
+func toRadians(deg float64) float64 { return deg * math.Pi / 180 }
+
+// haversineMeters returns the great-circle distance in meters between two
+// lat/lon points.
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRadians(lat1), toRadians(lat2)
+	dPhi := toRadians(lat2 - lat1)
+	dLambda := toRadians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return earthRadiusMeters * c
+}
+
+// initialBearingRadians returns the initial bearing (radians, clockwise
+// from north) of the great circle from point 1 to point 2.
+func initialBearingRadians(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRadians(lat1), toRadians(lat2)
+	dLambda := toRadians(lon2 - lon1)
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Atan2(y, x)
+}
+
+// pointToSegmentDistanceMeters returns the great-circle distance in
+// meters from (lat, lon) to the nearest point on the segment
+// (lat1,lon1)-(lat2,lon2). It uses the standard cross-track/along-track
+// distance formulas when the perpendicular foot of the point falls
+// within the segment, and falls back to the distance to the nearer
+// endpoint otherwise.
+func pointToSegmentDistanceMeters(lat, lon, lat1, lon1, lat2, lon2 float64) float64 {
+	segLen := haversineMeters(lat1, lon1, lat2, lon2)
+	if segLen == 0 {
+		return haversineMeters(lat, lon, lat1, lon1)
+	}
+
+	d13 := haversineMeters(lat1, lon1, lat, lon) / earthRadiusMeters
+	theta13 := initialBearingRadians(lat1, lon1, lat, lon)
+	theta12 := initialBearingRadians(lat1, lon1, lat2, lon2)
+
+	crossTrack := math.Asin(math.Sin(d13)*math.Sin(theta13-theta12)) * earthRadiusMeters
+
+	cosAlong := math.Cos(d13) / math.Cos(crossTrack/earthRadiusMeters)
+	// Clamp for float rounding right at the endpoints, where cosAlong can
+	// land a hair outside [-1, 1] and make Acos return NaN.
+	cosAlong = math.Max(-1, math.Min(1, cosAlong))
+	alongTrack := math.Acos(cosAlong) * earthRadiusMeters
+
+	if math.Cos(theta13-theta12) < 0 {
+		// The point lies behind the segment start.
+		return haversineMeters(lat, lon, lat1, lon1)
+	}
+	if alongTrack > segLen {
+		return haversineMeters(lat, lon, lat2, lon2)
+	}
+	return math.Abs(crossTrack)
+}
@@ -0,0 +1,44 @@
+package areas
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHighLatitudeBufferExcludesDistantPoint(t *testing.T) {
+	// A park spanning 70.0-70.5N, 10-11E, with a 5km buffer. At this
+	// latitude, cos(70deg) ~= 0.342, so a uniform-degree buffer (the old
+	// behavior) would treat a point ~2x further away (in real ground
+	// distance) as if it were within 5km.
+	park := ProtectedArea{
+		ID:       "arctic-park",
+		Geometry: makeRectPolygon(70.0, 70.5, 10.0, 11.0),
+		BufferKm: 5.0,
+	}
+	park.computeBoundingBox()
+
+	lat := 70.2
+	eastEdgeLon := 11.0
+
+	lonDeltaFor := func(km float64) float64 {
+		return km / (KmPerDegree * math.Cos(lat*math.Pi/180))
+	}
+
+	pointAt2km := eastEdgeLon + lonDeltaFor(2)
+	pointAt10km := eastEdgeLon + lonDeltaFor(10)
+
+	if !park.ContainsPoint(lat, pointAt2km) {
+		t.Errorf("expected point ~2km outside the edge (within the 5km buffer) to be included")
+	}
+	if park.ContainsPoint(lat, pointAt10km) {
+		t.Errorf("expected point ~10km outside the edge (beyond the 5km buffer) to be excluded")
+	}
+}
+
+func TestHaversineMetersKnownDistance(t *testing.T) {
+	// Roughly 111km per degree of latitude at the equator.
+	d := haversineMeters(0, 0, 1, 0)
+	if d < 110000 || d > 112000 {
+		t.Errorf("expected ~111km for 1 degree of latitude at the equator, got %.0fm", d)
+	}
+}
@@ -0,0 +1,311 @@
+package areas
+
+import (
+	"math"
+	"strings"
+)
+
+// geohashPrecision is the number of base32 characters each bucketed
+// cell is keyed by. At 5 characters a cell is roughly 5km x 5km, a
+// reasonable bucket size for WDPA-scale protected areas - tune this
+// constant (like rtreeFanout) rather than exposing it as a runtime
+// option.
+const geohashPrecision = 5
+
+const geohashBitsPerChar = 5
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohashEncode returns the standard base32 geohash for (lat, lon) at
+// the given number of characters, bit-interleaving longitude (on even
+// bits, starting with the first) and latitude the way the de facto
+// geohash.org format does.
+func geohashEncode(lat, lon float64, precision int) string {
+	latMin, latMax := -90.0, 90.0
+	lonMin, lonMax := -180.0, 180.0
+
+	hash := make([]byte, 0, precision)
+	evenBit := true
+	idx, bitCount := 0, 0
+
+	for len(hash) < precision {
+		if evenBit {
+			mid := (lonMin + lonMax) / 2
+			if lon >= mid {
+				idx = idx*2 + 1
+				lonMin = mid
+			} else {
+				idx = idx * 2
+				lonMax = mid
+			}
+		} else {
+			mid := (latMin + latMax) / 2
+			if lat >= mid {
+				idx = idx*2 + 1
+				latMin = mid
+			} else {
+				idx = idx * 2
+				latMax = mid
+			}
+		}
+		evenBit = !evenBit
+
+		bitCount++
+		if bitCount == geohashBitsPerChar {
+			hash = append(hash, geohashBase32[idx])
+			bitCount, idx = 0, 0
+		}
+	}
+
+	return string(hash)
+}
+
+// geohashCellSize returns the lat/lon span, in degrees, of every cell
+// at the given precision. Unlike a decoded cell's bounds, this doesn't
+// depend on location: bit-interleaving always hands longitude
+// ceil(totalBits/2) bits and latitude the remaining floor(totalBits/2),
+// so every cell at a given precision has the same size.
+func geohashCellSize(precision int) (latSpan, lonSpan float64) {
+	totalBits := geohashBitsPerChar * precision
+	lonBits := (totalBits + 1) / 2
+	latBits := totalBits / 2
+	return 180.0 / math.Pow(2, float64(latBits)), 360.0 / math.Pow(2, float64(lonBits))
+}
+
+// cellsForBBox returns the geohash cells (at the given precision) that
+// bbox overlaps, found by walking a grid of cell-sized steps across it
+// and encoding each grid point. Doesn't handle a bbox crossing the
+// antimeridian, matching this package's existing bbox simplifications
+// (see bufferedBBox).
+func cellsForBBox(bbox boundingBox, precision int) []string {
+	latSpan, lonSpan := geohashCellSize(precision)
+
+	seen := make(map[string]bool)
+	var cells []string
+	for lat := bbox.LatMin; lat <= bbox.LatMax+latSpan; lat += latSpan {
+		clamped := math.Max(-90, math.Min(90, lat))
+		for lon := bbox.LonMin; lon <= bbox.LonMax+lonSpan; lon += lonSpan {
+			h := geohashEncode(clamped, lon, precision)
+			if !seen[h] {
+				seen[h] = true
+				cells = append(cells, h)
+			}
+		}
+	}
+	return cells
+}
+
+// geohashDecodeBounds reverses geohashEncode, returning the bounding
+// box a cell hash represents.
+func geohashDecodeBounds(hash string) boundingBox {
+	latMin, latMax := -90.0, 90.0
+	lonMin, lonMax := -180.0, 180.0
+	evenBit := true
+
+	for _, c := range hash {
+		charIdx := strings.IndexRune(geohashBase32, c)
+		if charIdx < 0 {
+			continue
+		}
+		for bit := geohashBitsPerChar - 1; bit >= 0; bit-- {
+			bitSet := (charIdx>>uint(bit))&1 == 1
+			if evenBit {
+				mid := (lonMin + lonMax) / 2
+				if bitSet {
+					lonMin = mid
+				} else {
+					lonMax = mid
+				}
+			} else {
+				mid := (latMin + latMax) / 2
+				if bitSet {
+					latMin = mid
+				} else {
+					latMax = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+
+	return boundingBox{LatMin: latMin, LatMax: latMax, LonMin: lonMin, LonMax: lonMax}
+}
+
+// bboxOverlaps reports whether two bounding boxes share any area.
+func bboxOverlaps(a, b boundingBox) bool {
+	return a.LatMin <= b.LatMax && a.LatMax >= b.LatMin && a.LonMin <= b.LonMax && a.LonMax >= b.LonMin
+}
+
+// geohashIndex lazily builds (once) and returns an inverted index from
+// geohash cell to the indices (into AreaStore.Areas) of every area
+// whose buffer-expanded bounding box overlaps that cell.
+func (s *AreaStore) geohashIndex() map[string][]int {
+	s.geohashOnce.Do(func() {
+		idx := make(map[string][]int)
+		for i := range s.Areas {
+			if s.Areas[i].bbox == nil {
+				continue
+			}
+			buffered := bufferedBBox(s.Areas[i].bbox, s.Areas[i].BufferKm)
+			for _, cell := range cellsForBBox(buffered, geohashPrecision) {
+				idx[cell] = append(idx[cell], i)
+			}
+		}
+		s.geohashIdx = idx
+	})
+	return s.geohashIdx
+}
+
+// FindAreaByGeohash finds which protected area contains (lat, lon),
+// using the geohash bucket index to narrow the candidates down before
+// running the exact ContainsPoint check - an alternative to
+// FindArea's R-tree lookup, useful when the caller already has a
+// geohash-keyed cache or tile layer built around the same cells.
+func (s *AreaStore) FindAreaByGeohash(lat, lon float64) *ProtectedArea {
+	cell := geohashEncode(lat, lon, geohashPrecision)
+	for _, i := range s.geohashIndex()[cell] {
+		if s.Areas[i].ContainsPoint(lat, lon) {
+			return &s.Areas[i]
+		}
+	}
+	return nil
+}
+
+// AreasInTile returns every protected area whose buffer-expanded
+// bounding box overlaps the given slippy-map tile (z/x/y, standard Web
+// Mercator tiling), for serving a tile-based map layer without scanning
+// every loaded area.
+//
+// A low-zoom tile can span the whole world, so this walks the index's
+// own (already-built) cell keys and checks each cell's decoded bounds
+// against the tile, rather than gridding the tile's bbox at cell
+// resolution the way cellsForBBox does for a (normally much smaller)
+// area bbox - gridding a world-sized bbox at ~5km cells would be tens
+// of millions of steps.
+func (s *AreaStore) AreasInTile(z, x, y int) []*ProtectedArea {
+	tileBBox := tileBounds(z, x, y)
+	idx := s.geohashIndex()
+
+	seen := make(map[int]bool)
+	var result []*ProtectedArea
+	for cell, areaIdxs := range idx {
+		if !bboxOverlaps(geohashDecodeBounds(cell), tileBBox) {
+			continue
+		}
+		for _, i := range areaIdxs {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			buffered := bufferedBBox(s.Areas[i].bbox, s.Areas[i].BufferKm)
+			if bboxOverlaps(buffered, tileBBox) {
+				result = append(result, &s.Areas[i])
+			}
+		}
+	}
+	return result
+}
+
+// tileBounds returns the lat/lon bounding box of slippy-map tile
+// z/x/y, using the standard Web Mercator tile-to-degrees formulas.
+func tileBounds(z, x, y int) boundingBox {
+	n := math.Pow(2, float64(z))
+	lonMin := float64(x)/n*360.0 - 180.0
+	lonMax := float64(x+1)/n*360.0 - 180.0
+	latMax := mercatorTileLat(float64(y) / n)
+	latMin := mercatorTileLat(float64(y+1) / n)
+	return boundingBox{LatMin: latMin, LatMax: latMax, LonMin: lonMin, LonMax: lonMax}
+}
+
+// mercatorTileLat converts a tile's fractional y-coordinate (0 at the
+// north edge of the world, 1 at the south) into a latitude in degrees.
+func mercatorTileLat(yFrac float64) float64 {
+	rad := math.Atan(math.Sinh(math.Pi * (1 - 2*yFrac)))
+	return rad * 180.0 / math.Pi
+}
+
+// NearestArea returns the protected area whose polygon edge is closest
+// to (lat, lon) by great-circle distance, and that distance in meters,
+// searching no further than maxKm. Returns (nil, 0) if nothing is
+// within range. A point already inside an area (polygon or buffer) has
+// distance 0.
+//
+// The search starts from the geohash cell containing the point and
+// doubles its search radius until a candidate turns up, rather than
+// walking cell-by-cell rings outward - a point just inside a cell edge
+// can have its nearest area sitting in the next cell over, so each
+// pass re-queries a full bounding box rather than just the newly added
+// ring. This can occasionally settle for a candidate that isn't quite
+// the true nearest, if a closer area's edge sits just outside the
+// radius where the first candidate was found; for proximity lookups
+// this is an acceptable trade-off against re-scanning the whole store.
+func (s *AreaStore) NearestArea(lat, lon, maxKm float64) (*ProtectedArea, float64) {
+	if maxKm <= 0 {
+		return nil, 0
+	}
+
+	var best *ProtectedArea
+	bestMeters := math.Inf(1)
+
+	latSpan, _ := geohashCellSize(geohashPrecision)
+	startKm := latSpan * KmPerDegree
+	if startKm <= 0 {
+		startKm = 1
+	}
+
+	for radiusKm := startKm; ; radiusKm *= 2 {
+		bbox := boundingBox{
+			LatMin: lat - radiusKm/KmPerDegree,
+			LatMax: lat + radiusKm/KmPerDegree,
+			LonMin: lon - lonDegreesForKm(radiusKm, lat),
+			LonMax: lon + lonDegreesForKm(radiusKm, lat),
+		}
+
+		seen := make(map[int]bool)
+		for _, cell := range cellsForBBox(bbox, geohashPrecision) {
+			for _, i := range s.geohashIndex()[cell] {
+				if seen[i] {
+					continue
+				}
+				seen[i] = true
+				if d := distanceToAreaMeters(lat, lon, &s.Areas[i]); d < bestMeters {
+					bestMeters = d
+					best = &s.Areas[i]
+				}
+			}
+		}
+
+		if best != nil || radiusKm >= maxKm {
+			break
+		}
+	}
+
+	if best == nil || bestMeters > maxKm*1000 {
+		return nil, 0
+	}
+	return best, bestMeters
+}
+
+// distanceToAreaMeters returns the great-circle distance from (lat,
+// lon) to area's nearest polygon edge, or 0 if the point is already
+// inside the polygon itself.
+func distanceToAreaMeters(lat, lon float64, area *ProtectedArea) float64 {
+	polys := area.Geometry.getPolys()
+	if pointInPolygon(lat, lon, polys) {
+		return 0
+	}
+
+	best := math.Inf(1)
+	for _, rings := range polys {
+		for _, ring := range rings {
+			n := len(ring)
+			for i := 0; i < n-1; i++ {
+				lon1, lat1 := ring[i][0], ring[i][1]
+				lon2, lat2 := ring[i+1][0], ring[i+1][1]
+				if d := pointToSegmentDistanceMeters(lat, lon, lat1, lon1, lat2, lon2); d < best {
+					best = d
+				}
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,86 @@
+package areas
+
+import "testing"
+
+func TestGeohashEncodeKnownValue(t *testing.T) {
+	// A well-known reference value for this encoding (e.g. used in
+	// geohash.org examples and most geohash library test suites).
+	got := geohashEncode(57.64911, 10.40744, 9)
+	want := "u4pruydqq"
+	if got != want {
+		t.Errorf("geohashEncode(57.64911, 10.40744, 9) = %q, want %q", got, want)
+	}
+}
+
+func TestFindAreaByGeohashMatchesFindArea(t *testing.T) {
+	park1 := ProtectedArea{ID: "park1", Geometry: makeRectPolygon(0, 1, 0, 1), BufferKm: 1}
+	park2 := ProtectedArea{ID: "park2", Geometry: makeRectPolygon(5, 6, 5, 6), BufferKm: 1}
+	store := newTestAreaStore(park1, park2)
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		wantID   string
+	}{
+		{"in park1", 0.5, 0.5, "park1"},
+		{"in park2", 5.5, 5.5, "park2"},
+		{"outside both", 20, 20, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := store.FindAreaByGeohash(tt.lat, tt.lon)
+			if tt.wantID == "" {
+				if got != nil {
+					t.Errorf("expected no match, got %s", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != tt.wantID {
+				t.Errorf("expected %s, got %v", tt.wantID, got)
+			}
+		})
+	}
+}
+
+func TestAreasInTileFindsOverlappingArea(t *testing.T) {
+	// Tile 0/0/0 covers the whole world, so every loaded area overlaps it.
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(0, 1, 0, 1)}
+	store := newTestAreaStore(park)
+
+	result := store.AreasInTile(0, 0, 0)
+	if len(result) != 1 || result[0].ID != "park" {
+		t.Errorf("expected the world tile to contain park, got %v", result)
+	}
+
+	// A tile far from the equator/prime-meridian quadrant the park sits
+	// in shouldn't match.
+	empty := store.AreasInTile(4, 15, 0)
+	if len(empty) != 0 {
+		t.Errorf("expected no overlap for a distant tile, got %v", empty)
+	}
+}
+
+func TestNearestAreaFindsClosestWithinRange(t *testing.T) {
+	near := ProtectedArea{ID: "near", Geometry: makeRectPolygon(0, 1, 0, 1)}
+	far := ProtectedArea{ID: "far", Geometry: makeRectPolygon(10, 11, 10, 11)}
+	store := newTestAreaStore(near, far)
+
+	area, dist := store.NearestArea(1.01, 0.5, 50)
+	if area == nil || area.ID != "near" {
+		t.Fatalf("expected to find 'near', got %v", area)
+	}
+	if dist <= 0 || dist > 5000 {
+		t.Errorf("expected a small positive distance, got %.0fm", dist)
+	}
+}
+
+func TestNearestAreaOutOfRange(t *testing.T) {
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(0, 1, 0, 1)}
+	store := newTestAreaStore(park)
+
+	area, _ := store.NearestArea(50, 50, 10)
+	if area != nil {
+		t.Errorf("expected no match beyond maxKm, got %v", area)
+	}
+}
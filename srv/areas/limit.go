@@ -0,0 +1,275 @@
+package areas
+
+import "math"
+
+// Limiter restricts imported/tracked geometry to the union of a fixed
+// set of protected areas (plus their buffers), the way imposm3's
+// "limit to" option restricts an OSM import to a region of interest.
+// Patrol analysts use it to split a GPS track into the pieces that fall
+// inside a park, so "effort inside" can be measured without a PostGIS
+// job.
+type Limiter struct {
+	areas []*ProtectedArea
+}
+
+// NewLimiter builds a Limiter over the given area IDs. IDs that don't
+// match any loaded area are silently skipped, since a saved limiter
+// config can outlive an area being renamed or retired.
+func (s *AreaStore) NewLimiter(ids []string) *Limiter {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	l := &Limiter{}
+	for i := range s.Areas {
+		if wanted[s.Areas[i].ID] {
+			l.areas = append(l.areas, &s.Areas[i])
+		}
+	}
+	return l
+}
+
+// contains reports whether (lat, lon) falls inside any of the
+// Limiter's areas, buffer included.
+func (l *Limiter) contains(lat, lon float64) bool {
+	for _, area := range l.areas {
+		if area.ContainsPoint(lat, lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClipLineString splits pts at the boundary of the Limiter's areas and
+// returns only the pieces that fall inside. A track that crosses in and
+// out several times comes back as several pieces; a track that never
+// enters any area comes back empty.
+//
+// Crossings are located with an exact parametric segment-edge
+// intersection against each area's polygon edges. That's only exact for
+// the polygon boundary itself — an area's buffer has no straight edges
+// (it's a rounded offset of the polygon), so a transition that happens
+// entirely within the buffer strip, with no polygon edge crossing on
+// the segment, falls back to bisecting the segment against
+// ContainsPoint until it converges on the buffer boundary.
+func (l *Limiter) ClipLineString(pts []Point) [][]Point {
+	if len(pts) == 0 {
+		return nil
+	}
+
+	var pieces [][]Point
+	var current []Point
+
+	wasInside := l.contains(pts[0].Lat, pts[0].Lon)
+	if wasInside {
+		current = append(current, pts[0])
+	}
+
+	for i := 0; i+1 < len(pts); i++ {
+		p1, p2 := pts[i], pts[i+1]
+		isInside := l.contains(p2.Lat, p2.Lon)
+
+		if isInside == wasInside {
+			if isInside {
+				current = append(current, p2)
+			}
+			continue
+		}
+
+		cross, ok := l.edgeCrossing(p1, p2)
+		if !ok {
+			cross = l.bisectCrossing(p1, p2, wasInside)
+		}
+
+		if wasInside {
+			current = append(current, cross)
+			pieces = append(pieces, current)
+			current = nil
+		} else {
+			current = []Point{cross, p2}
+		}
+		wasInside = isInside
+	}
+
+	if wasInside && len(current) > 1 {
+		pieces = append(pieces, current)
+	}
+
+	return pieces
+}
+
+// edgeCrossing finds where segment (p1, p2) crosses a polygon edge of
+// one of the Limiter's areas, picking whichever crossing is nearest p1
+// if the segment happens to cross more than one edge.
+func (l *Limiter) edgeCrossing(p1, p2 Point) (Point, bool) {
+	bestT := math.Inf(1)
+	var best Point
+	found := false
+
+	for _, area := range l.areas {
+		for _, rings := range area.Geometry.getPolys() {
+			for _, ring := range rings {
+				n := len(ring)
+				for i := 0; i < n-1; i++ {
+					a := Point{Lon: ring[i][0], Lat: ring[i][1]}
+					b := Point{Lon: ring[i+1][0], Lat: ring[i+1][1]}
+					if pt, t, ok := segmentIntersection(p1, p2, a, b); ok && t < bestT {
+						bestT, best, found = t, pt, true
+					}
+				}
+			}
+		}
+	}
+
+	return best, found
+}
+
+// bisectCrossing locates an inside/outside transition that edgeCrossing
+// couldn't pin to a polygon edge (a buffer-only transition). wasInside
+// says which endpoint is inside to start; 20 halvings converge to well
+// under a meter of error for any track segment length this package
+// would realistically see.
+func (l *Limiter) bisectCrossing(p1, p2 Point, wasInside bool) Point {
+	lo, hi := p1, p2
+	if !wasInside {
+		lo, hi = p2, p1
+	}
+	for i := 0; i < 20; i++ {
+		mid := Point{Lat: (lo.Lat + hi.Lat) / 2, Lon: (lo.Lon + hi.Lon) / 2}
+		if l.contains(mid.Lat, mid.Lon) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// segmentIntersection returns where segment (p1, p2) crosses segment
+// (a, b), the parameter t along (p1, p2) at which it does, and whether
+// such a crossing exists within both segments.
+func segmentIntersection(p1, p2, a, b Point) (pt Point, t float64, ok bool) {
+	d1x, d1y := p2.Lon-p1.Lon, p2.Lat-p1.Lat
+	d2x, d2y := b.Lon-a.Lon, b.Lat-a.Lat
+
+	denom := d1x*d2y - d1y*d2x
+	if denom == 0 {
+		return Point{}, 0, false
+	}
+
+	t = ((a.Lon-p1.Lon)*d2y - (a.Lat-p1.Lat)*d2x) / denom
+	u := ((a.Lon-p1.Lon)*d1y - (a.Lat-p1.Lat)*d1x) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return Point{}, 0, false
+	}
+
+	return Point{Lat: p1.Lat + t*d1y, Lon: p1.Lon + t*d1x}, t, true
+}
+
+// ClipPolygon clips rings' outer boundary against each outer ring of
+// the Limiter's areas in turn, using Sutherland-Hodgman, and returns
+// one result polygon (a single ring) per area whose clip keeps any
+// area. Holes in either the subject or the clip polygon aren't
+// considered — Sutherland-Hodgman only clips a simple ring against a
+// simple ring — so this is exact for areas without holes and ignores
+// the hole for areas that have one. Clip rings are assumed wound
+// counterclockwise, per the GeoJSON right-hand rule.
+func (l *Limiter) ClipPolygon(rings [][][]float64) [][][][]float64 {
+	if len(rings) == 0 {
+		return nil
+	}
+	subject := ringToPoints(rings[0])
+
+	var result [][][][]float64
+	for _, area := range l.areas {
+		for _, areaRings := range area.Geometry.getPolys() {
+			if len(areaRings) == 0 {
+				continue
+			}
+			clipped := sutherlandHodgman(subject, ringToPoints(areaRings[0]))
+			if len(clipped) < 3 {
+				continue
+			}
+			result = append(result, [][][]float64{pointsToRing(clipped)})
+		}
+	}
+	return result
+}
+
+// sutherlandHodgman clips subject against the convex polygon clip,
+// assumed wound counterclockwise, one clip edge at a time.
+func sutherlandHodgman(subject, clip []Point) []Point {
+	output := subject
+	n := len(clip)
+	for i := 0; i < n && len(output) > 0; i++ {
+		a, b := clip[i], clip[(i+1)%n]
+		input := output
+		output = nil
+		m := len(input)
+		for j := 0; j < m; j++ {
+			cur := input[j]
+			prev := input[(j-1+m)%m]
+			curInside := isLeft(a, b, cur) >= 0
+			prevInside := isLeft(a, b, prev) >= 0
+			switch {
+			case curInside && prevInside:
+				output = append(output, cur)
+			case curInside && !prevInside:
+				output = append(output, lineIntersect(prev, cur, a, b), cur)
+			case !curInside && prevInside:
+				output = append(output, lineIntersect(prev, cur, a, b))
+			}
+		}
+	}
+	return output
+}
+
+// isLeft is positive when p is left of (inside, for a counterclockwise
+// ring) the directed edge a->b, negative when right, zero when on it.
+func isLeft(a, b, p Point) float64 {
+	return (b.Lon-a.Lon)*(p.Lat-a.Lat) - (b.Lat-a.Lat)*(p.Lon-a.Lon)
+}
+
+// lineIntersect returns where the infinite line through (p1, p2)
+// crosses the infinite line through (a, b). Sutherland-Hodgman needs
+// the clip edge treated as a full line, not a bounded segment, since
+// the crossing point it's looking for can fall outside (a, b)'s own
+// span.
+func lineIntersect(p1, p2, a, b Point) Point {
+	d1x, d1y := p2.Lon-p1.Lon, p2.Lat-p1.Lat
+	d2x, d2y := b.Lon-a.Lon, b.Lat-a.Lat
+
+	denom := d1x*d2y - d1y*d2x
+	if denom == 0 {
+		return p2
+	}
+
+	t := ((a.Lon-p1.Lon)*d2y - (a.Lat-p1.Lat)*d2x) / denom
+	return Point{Lat: p1.Lat + t*d1y, Lon: p1.Lon + t*d1x}
+}
+
+// ringToPoints converts a GeoJSON [lon, lat] ring into Points, dropping
+// the closing duplicate vertex that Sutherland-Hodgman doesn't want.
+func ringToPoints(ring [][]float64) []Point {
+	pts := make([]Point, len(ring))
+	for i, c := range ring {
+		pts[i] = Point{Lon: c[0], Lat: c[1]}
+	}
+	if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	return pts
+}
+
+// pointsToRing converts Points back into a closed GeoJSON [lon, lat] ring.
+func pointsToRing(pts []Point) [][]float64 {
+	if len(pts) == 0 {
+		return nil
+	}
+	ring := make([][]float64, 0, len(pts)+1)
+	for _, p := range pts {
+		ring = append(ring, []float64{p.Lon, p.Lat})
+	}
+	return append(ring, ring[0])
+}
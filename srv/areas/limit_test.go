@@ -0,0 +1,106 @@
+package areas
+
+import "testing"
+
+func newTestAreaStore(areas ...ProtectedArea) *AreaStore {
+	for i := range areas {
+		areas[i].computeBoundingBox()
+	}
+	return &AreaStore{Areas: areas}
+}
+
+func TestClipLineStringEntersAndExits(t *testing.T) {
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(0.0, 2.0, 0.0, 2.0)}
+	store := newTestAreaStore(park)
+	limiter := store.NewLimiter([]string{"park"})
+
+	// Runs west to east along lat=1, starting and ending outside the park.
+	track := []Point{
+		{Lat: 1, Lon: -1},
+		{Lat: 1, Lon: 1},
+		{Lat: 1, Lon: 3},
+	}
+
+	pieces := limiter.ClipLineString(track)
+	if len(pieces) != 1 {
+		t.Fatalf("expected 1 piece, got %d: %v", len(pieces), pieces)
+	}
+
+	piece := pieces[0]
+	if piece[0].Lon < -0.001 || piece[0].Lon > 0.001 {
+		t.Errorf("expected piece to start at the west edge (lon=0), got %v", piece[0])
+	}
+	last := piece[len(piece)-1]
+	if last.Lon < 1.999 || last.Lon > 2.001 {
+		t.Errorf("expected piece to end at the east edge (lon=2), got %v", last)
+	}
+}
+
+func TestClipLineStringNeverEnters(t *testing.T) {
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(0.0, 2.0, 0.0, 2.0)}
+	store := newTestAreaStore(park)
+	limiter := store.NewLimiter([]string{"park"})
+
+	track := []Point{{Lat: 10, Lon: 10}, {Lat: 11, Lon: 11}}
+	if pieces := limiter.ClipLineString(track); len(pieces) != 0 {
+		t.Errorf("expected no pieces for a track that never enters the park, got %v", pieces)
+	}
+}
+
+func TestClipLineStringBufferOnlyTransition(t *testing.T) {
+	// A track that runs parallel to the park's east edge, just inside the
+	// buffer the whole way - no polygon edge crossing on the segment, so
+	// the bisection fallback is what has to find the transition.
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(0.0, 2.0, 0.0, 2.0), BufferKm: 5.0}
+	store := newTestAreaStore(park)
+	limiter := store.NewLimiter([]string{"park"})
+
+	track := []Point{
+		{Lat: -1, Lon: 2.02},
+		{Lat: 1, Lon: 2.02},
+		{Lat: 3, Lon: 2.02},
+	}
+
+	pieces := limiter.ClipLineString(track)
+	if len(pieces) != 1 {
+		t.Fatalf("expected 1 piece, got %d: %v", len(pieces), pieces)
+	}
+}
+
+func TestClipPolygonSubsetOfClipArea(t *testing.T) {
+	// Clipping a polygon that fully contains the park against the park
+	// should hand back (approximately) the park's own boundary.
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(0.0, 2.0, 0.0, 2.0)}
+	store := newTestAreaStore(park)
+	limiter := store.NewLimiter([]string{"park"})
+
+	bigSquare := [][][]float64{{
+		{-5, -5}, {5, -5}, {5, 5}, {-5, 5}, {-5, -5},
+	}}
+
+	clipped := limiter.ClipPolygon(bigSquare)
+	if len(clipped) != 1 {
+		t.Fatalf("expected 1 clipped polygon, got %d", len(clipped))
+	}
+
+	ring := clipped[0][0]
+	for _, c := range ring {
+		if c[0] < -0.001 || c[0] > 2.001 || c[1] < -0.001 || c[1] > 2.001 {
+			t.Errorf("clipped point %v falls outside the park bounds", c)
+		}
+	}
+}
+
+func TestClipPolygonDisjointAreas(t *testing.T) {
+	park := ProtectedArea{ID: "park", Geometry: makeRectPolygon(10.0, 12.0, 10.0, 12.0)}
+	store := newTestAreaStore(park)
+	limiter := store.NewLimiter([]string{"park"})
+
+	farAwaySquare := [][][]float64{{
+		{-2, -2}, {-1, -2}, {-1, -1}, {-2, -1}, {-2, -2},
+	}}
+
+	if clipped := limiter.ClipPolygon(farAwaySquare); len(clipped) != 0 {
+		t.Errorf("expected no clipped polygons for a disjoint subject, got %d", len(clipped))
+	}
+}
@@ -0,0 +1,187 @@
+package areas
+
+import (
+	"math"
+	"sort"
+)
+
+// rtreeFanout is the target number of children per R-tree node, both at
+// the leaf level (area indices per leaf) and at internal levels. 16 keeps
+// tree depth shallow for WDPA-scale area counts while still pruning most
+// of the tree on a typical point query.
+const rtreeFanout = 16
+
+// rtreeNode is either an internal node (children set, items nil) or a
+// leaf node (items set, children nil). bbox is the union of everything
+// beneath it, so a query only descends into nodes whose bbox could
+// possibly contain the point.
+type rtreeNode struct {
+	bbox     boundingBox
+	children []*rtreeNode
+	items    []int
+}
+
+// RTree is a packed, bulk-loaded spatial index over an AreaStore's
+// areas, built once via AreaStore.Index() and reused for every
+// subsequent point query.
+type RTree struct {
+	root *rtreeNode
+}
+
+// contains reports whether (lat, lon) falls within bbox.
+func (b boundingBox) contains(lat, lon float64) bool {
+	return lat >= b.LatMin && lat <= b.LatMax && lon >= b.LonMin && lon <= b.LonMax
+}
+
+// rtreeEntry is a bulk-loading input: the area's index into AreaStore.Areas
+// plus its buffer-expanded bounding box and centroid.
+type rtreeEntry struct {
+	idx       int
+	bbox      boundingBox
+	centerLat float64
+	centerLon float64
+}
+
+// buildRTree bulk-loads an R-tree over areas using the sort-tile-recursive
+// (STR) algorithm: sort by centroid longitude into vertical slices, sort
+// each slice by centroid latitude, and cut every rtreeFanout entries into
+// a leaf. Parent levels are built the same way, grouping sibling nodes by
+// rtreeFanout until a single root remains.
+func buildRTree(areas []ProtectedArea) *RTree {
+	entries := make([]rtreeEntry, 0, len(areas))
+	for i := range areas {
+		bbox := areas[i].bbox
+		if bbox == nil {
+			continue
+		}
+		expanded := bufferedBBox(bbox, areas[i].BufferKm)
+		entries = append(entries, rtreeEntry{
+			idx:       i,
+			bbox:      expanded,
+			centerLat: (expanded.LatMin + expanded.LatMax) / 2,
+			centerLon: (expanded.LonMin + expanded.LonMax) / 2,
+		})
+	}
+
+	if len(entries) == 0 {
+		return &RTree{}
+	}
+
+	leaves := strPartitionLeaves(entries)
+	nodes := make([]*rtreeNode, len(leaves))
+	for i, group := range leaves {
+		nodes[i] = leafNode(group)
+	}
+	for len(nodes) > 1 {
+		nodes = rtreeLevelUp(nodes)
+	}
+
+	return &RTree{root: nodes[0]}
+}
+
+// strPartitionLeaves implements the STR bulk-loading tiling: ceil(sqrt(numLeaves))
+// vertical slices by centroid longitude, then each slice cut into
+// rtreeFanout-sized groups by centroid latitude.
+func strPartitionLeaves(entries []rtreeEntry) [][]rtreeEntry {
+	numLeaves := int(math.Ceil(float64(len(entries)) / float64(rtreeFanout)))
+	numSlices := int(math.Ceil(math.Sqrt(float64(numLeaves))))
+	if numSlices < 1 {
+		numSlices = 1
+	}
+	sliceSize := int(math.Ceil(float64(len(entries)) / float64(numSlices)))
+
+	sorted := make([]rtreeEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].centerLon < sorted[j].centerLon })
+
+	var leaves [][]rtreeEntry
+	for start := 0; start < len(sorted); start += sliceSize {
+		end := start + sliceSize
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+		slice := sorted[start:end]
+		sort.Slice(slice, func(i, j int) bool { return slice[i].centerLat < slice[j].centerLat })
+
+		for s := 0; s < len(slice); s += rtreeFanout {
+			e := s + rtreeFanout
+			if e > len(slice) {
+				e = len(slice)
+			}
+			leaves = append(leaves, slice[s:e])
+		}
+	}
+	return leaves
+}
+
+func leafNode(group []rtreeEntry) *rtreeNode {
+	node := &rtreeNode{items: make([]int, len(group))}
+	node.bbox = group[0].bbox
+	for i, e := range group {
+		node.items[i] = e.idx
+		node.bbox = unionBBox(node.bbox, e.bbox)
+	}
+	return node
+}
+
+func rtreeLevelUp(nodes []*rtreeNode) []*rtreeNode {
+	var parents []*rtreeNode
+	for s := 0; s < len(nodes); s += rtreeFanout {
+		e := s + rtreeFanout
+		if e > len(nodes) {
+			e = len(nodes)
+		}
+		group := nodes[s:e]
+		parent := &rtreeNode{children: group, bbox: group[0].bbox}
+		for _, child := range group[1:] {
+			parent.bbox = unionBBox(parent.bbox, child.bbox)
+		}
+		parents = append(parents, parent)
+	}
+	return parents
+}
+
+func unionBBox(a, b boundingBox) boundingBox {
+	return boundingBox{
+		LatMin: math.Min(a.LatMin, b.LatMin),
+		LatMax: math.Max(a.LatMax, b.LatMax),
+		LonMin: math.Min(a.LonMin, b.LonMin),
+		LonMax: math.Max(a.LonMax, b.LonMax),
+	}
+}
+
+// Query returns the indices (into AreaStore.Areas) of every area whose
+// buffer-expanded bounding box overlaps (lat, lon). Callers still need to
+// run the exact ContainsPoint check on each candidate, since this is a
+// bbox-level prune, not an exact polygon test.
+func (t *RTree) Query(lat, lon float64) []int {
+	if t == nil || t.root == nil {
+		return nil
+	}
+
+	var results []int
+	var walk func(n *rtreeNode)
+	walk = func(n *rtreeNode) {
+		if !n.bbox.contains(lat, lon) {
+			return
+		}
+		if n.items != nil {
+			results = append(results, n.items...)
+			return
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(t.root)
+	return results
+}
+
+// Index lazily builds (once) and returns the AreaStore's R-tree spatial
+// index, reused by every later FindArea/FindAreas call.
+func (s *AreaStore) Index() *RTree {
+	s.indexOnce.Do(func() {
+		s.index = buildRTree(s.Areas)
+	})
+	return s.index
+}
@@ -0,0 +1,115 @@
+package areas
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestRTreeFindAreaMatchesLinearScan(t *testing.T) {
+	store := &AreaStore{Areas: []ProtectedArea{
+		{ID: "area1", Geometry: makeRectPolygon(-2.0, -1.0, 34.0, 35.0), BufferKm: 0.0},
+		{ID: "area2", Geometry: makeRectPolygon(-5.0, -4.0, 36.0, 37.0), BufferKm: 0.0},
+	}}
+	for i := range store.Areas {
+		store.Areas[i].computeBoundingBox()
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		wantID   string
+	}{
+		{"in area1", -1.5, 34.5, "area1"},
+		{"in area2", -4.5, 36.5, "area2"},
+		{"outside all", 0.0, 0.0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			area := store.FindArea(tt.lat, tt.lon)
+			if tt.wantID == "" {
+				if area != nil {
+					t.Errorf("expected nil, got %s", area.ID)
+				}
+				return
+			}
+			if area == nil || area.ID != tt.wantID {
+				t.Errorf("expected %s, got %v", tt.wantID, area)
+			}
+		})
+	}
+}
+
+func TestFindAreasReturnsOverlappingAreas(t *testing.T) {
+	store := &AreaStore{Areas: []ProtectedArea{
+		{ID: "outer", Geometry: makeRectPolygon(-2.0, 0.0, 34.0, 36.0), BufferKm: 0.0},
+		{ID: "inner", Geometry: makeRectPolygon(-1.5, -0.5, 34.5, 35.5), BufferKm: 0.0},
+	}}
+	for i := range store.Areas {
+		store.Areas[i].computeBoundingBox()
+	}
+
+	matches := store.FindAreas(-1.0, 35.0)
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 overlapping areas, got %d", len(matches))
+	}
+}
+
+// syntheticAreaStore builds n small non-overlapping rectangular areas laid
+// out on a grid, for benchmarking point lookups at WDPA scale.
+func syntheticAreaStore(n int) *AreaStore {
+	store := &AreaStore{Areas: make([]ProtectedArea, n)}
+	cols := 1
+	for cols*cols < n {
+		cols++
+	}
+	for i := 0; i < n; i++ {
+		row := i / cols
+		col := i % cols
+		latMin := -90.0 + float64(row)*0.1
+		lonMin := -180.0 + float64(col)*0.1
+		store.Areas[i] = ProtectedArea{
+			ID:       fmt.Sprintf("area%d", i),
+			Geometry: makeRectPolygon(latMin, latMin+0.05, lonMin, lonMin+0.05),
+			BufferKm: 1.0,
+		}
+		store.Areas[i].computeBoundingBox()
+	}
+	return store
+}
+
+func BenchmarkFindAreaLinearScan(b *testing.B) {
+	store := syntheticAreaStore(50000)
+	rng := rand.New(rand.NewSource(1))
+	points := make([]Point, 1000)
+	for i := range points {
+		points[i] = Point{Lat: rng.Float64()*180 - 90, Lon: rng.Float64()*360 - 180}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := points[i%len(points)]
+		for j := range store.Areas {
+			if store.Areas[j].ContainsPoint(p.Lat, p.Lon) {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkFindAreaIndexed(b *testing.B) {
+	store := syntheticAreaStore(50000)
+	store.Index() // build once, outside the timed loop
+	rng := rand.New(rand.NewSource(1))
+	points := make([]Point, 1000)
+	for i := range points {
+		points[i] = Point{Lat: rng.Float64()*180 - 90, Lon: rng.Float64()*360 - 180}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := points[i%len(points)]
+		store.FindArea(p.Lat, p.Lon)
+	}
+}
@@ -2,8 +2,15 @@ package areas
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 // WDPAIndexEntry represents a protected area from the WDPA index.
@@ -15,16 +22,33 @@ type WDPAIndexEntry struct {
 	Designation string  `json:"designation,omitempty"`
 	IUCNCat     string  `json:"iucn_category,omitempty"`
 	AreaKm2     float64 `json:"area_km2,omitempty"`
+	// CenterLat/CenterLon are optional; zero-value (0,0) means unknown and
+	// the entry is excluded from the spatial bucket index.
+	CenterLat float64 `json:"center_lat,omitempty"`
+	CenterLon float64 `json:"center_lon,omitempty"`
 }
 
+// spatialBucketDeg is the size (in degrees) of each cell in the WDPA index's
+// coarse spatial lookup. 1 degree (~111km at the equator) is coarse enough
+// to keep the bucket count small while still narrowing a bbox/nearest query
+// to a handful of candidate entries.
+const spatialBucketDeg = 1.0
+
 // WDPAIndex holds the index of all WDPA protected areas for search.
 type WDPAIndex struct {
 	Entries []WDPAIndexEntry
 	// Map of WDPA ID to entry for fast lookup
 	ByID map[int]*WDPAIndexEntry
+	// buckets maps "latCell_lonCell" to indices into Entries, for fast
+	// bounding-box / nearest-neighbor queries without scanning every entry.
+	buckets map[string][]int
+	// tokens maps a normalized name token to the indices into Entries
+	// whose Name contains it, for Search's inverted-index lookup.
+	tokens map[string][]int
 }
 
-// LoadWDPAIndex loads the WDPA index from a JSON file.
+// LoadWDPAIndex loads the WDPA index from a JSON file and builds the
+// in-memory spatial bucket lookup from each entry's center coordinates.
 func LoadWDPAIndex(path string) (*WDPAIndex, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -39,34 +63,247 @@ func LoadWDPAIndex(path string) (*WDPAIndex, error) {
 	index := &WDPAIndex{
 		Entries: entries,
 		ByID:    make(map[int]*WDPAIndexEntry, len(entries)),
+		buckets: make(map[string][]int),
+		tokens:  make(map[string][]int),
 	}
 
 	for i := range entries {
 		index.ByID[entries[i].WDPAID] = &entries[i]
+		if entries[i].CenterLat != 0 || entries[i].CenterLon != 0 {
+			key := bucketKey(entries[i].CenterLat, entries[i].CenterLon)
+			index.buckets[key] = append(index.buckets[key], i)
+		}
+		for _, tok := range tokenize(entries[i].Name) {
+			if n := index.tokens[tok]; len(n) == 0 || n[len(n)-1] != i {
+				index.tokens[tok] = append(index.tokens[tok], i)
+			}
+		}
 	}
 
 	return index, nil
 }
 
-// Search searches the WDPA index for entries matching the query.
-// Returns up to maxResults entries.
+// diacriticsFold strips combining marks after NFD decomposition, so
+// tokenize treats "Parque Nacional Yaguá" the same as "Yagua".
+var diacriticsFold = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// normalizeText lowercases s and folds diacritics, the shared first
+// step for both tokenize and the exact-phrase check in Search.
+func normalizeText(s string) string {
+	folded, _, err := transform.String(diacriticsFold, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+// tokenize splits s into lowercase, diacritic-folded words on anything
+// that isn't a letter or digit, for both indexing (LoadWDPAIndex) and
+// querying (Search).
+func tokenize(s string) []string {
+	normalized := normalizeText(s)
+	var tokens []string
+	var cur strings.Builder
+	for _, r := range normalized {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			cur.WriteRune(r)
+		} else if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// levenshtein returns the edit distance between a and b, capped
+// implicitly by the short token lengths Search calls it with (typo
+// correction only kicks in for tokens longer than 4 characters).
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			del := prev[j] + 1
+			ins := cur[j-1] + 1
+			sub := prev[j-1] + cost
+			min := del
+			if ins < min {
+				min = ins
+			}
+			if sub < min {
+				min = sub
+			}
+			cur[j] = min
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func bucketKey(lat, lon float64) string {
+	latCell := int(lat / spatialBucketDeg)
+	lonCell := int(lon / spatialBucketDeg)
+	return fmt.Sprintf("%d_%d", latCell, lonCell)
+}
+
+// QueryBBox returns entries whose center falls within the given bounding box,
+// using the spatial bucket index instead of scanning all entries.
+func (idx *WDPAIndex) QueryBBox(minLat, minLon, maxLat, maxLon float64) []WDPAIndexEntry {
+	if idx == nil {
+		return nil
+	}
+
+	var results []WDPAIndexEntry
+	latStart := int(minLat/spatialBucketDeg) - 1
+	latEnd := int(maxLat/spatialBucketDeg) + 1
+	lonStart := int(minLon/spatialBucketDeg) - 1
+	lonEnd := int(maxLon/spatialBucketDeg) + 1
+
+	for latCell := latStart; latCell <= latEnd; latCell++ {
+		for lonCell := lonStart; lonCell <= lonEnd; lonCell++ {
+			key := fmt.Sprintf("%d_%d", latCell, lonCell)
+			for _, i := range idx.buckets[key] {
+				e := idx.Entries[i]
+				if e.CenterLat >= minLat && e.CenterLat <= maxLat &&
+					e.CenterLon >= minLon && e.CenterLon <= maxLon {
+					results = append(results, e)
+				}
+			}
+		}
+	}
+
+	return results
+}
+
+// WDPASearchOptions filters and scores a Search query. Query is
+// tokenized and matched against entry names; CountryCode, IUCNCat, and
+// MinAreaKm2 (all optional) narrow the candidates before scoring and
+// ranking. MaxResults caps the number of entries returned; 0 means
+// "use a reasonable default" (see SearchFiltered).
+type WDPASearchOptions struct {
+	Query       string
+	CountryCode string
+	IUCNCat     string
+	MinAreaKm2  float64
+	MaxResults  int
+}
+
+const defaultWDPASearchMaxResults = 25
+
+// fuzzyTokenMinLen is the shortest token Search will typo-correct via
+// Levenshtein distance; below this length, a one-character edit
+// changes the word too much to trust as a typo (e.g. "car" -> "care").
+const fuzzyTokenMinLen = 5
+
+// Search searches the WDPA index for entries matching the query,
+// scoring and ranking them (see SearchFiltered). Returns up to
+// maxResults entries; kept for callers that don't need
+// WDPASearchOptions' filters.
 func (idx *WDPAIndex) Search(query string, maxResults int) []WDPAIndexEntry {
-	if idx == nil || query == "" {
+	return idx.SearchFiltered(WDPASearchOptions{Query: query, MaxResults: maxResults})
+}
+
+// SearchFiltered scores every entry matching opts.Query against the
+// token inverted index built by LoadWDPAIndex, then applies
+// CountryCode/IUCNCat/MinAreaKm2 as post-filters. A candidate's score
+// is the sum, over each query token, of:
+//   - 3 for an exact token match
+//   - 1.5 for a prefix match (a name token starts with the query token)
+//   - 1 for a fuzzy match (Levenshtein distance <= 1, query tokens
+//     longer than fuzzyTokenMinLen only, to catch typos like "Serengti")
+//
+// plus a flat +5 if the whole (normalized) query appears verbatim in
+// the entry's name. Results are sorted by score descending, ties
+// broken by name.
+func (idx *WDPAIndex) SearchFiltered(opts WDPASearchOptions) []WDPAIndexEntry {
+	if idx == nil || strings.TrimSpace(opts.Query) == "" {
 		return nil
 	}
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultWDPASearchMaxResults
+	}
 
-	queryLower := strings.ToLower(query)
-	results := make([]WDPAIndexEntry, 0, maxResults)
+	queryTokens := tokenize(opts.Query)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+	normalizedQuery := normalizeText(opts.Query)
 
-	for _, entry := range idx.Entries {
-		if strings.Contains(strings.ToLower(entry.Name), queryLower) {
-			results = append(results, entry)
-			if len(results) >= maxResults {
-				break
+	scores := make(map[int]float64)
+	for _, qt := range queryTokens {
+		if matches, ok := idx.tokens[qt]; ok {
+			for _, i := range matches {
+				scores[i] += 3
 			}
 		}
+		for tok, matches := range idx.tokens {
+			if tok == qt {
+				continue
+			}
+			if strings.HasPrefix(tok, qt) {
+				for _, i := range matches {
+					scores[i] += 1.5
+				}
+				continue
+			}
+			if len(qt) > fuzzyTokenMinLen && len(tok) > fuzzyTokenMinLen && levenshtein(qt, tok) <= 1 {
+				for _, i := range matches {
+					scores[i] += 1
+				}
+			}
+		}
+	}
+
+	for i, entry := range idx.Entries {
+		if strings.Contains(normalizeText(entry.Name), normalizedQuery) {
+			scores[i] += 5
+		}
 	}
 
+	candidates := make([]int, 0, len(scores))
+	for i := range scores {
+		entry := idx.Entries[i]
+		if opts.CountryCode != "" && !strings.EqualFold(entry.CountryCode, opts.CountryCode) {
+			continue
+		}
+		if opts.IUCNCat != "" && !strings.EqualFold(entry.IUCNCat, opts.IUCNCat) {
+			continue
+		}
+		if opts.MinAreaKm2 > 0 && entry.AreaKm2 < opts.MinAreaKm2 {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		ia, ib := candidates[a], candidates[b]
+		if scores[ia] != scores[ib] {
+			return scores[ia] > scores[ib]
+		}
+		return idx.Entries[ia].Name < idx.Entries[ib].Name
+	})
+
+	if len(candidates) > maxResults {
+		candidates = candidates[:maxResults]
+	}
+
+	results := make([]WDPAIndexEntry, len(candidates))
+	for i, idxInto := range candidates {
+		results[i] = idx.Entries[idxInto]
+	}
 	return results
 }
 
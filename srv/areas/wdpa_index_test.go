@@ -74,6 +74,44 @@ func TestWDPAIndexSearch(t *testing.T) {
 	}
 }
 
+func TestWDPAIndexSearchFuzzyAndFilters(t *testing.T) {
+	idx, err := LoadWDPAIndex("../../data/wdpa_index.json")
+	if err != nil {
+		t.Fatalf("LoadWDPAIndex failed: %v", err)
+	}
+
+	// "Serengti" is a one-edit typo of "Serengeti"; the fuzzy match
+	// should still surface it.
+	results := idx.Search("Serengti", 10)
+	found := false
+	for _, r := range results {
+		if contains(r.Name, "Serengeti") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("expected fuzzy match for \"Serengti\" to find Serengeti")
+	}
+
+	exact := idx.SearchFiltered(WDPASearchOptions{Query: "Serengeti", MaxResults: 10})
+	if len(exact) == 0 {
+		t.Fatal("expected exact query to find Serengeti")
+	}
+	if exact[0].CountryCode == "" {
+		t.Skip("index entry has no country_code to filter on")
+	}
+
+	filtered := idx.SearchFiltered(WDPASearchOptions{
+		Query:       "Serengeti",
+		CountryCode: "zz-does-not-exist",
+		MaxResults:  10,
+	})
+	if len(filtered) != 0 {
+		t.Errorf("expected CountryCode filter to exclude all results, got %d", len(filtered))
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > 0 && len(substr) > 0 && findSubstring(s, substr)))
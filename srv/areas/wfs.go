@@ -0,0 +1,63 @@
+package areas
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"srv.exe.dev/srv/pa/wfs"
+	"srv.exe.dev/srv/protectedplanet"
+)
+
+// LoadFromWFS builds an AreaStore by pulling every feature of cfg's
+// feature type from a WFS 2.0.0/OGC API - Features endpoint, as an
+// alternative to LoadKeystones' pre-baked keystones_basic.json/
+// keystones_with_boundaries.json for operators who'd rather point the
+// app at a live WDPA (or national) WFS than ship GeoJSON snapshots.
+// It reuses srv/pa/wfs's GetCapabilities/paged-GetFeature client —
+// GetCapabilities confirms cfg.FeatureType exists before paging
+// through all of it with an empty name/country filter.
+func LoadFromWFS(ctx context.Context, cfg wfs.Config) (*AreaStore, error) {
+	client := wfs.NewClient(cfg)
+	if err := client.GetCapabilities(ctx); err != nil {
+		return nil, fmt.Errorf("load areas from wfs: %w", err)
+	}
+
+	pas, err := client.SearchByName(ctx, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("load areas from wfs: %w", err)
+	}
+
+	areasOut := make([]ProtectedArea, 0, len(pas))
+	for _, pa := range pas {
+		area := paToArea(pa)
+		area.computeBoundingBox()
+		areasOut = append(areasOut, area)
+	}
+
+	return &AreaStore{Areas: areasOut}, nil
+}
+
+// paToArea converts a protectedplanet.PA (the shape srv/pa/wfs
+// normalizes every WFS feature into) to a ProtectedArea. WFS sources
+// only surface an ISO3 country code, not the full country name, so
+// both Country and CountryCode are set to it.
+func paToArea(pa protectedplanet.PA) ProtectedArea {
+	id := strconv.Itoa(pa.WDPAID)
+	area := ProtectedArea{
+		ID:          id,
+		Name:        pa.Name,
+		Country:     pa.Country,
+		CountryCode: pa.Country,
+		WDPAID:      id,
+		AreaKm2:     pa.AreaKm2,
+		BufferKm:    5.0, // matches loadKeystonesWithBoundaries' buffer for real geometry
+	}
+	if pa.Geometry != nil && pa.Geometry.Geometry != nil {
+		area.Geometry = GeoJSONGeometry{
+			Type:        pa.Geometry.Geometry.Type,
+			Coordinates: pa.Geometry.Geometry.Coordinates,
+		}
+	}
+	return area
+}
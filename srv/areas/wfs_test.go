@@ -0,0 +1,52 @@
+package areas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"srv.exe.dev/srv/protectedplanet"
+)
+
+func TestPaToAreaMapsFieldsAndGeometry(t *testing.T) {
+	geom := json.RawMessage(`[[[36.0, -1.0], [36.1, -1.0], [36.1, -0.9], [36.0, -1.0]]]`)
+	pa := protectedplanet.PA{
+		WDPAID:  916,
+		Name:    "Serengeti",
+		Country: "TZA",
+		AreaKm2: 14763.0,
+		Geometry: &protectedplanet.GeoJSONFeature{
+			Type: "Feature",
+			Geometry: &protectedplanet.GeoJSON{
+				Type:        "Polygon",
+				Coordinates: geom,
+			},
+		},
+	}
+
+	area := paToArea(pa)
+	if area.ID != "916" || area.WDPAID != "916" {
+		t.Errorf("expected ID/WDPAID %q, got ID=%q WDPAID=%q", "916", area.ID, area.WDPAID)
+	}
+	if area.Name != "Serengeti" {
+		t.Errorf("expected Name %q, got %q", "Serengeti", area.Name)
+	}
+	if area.Country != "TZA" || area.CountryCode != "TZA" {
+		t.Errorf("expected Country/CountryCode %q, got Country=%q CountryCode=%q", "TZA", area.Country, area.CountryCode)
+	}
+	if area.Geometry.Type != "Polygon" {
+		t.Errorf("expected geometry type Polygon, got %q", area.Geometry.Type)
+	}
+
+	area.computeBoundingBox()
+	if area.bbox == nil {
+		t.Fatal("expected bounding box to be computed from converted geometry")
+	}
+}
+
+func TestPaToAreaHandlesMissingGeometry(t *testing.T) {
+	pa := protectedplanet.PA{WDPAID: 1, Name: "No Geometry"}
+	area := paToArea(pa)
+	if area.Geometry.Type != "" {
+		t.Errorf("expected empty geometry, got %q", area.Geometry.Type)
+	}
+}
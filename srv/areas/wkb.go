@@ -0,0 +1,327 @@
+package areas
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// DefaultSRID is the spatial reference system ID assumed for EWKB output
+// and expected on EWKB input when none is given some other way — WGS84,
+// the same lon/lat system GeoJSON coordinates are already in.
+const DefaultSRID = 4326
+
+// WKB geometry type codes (OGC Simple Features), before the EWKB SRID
+// flag is ORed in.
+const (
+	wkbTypePoint        = 1
+	wkbTypePolygon      = 3
+	wkbTypeMultiPolygon = 6
+)
+
+// wkbSRIDFlag is the EWKB extension bit (PostGIS convention) that marks a
+// uint32 SRID as present right after the geometry type.
+const wkbSRIDFlag = 0x20000000
+
+// MarshalWKB encodes the geometry as little-endian EWKF: 1 byte
+// endianness, uint32 geometry type (with the SRID flag set), uint32 SRID
+// (DefaultSRID), then a type-specific body of uint32 counts and float64
+// lon/lat pairs. Only Point, Polygon, and MultiPolygon are supported, the
+// three types GeoJSONGeometry itself parses.
+func (g *GeoJSONGeometry) MarshalWKB() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(1) // little-endian
+
+	switch g.Type {
+	case "Point":
+		var coord []float64
+		if err := json.Unmarshal(g.Coordinates, &coord); err != nil {
+			return nil, fmt.Errorf("parse point coordinates: %w", err)
+		}
+		if len(coord) != 2 {
+			return nil, fmt.Errorf("point must have exactly 2 coordinates, got %d", len(coord))
+		}
+		writeUint32(&buf, wkbTypePoint|wkbSRIDFlag)
+		writeUint32(&buf, DefaultSRID)
+		writeFloat64(&buf, coord[0])
+		writeFloat64(&buf, coord[1])
+
+	case "Polygon":
+		polys := g.getPolys()
+		if len(polys) == 0 {
+			return nil, fmt.Errorf("polygon has no rings")
+		}
+		writeUint32(&buf, wkbTypePolygon|wkbSRIDFlag)
+		writeUint32(&buf, DefaultSRID)
+		writeWKBRings(&buf, polys[0])
+
+	case "MultiPolygon":
+		polys := g.getPolys()
+		writeUint32(&buf, wkbTypeMultiPolygon|wkbSRIDFlag)
+		writeUint32(&buf, DefaultSRID)
+		writeUint32(&buf, uint32(len(polys)))
+		for _, rings := range polys {
+			buf.WriteByte(1)
+			writeUint32(&buf, wkbTypePolygon)
+			writeWKBRings(&buf, rings)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported geometry type for WKB: %q", g.Type)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeWKBRings(buf *bytes.Buffer, rings [][][]float64) {
+	writeUint32(buf, uint32(len(rings)))
+	for _, ring := range rings {
+		writeUint32(buf, uint32(len(ring)))
+		for _, coord := range ring {
+			writeFloat64(buf, coord[0])
+			writeFloat64(buf, coord[1])
+		}
+	}
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeFloat64(buf *bytes.Buffer, v float64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	buf.Write(b[:])
+}
+
+// wkbReader sequentially consumes a WKB/EWKB byte buffer using whichever
+// byte order the first byte specified.
+type wkbReader struct {
+	b     []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	if r.pos+4 > len(r.b) {
+		return 0, fmt.Errorf("unexpected end of WKB data reading uint32")
+	}
+	v := r.order.Uint32(r.b[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) float64() (float64, error) {
+	if r.pos+8 > len(r.b) {
+		return 0, fmt.Errorf("unexpected end of WKB data reading float64")
+	}
+	v := math.Float64frombits(r.order.Uint64(r.b[r.pos:]))
+	r.pos += 8
+	return v, nil
+}
+
+func (r *wkbReader) ring() ([][]float64, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	ring := make([][]float64, n)
+	for i := range ring {
+		lon, err := r.float64()
+		if err != nil {
+			return nil, err
+		}
+		lat, err := r.float64()
+		if err != nil {
+			return nil, err
+		}
+		ring[i] = []float64{lon, lat}
+	}
+	return ring, nil
+}
+
+func (r *wkbReader) rings() ([][][]float64, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	rings := make([][][]float64, n)
+	for i := range rings {
+		ring, err := r.ring()
+		if err != nil {
+			return nil, err
+		}
+		rings[i] = ring
+	}
+	return rings, nil
+}
+
+// ParseWKB decodes a WKB or EWKB byte buffer (as produced by MarshalWKB,
+// PostGIS's ST_AsEWKB, or any imposm3-style pipeline) into a
+// GeoJSONGeometry. Point, Polygon, and MultiPolygon are supported; the
+// EWKB SRID, if present, is read and discarded (geometries are assumed to
+// already be in WGS84 lon/lat, matching GeoJSON).
+func ParseWKB(b []byte) (*GeoJSONGeometry, error) {
+	if len(b) < 5 {
+		return nil, fmt.Errorf("WKB data too short: %d bytes", len(b))
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if b[0] == 0 {
+		order = binary.BigEndian
+	}
+	r := &wkbReader{b: b[1:], order: order}
+
+	rawType, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	hasSRID := rawType&wkbSRIDFlag != 0
+	geomType := rawType &^ wkbSRIDFlag
+	if hasSRID {
+		if _, err := r.uint32(); err != nil { // SRID, discarded
+			return nil, err
+		}
+	}
+
+	switch geomType {
+	case wkbTypePoint:
+		lon, err := r.float64()
+		if err != nil {
+			return nil, err
+		}
+		lat, err := r.float64()
+		if err != nil {
+			return nil, err
+		}
+		coords, _ := json.Marshal([]float64{lon, lat})
+		return &GeoJSONGeometry{Type: "Point", Coordinates: coords}, nil
+
+	case wkbTypePolygon:
+		rings, err := r.rings()
+		if err != nil {
+			return nil, err
+		}
+		coords, _ := json.Marshal(rings)
+		return &GeoJSONGeometry{Type: "Polygon", Coordinates: coords, parsedPolys: [][][][]float64{rings}}, nil
+
+	case wkbTypeMultiPolygon:
+		numPolys, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		polys := make([][][][]float64, numPolys)
+		for i := range polys {
+			// Each sub-geometry repeats its own byte-order + type header.
+			if r.pos+5 > len(r.b) {
+				return nil, fmt.Errorf("unexpected end of WKB data reading sub-polygon header")
+			}
+			if r.b[r.pos] == 0 {
+				r.order = binary.BigEndian
+			} else {
+				r.order = binary.LittleEndian
+			}
+			r.pos++
+			if _, err := r.uint32(); err != nil { // sub-geometry type, assumed Polygon
+				return nil, err
+			}
+			rings, err := r.rings()
+			if err != nil {
+				return nil, err
+			}
+			polys[i] = rings
+		}
+		coords, _ := json.Marshal(polys)
+		return &GeoJSONGeometry{Type: "MultiPolygon", Coordinates: coords, parsedPolys: polys}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported WKB geometry type: %d", geomType)
+	}
+}
+
+// LoadAreasFromWKBColumn loads protected areas from a PostGIS-backed
+// boundaries table, as an alternative to keystones_with_boundaries.json.
+// query must select (id, name, country, country_code, wdpa_id, area_km2,
+// buffer_km, geom) in that order, with geom as a WKB/EWKB-encoded column
+// (e.g. `ST_AsEWKB(geom)` in the query itself).
+func LoadAreasFromWKBColumn(db *sql.DB, query string) (*AreaStore, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("query boundaries table: %w", err)
+	}
+	defer rows.Close()
+
+	var areas []ProtectedArea
+	for rows.Next() {
+		var a ProtectedArea
+		var wkb []byte
+		if err := rows.Scan(&a.ID, &a.Name, &a.Country, &a.CountryCode, &a.WDPAID, &a.AreaKm2, &a.BufferKm, &wkb); err != nil {
+			return nil, fmt.Errorf("scan boundary row: %w", err)
+		}
+		geom, err := ParseWKB(wkb)
+		if err != nil {
+			return nil, fmt.Errorf("parse WKB for area %q: %w", a.ID, err)
+		}
+		a.Geometry = *geom
+		a.computeBoundingBox()
+		areas = append(areas, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &AreaStore{Areas: areas}, nil
+}
+
+// MarshalJSON emits the usual {type, coordinates} GeoJSON object, unless
+// EncodeAsWKB is set, in which case it emits a base64 EWKB string — see
+// EncodeAsWKB's doc comment in areas.go.
+func (g GeoJSONGeometry) MarshalJSON() ([]byte, error) {
+	if g.EncodeAsWKB {
+		wkb, err := g.MarshalWKB()
+		if err != nil {
+			return nil, fmt.Errorf("encode geometry as EWKB: %w", err)
+		}
+		return json.Marshal(base64.StdEncoding.EncodeToString(wkb))
+	}
+	type alias GeoJSONGeometry
+	return json.Marshal(alias(g))
+}
+
+// UnmarshalJSON accepts either a GeoJSON {type, coordinates} object or a
+// base64 EWKB string, auto-detecting which it's given: a JSON string is
+// decoded as base64 EWKB, a JSON object as GeoJSON.
+func (g *GeoJSONGeometry) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(data, &encoded); err != nil {
+			return fmt.Errorf("decode geometry string: %w", err)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("decode geometry base64: %w", err)
+		}
+		parsed, err := ParseWKB(raw)
+		if err != nil {
+			return fmt.Errorf("parse geometry EWKB: %w", err)
+		}
+		*g = *parsed
+		g.EncodeAsWKB = true
+		return nil
+	}
+
+	type alias GeoJSONGeometry
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*g = GeoJSONGeometry(a)
+	return nil
+}
@@ -0,0 +1,82 @@
+package areas
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWKBPolygonRoundTrip(t *testing.T) {
+	rings := [][][]float64{{{34.0, -2.0}, {35.0, -2.0}, {35.0, -1.0}, {34.0, -1.0}, {34.0, -2.0}}}
+	coords, _ := json.Marshal(rings)
+	geom := &GeoJSONGeometry{Type: "Polygon", Coordinates: coords, parsedPolys: [][][][]float64{rings}}
+
+	wkb, err := geom.MarshalWKB()
+	if err != nil {
+		t.Fatalf("MarshalWKB failed: %v", err)
+	}
+
+	parsed, err := ParseWKB(wkb)
+	if err != nil {
+		t.Fatalf("ParseWKB failed: %v", err)
+	}
+	if parsed.Type != "Polygon" {
+		t.Errorf("expected Polygon, got %s", parsed.Type)
+	}
+
+	gotRings := parsed.getPolys()[0]
+	if len(gotRings) != 1 || len(gotRings[0]) != len(rings[0]) {
+		t.Fatalf("ring shape mismatch: got %v", gotRings)
+	}
+	for i, coord := range rings[0] {
+		if gotRings[0][i][0] != coord[0] || gotRings[0][i][1] != coord[1] {
+			t.Errorf("coord %d: got %v, want %v", i, gotRings[0][i], coord)
+		}
+	}
+}
+
+func TestWKBMultiPolygonRoundTrip(t *testing.T) {
+	multi := [][][][]float64{
+		{{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}},
+		{{{10, 10}, {12, 10}, {12, 12}, {10, 12}, {10, 10}}},
+	}
+	coords, _ := json.Marshal(multi)
+	geom := &GeoJSONGeometry{Type: "MultiPolygon", Coordinates: coords, parsedPolys: multi}
+
+	wkb, err := geom.MarshalWKB()
+	if err != nil {
+		t.Fatalf("MarshalWKB failed: %v", err)
+	}
+
+	parsed, err := ParseWKB(wkb)
+	if err != nil {
+		t.Fatalf("ParseWKB failed: %v", err)
+	}
+	if parsed.Type != "MultiPolygon" {
+		t.Errorf("expected MultiPolygon, got %s", parsed.Type)
+	}
+	if len(parsed.getPolys()) != 2 {
+		t.Fatalf("expected 2 polygons, got %d", len(parsed.getPolys()))
+	}
+}
+
+func TestGeoJSONGeometryJSONRoundTripAsEWKB(t *testing.T) {
+	rings := [][][]float64{{{34.0, -2.0}, {35.0, -2.0}, {35.0, -1.0}, {34.0, -1.0}, {34.0, -2.0}}}
+	coords, _ := json.Marshal(rings)
+	geom := GeoJSONGeometry{Type: "Polygon", Coordinates: coords, parsedPolys: [][][][]float64{rings}, EncodeAsWKB: true}
+
+	data, err := json.Marshal(geom)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var roundTripped GeoJSONGeometry
+	if err := json.Unmarshal(data, &roundTripped); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if roundTripped.Type != "Polygon" {
+		t.Errorf("expected Polygon after round trip, got %s", roundTripped.Type)
+	}
+	if !roundTripped.EncodeAsWKB {
+		t.Error("expected EncodeAsWKB to be auto-detected as true after unmarshaling a base64 EWKB string")
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// RoleSuspended marks an account that was approved but has since been
+// suspended by an admin; it's rejected at Login the same way "pending"
+// is, but keeps the account's history instead of deleting it.
+const RoleSuspended = "suspended"
+
+// PasswordResetDuration bounds how long an issued reset link is valid.
+const PasswordResetDuration = 1 * time.Hour
+
+// UserFilter narrows ListUsers. Zero values mean "no filter" for that
+// field; Page is 1-indexed and defaults to 1 if <= 0.
+type UserFilter struct {
+	Role    string // "", "pending", "approved", "admin", "suspended"
+	OrgType string
+	Country string
+	Query   string // substring match against email/name/organization
+	Page    int
+	PerPage int
+}
+
+// ListUsers returns a page of users matching filter along with the
+// total number of matching rows, for server-side pagination on the
+// admin console.
+func (m *Manager) ListUsers(ctx context.Context, filter UserFilter) ([]dbgen.User, int, error) {
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+	perPage := filter.PerPage
+	if perPage <= 0 {
+		perPage = 50
+	}
+
+	q := dbgen.New(m.db)
+	users, total, err := q.ListUsersFiltered(ctx, dbgen.ListUsersFilteredParams{
+		Role:    filter.Role,
+		OrgType: filter.OrgType,
+		Country: filter.Country,
+		Query:   filter.Query,
+		Limit:   int64(perPage),
+		Offset:  int64((page - 1) * perPage),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, int(total), nil
+}
+
+// UpdateRole changes a user's role (e.g. promoting to "admin" or
+// demoting back to "approved").
+func (m *Manager) UpdateRole(ctx context.Context, userID, role string) error {
+	return dbgen.New(m.db).SetUserRole(ctx, dbgen.SetUserRoleParams{ID: userID, Role: role})
+}
+
+// SetStatus suspends or reinstates a user by setting their role to
+// RoleSuspended or back to "approved".
+func (m *Manager) SetStatus(ctx context.Context, userID, status string) error {
+	return m.UpdateRole(ctx, userID, status)
+}
+
+// DeleteAllSessions force-logs-out a user everywhere, e.g. after a
+// suspension or a suspected compromise.
+func (m *Manager) DeleteAllSessions(ctx context.Context, userID string) error {
+	return dbgen.New(m.db).DeleteUserSessions(ctx, userID)
+}
+
+// IssuePasswordReset generates a one-time reset token for userID,
+// stores only its hash (the same pattern as session IDs - the
+// plaintext is never persisted), and returns the plaintext to embed in
+// the reset link.
+func (m *Manager) IssuePasswordReset(ctx context.Context, userID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	tokenHash := sha256Hex(token)
+
+	q := dbgen.New(m.db)
+	now := time.Now()
+	if err := q.CreatePasswordReset(ctx, dbgen.CreatePasswordResetParams{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(PasswordResetDuration),
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// LogEvent records an admin or auth action to the user_events audit
+// trail. actorUserID is empty for system-initiated events (e.g. a
+// login attempt). Failures are logged but not returned: a broken audit
+// write should never block the action it's recording.
+func (m *Manager) LogEvent(ctx context.Context, actorUserID, targetUserID, action string, metadata map[string]any) {
+	metaJSON, err := json.Marshal(metadata)
+	if err != nil {
+		metaJSON = []byte("{}")
+	}
+
+	q := dbgen.New(m.db)
+	if err := q.CreateUserEvent(ctx, dbgen.CreateUserEventParams{
+		ActorUserID:  actorUserID,
+		TargetUserID: targetUserID,
+		Action:       action,
+		Metadata:     string(metaJSON),
+		CreatedAt:    time.Now(),
+	}); err != nil {
+		slog.Warn("failed to write audit event", "action", action, "actor", actorUserID, "target", targetUserID, "error", err)
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -13,12 +13,22 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/mailer"
 )
 
 const (
 	SessionCookieName = "session"
-	SessionDuration   = 30 * 24 * time.Hour // 30 days
-	bcryptCost        = 12
+
+	// SessionDuration is the default MaxLifetime: the absolute cap on
+	// a session's age regardless of activity.
+	SessionDuration = 30 * 24 * time.Hour // 30 days
+
+	// DefaultIdleTimeout is the default IdleTimeout: how long a session
+	// survives with no requests before it expires, well short of
+	// MaxLifetime so an abandoned login doesn't stay valid for a month.
+	DefaultIdleTimeout = 24 * time.Hour
+
+	bcryptCost = 12
 )
 
 var (
@@ -27,24 +37,76 @@ var (
 	ErrUserExists         = errors.New("user already exists")
 	ErrInvalidSession     = errors.New("invalid or expired session")
 	ErrSessionStorage     = errors.New("session storage error")
+	ErrTooManyAttempts    = errors.New("too many login attempts, try again later")
 )
 
 // User represents an authenticated user.
 type User struct {
-	ID       string
-	Email    string
-	Name     string
-	Role     string
+	ID    string
+	Email string
+	Name  string
+	Role  string
 }
 
-// Manager handles authentication operations.
+// Manager handles authentication operations. Everything but session
+// storage (credentials, approval state, audit log) still goes through
+// db/dbgen directly; sessions alone are abstracted behind a
+// SessionStore so operators can pick a backend suited to their
+// deployment without forking this package.
 type Manager struct {
-	db *sql.DB
+	db       *sql.DB
+	sessions SessionStore
+
+	// MaxLifetime bounds a session's total age from creation,
+	// regardless of activity. IdleTimeout bounds how long it survives
+	// since its last use. Every touch (a successful GetUserFromSession)
+	// extends ExpiresAt to min(now+IdleTimeout, createdAt+MaxLifetime),
+	// so an active session rides the idle window but can never outlive
+	// the absolute cap.
+	MaxLifetime time.Duration
+	IdleTimeout time.Duration
+
+	// Mailer sends password-reset and email-verification messages. It's
+	// nil until a caller sets it (Server.New wires up an SMTPMailer or
+	// falls back to mailer.NoopMailer); RequestPasswordReset and
+	// RequestEmailVerification treat a nil Mailer the same as NoopMailer,
+	// logging a warning instead of failing, so a deployment missing SMTP
+	// config doesn't break login/registration.
+	Mailer mailer.Mailer
 }
 
-// NewManager creates a new auth manager.
+// NewManager creates an auth manager backed by db for everything,
+// including the default SQL-backed session store. Use
+// NewManagerWithSessionStore to plug in MemorySessionStore,
+// RedisSessionStore, or another SessionStore implementation instead.
 func NewManager(db *sql.DB) *Manager {
-	return &Manager{db: db}
+	return NewManagerWithSessionStore(db, NewSQLSessionStore(db))
+}
+
+// NewManagerWithSessionStore creates an auth manager backed by db for
+// credentials/users and sessions for session storage, with
+// MaxLifetime/IdleTimeout set to SessionDuration/DefaultIdleTimeout.
+// Override the fields directly to change them.
+func NewManagerWithSessionStore(db *sql.DB, sessions SessionStore) *Manager {
+	return &Manager{
+		db:          db,
+		sessions:    sessions,
+		MaxLifetime: SessionDuration,
+		IdleTimeout: DefaultIdleTimeout,
+	}
+}
+
+// sessionExpiry computes a session's ExpiresAt given when it was
+// created and now, per the idle/absolute timeout rules described on
+// Manager. At creation, now == createdAt, so this also gives the
+// initial expiry.
+func (m *Manager) sessionExpiry(now, createdAt time.Time) time.Time {
+	idle := now.Add(m.IdleTimeout)
+	absolute := createdAt.Add(m.MaxLifetime)
+	if idle.Before(absolute) {
+		return idle
+	}
+	return absolute
 }
 
 // HashPassword creates a bcrypt hash of a password.
@@ -134,9 +196,16 @@ func (m *Manager) Register(ctx context.Context, email, password, name, org, orgT
 	})
 }
 
-// Login authenticates a user and creates a session.
+// Login authenticates a user and creates a session. ip is the
+// connecting client's address, used alongside email to rate-limit
+// guessing (see login_attempts.go); pass "" if the caller has no
+// meaningful IP (e.g. a test harness).
 // Returns the session ID on success.
-func (m *Manager) Login(ctx context.Context, email, password string) (string, *User, error) {
+func (m *Manager) Login(ctx context.Context, email, password, ip string) (string, *User, error) {
+	if err := m.checkLoginRateLimit(ctx, email, ip); err != nil {
+		return "", nil, err
+	}
+
 	q := dbgen.New(m.db)
 
 	user, err := q.GetUserByEmail(ctx, email)
@@ -144,13 +213,19 @@ func (m *Manager) Login(ctx context.Context, email, password string) (string, *U
 		if err != sql.ErrNoRows {
 			slog.Error("database error during login", "email", email, "error", err)
 		}
+		m.recordLoginAttempt(ctx, email, ip, false)
 		return "", nil, ErrInvalidCredentials
 	}
 
 	if !CheckPassword(password, user.PasswordHash) {
+		m.recordLoginAttempt(ctx, email, ip, false)
 		return "", nil, ErrInvalidCredentials
 	}
 
+	// The credential check passed, so this isn't a guessing signal even
+	// though the login itself doesn't proceed.
+	m.recordLoginAttempt(ctx, email, ip, true)
+
 	if user.Role == "pending" {
 		return "", nil, ErrUserNotApproved
 	}
@@ -163,18 +238,17 @@ func (m *Manager) Login(ctx context.Context, email, password string) (string, *U
 	}
 
 	now := time.Now()
-	err = q.CreateSession(ctx, dbgen.CreateSessionParams{
+	if err := m.sessions.Create(ctx, Session{
 		ID:        sessionID,
 		UserID:    user.ID,
 		CreatedAt: now,
-		ExpiresAt: now.Add(SessionDuration),
-	})
-	if err != nil {
-		slog.Error("failed to create session", "user_id", user.ID, "error", err)
-		return "", nil, ErrSessionStorage
+		ExpiresAt: m.sessionExpiry(now, now),
+	}); err != nil {
+		return "", nil, err
 	}
 
 	slog.Info("user logged in", "user_id", user.ID, "email", user.Email)
+	m.LogEvent(ctx, "", user.ID, "login", map[string]any{"method": "password"})
 	return sessionID, &User{
 		ID:    user.ID,
 		Email: user.Email,
@@ -183,6 +257,70 @@ func (m *Manager) Login(ctx context.Context, email, password string) (string, *U
 	}, nil
 }
 
+// ProvisionPendingUser creates a pending-approval account for an
+// identity verified by an external provider (SSO, WebAuthn-only
+// signup), without a password. It mirrors Register's "awaiting
+// approval" posture so admins still vet who can upload.
+func (m *Manager) ProvisionPendingUser(ctx context.Context, email, name string) (string, error) {
+	q := dbgen.New(m.db)
+
+	userID, err := generateUserID()
+	if err != nil {
+		return "", err
+	}
+
+	if err := q.CreateUser(ctx, dbgen.CreateUserParams{
+		ID:        userID,
+		Email:     email,
+		Name:      name,
+		Role:      "pending",
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+// LoginWithoutPassword creates a session for an email that has already
+// been authenticated by another factor (currently WebAuthn). It applies
+// the same approval check as Login but skips CheckPassword.
+func (m *Manager) LoginWithoutPassword(ctx context.Context, email string) (string, *User, error) {
+	q := dbgen.New(m.db)
+
+	user, err := q.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("database error during passwordless login", "email", email, "error", err)
+		}
+		return "", nil, ErrInvalidCredentials
+	}
+
+	if user.Role == "pending" {
+		return "", nil, ErrUserNotApproved
+	}
+
+	sessionID, err := generateSessionID()
+	if err != nil {
+		slog.Error("failed to generate session ID during passwordless login", "user_id", user.ID, "error", err)
+		return "", nil, ErrSessionStorage
+	}
+
+	now := time.Now()
+	if err := m.sessions.Create(ctx, Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: m.sessionExpiry(now, now),
+	}); err != nil {
+		return "", nil, err
+	}
+
+	slog.Info("user logged in via passkey", "user_id", user.ID, "email", user.Email)
+	m.LogEvent(ctx, "", user.ID, "login", map[string]any{"method": "webauthn"})
+	return sessionID, &User{ID: user.ID, Email: user.Email, Name: user.Name, Role: user.Role}, nil
+}
+
 // Logout invalidates a session.
 // Returns nil if the session was deleted or didn't exist.
 // Returns an error only if there was a database problem.
@@ -192,43 +330,69 @@ func (m *Manager) Logout(ctx context.Context, sessionID string) error {
 		return nil
 	}
 
-	q := dbgen.New(m.db)
-	err := q.DeleteSession(ctx, sessionID)
-	if err != nil {
-		slog.Error("failed to delete session during logout", "error", err)
-		return err
+	if user, err := m.GetUserFromSession(ctx, sessionID); err == nil {
+		m.LogEvent(ctx, "", user.ID, "logout", nil)
 	}
-	return nil
+
+	return m.sessions.Delete(ctx, sessionID)
 }
 
-// GetUserFromSession retrieves the user for a session ID.
+// GetUserFromSession retrieves the user for a session ID, extending
+// the session's expiry per the idle/absolute timeout rules (see
+// Manager's doc comment) as a side effect of the lookup succeeding.
 // Returns ErrInvalidSession if the session doesn't exist or is expired.
 // Returns ErrSessionStorage if there was a database error.
 func (m *Manager) GetUserFromSession(ctx context.Context, sessionID string) (*User, error) {
+	user, _, err := m.getUserAndTouch(ctx, sessionID)
+	return user, err
+}
+
+// getUserAndTouch is GetUserFromSession plus the refreshed expiry,
+// for RefreshSessionCookie to use when re-issuing the cookie's MaxAge.
+func (m *Manager) getUserAndTouch(ctx context.Context, sessionID string) (*User, time.Time, error) {
 	// Validate session ID format before hitting the database
 	if !isValidSessionID(sessionID) {
-		return nil, ErrInvalidSession
+		return nil, time.Time{}, ErrInvalidSession
 	}
 
-	q := dbgen.New(m.db)
-
-	sess, err := q.GetSession(ctx, sessionID)
+	sess, err := m.sessions.Get(ctx, sessionID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			// Session not found or expired - this is expected behavior
-			return nil, ErrInvalidSession
+		// ErrInvalidSession (missing/expired) and ErrSessionStorage
+		// (backend failure) are both already the right sentinel to
+		// return as-is.
+		return nil, time.Time{}, err
+	}
+
+	newExpiry := sess.ExpiresAt
+	now := time.Now()
+	if extended := m.sessionExpiry(now, sess.CreatedAt); extended.After(sess.ExpiresAt) {
+		if ttl := extended.Sub(now); ttl > 0 {
+			if err := m.sessions.Touch(ctx, sessionID, ttl); err != nil {
+				// Not fatal: the session is still valid until its
+				// current ExpiresAt, it just misses this extension.
+				slog.Warn("failed to touch session expiry", "error", err)
+			} else {
+				newExpiry = extended
+			}
 		}
-		// Unexpected database error - log it
-		slog.Error("database error retrieving session", "error", err)
-		return nil, ErrSessionStorage
 	}
 
-	return &User{
-		ID:    sess.UserID,
-		Email: sess.Email,
-		Name:  sess.Name,
-		Role:  sess.Role,
-	}, nil
+	user, err := m.GetUserByID(ctx, sess.UserID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return user, newExpiry, nil
+}
+
+// GetUserByID looks up a user by ID, for callers (like bearer-token
+// authentication) that authenticate a user without a session.
+func (m *Manager) GetUserByID(ctx context.Context, userID string) (*User, error) {
+	q := dbgen.New(m.db)
+	u, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &User{ID: u.ID, Email: u.Email, Name: u.Name, Role: u.Role}, nil
 }
 
 // GetUserFromRequest extracts the user from request cookies.
@@ -251,41 +415,79 @@ func (m *Manager) GetUserFromRequest(r *http.Request) *User {
 	return user
 }
 
-// SetSessionCookie sets the session cookie on the response.
-func SetSessionCookie(w http.ResponseWriter, sessionID string) {
+// RefreshSessionCookie resolves r's session cookie the same way
+// GetUserFromRequest does, and additionally re-issues the cookie with
+// a MaxAge matching whatever idle-timeout extension
+// GetUserFromSession's touch just applied — the middleware hook that
+// keeps an actively used session's cookie in sync with its
+// server-side expiry instead of letting the browser's copy of MaxAge
+// go stale. Returns nil, writing nothing, if there's no valid session.
+func (m *Manager) RefreshSessionCookie(w http.ResponseWriter, r *http.Request, secure bool) *User {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return nil
+	}
+
+	user, expiresAt, err := m.getUserAndTouch(r.Context(), cookie.Value)
+	if err != nil {
+		return nil
+	}
+
+	SetSessionCookie(w, cookie.Value, time.Until(expiresAt), secure)
+	return user
+}
+
+// InitialSessionMaxAge returns the cookie MaxAge to use for a freshly
+// created session (Login/LoginWithoutPassword): since CreatedAt == now
+// at creation, sessionExpiry collapses to min(IdleTimeout, MaxLifetime).
+func (m *Manager) InitialSessionMaxAge() time.Duration {
+	if m.IdleTimeout < m.MaxLifetime {
+		return m.IdleTimeout
+	}
+	return m.MaxLifetime
+}
+
+// SetSessionCookie sets the session cookie on the response with the
+// given MaxAge. secure should be true whenever the request arrived
+// over TLS, directly or (via Server.IsSecureRequest) through a trusted
+// reverse proxy.
+func SetSessionCookie(w http.ResponseWriter, sessionID string, maxAge time.Duration, secure bool) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     SessionCookieName,
 		Value:    sessionID,
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   true,
+		Secure:   secure,
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   int(SessionDuration.Seconds()),
+		MaxAge:   int(maxAge.Seconds()),
 	})
 }
 
 // ClearSessionCookie removes the session cookie.
-func ClearSessionCookie(w http.ResponseWriter) {
+func ClearSessionCookie(w http.ResponseWriter, secure bool) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     SessionCookieName,
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   true,
+		Secure:   secure,
 		SameSite: http.SameSiteLaxMode,
 		MaxAge:   -1,
 	})
 }
 
-// CleanupExpiredSessions removes expired sessions from the database.
-// This should be called periodically (e.g., via a background goroutine).
+// CleanupExpiredSessions removes expired sessions from the session
+// store, and login attempts older than loginAttemptWindow from the
+// login_attempts table. This should be called periodically (e.g., via a
+// background goroutine); the session half is a no-op for stores (like
+// Redis) that expire entries natively.
 func (m *Manager) CleanupExpiredSessions(ctx context.Context) error {
-	q := dbgen.New(m.db)
-	err := q.DeleteExpiredSessions(ctx)
-	if err != nil {
-		slog.Error("failed to cleanup expired sessions", "error", err)
+	if err := m.sessions.DeleteExpired(ctx); err != nil {
 		return err
 	}
+	if err := m.cleanupLoginAttempts(ctx); err != nil {
+		slog.Error("failed to clean up old login attempts", "error", err)
+	}
 	slog.Debug("cleaned up expired sessions")
 	return nil
 }
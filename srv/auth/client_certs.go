@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// ErrInvalidClientCert is returned when a presented client certificate
+// doesn't match any registered fingerprint, or matches one that's been
+// revoked or has expired.
+var ErrInvalidClientCert = errors.New("auth: unknown, revoked, or expired client certificate")
+
+// ClientCert is a machine identity bound to a user account via the
+// SHA-256 fingerprint of an mTLS client certificate. It lives in its
+// own client_certs table, separate from both sessions and API tokens:
+// unlike a token it's never transmitted on the wire (the fingerprint is
+// public; the private key stays on the calling host), so there's no
+// secret to hash or rotate here — just a fingerprint to match.
+type ClientCert struct {
+	Fingerprint string
+	UserID      string
+	Name        string
+	CreatedAt   time.Time
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+}
+
+// RegisterClientCert parses a PEM-encoded certificate, computes its
+// SHA-256 fingerprint, and binds it to userID under name. It's the
+// provisioning step an operator runs once per machine identity; there's
+// no corresponding "password" to distribute, only the cert/key pair the
+// caller already has.
+func (m *Manager) RegisterClientCert(ctx context.Context, userID string, pemBytes []byte, name string) (*ClientCert, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("register client cert: not a PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("register client cert: %w", err)
+	}
+
+	fingerprint := fingerprintCert(cert)
+	now := time.Now()
+	var expiresAt *time.Time
+	if !cert.NotAfter.IsZero() {
+		expiresAt = &cert.NotAfter
+	}
+
+	q := dbgen.New(m.db)
+	if err := q.CreateClientCert(ctx, dbgen.CreateClientCertParams{
+		Fingerprint: fingerprint,
+		UserID:      userID,
+		Name:        name,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("register client cert: %w", err)
+	}
+
+	return &ClientCert{
+		Fingerprint: fingerprint,
+		UserID:      userID,
+		Name:        name,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// RevokeClientCert marks a registered certificate as no longer usable,
+// identified by its fingerprint.
+func (m *Manager) RevokeClientCert(ctx context.Context, fingerprint string) error {
+	return dbgen.New(m.db).RevokeClientCert(ctx, dbgen.RevokeClientCertParams{
+		Fingerprint: fingerprint,
+		RevokedAt:   timePtr(time.Now()),
+	})
+}
+
+// GetUserFromClientCert resolves cert (the leaf certificate presented
+// during the TLS handshake, i.e. r.TLS.PeerCertificates[0]) to a User by
+// matching its fingerprint against the client_certs table. It returns
+// ErrInvalidClientCert for an unregistered, revoked, or expired
+// certificate rather than distinguishing those cases, matching
+// GetUserFromSession's treatment of invalid sessions.
+func (m *Manager) GetUserFromClientCert(ctx context.Context, cert *x509.Certificate) (*User, error) {
+	fingerprint := fingerprintCert(cert)
+
+	q := dbgen.New(m.db)
+	row, err := q.GetClientCertByFingerprint(ctx, fingerprint)
+	if err != nil {
+		return nil, ErrInvalidClientCert
+	}
+	if row.RevokedAt != nil {
+		return nil, ErrInvalidClientCert
+	}
+	if row.ExpiresAt != nil && time.Now().After(*row.ExpiresAt) {
+		return nil, ErrInvalidClientCert
+	}
+
+	return m.GetUserByID(ctx, row.UserID)
+}
+
+func fingerprintCert(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return fmt.Sprintf("%x", sum)
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// loginAttemptWindow is the sliding window Login checks failures
+// against, per email and per IP independently — either one tripping
+// the threshold locks out the request, since a credential-stuffing run
+// might spread across many emails from one IP, or hammer one email from
+// many IPs via a botnet.
+const loginAttemptWindow = 15 * time.Minute
+
+// maxLoginAttempts is the failure count within loginAttemptWindow that
+// triggers ErrTooManyAttempts.
+const maxLoginAttempts = 10
+
+// backoffStartAttempt is the failure count at which Login starts
+// sleeping an increasing delay before responding, so online guessing
+// slows down well before it's locked out outright by maxLoginAttempts,
+// without tying up a goroutine indefinitely the way blocking until the
+// window clears would. backoffMaxDelay caps how long that sleep grows.
+const (
+	backoffStartAttempt = 5
+	backoffMaxDelay     = 4 * time.Second
+)
+
+// checkLoginRateLimit rejects with ErrTooManyAttempts if email or ip
+// has hit maxLoginAttempts failures within loginAttemptWindow, and
+// otherwise sleeps an increasing backoff once either counter passes
+// backoffStartAttempt.
+func (m *Manager) checkLoginRateLimit(ctx context.Context, email, ip string) error {
+	q := dbgen.New(m.db)
+	since := time.Now().Add(-loginAttemptWindow)
+
+	emailFailures, err := q.CountRecentLoginFailuresByEmail(ctx, dbgen.CountRecentLoginFailuresByEmailParams{
+		Email:       email,
+		AttemptedAt: since,
+	})
+	if err != nil {
+		slog.Error("failed to check login attempts by email", "error", err)
+		emailFailures = 0
+	}
+
+	var ipFailures int64
+	if ip != "" {
+		ipFailures, err = q.CountRecentLoginFailuresByIP(ctx, dbgen.CountRecentLoginFailuresByIPParams{
+			IP:          ip,
+			AttemptedAt: since,
+		})
+		if err != nil {
+			slog.Error("failed to check login attempts by IP", "error", err)
+			ipFailures = 0
+		}
+	}
+
+	worst := emailFailures
+	if ipFailures > worst {
+		worst = ipFailures
+	}
+
+	if worst >= maxLoginAttempts {
+		slog.Warn("login locked out: too many recent failures", "email", email, "ip", ip, "email_failures", emailFailures, "ip_failures", ipFailures)
+		return ErrTooManyAttempts
+	}
+
+	if worst >= backoffStartAttempt {
+		delay := backoffDelay(int(worst))
+		slog.Warn("login attempt slowed by backoff", "email", email, "ip", ip, "failures", worst, "delay", delay)
+		time.Sleep(delay)
+	}
+
+	return nil
+}
+
+// backoffDelay doubles starting at backoffStartAttempt failures,
+// capped at backoffMaxDelay.
+func backoffDelay(failures int) time.Duration {
+	shift := failures - backoffStartAttempt
+	delay := 250 * time.Millisecond << shift
+	if delay > backoffMaxDelay || delay <= 0 {
+		return backoffMaxDelay
+	}
+	return delay
+}
+
+// recordLoginAttempt logs one login outcome for rate-limiting purposes.
+// Failures here are logged but not returned — a broken audit log
+// shouldn't block login itself.
+func (m *Manager) recordLoginAttempt(ctx context.Context, email, ip string, success bool) {
+	q := dbgen.New(m.db)
+	if err := q.CreateLoginAttempt(ctx, dbgen.CreateLoginAttemptParams{
+		Email:       email,
+		IP:          ip,
+		AttemptedAt: time.Now(),
+		Success:     success,
+	}); err != nil {
+		slog.Error("failed to record login attempt", "error", err)
+	}
+}
+
+// ClearLoginAttempts removes every recorded attempt for email, for an
+// admin to unlock a legitimately-locked-out user without waiting out
+// loginAttemptWindow.
+func (m *Manager) ClearLoginAttempts(ctx context.Context, email string) error {
+	return dbgen.New(m.db).DeleteLoginAttemptsByEmail(ctx, email)
+}
+
+// cleanupLoginAttempts deletes attempts older than loginAttemptWindow,
+// called from CleanupExpiredSessions's periodic job so the
+// login_attempts table doesn't grow unbounded.
+func (m *Manager) cleanupLoginAttempts(ctx context.Context) error {
+	return dbgen.New(m.db).DeleteOldLoginAttempts(ctx, time.Now().Add(-loginAttemptWindow))
+}
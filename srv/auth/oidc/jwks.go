@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// jwk is a single entry from a provider's JWKS document (RFC 7517),
+// restricted to the RSA and EC key types real-world OIDC providers
+// actually issue for ID token signing.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"` // RSA modulus, base64url
+	E   string `json:"e"` // RSA exponent, base64url
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// publicKey decodes this JWK into a standard library public key usable
+// with crypto/rsa or crypto/ecdsa Verify functions.
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode RSA n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode RSA e: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode EC x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode EC y: %w", err)
+		}
+		curve := elliptic.P256()
+		if k.Crv == "P-384" {
+			curve = elliptic.P384()
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWK kty %q", k.Kty)
+	}
+}
+
+// keyByID fetches (and caches for jwksCacheTTL) the provider's JWKS and
+// returns the public key matching kid.
+func (p *Provider) keyByID(ctx context.Context, kid string) (any, error) {
+	p.jwksMu.Lock()
+	stale := p.jwks == nil || time.Since(p.jwksFetched) > jwksCacheTTL
+	p.jwksMu.Unlock()
+
+	if stale {
+		if err := p.refreshJWKS(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	p.jwksMu.Lock()
+	defer p.jwksMu.Unlock()
+	for _, k := range p.jwks.Keys {
+		if k.Kid == kid {
+			return k.publicKey()
+		}
+	}
+	return nil, fmt.Errorf("no JWKS key found for kid %q", kid)
+}
+
+func (p *Provider) refreshJWKS(ctx context.Context) error {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	p.jwksMu.Lock()
+	p.jwks = &set
+	p.jwksFetched = time.Now()
+	p.jwksMu.Unlock()
+	return nil
+}
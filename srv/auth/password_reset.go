@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// resetTokenLength matches SessionIDLength: both are bearer secrets
+// handed to a browser, just over two different channels (a cookie vs.
+// a link in an email).
+const resetTokenLength = SessionIDLength
+
+// passwordResetTTL and emailVerificationTTL bound how long a minted
+// link stays usable. An hour is generous enough for someone to find the
+// email without leaving the token valid indefinitely.
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = time.Hour
+)
+
+var (
+	// ErrInvalidResetToken covers an unknown, expired, or already-used
+	// password reset or email verification token alike — like
+	// ErrInvalidSession, the cause isn't worth distinguishing to the
+	// caller, who can only ever ask the user to request a fresh link.
+	ErrInvalidResetToken = errors.New("auth: invalid or expired token")
+)
+
+// RequestPasswordReset mints a single-use, 1-hour password reset token
+// for email and mails it via m.Mailer, if a user with that email
+// exists. It always returns nil regardless of whether the email
+// matched a user, so callers can't use response timing/errors to probe
+// which emails are registered.
+func (m *Manager) RequestPasswordReset(ctx context.Context, email string) error {
+	q := dbgen.New(m.db)
+	user, err := q.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("database error looking up user for password reset", "error", err)
+		}
+		return nil
+	}
+
+	token, hash, err := newResetToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := q.CreatePasswordReset(ctx, dbgen.CreatePasswordResetParams{
+		TokenHash: hash,
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetTTL),
+	}); err != nil {
+		slog.Error("failed to create password reset", "error", err)
+		return err
+	}
+
+	m.sendMail(user.Email, "Reset your password",
+		fmt.Sprintf("Use this code to reset your password (expires in 1 hour): %s", token))
+	return nil
+}
+
+// ConfirmPasswordReset validates token and, if it's unexpired and
+// unused, sets the bound user's password to newPassword and consumes
+// the token so it can't be replayed.
+func (m *Manager) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	q := dbgen.New(m.db)
+
+	row, err := q.GetPasswordResetByHash(ctx, hashResetToken(token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if row.UsedAt != nil || time.Now().After(row.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	passwordHash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := q.UpdateUserPassword(ctx, dbgen.UpdateUserPasswordParams{
+		PasswordHash: passwordHash,
+		ID:           row.UserID,
+	}); err != nil {
+		return err
+	}
+
+	return q.MarkPasswordResetUsed(ctx, dbgen.MarkPasswordResetUsedParams{
+		TokenHash: hashResetToken(token),
+		UsedAt:    timePtr(time.Now()),
+	})
+}
+
+// RequestEmailVerification mints a single-use, 1-hour verification
+// token for userID's current email and mails it via m.Mailer.
+func (m *Manager) RequestEmailVerification(ctx context.Context, userID string) error {
+	q := dbgen.New(m.db)
+	user, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	token, hash, err := newResetToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := q.CreateEmailVerification(ctx, dbgen.CreateEmailVerificationParams{
+		TokenHash: hash,
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(emailVerificationTTL),
+	}); err != nil {
+		slog.Error("failed to create email verification", "error", err)
+		return err
+	}
+
+	m.sendMail(user.Email, "Verify your email",
+		fmt.Sprintf("Use this code to verify your email (expires in 1 hour): %s", token))
+	return nil
+}
+
+// ConfirmEmailVerification validates token and, if it's unexpired and
+// unused, stamps the bound user's email_verified_at and consumes the
+// token.
+func (m *Manager) ConfirmEmailVerification(ctx context.Context, token string) error {
+	q := dbgen.New(m.db)
+
+	row, err := q.GetEmailVerificationByHash(ctx, hashResetToken(token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if row.UsedAt != nil || time.Now().After(row.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	if err := q.MarkUserEmailVerified(ctx, dbgen.MarkUserEmailVerifiedParams{
+		ID:              row.UserID,
+		EmailVerifiedAt: timePtr(time.Now()),
+	}); err != nil {
+		return err
+	}
+
+	return q.MarkEmailVerificationUsed(ctx, dbgen.MarkEmailVerificationUsedParams{
+		TokenHash: hashResetToken(token),
+		UsedAt:    timePtr(time.Now()),
+	})
+}
+
+// sendMail delegates to m.Mailer, falling back to a logged no-op if
+// none is configured, and logging (rather than returning) any send
+// failure — a bounced email shouldn't turn into a 500 for the user who
+// requested the reset.
+func (m *Manager) sendMail(to, subject, body string) {
+	if m.Mailer == nil {
+		slog.Warn("no mailer configured, dropping message", "to", to, "subject", subject)
+		return
+	}
+	if err := m.Mailer.Send(to, subject, body); err != nil {
+		slog.Error("failed to send mail", "to", to, "subject", subject, "error", err)
+	}
+}
+
+// newResetToken generates a random bearer token (returned once, for the
+// email) alongside the SHA-256 hash that's actually persisted — mirrors
+// how personal access tokens are stored (see tokens.hashSecret): the
+// plaintext that grants access never touches the database.
+func newResetToken() (token, hash string, err error) {
+	b := make([]byte, resetTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, hashResetToken(token), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package auth
+
+// Permission is a coarse-grained capability level, ordered from least
+// to most privileged: Viewer can read, Editor can also write, Admin can
+// do anything Editor can plus user/account management.
+//
+// This sits alongside the existing Role field's account-lifecycle
+// values ("", "pending", "approved", "admin", "suspended") rather than
+// replacing them - Role answers "is this account active", Permission
+// answers "what can this request do" - so permissionForRole is the one
+// place that translates between the two.
+type Permission string
+
+const (
+	PermissionViewer Permission = "viewer"
+	PermissionEditor Permission = "editor"
+	PermissionAdmin  Permission = "admin"
+)
+
+// permissionRank orders permissions so HasPermission can compare them
+// numerically instead of special-casing every pair.
+var permissionRank = map[Permission]int{
+	PermissionViewer: 0,
+	PermissionEditor: 1,
+	PermissionAdmin:  2,
+}
+
+// permissionForRole maps an account's Role to the Permission it grants.
+// "approved" - this package's normal steady-state role for a logged-in
+// user - grants Editor, since the accounts needing write access
+// (rangers, researchers submitting documents) are exactly the ones
+// that clear approval. "pending" and "suspended" accounts, and the
+// zero value for an unauthenticated request, get Viewer.
+func permissionForRole(role string) Permission {
+	switch role {
+	case "admin":
+		return PermissionAdmin
+	case "approved":
+		return PermissionEditor
+	default:
+		return PermissionViewer
+	}
+}
+
+// HasPermission reports whether role grants at least the required
+// permission level.
+func HasPermission(role string, required Permission) bool {
+	return permissionRank[permissionForRole(role)] >= permissionRank[required]
+}
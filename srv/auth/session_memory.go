@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemorySessionStore is an in-process, non-persistent SessionStore.
+// It exists for tests and single-node dev runs where spinning up
+// SQLite (or Redis) just to exercise login/logout isn't worth it;
+// sessions vanish on restart. Expiry is checked lazily on Get rather
+// than by a background sweep, since DeleteExpired is already called
+// periodically by whoever wires up Manager (see cmd/srv).
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]Session)}
+}
+
+func (m *MemorySessionStore) Create(ctx context.Context, sess Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[sess.ID] = sess
+	return nil
+}
+
+func (m *MemorySessionStore) Get(ctx context.Context, id string) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[id]
+	if !ok || sess.ExpiresAt.Before(time.Now()) {
+		return Session{}, ErrInvalidSession
+	}
+	return sess, nil
+}
+
+func (m *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *MemorySessionStore) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, sess := range m.sessions {
+		if sess.ExpiresAt.Before(now) {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemorySessionStore) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[id]
+	if !ok {
+		return nil
+	}
+	sess.ExpiresAt = time.Now().Add(ttl)
+	m.sessions[id] = sess
+	return nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisSessionKeyPrefix namespaces session keys in a shared Redis
+// instance so they don't collide with keys other parts of a
+// deployment might store there.
+const redisSessionKeyPrefix = "5mp:session:"
+
+// RedisSessionStore is a SessionStore for horizontally scaled
+// deployments, where sessions need to be visible to every srv instance
+// behind a load balancer rather than pinned to whichever one handled
+// login. Expiration is native to Redis (each key carries its own TTL),
+// so DeleteExpired is a no-op here — there's nothing left for it to
+// find once a key's TTL elapses.
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+// NewRedisSessionStore wraps an already-configured redis.Client. The
+// caller owns the client's lifecycle (address, auth, TLS, pooling).
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+func (s *RedisSessionStore) Create(ctx context.Context, sess Session) error {
+	ttl := time.Until(sess.ExpiresAt)
+	if ttl <= 0 {
+		return ErrSessionStorage
+	}
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return ErrSessionStorage
+	}
+	if err := s.client.Set(ctx, redisSessionKeyPrefix+sess.ID, data, ttl).Err(); err != nil {
+		slog.Error("redis: failed to create session", "error", err)
+		return ErrSessionStorage
+	}
+	return nil
+}
+
+func (s *RedisSessionStore) Get(ctx context.Context, id string) (Session, error) {
+	data, err := s.client.Get(ctx, redisSessionKeyPrefix+id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return Session{}, ErrInvalidSession
+		}
+		slog.Error("redis: failed to get session", "error", err)
+		return Session{}, ErrSessionStorage
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		slog.Error("redis: failed to decode session", "error", err)
+		return Session{}, ErrSessionStorage
+	}
+	return sess, nil
+}
+
+func (s *RedisSessionStore) Delete(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, redisSessionKeyPrefix+id).Err(); err != nil {
+		slog.Error("redis: failed to delete session", "error", err)
+		return ErrSessionStorage
+	}
+	return nil
+}
+
+func (s *RedisSessionStore) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+func (s *RedisSessionStore) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	sess, err := s.Get(ctx, id)
+	if err != nil {
+		if err == ErrInvalidSession {
+			return nil
+		}
+		return err
+	}
+	sess.ExpiresAt = time.Now().Add(ttl)
+	return s.Create(ctx, sess)
+}
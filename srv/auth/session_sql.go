@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// SQLSessionStore is the default SessionStore, backed by the same
+// SQLite database as everything else. It's what NewManager wires up
+// unless told otherwise.
+type SQLSessionStore struct {
+	db *sql.DB
+}
+
+// NewSQLSessionStore creates a SessionStore backed by db.
+func NewSQLSessionStore(db *sql.DB) *SQLSessionStore {
+	return &SQLSessionStore{db: db}
+}
+
+func (s *SQLSessionStore) Create(ctx context.Context, sess Session) error {
+	q := dbgen.New(s.db)
+	if err := q.CreateSession(ctx, dbgen.CreateSessionParams{
+		ID:        sess.ID,
+		UserID:    sess.UserID,
+		CreatedAt: sess.CreatedAt,
+		ExpiresAt: sess.ExpiresAt,
+	}); err != nil {
+		slog.Error("failed to create session", "user_id", sess.UserID, "error", err)
+		return ErrSessionStorage
+	}
+	return nil
+}
+
+func (s *SQLSessionStore) Get(ctx context.Context, id string) (Session, error) {
+	q := dbgen.New(s.db)
+	row, err := q.GetSessionByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Session{}, ErrInvalidSession
+		}
+		slog.Error("database error retrieving session", "error", err)
+		return Session{}, ErrSessionStorage
+	}
+	return Session{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+	}, nil
+}
+
+func (s *SQLSessionStore) Delete(ctx context.Context, id string) error {
+	q := dbgen.New(s.db)
+	if err := q.DeleteSession(ctx, id); err != nil {
+		slog.Error("failed to delete session", "error", err)
+		return ErrSessionStorage
+	}
+	return nil
+}
+
+func (s *SQLSessionStore) DeleteExpired(ctx context.Context) error {
+	q := dbgen.New(s.db)
+	if err := q.DeleteExpiredSessions(ctx); err != nil {
+		slog.Error("failed to delete expired sessions", "error", err)
+		return ErrSessionStorage
+	}
+	return nil
+}
+
+func (s *SQLSessionStore) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	q := dbgen.New(s.db)
+	if err := q.TouchSession(ctx, dbgen.TouchSessionParams{
+		ID:        id,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		slog.Error("failed to touch session", "error", err)
+		return ErrSessionStorage
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Session is the storage-layer representation of a session: just the
+// ID-to-user mapping and its lifetime. SessionStore implementations
+// only ever see this, never the joined user row — Manager looks up the
+// User separately (via GetUserByID) once a SessionStore confirms the
+// session is valid, so swapping storage backends can't change what a
+// session is allowed to carry.
+type Session struct {
+	ID        string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SessionStore persists sessions for auth.Manager. Every method treats
+// a missing or expired session as an ErrInvalidSession error (for Get)
+// or a silent no-op (for Delete/Touch) rather than a distinct
+// not-found error, so Manager doesn't need backend-specific error
+// handling. Unexpected storage failures are reported as
+// ErrSessionStorage.
+type SessionStore interface {
+	// Create persists a new session. It's an error if sess.ID already
+	// exists.
+	Create(ctx context.Context, sess Session) error
+
+	// Get returns the session for id. It returns ErrInvalidSession if
+	// no such session exists or it has already expired.
+	Get(ctx context.Context, id string) (Session, error)
+
+	// Delete removes a session. It's not an error if id doesn't exist.
+	Delete(ctx context.Context, id string) error
+
+	// DeleteExpired removes every session whose ExpiresAt has passed.
+	DeleteExpired(ctx context.Context) error
+
+	// Touch extends id's ExpiresAt to now+ttl, for sliding expiration.
+	// It's not an error if id doesn't exist (the caller is about to
+	// find that out from its own Get anyway).
+	Touch(ctx context.Context, id string, ttl time.Duration) error
+}
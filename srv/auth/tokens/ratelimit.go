@@ -0,0 +1,58 @@
+package tokens
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter applies a token-bucket limit per API token, so a
+// compromised token is easy to contain instead of being able to hammer
+// the whole /api/* surface at the rate of the underlying connection.
+type RateLimiter struct {
+	ratePerSec float64
+	burst      int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewRateLimiter creates a limiter refilling at ratePerSec tokens/second
+// up to burst tokens.
+func NewRateLimiter(ratePerSec float64, burst int) *RateLimiter {
+	return &RateLimiter{ratePerSec: ratePerSec, burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// Allow reports whether a request for the given token ID may proceed,
+// and returns the remaining bucket size and seconds until it next
+// refills by one token (for X-RateLimit-Remaining/X-RateLimit-Reset).
+func (rl *RateLimiter) Allow(tokenKey string) (allowed bool, remaining int, resetSeconds int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	b, ok := rl.buckets[tokenKey]
+	if !ok {
+		b = &bucket{tokens: float64(rl.burst), lastFill: now}
+		rl.buckets[tokenKey] = b
+	}
+
+	elapsed := now.Sub(b.lastFill).Seconds()
+	b.tokens += elapsed * rl.ratePerSec
+	if b.tokens > float64(rl.burst) {
+		b.tokens = float64(rl.burst)
+	}
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		resetIn := (1 - b.tokens) / rl.ratePerSec
+		return false, 0, int(resetIn) + 1
+	}
+
+	b.tokens--
+	return true, int(b.tokens), int(1 / rl.ratePerSec)
+}
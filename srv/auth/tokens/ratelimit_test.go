@@ -0,0 +1,51 @@
+package tokens
+
+import "testing"
+
+func TestRateLimiterAllowsUpToBurst(t *testing.T) {
+	rl := NewRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		allowed, _, _ := rl.Allow("user-a")
+		if !allowed {
+			t.Fatalf("request %d: expected allowed, got denied", i)
+		}
+	}
+
+	allowed, remaining, resetSeconds := rl.Allow("user-a")
+	if allowed {
+		t.Fatal("expected the 4th request within the burst window to be denied")
+	}
+	if remaining != 0 {
+		t.Errorf("remaining = %d, want 0 when denied", remaining)
+	}
+	if resetSeconds <= 0 {
+		t.Errorf("resetSeconds = %d, want > 0", resetSeconds)
+	}
+}
+
+func TestRateLimiterIndependentBuckets(t *testing.T) {
+	rl := NewRateLimiter(1, 1)
+
+	if allowed, _, _ := rl.Allow("user-a"); !allowed {
+		t.Fatal("expected user-a's first request to be allowed")
+	}
+	if allowed, _, _ := rl.Allow("user-a"); allowed {
+		t.Fatal("expected user-a's second request to be denied")
+	}
+	if allowed, _, _ := rl.Allow("user-b"); !allowed {
+		t.Fatal("expected user-b to have its own untouched bucket")
+	}
+}
+
+func TestRateLimiterNeverExceedsBurst(t *testing.T) {
+	rl := NewRateLimiter(1000, 5)
+
+	for i := 0; i < 5; i++ {
+		rl.Allow("user-a")
+	}
+	_, remaining, _ := rl.Allow("user-a")
+	if remaining >= 5 {
+		t.Errorf("remaining = %d, bucket should never report at or above its burst size once depleted", remaining)
+	}
+}
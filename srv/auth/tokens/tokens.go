@@ -0,0 +1,199 @@
+// Package tokens implements personal access tokens for the /api/*
+// surface: minting, hashing/verifying, and a per-token rate limiter, so
+// programmatic clients (QField, CI pipelines, the ranger phone app) can
+// authenticate without a browser session cookie. This is the "sibling
+// subsystem to sessions" for long-lived, revocable credentials: its own
+// table (not auth's sessions table), its own scopes, and its own
+// ListForUser/Revoke self-service API, already wired into
+// Server.GetUserFromRequest as a Bearer-header fallback ahead of the
+// cookie check.
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// tokenPrefix identifies this server's tokens in logs/secret scanners,
+// following the "cpt_<prefix>_<secret>" convention described for
+// conservation-patrol-tracking personal access tokens.
+const tokenPrefix = "cpt"
+
+var (
+	ErrInvalidFormat = errors.New("tokens: malformed token")
+	ErrNotFound      = errors.New("tokens: unknown, expired, or revoked token")
+)
+
+// argon2 parameters. These follow the OWASP-recommended minimums for
+// argon2id (1 iteration, 64MB memory, 4 threads) given tokens are
+// verified on every API request and must stay fast to check.
+const (
+	argonTime    = 1
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	argonKeyLen  = 32
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Scope is a typed permission a token can be granted; handlers gate
+// writes with RequireScope against this allowlist rather than trusting
+// arbitrary strings from the tokens table.
+type Scope string
+
+const (
+	ScopeUploadWrite    Scope = "upload:write"
+	ScopeChecklistWrite Scope = "checklist:write"
+	ScopeAdmin          Scope = "admin"
+)
+
+// ValidScopes lists every scope a token may be granted.
+var ValidScopes = []Scope{ScopeUploadWrite, ScopeChecklistWrite, ScopeAdmin}
+
+func isValidScope(s string) bool {
+	for _, v := range ValidScopes {
+		if string(v) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Token is a minted personal access token, returned once in plaintext
+// at creation time.
+type Token struct {
+	ID        int64
+	UserID    string
+	Name      string
+	Scopes    []string
+	Plaintext string // only populated by Create, never stored
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+// Manager mints and verifies personal access tokens against the
+// `tokens` table (id, user_id, name, scopes, prefix, hash, created_at,
+// last_used_at, expires_at, revoked_at).
+type Manager struct {
+	db *sql.DB
+}
+
+// NewManager creates a token Manager.
+func NewManager(db *sql.DB) *Manager {
+	return &Manager{db: db}
+}
+
+// Create mints a new token for userID with the given name and scopes,
+// valid until expiresAt (nil for no expiry). The plaintext secret is
+// returned only here; only its argon2id hash is persisted.
+func (m *Manager) Create(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (*Token, error) {
+	for _, s := range scopes {
+		if !isValidScope(s) {
+			return nil, fmt.Errorf("invalid scope %q", s)
+		}
+	}
+
+	prefixBytes := make([]byte, 5) // 5 bytes -> 8 base32 chars
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, err
+	}
+	secretBytes := make([]byte, 25)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+	prefix := b32.EncodeToString(prefixBytes)
+	secret := b32.EncodeToString(secretBytes)
+	plaintext := fmt.Sprintf("%s_%s_%s", tokenPrefix, prefix, secret)
+
+	hash := hashSecret(secret)
+
+	q := dbgen.New(m.db)
+	now := time.Now()
+	id, err := q.CreateToken(ctx, dbgen.CreateTokenParams{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    strings.Join(scopes, ","),
+		Prefix:    prefix,
+		Hash:      hash,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create token: %w", err)
+	}
+
+	return &Token{ID: id, UserID: userID, Name: name, Scopes: scopes, Plaintext: plaintext, CreatedAt: now, ExpiresAt: expiresAt}, nil
+}
+
+// Verify checks a bearer token's plaintext against the tokens table and
+// returns the user ID and granted scopes it was minted for. It updates
+// last_used_at on success.
+func (m *Manager) Verify(ctx context.Context, plaintext string) (userID string, scopes []string, err error) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != tokenPrefix {
+		return "", nil, ErrInvalidFormat
+	}
+	prefix, secret := parts[1], parts[2]
+
+	q := dbgen.New(m.db)
+	row, err := q.GetTokenByPrefix(ctx, prefix)
+	if err != nil {
+		return "", nil, ErrNotFound
+	}
+	if row.RevokedAt != nil {
+		return "", nil, ErrNotFound
+	}
+	if row.ExpiresAt != nil && time.Now().After(*row.ExpiresAt) {
+		return "", nil, ErrNotFound
+	}
+
+	if subtle.ConstantTimeCompare(hashSecret(secret), row.Hash) != 1 {
+		return "", nil, ErrNotFound
+	}
+
+	_ = q.TouchTokenLastUsed(ctx, dbgen.TouchTokenLastUsedParams{ID: row.ID, LastUsedAt: time.Now()})
+
+	var grantedScopes []string
+	if row.Scopes != "" {
+		grantedScopes = strings.Split(row.Scopes, ",")
+	}
+	return row.UserID, grantedScopes, nil
+}
+
+// Revoke marks a token as no longer usable.
+func (m *Manager) Revoke(ctx context.Context, tokenID int64) error {
+	return dbgen.New(m.db).RevokeToken(ctx, dbgen.RevokeTokenParams{ID: tokenID, RevokedAt: timePtr(time.Now())})
+}
+
+// ListForUser returns a user's tokens (without plaintext/hash) for the
+// settings page.
+func (m *Manager) ListForUser(ctx context.Context, userID string) ([]dbgen.Token, error) {
+	return dbgen.New(m.db).ListTokensByUser(ctx, userID)
+}
+
+func hashSecret(secret string) []byte {
+	return argon2.IDKey([]byte(secret), []byte(tokenPrefix), argonTime, argonMemory, argonThreads, argonKeyLen)
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+// HasScope reports whether scopes grants required.
+func HasScope(scopes []string, required Scope) bool {
+	for _, s := range scopes {
+		if s == string(required) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package tokens
+
+import "testing"
+
+func TestHashSecretDeterministic(t *testing.T) {
+	a := hashSecret("same-secret")
+	b := hashSecret("same-secret")
+	if string(a) != string(b) {
+		t.Error("hashSecret is not deterministic for the same input")
+	}
+}
+
+func TestHashSecretDiffersPerSecret(t *testing.T) {
+	a := hashSecret("secret-one")
+	b := hashSecret("secret-two")
+	if string(a) == string(b) {
+		t.Error("hashSecret produced the same hash for different secrets")
+	}
+}
+
+func TestIsValidScope(t *testing.T) {
+	cases := []struct {
+		scope string
+		want  bool
+	}{
+		{string(ScopeUploadWrite), true},
+		{string(ScopeChecklistWrite), true},
+		{string(ScopeAdmin), true},
+		{"nonexistent:scope", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := isValidScope(c.scope); got != c.want {
+			t.Errorf("isValidScope(%q) = %v, want %v", c.scope, got, c.want)
+		}
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	scopes := []string{string(ScopeUploadWrite), string(ScopeChecklistWrite)}
+
+	if !HasScope(scopes, ScopeUploadWrite) {
+		t.Error("expected HasScope to find a granted scope")
+	}
+	if HasScope(scopes, ScopeAdmin) {
+		t.Error("expected HasScope to reject an ungranted scope")
+	}
+	if HasScope(nil, ScopeUploadWrite) {
+		t.Error("expected HasScope to reject against a nil scope list")
+	}
+}
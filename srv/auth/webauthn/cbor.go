@@ -0,0 +1,120 @@
+package webauthn
+
+import (
+	"fmt"
+	"math"
+)
+
+// decodeCBOR decodes the minimal subset of CBOR (RFC 8949) WebAuthn
+// actually uses: unsigned/negative ints, byte strings, text strings,
+// maps and arrays of those. It returns the decoded value (one of
+// int64, []byte, string, []any, map[any]any) and the number of bytes
+// consumed from b.
+//
+// This is not a general-purpose CBOR decoder: attestationObject and
+// COSE_Key both only ever nest these types, so anything else is an
+// error rather than silently accepted.
+func decodeCBOR(b []byte) (any, int, error) {
+	if len(b) == 0 {
+		return nil, 0, fmt.Errorf("cbor: empty input")
+	}
+	major := b[0] >> 5
+	addl := b[0] & 0x1f
+
+	val, hdrLen, err := readCBORLength(b, addl)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case 0: // unsigned int
+		return int64(val), hdrLen, nil
+	case 1: // negative int
+		return -1 - int64(val), hdrLen, nil
+	case 2: // byte string
+		n := int(val)
+		if hdrLen+n > len(b) {
+			return nil, 0, fmt.Errorf("cbor: byte string overruns input")
+		}
+		return append([]byte{}, b[hdrLen:hdrLen+n]...), hdrLen + n, nil
+	case 3: // text string
+		n := int(val)
+		if hdrLen+n > len(b) {
+			return nil, 0, fmt.Errorf("cbor: text string overruns input")
+		}
+		return string(b[hdrLen : hdrLen+n]), hdrLen + n, nil
+	case 4: // array
+		n := int(val)
+		items := make([]any, 0, n)
+		off := hdrLen
+		for i := 0; i < n; i++ {
+			item, used, err := decodeCBOR(b[off:])
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			off += used
+		}
+		return items, off, nil
+	case 5: // map
+		n := int(val)
+		m := make(map[any]any, n)
+		off := hdrLen
+		for i := 0; i < n; i++ {
+			k, used, err := decodeCBOR(b[off:])
+			if err != nil {
+				return nil, 0, err
+			}
+			off += used
+			v, used, err := decodeCBOR(b[off:])
+			if err != nil {
+				return nil, 0, err
+			}
+			off += used
+			m[k] = v
+		}
+		return m, off, nil
+	default:
+		return nil, 0, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+// readCBORLength decodes a CBOR argument (the "additional information"
+// in the initial byte, possibly followed by 1/2/4/8 bytes) into a
+// length/value and reports how many bytes of the header it consumed
+// (including the initial byte).
+func readCBORLength(b []byte, addl byte) (uint64, int, error) {
+	switch {
+	case addl < 24:
+		return uint64(addl), 1, nil
+	case addl == 24:
+		if len(b) < 2 {
+			return 0, 0, fmt.Errorf("cbor: truncated 1-byte length")
+		}
+		return uint64(b[1]), 2, nil
+	case addl == 25:
+		if len(b) < 3 {
+			return 0, 0, fmt.Errorf("cbor: truncated 2-byte length")
+		}
+		return uint64(b[1])<<8 | uint64(b[2]), 3, nil
+	case addl == 26:
+		if len(b) < 5 {
+			return 0, 0, fmt.Errorf("cbor: truncated 4-byte length")
+		}
+		return uint64(b[1])<<24 | uint64(b[2])<<16 | uint64(b[3])<<8 | uint64(b[4]), 5, nil
+	case addl == 27:
+		if len(b) < 9 {
+			return 0, 0, fmt.Errorf("cbor: truncated 8-byte length")
+		}
+		var v uint64
+		for i := 1; i <= 8; i++ {
+			v = v<<8 | uint64(b[i])
+		}
+		if v > math.MaxInt64 {
+			return 0, 0, fmt.Errorf("cbor: length overflow")
+		}
+		return v, 9, nil
+	default:
+		return 0, 0, fmt.Errorf("cbor: unsupported additional info %d (indefinite-length items aren't used by WebAuthn)", addl)
+	}
+}
@@ -0,0 +1,160 @@
+package webauthn
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// cborEncode is a tiny encoder for the same minimal subset decodeCBOR
+// understands, used only to build fixtures for these tests.
+func cborEncodeHeader(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+	default:
+		return []byte{major<<5 | 26, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+}
+
+func cborEncodeInt(i int64) []byte {
+	if i >= 0 {
+		return cborEncodeHeader(0, uint64(i))
+	}
+	return cborEncodeHeader(1, uint64(-1-i))
+}
+
+func cborEncodeBytes(b []byte) []byte {
+	return append(cborEncodeHeader(2, uint64(len(b))), b...)
+}
+
+func cborEncodeText(s string) []byte {
+	return append(cborEncodeHeader(3, uint64(len(s))), []byte(s)...)
+}
+
+func TestDecodeCBORUnsignedInt(t *testing.T) {
+	v, n, err := decodeCBOR([]byte{0x05})
+	if err != nil {
+		t.Fatalf("decodeCBOR: %v", err)
+	}
+	if v != int64(5) || n != 1 {
+		t.Errorf("got (%v, %d), want (5, 1)", v, n)
+	}
+}
+
+func TestDecodeCBORNegativeInt(t *testing.T) {
+	// -7, the ES256 COSE algorithm identifier: major type 1, value 6.
+	v, n, err := decodeCBOR(cborEncodeInt(-7))
+	if err != nil {
+		t.Fatalf("decodeCBOR: %v", err)
+	}
+	if v != int64(-7) || n != 1 {
+		t.Errorf("got (%v, %d), want (-7, 1)", v, n)
+	}
+}
+
+func TestDecodeCBORByteString(t *testing.T) {
+	want := []byte{1, 2, 3, 4, 5}
+	v, n, err := decodeCBOR(cborEncodeBytes(want))
+	if err != nil {
+		t.Fatalf("decodeCBOR: %v", err)
+	}
+	got, ok := v.([]byte)
+	if !ok || !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", v, want)
+	}
+	if n != 1+len(want) {
+		t.Errorf("consumed %d bytes, want %d", n, 1+len(want))
+	}
+}
+
+func TestDecodeCBORTextString(t *testing.T) {
+	v, _, err := decodeCBOR(cborEncodeText("none"))
+	if err != nil {
+		t.Fatalf("decodeCBOR: %v", err)
+	}
+	if v != "none" {
+		t.Errorf("got %q, want %q", v, "none")
+	}
+}
+
+func TestDecodeCBORArray(t *testing.T) {
+	var b []byte
+	b = append(b, cborEncodeHeader(4, 2)...)
+	b = append(b, cborEncodeInt(1)...)
+	b = append(b, cborEncodeInt(2)...)
+
+	v, _, err := decodeCBOR(b)
+	if err != nil {
+		t.Fatalf("decodeCBOR: %v", err)
+	}
+	got, ok := v.([]any)
+	if !ok {
+		t.Fatalf("got %T, want []any", v)
+	}
+	want := []any{int64(1), int64(2)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeCBORMap(t *testing.T) {
+	var b []byte
+	b = append(b, cborEncodeHeader(5, 1)...)
+	b = append(b, cborEncodeInt(1)...)
+	b = append(b, cborEncodeInt(2)...)
+
+	v, _, err := decodeCBOR(b)
+	if err != nil {
+		t.Fatalf("decodeCBOR: %v", err)
+	}
+	m, ok := v.(map[any]any)
+	if !ok {
+		t.Fatalf("got %T, want map[any]any", v)
+	}
+	if m[int64(1)] != int64(2) {
+		t.Errorf("m[1] = %v, want 2", m[int64(1)])
+	}
+}
+
+func TestDecodeCBOREmptyInput(t *testing.T) {
+	if _, _, err := decodeCBOR(nil); err == nil {
+		t.Fatal("expected an error decoding empty input")
+	}
+}
+
+func TestDecodeCBORTruncatedByteString(t *testing.T) {
+	// Header claims 10 bytes but only 2 follow.
+	b := append(cborEncodeHeader(2, 10), 0x01, 0x02)
+	if _, _, err := decodeCBOR(b); err == nil {
+		t.Fatal("expected an error decoding a truncated byte string")
+	}
+}
+
+func TestDecodeCBORUnsupportedMajorType(t *testing.T) {
+	// Major type 6 (tag) isn't one decodeCBOR supports.
+	if _, _, err := decodeCBOR([]byte{6 << 5}); err == nil {
+		t.Fatal("expected an error decoding an unsupported major type")
+	}
+}
+
+func TestReadCBORLengthTruncatedHeader(t *testing.T) {
+	// addl == 24 means "one more length byte follows", but none does.
+	if _, _, err := readCBORLength([]byte{0x18}, 24); err == nil {
+		t.Fatal("expected an error reading a truncated 1-byte length")
+	}
+}
+
+func TestReadCBORLengthTwoByte(t *testing.T) {
+	v, n, err := readCBORLength([]byte{0x19, 0x01, 0x00}, 25)
+	if err != nil {
+		t.Fatalf("readCBORLength: %v", err)
+	}
+	if v != 256 || n != 3 {
+		t.Errorf("got (%d, %d), want (256, 3)", v, n)
+	}
+}
@@ -0,0 +1,238 @@
+package webauthn
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// clientData is the decoded form of the clientDataJSON the browser
+// includes with every ceremony, used to bind the response to the
+// challenge that was issued and the page origin that requested it.
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// flags bit positions within authenticatorData.flags (WebAuthn L2 §6.1).
+const (
+	flagUserPresent  = 1 << 0
+	flagUserVerified = 1 << 2
+	flagAttestedCred = 1 << 6
+)
+
+// authenticatorData is the parsed form of the fixed-layout binary blob
+// every registration and assertion response carries.
+type authenticatorData struct {
+	RPIDHash     [32]byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte // present only when flagAttestedCred is set
+	CredentialID []byte
+	COSEKey      []byte // raw CBOR, present only when flagAttestedCred is set
+}
+
+func parseAuthenticatorData(b []byte) (*authenticatorData, error) {
+	if len(b) < 37 {
+		return nil, fmt.Errorf("authenticatorData too short (%d bytes)", len(b))
+	}
+	ad := &authenticatorData{}
+	copy(ad.RPIDHash[:], b[0:32])
+	ad.Flags = b[32]
+	ad.SignCount = binary.BigEndian.Uint32(b[33:37])
+
+	if ad.Flags&flagAttestedCred == 0 {
+		return ad, nil
+	}
+
+	off := 37
+	if len(b) < off+16+2 {
+		return nil, fmt.Errorf("authenticatorData truncated in attestedCredentialData")
+	}
+	ad.AAGUID = b[off : off+16]
+	off += 16
+	credIDLen := int(binary.BigEndian.Uint16(b[off : off+2]))
+	off += 2
+	if len(b) < off+credIDLen {
+		return nil, fmt.Errorf("authenticatorData truncated reading credentialId")
+	}
+	ad.CredentialID = b[off : off+credIDLen]
+	off += credIDLen
+
+	// The COSE_Key is the remainder of the buffer (WebAuthn attestations
+	// don't include extensions in this server's registration options, so
+	// there's nothing else to trim off the end).
+	if off > len(b) {
+		return nil, fmt.Errorf("authenticatorData truncated before COSE key")
+	}
+	ad.COSEKey = b[off:]
+	return ad, nil
+}
+
+// attestationObject is the decoded top-level CBOR map returned by
+// navigator.credentials.create(): {"fmt": ..., "attStmt": {...}, "authData": ...}.
+type attestationObject struct {
+	Fmt      string
+	AuthData *authenticatorData
+}
+
+func parseAttestationObject(b []byte) (*attestationObject, error) {
+	decoded, _, err := decodeCBOR(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode attestationObject: %w", err)
+	}
+	m, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("attestationObject is not a map")
+	}
+
+	fmtName, _ := m["fmt"].(string)
+	rawAuthData, ok := m["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("attestationObject missing authData")
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	return &attestationObject{Fmt: fmtName, AuthData: authData}, nil
+}
+
+// VerifyRegistration checks a navigator.credentials.create() response
+// against the challenge it was issued with and this relying party's
+// identity, returning the new Credential to persist on success. It
+// validates: clientDataJSON.type, origin and challenge; rpIdHash; the
+// user-present flag; and that attested credential data (and so a COSE
+// public key) is present. It does not validate the attestation
+// statement's signature chain — self/none attestation is accepted, which
+// is the common, acceptable posture for a relying party that only cares
+// "was a FIDO2 authenticator used", not "which vendor made it".
+func (rp *RelyingParty) VerifyRegistration(clientDataJSON, attestationObjectCBOR, expectedChallenge []byte, userID string) (*Credential, error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return nil, fmt.Errorf("parse clientDataJSON: %w", err)
+	}
+	if cd.Type != "webauthn.create" {
+		return nil, fmt.Errorf("unexpected clientData.type %q", cd.Type)
+	}
+	if err := rp.checkChallengeAndOrigin(cd, expectedChallenge); err != nil {
+		return nil, err
+	}
+
+	ao, err := parseAttestationObject(attestationObjectCBOR)
+	if err != nil {
+		return nil, err
+	}
+	ad := ao.AuthData
+
+	rpIDHash := rp.RPIDHash()
+	if subtle.ConstantTimeCompare(ad.RPIDHash[:], rpIDHash[:]) != 1 {
+		return nil, fmt.Errorf("rpIdHash mismatch")
+	}
+	if ad.Flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("user presence flag not set")
+	}
+	if ad.Flags&flagAttestedCred == 0 || len(ad.COSEKey) == 0 {
+		return nil, fmt.Errorf("attestationObject has no attested credential data")
+	}
+
+	return &Credential{
+		ID:        append([]byte{}, ad.CredentialID...),
+		UserID:    userID,
+		PublicKey: append([]byte{}, ad.COSEKey...),
+		SignCount: ad.SignCount,
+		AAGUID:    append([]byte{}, ad.AAGUID...),
+	}, nil
+}
+
+// VerifyAssertion checks a navigator.credentials.get() response against
+// the challenge it was issued with and a previously registered
+// Credential. On success it returns the authenticator's new signCount,
+// which the caller must persist; a signCount that is not strictly
+// greater than cred.SignCount (and not the authenticator-opt-out value
+// 0) indicates the credential may have been cloned and should be
+// treated as compromised rather than accepted.
+func (rp *RelyingParty) VerifyAssertion(clientDataJSON, authenticatorDataRaw, signature, expectedChallenge []byte, cred *Credential) (newSignCount uint32, err error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return 0, fmt.Errorf("parse clientDataJSON: %w", err)
+	}
+	if cd.Type != "webauthn.get" {
+		return 0, fmt.Errorf("unexpected clientData.type %q", cd.Type)
+	}
+	if err := rp.checkChallengeAndOrigin(cd, expectedChallenge); err != nil {
+		return 0, err
+	}
+
+	ad, err := parseAuthenticatorData(authenticatorDataRaw)
+	if err != nil {
+		return 0, err
+	}
+	rpIDHash := rp.RPIDHash()
+	if subtle.ConstantTimeCompare(ad.RPIDHash[:], rpIDHash[:]) != 1 {
+		return 0, fmt.Errorf("rpIdHash mismatch")
+	}
+	if ad.Flags&flagUserPresent == 0 {
+		return 0, fmt.Errorf("user presence flag not set")
+	}
+
+	pubKey, err := ParseCOSEPublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("parse stored COSE key: %w", err)
+	}
+	alg, err := coseKeyAlgorithm(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, authenticatorDataRaw...), clientDataHash[:]...)
+	if err := VerifySignature(pubKey, alg, signedData, signature); err != nil {
+		return ad.SignCount, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	// A sign count that hasn't strictly increased (ignoring
+	// authenticators that always report 0) means the credential's key
+	// material has likely been cloned onto another device.
+	if ad.SignCount != 0 && cred.SignCount != 0 && ad.SignCount <= cred.SignCount {
+		return ad.SignCount, fmt.Errorf("sign count did not increase: stored=%d got=%d", cred.SignCount, ad.SignCount)
+	}
+
+	return ad.SignCount, nil
+}
+
+func (rp *RelyingParty) checkChallengeAndOrigin(cd clientData, expectedChallenge []byte) error {
+	if cd.Origin != rp.Origin {
+		return fmt.Errorf("origin mismatch: expected %q, got %q", rp.Origin, cd.Origin)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(cd.Challenge)
+	if err != nil {
+		return fmt.Errorf("decode clientData.challenge: %w", err)
+	}
+	if subtle.ConstantTimeCompare(got, expectedChallenge) != 1 {
+		return fmt.Errorf("challenge mismatch")
+	}
+	return nil
+}
+
+// coseKeyAlgorithm returns the COSE algorithm identifier stored with a
+// COSE_Key, used to pick the right Verify routine for an assertion.
+func coseKeyAlgorithm(cborBytes []byte) (int64, error) {
+	decoded, _, err := decodeCBOR(cborBytes)
+	if err != nil {
+		return 0, err
+	}
+	m, ok := decoded.(map[any]any)
+	if !ok {
+		return 0, fmt.Errorf("COSE key is not a map")
+	}
+	alg, ok := m[coseKeyAlg].(int64)
+	if !ok {
+		return 0, fmt.Errorf("COSE key missing alg")
+	}
+	return alg, nil
+}
@@ -0,0 +1,287 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func buildAuthenticatorData(rpID string, flags byte, signCount uint32, aaguid, credID, coseKey []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	b := append([]byte{}, rpIDHash[:]...)
+	b = append(b, flags)
+	var sc [4]byte
+	binary.BigEndian.PutUint32(sc[:], signCount)
+	b = append(b, sc[:]...)
+
+	if flags&flagAttestedCred != 0 {
+		b = append(b, aaguid...)
+		var credLen [2]byte
+		binary.BigEndian.PutUint16(credLen[:], uint16(len(credID)))
+		b = append(b, credLen[:]...)
+		b = append(b, credID...)
+		b = append(b, coseKey...)
+	}
+	return b
+}
+
+func encodeAttestationObject(fmtName string, authData []byte) []byte {
+	var b []byte
+	b = append(b, cborEncodeHeader(5, 3)...)
+	b = append(b, cborEncodeText("fmt")...)
+	b = append(b, cborEncodeText(fmtName)...)
+	b = append(b, cborEncodeText("attStmt")...)
+	b = append(b, cborEncodeHeader(5, 0)...) // empty map
+	b = append(b, cborEncodeText("authData")...)
+	b = append(b, cborEncodeBytes(authData)...)
+	return b
+}
+
+func buildClientDataJSON(t *testing.T, typ, origin string, challenge []byte) []byte {
+	t.Helper()
+	cd := clientData{
+		Type:      typ,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    origin,
+	}
+	b, err := json.Marshal(cd)
+	if err != nil {
+		t.Fatalf("marshal clientData: %v", err)
+	}
+	return b
+}
+
+func TestParseAuthenticatorDataNoAttestedCred(t *testing.T) {
+	raw := buildAuthenticatorData("example.com", flagUserPresent, 1, nil, nil, nil)
+	ad, err := parseAuthenticatorData(raw)
+	if err != nil {
+		t.Fatalf("parseAuthenticatorData: %v", err)
+	}
+	if ad.Flags&flagUserPresent == 0 {
+		t.Error("expected user-present flag to be set")
+	}
+	if ad.SignCount != 1 {
+		t.Errorf("SignCount = %d, want 1", ad.SignCount)
+	}
+	if len(ad.CredentialID) != 0 {
+		t.Error("expected no credential ID without flagAttestedCred")
+	}
+}
+
+func TestParseAuthenticatorDataTooShort(t *testing.T) {
+	if _, err := parseAuthenticatorData(make([]byte, 10)); err == nil {
+		t.Fatal("expected an error for authenticatorData shorter than 37 bytes")
+	}
+}
+
+func TestParseAuthenticatorDataTruncatedAttestedCred(t *testing.T) {
+	raw := buildAuthenticatorData("example.com", flagUserPresent|flagAttestedCred, 1, nil, nil, nil)
+	// buildAuthenticatorData with flagAttestedCred set but zero-length
+	// aaguid/credID still writes a truncated (non-16-byte) AAGUID field,
+	// which parseAuthenticatorData must reject rather than index out of
+	// range on.
+	if _, err := parseAuthenticatorData(raw); err == nil {
+		t.Fatal("expected an error for truncated attested credential data")
+	}
+}
+
+type testAuthenticator struct {
+	rpID   string
+	priv   *ecdsa.PrivateKey
+	credID []byte
+	aaguid []byte
+}
+
+func newTestAuthenticator(t *testing.T, rpID string) *testAuthenticator {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return &testAuthenticator{
+		rpID:   rpID,
+		priv:   priv,
+		credID: []byte("test-credential-id"),
+		aaguid: make([]byte, 16),
+	}
+}
+
+func (a *testAuthenticator) registrationAuthData(signCount uint32) []byte {
+	coseKey := encodeEC2COSEKey(&a.priv.PublicKey)
+	return buildAuthenticatorData(a.rpID, flagUserPresent|flagAttestedCred, signCount, a.aaguid, a.credID, coseKey)
+}
+
+func (a *testAuthenticator) assertionAuthData(signCount uint32) []byte {
+	return buildAuthenticatorData(a.rpID, flagUserPresent, signCount, nil, nil, nil)
+}
+
+func (a *testAuthenticator) sign(authData, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signedData := append(append([]byte{}, authData...), clientDataHash[:]...)
+	digest := sha256.Sum256(signedData)
+	sig, err := ecdsa.SignASN1(rand.Reader, a.priv, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func TestVerifyRegistrationSuccess(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cdj := buildClientDataJSON(t, "webauthn.create", rp.Origin, challenge)
+	ao := encodeAttestationObject("none", auth.registrationAuthData(0))
+
+	cred, err := rp.VerifyRegistration(cdj, ao, challenge, "user-1")
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	if string(cred.ID) != string(auth.credID) {
+		t.Errorf("credential ID = %q, want %q", cred.ID, auth.credID)
+	}
+	if cred.UserID != "user-1" {
+		t.Errorf("UserID = %q, want user-1", cred.UserID)
+	}
+}
+
+func TestVerifyRegistrationWrongType(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cdj := buildClientDataJSON(t, "webauthn.get", rp.Origin, challenge)
+	ao := encodeAttestationObject("none", auth.registrationAuthData(0))
+
+	if _, err := rp.VerifyRegistration(cdj, ao, challenge, "user-1"); err == nil {
+		t.Fatal("expected an error for a clientData.type other than webauthn.create")
+	}
+}
+
+func TestVerifyRegistrationOriginMismatch(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cdj := buildClientDataJSON(t, "webauthn.create", "https://evil.example", challenge)
+	ao := encodeAttestationObject("none", auth.registrationAuthData(0))
+
+	if _, err := rp.VerifyRegistration(cdj, ao, challenge, "user-1"); err == nil {
+		t.Fatal("expected an error for an origin mismatch")
+	}
+}
+
+func TestVerifyRegistrationChallengeMismatch(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+	otherChallenge := []byte("ffffffffffffffffffffffffffffffff")
+
+	cdj := buildClientDataJSON(t, "webauthn.create", rp.Origin, challenge)
+	ao := encodeAttestationObject("none", auth.registrationAuthData(0))
+
+	if _, err := rp.VerifyRegistration(cdj, ao, otherChallenge, "user-1"); err == nil {
+		t.Fatal("expected an error for a challenge mismatch")
+	}
+}
+
+func TestVerifyRegistrationRPIDHashMismatch(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, "other.example") // authData hashed under a different RPID
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cdj := buildClientDataJSON(t, "webauthn.create", rp.Origin, challenge)
+	ao := encodeAttestationObject("none", auth.registrationAuthData(0))
+
+	if _, err := rp.VerifyRegistration(cdj, ao, challenge, "user-1"); err == nil {
+		t.Fatal("expected an error for an rpIdHash mismatch")
+	}
+}
+
+func TestVerifyRegistrationMissingUserPresent(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cdj := buildClientDataJSON(t, "webauthn.create", rp.Origin, challenge)
+	coseKey := encodeEC2COSEKey(&auth.priv.PublicKey)
+	authData := buildAuthenticatorData(rp.ID, flagAttestedCred, 0, auth.aaguid, auth.credID, coseKey)
+	ao := encodeAttestationObject("none", authData)
+
+	if _, err := rp.VerifyRegistration(cdj, ao, challenge, "user-1"); err == nil {
+		t.Fatal("expected an error when the user-present flag is not set")
+	}
+}
+
+func TestVerifyAssertionSuccess(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cred := &Credential{
+		ID:        auth.credID,
+		PublicKey: encodeEC2COSEKey(&auth.priv.PublicKey),
+		SignCount: 5,
+	}
+
+	cdj := buildClientDataJSON(t, "webauthn.get", rp.Origin, challenge)
+	authData := auth.assertionAuthData(6)
+	sig := auth.sign(authData, cdj)
+
+	newCount, err := rp.VerifyAssertion(cdj, authData, sig, challenge, cred)
+	if err != nil {
+		t.Fatalf("VerifyAssertion: %v", err)
+	}
+	if newCount != 6 {
+		t.Errorf("newCount = %d, want 6", newCount)
+	}
+}
+
+func TestVerifyAssertionRejectsTamperedSignature(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cred := &Credential{
+		ID:        auth.credID,
+		PublicKey: encodeEC2COSEKey(&auth.priv.PublicKey),
+		SignCount: 5,
+	}
+
+	cdj := buildClientDataJSON(t, "webauthn.get", rp.Origin, challenge)
+	authData := auth.assertionAuthData(6)
+	sig := auth.sign(authData, cdj)
+	sig[len(sig)-1] ^= 0xff
+
+	if _, err := rp.VerifyAssertion(cdj, authData, sig, challenge, cred); err == nil {
+		t.Fatal("expected an error for a tampered signature")
+	}
+}
+
+func TestVerifyAssertionRejectsNonIncreasingSignCount(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	auth := newTestAuthenticator(t, rp.ID)
+	challenge := []byte("0123456789abcdef0123456789abcdef")
+
+	cred := &Credential{
+		ID:        auth.credID,
+		PublicKey: encodeEC2COSEKey(&auth.priv.PublicKey),
+		SignCount: 10,
+	}
+
+	cdj := buildClientDataJSON(t, "webauthn.get", rp.Origin, challenge)
+	// Authenticator reports a sign count that didn't advance past what's
+	// stored - a sign of cloned credential material.
+	authData := auth.assertionAuthData(10)
+	sig := auth.sign(authData, cdj)
+
+	if _, err := rp.VerifyAssertion(cdj, authData, sig, challenge, cred); err == nil {
+		t.Fatal("expected an error for a non-increasing sign count")
+	}
+}
@@ -0,0 +1,92 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers registered with IANA; these are the two
+// pubKeyCredParams this server advertises in registration options.
+const (
+	COSEAlgES256 = -7   // ECDSA w/ SHA-256 over P-256
+	COSEAlgRS256 = -257 // RSASSA-PKCS1-v1_5 w/ SHA-256
+)
+
+// COSE_Key map keys (RFC 9053 / RFC 8152 §7,13).
+const (
+	coseKeyKty  int64 = 1
+	coseKeyAlg  int64 = 3
+	coseKeyCrvX int64 = -2 // EC2 x-coordinate / RSA modulus n
+	coseKeyCrvY int64 = -3 // EC2 y-coordinate / RSA exponent e
+	coseKtyEC2  int64 = 2
+	coseKtyRSA  int64 = 3
+)
+
+// ParseCOSEPublicKey decodes a CBOR-encoded COSE_Key into a standard
+// library public key (*ecdsa.PublicKey or *rsa.PublicKey) ready for use
+// with the crypto package's Verify functions.
+func ParseCOSEPublicKey(cborBytes []byte) (any, error) {
+	decoded, _, err := decodeCBOR(cborBytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode COSE key: %w", err)
+	}
+	m, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("COSE key is not a map")
+	}
+
+	kty, _ := m[coseKeyKty].(int64)
+	switch kty {
+	case coseKtyEC2:
+		xb, _ := m[coseKeyCrvX].([]byte)
+		yb, _ := m[coseKeyCrvY].([]byte)
+		if len(xb) == 0 || len(yb) == 0 {
+			return nil, fmt.Errorf("EC2 COSE key missing x/y")
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(xb),
+			Y:     new(big.Int).SetBytes(yb),
+		}, nil
+	case coseKtyRSA:
+		nb, _ := m[coseKeyCrvX].([]byte)
+		eb, _ := m[coseKeyCrvY].([]byte)
+		if len(nb) == 0 || len(eb) == 0 {
+			return nil, fmt.Errorf("RSA COSE key missing n/e")
+		}
+		e := new(big.Int).SetBytes(eb)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(e.Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported COSE key type %d", kty)
+	}
+}
+
+// VerifySignature checks that sig is a valid signature over signedData
+// (clientDataHash appended to authenticatorData, per the WebAuthn
+// assertion format) under pubKey, for the given COSE algorithm.
+func VerifySignature(pubKey any, alg int64, signedData, sig []byte) error {
+	digest := sha256.Sum256(signedData)
+
+	switch key := pubKey.(type) {
+	case *ecdsa.PublicKey:
+		if alg != COSEAlgES256 {
+			return fmt.Errorf("unexpected algorithm %d for EC2 key", alg)
+		}
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return fmt.Errorf("ECDSA signature verification failed")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg != COSEAlgRS256 {
+			return fmt.Errorf("unexpected algorithm %d for RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	default:
+		return fmt.Errorf("unsupported public key type %T", pubKey)
+	}
+}
@@ -0,0 +1,139 @@
+// Package webauthn implements just enough of the W3C WebAuthn Level 2
+// spec to register and verify FIDO2 passkeys: challenge issuance,
+// attestationObject/authenticatorData parsing, COSE public key
+// extraction, and assertion signature verification. It has no
+// dependency on the auth package so it can be unit tested and reused
+// independently of session management.
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RelyingParty identifies this server to authenticators during
+// registration and assertion ceremonies.
+type RelyingParty struct {
+	ID     string // RPID: the effective domain, e.g. s.Hostname
+	Name   string
+	Origin string // expected fully-qualified origin of the calling page
+}
+
+// NewRelyingParty builds a RelyingParty from the server's hostname,
+// deriving a matching HTTPS origin (WebAuthn requires a secure context
+// outside of localhost).
+func NewRelyingParty(hostname string) *RelyingParty {
+	return &RelyingParty{
+		ID:     hostname,
+		Name:   "5mp",
+		Origin: "https://" + hostname,
+	}
+}
+
+// Credential is a single registered authenticator, persisted in the
+// credentials table (credential_id, user_id, public_key, sign_count,
+// transports, aaguid, created_at).
+type Credential struct {
+	ID          []byte // credential_id, the authenticator-chosen opaque handle
+	UserID      string
+	PublicKey   []byte // COSE_Key, CBOR-encoded
+	SignCount   uint32
+	Transports  string
+	AAGUID      []byte
+	CreatedAt   time.Time
+	Compromised bool
+}
+
+// ErrChallengeNotFound is returned when a ceremony handle is unknown or
+// has expired.
+var ErrChallengeNotFound = errors.New("webauthn: challenge not found or expired")
+
+// challengeTTL bounds how long a client has to complete a ceremony once
+// begin has returned its options, matching the "short-TTL table keyed by
+// a one-time opaque handle" the registration/login begin endpoints hand
+// back to the browser.
+const challengeTTL = 5 * time.Minute
+
+type pendingChallenge struct {
+	challenge []byte
+	userID    string
+	email     string
+	expires   time.Time
+}
+
+// ChallengeStore tracks in-flight registration/login ceremonies. It is
+// an in-memory, mutex-guarded map rather than a DB table: challenges are
+// short-lived and single-use, so surviving a process restart isn't
+// required, unlike the credentials themselves.
+type ChallengeStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingChallenge
+}
+
+// NewChallengeStore creates an empty ChallengeStore.
+func NewChallengeStore() *ChallengeStore {
+	return &ChallengeStore{pending: make(map[string]pendingChallenge)}
+}
+
+// NewChallenge generates a random 32-byte challenge, stores it under a
+// fresh opaque handle, and returns both.
+func (cs *ChallengeStore) NewChallenge(userID, email string) (handle string, challenge []byte, err error) {
+	challenge = make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", nil, fmt.Errorf("generate challenge: %w", err)
+	}
+	handleBytes := make([]byte, 16)
+	if _, err := rand.Read(handleBytes); err != nil {
+		return "", nil, fmt.Errorf("generate handle: %w", err)
+	}
+	handle = base64.RawURLEncoding.EncodeToString(handleBytes)
+
+	cs.mu.Lock()
+	cs.gc()
+	cs.pending[handle] = pendingChallenge{
+		challenge: challenge,
+		userID:    userID,
+		email:     email,
+		expires:   time.Now().Add(challengeTTL),
+	}
+	cs.mu.Unlock()
+
+	return handle, challenge, nil
+}
+
+// Take validates and consumes a handle, returning the challenge bytes it
+// was issued with and the identity it was issued for. Challenges are
+// single-use: a second call with the same handle returns
+// ErrChallengeNotFound.
+func (cs *ChallengeStore) Take(handle string) (challenge []byte, userID, email string, err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	pc, ok := cs.pending[handle]
+	delete(cs.pending, handle)
+	if !ok || time.Now().After(pc.expires) {
+		return nil, "", "", ErrChallengeNotFound
+	}
+	return pc.challenge, pc.userID, pc.email, nil
+}
+
+// gc drops expired entries. Called with cs.mu held.
+func (cs *ChallengeStore) gc() {
+	now := time.Now()
+	for h, pc := range cs.pending {
+		if now.After(pc.expires) {
+			delete(cs.pending, h)
+		}
+	}
+}
+
+// RPIDHash returns SHA-256(rp.ID), which must match the rpIdHash field
+// of both authenticatorData during registration and assertion.
+func (rp *RelyingParty) RPIDHash() [32]byte {
+	return sha256.Sum256([]byte(rp.ID))
+}
@@ -0,0 +1,59 @@
+package webauthn
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestRPIDHash(t *testing.T) {
+	rp := NewRelyingParty("example.com")
+	want := sha256.Sum256([]byte("example.com"))
+	if rp.RPIDHash() != want {
+		t.Error("RPIDHash does not match sha256(rp.ID)")
+	}
+}
+
+func TestChallengeStoreNewAndTake(t *testing.T) {
+	cs := NewChallengeStore()
+
+	handle, challenge, err := cs.NewChallenge("user-1", "user@example.com")
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+	if len(challenge) != 32 {
+		t.Errorf("challenge length = %d, want 32", len(challenge))
+	}
+
+	gotChallenge, userID, email, err := cs.Take(handle)
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if string(gotChallenge) != string(challenge) {
+		t.Error("Take returned a different challenge than NewChallenge issued")
+	}
+	if userID != "user-1" || email != "user@example.com" {
+		t.Errorf("Take returned (%q, %q), want (user-1, user@example.com)", userID, email)
+	}
+}
+
+func TestChallengeStoreTakeIsSingleUse(t *testing.T) {
+	cs := NewChallengeStore()
+	handle, _, err := cs.NewChallenge("user-1", "user@example.com")
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+
+	if _, _, _, err := cs.Take(handle); err != nil {
+		t.Fatalf("first Take: %v", err)
+	}
+	if _, _, _, err := cs.Take(handle); err != ErrChallengeNotFound {
+		t.Errorf("second Take = %v, want ErrChallengeNotFound", err)
+	}
+}
+
+func TestChallengeStoreTakeUnknownHandle(t *testing.T) {
+	cs := NewChallengeStore()
+	if _, _, _, err := cs.Take("nonexistent-handle"); err != ErrChallengeNotFound {
+		t.Errorf("Take = %v, want ErrChallengeNotFound", err)
+	}
+}
@@ -71,9 +71,9 @@ func (s *Server) HandleAPIGetParkChecklist(w http.ResponseWriter, r *http.Reques
 	}
 
 	response := map[string]interface{}{
-		"pa_id":    paID,
-		"schema":   checklistSchema,
-		"items":    itemStatus,
+		"pa_id":  paID,
+		"schema": checklistSchema,
+		"items":  itemStatus,
 	}
 
 	// Get stats
@@ -98,7 +98,7 @@ func (s *Server) HandleAPIUpdateChecklistItem(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	user := s.Auth.GetUserFromRequest(r)
+	user := s.GetUserFromRequest(r)
 	if user == nil {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
@@ -0,0 +1,82 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"srv.exe.dev/srv/areas"
+)
+
+// clipTrackRequest is the request body for HandleAPIClipTrack: a GeoJSON
+// LineString geometry (the patrol track to clip).
+type clipTrackRequest struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+// HandleAPIClipTrack clips a GeoJSON LineString (posted as the request
+// body) to the portions that fall inside the protected areas named by
+// ?ids= (comma-separated), buffers included, and returns a GeoJSON
+// MultiLineString of the retained pieces. Patrol analysts use this to
+// split "effort inside the park" from "effort in the buffer" and
+// "effort outside" without running a PostGIS job.
+func (s *Server) HandleAPIClipTrack(w http.ResponseWriter, r *http.Request) {
+	if s.AreaStore == nil {
+		http.Error(w, "area store not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var ids []string
+	for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		http.Error(w, "ids query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	var req clipTrackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Type != "LineString" {
+		http.Error(w, fmt.Sprintf("expected a LineString geometry, got %q", req.Type), http.StatusBadRequest)
+		return
+	}
+	if len(req.Coordinates) < 2 {
+		http.Error(w, "LineString must have at least 2 coordinates", http.StatusBadRequest)
+		return
+	}
+
+	pts := make([]areas.Point, len(req.Coordinates))
+	for i, c := range req.Coordinates {
+		if len(c) < 2 {
+			http.Error(w, "each coordinate must be [lon, lat]", http.StatusBadRequest)
+			return
+		}
+		pts[i] = areas.Point{Lat: c[1], Lon: c[0]}
+	}
+
+	limiter := s.AreaStore.NewLimiter(ids)
+	pieces := limiter.ClipLineString(pts)
+
+	lines := make([][][]float64, 0, len(pieces))
+	for _, piece := range pieces {
+		line := make([][]float64, len(piece))
+		for i, p := range piece {
+			line[i] = []float64{p.Lon, p.Lat}
+		}
+		lines = append(lines, line)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":        "MultiLineString",
+		"coordinates": lines,
+	})
+}
@@ -0,0 +1,278 @@
+// Package config loads deployment configuration for the srv server:
+// listen address, TLS/ACME, the privileges to drop to after binding,
+// SSO providers, and the handful of settings that differ between a
+// laptop dev run and a server sitting on the public internet with real
+// protected-area data behind it.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// OIDCProvider configures one SSO identity provider registered under
+// /auth/{Name}/....
+type OIDCProvider struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// RateLimit configures the personal-access-token bucket applied per
+// token ID (see srv/auth/tokens.RateLimiter).
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// Config is the full set of deployment settings for a srv instance.
+// Zero values are sane dev defaults; Load fills in Defaults() first so
+// a minimal config file only needs to override what differs.
+type Config struct {
+	Addr     string
+	Hostname string
+
+	DBPath       string
+	TemplatesDir string
+	StaticDir    string
+
+	// TLS: set TLSCertFile/TLSKeyFile for a static certificate, or
+	// ACMEDomains for automatic Let's Encrypt certificates. At most one
+	// of the two should be set.
+	TLSCertFile  string
+	TLSKeyFile   string
+	ACMEDomains  []string
+	ACMECacheDir string
+
+	// EnableClientCertAuth makes the TLS listener request an optional
+	// client certificate on every handshake (tls.RequestClientCert), so
+	// clientCertUser can resolve it to a User via the client_certs
+	// fingerprint table. Left false, the server never asks for a client
+	// certificate and mTLS auth can never succeed no matter how many
+	// certs are registered with RegisterClientCert.
+	EnableClientCertAuth bool
+
+	// User/Group are dropped to via setuid/setgid after the listener is
+	// bound, so the process can bind :443 as root and then run
+	// unprivileged. Both empty means "don't drop privileges".
+	User  string
+	Group string
+
+	// ShutdownGrace bounds how long graceful shutdown waits for
+	// in-flight requests before the process exits anyway.
+	ShutdownGrace time.Duration
+
+	// DisableAuthentication bypasses RequireAuth/RequireAdmin entirely.
+	// It exists strictly for local dev and logs a loud warning on
+	// startup; never set it in a deployed config.
+	DisableAuthentication bool
+
+	// TrustedProxies lists the IPs/CIDRs of reverse proxies allowed to
+	// set X-Forwarded-For/X-Forwarded-Proto. Requests from any other
+	// source have those headers ignored.
+	TrustedProxies []string
+
+	// AllowAnonymousDocumentReads lets GET /api/parks/{id}/documents
+	// serve without authentication. Writes to that path always require
+	// editor permission regardless of this setting.
+	AllowAnonymousDocumentReads bool
+
+	SessionSecret string
+
+	// VaultPassphraseFile points at a locked (mode 0600, owner-only)
+	// file holding the at-rest encryption passphrase for uploaded
+	// fire/GHSL data (see srv/vault). Left empty, SRV_VAULT_PASSPHRASE
+	// is tried instead; if neither is set, uploads are stored
+	// unencrypted and a warning is logged at startup.
+	VaultPassphraseFile string
+
+	// SMTP configures the relay used to send password-reset and
+	// email-verification mail (see srv/mailer). Left with SMTPAddr
+	// empty, the server falls back to mailer.NoopMailer and logs a
+	// warning, so password reset/verification silently no-ops rather
+	// than failing startup.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// GeoIP points at a local MaxMind GeoLite2-City database (see
+	// srv/geoip) used to auto-scope area searches by the caller's
+	// location. GeoIPAccountID/GeoIPLicenseKey authenticate the
+	// scheduled redownload (srv/scheduler_jobs.go's geoipRefreshJob); if
+	// either is empty, the job just skips its run and the existing file
+	// on disk keeps serving lookups. Leaving GeoIPDBPath empty disables
+	// the feature entirely and logs a warning at startup.
+	GeoIPDBPath     string
+	GeoIPAccountID  string
+	GeoIPLicenseKey string
+
+	// PlacesPostGISDSN, if set, backs srv/places' nearest-place lookups
+	// (used by the narrative handlers) with a Postgres/PostGIS
+	// connection instead of the osm_places table in the main SQLite
+	// database. Left empty, the SQLite fallback is used.
+	PlacesPostGISDSN string
+
+	// BasemapTileURLTemplate is the XYZ tile server URL used by the
+	// patrol PDF map export (see srv/pdfexport), with {z}/{x}/{y}
+	// placeholders. Left empty, pdfexport.DefaultTileURLTemplate (OSM's
+	// public tile server) is used.
+	BasemapTileURLTemplate string
+
+	// OverpassAPIURL is the Overpass API endpoint HandleParkRoads
+	// queries for OSM road geometry (see srv/osmroads). Left empty,
+	// osmroads.DefaultEndpoint (the public overpass-api.de instance) is
+	// used.
+	OverpassAPIURL string
+
+	// DisabledResearchSources lists PublicationSource.Name() values
+	// (e.g. "crossref", "semanticscholar") that StartResearchWorker
+	// should skip, for operators whose network can't reach a given
+	// index. Left empty, every source in DefaultResearchSources runs.
+	DisabledResearchSources []string
+
+	// ResearchHTTPTimeout bounds a single request any PublicationSource
+	// makes to its bibliographic index; ResearchHTTPRateLimit throttles
+	// those requests through the shared client built by
+	// NewResearchHTTPClient. The defaults stay comfortably under
+	// OpenAlex's 10 req/s polite-pool limit, the most generous of the
+	// three sources StartResearchWorker fans out to.
+	ResearchHTTPTimeout   time.Duration
+	ResearchHTTPRateLimit RateLimit
+
+	// OverpassHTTPTimeout/OverpassHTTPRateLimit do the same for
+	// HandleParkRoads' Overpass fetch (see srv/osmroads). Public
+	// Overpass instances tolerate far less concurrent traffic than
+	// OpenAlex, hence the much lower default rate.
+	OverpassHTTPTimeout   time.Duration
+	OverpassHTTPRateLimit RateLimit
+
+	OIDCProviders []OIDCProvider
+	RateLimit     RateLimit
+}
+
+// Defaults returns the configuration used when a field isn't set in
+// the loaded file, matching what cmd/srv/main.go previously hard-coded.
+func Defaults() Config {
+	return Config{
+		Addr:                  ":8000",
+		DBPath:                "db.sqlite3",
+		ShutdownGrace:         10 * time.Second,
+		RateLimit:             RateLimit{PerSecond: 5, Burst: 20},
+		ResearchHTTPTimeout:   20 * time.Second,
+		ResearchHTTPRateLimit: RateLimit{PerSecond: 8, Burst: 4},
+		OverpassHTTPTimeout:   60 * time.Second,
+		OverpassHTTPRateLimit: RateLimit{PerSecond: 1, Burst: 1},
+	}
+}
+
+// Load reads a config file (.toml or .json, by extension) into
+// Defaults(), then applies environment overrides for the settings most
+// likely to differ per-deployment without editing the file (secrets,
+// addr, and the local-dev escape hatch).
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config %q: %w", path, err)
+		}
+		switch ext := filepath.Ext(path); ext {
+		case ".toml":
+			if err := toml.Unmarshal(data, &cfg); err != nil {
+				return nil, fmt.Errorf("parse config %q: %w", path, err)
+			}
+		case ".json":
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return nil, fmt.Errorf("parse config %q: %w", path, err)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported config extension %q (want .toml or .json)", ext)
+		}
+	}
+
+	applyEnvOverrides(&cfg)
+
+	if cfg.Hostname == "" {
+		if h, err := os.Hostname(); err == nil {
+			cfg.Hostname = h
+		} else {
+			cfg.Hostname = "unknown"
+		}
+	}
+
+	return &cfg, nil
+}
+
+// applyEnvOverrides lets an operator override the settings that
+// typically come from secrets/orchestration rather than a checked-in
+// config file, without templating the config file itself.
+func applyEnvOverrides(cfg *Config) {
+	if v := os.Getenv("SRV_ADDR"); v != "" {
+		cfg.Addr = v
+	}
+	if v := os.Getenv("SRV_DB_PATH"); v != "" {
+		cfg.DBPath = v
+	}
+	if v := os.Getenv("SRV_SESSION_SECRET"); v != "" {
+		cfg.SessionSecret = v
+	}
+	if v := os.Getenv("SRV_DISABLE_AUTHENTICATION"); v != "" {
+		cfg.DisableAuthentication = v == "1" || strings.EqualFold(v, "true")
+	}
+	if v := os.Getenv("SRV_ALLOW_ANONYMOUS_DOCUMENT_READS"); v != "" {
+		cfg.AllowAnonymousDocumentReads = v == "1" || strings.EqualFold(v, "true")
+	}
+	if v := os.Getenv("SRV_SHUTDOWN_GRACE_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ShutdownGrace = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("SRV_VAULT_PASSPHRASE_FILE"); v != "" {
+		cfg.VaultPassphraseFile = v
+	}
+	if v := os.Getenv("SRV_SMTP_ADDR"); v != "" {
+		cfg.SMTPAddr = v
+	}
+	if v := os.Getenv("SRV_SMTP_USERNAME"); v != "" {
+		cfg.SMTPUsername = v
+	}
+	if v := os.Getenv("SRV_SMTP_PASSWORD"); v != "" {
+		cfg.SMTPPassword = v
+	}
+	if v := os.Getenv("SRV_SMTP_FROM"); v != "" {
+		cfg.SMTPFrom = v
+	}
+	if v := os.Getenv("SRV_GEOIP_DB_PATH"); v != "" {
+		cfg.GeoIPDBPath = v
+	}
+	if v := os.Getenv("SRV_GEOIP_ACCOUNT_ID"); v != "" {
+		cfg.GeoIPAccountID = v
+	}
+	if v := os.Getenv("SRV_GEOIP_LICENSE_KEY"); v != "" {
+		cfg.GeoIPLicenseKey = v
+	}
+	if v := os.Getenv("SRV_PLACES_POSTGIS_DSN"); v != "" {
+		cfg.PlacesPostGISDSN = v
+	}
+	if v := os.Getenv("SRV_BASEMAP_TILE_URL_TEMPLATE"); v != "" {
+		cfg.BasemapTileURLTemplate = v
+	}
+	if v := os.Getenv("SRV_OVERPASS_API_URL"); v != "" {
+		cfg.OverpassAPIURL = v
+	}
+	if v := os.Getenv("SRV_DISABLED_RESEARCH_SOURCES"); v != "" {
+		cfg.DisabledResearchSources = strings.Split(v, ",")
+	}
+}
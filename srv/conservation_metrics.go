@@ -0,0 +1,191 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"srv.exe.dev/srv/metrics"
+)
+
+// DefaultConservationMetricsInterval is how often
+// StartConservationMetricsRefresher recomputes the Conservation* gauges
+// when the operator doesn't override it.
+const DefaultConservationMetricsInterval = 15 * time.Minute
+
+// StartConservationMetricsRefresher periodically recomputes the
+// Conservation* Prometheus gauges (fire detections, response rate,
+// deforestation, settlements, roadless percentage) for every park in
+// s.AreaStore, following the same "rebuild off-thread on a ticker" shape
+// as StartAreaWFSRefresher. The gauges themselves already hold the last
+// computed value between ticks, so a /metrics scrape never runs a SQLite
+// query - there's no separate cache to guard with a mutex beyond the one
+// promauto's GaugeVec already uses internally.
+func (s *Server) StartConservationMetricsRefresher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultConservationMetricsInterval
+	}
+
+	s.refreshConservationMetrics(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshConservationMetrics(ctx)
+		}
+	}
+}
+
+// refreshConservationMetrics recomputes every Conservation* gauge for
+// each park in s.AreaStore, logging (but not aborting on) a single
+// park's query failure so one bad row doesn't block every other park's
+// refresh.
+func (s *Server) refreshConservationMetrics(ctx context.Context) {
+	if s.AreaStore == nil || s.DB == nil {
+		return
+	}
+
+	for i := range s.AreaStore.Areas {
+		parkID := s.AreaStore.Areas[i].ID
+
+		if err := s.refreshFireMetrics(ctx, parkID); err != nil {
+			slog.Warn("refresh conservation fire metrics", "park_id", parkID, "error", err)
+		}
+		if err := s.refreshDeforestationMetrics(ctx, parkID); err != nil {
+			slog.Warn("refresh conservation deforestation metrics", "park_id", parkID, "error", err)
+		}
+		if err := s.refreshSettlementMetric(ctx, parkID); err != nil {
+			slog.Warn("refresh conservation settlement metric", "park_id", parkID, "error", err)
+		}
+		if err := s.refreshRoadlessMetric(ctx, parkID); err != nil {
+			slog.Warn("refresh conservation roadless metric", "park_id", parkID, "error", err)
+		}
+	}
+
+	slog.Info("refreshed conservation metrics", "parks", len(s.AreaStore.Areas))
+}
+
+// refreshFireMetrics sets ConservationFireDetectionsTotal and
+// ConservationFireGroupsStoppedInside per year, plus the park's
+// all-time ConservationFireResponseRate - the same per-year fire count
+// and group-infraction queries HandleAPIParkStats already runs.
+func (s *Server) refreshFireMetrics(ctx context.Context, parkID string) error {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT
+			pgi.year,
+			pgi.groups_stopped_inside,
+			COALESCE(fd.fire_count, 0) as total_fires
+		FROM park_group_infractions pgi
+		LEFT JOIN (
+			SELECT
+				protected_area_id,
+				CAST(strftime('%Y', acq_date) AS INTEGER) as year,
+				COUNT(*) as fire_count
+			FROM fire_detections
+			GROUP BY protected_area_id, strftime('%Y', acq_date)
+		) fd ON pgi.park_id = fd.protected_area_id AND pgi.year = fd.year
+		WHERE pgi.park_id = ?
+		ORDER BY pgi.year
+	`, parkID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var year, groupsStoppedInside, totalFires int
+		if err := rows.Scan(&year, &groupsStoppedInside, &totalFires); err != nil {
+			return err
+		}
+		yearLabel := strconv.Itoa(year)
+		metrics.ConservationFireDetectionsTotal.WithLabelValues(parkID, yearLabel).Set(float64(totalFires))
+		metrics.ConservationFireGroupsStoppedInside.WithLabelValues(parkID, yearLabel).Set(float64(groupsStoppedInside))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var groupsEntered, groupsStoppedTotal int
+	err = s.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(total_groups), 0), COALESCE(SUM(groups_stopped_inside), 0)
+		FROM park_group_infractions
+		WHERE park_id = ?
+	`, parkID).Scan(&groupsEntered, &groupsStoppedTotal)
+	if err != nil {
+		return err
+	}
+	if groupsEntered > 0 {
+		responseRate := float64(groupsStoppedTotal) / float64(groupsEntered) * 100
+		metrics.ConservationFireResponseRate.WithLabelValues(parkID).Set(responseRate)
+	}
+
+	return nil
+}
+
+// refreshDeforestationMetrics sets ConservationDeforestationKm2 per
+// year, from the same deforestation_events table HandleAPIParkStats
+// reads.
+func (s *Server) refreshDeforestationMetrics(ctx context.Context, parkID string) error {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT year, area_km2
+		FROM deforestation_events
+		WHERE park_id = ?
+		ORDER BY year
+	`, parkID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var year int
+		var areaKm2 float64
+		if err := rows.Scan(&year, &areaKm2); err != nil {
+			return err
+		}
+		metrics.ConservationDeforestationKm2.WithLabelValues(parkID, strconv.Itoa(year)).Set(areaKm2)
+	}
+	return rows.Err()
+}
+
+// refreshSettlementMetric sets ConservationSettlementBuiltupKm2 from
+// ghsl_data, the same table HandleAPIParkStats reads for SettlementStats.
+func (s *Server) refreshSettlementMetric(ctx context.Context, parkID string) error {
+	var builtUpKm2 float64
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT built_up_km2 FROM ghsl_data WHERE park_id = ?
+	`, parkID).Scan(&builtUpKm2)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	metrics.ConservationSettlementBuiltupKm2.WithLabelValues(parkID).Set(builtUpKm2)
+	return nil
+}
+
+// refreshRoadlessMetric sets ConservationRoadlessPercentage from
+// osm_roadless_data, the same table HandleAPIParkStats reads for
+// RoadlessStats.
+func (s *Server) refreshRoadlessMetric(ctx context.Context, parkID string) error {
+	var roadlessPercentage float64
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT roadless_percentage FROM osm_roadless_data WHERE park_id = ?
+	`, parkID).Scan(&roadlessPercentage)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	metrics.ConservationRoadlessPercentage.WithLabelValues(parkID).Set(roadlessPercentage)
+	return nil
+}
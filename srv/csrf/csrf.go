@@ -0,0 +1,84 @@
+// Package csrf implements a double-submit-cookie CSRF defense for the
+// server's HTML forms: a random token is set in a cookie the browser
+// sends automatically, and the same value must also be present in the
+// request (form field or header) for a mutating request to be
+// accepted. An attacker's cross-site form can make the browser send
+// the cookie, but can't read its value to also supply it back.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// CookieName is the cookie holding the token; FieldName/HeaderName are
+// where callers are expected to also send it.
+const (
+	CookieName = "csrf_token"
+	FieldName  = "csrf_token"
+	HeaderName = "X-CSRF-Token"
+)
+
+// ErrMissingToken means the cookie or the form/header value was absent.
+var ErrMissingToken = errors.New("csrf: missing token")
+
+// ErrTokenMismatch means both were present but didn't match.
+var ErrTokenMismatch = errors.New("csrf: token mismatch")
+
+// Token generates a new random token value.
+func Token() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// EnsureCookie returns the CSRF token for this request, setting a new
+// cookie if one wasn't already present. Call it when rendering a page
+// that contains a form, and put the returned value in a hidden
+// "csrf_token" field.
+func EnsureCookie(w http.ResponseWriter, r *http.Request, secure bool) (string, error) {
+	if cookie, err := r.Cookie(CookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	token, err := Token()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token, nil
+}
+
+// Verify checks the request's CSRF cookie against its form field (or,
+// failing that, its X-CSRF-Token header), for use before processing a
+// mutating POST.
+func Verify(r *http.Request) error {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil || cookie.Value == "" {
+		return ErrMissingToken
+	}
+
+	submitted := r.FormValue(FieldName)
+	if submitted == "" {
+		submitted = r.Header.Get(HeaderName)
+	}
+	if submitted == "" {
+		return ErrMissingToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(submitted)) != 1 {
+		return ErrTokenMismatch
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTokenFormatAndUniqueness(t *testing.T) {
+	a, err := Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if len(a) != 64 {
+		t.Errorf("expected 64 hex chars (32 bytes), got %d: %q", len(a), a)
+	}
+
+	b, err := Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if a == b {
+		t.Error("two calls to Token returned the same value")
+	}
+}
+
+func TestEnsureCookieSetsNewCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/form", nil)
+
+	token, err := EnsureCookie(w, r, true)
+	if err != nil {
+		t.Fatalf("EnsureCookie: %v", err)
+	}
+	if token == "" {
+		t.Fatal("expected a non-empty token")
+	}
+
+	resp := w.Result()
+	cookies := resp.Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected exactly one cookie to be set, got %d", len(cookies))
+	}
+	if cookies[0].Name != CookieName {
+		t.Errorf("cookie name = %q, want %q", cookies[0].Name, CookieName)
+	}
+	if cookies[0].Value != token {
+		t.Errorf("cookie value = %q, want %q", cookies[0].Value, token)
+	}
+	if !cookies[0].Secure {
+		t.Error("expected Secure to be true")
+	}
+}
+
+func TestEnsureCookieReusesExistingCookie(t *testing.T) {
+	r := httptest.NewRequest("GET", "/form", nil)
+	r.AddCookie(&http.Cookie{Name: CookieName, Value: "existing-token-value"})
+
+	w := httptest.NewRecorder()
+	token, err := EnsureCookie(w, r, false)
+	if err != nil {
+		t.Fatalf("EnsureCookie: %v", err)
+	}
+	if token != "existing-token-value" {
+		t.Errorf("token = %q, want existing cookie value", token)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("EnsureCookie should not set a new cookie when one already exists")
+	}
+}
+
+func TestVerifySucceedsWithFormField(t *testing.T) {
+	form := url.Values{FieldName: {"abc123"}}
+	r := httptest.NewRequest("POST", "/submit", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: CookieName, Value: "abc123"})
+
+	if err := Verify(r); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestVerifySucceedsWithHeader(t *testing.T) {
+	r := httptest.NewRequest("POST", "/submit", nil)
+	r.Header.Set(HeaderName, "abc123")
+	r.AddCookie(&http.Cookie{Name: CookieName, Value: "abc123"})
+
+	if err := Verify(r); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestVerifyMissingCookie(t *testing.T) {
+	r := httptest.NewRequest("POST", "/submit", nil)
+	r.Header.Set(HeaderName, "abc123")
+
+	if err := Verify(r); err != ErrMissingToken {
+		t.Errorf("Verify = %v, want ErrMissingToken", err)
+	}
+}
+
+func TestVerifyMissingSubmittedValue(t *testing.T) {
+	r := httptest.NewRequest("POST", "/submit", nil)
+	r.AddCookie(&http.Cookie{Name: CookieName, Value: "abc123"})
+
+	if err := Verify(r); err != ErrMissingToken {
+		t.Errorf("Verify = %v, want ErrMissingToken", err)
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	r := httptest.NewRequest("POST", "/submit", nil)
+	r.Header.Set(HeaderName, "other-value")
+	r.AddCookie(&http.Cookie{Name: CookieName, Value: "abc123"})
+
+	if err := Verify(r); err != ErrTokenMismatch {
+		t.Errorf("Verify = %v, want ErrTokenMismatch", err)
+	}
+}
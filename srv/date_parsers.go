@@ -0,0 +1,78 @@
+package srv
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// parseDateOnly parses a bare "2006-01-02" calendar date.
+func parseDateOnly(s string) (time.Time, error) {
+	return time.Parse("2006-01-02", s)
+}
+
+// parseYear parses a bare four-digit year (e.g. "2020") as January 1st
+// of that year.
+func parseYear(s string) (time.Time, error) {
+	if len(s) != 4 {
+		return time.Time{}, fmt.Errorf("not a 4-digit year: %q", s)
+	}
+	y, err := strconv.Atoi(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not a year: %w", err)
+	}
+	return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseYearMonth parses a "2006-01" year-month.
+func parseYearMonth(s string) (time.Time, error) {
+	return time.Parse("2006-01", s)
+}
+
+// parseRFC3339 parses a full RFC3339 timestamp.
+func parseRFC3339(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseFlexible tries each other built-in parser in turn — dateonly,
+// yearmonth, rfc3339, then year — and returns the first success, so a
+// caller that doesn't know (or care) which shape its from/to value is
+// in can just omit ?date_parser= entirely.
+func parseFlexible(s string) (time.Time, error) {
+	for _, parse := range []func(string) (time.Time, error){parseDateOnly, parseYearMonth, parseRFC3339, parseYear} {
+		if t, err := parse(s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("value %q didn't match any known date format", s)
+}
+
+// DefaultDateParsers builds the named from/to parser registry New()
+// installs as Server.DateParsers. "flexible" is what a handler falls
+// back to when a request doesn't set ?date_parser=. Exported so
+// cmd/5mp's "aggregate narratives" subcommand, which builds a bare
+// *Server rather than going through New(), can populate the same field.
+func DefaultDateParsers() map[string]func(string) (time.Time, error) {
+	return map[string]func(string) (time.Time, error){
+		"dateonly":  parseDateOnly,
+		"year":      parseYear,
+		"yearmonth": parseYearMonth,
+		"rfc3339":   parseRFC3339,
+		"flexible":  parseFlexible,
+	}
+}
+
+// resolveDateParser returns the parser a request asked for via
+// ?date_parser=, defaulting to "flexible" when unset. ok is false if
+// the requested name isn't a registered parser, in which case callers
+// should reject the request with the offending name rather than
+// silently falling back to a default date range.
+func (s *Server) resolveDateParser(r *http.Request) (name string, parse func(string) (time.Time, error), ok bool) {
+	name = r.URL.Query().Get("date_parser")
+	if name == "" {
+		name = "flexible"
+	}
+	parse, ok = s.DateParsers[name]
+	return name, parse, ok
+}
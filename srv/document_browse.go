@@ -0,0 +1,316 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// ThumbnailRenderer produces a small preview image URL for a document, used
+// by the browse listing's thumbnail column. It's pluggable so a deployment
+// can wire in a real PDF rasterizer (e.g. shelling out to pdftoppm or
+// poppler) without this package needing to vendor one; Server defaults to
+// noThumbnailRenderer, which never produces a preview.
+type ThumbnailRenderer interface {
+	// Thumbnail returns the URL of a preview image for a document with
+	// the given file URL/type, or ok=false if it can't produce one
+	// (unsupported file type, renderer unavailable, etc).
+	Thumbnail(fileURL, fileType string) (url string, ok bool)
+}
+
+type noThumbnailRenderer struct{}
+
+func (noThumbnailRenderer) Thumbnail(string, string) (string, bool) { return "", false }
+
+// documentBrowseSortColumns are the only columns /parks/{id}/documents/
+// can sort by - the same short, fixed set Caddy's browse middleware
+// exposes (name/size/time), both because there's no real need for more
+// and because an unbounded sort key turning into a SQL identifier is an
+// injection risk. title/year/category are real ParkDocument columns and
+// are sorted in SQL; size has no column to sort by (see DocumentFileInfo),
+// so that one sorts the page in Go after fetching it.
+var documentBrowseSortColumns = map[string]bool{
+	"title":    true,
+	"year":     true,
+	"category": true,
+	"size":     true,
+}
+
+const (
+	documentBrowseDefaultLimit = 50
+	documentBrowseMaxLimit     = 500
+)
+
+// DocumentFileInfo is one row of the /parks/{id}/documents/ browse
+// listing, named and shaped after Caddy's browse.FileInfo: it embeds the
+// same DocumentResponse every other document endpoint returns, plus the
+// fields only the browse view needs, so a deployment can override
+// documents_browse.html to skin the listing without learning a second
+// field set.
+type DocumentFileInfo struct {
+	DocumentResponse
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+type documentsBrowsePageData struct {
+	ParkID   string
+	ParkName string
+	Sort     string
+	Order    string
+	Limit    int
+	Offset   int
+	Total    int64
+	HasPrev  bool
+	HasNext  bool
+	Files    []DocumentFileInfo
+}
+
+// HandleParkDocumentsBrowse serves /parks/{id}/documents/: an HTML
+// directory-style listing of a protected area's documents, sortable by
+// title/year/category/size via ?sort=&order=, paginated via
+// ?limit=&offset=, with a JSON alternate when the caller sends
+// Accept: application/json instead of (or ahead of) text/html.
+func (s *Server) HandleParkDocumentsBrowse(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	if paID == "" {
+		http.Error(w, "missing park ID", http.StatusBadRequest)
+		return
+	}
+
+	sortCol := r.URL.Query().Get("sort")
+	if sortCol == "" || !documentBrowseSortColumns[sortCol] {
+		sortCol = "title"
+	}
+	order := strings.ToUpper(r.URL.Query().Get("order"))
+	if order != "DESC" {
+		order = "ASC"
+	}
+	limit := documentBrowseDefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= documentBrowseMaxLimit {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	ctx := r.Context()
+	q := dbgen.New(s.DB)
+
+	docs, total, err := s.listParkDocumentsSorted(ctx, q, paID, sortCol, order, limit, offset)
+	if err != nil {
+		slog.Error("failed to list park documents for browse view", "pa_id", paID, "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+
+	files := make([]DocumentFileInfo, 0, len(docs))
+	for _, d := range docs {
+		files = append(files, s.documentFileInfo(d))
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"pa_id":     paID,
+			"documents": files,
+			"total":     total,
+			"sort":      sortCol,
+			"order":     order,
+			"limit":     limit,
+			"offset":    offset,
+		})
+		return
+	}
+
+	data := documentsBrowsePageData{
+		ParkID:   paID,
+		ParkName: s.parkName(paID),
+		Sort:     sortCol,
+		Order:    order,
+		Limit:    limit,
+		Offset:   offset,
+		Total:    total,
+		HasPrev:  offset > 0,
+		HasNext:  int64(offset+limit) < total,
+		Files:    files,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.renderTemplate(w, "documents_browse.html", data); err != nil {
+		slog.Warn("render documents browse template", "pa_id", paID, "error", err)
+	}
+}
+
+// wantsJSON reports whether r's Accept header prefers application/json
+// over text/html - a browser's default Accept lists text/html first, so a
+// plain substring check on "application/json" appearing before "text/html"
+// would misfire on it; instead this only matches an Accept header that
+// names application/json without also naming text/html ahead of it, which
+// covers both "Accept: application/json" (curl, fetch()) and absent
+// Accept entirely defaulting to HTML.
+func wantsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+	jsonIdx := strings.Index(accept, "application/json")
+	htmlIdx := strings.Index(accept, "text/html")
+	if jsonIdx < 0 {
+		return false
+	}
+	return htmlIdx < 0 || jsonIdx < htmlIdx
+}
+
+// listParkDocumentsSorted dispatches to the dbgen query matching
+// (sortCol, order), each a static SQL query with its own ORDER BY -
+// sqlc queries are static SQL, so there's one query per sortable column/
+// direction pair rather than a single query taking the column name as a
+// parameter. "size" isn't a column GetAllParkDocuments/ParkDocument has
+// (documents don't persist a byte size), so it can't be pushed into SQL
+// the same way: that case fetches every document for the park, stats each
+// one for its size (see documentFileInfo), sorts by that in Go, and
+// paginates the slice instead of the query.
+func (s *Server) listParkDocumentsSorted(ctx context.Context, q *dbgen.Queries, paID, sortCol, order string, limit, offset int) ([]dbgen.ParkDocument, int64, error) {
+	if sortCol == "size" {
+		all, err := q.GetAllParkDocuments(ctx, paID)
+		if err != nil {
+			return nil, 0, err
+		}
+		sortDocumentsBySize(s, all, order)
+		total := int64(len(all))
+		end := offset + limit
+		if offset >= len(all) {
+			return nil, total, nil
+		}
+		if end > len(all) {
+			end = len(all)
+		}
+		return all[offset:end], total, nil
+	}
+
+	total, err := q.CountParkDocuments(ctx, paID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	params := dbgen.ListParkDocumentsSortedParams{PaID: paID, Limit: int64(limit), Offset: int64(offset)}
+	var docs []dbgen.ParkDocument
+	switch {
+	case sortCol == "title" && order == "ASC":
+		docs, err = q.ListParkDocumentsByTitleAsc(ctx, params)
+	case sortCol == "title":
+		docs, err = q.ListParkDocumentsByTitleDesc(ctx, params)
+	case sortCol == "year" && order == "ASC":
+		docs, err = q.ListParkDocumentsByYearAsc(ctx, params)
+	case sortCol == "year":
+		docs, err = q.ListParkDocumentsByYearDesc(ctx, params)
+	case sortCol == "category" && order == "ASC":
+		docs, err = q.ListParkDocumentsByCategoryAsc(ctx, params)
+	default:
+		docs, err = q.ListParkDocumentsByCategoryDesc(ctx, params)
+	}
+	return docs, total, err
+}
+
+// sortDocumentsBySize orders docs by their on-disk size (see
+// documentFileInfo), largest/smallest first depending on order. A
+// document whose size can't be determined (external URL, missing file)
+// sorts as zero bytes.
+func sortDocumentsBySize(s *Server, docs []dbgen.ParkDocument, order string) {
+	size := func(d dbgen.ParkDocument) int64 {
+		sz, _, _ := s.documentFileStat(d)
+		return sz
+	}
+	for i := 1; i < len(docs); i++ {
+		for j := i; j > 0; j-- {
+			a, b := size(docs[j-1]), size(docs[j])
+			swap := a > b
+			if order == "DESC" {
+				swap = a < b
+			}
+			if !swap {
+				break
+			}
+			docs[j-1], docs[j] = docs[j], docs[j-1]
+		}
+	}
+}
+
+// documentFileInfo builds a DocumentFileInfo row from a ParkDocument,
+// filling in the computed size_bytes/human_size/mod_time fields and a
+// thumbnail URL if s.DocumentThumbnailer can produce one.
+func (s *Server) documentFileInfo(d dbgen.ParkDocument) DocumentFileInfo {
+	resp := DocumentResponse{
+		ID:          d.ID,
+		PaID:        d.PaID,
+		Category:    d.Category,
+		Title:       d.Title,
+		Description: d.Description,
+		URL:         d.FileUrl,
+		FileType:    d.FileType,
+		Year:        d.Year,
+		Summary:     d.Summary,
+	}
+
+	if size, modTime, ok := s.documentFileStat(d); ok {
+		resp.SizeBytes = &size
+		resp.HumanSize = humanSize(size)
+		resp.ModTime = &modTime
+	}
+
+	info := DocumentFileInfo{DocumentResponse: resp}
+	if d.FileUrl != nil && d.FileType != nil {
+		if thumb, ok := s.DocumentThumbnailer.Thumbnail(*d.FileUrl, *d.FileType); ok {
+			info.ThumbnailURL = thumb
+		}
+	}
+	return info
+}
+
+// documentFileStat stats a document's file on disk, for the computed
+// size/mtime fields. Only file URLs served from s.StaticDir (i.e.
+// "/static/...") resolve to a local path; anything else (an external
+// link, or no StaticDir configured) has no local file to stat, so ok is
+// false and the computed fields are left unset.
+func (s *Server) documentFileStat(d dbgen.ParkDocument) (size int64, modTime time.Time, ok bool) {
+	if s.StaticDir == "" || d.FileUrl == nil {
+		return 0, time.Time{}, false
+	}
+	rel := strings.TrimPrefix(*d.FileUrl, "/static/")
+	if rel == *d.FileUrl {
+		return 0, time.Time{}, false
+	}
+	fi, err := os.Stat(path.Join(s.StaticDir, rel))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return fi.Size(), fi.ModTime(), true
+}
+
+// humanSize formats a byte count the way a directory listing traditionally
+// does: one decimal place past bytes, binary (1024) units.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,173 @@
+package srv
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"srv.exe.dev/srv/areas"
+	"srv.exe.dev/srv/drivers"
+)
+
+// colocatedFireRadiusKm is how close a fire_detections row must be to a
+// deforestation cluster's centroid, in the same year, to count as
+// "co-located" for the fire_count feature — roughly the DBSCAN eps
+// used elsewhere for a single hotspot (see resolveClusterParams).
+const colocatedFireRadiusKm = 5.0
+
+// unknownSettlementDistKm is DistSettlementKm's value when no
+// settlement is within places.DefaultRadiusKm — "far enough that it
+// isn't the driver" rather than 0, which would read as "a settlement
+// is right here".
+const unknownSettlementDistKm = 50.0
+
+// classifyCluster extracts drivers.Features for a deforestation
+// cluster centered at (lat, lon) and runs s.DriverModel (see
+// srv/drivers) over them, returning the likely human driver and the
+// tree's training-set confidence for that leaf. Returns ("", 0) if no
+// model is loaded.
+//
+// DistRoadKm, Slope, ElevationM, and NightLightsDelta are left at 0:
+// OSM road data isn't ingested yet (HandleParkRoads is still a stub
+// pending Overpass integration) and this deployment has no
+// elevation/night-lights source, so the shipped tree is built to treat
+// those as "no evidence" rather than a false negative.
+func (s *Server) classifyCluster(parkID string, lat, lon, areaKm2, aspectRatio float64, year int) (label string, confidence float64) {
+	if s.DriverModel == nil {
+		return "", 0
+	}
+
+	f := drivers.Features{
+		AreaKm2:          areaKm2,
+		AspectRatio:      aspectRatio,
+		DistBoundaryKm:   s.distToBoundaryKm(parkID, lat, lon),
+		DistSettlementKm: s.distToNearestSettlementKm(parkID, lat, lon),
+		FireCount:        float64(s.colocatedFireCount(parkID, year, lat, lon, colocatedFireRadiusKm)),
+	}
+	return s.DriverModel.Classify(f)
+}
+
+// persistClusterDriver best-effort writes a classified driver back to
+// every deforestation_clusters row for (parkID, year), mirroring how
+// determinePatternType reads pattern_type back from that same table.
+func (s *Server) persistClusterDriver(parkID string, year int, label string, confidence float64) {
+	if label == "" {
+		return
+	}
+	if _, err := s.DB.Exec(`
+		UPDATE deforestation_clusters SET driver_label = ?, driver_confidence = ?
+		WHERE park_id = ? AND year = ?
+	`, label, confidence, parkID, year); err != nil {
+		slog.Warn("persist deforestation cluster driver label", "park_id", parkID, "year", year, "error", err)
+	}
+}
+
+// aspectRatioFromPattern approximates a cluster's shape from its
+// already-determined pattern_type label when the caller only has a
+// single aggregate point for the year, not the member events'
+// individual spread (contrast with fetchHotspots' DBSCAN clusters,
+// which compute a real aspect ratio from point spread).
+func aspectRatioFromPattern(pattern string) float64 {
+	switch pattern {
+	case "strip":
+		return 4.0
+	case "edge":
+		return 2.5
+	case "scattered":
+		return 1.3
+	default: // "cluster", "unknown", ""
+		return 1.0
+	}
+}
+
+// distToBoundaryKm approximates a point's distance to parkID's
+// boundary as its distance to the nearest edge of the area's bounding
+// box (areas.ProtectedArea.GetBoundingBox), converted from degrees via
+// areas.KmPerDegree. This is a rough stand-in for a true
+// point-to-polygon distance, which would need the exact ring geometry
+// areas.ProtectedArea doesn't expose outside the package; it's in the
+// same spirit as determinePatternType's "111*111 km² conversion" for
+// cluster spread.
+func (s *Server) distToBoundaryKm(parkID string, lat, lon float64) float64 {
+	if s.AreaStore == nil {
+		return 0
+	}
+	for i := range s.AreaStore.Areas {
+		a := &s.AreaStore.Areas[i]
+		if a.ID != parkID && a.WDPAID != parkID {
+			continue
+		}
+		latMin, latMax, lonMin, lonMax := a.GetBoundingBox()
+		if latMin == 0 && latMax == 0 && lonMin == 0 && lonMax == 0 {
+			return 0
+		}
+		dLat := math.Min(math.Max(lat-latMin, 0), math.Max(latMax-lat, 0))
+		dLon := math.Min(math.Max(lon-lonMin, 0), math.Max(lonMax-lon, 0))
+		latKm := dLat * areas.KmPerDegree
+		lonKm := dLon * areas.KmPerDegree * math.Cos(lat*math.Pi/180)
+		return math.Min(latKm, lonKm)
+	}
+	return 0
+}
+
+// distToNearestSettlementKm returns the distance in km from (lat, lon)
+// to the nearest village/hamlet/town/city osm_place, or
+// unknownSettlementDistKm if none was found within range.
+func (s *Server) distToNearestSettlementKm(parkID string, lat, lon float64) float64 {
+	settlements, err := s.findNearestPlaces(parkID, lat, lon, 1, []string{"village", "hamlet", "town", "city"})
+	if err != nil || len(settlements) == 0 {
+		return unknownSettlementDistKm
+	}
+	return settlements[0].Distance
+}
+
+// colocatedFireCount counts fire_detections rows for parkID in year
+// within radiusKm of (lat, lon). fire_detections isn't indexed for
+// a bounding radius query, so (as analyzeFireHotspots already does for
+// the whole park-year) it scans every detection for the year and
+// filters with haversineDistance in Go.
+func (s *Server) colocatedFireCount(parkID string, year int, lat, lon, radiusKm float64) int {
+	rows, err := s.DB.Query(`
+		SELECT latitude, longitude FROM fire_detections
+		WHERE protected_area_id = ? AND strftime('%Y', acq_date) = ?
+	`, parkID, fmt.Sprintf("%d", year))
+	if err != nil {
+		return 0
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var flat, flon float64
+		if err := rows.Scan(&flat, &flon); err != nil {
+			continue
+		}
+		if haversineDistance(lat, lon, flat, flon) <= radiusKm {
+			count++
+		}
+	}
+	return count
+}
+
+// describeDriver turns a drivers.Model leaf label into the prose
+// fetchHotspots' Description and the narrative summaries use.
+func describeDriver(label string) string {
+	switch label {
+	case "smallholder_ag":
+		return "smallholder agricultural expansion"
+	case "commercial_ag":
+		return "commercial agricultural clearing"
+	case "logging_road":
+		return "logging or road-building"
+	case "mining":
+		return "mining"
+	case "settlement_expansion":
+		return "settlement expansion"
+	case "fire_driven":
+		return "fire"
+	case "edge_encroachment":
+		return "encroachment from the park boundary"
+	default:
+		return "an undetermined driver"
+	}
+}
@@ -0,0 +1,118 @@
+// Package drivers classifies a deforestation cluster into a likely
+// human driver — smallholder_ag, commercial_ag, logging_road, mining,
+// settlement_expansion, fire_driven, or edge_encroachment — using a
+// small CART decision tree fitted offline against labeled clearings
+// (feature schema mirroring Global Forest Watch's
+// "TrainingPoints_PrimaryData" tables) and shipped as JSON, the way
+// areas.LoadWDPAIndex ships a built index instead of rebuilding one at
+// request time.
+package drivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Features are the per-cluster inputs the tree was fitted on. Slope,
+// ElevationM, and NightLightsDelta aren't backed by an ingest pipeline
+// in this deployment yet (elevation and night-lights sources are
+// future work); callers that can't compute them pass 0, which the
+// shipped tree treats as "no evidence either way" rather than a hard
+// signal in either direction.
+type Features struct {
+	AreaKm2          float64
+	AspectRatio      float64
+	DistRoadKm       float64
+	DistBoundaryKm   float64
+	DistSettlementKm float64
+	FireCount        float64
+	Slope            float64
+	ElevationM       float64
+	NightLightsDelta float64
+}
+
+// value looks up the feature named by a tree node's Feature field.
+func (f Features) value(name string) (float64, error) {
+	switch name {
+	case "area_km2":
+		return f.AreaKm2, nil
+	case "aspect_ratio":
+		return f.AspectRatio, nil
+	case "dist_road_km":
+		return f.DistRoadKm, nil
+	case "dist_boundary_km":
+		return f.DistBoundaryKm, nil
+	case "dist_settlement_km":
+		return f.DistSettlementKm, nil
+	case "fire_count":
+		return f.FireCount, nil
+	case "slope":
+		return f.Slope, nil
+	case "elevation_m":
+		return f.ElevationM, nil
+	case "night_lights_delta":
+		return f.NightLightsDelta, nil
+	default:
+		return 0, fmt.Errorf("drivers: unknown feature %q", name)
+	}
+}
+
+// Node is one node of the fitted tree, serialized as
+// {feature, threshold, left, right, class, prob}. A leaf has Class and
+// Prob set and Feature empty; an internal split has Feature, Threshold,
+// Left, and Right set. A sample routes to Left when its feature value
+// is <= Threshold, Right otherwise.
+type Node struct {
+	Feature   string  `json:"feature,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Left      *Node   `json:"left,omitempty"`
+	Right     *Node   `json:"right,omitempty"`
+	Class     string  `json:"class,omitempty"`
+	Prob      float64 `json:"prob,omitempty"`
+}
+
+func (n *Node) isLeaf() bool {
+	return n.Feature == ""
+}
+
+// Model is a fitted decision tree ready to classify Features.
+type Model struct {
+	Root *Node
+}
+
+// LoadModel reads a tree fitted offline from a JSON file shaped like
+// Node.
+func LoadModel(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root Node
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse driver tree %s: %w", path, err)
+	}
+	return &Model{Root: &root}, nil
+}
+
+// Classify walks the tree for f and returns the leaf's driver label and
+// its training-set confidence. Returns ("", 0) if m is nil, has no
+// root, or a node names a feature Features doesn't recognize.
+func (m *Model) Classify(f Features) (label string, confidence float64) {
+	if m == nil || m.Root == nil {
+		return "", 0
+	}
+	n := m.Root
+	for !n.isLeaf() {
+		v, err := f.value(n.Feature)
+		if err != nil || n.Left == nil || n.Right == nil {
+			return "", 0
+		}
+		if v <= n.Threshold {
+			n = n.Left
+		} else {
+			n = n.Right
+		}
+	}
+	return n.Class, n.Prob
+}
@@ -0,0 +1,252 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// effortRollupsLockName identifies the advisory lock acquireAggregateLock
+// takes while materializing the rollup tables below, so a manual
+// "5mp aggregate stats" run and the scheduled job don't race each other.
+const effortRollupsLockName = "effort_rollups"
+
+// effortRollupsLockTTL bounds how long a held lock is honored before
+// another pod is allowed to take over, in case the holder crashed
+// mid-run instead of releasing it.
+const effortRollupsLockTTL = 30 * time.Minute
+
+// effortRollupsDefaultLookback is how far back to recompute when no
+// prior successful run is recorded in aggregate_runs (first run after
+// deploy, or a dropped lock row).
+const effortRollupsDefaultLookback = 90 * 24 * time.Hour
+
+// MaterializeEffortRollups recomputes daily_effort_stats, daily_fire_stats,
+// monthly_effort_stats, and pa_monthly_rollup for every day on or after
+// since, so HandleAPIStats can answer with a handful of indexed range
+// queries instead of a per-year dbgen loop plus three hand-written
+// s.DB.QueryRow fire/deforestation/settlement scans.
+//
+// pa_monthly_rollup is built from park_metrics_daily, not from a raw
+// fact table: that table already stores one cumulative snapshot per
+// (park_id, snapshot_date) every time park_metrics_refresh runs, so
+// summing fire_count/deforestation_km2 straight across days would double-
+// count whatever a --since rerun re-touches. Per-day deltas are computed
+// first with LAG, partitioned by park_id and ordered by snapshot_date,
+// and only those deltas are summed into a month — the same shape as
+// diffing any cumulative counter against its previous reading, just
+// expressed as a window function instead of an in-memory loop.
+//
+// It's called both by cmd/5mp's "aggregate stats" subcommand and by the
+// effort_rollups_refresh scheduled job (see scheduler_jobs.go); either
+// caller skips the run entirely (ok=false, err=nil) if another pod
+// currently holds the lock.
+func (s *Server) MaterializeEffortRollups(ctx context.Context, since time.Time) (rowCount int, ok bool, err error) {
+	got, err := s.acquireAggregateLock(effortRollupsLockName, effortRollupsLockTTL)
+	if err != nil {
+		return 0, false, err
+	}
+	if !got {
+		return 0, false, nil
+	}
+	defer s.releaseAggregateLock(effortRollupsLockName)
+
+	start := time.Now()
+	sinceDay := since.UTC().Format("2006-01-02")
+	generatedAt := time.Now()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, true, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	n, err := upsertDailyEffortStats(tx, sinceDay, generatedAt)
+	if err != nil {
+		return rowCount, true, fmt.Errorf("daily_effort_stats: %w", err)
+	}
+	rowCount += n
+
+	n, err = upsertDailyFireStats(tx, sinceDay, generatedAt)
+	if err != nil {
+		return rowCount, true, fmt.Errorf("daily_fire_stats: %w", err)
+	}
+	rowCount += n
+
+	n, err = upsertMonthlyEffortStats(tx, sinceDay, generatedAt)
+	if err != nil {
+		return rowCount, true, fmt.Errorf("monthly_effort_stats: %w", err)
+	}
+	rowCount += n
+
+	n, err = upsertPAMonthlyRollup(tx, sinceDay, generatedAt)
+	if err != nil {
+		return rowCount, true, fmt.Errorf("pa_monthly_rollup: %w", err)
+	}
+	rowCount += n
+
+	runErr := recordAggregateRun(tx, effortRollupsLockName, generatedAt, rowCount, time.Since(start))
+
+	if err := tx.Commit(); err != nil {
+		return rowCount, true, fmt.Errorf("commit: %w", err)
+	}
+	return rowCount, true, runErr
+}
+
+// LastEffortRollupRun returns the last recorded effort_rollups run time,
+// so callers (the scheduled job, "5mp aggregate stats" with no -since
+// flag) can default to recomputing only what's changed since then. ok is
+// false if no run has ever succeeded.
+func (s *Server) LastEffortRollupRun(ctx context.Context) (ranAt time.Time, ok bool, err error) {
+	err = s.DB.QueryRowContext(ctx, `SELECT last_run_at FROM aggregate_runs WHERE name = ?`, effortRollupsLockName).Scan(&ranAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return ranAt, true, nil
+}
+
+// upsertDailyEffortStats rolls gpx_uploads up to one row per calendar
+// day: total distance and upload count come straight off gpx_uploads,
+// while active_pixels (distinct grid cells touched that day) is joined
+// in separately from track_points to avoid the join fanout inflating
+// the distance/upload sums.
+func upsertDailyEffortStats(tx *sql.Tx, sinceDay string, generatedAt time.Time) (int, error) {
+	res, err := tx.Exec(`
+		INSERT INTO daily_effort_stats (day, active_pixels, total_distance_km, total_uploads, generated_at)
+		SELECT e.day, COALESCE(p.active_pixels, 0), e.total_distance_km, e.total_uploads, ?
+		FROM (
+			SELECT date(upload_date) AS day, SUM(total_distance_km) AS total_distance_km, COUNT(*) AS total_uploads
+			FROM gpx_uploads
+			WHERE upload_date >= ?
+			GROUP BY date(upload_date)
+		) e
+		LEFT JOIN (
+			SELECT date(u.upload_date) AS day, COUNT(DISTINCT tp.grid_cell_id) AS active_pixels
+			FROM gpx_uploads u
+			JOIN track_points tp ON tp.upload_id = u.id
+			WHERE u.upload_date >= ?
+			GROUP BY date(u.upload_date)
+		) p ON p.day = e.day
+		ON CONFLICT (day) DO UPDATE SET
+			active_pixels = excluded.active_pixels,
+			total_distance_km = excluded.total_distance_km,
+			total_uploads = excluded.total_uploads,
+			generated_at = excluded.generated_at
+	`, generatedAt, sinceDay, sinceDay)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// upsertDailyFireStats rolls fire_detections up to one row per day.
+// Detections aren't a cumulative counter (each row is a single VIIRS
+// hotspot), so a plain COUNT per day is already idempotent — no LEAD/LAG
+// needed here.
+func upsertDailyFireStats(tx *sql.Tx, sinceDay string, generatedAt time.Time) (int, error) {
+	res, err := tx.Exec(`
+		INSERT INTO daily_fire_stats (day, fire_count, generated_at)
+		SELECT acq_date, COUNT(*), ?
+		FROM fire_detections
+		WHERE acq_date >= ?
+		GROUP BY acq_date
+		ON CONFLICT (day) DO UPDATE SET
+			fire_count = excluded.fire_count,
+			generated_at = excluded.generated_at
+	`, generatedAt, sinceDay)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// upsertMonthlyEffortStats rolls daily_effort_stats up one more level, to
+// one row per calendar month, so a multi-year trend query isn't scanning
+// day-level rows. active_pixels here is a sum-of-daily-distinct-counts
+// (a cell active on five different days adds five, not one), which is an
+// approximation of monthly effort volume rather than a true distinct-
+// cell count for the month — acceptable for a trend line, unlike the
+// exact per-query distinct count HandleAPIStats still computes for a
+// bbox-filtered request.
+func upsertMonthlyEffortStats(tx *sql.Tx, sinceDay string, generatedAt time.Time) (int, error) {
+	res, err := tx.Exec(`
+		INSERT INTO monthly_effort_stats (month, active_pixels, total_distance_km, total_uploads, generated_at)
+		SELECT strftime('%Y-%m', day), SUM(active_pixels), SUM(total_distance_km), SUM(total_uploads), ?
+		FROM daily_effort_stats
+		WHERE day >= ?
+		GROUP BY strftime('%Y-%m', day)
+		ON CONFLICT (month) DO UPDATE SET
+			active_pixels = excluded.active_pixels,
+			total_distance_km = excluded.total_distance_km,
+			total_uploads = excluded.total_uploads,
+			generated_at = excluded.generated_at
+	`, generatedAt, sinceDay)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// upsertPAMonthlyRollup derives one row per (park_id, month) from
+// park_metrics_daily's cumulative snapshots. See MaterializeEffortRollups'
+// doc comment for why the LAG-based delta is necessary rather than
+// summing fire_count/deforestation_km2 directly.
+func upsertPAMonthlyRollup(tx *sql.Tx, sinceDay string, generatedAt time.Time) (int, error) {
+	res, err := tx.Exec(`
+		INSERT INTO pa_monthly_rollup (park_id, month, fire_count, deforestation_km2, settlement_count, generated_at)
+		SELECT park_id, strftime('%Y-%m', snapshot_date), SUM(fire_delta), SUM(deforestation_delta), MAX(settlement_count), ?
+		FROM (
+			SELECT park_id, snapshot_date, settlement_count,
+				fire_count - COALESCE(LAG(fire_count) OVER (PARTITION BY park_id ORDER BY snapshot_date), 0) AS fire_delta,
+				deforestation_km2 - COALESCE(LAG(deforestation_km2) OVER (PARTITION BY park_id ORDER BY snapshot_date), 0) AS deforestation_delta
+			FROM park_metrics_daily
+		) diffs
+		WHERE snapshot_date >= ?
+		GROUP BY park_id, strftime('%Y-%m', snapshot_date)
+		ON CONFLICT (park_id, month) DO UPDATE SET
+			fire_count = excluded.fire_count,
+			deforestation_km2 = excluded.deforestation_km2,
+			settlement_count = excluded.settlement_count,
+			generated_at = excluded.generated_at
+	`, generatedAt, sinceDay)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// HandleAdminAggregateRun triggers an immediate effort-rollup rebuild, so
+// an operator doesn't have to wait for the next scheduled cycle after a
+// bulk import. Recomputes since the last successful run (or
+// effortRollupsDefaultLookback if there isn't one) — same default the
+// scheduled job and "5mp aggregate stats" use when -since isn't given.
+func (s *Server) HandleAdminAggregateRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	since := time.Now().Add(-effortRollupsDefaultLookback)
+	if lastRun, ok, err := s.LastEffortRollupRun(ctx); err == nil && ok {
+		since = lastRun
+	}
+
+	_, ok, err := s.MaterializeEffortRollups(ctx, since)
+	if err != nil {
+		http.Error(w, "rebuild failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		slog.Info("admin aggregate run: another pod holds the effort_rollups lock, skipped")
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,13 +1,86 @@
 package srv
 
 import (
-	"encoding/csv"
 	"fmt"
+	"io"
+	"iter"
 	"net/http"
+	"strconv"
 	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/export"
+	"srv.exe.dev/srv/filter"
 )
 
-// ParkExportRow represents a single park's data for CSV export.
+// parkMetricsFilterSchema describes the DSL fields ?filter= supports
+// against park_metrics_current/park_metrics_daily and live ParkExportRows
+// (see srv/filter). bbox isn't listed: those tables hold per-park
+// aggregates, not points or polygons, so there's no lon/lat column a
+// bbox term could compile against.
+var parkMetricsFilterSchema = filter.Schema{
+	Columns: map[string]string{
+		"park_id":           "park_id",
+		"name":              "name",
+		"country":           "country",
+		"area_km2":          "area_km2",
+		"fire_count":        "fire_count",
+		"settlement_count":  "settlement_count",
+		"deforestation_km2": "deforestation_km2",
+		"roadless_pct":      "roadless_pct",
+	},
+	Numeric: map[string]bool{
+		"area_km2":          true,
+		"fire_count":        true,
+		"settlement_count":  true,
+		"deforestation_km2": true,
+		"roadless_pct":      true,
+	},
+}
+
+// Field implements filter.FieldGetter, letting a parsed filter.Expr be
+// evaluated in-memory against a row (the live-computation and ?since=
+// diff paths, which don't go through parkMetricsFilterSchema.CompileSQL).
+func (row ParkExportRow) Field(name string) (interface{}, bool) {
+	switch name {
+	case "park_id":
+		return row.ParkID, true
+	case "name":
+		return row.Name, true
+	case "country":
+		return row.Country, true
+	case "area_km2":
+		return row.AreaKm2, true
+	case "fire_count":
+		return float64(row.FireCount), true
+	case "settlement_count":
+		return float64(row.SettlementCount), true
+	case "deforestation_km2":
+		return row.DeforestationKm2, true
+	case "roadless_pct":
+		return row.RoadlessPct, true
+	default:
+		return nil, false
+	}
+}
+
+// filterRowSeq wraps rows so only those matching pred are yielded. A nil
+// pred (no ?filter= given) returns rows unchanged.
+func filterRowSeq(rows iter.Seq[ParkExportRow], pred func(filter.FieldGetter) bool) iter.Seq[ParkExportRow] {
+	if pred == nil {
+		return rows
+	}
+	return func(yield func(ParkExportRow) bool) {
+		for row := range rows {
+			if pred(row) && !yield(row) {
+				return
+			}
+		}
+	}
+}
+
+// ParkExportRow represents a single park's data for export, in any
+// of the formats registered in exporters (see exporters.go).
 type ParkExportRow struct {
 	ParkID           string
 	Name             string
@@ -19,139 +92,393 @@ type ParkExportRow struct {
 	RoadlessPct      float64
 }
 
-// HandleAPIExportParks exports park data as CSV.
-// GET /api/export/parks?format=csv
+// HandleAPIExportParks exports park data in a caller-chosen format.
+// GET /api/export/parks?format=csv|geojson|ndjson|xlsx|parquet&include=fires,settlements,deforestation,roadless&asof=YYYY-MM-DD&since=YYYY-MM-DD&filter=...
+//
+// format defaults to csv and falls back to the Accept header when
+// unset; include defaults to all four metrics. filter is a srv/filter
+// DSL expression (e.g. "country:TZA AND area_km2:>1000") evaluated
+// against whichever row source asof/since/the default selects; a
+// malformed expression fails the request with a 400 naming the
+// offending token position instead of silently ignoring it.
+//
+// Rows come from the park_metrics_current/park_metrics_daily tables
+// materialized by MaterializeParkMetrics (see park_metrics.go and
+// cmd/5mp's "aggregate parks" subcommand), not from a live join over
+// fire_detections/park_settlements/deforestation_events/
+// osm_roadless_data on every request. ?asof= serves a historical daily
+// snapshot instead of the current one; ?since= switches to diff mode,
+// returning only parks whose metrics changed between that snapshot and
+// the current one. If no snapshot has been materialized yet (an empty
+// park_metrics_current, e.g. right after a fresh deploy), it falls back
+// to computing rows live so the endpoint still works before the first
+// aggregation run.
 func (s *Server) HandleAPIExportParks(w http.ResponseWriter, r *http.Request) {
-	format := r.URL.Query().Get("format")
-	if format != "csv" {
-		http.Error(w, "Only CSV format is supported. Use ?format=csv", http.StatusBadRequest)
+	formatName, exp := resolveExporter(r)
+	if exp == nil {
+		http.Error(w, fmt.Sprintf("Unsupported format %q. Supported: csv, geojson, ndjson, xlsx, parquet", formatName), http.StatusBadRequest)
 		return
 	}
 
-	// Get park data from AreaStore
 	if s.AreaStore == nil {
 		http.Error(w, "Area store not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	// Build map of park IDs to export rows from area data
-	parkData := make(map[string]*ParkExportRow)
-	for _, area := range s.AreaStore.Areas {
-		parkData[area.ID] = &ParkExportRow{
-			ParkID:  area.ID,
-			Name:    area.Name,
-			Country: area.Country,
-			AreaKm2: area.AreaKm2,
-		}
-	}
-
-	// Query fire_detections: count per park
-	fireRows, err := s.DB.Query(`
-		SELECT protected_area_id, COUNT(*) as fire_count 
-		FROM fire_detections 
-		WHERE protected_area_id IS NOT NULL AND protected_area_id != ''
-		GROUP BY protected_area_id
-	`)
-	if err == nil {
-		defer fireRows.Close()
-		for fireRows.Next() {
-			var parkID string
-			var count int64
-			if err := fireRows.Scan(&parkID, &count); err == nil {
-				if row, ok := parkData[parkID]; ok {
-					row.FireCount = count
+	include := parseIncludeSet(r.URL.Query().Get("include"))
+	q := r.URL.Query()
+
+	var filterExpr filter.Expr
+	if raw := q.Get("filter"); raw != "" {
+		expr, err := filter.Parse(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid filter: %v", err), http.StatusBadRequest)
+			return
+		}
+		filterExpr = expr
+	}
+
+	var rows iter.Seq[ParkExportRow]
+	var err error
+	switch {
+	case q.Get("since") != "":
+		rows, err = s.exportDiffRowSeq(q.Get("since"))
+		rows = filterRowSeq(rows, predicateFor(filterExpr))
+	case q.Get("asof") != "":
+		rows, err = s.exportSnapshotRowSeq(q.Get("asof"), filterExpr)
+	default:
+		rows, err = s.exportCurrentRowSeq(filterExpr)
+	}
+	if err != nil {
+		if _, ok := err.(*filter.ParseError); ok {
+			http.Error(w, fmt.Sprintf("invalid filter: %v", err), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to load park metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if rows == nil {
+		rows = filterRowSeq(s.exportRowSeq(include), predicateFor(filterExpr))
+	}
+
+	filename := fmt.Sprintf("parks_export_%s.%s", time.Now().Format("2006-01-02"), exp.Extension())
+	w.Header().Set("Content-Type", exp.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	out := io.Writer(w)
+	if encoding, enc := negotiateEncoding(r, w); enc != nil {
+		w.Header().Set("Content-Encoding", encoding)
+		defer enc.Close()
+		out = enc
+	}
+
+	geoms := s.exportGeomSeq()
+	if err := exp.Write(out, rows, geoms); err != nil {
+		// Headers (and possibly part of the body) are already written by
+		// the time a streaming exporter fails, so there's no clean way to
+		// turn this into an HTTP error response; just stop.
+		return
+	}
+}
+
+// predicateFor compiles expr (if non-nil) into an in-memory predicate
+// for filterRowSeq; nil in, nil out.
+func predicateFor(expr filter.Expr) func(filter.FieldGetter) bool {
+	if expr == nil {
+		return nil
+	}
+	return filter.CompilePredicate(expr)
+}
+
+// exportCurrentRowSeq streams park_metrics_current, the rolling
+// materialized snapshot MaterializeParkMetrics keeps up to date,
+// optionally narrowed by filterExpr (compiled to a SQL WHERE fragment
+// via parkMetricsFilterSchema). It returns a nil Seq (not an error) when
+// the table is empty, so the caller can fall back to exportRowSeq's live
+// computation.
+func (s *Server) exportCurrentRowSeq(filterExpr filter.Expr) (iter.Seq[ParkExportRow], error) {
+	query := `
+		SELECT park_id, name, country, area_km2, fire_count, settlement_count, deforestation_km2, roadless_pct
+		FROM park_metrics_current
+	`
+	var args []interface{}
+	if filterExpr != nil {
+		where, fargs, err := parkMetricsFilterSchema.CompileSQL(filterExpr)
+		if err != nil {
+			return nil, err
+		}
+		query += " WHERE " + where
+		args = fargs
+	}
+	return s.queryParkMetricsRows(query, args...)
+}
+
+// exportSnapshotRowSeq streams the park_metrics_daily snapshot for
+// asofDate (YYYY-MM-DD), optionally narrowed by filterExpr, for ?asof=
+// historical exports.
+func (s *Server) exportSnapshotRowSeq(asofDate string, filterExpr filter.Expr) (iter.Seq[ParkExportRow], error) {
+	query := `
+		SELECT park_id, name, country, area_km2, fire_count, settlement_count, deforestation_km2, roadless_pct
+		FROM park_metrics_daily
+		WHERE snapshot_date = ?
+	`
+	args := []interface{}{asofDate}
+	if filterExpr != nil {
+		where, fargs, err := parkMetricsFilterSchema.CompileSQL(filterExpr)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND " + where
+		args = append(args, fargs...)
+	}
+	return s.queryParkMetricsRows(query, args...)
+}
+
+// exportDiffRowSeq compares the sinceDate (YYYY-MM-DD) snapshot in
+// park_metrics_daily against park_metrics_current and yields only the
+// parks whose metrics changed (or that didn't exist in the since
+// snapshot at all), for ?since= diff-mode exports. It has to hold both
+// snapshots in memory to compare them — unlike the other row sources,
+// this one can't stream without a second pass over the same data.
+func (s *Server) exportDiffRowSeq(sinceDate string) (iter.Seq[ParkExportRow], error) {
+	before := make(map[string]ParkExportRow)
+	rows, err := s.DB.Query(`
+		SELECT park_id, name, country, area_km2, fire_count, settlement_count, deforestation_km2, roadless_pct
+		FROM park_metrics_daily WHERE snapshot_date = ?
+	`, sinceDate)
+	if err != nil {
+		return nil, fmt.Errorf("query since snapshot: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row ParkExportRow
+		if err := rows.Scan(&row.ParkID, &row.Name, &row.Country, &row.AreaKm2, &row.FireCount, &row.SettlementCount, &row.DeforestationKm2, &row.RoadlessPct); err == nil {
+			before[row.ParkID] = row
+		}
+	}
+
+	currentSeq, err := s.exportCurrentRowSeq(nil)
+	if err != nil {
+		return nil, err
+	}
+	if currentSeq == nil {
+		return func(yield func(ParkExportRow) bool) {}, nil
+	}
+
+	return func(yield func(ParkExportRow) bool) {
+		for row := range currentSeq {
+			if prior, ok := before[row.ParkID]; ok && prior == row {
+				continue
+			}
+			if !yield(row) {
+				return
+			}
+		}
+	}, nil
+}
+
+// queryParkMetricsRows runs query (which must select the eight
+// ParkExportRow columns in order) and buffers the results into a Seq.
+// It returns (nil, nil) on a successful query with zero rows, which
+// HandleAPIExportParks treats as "no snapshot yet, fall back to live".
+func (s *Server) queryParkMetricsRows(query string, args ...interface{}) (iter.Seq[ParkExportRow], error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query park metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ParkExportRow
+	for rows.Next() {
+		var row ParkExportRow
+		if err := rows.Scan(&row.ParkID, &row.Name, &row.Country, &row.AreaKm2, &row.FireCount, &row.SettlementCount, &row.DeforestationKm2, &row.RoadlessPct); err != nil {
+			return nil, fmt.Errorf("scan park metrics row: %w", err)
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return func(yield func(ParkExportRow) bool) {
+		for _, row := range out {
+			if !yield(row) {
+				return
+			}
+		}
+	}, nil
+}
+
+// exportRowSeq streams one ParkExportRow per protected area, filling
+// in only the metrics include asks for. It issues the same four
+// GROUP BY queries HandleAPIExportParks used to run on every request
+// before MaterializeParkMetrics existed; it's still used by that
+// aggregation job itself, and as HandleAPIExportParks' fallback when no
+// snapshot has been materialized yet.
+func (s *Server) exportRowSeq(include includeSet) iter.Seq[ParkExportRow] {
+	fireCounts := make(map[string]int64)
+	if include.wantFires() {
+		if rows, err := s.DB.Query(`
+			SELECT protected_area_id, COUNT(*) as fire_count
+			FROM fire_detections
+			WHERE protected_area_id IS NOT NULL AND protected_area_id != ''
+			GROUP BY protected_area_id
+		`); err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var parkID string
+				var count int64
+				if rows.Scan(&parkID, &count) == nil {
+					fireCounts[parkID] = count
 				}
 			}
 		}
 	}
 
-	// Query park_settlements: count per park
-	settlementRows, err := s.DB.Query(`
-		SELECT park_id, COUNT(*) as settlement_count 
-		FROM park_settlements 
-		GROUP BY park_id
-	`)
-	if err == nil {
-		defer settlementRows.Close()
-		for settlementRows.Next() {
-			var parkID string
-			var count int64
-			if err := settlementRows.Scan(&parkID, &count); err == nil {
-				if row, ok := parkData[parkID]; ok {
-					row.SettlementCount = count
+	settlementCounts := make(map[string]int64)
+	if include.wantSettlements() {
+		if rows, err := s.DB.Query(`
+			SELECT park_id, COUNT(*) as settlement_count
+			FROM park_settlements
+			GROUP BY park_id
+		`); err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var parkID string
+				var count int64
+				if rows.Scan(&parkID, &count) == nil {
+					settlementCounts[parkID] = count
 				}
 			}
 		}
 	}
 
-	// Query deforestation_events: sum area per park
-	deforestRows, err := s.DB.Query(`
-		SELECT park_id, SUM(area_km2) as total_area 
-		FROM deforestation_events 
-		GROUP BY park_id
-	`)
-	if err == nil {
-		defer deforestRows.Close()
-		for deforestRows.Next() {
-			var parkID string
-			var totalArea float64
-			if err := deforestRows.Scan(&parkID, &totalArea); err == nil {
-				if row, ok := parkData[parkID]; ok {
-					row.DeforestationKm2 = totalArea
+	deforestationArea := make(map[string]float64)
+	if include.wantDeforestation() {
+		if rows, err := s.DB.Query(`
+			SELECT park_id, SUM(area_km2) as total_area
+			FROM deforestation_events
+			GROUP BY park_id
+		`); err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var parkID string
+				var totalArea float64
+				if rows.Scan(&parkID, &totalArea) == nil {
+					deforestationArea[parkID] = totalArea
 				}
 			}
 		}
 	}
 
-	// Query osm_roadless_data: roadless percentage per park
-	roadlessRows, err := s.DB.Query(`
-		SELECT park_id, roadless_percentage 
-		FROM osm_roadless_data 
-		WHERE roadless_percentage IS NOT NULL
-	`)
-	if err == nil {
-		defer roadlessRows.Close()
-		for roadlessRows.Next() {
-			var parkID string
-			var pct float64
-			if err := roadlessRows.Scan(&parkID, &pct); err == nil {
-				if row, ok := parkData[parkID]; ok {
-					row.RoadlessPct = pct
+	roadlessPct := make(map[string]float64)
+	if include.wantRoadless() {
+		if rows, err := s.DB.Query(`
+			SELECT park_id, roadless_percentage
+			FROM osm_roadless_data
+			WHERE roadless_percentage IS NOT NULL
+		`); err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var parkID string
+				var pct float64
+				if rows.Scan(&parkID, &pct) == nil {
+					roadlessPct[parkID] = pct
 				}
 			}
 		}
 	}
 
-	// Set headers for CSV download
-	filename := fmt.Sprintf("parks_export_%s.csv", time.Now().Format("2006-01-02"))
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return func(yield func(ParkExportRow) bool) {
+		for _, area := range s.AreaStore.Areas {
+			row := ParkExportRow{
+				ParkID:           area.ID,
+				Name:             area.Name,
+				Country:          area.Country,
+				AreaKm2:          area.AreaKm2,
+				FireCount:        fireCounts[area.ID],
+				SettlementCount:  settlementCounts[area.ID],
+				DeforestationKm2: deforestationArea[area.ID],
+				RoadlessPct:      roadlessPct[area.ID],
+			}
+			if !yield(row) {
+				return
+			}
+		}
+	}
+}
 
-	// Write CSV
-	csvWriter := csv.NewWriter(w)
-	defer csvWriter.Flush()
+// exportGeomSeq streams each protected area's polygon geometry, for
+// exporters (geoJSONExporter, ndjsonExporter) that attach spatial
+// layers to their output.
+func (s *Server) exportGeomSeq() iter.Seq[Geometry] {
+	return func(yield func(Geometry) bool) {
+		for _, area := range s.AreaStore.Areas {
+			g := Geometry{
+				ParkID:      area.ID,
+				Type:        area.Geometry.Type,
+				Coordinates: area.Geometry.Coordinates,
+			}
+			if !yield(g) {
+				return
+			}
+		}
+	}
+}
 
-	// Write header
-	header := []string{"park_id", "name", "country", "area_km2", "fire_count", "settlement_count", "deforestation_km2", "roadless_pct"}
-	if err := csvWriter.Write(header); err != nil {
-		http.Error(w, "Failed to write CSV header", http.StatusInternalServerError)
-		return
+// HandleAPIExportEffortNetCDF exports the effort_data grid as a
+// COARDS-conformant NetCDF file for scientific pipelines (xarray, R, etc).
+// GET /api/export/effort.nc?from_year=2023&to_year=2024&movement_type=foot,vehicle&bbox=minLon,minLat,maxLon,maxLat
+func (s *Server) HandleAPIExportEffortNetCDF(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	now := time.Now()
+	fromYear := int64(now.Year())
+	toYear := int64(now.Year())
+	if v := q.Get("from_year"); v != "" {
+		if y, err := strconv.ParseInt(v, 10, 64); err == nil {
+			fromYear = y
+		}
+	}
+	if v := q.Get("to_year"); v != "" {
+		if y, err := strconv.ParseInt(v, 10, 64); err == nil {
+			toYear = y
+		}
 	}
 
-	// Write data rows
-	for _, row := range parkData {
-		record := []string{
-			row.ParkID,
-			row.Name,
-			row.Country,
-			fmt.Sprintf("%.2f", row.AreaKm2),
-			fmt.Sprintf("%d", row.FireCount),
-			fmt.Sprintf("%d", row.SettlementCount),
-			fmt.Sprintf("%.4f", row.DeforestationKm2),
-			fmt.Sprintf("%.2f", row.RoadlessPct),
+	filter := export.EffortFilter{FromYear: fromYear, ToYear: toYear}
+	if v := q.Get("movement_type"); v != "" {
+		filter.MovementTypes = splitAndTrim(v)
+	}
+	if v := q.Get("bbox"); v != "" {
+		var minLon, minLat, maxLon, maxLat float64
+		if _, err := fmt.Sscanf(v, "%f,%f,%f,%f", &minLon, &minLat, &maxLon, &maxLat); err == nil {
+			filter.BBox = &export.BBox{MinLon: minLon, MinLat: minLat, MaxLon: maxLon, MaxLat: maxLat}
 		}
-		if err := csvWriter.Write(record); err != nil {
-			return // Connection closed or error
+	}
+
+	dbQ := dbgen.New(s.DB)
+
+	w.Header().Set("Content-Type", "application/x-netcdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="effort.nc"`)
+	if err := export.ExportEffortNetCDF(r.Context(), dbQ, filter, w); err != nil {
+		http.Error(w, fmt.Sprintf("failed to export NetCDF: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// splitAndTrim splits a comma-separated query parameter into trimmed values.
+func splitAndTrim(s string) []string {
+	var out []string
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == ',' {
+			if part := s[start:i]; part != "" {
+				out = append(out, part)
+			}
+			start = i + 1
 		}
 	}
+	return out
 }
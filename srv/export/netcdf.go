@@ -0,0 +1,354 @@
+// Package export writes the effort_data grid out in formats scientific
+// pipelines (xarray, R, InMAP-style workflows) expect, starting with
+// COARDS/NetCDF classic.
+package export
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// netCDFFillValue is the CF convention _FillValue for missing cells.
+const netCDFFillValue = float32(-9999.0)
+
+// EffortFilter narrows the effort_data rows included in an export.
+type EffortFilter struct {
+	FromYear, ToYear int64
+	MovementTypes    []string
+	BBox             *BBox
+}
+
+// BBox is a geographic bounding box in degrees.
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// gridCellSize matches srv.gridCellSize (0.1 degree cells); duplicated here
+// so this package has no dependency on srv.
+const gridCellSize = 0.1
+
+// ExportEffortNetCDF reads effort_data for the requested time range,
+// movement types, and BBox, materializes a regular lat x lon x time cube at
+// the existing 0.1° grid resolution (filling missing cells with the CF
+// _FillValue), and writes a COARDS-conformant NetCDF classic file to w.
+func ExportEffortNetCDF(ctx context.Context, db dbQuerier, filter EffortFilter, w io.Writer) error {
+	lats, lons := gridAxes(filter.BBox)
+	years := make([]int64, 0, filter.ToYear-filter.FromYear+1)
+	for y := filter.FromYear; y <= filter.ToYear; y++ {
+		years = append(years, y)
+	}
+	if len(years) == 0 {
+		years = []int64{filter.FromYear}
+	}
+
+	distance := newCube(len(years), len(lats), len(lons))
+	points := newCube(len(years), len(lats), len(lons))
+	uploads := newCube(len(years), len(lats), len(lons))
+
+	for ti, year := range years {
+		rows, err := db.GetEffortDataByYear(ctx, year)
+		if err != nil {
+			return fmt.Errorf("read effort_data for year %d: %w", year, err)
+		}
+		for _, row := range rows {
+			if !matchesMovementType(row.MovementType, filter.MovementTypes) {
+				continue
+			}
+			cellLat, cellLon, ok := parseGridCellID(row.GridCellID)
+			if !ok {
+				continue
+			}
+			li := latIndex(lats, cellLat+gridCellSize/2)
+			lo := lonIndex(lons, cellLon+gridCellSize/2)
+			if li < 0 || lo < 0 {
+				continue
+			}
+			idx := ti*len(lats)*len(lons) + li*len(lons) + lo
+			distance[idx] = float32(row.TotalDistanceKm)
+			points[idx] = float32(row.TotalPoints)
+			uploads[idx] = float32(row.UniqueUploads)
+		}
+	}
+
+	writer := newCDFWriter()
+	writer.addDim("lat", len(lats))
+	writer.addDim("lon", len(lons))
+	writer.addDim("time", len(years))
+
+	writer.addVar(cdfVar{
+		Name: "lat", Dims: []string{"lat"}, Data: lats,
+		Attrs: map[string]string{"units": "degrees_north", "standard_name": "latitude", "long_name": "latitude"},
+	})
+	writer.addVar(cdfVar{
+		Name: "lon", Dims: []string{"lon"}, Data: lons,
+		Attrs: map[string]string{"units": "degrees_east", "standard_name": "longitude", "long_name": "longitude"},
+	})
+	timeData := make([]float64, len(years))
+	epoch := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, y := range years {
+		timeData[i] = time.Date(int(y), 1, 1, 0, 0, 0, 0, time.UTC).Sub(epoch).Hours() / 24
+	}
+	writer.addVar(cdfVar{
+		Name: "time", Dims: []string{"time"}, Data: timeData,
+		Attrs: map[string]string{"units": "days since 1970-01-01 00:00:00", "standard_name": "time", "long_name": "time", "calendar": "gregorian"},
+	})
+
+	writer.addVar(cdfVar{
+		Name: "distance_km", Dims: []string{"time", "lat", "lon"}, DataF32: distance,
+		Attrs: map[string]string{"units": "km", "long_name": "total patrol distance", "standard_name": "distance"},
+		FillValue: netCDFFillValue,
+	})
+	writer.addVar(cdfVar{
+		Name: "point_count", Dims: []string{"time", "lat", "lon"}, DataF32: points,
+		Attrs: map[string]string{"units": "1", "long_name": "total GPS point count", "standard_name": "point_count"},
+		FillValue: netCDFFillValue,
+	})
+	writer.addVar(cdfVar{
+		Name: "unique_uploads", Dims: []string{"time", "lat", "lon"}, DataF32: uploads,
+		Attrs: map[string]string{"units": "1", "long_name": "unique upload count", "standard_name": "unique_uploads"},
+		FillValue: netCDFFillValue,
+	})
+
+	writer.globalAttrs = map[string]string{
+		"Conventions": "COARDS",
+		"title":       "5MP patrol effort grid",
+		"source":      "5mp effort_data",
+	}
+
+	return writer.write(w)
+}
+
+// dbQuerier is the subset of dbgen.Queries this package needs, so tests can
+// supply a fake without a real database.
+type dbQuerier interface {
+	GetEffortDataByYear(ctx context.Context, year int64) ([]dbgen.GetEffortDataByYearRow, error)
+}
+
+// parseGridCellID parses the "lat_lon" grid cell ID format used by
+// gridCellIDForPoint (srv/upload.go) back into its min-corner lat/lon.
+func parseGridCellID(id string) (lat, lon float64, ok bool) {
+	var n int
+	n, _ = fmt.Sscanf(id, "%f_%f", &lat, &lon)
+	return lat, lon, n == 2
+}
+
+func matchesMovementType(mt string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return mt == "all"
+	}
+	for _, a := range allowed {
+		if a == mt {
+			return true
+		}
+	}
+	return false
+}
+
+func gridAxes(bbox *BBox) (lats, lons []float64) {
+	minLat, maxLat, minLon, maxLon := -90.0, 90.0, -180.0, 180.0
+	if bbox != nil {
+		minLat, maxLat, minLon, maxLon = bbox.MinLat, bbox.MaxLat, bbox.MinLon, bbox.MaxLon
+	}
+	for lat := math.Floor(minLat/gridCellSize) * gridCellSize; lat < maxLat; lat += gridCellSize {
+		lats = append(lats, lat+gridCellSize/2)
+	}
+	for lon := math.Floor(minLon/gridCellSize) * gridCellSize; lon < maxLon; lon += gridCellSize {
+		lons = append(lons, lon+gridCellSize/2)
+	}
+	return lats, lons
+}
+
+func latIndex(lats []float64, lat float64) int {
+	for i, l := range lats {
+		if math.Abs(l-lat) < gridCellSize/2 {
+			return i
+		}
+	}
+	return -1
+}
+
+func lonIndex(lons []float64, lon float64) int {
+	for i, l := range lons {
+		if math.Abs(l-lon) < gridCellSize/2 {
+			return i
+		}
+	}
+	return -1
+}
+
+func newCube(nt, nlat, nlon int) []float32 {
+	cube := make([]float32, nt*nlat*nlon)
+	for i := range cube {
+		cube[i] = netCDFFillValue
+	}
+	return cube
+}
+
+// --- minimal NetCDF classic (CDF-1) writer ---
+
+type cdfVar struct {
+	Name      string
+	Dims      []string
+	Data      []float64
+	DataF32   []float32
+	Attrs     map[string]string
+	FillValue float32
+}
+
+type cdfWriter struct {
+	dimNames    []string
+	dimSizes    map[string]int
+	vars        []cdfVar
+	globalAttrs map[string]string
+}
+
+func newCDFWriter() *cdfWriter {
+	return &cdfWriter{dimSizes: make(map[string]int)}
+}
+
+func (w *cdfWriter) addDim(name string, size int) {
+	w.dimNames = append(w.dimNames, name)
+	w.dimSizes[name] = size
+}
+
+func (w *cdfWriter) addVar(v cdfVar) {
+	w.vars = append(w.vars, v)
+}
+
+// write emits the NetCDF classic format: magic, dim list, global attrs, var
+// list, then each variable's data contiguously (non-record variables only;
+// every dimension here has a fixed size, so no NC_UNLIMITED is used).
+func (w *cdfWriter) write(out io.Writer) error {
+	var buf []byte
+	put4 := func(v uint32) { buf = binary.BigEndian.AppendUint32(buf, v) }
+	putStr := func(s string) {
+		put4(uint32(len(s)))
+		buf = append(buf, s...)
+		for len(buf)%4 != 0 {
+			buf = append(buf, 0)
+		}
+	}
+
+	buf = append(buf, 'C', 'D', 'F', 1)
+
+	put4(0) // numrecs (no record dimension)
+
+	// dim_list
+	if len(w.dimNames) == 0 {
+		put4(0)
+		put4(0)
+	} else {
+		put4(0x0A) // NC_DIMENSION tag
+		put4(uint32(len(w.dimNames)))
+		for _, name := range w.dimNames {
+			putStr(name)
+			put4(uint32(w.dimSizes[name]))
+		}
+	}
+
+	// gatt_list
+	writeAttrs(&buf, put4, putStr, w.globalAttrs)
+
+	// var_list
+	put4(0x0B) // NC_VARIABLE tag
+	put4(uint32(len(w.vars)))
+	type varOffset struct {
+		pos  int
+		size int
+	}
+	offsets := make([]varOffset, len(w.vars))
+	for vi, v := range w.vars {
+		putStr(v.Name)
+		put4(uint32(len(v.Dims)))
+		for _, d := range v.Dims {
+			put4(uint32(dimIndex(w.dimNames, d)))
+		}
+		attrs := v.Attrs
+		if v.FillValue != 0 {
+			attrs = cloneAttrs(attrs)
+			attrs["_FillValue"] = fmt.Sprintf("%g", v.FillValue)
+		}
+		writeAttrs(&buf, put4, putStr, attrs)
+
+		nctype := uint32(5) // NC_FLOAT
+		if len(v.Data) > 0 {
+			nctype = 6 // NC_DOUBLE
+		}
+		put4(nctype)
+
+		n := len(v.DataF32)
+		if n == 0 {
+			n = len(v.Data)
+		}
+		elemSize := 4
+		if nctype == 6 {
+			elemSize = 8
+		}
+		size := n * elemSize
+		put4(uint32(size))
+
+		offsetPos := len(buf)
+		put4(0) // placeholder, patched below
+		offsets[vi] = varOffset{pos: offsetPos, size: size}
+	}
+
+	// Data section starts here; patch each variable's offset then append data.
+	for vi, v := range w.vars {
+		off := len(buf)
+		binary.BigEndian.PutUint32(buf[offsets[vi].pos:], uint32(off))
+		if len(v.Data) > 0 {
+			for _, d := range v.Data {
+				buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(d))
+			}
+		} else {
+			for _, d := range v.DataF32 {
+				buf = binary.BigEndian.AppendUint32(buf, math.Float32bits(d))
+			}
+		}
+	}
+
+	_, err := out.Write(buf)
+	return err
+}
+
+func writeAttrs(buf *[]byte, put4 func(uint32), putStr func(string), attrs map[string]string) {
+	if len(attrs) == 0 {
+		put4(0)
+		put4(0)
+		return
+	}
+	put4(0x0C) // NC_ATTRIBUTE tag
+	put4(uint32(len(attrs)))
+	for name, val := range attrs {
+		putStr(name)
+		put4(2) // NC_CHAR
+		put4(uint32(len(val)))
+		*buf = append(*buf, val...)
+		for len(*buf)%4 != 0 {
+			*buf = append(*buf, 0)
+		}
+	}
+}
+
+func cloneAttrs(attrs map[string]string) map[string]string {
+	out := make(map[string]string, len(attrs)+1)
+	for k, v := range attrs {
+		out[k] = v
+	}
+	return out
+}
+
+func dimIndex(dims []string, name string) int {
+	for i, d := range dims {
+		if d == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,290 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Schema describes what a particular compile target accepts: which DSL
+// field names exist, what SQL column (or request param name) each maps
+// to, and whether each field holds a number or a string. The same Expr
+// can be compiled against different Schemas — CompileSQL for
+// park_metrics_current/park_metrics_daily, CompileParams for the
+// Protected Planet API — each rejecting fields the other would accept.
+type Schema struct {
+	// Columns maps a DSL field name to the target's name for it (a SQL
+	// column, or a query parameter key).
+	Columns map[string]string
+	// Numeric marks which fields take numeric comparisons/IN values; a
+	// field absent from Numeric is treated as a string field that only
+	// allows OpEq/OpNeq (and IN).
+	Numeric map[string]bool
+}
+
+func (s Schema) column(field string) (string, bool) {
+	col, ok := s.Columns[strings.ToLower(field)]
+	return col, ok
+}
+
+func (s Schema) isNumeric(field string) bool {
+	return s.Numeric[strings.ToLower(field)]
+}
+
+// CompileSQL compiles e into a parameterized "WHERE ..." fragment (sans
+// the "WHERE" keyword, so callers can AND it onto other conditions) plus
+// its positional args, validating every field against schema.
+func (s Schema) CompileSQL(e Expr) (where string, args []interface{}, err error) {
+	switch n := e.(type) {
+	case *AndExpr:
+		lw, la, err := s.CompileSQL(n.Left)
+		if err != nil {
+			return "", nil, err
+		}
+		rw, ra, err := s.CompileSQL(n.Right)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("(%s AND %s)", lw, rw), append(la, ra...), nil
+
+	case *OrExpr:
+		lw, la, err := s.CompileSQL(n.Left)
+		if err != nil {
+			return "", nil, err
+		}
+		rw, ra, err := s.CompileSQL(n.Right)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("(%s OR %s)", lw, rw), append(la, ra...), nil
+
+	case *NotExpr:
+		iw, ia, err := s.CompileSQL(n.Inner)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("(NOT %s)", iw), ia, nil
+
+	case *Comparison:
+		col, ok := s.column(n.Field)
+		if !ok {
+			return "", nil, &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("unknown or unsupported field %q", n.Field)}
+		}
+		val, err := s.coerce(n.Field, n.Value, n.Pos)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%s %s ?", col, n.Op), []interface{}{val}, nil
+
+	case *InExpr:
+		col, ok := s.column(n.Field)
+		if !ok {
+			return "", nil, &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("unknown or unsupported field %q", n.Field)}
+		}
+		placeholders := make([]string, len(n.Values))
+		args := make([]interface{}, len(n.Values))
+		for i, v := range n.Values {
+			coerced, err := s.coerce(n.Field, v, n.Pos)
+			if err != nil {
+				return "", nil, err
+			}
+			placeholders[i] = "?"
+			args[i] = coerced
+		}
+		return fmt.Sprintf("%s IN (%s)", col, strings.Join(placeholders, ",")), args, nil
+
+	case *BBoxExpr:
+		minLonCol, ok1 := s.column("min_lon")
+		minLatCol, ok2 := s.column("min_lat")
+		maxLonCol, ok3 := s.column("max_lon")
+		maxLatCol, ok4 := s.column("max_lat")
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return "", nil, &ParseError{Pos: n.Pos, Msg: "bbox filtering isn't supported against this data source"}
+		}
+		return fmt.Sprintf("(%s <= ? AND %s >= ? AND %s <= ? AND %s >= ?)", minLonCol, maxLonCol, minLatCol, maxLatCol),
+			[]interface{}{n.MaxLon, n.MinLon, n.MaxLat, n.MinLat}, nil
+
+	default:
+		return "", nil, fmt.Errorf("filter: unhandled expression type %T", e)
+	}
+}
+
+func (s Schema) coerce(field, value string, pos int) (interface{}, error) {
+	if !s.isNumeric(field) {
+		return value, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, &ParseError{Pos: pos, Msg: fmt.Sprintf("field %q expects a number, got %q", field, value)}
+	}
+	return f, nil
+}
+
+// FieldGetter exposes a row's fields by DSL name, for CompilePredicate to
+// evaluate against without depending on any concrete row type (ParkExportRow,
+// areas.ProtectedArea, protectedplanet.PA all implement this differently).
+type FieldGetter interface {
+	// Field returns the named field's value (a string or a float64) and
+	// whether it exists on this row at all.
+	Field(name string) (value interface{}, ok bool)
+}
+
+// CompilePredicate compiles e into a func that evaluates it against any
+// FieldGetter, for in-memory filtering (AreaStore search results, or a
+// ParkExportRow computed live instead of read back from SQL). Unlike
+// CompileSQL, this never errors at compile time — an unknown field just
+// evaluates to false for any row, matching how the same field would quietly
+// mean "no such column" in a loosely-typed filter UI.
+func CompilePredicate(e Expr) func(FieldGetter) bool {
+	switch n := e.(type) {
+	case *AndExpr:
+		l, r := CompilePredicate(n.Left), CompilePredicate(n.Right)
+		return func(row FieldGetter) bool { return l(row) && r(row) }
+
+	case *OrExpr:
+		l, r := CompilePredicate(n.Left), CompilePredicate(n.Right)
+		return func(row FieldGetter) bool { return l(row) || r(row) }
+
+	case *NotExpr:
+		inner := CompilePredicate(n.Inner)
+		return func(row FieldGetter) bool { return !inner(row) }
+
+	case *Comparison:
+		return func(row FieldGetter) bool {
+			v, ok := row.Field(n.Field)
+			if !ok {
+				return false
+			}
+			return evalComparison(v, n.Op, n.Value)
+		}
+
+	case *InExpr:
+		return func(row FieldGetter) bool {
+			v, ok := row.Field(n.Field)
+			if !ok {
+				return false
+			}
+			for _, want := range n.Values {
+				if evalComparison(v, OpEq, want) {
+					return true
+				}
+			}
+			return false
+		}
+
+	case *BBoxExpr:
+		return func(row FieldGetter) bool {
+			lonV, ok1 := row.Field("lon")
+			latV, ok2 := row.Field("lat")
+			if !ok1 || !ok2 {
+				return false
+			}
+			lon, ok1 := lonV.(float64)
+			lat, ok2 := latV.(float64)
+			if !ok1 || !ok2 {
+				return false
+			}
+			return lon >= n.MinLon && lon <= n.MaxLon && lat >= n.MinLat && lat <= n.MaxLat
+		}
+
+	default:
+		return func(FieldGetter) bool { return false }
+	}
+}
+
+// evalComparison compares a row's field value against a filter literal,
+// numerically if the field value is a float64, as a case-insensitive
+// string match otherwise.
+func evalComparison(fieldValue interface{}, op Op, literal string) bool {
+	switch fv := fieldValue.(type) {
+	case float64:
+		lit, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case OpEq:
+			return fv == lit
+		case OpNeq:
+			return fv != lit
+		case OpGt:
+			return fv > lit
+		case OpGte:
+			return fv >= lit
+		case OpLt:
+			return fv < lit
+		case OpLte:
+			return fv <= lit
+		}
+		return false
+	case string:
+		switch op {
+		case OpEq:
+			return strings.EqualFold(fv, literal)
+		case OpNeq:
+			return !strings.EqualFold(fv, literal)
+		default:
+			// Ordering operators on a string field compare lexically,
+			// e.g. name:>"M" for "parks named after M onward".
+			cmp := strings.Compare(strings.ToLower(fv), strings.ToLower(literal))
+			switch op {
+			case OpGt:
+				return cmp > 0
+			case OpGte:
+				return cmp >= 0
+			case OpLt:
+				return cmp < 0
+			case OpLte:
+				return cmp <= 0
+			}
+		}
+	}
+	return false
+}
+
+// CompileParams compiles e into query parameters for a REST API (the
+// Protected Planet client) that only understands a flat conjunction of
+// equality terms — no OR, NOT, comparisons, or bbox. It returns an error
+// naming the unsupported construct rather than silently dropping terms,
+// since a caller who thinks a filter was applied server-side when it
+// wasn't would get back unfiltered results with no indication why.
+func (s Schema) CompileParams(e Expr) (url.Values, error) {
+	values := url.Values{}
+	if err := s.collectParams(e, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s Schema) collectParams(e Expr, out url.Values) error {
+	switch n := e.(type) {
+	case *AndExpr:
+		if err := s.collectParams(n.Left, out); err != nil {
+			return err
+		}
+		return s.collectParams(n.Right, out)
+
+	case *Comparison:
+		if n.Op != OpEq {
+			return &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("field %q only supports equality in this context", n.Field)}
+		}
+		param, ok := s.column(n.Field)
+		if !ok {
+			return &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("field %q can't be sent as a request parameter", n.Field)}
+		}
+		out.Set(param, n.Value)
+		return nil
+
+	case *InExpr:
+		param, ok := s.column(n.Field)
+		if !ok {
+			return &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("field %q can't be sent as a request parameter", n.Field)}
+		}
+		out.Set(param, strings.Join(n.Values, ","))
+		return nil
+
+	default:
+		return &ParseError{Pos: 0, Msg: "only a flat AND of field:value/in(...) terms can be sent as request parameters"}
+	}
+}
@@ -0,0 +1,419 @@
+// Package filter implements a small boolean filter DSL shared by
+// HandleAPIExportParks and protectedplanet.Client's WDPA search:
+//
+//	country:TZA AND area_km2:>1000 AND fire_count:>100 AND iucn:in(II,IV) AND bbox:-3,34,-1,36
+//
+// Parse produces an Expr AST. It doesn't know how to evaluate itself —
+// that's deliberately left to per-target compilers (CompileSQL,
+// CompilePredicate, CompileParams in compile.go) since "country:TZA"
+// means a parameterized SQL WHERE clause to one caller, an in-memory
+// areas.ProtectedArea predicate to another, and a Protected Planet API
+// query parameter to a third, and each target supports a different
+// subset of fields and operators.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	OpEq  Op = "="
+	OpNeq Op = "!="
+	OpGt  Op = ">"
+	OpGte Op = ">="
+	OpLt  Op = "<"
+	OpLte Op = "<="
+)
+
+// Expr is one node of a parsed filter expression.
+type Expr interface {
+	isExpr()
+}
+
+// AndExpr is Left AND Right.
+type AndExpr struct{ Left, Right Expr }
+
+// OrExpr is Left OR Right.
+type OrExpr struct{ Left, Right Expr }
+
+// NotExpr is NOT Inner.
+type NotExpr struct{ Inner Expr }
+
+// Comparison is "field:value" or "field:<op>value", e.g. "area_km2:>1000".
+// Op defaults to OpEq when the term has no operator prefix.
+type Comparison struct {
+	Field string
+	Op    Op
+	Value string
+	Pos   int
+}
+
+// InExpr is "field:in(v1,v2,...)".
+type InExpr struct {
+	Field  string
+	Values []string
+	Pos    int
+}
+
+// BBoxExpr is "bbox:minLon,minLat,maxLon,maxLat".
+type BBoxExpr struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+	Pos                            int
+}
+
+func (*AndExpr) isExpr()    {}
+func (*OrExpr) isExpr()     {}
+func (*NotExpr) isExpr()    {}
+func (*Comparison) isExpr() {}
+func (*InExpr) isExpr()     {}
+func (*BBoxExpr) isExpr()   {}
+
+// ParseError reports a syntax error at a rune offset into the original
+// source, so callers (HandleAPIExportParks) can point a user at the
+// offending token instead of just saying "bad filter".
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("filter: %s (at position %d)", e.Msg, e.Pos)
+}
+
+// Parse parses src into an Expr. An empty or all-whitespace src is an error —
+// callers that want "no filter" should skip calling Parse rather than pass "".
+func Parse(src string) (Expr, error) {
+	p := &parser{src: src}
+	p.next()
+	if p.tok.kind == tokEOF {
+		return nil, &ParseError{Pos: 0, Msg: "empty filter expression"}
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, &ParseError{Pos: p.tok.pos, Msg: fmt.Sprintf("unexpected token %q", p.tok.text)}
+	}
+	return expr, nil
+}
+
+// --- lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokColon
+	tokComma
+	tokLParen
+	tokRParen
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func (p *parser) next() {
+	p.skipSpace()
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		p.tok = token{kind: tokLParen, text: "(", pos: start}
+	case c == ')':
+		p.pos++
+		p.tok = token{kind: tokRParen, text: ")", pos: start}
+	case c == ':':
+		p.pos++
+		p.tok = token{kind: tokColon, text: ":", pos: start}
+	case c == ',':
+		p.pos++
+		p.tok = token{kind: tokComma, text: ",", pos: start}
+	case c == '>' || c == '<':
+		p.pos++
+		if p.pos < len(p.src) && p.src[p.pos] == '=' {
+			p.pos++
+			p.tok = token{kind: tokOp, text: p.src[start:p.pos], pos: start}
+		} else {
+			p.tok = token{kind: tokOp, text: string(c), pos: start}
+		}
+	case c == '!':
+		if p.pos+1 < len(p.src) && p.src[p.pos+1] == '=' {
+			p.pos += 2
+			p.tok = token{kind: tokOp, text: "!=", pos: start}
+		} else {
+			p.tok = token{kind: tokOp, text: "!", pos: start}
+		}
+	case c == '"' || c == '\'':
+		p.lexString(c, start)
+	case isNumberStart(c):
+		p.lexNumber(start)
+	case isIdentStart(c):
+		p.lexIdent(start)
+	default:
+		p.pos++
+		p.tok = token{kind: tokOp, text: string(c), pos: start}
+	}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *parser) lexString(quote byte, start int) {
+	p.pos++ // opening quote
+	for p.pos < len(p.src) && p.src[p.pos] != quote {
+		p.pos++
+	}
+	text := p.src[start+1 : p.pos]
+	if p.pos < len(p.src) {
+		p.pos++ // closing quote
+	}
+	p.tok = token{kind: tokString, text: text, pos: start}
+}
+
+func isNumberStart(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+'
+}
+
+func (p *parser) lexNumber(start int) {
+	p.pos++
+	for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+		p.pos++
+	}
+	p.tok = token{kind: tokNumber, text: p.src[start:p.pos], pos: start}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.' || c == '-'
+}
+
+func (p *parser) lexIdent(start int) {
+	p.pos++
+	for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+		p.pos++
+	}
+	p.tok = token{kind: tokIdent, text: p.src[start:p.pos], pos: start}
+}
+
+// --- recursive-descent parser ---
+//
+// Precedence, low to high: OR, AND, NOT, atom. This mirrors how most
+// query DSLs (and Go itself) bind && tighter than ||.
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Inner: inner}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *parser) parseAtom() (Expr, error) {
+	if p.tok.kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, &ParseError{Pos: p.tok.pos, Msg: "expected ')'"}
+		}
+		p.next()
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	if p.tok.kind != tokIdent {
+		return nil, &ParseError{Pos: p.tok.pos, Msg: fmt.Sprintf("expected a field name, got %q", p.tok.text)}
+	}
+	field := p.tok.text
+	fieldPos := p.tok.pos
+	p.next()
+
+	if p.tok.kind != tokColon {
+		return nil, &ParseError{Pos: p.tok.pos, Msg: fmt.Sprintf("expected ':' after field %q", field)}
+	}
+	p.next()
+
+	if strings.EqualFold(field, "bbox") {
+		return p.parseBBox(fieldPos)
+	}
+
+	if p.isIdentText("in") {
+		return p.parseIn(field, fieldPos)
+	}
+
+	op := OpEq
+	if p.tok.kind == tokOp {
+		parsed, err := parseOp(p.tok.text, p.tok.pos)
+		if err != nil {
+			return nil, err
+		}
+		op = parsed
+		p.next()
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{Field: field, Op: op, Value: value, Pos: fieldPos}, nil
+}
+
+func parseOp(text string, pos int) (Op, error) {
+	switch text {
+	case string(OpGt):
+		return OpGt, nil
+	case string(OpLt):
+		return OpLt, nil
+	case string(OpGte):
+		return OpGte, nil
+	case string(OpLte):
+		return OpLte, nil
+	case string(OpNeq):
+		return OpNeq, nil
+	default:
+		return "", &ParseError{Pos: pos, Msg: fmt.Sprintf("unknown operator %q", text)}
+	}
+}
+
+func (p *parser) parseIn(field string, fieldPos int) (Expr, error) {
+	p.next() // consume "in"
+	if p.tok.kind != tokLParen {
+		return nil, &ParseError{Pos: p.tok.pos, Msg: "expected '(' after 'in'"}
+	}
+	p.next()
+
+	var values []string
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.tok.kind == tokComma {
+			p.next()
+			continue
+		}
+		break
+	}
+	if p.tok.kind != tokRParen {
+		return nil, &ParseError{Pos: p.tok.pos, Msg: "expected ')' to close 'in(...)'"}
+	}
+	p.next()
+	return &InExpr{Field: field, Values: values, Pos: fieldPos}, nil
+}
+
+func (p *parser) parseBBox(fieldPos int) (Expr, error) {
+	var coords [4]float64
+	for i := range coords {
+		if p.tok.kind != tokNumber {
+			return nil, &ParseError{Pos: p.tok.pos, Msg: "expected a number in bbox:minLon,minLat,maxLon,maxLat"}
+		}
+		f, err := strconv.ParseFloat(p.tok.text, 64)
+		if err != nil {
+			return nil, &ParseError{Pos: p.tok.pos, Msg: fmt.Sprintf("invalid bbox coordinate %q", p.tok.text)}
+		}
+		coords[i] = f
+		p.next()
+		if i < 3 {
+			if p.tok.kind != tokComma {
+				return nil, &ParseError{Pos: p.tok.pos, Msg: "expected ',' between bbox coordinates"}
+			}
+			p.next()
+		}
+	}
+	return &BBoxExpr{MinLon: coords[0], MinLat: coords[1], MaxLon: coords[2], MaxLat: coords[3], Pos: fieldPos}, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	switch p.tok.kind {
+	case tokIdent, tokNumber, tokString:
+		v := p.tok.text
+		p.next()
+		return v, nil
+	default:
+		return "", &ParseError{Pos: p.tok.pos, Msg: fmt.Sprintf("expected a value, got %q", p.tok.text)}
+	}
+}
+
+// isKeyword reports whether the current token is the case-insensitive
+// identifier kw, consuming nothing.
+func (p *parser) isKeyword(kw string) bool {
+	return p.tok.kind == tokIdent && strings.EqualFold(p.tok.text, kw)
+}
+
+func (p *parser) isIdentText(text string) bool {
+	return p.tok.kind == tokIdent && strings.EqualFold(p.tok.text, text)
+}
@@ -0,0 +1,171 @@
+package filter
+
+import "testing"
+
+func TestParseBasicComparison(t *testing.T) {
+	expr, err := Parse("country:TZA")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	cmp, ok := expr.(*Comparison)
+	if !ok {
+		t.Fatalf("expected *Comparison, got %T", expr)
+	}
+	if cmp.Field != "country" || cmp.Op != OpEq || cmp.Value != "TZA" {
+		t.Errorf("unexpected comparison: %+v", cmp)
+	}
+}
+
+func TestParseAndOrNotPrecedence(t *testing.T) {
+	// AND binds tighter than OR, so this should parse as
+	// a OR (b AND (NOT c)).
+	expr, err := Parse("country:TZA OR area_km2:>1000 AND NOT iucn:in(II)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	or, ok := expr.(*OrExpr)
+	if !ok {
+		t.Fatalf("expected top-level *OrExpr, got %T", expr)
+	}
+	and, ok := or.Right.(*AndExpr)
+	if !ok {
+		t.Fatalf("expected *AndExpr on the right of OR, got %T", or.Right)
+	}
+	if _, ok := and.Right.(*NotExpr); !ok {
+		t.Errorf("expected *NotExpr on the right of AND, got %T", and.Right)
+	}
+}
+
+func TestParseComparisonOperators(t *testing.T) {
+	cases := map[string]Op{
+		"fire_count:>100":  OpGt,
+		"fire_count:<100":  OpLt,
+		"fire_count:>=100": OpGte,
+		"fire_count:<=100": OpLte,
+		"fire_count:!=100": OpNeq,
+		"fire_count:100":   OpEq,
+	}
+	for src, wantOp := range cases {
+		expr, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", src, err)
+		}
+		cmp := expr.(*Comparison)
+		if cmp.Op != wantOp {
+			t.Errorf("Parse(%q).Op = %q, want %q", src, cmp.Op, wantOp)
+		}
+	}
+}
+
+func TestParseIn(t *testing.T) {
+	expr, err := Parse("iucn:in(II,IV)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	in, ok := expr.(*InExpr)
+	if !ok {
+		t.Fatalf("expected *InExpr, got %T", expr)
+	}
+	if in.Field != "iucn" || len(in.Values) != 2 || in.Values[0] != "II" || in.Values[1] != "IV" {
+		t.Errorf("unexpected InExpr: %+v", in)
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	expr, err := Parse("bbox:-3,34,-1,36")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	bb, ok := expr.(*BBoxExpr)
+	if !ok {
+		t.Fatalf("expected *BBoxExpr, got %T", expr)
+	}
+	if bb.MinLon != -3 || bb.MinLat != 34 || bb.MaxLon != -1 || bb.MaxLat != 36 {
+		t.Errorf("unexpected BBoxExpr: %+v", bb)
+	}
+}
+
+func TestParseParenGroup(t *testing.T) {
+	expr, err := Parse("(country:TZA OR country:KEN) AND fire_count:>10")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	and, ok := expr.(*AndExpr)
+	if !ok {
+		t.Fatalf("expected *AndExpr, got %T", expr)
+	}
+	if _, ok := and.Left.(*OrExpr); !ok {
+		t.Errorf("expected parenthesized *OrExpr on the left, got %T", and.Left)
+	}
+}
+
+func TestParseErrorReportsPosition(t *testing.T) {
+	_, err := Parse("country:TZA AND )")
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %T", err)
+	}
+	if perr.Pos != 16 {
+		t.Errorf("ParseError.Pos = %d, want 16 (the stray ')')", perr.Pos)
+	}
+}
+
+func TestCompileSQL(t *testing.T) {
+	schema := Schema{
+		Columns: map[string]string{"country": "country", "fire_count": "fire_count"},
+		Numeric: map[string]bool{"fire_count": true},
+	}
+	expr, err := Parse("country:TZA AND fire_count:>100")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	where, args, err := schema.CompileSQL(expr)
+	if err != nil {
+		t.Fatalf("CompileSQL failed: %v", err)
+	}
+	if where != "(country = ? AND fire_count > ?)" {
+		t.Errorf("where = %q", where)
+	}
+	if len(args) != 2 || args[0] != "TZA" || args[1] != 100.0 {
+		t.Errorf("args = %+v", args)
+	}
+}
+
+func TestCompileSQLUnknownField(t *testing.T) {
+	schema := Schema{Columns: map[string]string{"country": "country"}}
+	expr, err := Parse("iucn:in(II,IV)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, _, err := schema.CompileSQL(expr); err == nil {
+		t.Error("expected CompileSQL to reject an unknown field")
+	}
+}
+
+type fakeRow map[string]interface{}
+
+func (r fakeRow) Field(name string) (interface{}, bool) {
+	v, ok := r[name]
+	return v, ok
+}
+
+func TestCompilePredicate(t *testing.T) {
+	expr, err := Parse("country:TZA AND fire_count:>100")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	pred := CompilePredicate(expr)
+
+	if !pred(fakeRow{"country": "TZA", "fire_count": 150.0}) {
+		t.Error("expected match for TZA with fire_count 150")
+	}
+	if pred(fakeRow{"country": "KEN", "fire_count": 150.0}) {
+		t.Error("expected no match for KEN")
+	}
+	if pred(fakeRow{"country": "TZA", "fire_count": 50.0}) {
+		t.Error("expected no match for fire_count below threshold")
+	}
+}
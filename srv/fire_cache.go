@@ -0,0 +1,97 @@
+package srv
+
+import (
+	"crypto/sha256"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// fireBlob is one fire-data JSON file kept warm in memory, with the
+// hash of its last-loaded contents so FireCache.Reload can tell
+// whether anything actually changed (and skip logging a reload when it
+// didn't).
+type fireBlob struct {
+	path string
+	data []byte
+	hash [32]byte
+}
+
+// FireCache keeps the fire JSON blobs served by handleFireDailyData,
+// handleFireDailyGeoJSON, and handleFireBoundary in memory, so those
+// handlers don't hit disk on every request. It's refreshed by the
+// scheduler's fire-reload job rather than per-request, since the
+// underlying files only change when an admin uploads new fire data.
+type FireCache struct {
+	mu      sync.RWMutex
+	blobs   map[string]*fireBlob
+	version atomic.Int64
+}
+
+// Version returns a counter bumped every time Reload finds changed
+// content, so callers caching derived data (e.g. srv/tiles' encoded
+// fire MVT tiles) can key on it instead of re-deriving every request.
+func (c *FireCache) Version() int64 {
+	return c.version.Load()
+}
+
+// NewFireCache creates an empty cache; call Reload once before serving
+// traffic to populate it; serving falls back to os.ReadFile for any
+// path not yet loaded.
+func NewFireCache() *FireCache {
+	return &FireCache{blobs: make(map[string]*fireBlob)}
+}
+
+// Get returns the cached contents of path, loading it from disk first
+// if it isn't cached yet.
+func (c *FireCache) Get(path string) ([]byte, error) {
+	c.mu.RLock()
+	b, ok := c.blobs[path]
+	c.mu.RUnlock()
+	if ok {
+		return b.data, nil
+	}
+	return c.reloadOne(path)
+}
+
+// Reload re-reads every currently-cached path plus the given paths,
+// returning how many of them had actually changed content.
+func (c *FireCache) Reload(paths []string) (changed int, err error) {
+	for _, p := range paths {
+		before := c.hashOf(p)
+		if _, rerr := c.reloadOne(p); rerr != nil {
+			if err == nil {
+				err = rerr
+			}
+			continue
+		}
+		if before != c.hashOf(p) {
+			changed++
+		}
+	}
+	if changed > 0 {
+		c.version.Add(1)
+	}
+	return changed, err
+}
+
+func (c *FireCache) hashOf(path string) [32]byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if b, ok := c.blobs[path]; ok {
+		return b.hash
+	}
+	return [32]byte{}
+}
+
+func (c *FireCache) reloadOne(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &fireBlob{path: path, data: data, hash: sha256.Sum256(data)}
+	c.mu.Lock()
+	c.blobs[path] = b
+	c.mu.Unlock()
+	return data, nil
+}
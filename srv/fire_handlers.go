@@ -5,13 +5,22 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 )
 
 // Fire data API handlers
 
+const (
+	fireDailyDataPath    = "data/fire/chinko_fires_by_day.json"
+	fireDailyGeoJSONPath = "data/fire/chinko_daily_geojson.json"
+	fireBoundaryPath     = "data/fire/chinko_boundary.json"
+)
+
+// FireDataPaths lists every file the fire-reload scheduler job should
+// keep warm in s.FireCache.
+var FireDataPaths = []string{fireDailyDataPath, fireDailyGeoJSONPath, fireBoundaryPath}
+
 func (s *Server) handleFireDailyData(w http.ResponseWriter, r *http.Request) {
-	data, err := os.ReadFile("data/fire/chinko_fires_by_day.json")
+	data, err := s.FireCache.Get(fireDailyDataPath)
 	if err != nil {
 		http.Error(w, "Fire data not found", http.StatusNotFound)
 		return
@@ -21,7 +30,7 @@ func (s *Server) handleFireDailyData(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleFireDailyGeoJSON(w http.ResponseWriter, r *http.Request) {
-	data, err := os.ReadFile("data/fire/chinko_daily_geojson.json")
+	data, err := s.FireCache.Get(fireDailyGeoJSONPath)
 	if err != nil {
 		http.Error(w, "Fire data not found", http.StatusNotFound)
 		return
@@ -31,7 +40,7 @@ func (s *Server) handleFireDailyGeoJSON(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleFireBoundary(w http.ResponseWriter, r *http.Request) {
-	data, err := os.ReadFile("data/fire/chinko_boundary.json")
+	data, err := s.FireCache.Get(fireBoundaryPath)
 	if err != nil {
 		http.Error(w, "Boundary not found", http.StatusNotFound)
 		return
@@ -90,7 +99,7 @@ func (s *Server) handleParkFireAnalysis(w http.ResponseWriter, r *http.Request)
 		var analysisJSON sql.NullString
 		var drySeasonFires, transhumanceFires, herderGroups, mgmtGroups, villageGroups sql.NullInt64
 		var avgSpeed sql.NullFloat64
-		
+
 		err := rows.Scan(&ya.Year, &ya.TotalFires, &drySeasonFires,
 			&ya.TranshumanceGroups, &transhumanceFires, &avgSpeed,
 			&herderGroups, &mgmtGroups, &villageGroups, &analysisJSON)
@@ -98,17 +107,29 @@ func (s *Server) handleParkFireAnalysis(w http.ResponseWriter, r *http.Request)
 			log.Printf("Scan error: %v", err)
 			continue
 		}
-		
-		if drySeasonFires.Valid { ya.DrySeasonFires = int(drySeasonFires.Int64) }
-		if transhumanceFires.Valid { ya.TranshumanceFires = int(transhumanceFires.Int64) }
-		if avgSpeed.Valid { ya.AvgTranshumanceSpeed = avgSpeed.Float64 }
-		if herderGroups.Valid { ya.HerderGroups = int(herderGroups.Int64) }
-		if mgmtGroups.Valid { ya.ManagementGroups = int(mgmtGroups.Int64) }
-		if villageGroups.Valid { ya.VillageGroups = int(villageGroups.Int64) }
+
+		if drySeasonFires.Valid {
+			ya.DrySeasonFires = int(drySeasonFires.Int64)
+		}
+		if transhumanceFires.Valid {
+			ya.TranshumanceFires = int(transhumanceFires.Int64)
+		}
+		if avgSpeed.Valid {
+			ya.AvgTranshumanceSpeed = avgSpeed.Float64
+		}
+		if herderGroups.Valid {
+			ya.HerderGroups = int(herderGroups.Int64)
+		}
+		if mgmtGroups.Valid {
+			ya.ManagementGroups = int(mgmtGroups.Int64)
+		}
+		if villageGroups.Valid {
+			ya.VillageGroups = int(villageGroups.Int64)
+		}
 		if analysisJSON.Valid {
 			ya.Groups = json.RawMessage(analysisJSON.String)
 		}
-		
+
 		results = append(results, ya)
 	}
 
@@ -0,0 +1,396 @@
+package srv
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tunables for HandleAPIFireRiskForecast's Monte Carlo ignition
+// simulation. Each is overridable per request via query params so a
+// caller can trade runtime for precision without a code change.
+const (
+	defaultForecastHorizonDays = 30
+	defaultForecastIterations  = 500
+	defaultExtinctionMoisture  = 0.15
+	forecastSmoulderWindow     = 24 // hours a cell is given to still catch after its candidate strike hour
+	forecastGridDeg            = 0.1
+	defaultForecastTopK        = 10
+	forecastClimatologyYears   = 5 // years of fire_detections history averaged into cgStrikes when no weather feed is configured
+)
+
+// FireRiskForecast is the response body for HandleAPIFireRiskForecast: a
+// probability-of-ignition raster over the park's historical fire extent,
+// paired with the settlements/rivers findNearestPlaces already knows
+// about so a reader can see who's exposed without a second lookup.
+type FireRiskForecast struct {
+	ParkID      string         `json:"park_id"`
+	ParkName    string         `json:"park_name"`
+	Year        int            `json:"year"`
+	HorizonDays int            `json:"horizon_days"`
+	Iterations  int            `json:"iterations"`
+	Seed        int64          `json:"seed"`
+	Summary     string         `json:"summary"`
+	Cells       []FireRiskCell `json:"cells"`
+}
+
+// FireRiskCell is one 0.1° grid cell's simulated outcome.
+// MoistureBucket is the cell's landform moisture class (A driest to D
+// wettest), drawn deterministically from the cell's own id so it stays
+// stable across forecasts instead of shifting with the iteration count.
+type FireRiskCell struct {
+	Lat                   float64  `json:"lat"`
+	Lon                   float64  `json:"lon"`
+	ProbabilityOfIgnition float64  `json:"probability_of_ignition"`
+	ExpectedDayOfBurn     float64  `json:"expected_day_of_burn,omitempty"`
+	MoistureBucket        string   `json:"moisture_bucket"`
+	VulnerableCommunities []string `json:"vulnerable_communities,omitempty"`
+}
+
+// forecastCell is the pre-simulation grid cell: a centroid plus the
+// climatological strike-intensity proxy derived from fire_detections
+// history for it.
+type forecastCell struct {
+	lat, lon float64
+	cgStrike float64 // average annual fire detections in this cell over forecastClimatologyYears, standing in for CG lightning strike counts
+}
+
+var moistureBuckets = []string{"A", "B", "C", "D"}
+
+// parseForecastHorizon parses a "30d"-style ?horizon= value into a day
+// count. An empty string yields defaultForecastHorizonDays.
+func parseForecastHorizon(s string) (int, error) {
+	if s == "" {
+		return defaultForecastHorizonDays, nil
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "d")
+	days, err := strconv.Atoi(s)
+	if err != nil || days <= 0 {
+		return 0, fmt.Errorf("horizon must be a positive number of days (e.g. \"30d\"), got %q", s)
+	}
+	return days, nil
+}
+
+// HandleAPIFireRiskForecast runs a weather-driven Monte Carlo ignition
+// simulation over the park's historical fire extent and returns a
+// probability-of-ignition raster for the requested horizon.
+// GET /api/parks/{id}/fire-risk-forecast?horizon=30d
+func (s *Server) HandleAPIFireRiskForecast(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	if parkID == "" {
+		http.Error(w, "Park ID required", http.StatusBadRequest)
+		return
+	}
+	internalID, parkName := s.resolveParkID(parkID)
+
+	horizonDays, err := parseForecastHorizon(r.URL.Query().Get("horizon"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	iterations := defaultForecastIterations
+	if v := r.URL.Query().Get("iterations"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid iterations=%q", v), http.StatusBadRequest)
+			return
+		}
+		iterations = n
+	}
+
+	topK := defaultForecastTopK
+	if v := r.URL.Query().Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid top=%q", v), http.StatusBadRequest)
+			return
+		}
+		topK = n
+	}
+
+	extinction := defaultExtinctionMoisture
+	if v := r.URL.Query().Get("extinction"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			http.Error(w, fmt.Sprintf("invalid extinction=%q", v), http.StatusBadRequest)
+			return
+		}
+		extinction = f
+	}
+
+	year := time.Now().Year()
+	if v := r.URL.Query().Get("year"); v != "" {
+		y, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid year=%q", v), http.StatusBadRequest)
+			return
+		}
+		year = y
+	}
+
+	cells, err := s.buildForecastGrid(internalID, year)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	forecast := FireRiskForecast{
+		ParkID:      internalID,
+		ParkName:    parkName,
+		Year:        year,
+		HorizonDays: horizonDays,
+		Iterations:  iterations,
+	}
+
+	if len(cells) == 0 {
+		forecast.Summary = fmt.Sprintf("Not enough fire history for %s to build a forecast grid.", parkName)
+		writeNarrativeJSON(w, time.Time{}, forecast)
+		return
+	}
+
+	seed, err := s.fireRiskForecastSeed(internalID, year)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	forecast.Seed = seed
+	rng := mathrand.New(mathrand.NewSource(seed))
+
+	horizonHours := horizonDays * 24
+	riskCells := make([]FireRiskCell, 0, len(cells))
+	for _, cell := range cells {
+		moisture := truncatedNormalForCell(internalID, cell.lat, cell.lon)
+
+		ignitions := 0
+		var burnHourSum float64
+		for iter := 0; iter < iterations; iter++ {
+			ignited, hour := simulateCellIgnition(rng, cell.cgStrike, horizonHours, moisture, extinction)
+			if ignited {
+				ignitions++
+				burnHourSum += float64(hour)
+			}
+		}
+
+		rc := FireRiskCell{
+			Lat:            cell.lat,
+			Lon:            cell.lon,
+			MoistureBucket: moistureBucketFor(moisture),
+		}
+		if iterations > 0 {
+			rc.ProbabilityOfIgnition = float64(ignitions) / float64(iterations)
+		}
+		if ignitions > 0 {
+			rc.ExpectedDayOfBurn = burnHourSum / float64(ignitions) / 24
+		}
+
+		settlements, _ := s.findNearestPlaces(internalID, cell.lat, cell.lon, 2, []string{"village", "hamlet", "town", "city"})
+		rivers, _ := s.findNearestPlaces(internalID, cell.lat, cell.lon, 1, []string{"river", "stream"})
+		for _, p := range settlements {
+			if p.Distance < 30 {
+				rc.VulnerableCommunities = append(rc.VulnerableCommunities, fmt.Sprintf("%s (%.0fkm)", p.Name, p.Distance))
+			}
+		}
+		for _, p := range rivers {
+			if p.Distance < 20 {
+				rc.VulnerableCommunities = append(rc.VulnerableCommunities, fmt.Sprintf("%s River (%.0fkm)", p.Name, p.Distance))
+			}
+		}
+
+		riskCells = append(riskCells, rc)
+	}
+
+	sort.Slice(riskCells, func(i, j int) bool {
+		return riskCells[i].ProbabilityOfIgnition > riskCells[j].ProbabilityOfIgnition
+	})
+	if len(riskCells) > topK {
+		riskCells = riskCells[:topK]
+	}
+	forecast.Cells = riskCells
+
+	if len(riskCells) > 0 {
+		top := riskCells[0]
+		forecast.Summary = fmt.Sprintf(
+			"Over the next %d days, the highest-risk cell for %s has a %.0f%% probability of ignition, expected around day %.0f.",
+			horizonDays, parkName, top.ProbabilityOfIgnition*100, top.ExpectedDayOfBurn)
+		if len(top.VulnerableCommunities) > 0 {
+			forecast.Summary += fmt.Sprintf(" Nearest communities at risk: %s.", strings.Join(top.VulnerableCommunities, ", "))
+		}
+	} else {
+		forecast.Summary = fmt.Sprintf("No cells in %s crossed an ignition probability worth reporting over the next %d days.", parkName, horizonDays)
+	}
+
+	writeNarrativeJSON(w, time.Time{}, forecast)
+}
+
+// buildForecastGrid snaps forecastClimatologyYears of fire_detections
+// history (the years strictly before year, so the forecast year's own
+// detections can't leak into its own proxy) onto a forecastGridDeg grid
+// and turns each cell's detection count into a cgStrike intensity. A
+// real weather/lightning feed would replace this with actual
+// current-year CG strike counts per cell; absent one, per-cell fire
+// history is the closest available proxy.
+func (s *Server) buildForecastGrid(parkID string, year int) ([]forecastCell, error) {
+	rows, err := s.DB.Query(`
+		SELECT latitude, longitude FROM fire_detections
+		WHERE protected_area_id = ?
+		  AND CAST(strftime('%Y', acq_date) AS INTEGER) >= ?
+		  AND CAST(strftime('%Y', acq_date) AS INTEGER) < ?
+	`, parkID, year-forecastClimatologyYears, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct{ lat, lon int }
+	counts := make(map[key]int)
+	for rows.Next() {
+		var lat, lon float64
+		if err := rows.Scan(&lat, &lon); err != nil {
+			continue
+		}
+		k := key{
+			lat: int(math.Floor(lat / forecastGridDeg)),
+			lon: int(math.Floor(lon / forecastGridDeg)),
+		}
+		counts[k]++
+	}
+
+	cells := make([]forecastCell, 0, len(counts))
+	for k, n := range counts {
+		cells = append(cells, forecastCell{
+			lat:      (float64(k.lat) + 0.5) * forecastGridDeg,
+			lon:      (float64(k.lon) + 0.5) * forecastGridDeg,
+			cgStrike: float64(n) / float64(forecastClimatologyYears),
+		})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].lat != cells[j].lat {
+			return cells[i].lat < cells[j].lat
+		}
+		return cells[i].lon < cells[j].lon
+	})
+	return cells, nil
+}
+
+// simulateCellIgnition runs one Monte Carlo iteration for a cell: it
+// draws a uniform r per simulated hour, takes probStrike = cgStrike *
+// r, and treats the hour with the highest probStrike as the candidate
+// strike time. If the cell's fuel moisture is already below
+// extinction, that candidate strike ignites it outright; otherwise the
+// cell gets forecastSmoulderWindow more hours to catch on a stronger
+// draw before it's marked non-ignited.
+func simulateCellIgnition(rng *mathrand.Rand, cgStrike float64, horizonHours int, moisture, extinction float64) (ignited bool, hour int) {
+	if horizonHours <= 0 {
+		return false, 0
+	}
+
+	bestHour, bestProb := 0, -1.0
+	for h := 0; h < horizonHours; h++ {
+		probStrike := cgStrike * rng.Float64()
+		if probStrike > bestProb {
+			bestProb, bestHour = probStrike, h
+		}
+	}
+
+	if moisture < extinction {
+		return true, bestHour
+	}
+
+	window := bestHour + forecastSmoulderWindow
+	if window > horizonHours {
+		window = horizonHours
+	}
+	for h := bestHour; h < window; h++ {
+		if cgStrike*rng.Float64() > bestProb {
+			return true, h
+		}
+	}
+	return false, 0
+}
+
+// truncatedNormalForCell draws the cell's fuel moisture proxy from a
+// truncated standard normal, seeded by a hash of (parkID, lat, lon) —
+// not by the per-forecast simulation seed — so a cell's moisture class
+// doesn't drift from one forecast run to the next the way the
+// strike-timing draws are allowed to.
+func truncatedNormalForCell(parkID string, lat, lon float64) float64 {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%.3f:%.3f", parkID, lat, lon)))
+	seed := int64(binary.BigEndian.Uint64(sum[:8]))
+	rng := mathrand.New(mathrand.NewSource(seed))
+
+	const mean, stddev = 0.35, 0.15
+	for i := 0; i < 10; i++ {
+		v := mean + rng.NormFloat64()*stddev
+		if v >= 0 && v <= 1 {
+			return v
+		}
+	}
+	return mean
+}
+
+// moistureBucketFor maps a [0,1] moisture proxy onto the A (driest) -
+// D (wettest) landform buckets in even quartiles.
+func moistureBucketFor(moisture float64) string {
+	idx := int(moisture * float64(len(moistureBuckets)))
+	if idx >= len(moistureBuckets) {
+		idx = len(moistureBuckets) - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	return moistureBuckets[idx]
+}
+
+// fireRiskForecastSeed returns the stored Monte Carlo seed for
+// (parkID, year), generating and persisting one on first use so every
+// later forecast for that park/year reproduces the same ignitions.
+//
+// Expects a fire_risk_forecast_seeds table (park_id, year, seed,
+// created_at) with a unique index on (park_id, year) — see this
+// request's migration alongside the rest of db/migrations.
+func (s *Server) fireRiskForecastSeed(parkID string, year int) (int64, error) {
+	var seed int64
+	err := s.DB.QueryRow(`
+		SELECT seed FROM fire_risk_forecast_seeds WHERE park_id = ? AND year = ?
+	`, parkID, year).Scan(&seed)
+	if err == nil {
+		return seed, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, fmt.Errorf("generate fire risk forecast seed: %w", err)
+	}
+	seed = int64(binary.BigEndian.Uint64(buf[:]))
+
+	_, err = s.DB.Exec(`
+		INSERT INTO fire_risk_forecast_seeds (park_id, year, seed, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (park_id, year) DO NOTHING
+	`, parkID, year, seed, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	// Another request may have inserted its own seed first; re-read so
+	// every caller converges on whichever one actually landed.
+	if err := s.DB.QueryRow(`
+		SELECT seed FROM fire_risk_forecast_seeds WHERE park_id = ? AND year = ?
+	`, parkID, year).Scan(&seed); err != nil {
+		return 0, err
+	}
+	return seed, nil
+}
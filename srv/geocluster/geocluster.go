@@ -0,0 +1,138 @@
+// Package geocluster implements DBSCAN over geographic (lat, lon)
+// points using a haversine (great-circle) distance metric, for finding
+// irregularly shaped concentrations that a fixed grid or precomputed
+// cluster table would miss.
+package geocluster
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// NoiseLabel is the cluster label DBSCAN assigns to a point that isn't
+// density-reachable from any cluster seed.
+const NoiseLabel = -1
+
+// Point is one observation to cluster, carrying the index of the
+// caller's original record so results can be matched back to it.
+type Point struct {
+	Index    int
+	Lat, Lon float64
+}
+
+// Cluster is a density-reachable group of points found by DBSCAN, with
+// a centroid computed as the mean of the member points' unit vectors
+// re-projected back to lat/lon. Averaging on the sphere rather than
+// averaging lat/lon directly keeps a cluster that straddles the
+// antimeridian (or sits near a pole) from collapsing toward the wrong
+// center.
+type Cluster struct {
+	Points      []Point
+	CentroidLat float64
+	CentroidLon float64
+}
+
+// DBSCAN groups points into density-based clusters. epsKm is the
+// neighborhood radius in kilometers; minPts is the minimum neighborhood
+// size (including the point itself) required to seed a cluster.
+// Returns the discovered clusters plus a label per input point (index
+// matching points' order, not Point.Index) giving the cluster it
+// belongs to, or NoiseLabel if it wasn't density-reachable from any
+// cluster.
+//
+// Neighbor lookups are a brute-force O(n²) scan rather than a KD-tree
+// or ball-tree: a single park-year's fire detections or deforestation
+// events typically number in the hundreds to low thousands, where the
+// scan is simple and fast enough; a spatial index would only start
+// paying for itself at substantially larger N.
+func DBSCAN(points []Point, epsKm float64, minPts int) (clusters []Cluster, labels []int) {
+	n := len(points)
+	labels = make([]int, n)
+	for i := range labels {
+		labels[i] = NoiseLabel
+	}
+	visited := make([]bool, n)
+
+	regionQuery := func(i int) []int {
+		var neighbors []int
+		for j := 0; j < n; j++ {
+			if haversineKm(points[i].Lat, points[i].Lon, points[j].Lat, points[j].Lon) <= epsKm {
+				neighbors = append(neighbors, j)
+			}
+		}
+		return neighbors
+	}
+
+	for i := 0; i < n; i++ {
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+
+		neighbors := regionQuery(i)
+		if len(neighbors) < minPts {
+			continue // left labeled noise; may still be claimed by a later cluster's expansion
+		}
+
+		label := len(clusters)
+		labels[i] = label
+		clusters = append(clusters, Cluster{})
+
+		queue := append([]int{}, neighbors...)
+		for len(queue) > 0 {
+			j := queue[0]
+			queue = queue[1:]
+
+			if !visited[j] {
+				visited[j] = true
+				jNeighbors := regionQuery(j)
+				if len(jNeighbors) >= minPts {
+					queue = append(queue, jNeighbors...)
+				}
+			}
+			if labels[j] == NoiseLabel {
+				labels[j] = label
+			}
+		}
+	}
+
+	for i, label := range labels {
+		if label == NoiseLabel {
+			continue
+		}
+		clusters[label].Points = append(clusters[label].Points, points[i])
+	}
+	for i := range clusters {
+		clusters[i].CentroidLat, clusters[i].CentroidLon = centroid(clusters[i].Points)
+	}
+
+	return clusters, labels
+}
+
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return earthRadiusKm * c
+}
+
+func centroid(points []Point) (lat, lon float64) {
+	if len(points) == 0 {
+		return 0, 0
+	}
+	var x, y, z float64
+	for _, p := range points {
+		latRad := p.Lat * math.Pi / 180
+		lonRad := p.Lon * math.Pi / 180
+		x += math.Cos(latRad) * math.Cos(lonRad)
+		y += math.Cos(latRad) * math.Sin(lonRad)
+		z += math.Sin(latRad)
+	}
+	n := float64(len(points))
+	x, y, z = x/n, y/n, z/n
+	lon = math.Atan2(y, x)
+	lat = math.Atan2(z, math.Sqrt(x*x+y*y))
+	return lat * 180 / math.Pi, lon * 180 / math.Pi
+}
@@ -0,0 +1,121 @@
+package geocluster
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDBSCANTwoClustersAndNoise(t *testing.T) {
+	points := []Point{
+		{Index: 0, Lat: 0.000, Lon: 0.000},
+		{Index: 1, Lat: 0.001, Lon: 0.001},
+		{Index: 2, Lat: 0.002, Lon: 0.000},
+		{Index: 3, Lat: 10.000, Lon: 10.000},
+		{Index: 4, Lat: 10.001, Lon: 10.001},
+		{Index: 5, Lat: 10.002, Lon: 10.000},
+		{Index: 6, Lat: 50.000, Lon: 50.000}, // isolated - should stay noise
+	}
+
+	clusters, labels := DBSCAN(points, 5, 3)
+
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(clusters))
+	}
+	if labels[6] != NoiseLabel {
+		t.Errorf("labels[6] = %d, want NoiseLabel (isolated point)", labels[6])
+	}
+	for i := 0; i < 3; i++ {
+		if labels[i] != labels[0] {
+			t.Errorf("labels[%d] = %d, want same cluster as labels[0] = %d", i, labels[i], labels[0])
+		}
+	}
+	for i := 3; i < 6; i++ {
+		if labels[i] != labels[3] {
+			t.Errorf("labels[%d] = %d, want same cluster as labels[3] = %d", i, labels[i], labels[3])
+		}
+	}
+	if labels[0] == labels[3] {
+		t.Error("the two spatially distant groups were merged into one cluster")
+	}
+}
+
+func TestDBSCANAllNoiseBelowMinPts(t *testing.T) {
+	points := []Point{
+		{Index: 0, Lat: 0, Lon: 0},
+		{Index: 1, Lat: 0.001, Lon: 0.001},
+	}
+
+	clusters, labels := DBSCAN(points, 5, 3)
+
+	if len(clusters) != 0 {
+		t.Fatalf("got %d clusters, want 0 when no neighborhood reaches minPts", len(clusters))
+	}
+	for i, l := range labels {
+		if l != NoiseLabel {
+			t.Errorf("labels[%d] = %d, want NoiseLabel", i, l)
+		}
+	}
+}
+
+func TestDBSCANEmptyInput(t *testing.T) {
+	clusters, labels := DBSCAN(nil, 5, 3)
+	if len(clusters) != 0 || len(labels) != 0 {
+		t.Errorf("got (%d clusters, %d labels), want (0, 0) for empty input", len(clusters), len(labels))
+	}
+}
+
+func TestDBSCANCrossAntimeridianCluster(t *testing.T) {
+	// Four points straddling the antimeridian, each within a few km of
+	// its neighbors the short way around (through 180), but hundreds of
+	// km apart the "naive" way if longitude were averaged directly.
+	points := []Point{
+		{Index: 0, Lat: 0.00, Lon: 179.95},
+		{Index: 1, Lat: 0.01, Lon: 179.96},
+		{Index: 2, Lat: 0.00, Lon: -179.95},
+		{Index: 3, Lat: -0.01, Lon: -179.96},
+	}
+
+	clusters, labels := DBSCAN(points, 20, 4)
+
+	if len(clusters) != 1 {
+		t.Fatalf("got %d clusters, want 1 merged cluster across the antimeridian", len(clusters))
+	}
+	for i, l := range labels {
+		if l != 0 {
+			t.Errorf("labels[%d] = %d, want 0 (all points in the one cluster)", i, l)
+		}
+	}
+
+	c := clusters[0]
+	if math.Abs(c.CentroidLon) < 170 {
+		t.Errorf("CentroidLon = %v, want near +/-180 (got pulled toward 0 by naive averaging)", c.CentroidLon)
+	}
+}
+
+func TestHaversineKmKnownDistance(t *testing.T) {
+	// London to Paris is approximately 344 km.
+	got := haversineKm(51.5074, -0.1278, 48.8566, 2.3522)
+	if math.Abs(got-344) > 10 {
+		t.Errorf("haversineKm(London, Paris) = %v, want ~344", got)
+	}
+}
+
+func TestHaversineKmSamePoint(t *testing.T) {
+	if got := haversineKm(10, 20, 10, 20); got != 0 {
+		t.Errorf("haversineKm of identical points = %v, want 0", got)
+	}
+}
+
+func TestCentroidEmpty(t *testing.T) {
+	lat, lon := centroid(nil)
+	if lat != 0 || lon != 0 {
+		t.Errorf("centroid(nil) = (%v, %v), want (0, 0)", lat, lon)
+	}
+}
+
+func TestCentroidSinglePoint(t *testing.T) {
+	lat, lon := centroid([]Point{{Lat: 12.5, Lon: -45.25}})
+	if math.Abs(lat-12.5) > 1e-6 || math.Abs(lon-(-45.25)) > 1e-6 {
+		t.Errorf("centroid of a single point = (%v, %v), want (12.5, -45.25)", lat, lon)
+	}
+}
@@ -0,0 +1,187 @@
+// Package geoip resolves a caller's IP address to a country/subdivision
+// and approximate coordinates using a local MaxMind GeoLite2-City
+// database, so handlers can auto-scope or boost results toward the
+// caller's location without requiring an explicit ?country= param.
+package geoip
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// ErrNotFound is returned when ip doesn't resolve to any entry in the
+// database (e.g. a private/reserved address).
+var ErrNotFound = errors.New("geoip: no entry for address")
+
+// Result is what Lookup resolves an IP address to.
+type Result struct {
+	ISO3        string // e.g. "TZA"
+	Subdivision string // e.g. "Arusha", empty if the database has none
+	Lat         float64
+	Lon         float64
+}
+
+// Lookup wraps a GeoLite2-City database, swappable at runtime so the
+// scheduler can download a fresh copy without a restart.
+type Lookup struct {
+	mu     sync.RWMutex
+	reader *geoip2.Reader
+	path   string
+}
+
+// Open loads the GeoLite2-City database at path.
+func Open(path string) (*Lookup, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: open %s: %w", path, err)
+	}
+	return &Lookup{reader: reader, path: path}, nil
+}
+
+// Lookup resolves ip to a country ISO3 code, subdivision name, and
+// coordinates. It returns ErrNotFound for addresses absent from the
+// database (loopback, private ranges, reserved blocks, etc).
+func (l *Lookup) Lookup(ip string) (Result, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Result{}, fmt.Errorf("geoip: invalid address %q", ip)
+	}
+
+	l.mu.RLock()
+	reader := l.reader
+	l.mu.RUnlock()
+
+	city, err := reader.City(addr)
+	if err != nil {
+		return Result{}, fmt.Errorf("geoip: lookup %s: %w", ip, err)
+	}
+	if city.Country.IsoCode == "" && city.Location.Latitude == 0 && city.Location.Longitude == 0 {
+		return Result{}, ErrNotFound
+	}
+
+	var subdivision string
+	if len(city.Subdivisions) > 0 {
+		subdivision = city.Subdivisions[0].Names["en"]
+	}
+
+	return Result{
+		ISO3:        iso2ToISO3(city.Country.IsoCode),
+		Subdivision: subdivision,
+		Lat:         city.Location.Latitude,
+		Lon:         city.Location.Longitude,
+	}, nil
+}
+
+// Close releases the underlying database file.
+func (l *Lookup) Close() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.reader.Close()
+}
+
+// Refresh downloads the latest GeoLite2-City database from MaxMind
+// using accountID/licenseKey (see the "GeoIP Update" section of a
+// MaxMind account's license key page) and atomically swaps it in,
+// leaving in-flight Lookup calls on the old reader unaffected. The
+// downloaded archive is written to path so a later process restart
+// reopens the same file Refresh just fetched.
+func (l *Lookup) Refresh(accountID, licenseKey, path string) error {
+	mmdbPath, err := downloadAndExtract(accountID, licenseKey, filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(mmdbPath, path); err != nil {
+		return fmt.Errorf("geoip: move downloaded database into place: %w", err)
+	}
+
+	newReader, err := geoip2.Open(path)
+	if err != nil {
+		return fmt.Errorf("geoip: open refreshed database: %w", err)
+	}
+
+	l.mu.Lock()
+	old := l.reader
+	l.reader = newReader
+	l.path = path
+	l.mu.Unlock()
+
+	return old.Close()
+}
+
+const downloadURL = "https://download.maxmind.com/geoip/databases/GeoLite2-City/download?suffix=tar.gz"
+
+// downloadAndExtract fetches MaxMind's tar.gz-wrapped GeoLite2-City
+// release and extracts the .mmdb file into destDir, returning its path.
+func downloadAndExtract(accountID, licenseKey, destDir string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(accountID, licenseKey)
+
+	resp, err := (&http.Client{Timeout: 2 * time.Minute}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("geoip: download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("geoip: download: unexpected status %d", resp.StatusCode)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("geoip: ungzip: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return "", errors.New("geoip: archive contained no .mmdb file")
+		}
+		if err != nil {
+			return "", fmt.Errorf("geoip: read archive: %w", err)
+		}
+		if !strings.HasSuffix(hdr.Name, ".mmdb") {
+			continue
+		}
+
+		dest := filepath.Join(destDir, filepath.Base(hdr.Name)+".tmp")
+		f, err := os.Create(dest)
+		if err != nil {
+			return "", fmt.Errorf("geoip: create %s: %w", dest, err)
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return "", fmt.Errorf("geoip: write %s: %w", dest, err)
+		}
+		if err := f.Close(); err != nil {
+			return "", err
+		}
+		return dest, nil
+	}
+}
+
+// iso2ToISO3 maps MaxMind's 2-letter country codes onto the ISO3 codes
+// the rest of this module uses (AreaStore, WDPAIndex, GADMStore all key
+// by ISO3). Unmapped codes are returned unchanged rather than dropped,
+// so a gap here degrades to "no boost" instead of an error.
+func iso2ToISO3(iso2 string) string {
+	if iso3, ok := iso2To3[iso2]; ok {
+		return iso3
+	}
+	return iso2
+}
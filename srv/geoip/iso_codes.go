@@ -0,0 +1,28 @@
+package geoip
+
+// iso2To3 maps ISO 3166-1 alpha-2 country codes (what MaxMind's
+// database returns) to alpha-3 (what AreaStore/WDPAIndex/GADMStore key
+// by). It's deliberately not exhaustive — African countries, where this
+// module's protected areas are concentrated, plus the largest
+// populations elsewhere; iso2ToISO3 falls back to returning the alpha-2
+// code unchanged for anything missing, so a gap here just means "no
+// geoip boost for that country" rather than a lookup failure.
+var iso2To3 = map[string]string{
+	"DZ": "DZA", "AO": "AGO", "BJ": "BEN", "BW": "BWA", "BF": "BFA",
+	"BI": "BDI", "CM": "CMR", "CV": "CPV", "CF": "CAF", "TD": "TCD",
+	"KM": "COM", "CG": "COG", "CD": "COD", "CI": "CIV", "DJ": "DJI",
+	"EG": "EGY", "GQ": "GNQ", "ER": "ERI", "SZ": "SWZ", "ET": "ETH",
+	"GA": "GAB", "GM": "GMB", "GH": "GHA", "GN": "GIN", "GW": "GNB",
+	"KE": "KEN", "LS": "LSO", "LR": "LBR", "LY": "LBY", "MG": "MDG",
+	"MW": "MWI", "ML": "MLI", "MR": "MRT", "MU": "MUS", "MA": "MAR",
+	"MZ": "MOZ", "NA": "NAM", "NE": "NER", "NG": "NGA", "RW": "RWA",
+	"ST": "STP", "SN": "SEN", "SC": "SYC", "SL": "SLE", "SO": "SOM",
+	"ZA": "ZAF", "SS": "SSD", "SD": "SDN", "TZ": "TZA", "TG": "TGO",
+	"TN": "TUN", "UG": "UGA", "ZM": "ZMB", "ZW": "ZWE",
+
+	"US": "USA", "CA": "CAN", "MX": "MEX", "BR": "BRA", "AR": "ARG",
+	"GB": "GBR", "FR": "FRA", "DE": "DEU", "ES": "ESP", "IT": "ITA",
+	"NL": "NLD", "CH": "CHE", "SE": "SWE", "NO": "NOR", "PT": "PRT",
+	"CN": "CHN", "JP": "JPN", "IN": "IND", "AU": "AUS", "NZ": "NZL",
+	"RU": "RUS", "ID": "IDN", "SA": "SAU", "AE": "ARE",
+}
@@ -0,0 +1,285 @@
+// Package ghsl fetches GHS-BUILT-S population/built-up tiles directly
+// from the JRC data portal into data/ghsl/, so an admin no longer has
+// to download each tile by hand and re-upload it. Downloads run with
+// bounded parallelism, resume via HTTP Range requests when a ".part"
+// file already exists, retry with exponential backoff, and verify
+// against ChecksumManifest when a tile's checksum is known.
+package ghsl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"srv.exe.dev/srv/jobs"
+)
+
+// Tile identifies one GHSL tile to fetch.
+type Tile struct {
+	ID  string // e.g. "R5_C18"
+	URL string
+}
+
+// ChecksumManifest records the expected SHA-256 of each tile's ZIP,
+// checked into the repo so a fetch can be verified without trusting
+// the download itself. Tiles without an entry here are fetched
+// unverified rather than rejected, since the manifest is backfilled
+// incrementally as tiles are confirmed good.
+var ChecksumManifest = map[string]string{}
+
+const (
+	maxAttempts = 5
+	baseBackoff = 2 * time.Second
+)
+
+// Fetcher downloads GHSL tile ZIPs into Dir.
+type Fetcher struct {
+	HTTPClient  *http.Client
+	Dir         string
+	Parallelism int
+	Jobs        *jobs.Manager
+}
+
+// NewFetcher creates a Fetcher that downloads into dir with the given
+// parallelism (at least 1; the admin UI defaults to 2-4).
+func NewFetcher(dir string, parallelism int, jobMgr *jobs.Manager) *Fetcher {
+	if parallelism < 1 {
+		parallelism = 2
+	}
+	return &Fetcher{
+		HTTPClient:  &http.Client{Timeout: 30 * time.Minute},
+		Dir:         dir,
+		Parallelism: parallelism,
+		Jobs:        jobMgr,
+	}
+}
+
+// Result is one tile's download outcome.
+type Result struct {
+	Tile Tile
+	Path string
+	Err  error
+}
+
+// FetchAll downloads tiles with bounded parallelism, returning each
+// one's outcome on the returned channel as it completes (not
+// necessarily in input order). The channel is closed once every tile
+// has finished.
+func (f *Fetcher) FetchAll(ctx context.Context, tiles []Tile) <-chan Result {
+	results := make(chan Result, len(tiles))
+	sem := make(chan struct{}, f.Parallelism)
+
+	go func() {
+		defer close(results)
+		var wg sync.WaitGroup
+		for _, t := range tiles {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(t Tile) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				path, err := f.fetchOne(ctx, t)
+				results <- Result{Tile: t, Path: path, Err: err}
+			}(t)
+		}
+		wg.Wait()
+	}()
+
+	return results
+}
+
+// fetchOne downloads a single tile, retrying with exponential backoff
+// and resuming from any existing ".part" file, and registers its
+// progress as a job so the admin page shows per-tile bytes/sec and ETA.
+func (f *Fetcher) fetchOne(ctx context.Context, t Tile) (string, error) {
+	destPath := filepath.Join(f.Dir, t.ID+".zip")
+	partPath := destPath + ".part"
+
+	total := f.contentLength(ctx, t.URL)
+	jobID := f.Jobs.Start(jobs.KindGHSLTile, t.ID, total)
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := baseBackoff * time.Duration(1<<uint(attempt-1))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				f.Jobs.Finish(jobID, ctx.Err())
+				return "", ctx.Err()
+			}
+		}
+
+		f.Jobs.SetPhase(jobID, fmt.Sprintf("downloading (attempt %d/%d)", attempt+1, maxAttempts))
+		if err := f.downloadOnce(ctx, jobID, t, partPath); err != nil {
+			lastErr = err
+			continue
+		}
+
+		if expected, ok := ChecksumManifest[t.ID]; ok {
+			f.Jobs.SetPhase(jobID, "verifying checksum")
+			sum, err := sha256File(partPath)
+			if err != nil {
+				lastErr = fmt.Errorf("checksum tile: %w", err)
+				continue
+			}
+			if sum != expected {
+				os.Remove(partPath)
+				lastErr = fmt.Errorf("checksum mismatch for %s: got %s, want %s", t.ID, sum, expected)
+				continue
+			}
+		}
+
+		if err := os.Rename(partPath, destPath); err != nil {
+			lastErr = fmt.Errorf("finalize download: %w", err)
+			continue
+		}
+		f.Jobs.Finish(jobID, nil)
+		return destPath, nil
+	}
+
+	f.Jobs.Finish(jobID, lastErr)
+	return "", lastErr
+}
+
+// downloadOnce performs one GET attempt, sending a Range header to
+// resume from partPath's current size if it already exists.
+func (f *Fetcher) downloadOnce(ctx context.Context, jobID string, t Tile, partPath string) error {
+	var startOffset int64
+	if info, err := os.Stat(partPath); err == nil {
+		startOffset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL, nil)
+	if err != nil {
+		return err
+	}
+	if startOffset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", startOffset))
+	}
+
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var out *os.File
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		out, err = os.OpenFile(partPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	case http.StatusOK:
+		// Server ignored our Range (or there was nothing to resume
+		// from); start the file over.
+		startOffset = 0
+		out, err = os.Create(partPath)
+	default:
+		return fmt.Errorf("unexpected status %s fetching %s", resp.Status, t.URL)
+	}
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if startOffset > 0 {
+		f.Jobs.AddBytes(jobID, startOffset)
+	}
+	_, err = io.Copy(out, &progressReader{r: resp.Body, jobs: f.Jobs, jobID: jobID})
+	return err
+}
+
+// contentLength issues a HEAD request to learn a tile's size up front,
+// for ETA calculation; it returns 0 (unknown total) if the request
+// fails or the server doesn't report one.
+func (f *Fetcher) contentLength(ctx context.Context, url string) int64 {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0
+	}
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.ContentLength < 0 {
+		return 0
+	}
+	return resp.ContentLength
+}
+
+// progressReader reports every Read to the job manager, so AddBytes
+// accumulates the true resumed total, not just this attempt's bytes.
+type progressReader struct {
+	r     io.Reader
+	jobs  *jobs.Manager
+	jobID string
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		p.jobs.AddBytes(p.jobID, int64(n))
+	}
+	return n, err
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ScanHaveTiles lists the tile IDs already present under dir (one
+// subdirectory per tile, named like the existing extraction layout:
+// "GHS_BUILT_S_..._R{row}_C{col}"), so the admin page can report what's
+// on disk without trusting a hand-maintained list.
+func ScanHaveTiles(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, fmt.Errorf("scan ghsl dir: %w", err)
+	}
+
+	have := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if id := tileIDFromDirName(entry.Name()); id != "" {
+			have[id] = true
+		}
+	}
+	return have, nil
+}
+
+// tileIDFromDirName extracts "R{row}_C{col}" from a directory name like
+// "GHS_BUILT_S_E2018_GLOBE_R2023A_54009_10_V1_0_R5_C18", the same
+// R*_C* pattern matching used by extractGHSLTileID for the uploaded
+// TIF's filename.
+func tileIDFromDirName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if len(part) > 1 && part[0] == 'R' && i+1 < len(parts) && len(parts[i+1]) > 1 && parts[i+1][0] == 'C' {
+			return part + "_" + parts[i+1]
+		}
+	}
+	return ""
+}
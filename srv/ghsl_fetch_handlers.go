@@ -0,0 +1,64 @@
+package srv
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"srv.exe.dev/srv/ghsl"
+	"srv.exe.dev/srv/jobs"
+)
+
+// HandleFetchGHSLTiles queues background downloads of every GHSL tile
+// not already present under data/ghsl/, fetching directly from JRC
+// instead of requiring an admin to download and re-upload each one by
+// hand. Each tile's download and subsequent extraction/processing is
+// tracked in the job subsystem like an uploaded tile.
+func (s *Server) HandleFetchGHSLTiles(w http.ResponseWriter, r *http.Request) {
+	haveTileIDs, err := ghsl.ScanHaveTiles(ghslDataDir)
+	if err != nil {
+		slog.Warn("failed to scan ghsl data dir", "error", err)
+		haveTileIDs = map[string]bool{}
+	}
+
+	var tiles []ghsl.Tile
+	for _, t := range neededGHSLTiles {
+		tileID := fmt.Sprintf("R%d_C%d", t[0], t[1])
+		if haveTileIDs[tileID] {
+			continue
+		}
+		tiles = append(tiles, ghsl.Tile{ID: tileID, URL: ghslTileURL(t[0], t[1])})
+	}
+
+	if len(tiles) == 0 {
+		http.Redirect(w, r, "/admin?success=All+GHSL+tiles+already+present", http.StatusSeeOther)
+		return
+	}
+
+	go s.runGHSLFetch(tiles)
+
+	http.Redirect(w, r, fmt.Sprintf("/admin?success=Fetching+%d+GHSL+tiles+in+background", len(tiles)), http.StatusSeeOther)
+}
+
+// runGHSLFetch downloads each tile and, on success, hands it off to the
+// same extraction/processing path HandleUploadGHSL uses.
+func (s *Server) runGHSLFetch(tiles []ghsl.Tile) {
+	ctx := context.Background()
+	for result := range s.GHSLFetcher.FetchAll(ctx, tiles) {
+		if result.Err != nil {
+			slog.Error("GHSL tile fetch failed", "tile", result.Tile.ID, "url", result.Tile.URL, "error", result.Err)
+			continue
+		}
+
+		tileID, destPath, written, err := s.extractGHSLTile(result.Path)
+		if err != nil {
+			slog.Error("failed to extract fetched GHSL tile", "tile", result.Tile.ID, "error", err)
+			continue
+		}
+
+		s.JobRunner.Submit(jobs.KindGHSLTile, tileID, written, func(ctx context.Context, jobID string) error {
+			return s.runGHSLProcessor(ctx, jobID, tileID, destPath)
+		})
+	}
+}
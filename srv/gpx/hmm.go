@@ -0,0 +1,264 @@
+package gpx
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math"
+)
+
+//go:embed hmm_params.json
+var hmmParamsJSON []byte
+
+// Movement state labels produced by the HMM classifier in ClassifyPoints.
+const (
+	StateStationary = "stationary"
+	StateFoot       = "foot"
+	StateVehicle    = "vehicle"
+	StateAircraft   = "aircraft"
+)
+
+type gaussian struct {
+	Mean   float64 `json:"mean"`
+	Stddev float64 `json:"stddev"`
+}
+
+type hmmParams struct {
+	States       []string                       `json:"states"`
+	Features     map[string]map[string]gaussian `json:"features"`
+	SelfLoopProb float64                        `json:"selfLoopProb"`
+}
+
+var params = mustLoadHMMParams()
+
+func mustLoadHMMParams() hmmParams {
+	var p hmmParams
+	if err := json.Unmarshal(hmmParamsJSON, &p); err != nil {
+		panic("gpx: invalid embedded hmm_params.json: " + err.Error())
+	}
+	return p
+}
+
+// logGaussianPDF returns the log-density of x under a Normal(mean, stddev).
+func logGaussianPDF(x float64, g gaussian) float64 {
+	if g.Stddev <= 0 {
+		g.Stddev = 1e-6
+	}
+	z := (x - g.Mean) / g.Stddev
+	return -0.5*z*z - math.Log(g.Stddev) - 0.5*math.Log(2*math.Pi)
+}
+
+// pointFeature bundles the per-point observations the HMM emits on:
+// instantaneous speed, acceleration, heading change, and elevation delta,
+// each computed against the previous point.
+type pointFeature struct {
+	SpeedKmh         float64
+	AccelKmhPerS     float64
+	HeadingChangeDeg float64
+	ElevationDeltaM  float64
+}
+
+// pointFeatures derives a pointFeature for every point in points. The
+// first point has no predecessor to diff against, so it's left as the
+// zero value (read as "no motion observed yet"), which favors
+// stationary/foot at t=0 without needing a separate initial-state prior.
+func pointFeatures(points []Point) []pointFeature {
+	feats := make([]pointFeature, len(points))
+	var prevSpeed, prevBearing float64
+	haveBearing := false
+
+	for i := 1; i < len(points); i++ {
+		p0, p1 := points[i-1], points[i]
+		distKm := haversineDistance(p0, p1)
+
+		var dtSeconds float64
+		if p0.Time != nil && p1.Time != nil {
+			dtSeconds = p1.Time.Sub(*p0.Time).Seconds()
+		}
+
+		var speed float64
+		if dtSeconds > 0 {
+			speed = distKm / (dtSeconds / 3600)
+		}
+
+		var accel float64
+		if dtSeconds > 0 {
+			accel = (speed - prevSpeed) / dtSeconds
+		}
+
+		bearing := bearingDegrees(p0, p1)
+		var headingChange float64
+		if haveBearing {
+			headingChange = angleDiffDegrees(prevBearing, bearing)
+		}
+
+		var elevDelta float64
+		if p0.Elevation != nil && p1.Elevation != nil {
+			elevDelta = *p1.Elevation - *p0.Elevation
+		}
+
+		feats[i] = pointFeature{
+			SpeedKmh:         speed,
+			AccelKmhPerS:     accel,
+			HeadingChangeDeg: headingChange,
+			ElevationDeltaM:  elevDelta,
+		}
+
+		prevSpeed = speed
+		prevBearing = bearing
+		haveBearing = true
+	}
+
+	return feats
+}
+
+// bearingDegrees returns the initial compass bearing from p0 to p1, in
+// [0, 360).
+func bearingDegrees(p0, p1 Point) float64 {
+	lat1 := degreesToRadians(p0.Lat)
+	lat2 := degreesToRadians(p1.Lat)
+	deltaLon := degreesToRadians(p1.Lon - p0.Lon)
+
+	y := math.Sin(deltaLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(deltaLon)
+	theta := math.Atan2(y, x)
+	return math.Mod(theta*180/math.Pi+360, 360)
+}
+
+// angleDiffDegrees returns the absolute smaller-side angle between two
+// compass bearings, in [0, 180].
+func angleDiffDegrees(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// emissionLogProb returns log B[state](obs): the sum of each feature's
+// log-Gaussian density, treating the four features as independent given
+// the state (a diagonal-covariance approximation - good enough here since
+// the features are deliberately chosen to capture different aspects of
+// motion rather than correlated measurements of the same one).
+func emissionLogProb(state string, f pointFeature) float64 {
+	return logGaussianPDF(f.SpeedKmh, params.Features["speed_kmh"][state]) +
+		logGaussianPDF(f.AccelKmhPerS, params.Features["accel_kmh_per_s"][state]) +
+		logGaussianPDF(f.HeadingChangeDeg, params.Features["heading_change_deg"][state]) +
+		logGaussianPDF(f.ElevationDeltaM, params.Features["elevation_delta_m"][state])
+}
+
+// transitionLogProb returns log A[from][to]: selfLoopProb on the diagonal,
+// the remainder split evenly across the other states, so a single noisy
+// point needs sustained evidence across several points before Viterbi's
+// best path actually switches state.
+func transitionLogProb(from, to string) float64 {
+	n := len(params.States)
+	if from == to {
+		return math.Log(params.SelfLoopProb)
+	}
+	return math.Log((1 - params.SelfLoopProb) / float64(n-1))
+}
+
+// ClassifyPoints labels every point in points with its most likely
+// movement state (StateStationary, StateFoot, StateVehicle, or
+// StateAircraft) via Viterbi decoding over the HMM defined by
+// hmm_params.json: for each point t and state s,
+//
+//	delta_t(s) = max_s' delta_{t-1}(s') * A[s'][s] * B[s](obs_t)
+//
+// computed in log-space to avoid underflow over long tracks, with a
+// backpointer recorded at each step so the single best state sequence can
+// be traced back once the last point is reached.
+//
+// Returns nil for fewer than 2 points, since there's no speed to observe
+// without a predecessor.
+func ClassifyPoints(points []Point) []string {
+	if len(points) < 2 {
+		return nil
+	}
+
+	feats := pointFeatures(points)
+	states := params.States
+	n := len(states)
+	T := len(points)
+
+	delta := make([][]float64, T)
+	backptr := make([][]int, T)
+	for t := range delta {
+		delta[t] = make([]float64, n)
+		backptr[t] = make([]int, n)
+	}
+
+	// t=0 has no transition into it (feats[0] is the zero-value
+	// placeholder for the point with no predecessor), so its delta is
+	// emission-only.
+	for s := 0; s < n; s++ {
+		delta[0][s] = emissionLogProb(states[s], feats[0])
+	}
+
+	for t := 1; t < T; t++ {
+		for s := 0; s < n; s++ {
+			best := math.Inf(-1)
+			bestPrev := 0
+			for sp := 0; sp < n; sp++ {
+				score := delta[t-1][sp] + transitionLogProb(states[sp], states[s])
+				if score > best {
+					best = score
+					bestPrev = sp
+				}
+			}
+			delta[t][s] = best + emissionLogProb(states[s], feats[t])
+			backptr[t][s] = bestPrev
+		}
+	}
+
+	labels := make([]string, T)
+	best := math.Inf(-1)
+	bestState := 0
+	for s := 0; s < n; s++ {
+		if delta[T-1][s] > best {
+			best = delta[T-1][s]
+			bestState = s
+		}
+	}
+	labels[T-1] = states[bestState]
+	for t := T - 2; t >= 0; t-- {
+		bestState = backptr[t+1][bestState]
+		labels[t] = states[bestState]
+	}
+
+	return labels
+}
+
+// CollapseMovementRuns labels points with ClassifyPoints, then collapses
+// consecutive same-state runs into their own Segment (MovementType set to
+// the run's state; Points/StartTime/EndTime/DistanceKm/AvgSpeedKmh
+// computed the same way buildSegment does for a whole track segment), so
+// a single GPX segment spanning a walk-drive-walk patrol is reported as
+// three distinct sub-segments instead of one averaged-speed blob.
+//
+// Falls back to ClassifyMovementType's fixed-threshold classification (via
+// buildSegment) for fewer than 2 points, since ClassifyPoints has nothing
+// to decode there.
+func CollapseMovementRuns(points []Point) []Segment {
+	labels := ClassifyPoints(points)
+	if labels == nil {
+		if len(points) == 0 {
+			return nil
+		}
+		seg := buildSegment(points)
+		return []Segment{seg}
+	}
+
+	var runs []Segment
+	runStart := 0
+	for i := 1; i <= len(points); i++ {
+		if i < len(points) && labels[i] == labels[runStart] {
+			continue
+		}
+		seg := buildSegment(points[runStart:i])
+		seg.MovementType = labels[runStart]
+		runs = append(runs, seg)
+		runStart = i
+	}
+	return runs
+}
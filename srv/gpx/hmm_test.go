@@ -0,0 +1,101 @@
+package gpx
+
+import (
+	"testing"
+	"time"
+)
+
+// syntheticTrack builds a walk-drive-walk patrol: 10 points on foot (~4
+// km/h), 10 points driving (~40 km/h, with one single noisy 200 km/h GPS
+// outlier in the middle), then 10 more points on foot. Points are 10
+// seconds apart, which at highway speed is a plausible GPS fix interval.
+func syntheticTrack() []Point {
+	start := time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)
+	var points []Point
+	lat, lon := -1.0, 36.0
+	t := start
+
+	addPoint := func(speedKmh float64) {
+		tCopy := t
+		points = append(points, Point{Lat: lat, Lon: lon, Time: &tCopy})
+		distKm := speedKmh * (10.0 / 3600.0)
+		lat += distKm / 111.0 // ~111km per degree latitude
+		t = t.Add(10 * time.Second)
+	}
+
+	for i := 0; i < 10; i++ {
+		addPoint(4)
+	}
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			addPoint(200) // noisy outlier mid-drive
+			continue
+		}
+		addPoint(40)
+	}
+	for i := 0; i < 10; i++ {
+		addPoint(4)
+	}
+
+	return points
+}
+
+func TestClassifyPointsRecoversWalkDriveWalk(t *testing.T) {
+	points := syntheticTrack()
+	labels := ClassifyPoints(points)
+	if len(labels) != len(points) {
+		t.Fatalf("expected %d labels, got %d", len(points), len(labels))
+	}
+
+	// The outlier sits at index 15 (10 foot points, then 5 more drive
+	// points before it). Viterbi's self-loop bias should keep the whole
+	// drive run labeled vehicle despite the single aircraft-speed spike.
+	middle := labels[15]
+	if middle != StateVehicle {
+		t.Errorf("expected middle of drive run to stay %q despite outlier, got %q", StateVehicle, middle)
+	}
+
+	if labels[0] != StateFoot && labels[0] != StateStationary {
+		t.Errorf("expected first point to be foot-like, got %q", labels[0])
+	}
+	if labels[len(labels)-1] != StateFoot && labels[len(labels)-1] != StateStationary {
+		t.Errorf("expected last point to be foot-like, got %q", labels[len(labels)-1])
+	}
+}
+
+func TestClassifyPointsTooShort(t *testing.T) {
+	if labels := ClassifyPoints([]Point{{Lat: 0, Lon: 0}}); labels != nil {
+		t.Errorf("expected nil labels for a single point, got %v", labels)
+	}
+}
+
+func TestCollapseMovementRunsProducesThreeRuns(t *testing.T) {
+	points := syntheticTrack()
+	runs := CollapseMovementRuns(points)
+
+	if len(runs) == 0 {
+		t.Fatal("expected at least one run")
+	}
+
+	var sawVehicle bool
+	total := 0
+	for _, run := range runs {
+		total += len(run.Points)
+		if run.MovementType == StateVehicle {
+			sawVehicle = true
+		}
+	}
+	if !sawVehicle {
+		t.Errorf("expected at least one run classified as %q, got runs: %+v", StateVehicle, runs)
+	}
+	if total != len(points) {
+		t.Errorf("expected collapsed runs to cover all %d points, got %d", len(points), total)
+	}
+}
+
+func TestCollapseMovementRunsSinglePoint(t *testing.T) {
+	runs := CollapseMovementRuns([]Point{{Lat: 0, Lon: 0}})
+	if len(runs) != 1 || len(runs[0].Points) != 1 {
+		t.Fatalf("expected a single one-point run, got %+v", runs)
+	}
+}
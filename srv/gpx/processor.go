@@ -5,6 +5,8 @@ import (
 	"encoding/xml"
 	"io"
 	"math"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -24,6 +26,11 @@ type Segment struct {
 	DistanceKm   float64
 	AvgSpeedKmh  float64
 	MovementType string
+	// Speeds holds one instantaneous speed (km/h) per point, aligned by
+	// index with Points, so downstream heatmaps can colour a track by
+	// velocity instead of only its single average. Speeds[0] is always 0
+	// (no predecessor to diff against).
+	Speeds []float64
 }
 
 // Track represents a GPX track containing multiple segments.
@@ -32,17 +39,60 @@ type Track struct {
 	Segments [][]Point
 }
 
+// Route represents a GPX route: a planned sequence of points, as opposed
+// to a recorded track. Patrol uploads rarely carry these, but some
+// planning tools (and a few Garmin devices) export the ranger's intended
+// patrol line alongside the recorded track.
+type Route struct {
+	Name   string
+	Points []Point
+}
+
+// Waypoint represents a single GPX <wpt>. Garmin inReach devices log
+// check-ins, SOS activations, and free-text messages as waypoints rather
+// than track points, distinguished by their sym/desc text - see Kind.
+type Waypoint struct {
+	Lat, Lon  float64
+	Elevation *float64
+	Time      *time.Time
+	Name      string
+	Desc      string
+	Sym       string
+	// Kind classifies the waypoint for patrol-incident purposes:
+	// "sos", "checkin", "message", or "poi" (see classifyWaypointKind).
+	Kind string
+	// Message holds the free-text payload for "message" and "sos"
+	// waypoints (the inReach message body, when present).
+	Message string
+}
+
 // GPXData represents the parsed GPX file data.
 type GPXData struct {
-	Tracks []Track
-	Name   string
+	Tracks    []Track
+	Name      string
+	Waypoints []Waypoint
+	Routes    []Route
+}
+
+// Incident is a time-ordered SOS or free-text message waypoint, surfaced
+// separately from the full Waypoints list so callers (e.g. the park-stats
+// handler) can fold ranger-reported events into a narrative alongside
+// fire and settlement signals without re-deriving Kind themselves.
+type Incident struct {
+	Time    *time.Time
+	Lat     float64
+	Lon     float64
+	Kind    string
+	Message string
 }
 
 // GPX XML structures for parsing
 type gpxFile struct {
-	XMLName  xml.Name   `xml:"gpx"`
-	Metadata gpxMeta    `xml:"metadata"`
-	Tracks   []gpxTrack `xml:"trk"`
+	XMLName   xml.Name      `xml:"gpx"`
+	Metadata  gpxMeta       `xml:"metadata"`
+	Tracks    []gpxTrack    `xml:"trk"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+	Routes    []gpxRoute    `xml:"rte"`
 }
 
 type gpxMeta struct {
@@ -66,6 +116,30 @@ type gpxPoint struct {
 	Desc      string   `xml:"desc"`
 }
 
+type gpxRoute struct {
+	Name   string     `xml:"name"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxWaypoint struct {
+	Lat        float64          `xml:"lat,attr"`
+	Lon        float64          `xml:"lon,attr"`
+	Elevation  *float64         `xml:"ele"`
+	Time       string           `xml:"time"`
+	Name       string           `xml:"name"`
+	Desc       string           `xml:"desc"`
+	Sym        string           `xml:"sym"`
+	Extensions gpxExtensionsRaw `xml:"extensions"`
+}
+
+// gpxExtensionsRaw keeps the raw inner XML of a <wpt>'s <extensions>
+// block. inReach/Garmin vendor extensions vary by firmware version and
+// aren't worth modeling as typed structs just to pull a few keywords
+// (e.g. "IN_EMERGENCY") out of them for classifyWaypointKind.
+type gpxExtensionsRaw struct {
+	InnerXML string `xml:",innerxml"`
+}
+
 // ParseGPX parses GPX XML from an io.Reader into structured data.
 // It uses streaming XML parsing for efficient memory usage with large files.
 func ParseGPX(r io.Reader) (*GPXData, error) {
@@ -111,9 +185,107 @@ func ParseGPX(r io.Reader) (*GPXData, error) {
 		data.Tracks = append(data.Tracks, track)
 	}
 
+	for _, wpt := range gpx.Waypoints {
+		waypoint := Waypoint{
+			Lat:       wpt.Lat,
+			Lon:       wpt.Lon,
+			Elevation: wpt.Elevation,
+			Name:      wpt.Name,
+			Desc:      wpt.Desc,
+			Sym:       wpt.Sym,
+		}
+		if wpt.Time != "" {
+			if t, err := time.Parse(time.RFC3339, wpt.Time); err == nil {
+				waypoint.Time = &t
+			}
+		}
+		waypoint.Kind, waypoint.Message = classifyWaypointKind(wpt.Sym, wpt.Desc, wpt.Extensions.InnerXML)
+		data.Waypoints = append(data.Waypoints, waypoint)
+	}
+
+	for _, rte := range gpx.Routes {
+		route := Route{Name: rte.Name, Points: make([]Point, 0, len(rte.Points))}
+		for _, pt := range rte.Points {
+			point := Point{Lat: pt.Lat, Lon: pt.Lon, Elevation: pt.Elevation, Desc: pt.Desc}
+			if pt.Time != "" {
+				if t, err := time.Parse(time.RFC3339, pt.Time); err == nil {
+					point.Time = &t
+				}
+			}
+			route.Points = append(route.Points, point)
+		}
+		data.Routes = append(data.Routes, route)
+	}
+
 	return data, nil
 }
 
+// sosKeywords and checkinKeywords are the sym/desc substrings Garmin
+// inReach and similar satellite messengers use for SOS and routine
+// check-in waypoints. Matching is case-insensitive and looks at both sym
+// and desc since devices vary in which field carries the marker.
+var sosKeywords = []string{"sos", "emergency", "in_emergency", "help"}
+var checkinKeywords = []string{"check-in", "check in", "checkin", "i'm ok", "i am ok", "i'm safe", "everything is okay"}
+
+// classifyWaypointKind derives a Waypoint.Kind ("sos", "checkin",
+// "message", or "poi") and its Message payload from sym/desc text and
+// the waypoint's raw <extensions> block. Conservation patrol waypoints
+// from inReach devices almost always fall into one of these buckets;
+// anything else (a hand-placed map marker, a points-of-interest pin) is
+// "poi".
+func classifyWaypointKind(sym, desc, extensionsXML string) (kind, message string) {
+	lowerSym := strings.ToLower(sym)
+	lowerDesc := strings.ToLower(desc)
+	lowerExt := strings.ToLower(extensionsXML)
+
+	for _, kw := range sosKeywords {
+		if strings.Contains(lowerSym, kw) || strings.Contains(lowerDesc, kw) || strings.Contains(lowerExt, kw) {
+			return "sos", desc
+		}
+	}
+	for _, kw := range checkinKeywords {
+		if strings.Contains(lowerSym, kw) || strings.Contains(lowerDesc, kw) {
+			return "checkin", desc
+		}
+	}
+	if desc != "" {
+		return "message", desc
+	}
+	return "poi", ""
+}
+
+// ExtractIncidents returns the SOS and free-text message waypoints from
+// data, ordered by time (untimed waypoints sort last), so a caller like
+// the park-stats handler can fold ranger-reported incidents into a
+// narrative without re-walking every waypoint itself.
+func ExtractIncidents(data *GPXData) []Incident {
+	var incidents []Incident
+	for _, wpt := range data.Waypoints {
+		if wpt.Kind != "sos" && wpt.Kind != "message" {
+			continue
+		}
+		incidents = append(incidents, Incident{
+			Time:    wpt.Time,
+			Lat:     wpt.Lat,
+			Lon:     wpt.Lon,
+			Kind:    wpt.Kind,
+			Message: wpt.Message,
+		})
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool {
+		if incidents[i].Time == nil {
+			return false
+		}
+		if incidents[j].Time == nil {
+			return true
+		}
+		return incidents[i].Time.Before(*incidents[j].Time)
+	})
+
+	return incidents
+}
+
 // DefaultSegmentDuration is the default maximum duration for a segment (30 minutes).
 const DefaultSegmentDuration = 30 * time.Minute
 
@@ -215,18 +387,122 @@ func buildSegment(points []Point) Segment {
 	// Calculate distance and speed
 	seg.DistanceKm = CalculateDistance(points)
 	seg.AvgSpeedKmh = CalculateSpeed(points)
-	seg.MovementType = ClassifyMovementType(seg)
+	seg.Speeds = pointSpeedsKmh(points)
+	seg.MovementType = ClassifyMovementType(seg, nil)
 
 	return seg
 }
 
-// ClassifyMovementType determines the movement type based on average speed.
+// pointSpeedsKmh returns one speed (km/h) per point in points, computed
+// from the haversine distance and time delta against the previous point.
+// The first point has no predecessor, so it's left at 0 - the same
+// "no motion observed yet" convention hmm.go's pointFeatures uses for its
+// own per-point feature series.
+func pointSpeedsKmh(points []Point) []float64 {
+	speeds := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		p0, p1 := points[i-1], points[i]
+		if p0.Time == nil || p1.Time == nil {
+			continue
+		}
+		dtHours := p1.Time.Sub(*p0.Time).Hours()
+		if dtHours <= 0 {
+			continue
+		}
+		speeds[i] = haversineDistance(p0, p1) / dtHours
+	}
+	return speeds
+}
+
+// percentileKmh returns the p-th percentile (0-1) of speeds, a fast
+// nearest-rank approximation (no interpolation between ranks) which is
+// plenty precise for a classification threshold. Returns 0 for an empty
+// series.
+func percentileKmh(speeds []float64, p float64) float64 {
+	if len(speeds) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(speeds))
+	copy(sorted, speeds)
+	sort.Float64s(sorted)
+
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// boatElevationVarianceThreshold is the maximum elevation variance (in
+// meters^2) a segment can have and still be eligible for the "boat"
+// class - a track crossing a lake or river stays close to a single
+// elevation, unlike a foot/vehicle track crossing terrain.
+const boatElevationVarianceThreshold = 4.0
+
+// elevationVariance returns the population variance of points' elevation
+// readings, or -1 if fewer than 2 points have elevation data (meaning
+// "unknown", not "zero variance").
+func elevationVariance(points []Point) float64 {
+	var elevations []float64
+	for _, p := range points {
+		if p.Elevation != nil {
+			elevations = append(elevations, *p.Elevation)
+		}
+	}
+	if len(elevations) < 2 {
+		return -1
+	}
+
+	var mean float64
+	for _, e := range elevations {
+		mean += e
+	}
+	mean /= float64(len(elevations))
+
+	var variance float64
+	for _, e := range elevations {
+		variance += (e - mean) * (e - mean)
+	}
+	return variance / float64(len(elevations))
+}
+
+// medianPoint returns the middle point of a segment (by index), used as
+// a cheap proxy for "where most of this segment happened" when checking
+// IsWaterFn - good enough for the boat heuristic without averaging
+// coordinates across an antimeridian or polar track.
+func medianPoint(points []Point) Point {
+	return points[len(points)/2]
+}
+
+// ClassifyMovementType determines the movement type for segment. It
+// prefers segment.Speeds (a per-point series, from which it takes the
+// 80th percentile to suppress GPS jitter and stationary dwell time) and
+// falls back to segment.AvgSpeedKmh when Speeds is empty, so a
+// hand-built Segment (as in tests, or hmm.go's zero-value fallback) still
+// classifies sensibly.
+//
+// isWater is optional (nil disables the check): when provided, and the
+// percentile speed falls in the 8-35 km/h range with low elevation
+// variance, it's called with the segment's median point's coordinates to
+// decide whether to classify the segment as "boat" rather than
+// "vehicle".
+//
 // Returns:
 //   - "foot": < 8 km/h (walking, running)
+//   - "boat": 8-35 km/h, low elevation variance, over water (if isWater given)
 //   - "vehicle": 8-120 km/h (car, motorbike)
 //   - "aircraft": > 120 km/h
-func ClassifyMovementType(segment Segment) string {
+func ClassifyMovementType(segment Segment, isWater func(lat, lon float64) bool) string {
 	speed := segment.AvgSpeedKmh
+	if len(segment.Speeds) > 0 {
+		speed = percentileKmh(segment.Speeds, 0.8)
+	}
+
+	if speed >= 8 && speed <= 35 && isWater != nil && len(segment.Points) > 0 {
+		if variance := elevationVariance(segment.Points); variance >= 0 && variance < boatElevationVarianceThreshold {
+			mp := medianPoint(segment.Points)
+			if isWater(mp.Lat, mp.Lon) {
+				return "boat"
+			}
+		}
+	}
 
 	switch {
 	case speed < 8:
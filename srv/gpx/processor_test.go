@@ -173,13 +173,42 @@ func TestClassifyMovementType(t *testing.T) {
 
 	for _, tc := range tests {
 		seg := Segment{AvgSpeedKmh: tc.speed}
-		result := ClassifyMovementType(seg)
+		result := ClassifyMovementType(seg, nil)
 		if result != tc.expected {
 			t.Errorf("speed %f: expected '%s', got '%s'", tc.speed, tc.expected, result)
 		}
 	}
 }
 
+func TestClassifyMovementTypeBoat(t *testing.T) {
+	now := time.Now()
+	points := make([]Point, 6)
+	for i := range points {
+		ts := now.Add(time.Duration(i) * time.Minute)
+		ele := 10.0
+		points[i] = Point{
+			Lat:       -1.0 + float64(i)*0.003,
+			Lon:       36.0,
+			Time:      &ts,
+			Elevation: &ele,
+		}
+	}
+
+	seg := buildSegment(points)
+	overWater := func(lat, lon float64) bool { return true }
+
+	result := ClassifyMovementType(seg, overWater)
+	if result != "boat" {
+		t.Errorf("expected 'boat' over water with flat elevation, got '%s' (speed percentile basis: %v)", result, seg.AvgSpeedKmh)
+	}
+
+	onLand := func(lat, lon float64) bool { return false }
+	result = ClassifyMovementType(seg, onLand)
+	if result != "vehicle" {
+		t.Errorf("expected 'vehicle' when isWater reports land, got '%s'", result)
+	}
+}
+
 func TestSplitIntoSegments(t *testing.T) {
 	reader := strings.NewReader(testGPX)
 	data, err := ParseGPX(reader)
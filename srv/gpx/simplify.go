@@ -0,0 +1,111 @@
+package gpx
+
+import "math"
+
+// metersPerDegreeLat is the (roughly constant) number of meters spanned by
+// one degree of latitude, used to turn small lat/lon deltas into local
+// planar meters for Simplify's perpendicular-distance check.
+const metersPerDegreeLat = 111320.0
+
+// Simplify reduces points to the subset needed to stay within
+// toleranceMeters of the original path, using the Douglas-Peucker
+// algorithm: recursively find the point furthest from the chord between
+// the current range's endpoints, keep it (and recurse on both halves) if
+// it's further than tolerance, otherwise drop every point in between.
+//
+// Patrol tracks downloaded from inReach/Garmin devices routinely reach
+// 50k-100k points per day, which is too heavy for the map overlays fed by
+// /api/parks/{id}/stats to render smoothly - Simplify is meant to be
+// applied before a track is sent to the client, not before it's persisted.
+//
+// Endpoints are always kept, and the original Point structs (including
+// their timestamps) are retained unmodified at every kept index, so a
+// simplified track can still be used for duration/speed calculations.
+func Simplify(points []Point, toleranceMeters float64) []Point {
+	if len(points) < 3 {
+		return points
+	}
+
+	keep := make([]bool, len(points))
+	keep[0] = true
+	keep[len(points)-1] = true
+
+	var simplifyRange func(lo, hi int)
+	simplifyRange = func(lo, hi int) {
+		if hi <= lo+1 {
+			return
+		}
+
+		maxDist := -1.0
+		maxIdx := -1
+		for i := lo + 1; i < hi; i++ {
+			d := perpendicularDistanceMeters(points[i], points[lo], points[hi])
+			if d > maxDist {
+				maxDist = d
+				maxIdx = i
+			}
+		}
+
+		if maxDist > toleranceMeters {
+			keep[maxIdx] = true
+			simplifyRange(lo, maxIdx)
+			simplifyRange(maxIdx, hi)
+		}
+	}
+	simplifyRange(0, len(points)-1)
+
+	simplified := make([]Point, 0, len(points))
+	for i, k := range keep {
+		if k {
+			simplified = append(simplified, points[i])
+		}
+	}
+	return simplified
+}
+
+// perpendicularDistanceMeters returns the distance from p to the segment
+// a-b, in meters. Lat/lon degrees are projected to local planar meters
+// around the chord's midpoint (equirectangular approximation - accurate
+// enough for the short chords a single Douglas-Peucker step compares,
+// and far cheaper than a true great-circle cross-track calculation).
+func perpendicularDistanceMeters(p, a, b Point) float64 {
+	midLat := (a.Lat + b.Lat) / 2
+	midLon := (a.Lon + b.Lon) / 2
+
+	px, py := toLocalMeters(p, midLat, midLon)
+	ax, ay := toLocalMeters(a, midLat, midLon)
+	bx, by := toLocalMeters(b, midLat, midLon)
+
+	return pointToSegmentDistance(px, py, ax, ay, bx, by)
+}
+
+// toLocalMeters projects a point to planar (x, y) meters relative to
+// (refLat, refLon).
+func toLocalMeters(p Point, refLat, refLon float64) (x, y float64) {
+	x = (p.Lon - refLon) * metersPerDegreeLat * math.Cos(degreesToRadians(refLat))
+	y = (p.Lat - refLat) * metersPerDegreeLat
+	return x, y
+}
+
+// pointToSegmentDistance returns the distance from (px, py) to the
+// segment (ax, ay)-(bx, by), clamping the projection to the segment
+// itself so points beyond either endpoint measure against that endpoint
+// rather than the infinite line.
+func pointToSegmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx := bx - ax
+	dy := by - ay
+	if dx == 0 && dy == 0 {
+		return math.Hypot(px-ax, py-ay)
+	}
+
+	t := ((px-ax)*dx + (py-ay)*dy) / (dx*dx + dy*dy)
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+
+	projX := ax + t*dx
+	projY := ay + t*dy
+	return math.Hypot(px-projX, py-projY)
+}
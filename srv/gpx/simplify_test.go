@@ -0,0 +1,69 @@
+package gpx
+
+import "testing"
+
+func TestSimplifyDropsCollinearPoints(t *testing.T) {
+	// A straight line (within rounding) from (0,0) to (0,1) along a
+	// constant latitude - every interior point lies on the chord, so a
+	// generous tolerance should collapse it to just the endpoints.
+	points := make([]Point, 11)
+	for i := range points {
+		points[i] = Point{Lat: 0, Lon: float64(i) * 0.1}
+	}
+
+	simplified := Simplify(points, 50)
+	if len(simplified) != 2 {
+		t.Fatalf("expected straight line to simplify to 2 points, got %d", len(simplified))
+	}
+	if simplified[0] != points[0] || simplified[1] != points[len(points)-1] {
+		t.Errorf("expected endpoints preserved, got %+v", simplified)
+	}
+}
+
+func TestSimplifyKeepsOutlier(t *testing.T) {
+	// A single point pushed off the chord between two endpoints - a
+	// tight tolerance should keep it.
+	points := []Point{
+		{Lat: 0, Lon: 0},
+		{Lat: 0.01, Lon: 0.2}, // ~1.1km off the chord at the equator
+		{Lat: 0, Lon: 0.4},
+	}
+
+	simplified := Simplify(points, 100)
+	if len(simplified) != 3 {
+		t.Fatalf("expected outlier to be kept (3 points), got %d: %+v", len(simplified), simplified)
+	}
+	if simplified[1] != points[1] {
+		t.Errorf("expected the outlier point to survive, got %+v", simplified[1])
+	}
+}
+
+func TestSimplifyPreservesTimestamps(t *testing.T) {
+	points := make([]Point, 4)
+	for i := range points {
+		points[i] = Point{Lat: 0, Lon: float64(i) * 0.1}
+	}
+	points[3].Lat = 0.02
+
+	simplified := Simplify(points, 5000)
+	for _, p := range simplified {
+		found := false
+		for _, orig := range points {
+			if p == orig {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("simplified point %+v doesn't match any original Point struct", p)
+		}
+	}
+}
+
+func TestSimplifyShortInputUnchanged(t *testing.T) {
+	points := []Point{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 1}}
+	simplified := Simplify(points, 1)
+	if len(simplified) != 2 {
+		t.Errorf("expected 2-point input unchanged, got %d", len(simplified))
+	}
+}
@@ -0,0 +1,225 @@
+package gpx
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ParseGPXStream parses GPX XML from r one <trkpt> at a time via
+// xml.Decoder.Token(), calling handler for each point as it's read instead
+// of building a GPXData tree. This is the streaming counterpart to
+// ParseGPX, for callers (SplitIntoSegmentsStream, and in turn large patrol
+// uploads) that want to fold points into a running accumulator without
+// ever holding the whole track in memory.
+//
+// Track/segment boundaries (<trk>, <trkseg>) are not reported to handler -
+// only points. Returns the first error from decoding or from handler,
+// whichever happens first; handler returning an error stops parsing early.
+func ParseGPXStream(r io.Reader, handler func(Point) error) error {
+	decoder := xml.NewDecoder(r)
+
+	var (
+		inTrkpt  bool
+		curPt    gpxPoint
+		curField string
+	)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "trkpt":
+				inTrkpt = true
+				curPt = gpxPoint{}
+				for _, attr := range el.Attr {
+					switch attr.Name.Local {
+					case "lat":
+						curPt.Lat, _ = strconv.ParseFloat(attr.Value, 64)
+					case "lon":
+						curPt.Lon, _ = strconv.ParseFloat(attr.Value, 64)
+					}
+				}
+			case "ele", "time", "desc":
+				if inTrkpt {
+					curField = el.Name.Local
+				}
+			}
+
+		case xml.CharData:
+			if !inTrkpt || curField == "" {
+				continue
+			}
+			text := string(el)
+			switch curField {
+			case "ele":
+				if v, err := strconv.ParseFloat(text, 64); err == nil {
+					curPt.Elevation = &v
+				}
+			case "time":
+				curPt.Time = text
+			case "desc":
+				curPt.Desc = text
+			}
+
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "ele", "time", "desc":
+				curField = ""
+			case "trkpt":
+				inTrkpt = false
+				if err := handler(gpxPointToPoint(curPt)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// gpxPointToPoint converts a parsed gpxPoint into the public Point type,
+// the same conversion ParseGPX does inline for each point.
+func gpxPointToPoint(pt gpxPoint) Point {
+	point := Point{
+		Lat:       pt.Lat,
+		Lon:       pt.Lon,
+		Elevation: pt.Elevation,
+		Desc:      pt.Desc,
+	}
+	if pt.Time != "" {
+		if t, err := time.Parse(time.RFC3339, pt.Time); err == nil {
+			point.Time = &t
+		}
+	}
+	return point
+}
+
+// DistanceAccumulator folds a stream of points into a running distance,
+// speed, and bounding box without keeping the points themselves, for
+// callers that only need the aggregate stats ParseGPX's in-memory Point
+// slice would otherwise be used to compute.
+type DistanceAccumulator struct {
+	first, prev *Point
+
+	DistanceKm float64
+	Count      int
+
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Add folds p into the accumulator, updating the running distance and
+// bounding box against the previously added point.
+func (a *DistanceAccumulator) Add(p Point) {
+	if a.Count == 0 {
+		a.MinLat, a.MaxLat = p.Lat, p.Lat
+		a.MinLon, a.MaxLon = p.Lon, p.Lon
+		first := p
+		a.first = &first
+	} else {
+		a.DistanceKm += haversineDistance(*a.prev, p)
+		a.MinLat = math.Min(a.MinLat, p.Lat)
+		a.MaxLat = math.Max(a.MaxLat, p.Lat)
+		a.MinLon = math.Min(a.MinLon, p.Lon)
+		a.MaxLon = math.Max(a.MaxLon, p.Lon)
+	}
+
+	prev := p
+	a.prev = &prev
+	a.Count++
+}
+
+// AvgSpeedKmh returns the average speed across every point Add has seen so
+// far, the same start/end-time-and-distance calculation CalculateSpeed
+// does over a materialized slice. Returns 0 until at least two timestamped
+// points have been added.
+func (a *DistanceAccumulator) AvgSpeedKmh() float64 {
+	if a.first == nil || a.prev == nil || a.first.Time == nil || a.prev.Time == nil {
+		return 0
+	}
+	duration := a.prev.Time.Sub(*a.first.Time)
+	if duration <= 0 {
+		return 0
+	}
+	return a.DistanceKm / duration.Hours()
+}
+
+// SplitIntoSegmentsStream is the streaming counterpart to
+// SplitIntoSegments: it parses r with ParseGPXStream and sends each
+// completed time-bounded Segment to segments as soon as it's finalized,
+// instead of returning a single slice once the whole document has been
+// read. This lets a caller (see the HTTP upload handler) start persisting
+// segments before the rest of the upload has even been parsed.
+//
+// Closes segments when done. Any error from parsing is sent to errc before
+// segments is closed; errc is only ever sent to once and is never closed
+// (the caller is expected to select on a single receive).
+//
+// The point-accumulation and duration-boundary logic here intentionally
+// duplicates splitByDuration's rather than calling it, since splitByDuration
+// needs every point of a track segment in hand up front and this can't
+// buffer more than the current in-progress segment. One further
+// simplification versus SplitIntoSegments: track/trkseg boundaries in the
+// source document aren't threaded through ParseGPXStream (see its doc
+// comment), so a document with multiple <trk>/<trkseg> elements is treated
+// as one continuous point stream rather than being split at those
+// boundaries too - only elapsed time against maxDuration ends a segment.
+func SplitIntoSegmentsStream(r io.Reader, maxDuration time.Duration, segments chan<- Segment, errc chan<- error) {
+	defer close(segments)
+
+	if maxDuration == 0 {
+		maxDuration = DefaultSegmentDuration
+	}
+
+	var (
+		currentPoints []Point
+		segmentStart  *time.Time
+	)
+
+	flush := func() {
+		if len(currentPoints) == 0 {
+			return
+		}
+		segments <- buildSegment(currentPoints)
+		currentPoints = nil
+		segmentStart = nil
+	}
+
+	err := ParseGPXStream(r, func(pt Point) error {
+		if len(currentPoints) == 0 {
+			currentPoints = append(currentPoints, pt)
+			if pt.Time != nil {
+				segmentStart = pt.Time
+			}
+			return nil
+		}
+
+		if pt.Time != nil && segmentStart != nil && pt.Time.Sub(*segmentStart) > maxDuration {
+			flush()
+			currentPoints = append(currentPoints, pt)
+			segmentStart = pt.Time
+			return nil
+		}
+
+		currentPoints = append(currentPoints, pt)
+		if pt.Time != nil && segmentStart == nil {
+			segmentStart = pt.Time
+		}
+		return nil
+	})
+
+	flush()
+
+	if err != nil {
+		errc <- err
+	}
+}
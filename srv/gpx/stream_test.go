@@ -0,0 +1,184 @@
+package gpx
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syntheticGPXDocument builds a single-track, single-segment GPX document
+// with n points, 10 seconds apart, walking a short zig-zag path so distance
+// and speed calculations have something non-trivial to chew on.
+func syntheticGPXDocument(n int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><gpx><trk><name>bench</name><trkseg>`)
+
+	start := time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)
+	lat, lon := -1.0, 36.0
+	for i := 0; i < n; i++ {
+		t := start.Add(time.Duration(i) * 10 * time.Second)
+		lat += 0.00001
+		if i%2 == 0 {
+			lon += 0.00001
+		}
+		fmt.Fprintf(&b, `<trkpt lat="%f" lon="%f"><ele>%d</ele><time>%s</time></trkpt>`,
+			lat, lon, 1500+i%50, t.Format(time.RFC3339))
+	}
+
+	b.WriteString(`</trkseg></trk></gpx>`)
+	return b.String()
+}
+
+func TestParseGPXStreamMatchesParseGPX(t *testing.T) {
+	doc := syntheticGPXDocument(200)
+
+	want, err := ParseGPX(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseGPX: %v", err)
+	}
+	var wantPoints []Point
+	for _, trk := range want.Tracks {
+		for _, seg := range trk.Segments {
+			wantPoints = append(wantPoints, seg...)
+		}
+	}
+
+	var gotPoints []Point
+	if err := ParseGPXStream(strings.NewReader(doc), func(p Point) error {
+		gotPoints = append(gotPoints, p)
+		return nil
+	}); err != nil {
+		t.Fatalf("ParseGPXStream: %v", err)
+	}
+
+	if len(gotPoints) != len(wantPoints) {
+		t.Fatalf("expected %d points, got %d", len(wantPoints), len(gotPoints))
+	}
+	for i := range wantPoints {
+		if gotPoints[i].Lat != wantPoints[i].Lat || gotPoints[i].Lon != wantPoints[i].Lon {
+			t.Fatalf("point %d mismatch: got %+v, want %+v", i, gotPoints[i], wantPoints[i])
+		}
+		if (gotPoints[i].Time == nil) != (wantPoints[i].Time == nil) {
+			t.Fatalf("point %d time presence mismatch: got %v, want %v", i, gotPoints[i].Time, wantPoints[i].Time)
+		}
+	}
+}
+
+func TestDistanceAccumulatorMatchesCalculateDistance(t *testing.T) {
+	doc := syntheticGPXDocument(100)
+
+	data, err := ParseGPX(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseGPX: %v", err)
+	}
+	var points []Point
+	for _, trk := range data.Tracks {
+		for _, seg := range trk.Segments {
+			points = append(points, seg...)
+		}
+	}
+	wantDist := CalculateDistance(points)
+	wantSpeed := CalculateSpeed(points)
+
+	var acc DistanceAccumulator
+	if err := ParseGPXStream(strings.NewReader(doc), func(p Point) error {
+		acc.Add(p)
+		return nil
+	}); err != nil {
+		t.Fatalf("ParseGPXStream: %v", err)
+	}
+
+	if diff := math.Abs(acc.DistanceKm - wantDist); diff > 1e-9 {
+		t.Errorf("distance mismatch: got %v, want %v", acc.DistanceKm, wantDist)
+	}
+	if diff := math.Abs(acc.AvgSpeedKmh() - wantSpeed); diff > 1e-9 {
+		t.Errorf("avg speed mismatch: got %v, want %v", acc.AvgSpeedKmh(), wantSpeed)
+	}
+	if acc.Count != len(points) {
+		t.Errorf("expected count %d, got %d", len(points), acc.Count)
+	}
+}
+
+func TestSplitIntoSegmentsStreamMatchesSplitIntoSegments(t *testing.T) {
+	// Build a document with a 40-minute gap in the middle so it's split
+	// into two segments under the 30-minute default.
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><gpx><trk><trkseg>`)
+	start := time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		t := start.Add(time.Duration(i) * time.Minute)
+		fmt.Fprintf(&b, `<trkpt lat="-1.0" lon="36.0"><time>%s</time></trkpt>`, t.Format(time.RFC3339))
+	}
+	gapStart := start.Add(45 * time.Minute)
+	for i := 0; i < 5; i++ {
+		t := gapStart.Add(time.Duration(i) * time.Minute)
+		fmt.Fprintf(&b, `<trkpt lat="-1.1" lon="36.1"><time>%s</time></trkpt>`, t.Format(time.RFC3339))
+	}
+	b.WriteString(`</trkseg></trk></gpx>`)
+	doc := b.String()
+
+	data, err := ParseGPX(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseGPX: %v", err)
+	}
+	want := SplitIntoSegments(data, 0)
+
+	segCh := make(chan Segment)
+	errCh := make(chan error, 1)
+	go SplitIntoSegmentsStream(strings.NewReader(doc), 0, segCh, errCh)
+
+	var got []Segment
+	for seg := range segCh {
+		got = append(got, seg)
+	}
+	select {
+	case err := <-errCh:
+		t.Fatalf("SplitIntoSegmentsStream: %v", err)
+	default:
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d segments, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if len(got[i].Points) != len(want[i].Points) {
+			t.Errorf("segment %d: expected %d points, got %d", i, len(want[i].Points), len(got[i].Points))
+		}
+		if got[i].MovementType != want[i].MovementType {
+			t.Errorf("segment %d: expected movement type %q, got %q", i, want[i].MovementType, got[i].MovementType)
+		}
+	}
+}
+
+// BenchmarkParseGPXLargeTrack and BenchmarkParseGPXStreamLargeTrack compare
+// the fully materialized parse against the streaming one over a
+// 500k-point synthetic track, the scale a multi-day patrol upload can
+// reach - see BenchmarkParseGPX above for the same comparison at the
+// package's existing small-fixture scale.
+func BenchmarkParseGPXLargeTrack(b *testing.B) {
+	doc := []byte(syntheticGPXDocument(500_000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseGPX(bytes.NewReader(doc)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseGPXStreamLargeTrack(b *testing.B) {
+	doc := []byte(syntheticGPXDocument(500_000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var acc DistanceAccumulator
+		err := ParseGPXStream(bytes.NewReader(doc), func(p Point) error {
+			acc.Add(p)
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package srv
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"srv.exe.dev/srv/config"
+)
+
+// HTTPClient wraps http.Client with a bounded connection pool and a
+// per-client token-bucket rate limiter, for outbound calls to external
+// services (OpenAlex/Crossref/Semantic Scholar in research.go, the
+// Overpass endpoint in osm_roads_cache.go) that enforce their own
+// "polite use" rate limits. One HTTPClient is shared across every
+// goroutine calling the same host, the same way protectedplanet.Client
+// shares a single rate.Limiter.
+type HTTPClient struct {
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+// HTTPClientConfig configures NewHTTPClient. RatePerSecond/Burst size
+// the token bucket every Do call waits on before dialing out.
+type HTTPClientConfig struct {
+	Timeout       time.Duration
+	RatePerSecond float64
+	Burst         int
+}
+
+// NewHTTPClient builds an HTTPClient from cfg, filling in the
+// conservative defaults a zero-value HTTPClientConfig would otherwise
+// produce (no timeout, no rate limit).
+func NewHTTPClient(cfg HTTPClientConfig) *HTTPClient {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	rps := cfg.RatePerSecond
+	if rps <= 0 {
+		rps = 5
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &HTTPClient{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		limiter: rate.NewLimiter(rate.Limit(rps), burst),
+	}
+}
+
+// NewResearchHTTPClient builds the HTTPClient fetchPublicationsForPA's
+// PublicationSource implementations share, from config.Config's
+// ResearchHTTP* settings.
+func NewResearchHTTPClient(cfg *config.Config) *HTTPClient {
+	return NewHTTPClient(HTTPClientConfig{
+		Timeout:       cfg.ResearchHTTPTimeout,
+		RatePerSecond: cfg.ResearchHTTPRateLimit.PerSecond,
+		Burst:         cfg.ResearchHTTPRateLimit.Burst,
+	})
+}
+
+// NewOverpassHTTPClient builds the HTTPClient HandleParkRoads' Overpass
+// fetch uses, from config.Config's OverpassHTTP* settings.
+func NewOverpassHTTPClient(cfg *config.Config) *HTTPClient {
+	return NewHTTPClient(HTTPClientConfig{
+		Timeout:       cfg.OverpassHTTPTimeout,
+		RatePerSecond: cfg.OverpassHTTPRateLimit.PerSecond,
+		Burst:         cfg.OverpassHTTPRateLimit.Burst,
+	})
+}
+
+// Do waits on the rate limiter — honoring req.Context()'s deadline, so
+// a request whose caller already gave up doesn't block behind the
+// bucket — then sends req. Callers should build req with
+// http.NewRequestWithContext using the inbound request's context (or a
+// context.WithTimeout child of it) so both the caller's cancellation
+// and Do's own rate limiting apply.
+func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
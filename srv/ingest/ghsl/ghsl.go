@@ -0,0 +1,360 @@
+// Package ghsl decodes GHS-BUILT-S tile GeoTIFFs in pure Go and
+// aggregates built-up surface into the ghsl_data table, replacing
+// scripts/ghsl_processor_streaming.py so a deployment no longer needs
+// a Python toolchain. Each pixel holds a built-up surface density
+// (0-100, percent of the cell that's built); pixel coordinates are
+// reprojected from the tile's Mollweide grid (ESRI:54009, the GHSL
+// native projection) to WGS84 via a closed-form inverse, using the
+// ModelPixelScaleTag/ModelTiepointTag GeoTIFF tags parsed directly out
+// of the TIFF IFD, since the stdlib-style golang.org/x/image/tiff
+// decoder only exposes raster pixels, not private GeoTIFF tags.
+package ghsl
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+
+	"golang.org/x/image/tiff"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/areas"
+)
+
+// builtThreshold is the minimum per-pixel built-up percentage counted
+// toward built-up area, filtering out the low-density noise bare
+// soil/rock is sometimes misclassified as.
+const builtThreshold = 20.0
+
+// settlementMinCluster is the smallest connected cluster of built-up
+// pixels counted as a distinct settlement, so a handful of scattered
+// pixels (a lone building, a road junction) isn't tallied as one.
+const settlementMinCluster = 5
+
+// mollweideRadius is the authalic sphere radius GHSL's Mollweide grid
+// is defined on.
+const mollweideRadius = 6371007.181
+
+// ProgressFunc reports cumulative bytes read from the input so far.
+type ProgressFunc func(bytesRead int64)
+
+// Ingest decodes a GHS-BUILT-S tile GeoTIFF from r, reprojects its
+// built-up pixels to WGS84, clusters them into settlement-sized
+// groups, and adds each one's built-up area and settlement count onto
+// whichever protected area (from store) its centroid falls inside via
+// UpsertGHSLData. It returns the number of distinct areas touched.
+func Ingest(ctx context.Context, db *sql.DB, store *areas.AreaStore, r io.Reader, onProgress ProgressFunc) (int, error) {
+	buf, err := readAllCounting(r, onProgress)
+	if err != nil {
+		return 0, fmt.Errorf("read tile: %w", err)
+	}
+
+	transform, err := parseGeoTags(buf)
+	if err != nil {
+		return 0, fmt.Errorf("parse geotiff tags: %w", err)
+	}
+
+	img, err := tiff.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return 0, fmt.Errorf("decode tiff: %w", err)
+	}
+
+	bounds := img.Bounds()
+	built, err := buildMask(ctx, img, bounds, builtThreshold)
+	if err != nil {
+		return 0, err
+	}
+	cellAreaKm2 := (transform.pixelScaleX * transform.pixelScaleY) / 1e6
+
+	aggregates := make(map[string]*dbgen.UpsertGHSLDataParams)
+	for _, cluster := range connectedComponents(built, bounds) {
+		cx, cy := cluster.centroid()
+		x, y := transform.projected(cx, cy)
+		lat, lon := mollweideInverse(x, y)
+
+		area := store.FindArea(lat, lon)
+		if area == nil {
+			continue
+		}
+
+		agg, ok := aggregates[area.ID]
+		if !ok {
+			agg = &dbgen.UpsertGHSLDataParams{ParkID: area.ID}
+			aggregates[area.ID] = agg
+		}
+		agg.BuiltUpKm2 += float64(len(cluster)) * cellAreaKm2
+		if len(cluster) >= settlementMinCluster {
+			agg.SettlementCount++
+		}
+	}
+
+	for _, agg := range aggregates {
+		if err := dbgen.New(db).UpsertGHSLData(ctx, *agg); err != nil {
+			return 0, fmt.Errorf("upsert ghsl data for area %q: %w", agg.ParkID, err)
+		}
+	}
+	return len(aggregates), nil
+}
+
+// buildMask reports, for every pixel in bounds, whether its built-up
+// density meets builtThreshold. It checks ctx once per row so a
+// cancelled job (via JobRunner.Cancel) stops promptly instead of
+// finishing a multi-million-pixel tile first.
+func buildMask(ctx context.Context, img image.Image, bounds image.Rectangle, threshold float64) ([][]bool, error) {
+	mask := make([][]bool, bounds.Dy())
+	for y := 0; y < bounds.Dy(); y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mask[y] = make([]bool, bounds.Dx())
+		for x := 0; x < bounds.Dx(); x++ {
+			mask[y][x] = pixelValue(img, bounds.Min.X+x, bounds.Min.Y+y) >= threshold
+		}
+	}
+	return mask, nil
+}
+
+// pixelValue reads a pixel's built-up density as a 0-100 value,
+// handling the handful of pixel formats GHS-BUILT-S tiles ship in.
+func pixelValue(img image.Image, x, y int) float64 {
+	switch im := img.(type) {
+	case *image.Gray:
+		return float64(im.GrayAt(x, y).Y)
+	case *image.Gray16:
+		return float64(im.Gray16At(x, y).Y) / 257.0
+	default:
+		g := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+		return float64(g.Y)
+	}
+}
+
+// cluster is the set of pixel coordinates making up one connected
+// component of built-up pixels.
+type cluster []image.Point
+
+func (c cluster) centroid() (x, y int) {
+	var sumX, sumY int
+	for _, p := range c {
+		sumX += p.X
+		sumY += p.Y
+	}
+	return sumX / len(c), sumY / len(c)
+}
+
+// connectedComponents groups mask's true cells into 4-connected
+// clusters using an iterative flood fill, so a tile with millions of
+// pixels doesn't risk blowing the goroutine stack with recursion.
+func connectedComponents(mask [][]bool, bounds image.Rectangle) []cluster {
+	h := len(mask)
+	if h == 0 {
+		return nil
+	}
+	w := len(mask[0])
+	visited := make([][]bool, h)
+	for y := range visited {
+		visited[y] = make([]bool, w)
+	}
+
+	var clusters []cluster
+	stack := make([]image.Point, 0, 64)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !mask[y][x] || visited[y][x] {
+				continue
+			}
+
+			var c cluster
+			stack = append(stack[:0], image.Point{X: x, Y: y})
+			visited[y][x] = true
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				c = append(c, image.Point{X: bounds.Min.X + p.X, Y: bounds.Min.Y + p.Y})
+
+				for _, d := range [4]image.Point{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}} {
+					nx, ny := p.X+d.X, p.Y+d.Y
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					if !mask[ny][nx] || visited[ny][nx] {
+						continue
+					}
+					visited[ny][nx] = true
+					stack = append(stack, image.Point{X: nx, Y: ny})
+				}
+			}
+			clusters = append(clusters, c)
+		}
+	}
+	return clusters
+}
+
+// geoTransform maps raster pixel coordinates to the tile's Mollweide
+// projected coordinates (meters).
+type geoTransform struct {
+	pixelScaleX, pixelScaleY float64
+	originX, originY         float64
+}
+
+func (g geoTransform) projected(px, py int) (x, y float64) {
+	return g.originX + float64(px)*g.pixelScaleX, g.originY - float64(py)*g.pixelScaleY
+}
+
+// mollweideInverse converts Mollweide-projected meters to WGS84
+// degrees. Unlike the forward projection (which needs Newton's method
+// to solve for the auxiliary angle from latitude), the inverse is
+// closed-form since y determines that angle directly.
+func mollweideInverse(x, y float64) (lat, lon float64) {
+	theta := math.Asin(y / (mollweideRadius * math.Sqrt2))
+	latRad := math.Asin((2*theta + math.Sin(2*theta)) / math.Pi)
+	lonRad := (math.Pi * x) / (2 * mollweideRadius * math.Sqrt2 * math.Cos(theta))
+	return latRad * 180 / math.Pi, lonRad * 180 / math.Pi
+}
+
+// geoTiffTagModelPixelScale and geoTiffTagModelTiepoint are the
+// private TIFF tags GeoTIFF uses to carry a raster's affine transform.
+const (
+	geoTiffTagModelPixelScale = 33550
+	geoTiffTagModelTiepoint   = 33922
+	tiffTypeDouble            = 12
+)
+
+// parseGeoTags walks buf's TIFF IFD directly to pull out the
+// ModelPixelScaleTag and ModelTiepointTag values x/image/tiff doesn't
+// surface, then folds them into a single pixel->Mollweide transform.
+func parseGeoTags(buf []byte) (geoTransform, error) {
+	if len(buf) < 8 {
+		return geoTransform{}, fmt.Errorf("file too small to be a TIFF")
+	}
+
+	var order binary.ByteOrder
+	switch string(buf[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return geoTransform{}, fmt.Errorf("missing TIFF byte-order marker")
+	}
+	if order.Uint16(buf[2:4]) != 42 {
+		return geoTransform{}, fmt.Errorf("bad TIFF magic number")
+	}
+
+	var scale, tiepoint []float64
+	offset := order.Uint32(buf[4:8])
+	for offset != 0 {
+		entries, next, err := readIFD(buf, order, offset)
+		if err != nil {
+			return geoTransform{}, err
+		}
+		for _, e := range entries {
+			switch e.tag {
+			case geoTiffTagModelPixelScale:
+				if e.typ == tiffTypeDouble && e.count >= 3 {
+					scale, _ = readDoubles(buf, order, e.valueOffset, 3)
+				}
+			case geoTiffTagModelTiepoint:
+				if e.typ == tiffTypeDouble && e.count >= 6 {
+					tiepoint, _ = readDoubles(buf, order, e.valueOffset, 6)
+				}
+			}
+		}
+		offset = next
+	}
+
+	if len(scale) < 3 || len(tiepoint) < 6 {
+		return geoTransform{}, fmt.Errorf("tile is missing ModelPixelScaleTag/ModelTiepointTag")
+	}
+
+	return geoTransform{
+		pixelScaleX: scale[0],
+		pixelScaleY: scale[1],
+		// ModelTiepointTag maps raster point (tiepoint[0],tiepoint[1])
+		// to model point (tiepoint[3],tiepoint[4]); GHSL tiles tie
+		// down pixel (0,0), but resolve the general case anyway.
+		originX: tiepoint[3] - tiepoint[0]*scale[0],
+		originY: tiepoint[4] + tiepoint[1]*scale[1],
+	}, nil
+}
+
+type ifdEntry struct {
+	tag, typ    uint16
+	count       uint32
+	valueOffset uint32 // offset of the entry's 4-byte value/offset field
+}
+
+func readIFD(buf []byte, order binary.ByteOrder, offset uint32) (entries []ifdEntry, next uint32, err error) {
+	if int(offset)+2 > len(buf) {
+		return nil, 0, fmt.Errorf("IFD offset out of range")
+	}
+	count := int(order.Uint16(buf[offset : offset+2]))
+	base := offset + 2
+	for i := 0; i < count; i++ {
+		entryOff := base + uint32(i*12)
+		if int(entryOff)+12 > len(buf) {
+			return nil, 0, fmt.Errorf("IFD entry out of range")
+		}
+		entries = append(entries, ifdEntry{
+			tag:         order.Uint16(buf[entryOff : entryOff+2]),
+			typ:         order.Uint16(buf[entryOff+2 : entryOff+4]),
+			count:       order.Uint32(buf[entryOff+4 : entryOff+8]),
+			valueOffset: entryOff + 8,
+		})
+	}
+	nextOff := base + uint32(count*12)
+	if int(nextOff)+4 > len(buf) {
+		return entries, 0, nil
+	}
+	return entries, order.Uint32(buf[nextOff : nextOff+4]), nil
+}
+
+// readDoubles reads count 8-byte IEEE-754 doubles starting at the data
+// ModelTag's 4-byte value field points to (DOUBLE values never fit
+// inline, unlike short scalar types).
+func readDoubles(buf []byte, order binary.ByteOrder, valueFieldOffset uint32, count int) ([]float64, error) {
+	if int(valueFieldOffset)+4 > len(buf) {
+		return nil, fmt.Errorf("value field out of range")
+	}
+	dataOffset := order.Uint32(buf[valueFieldOffset : valueFieldOffset+4])
+	out := make([]float64, count)
+	for i := 0; i < count; i++ {
+		start := dataOffset + uint32(i*8)
+		if int(start)+8 > len(buf) {
+			return nil, fmt.Errorf("double value out of range")
+		}
+		out[i] = math.Float64frombits(order.Uint64(buf[start : start+8]))
+	}
+	return out, nil
+}
+
+// readAllCounting reads r fully into memory (GeoTIFF tag parsing needs
+// random access to the raw bytes, and tiles are tens of MB, not
+// gigabytes), reporting cumulative bytes read via onProgress as it goes.
+func readAllCounting(r io.Reader, onProgress ProgressFunc) ([]byte, error) {
+	var buf bytes.Buffer
+	chunk := make([]byte, 256*1024)
+	var total int64
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			buf.Write(chunk[:n])
+			total += int64(n)
+			if onProgress != nil {
+				onProgress(total)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
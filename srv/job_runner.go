@@ -0,0 +1,142 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/jobs"
+)
+
+// jobRunnerWorkers caps how many fire/GHSL processing tasks run at
+// once, so a burst of uploads doesn't spawn an unbounded number of
+// goroutines decoding CSVs/GeoTIFFs concurrently.
+const jobRunnerWorkers = 3
+
+// JobRunner bounds how many background processing tasks run
+// concurrently, lets an admin cancel one in flight, and persists the
+// queue to SQLite so a pending or running job isn't silently lost if
+// the server restarts mid-task.
+type JobRunner struct {
+	db   *sql.DB
+	jobs *jobs.Manager
+	sem  chan struct{}
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewJobRunner creates a runner backed by db for persistence and mgr
+// for live progress tracking.
+func NewJobRunner(db *sql.DB, mgr *jobs.Manager) *JobRunner {
+	return &JobRunner{
+		db:      db,
+		jobs:    mgr,
+		sem:     make(chan struct{}, jobRunnerWorkers),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Submit registers kind/filename/total as a tracked job, persists it as
+// pending, and runs task once a worker slot is free. task is called
+// with a context that Cancel cancels; if it returns an error the job is
+// marked failed with that error, otherwise it's marked successful.
+func (r *JobRunner) Submit(kind jobs.Kind, filename string, total int64, task func(ctx context.Context, jobID string) error) string {
+	jobID := r.jobs.Start(kind, filename, total)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.cancels[jobID] = cancel
+	r.mu.Unlock()
+
+	if err := r.persistCreate(jobID, kind, filename, total); err != nil {
+		slog.Warn("failed to persist background job", "job_id", jobID, "error", err)
+	}
+
+	go func() {
+		r.sem <- struct{}{}
+		defer func() { <-r.sem }()
+
+		r.persistStatus(jobID, "running", "")
+		err := task(ctx, jobID)
+
+		r.mu.Lock()
+		delete(r.cancels, jobID)
+		r.mu.Unlock()
+		cancel()
+
+		if err != nil {
+			r.jobs.Finish(jobID, err)
+			r.persistStatus(jobID, "error", err.Error())
+			return
+		}
+		r.jobs.Finish(jobID, nil)
+		r.persistStatus(jobID, "success", "")
+	}()
+
+	return jobID
+}
+
+// Cancel requests that jobID stop: its context is cancelled (the
+// ingest packages under srv/ingest check ctx at their next row/pixel
+// boundary and return promptly) and it's marked cancelled in both the
+// live manager and the database. It reports false if jobID isn't
+// running.
+func (r *JobRunner) Cancel(jobID string) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[jobID]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	cancel()
+	r.jobs.Cancel(jobID)
+	r.persistStatus(jobID, "cancelled", "")
+	return true
+}
+
+func (r *JobRunner) persistCreate(jobID string, kind jobs.Kind, filename string, total int64) error {
+	return dbgen.New(r.db).CreateBackgroundJob(context.Background(), dbgen.CreateBackgroundJobParams{
+		ID:         jobID,
+		Kind:       string(kind),
+		Filename:   filename,
+		TotalBytes: total,
+		Status:     "pending",
+	})
+}
+
+func (r *JobRunner) persistStatus(jobID, status, errMsg string) {
+	if err := dbgen.New(r.db).UpdateBackgroundJobStatus(context.Background(), dbgen.UpdateBackgroundJobStatusParams{
+		ID:     jobID,
+		Status: status,
+		Error:  errMsg,
+	}); err != nil {
+		slog.Warn("failed to update background job status", "job_id", jobID, "status", status, "error", err)
+	}
+}
+
+// ResumeOrFail reconciles the persisted job queue with reality on
+// startup. A pending or running row's subprocess doesn't survive a
+// restart — there's nothing left to resume — so each one is marked
+// failed with a clear reason instead of silently forgotten.
+func (r *JobRunner) ResumeOrFail(ctx context.Context) error {
+	rows, err := dbgen.New(r.db).ListIncompleteBackgroundJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("list incomplete background jobs: %w", err)
+	}
+	for _, row := range rows {
+		if err := dbgen.New(r.db).UpdateBackgroundJobStatus(ctx, dbgen.UpdateBackgroundJobStatusParams{
+			ID:     row.ID,
+			Status: "error",
+			Error:  "interrupted by server restart",
+		}); err != nil {
+			slog.Warn("failed to mark interrupted background job as failed", "job_id", row.ID, "error", err)
+			continue
+		}
+		slog.Warn("background job interrupted by restart, marked failed", "job_id", row.ID, "kind", row.Kind, "filename", row.Filename)
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+// Package jobs tracks long-running background tasks (CSV ingestion,
+// GHSL tile processing, future tile downloads) so the admin console can
+// show live progress instead of a single opaque status string. A Job's
+// byte counters are updated from the worker goroutine as it reads or
+// writes; Manager derives throughput and ETA from a rolling window of
+// those updates, the same way tools built around cheggaaa/pb report
+// "12.4 MB/s" and "ETA 3m20s" for a long copy.
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Kind identifies what a job is doing, for display and filtering.
+type Kind string
+
+const (
+	KindFireCSV  Kind = "fire_csv"
+	KindGHSLTile Kind = "ghsl_tile"
+)
+
+// State is a job's lifecycle stage.
+type State string
+
+const (
+	StateRunning   State = "running"
+	StateSuccess   State = "success"
+	StateError     State = "error"
+	StateCancelled State = "cancelled"
+)
+
+// Job is a snapshot of one tracked task. It's returned by value so
+// callers can't mutate a Manager's internal state by holding onto one.
+type Job struct {
+	ID         string    `json:"id"`
+	Kind       Kind      `json:"kind"`
+	Filename   string    `json:"filename"`
+	Phase      string    `json:"phase"`
+	State      State     `json:"state"`
+	BytesDone  int64     `json:"bytesDone"`
+	BytesTotal int64     `json:"bytesTotal"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	Err        string    `json:"error,omitempty"`
+
+	// ThroughputBytesPerSec and ETASeconds are derived from a rolling
+	// window of recent progress, not stored state; they're zero for
+	// jobs that haven't reported progress recently or have finished.
+	ThroughputBytesPerSec float64 `json:"throughputBytesPerSec"`
+	ETASeconds            float64 `json:"etaSeconds"`
+}
+
+// Throughput renders ThroughputBytesPerSec as "12.4 MB/s", or "" if unknown.
+func (j Job) Throughput() string {
+	if j.ThroughputBytesPerSec <= 0 {
+		return ""
+	}
+	return humanizeBytes(j.ThroughputBytesPerSec) + "/s"
+}
+
+// ETA renders ETASeconds as "3m20s", or "" if unknown or the job is done.
+func (j Job) ETA() string {
+	if j.State != StateRunning || j.ETASeconds <= 0 {
+		return ""
+	}
+	return humanizeDuration(j.ETASeconds)
+}
+
+// ProgressPct returns 0-100, or 0 if BytesTotal is unknown.
+func (j Job) ProgressPct() int {
+	if j.BytesTotal <= 0 {
+		return 0
+	}
+	pct := int(float64(j.BytesDone) / float64(j.BytesTotal) * 100)
+	if pct > 100 {
+		pct = 100
+	}
+	return pct
+}
+
+// windowDuration bounds how far back a job's rate calculation looks;
+// samples older than this are dropped as they're reported.
+const windowDuration = 15 * time.Second
+
+type sample struct {
+	at    time.Time
+	bytes int64
+}
+
+type trackedJob struct {
+	Job
+	mu      sync.Mutex
+	samples []sample
+}
+
+// Manager holds the set of jobs started since the process came up. It's
+// in-memory only: restarting the server clears job history, which is
+// fine since jobs themselves are re-triggered by re-uploading.
+type Manager struct {
+	mu   sync.Mutex
+	jobs map[string]*trackedJob
+
+	subMu sync.Mutex
+	subs  map[chan []Job]struct{}
+
+	nextID int64
+}
+
+// NewManager creates an empty job manager.
+func NewManager() *Manager {
+	return &Manager{
+		jobs: make(map[string]*trackedJob),
+		subs: make(map[chan []Job]struct{}),
+	}
+}
+
+// Start registers a new running job and returns its ID. total may be 0
+// if the size isn't known up front (e.g. a streaming decode); ProgressPct
+// and ETA degrade gracefully to 0/"" in that case.
+func (m *Manager) Start(kind Kind, filename string, total int64) string {
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("job-%d", m.nextID)
+	m.jobs[id] = &trackedJob{
+		Job: Job{
+			ID:         id,
+			Kind:       kind,
+			Filename:   filename,
+			Phase:      "starting",
+			State:      StateRunning,
+			BytesTotal: total,
+			StartedAt:  time.Now(),
+		},
+	}
+	m.mu.Unlock()
+	m.publish()
+	return id
+}
+
+// SetPhase updates a job's human-readable current phase (e.g. "extracting",
+// "ingesting rows").
+func (m *Manager) SetPhase(id, phase string) {
+	m.withJob(id, func(tj *trackedJob) {
+		tj.Phase = phase
+	})
+	m.publish()
+}
+
+// AddBytes advances a job's progress counter by delta and records a
+// sample for the throughput/ETA calculation.
+func (m *Manager) AddBytes(id string, delta int64) {
+	m.withJob(id, func(tj *trackedJob) {
+		tj.BytesDone += delta
+		now := time.Now()
+		tj.samples = append(tj.samples, sample{at: now, bytes: tj.BytesDone})
+		cutoff := now.Add(-windowDuration)
+		i := 0
+		for i < len(tj.samples) && tj.samples[i].at.Before(cutoff) {
+			i++
+		}
+		tj.samples = tj.samples[i:]
+		tj.ThroughputBytesPerSec, tj.ETASeconds = rate(tj.samples, tj.BytesTotal, tj.BytesDone)
+	})
+	m.publish()
+}
+
+// Finish marks a job as finished: success if err is nil, error otherwise.
+func (m *Manager) Finish(id string, err error) {
+	m.withJob(id, func(tj *trackedJob) {
+		tj.FinishedAt = time.Now()
+		tj.ThroughputBytesPerSec = 0
+		tj.ETASeconds = 0
+		if err != nil {
+			tj.State = StateError
+			tj.Err = err.Error()
+		} else {
+			tj.State = StateSuccess
+			tj.Phase = "done"
+		}
+	})
+	m.publish()
+}
+
+// Cancel marks a running job as cancelled.
+func (m *Manager) Cancel(id string) {
+	m.withJob(id, func(tj *trackedJob) {
+		tj.FinishedAt = time.Now()
+		tj.State = StateCancelled
+		tj.ThroughputBytesPerSec = 0
+		tj.ETASeconds = 0
+	})
+	m.publish()
+}
+
+// Get returns one job by ID.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tj, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	tj.mu.Lock()
+	defer tj.mu.Unlock()
+	return tj.Job, true
+}
+
+// List returns all jobs, most recently started first.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Job, 0, len(m.jobs))
+	for _, tj := range m.jobs {
+		tj.mu.Lock()
+		out = append(out, tj.Job)
+		tj.mu.Unlock()
+	}
+	sortJobsNewestFirst(out)
+	return out
+}
+
+// Subscribe returns a channel that receives the full job list whenever
+// any job changes, plus an unsubscribe func the caller must call when
+// done (typically via defer when its SSE connection closes). The
+// channel is buffered by 1 and updates are dropped rather than blocking
+// a slow reader, since only the latest snapshot matters.
+func (m *Manager) Subscribe() (<-chan []Job, func()) {
+	ch := make(chan []Job, 1)
+	m.subMu.Lock()
+	m.subs[ch] = struct{}{}
+	m.subMu.Unlock()
+
+	unsubscribe := func() {
+		m.subMu.Lock()
+		delete(m.subs, ch)
+		m.subMu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+func (m *Manager) withJob(id string, fn func(tj *trackedJob)) {
+	m.mu.Lock()
+	tj, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	tj.mu.Lock()
+	fn(tj)
+	tj.mu.Unlock()
+}
+
+func (m *Manager) publish() {
+	list := m.List()
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	for ch := range m.subs {
+		select {
+		case ch <- list:
+		default:
+		}
+	}
+}
+
+func sortJobsNewestFirst(jobs []Job) {
+	for i := 1; i < len(jobs); i++ {
+		for j := i; j > 0 && jobs[j].StartedAt.After(jobs[j-1].StartedAt); j-- {
+			jobs[j], jobs[j-1] = jobs[j-1], jobs[j]
+		}
+	}
+}
+
+// rate computes bytes/sec over the sample window and, if total is
+// known, a remaining-time estimate from that rate.
+func rate(samples []sample, total, done int64) (bytesPerSec, etaSeconds float64) {
+	if len(samples) < 2 {
+		return 0, 0
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	elapsed := last.at.Sub(first.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	bytesPerSec = float64(last.bytes-first.bytes) / elapsed
+	if bytesPerSec <= 0 || total <= 0 {
+		return bytesPerSec, 0
+	}
+	remaining := total - done
+	if remaining <= 0 {
+		return bytesPerSec, 0
+	}
+	return bytesPerSec, float64(remaining) / bytesPerSec
+}
+
+func humanizeBytes(n float64) string {
+	const unit = 1024.0
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	i := 0
+	for n >= unit && i < len(units)-1 {
+		n /= unit
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
+
+func humanizeDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+
+	switch {
+	case h > 0:
+		return fmt.Sprintf("%dh%dm", h, m)
+	case m > 0:
+		return fmt.Sprintf("%dm%ds", m, s)
+	default:
+		return fmt.Sprintf("%ds", s)
+	}
+}
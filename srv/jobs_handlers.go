@@ -0,0 +1,82 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HandleJobsList returns the current snapshot of all tracked background
+// jobs, newest first.
+func (s *Server) HandleJobsList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Jobs.List())
+}
+
+// HandleJobDetail returns one job by ID.
+func (s *Server) HandleJobDetail(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Jobs.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleJobCancel cancels a running job: its context is cancelled,
+// escalating from SIGTERM to SIGKILL for a job running an external
+// process, and it's marked cancelled.
+func (s *Server) HandleJobCancel(w http.ResponseWriter, r *http.Request) {
+	if !s.JobRunner.Cancel(r.PathValue("id")) {
+		http.Error(w, "job not found or already finished", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleJobsStream streams the job list as Server-Sent Events, pushing
+// a new snapshot whenever any job's progress changes, so the admin page
+// can render live progress bars without polling.
+func (s *Server) HandleJobsStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	updates, unsubscribe := s.Jobs.Subscribe()
+	defer unsubscribe()
+
+	writeSnapshot := func(list any) error {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := writeSnapshot(s.Jobs.List()); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case list := <-updates:
+			if err := writeSnapshot(list); err != nil {
+				return
+			}
+		}
+	}
+}
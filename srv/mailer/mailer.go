@@ -0,0 +1,69 @@
+// Package mailer sends the handful of transactional emails this
+// service needs (password reset, email verification) behind a single
+// Mailer interface, so a deployment can point at Postmark/SES/whatever
+// by swapping in an SMTP relay address rather than this module taking
+// on a provider-specific SDK dependency.
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+)
+
+// Mailer sends a plain-text email. Implementations should treat to,
+// subject, and body as already final — callers are responsible for
+// composing the message text.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends mail through a single SMTP relay, authenticated with
+// PLAIN auth if Username is set. This covers every mainstream
+// transactional provider (Postmark, SES, SendGrid, etc. all expose an
+// SMTP endpoint), so it's the only Mailer implementation this module
+// needs to ship.
+type SMTPMailer struct {
+	Addr     string // host:port of the SMTP relay
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPMailer creates a Mailer that relays through addr, authenticating
+// with username/password (PLAIN auth) if username is non-empty.
+func NewSMTPMailer(addr, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{Addr: addr, Username: username, Password: password, From: from}
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	host := m.Addr
+	if idx := lastColon(host); idx != -1 {
+		host = host[:idx]
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.From, to, subject, body)
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, []byte(msg))
+}
+
+func lastColon(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == ':' {
+			return i
+		}
+	}
+	return -1
+}
+
+// NoopMailer discards every message. It's the Mailer wired up in tests
+// and any deployment that hasn't configured an SMTP relay yet — sent
+// messages are logged by the caller, not by NoopMailer itself, so tests
+// asserting on reset/verification flows don't need to intercept real
+// mail.
+type NoopMailer struct{}
+
+func (NoopMailer) Send(to, subject, body string) error { return nil }
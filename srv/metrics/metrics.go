@@ -0,0 +1,197 @@
+// Package metrics holds this server's Prometheus collectors and the
+// HTTP middleware that instruments every handler with them. Collectors
+// are package-level so any part of srv can record against them without
+// threading a registry through the Server struct, the same way the
+// standard client_golang promauto helpers are meant to be used.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Version and Commit are overridden at build time via
+// -ldflags "-X srv.exe.dev/srv/metrics.Version=... -X srv.exe.dev/srv/metrics.Commit=..."
+// and reported through BuildInfo.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+var (
+	// HTTPRequestsTotal counts every request an instrumented handler
+	// served, labeled by handler name, HTTP method, and response status.
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests handled, by handler, method, and status.",
+	}, []string{"handler", "method", "status"})
+
+	// HTTPRequestDurationSeconds times each instrumented handler call.
+	HTTPRequestDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP handler request duration in seconds, by handler.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"handler"})
+
+	// GridFeaturesReturned records how many grid cell features
+	// HandleAPIGrid returned, so an operator can tell a slow request
+	// from a request that's just legitimately returning a lot of data.
+	GridFeaturesReturned = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "grid_features_returned",
+		Help:    "Number of grid cell features returned per /api/grid request.",
+		Buckets: []float64{0, 10, 50, 100, 500, 1000, 5000, 10000},
+	})
+
+	// UploadBytesTotal sums the bytes accepted through HandleAPIUpload.
+	UploadBytesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "upload_bytes_total",
+		Help: "Total bytes accepted by the track upload endpoint.",
+	})
+
+	// IngestJobs gauges how many upload jobs currently sit in each
+	// status (pending, processing, done, error) in the upload queue.
+	IngestJobs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ingest_jobs",
+		Help: "Number of upload queue jobs currently in each status.",
+	}, []string{"status"})
+
+	// AuthLoginAttemptsTotal counts login attempts by outcome
+	// ("success" or "failure").
+	AuthLoginAttemptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Total login attempts, by result.",
+	}, []string{"result"})
+
+	// WDPASearchResults records how many results HandleAPIWDPASearch
+	// returned per query.
+	WDPASearchResults = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "wdpa_search_results",
+		Help:    "Number of results returned per /api/wdpa/search query.",
+		Buckets: []float64{0, 1, 5, 10, 25, 50},
+	})
+
+	// DBQueryDurationSeconds times individual dbgen query calls, labeled
+	// by query name, for spotting which query is behind a slow handler.
+	DBQueryDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of individual dbgen query calls in seconds, by query.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"query"})
+
+	// DBErrorsTotal counts database errors returned to an instrumented
+	// handler, labeled by handler name, for alerting on a backend gone
+	// bad without waiting for HTTPRequestsTotal's 5xx rate to climb.
+	DBErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_errors_total",
+		Help: "Total database errors encountered, by handler.",
+	}, []string{"handler"})
+
+	// ParkDataSourceReady gauges whether a park's data source is ready
+	// (1) or not (0), labeled by park_id and source (fire_analysis,
+	// group_infractions, publications, ghsl, roadless) — set by
+	// HandleAPIParkDataStatus each time it's called, so "which parks are
+	// still pending" is a dashboard query instead of a per-park API call.
+	ParkDataSourceReady = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "park_data_source_ready",
+		Help: "Whether a park's data source is ready (1) or not (0), by park_id and source.",
+	}, []string{"park_id", "source"})
+
+	// BuildInfo is a gauge permanently set to 1, with version/commit
+	// labels, so build identity is queryable the same way as any other
+	// metric instead of requiring a separate endpoint.
+	BuildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "build_info",
+		Help: "Build version and commit, always 1.",
+	}, []string{"version", "commit"})
+
+	// The Conservation* gauges mirror the signals HandleAPIParkStats
+	// computes from SQL on every request, so operators can alert on them
+	// (e.g. a response-rate regression, a spike in daily fire detections)
+	// without polling the JSON API. They're populated by a background
+	// refresher (see srv.StartConservationMetricsRefresher) rather than
+	// on scrape, so a /metrics request never touches SQLite directly.
+
+	// ConservationFireDetectionsTotal gauges the number of individual
+	// fire_detections rows recorded for a park in a given year.
+	ConservationFireDetectionsTotal = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "conservation_fire_detections_total",
+		Help: "Total fire detections recorded for a park in a year.",
+	}, []string{"park_id", "year"})
+
+	// ConservationFireGroupsStoppedInside gauges how many fire groups
+	// were stopped inside a park in a given year (park_group_infractions).
+	ConservationFireGroupsStoppedInside = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "conservation_fire_groups_stopped_inside",
+		Help: "Fire groups stopped inside a park in a year.",
+	}, []string{"park_id", "year"})
+
+	// ConservationFireResponseRate gauges a park's all-time fire response
+	// rate (groups stopped inside / groups entered * 100).
+	ConservationFireResponseRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "conservation_fire_response_rate",
+		Help: "Percentage of fire groups stopped inside a park, out of all groups that entered.",
+	}, []string{"park_id"})
+
+	// ConservationDeforestationKm2 gauges forest loss in a park for a
+	// given year (deforestation_events).
+	ConservationDeforestationKm2 = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "conservation_deforestation_km2",
+		Help: "Forest loss in square kilometers for a park in a year.",
+	}, []string{"park_id", "year"})
+
+	// ConservationSettlementBuiltupKm2 gauges a park's current built-up
+	// settlement area (ghsl_data).
+	ConservationSettlementBuiltupKm2 = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "conservation_settlement_builtup_km2",
+		Help: "Built-up settlement area in square kilometers detected inside a park.",
+	}, []string{"park_id"})
+
+	// ConservationRoadlessPercentage gauges the fraction of a park's
+	// area with no nearby road (osm_roadless_data).
+	ConservationRoadlessPercentage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "conservation_roadless_percentage",
+		Help: "Percentage of a park's area classified as roadless.",
+	}, []string{"park_id"})
+)
+
+func init() {
+	BuildInfo.WithLabelValues(Version, Commit).Set(1)
+}
+
+// statusRecorder wraps http.ResponseWriter to capture the status code a
+// handler wrote, for HTTPRequestsTotal's status label.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush forwards to the underlying ResponseWriter's Flusher, if any, so
+// wrapping with Instrument doesn't break SSE handlers that type-assert
+// http.Flusher (see HandleJobsStream, HandleAPIUploadJobStream).
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Instrument wraps next with HTTPRequestsTotal/HTTPRequestDurationSeconds
+// recording under the given handler name. Call it where routes are
+// mounted, e.g. mux.HandleFunc("GET /api/grid", metrics.Instrument("api_grid", s.HandleAPIGrid)).
+func Instrument(handler string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next(rec, r)
+		HTTPRequestDurationSeconds.WithLabelValues(handler).Observe(time.Since(start).Seconds())
+		HTTPRequestsTotal.WithLabelValues(handler, r.Method, strconv.Itoa(rec.status)).Inc()
+	}
+}
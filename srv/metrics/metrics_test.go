@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestInstrumentAndScrape(t *testing.T) {
+	handler := Instrument("test_handler", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/whatever", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/whatever", nil))
+
+	scrapeReq := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	scrapeW := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(scrapeW, scrapeReq)
+
+	if scrapeW.Code != http.StatusOK {
+		t.Fatalf("expected /metrics status 200, got %d", scrapeW.Code)
+	}
+	body := scrapeW.Body.String()
+
+	for _, want := range []string{
+		`http_requests_total{handler="test_handler",method="GET",status="200"} 2`,
+		"http_request_duration_seconds",
+		"build_info",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected /metrics output to contain %q, got:\n%s", want, body)
+		}
+	}
+}
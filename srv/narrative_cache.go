@@ -0,0 +1,89 @@
+package srv
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultFireNarrativeYearRange is the (fromYear, toYear) range
+// HandleAPIFireNarrative computes when a request has no year/from/to
+// query params. cmd/5mp's "aggregate narratives" subcommand calls this
+// too, so the rows it materializes are keyed the same way an
+// unparameterized request looks them up.
+func DefaultFireNarrativeYearRange() (fromYear, toYear int) {
+	return 2000, time.Now().Year()
+}
+
+// DefaultDeforestationNarrativeYearRange is the HandleAPIDeforestationNarrative
+// equivalent of DefaultFireNarrativeYearRange.
+func DefaultDeforestationNarrativeYearRange() (fromYear, toYear int) {
+	return 1900, 2100
+}
+
+// narrativeCacheTTL is how long a materialized park_narratives row (see
+// cmd/5mp's "aggregate narratives" subcommand) is served before a
+// request falls back to live computation instead.
+const narrativeCacheTTL = 24 * time.Hour
+
+// loadCachedNarrative looks up the materialized row for (kind, parkID,
+// fromYear, toYear) and decodes its payload into dest if present and
+// fresher than narrativeCacheTTL. ok is false (dest left untouched) on
+// any miss, staleness, or decode error, so callers always have a live
+// computation path to fall back to.
+func (s *Server) loadCachedNarrative(kind, parkID string, fromYear, toYear int, dest interface{}) (generatedAt time.Time, ok bool) {
+	var payload string
+	err := s.DB.QueryRow(`
+		SELECT payload, generated_at FROM park_narratives
+		WHERE kind = ? AND park_id = ? AND from_year = ? AND to_year = ?
+	`, kind, parkID, fromYear, toYear).Scan(&payload, &generatedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if time.Since(generatedAt) > narrativeCacheTTL {
+		return generatedAt, false
+	}
+	if err := json.Unmarshal([]byte(payload), dest); err != nil {
+		return generatedAt, false
+	}
+	return generatedAt, true
+}
+
+// SaveNarrative upserts a materialized park_narratives row. It's exported
+// for cmd/5mp's "aggregate narratives" subcommand (see
+// cmd/5mp/narratives.go); the HTTP handlers only read this table, they
+// never write it, so every row's freshness reflects an actual offline
+// aggregation run rather than whatever request happened to compute it live.
+//
+// Expects a park_narratives table (kind, park_id, from_year, to_year,
+// payload, generated_at) with a unique index on (kind, park_id,
+// from_year, to_year) — see this request's migration alongside the rest
+// of db/migrations.
+func SaveNarrative(db *sql.DB, kind, parkID string, fromYear, toYear int, payload interface{}, generatedAt time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s narrative for %s: %w", kind, parkID, err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO park_narratives (kind, park_id, from_year, to_year, payload, generated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (kind, park_id, from_year, to_year)
+		DO UPDATE SET payload = excluded.payload, generated_at = excluded.generated_at
+	`, kind, parkID, fromYear, toYear, string(data), generatedAt)
+	return err
+}
+
+// writeNarrativeJSON encodes payload as the response body. When
+// generatedAt isn't zero (the response came from a materialized
+// park_narratives row rather than a live computation), it also sets
+// ETag/Last-Modified so a client can cheaply revalidate.
+func writeNarrativeJSON(w http.ResponseWriter, generatedAt time.Time, payload interface{}) {
+	if !generatedAt.IsZero() {
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, generatedAt.Unix()))
+		w.Header().Set("Last-Modified", generatedAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,135 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// wantsGeoJSON reports whether r asked a narrative endpoint for its
+// GeoJSON FeatureCollection representation instead of the default
+// textual JSON shape, via ?format=geojson or an Accept header.
+func wantsGeoJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "geojson" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/geo+json")
+}
+
+// writeNarrativeResponse serves payload as the usual narrative JSON, or,
+// when wantsGeoJSON(r), as a FeatureCollection built by toFeatureCollection
+// instead. generatedAt carries through to ETag/Last-Modified exactly like
+// writeNarrativeJSON — zero for a live computation, the materialized
+// row's timestamp for a park_narratives cache hit.
+func writeNarrativeResponse(w http.ResponseWriter, r *http.Request, generatedAt time.Time, payload interface{}, toFeatureCollection func() *geojson.FeatureCollection) {
+	if !wantsGeoJSON(r) {
+		writeNarrativeJSON(w, generatedAt, payload)
+		return
+	}
+
+	fc := toFeatureCollection()
+	if !generatedAt.IsZero() {
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, generatedAt.Unix()))
+		w.Header().Set("Last-Modified", generatedAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(fc)
+}
+
+// fireNarrativeFeatureCollection converts a FireNarrative into a
+// FeatureCollection: each hotspot is a Point, each fire group story
+// with known origin/destination coordinates is a LineString carrying
+// outcome/days_inside/fires_inside/narrative properties.
+func fireNarrativeFeatureCollection(n FireNarrative) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	fc.ExtraMembers = geojson.Properties{
+		"park_id":   n.ParkID,
+		"park_name": n.ParkName,
+		"year":      n.Year,
+		"summary":   n.Summary,
+	}
+
+	for _, h := range n.Hotspots {
+		f := geojson.NewFeature(orb.Point{h.Lon, h.Lat})
+		f.Properties["fire_count"] = h.FireCount
+		f.Properties["percentage"] = h.Percentage
+		f.Properties["description"] = h.Description
+		if h.SocioFactors != nil {
+			f.Properties["conflict_index"] = h.SocioFactors.Index
+		}
+		fc.Append(f)
+	}
+
+	for _, story := range n.Narratives {
+		if story.OriginLat == 0 && story.OriginLon == 0 && story.DestLat == 0 && story.DestLon == 0 {
+			continue
+		}
+		f := geojson.NewFeature(orb.LineString{
+			{story.OriginLon, story.OriginLat},
+			{story.DestLon, story.DestLat},
+		})
+		f.Properties["outcome"] = story.Outcome
+		f.Properties["days_inside"] = story.DaysInside
+		f.Properties["fires_inside"] = story.FiresInside
+		f.Properties["narrative"] = story.Narrative
+		fc.Append(f)
+	}
+
+	return fc
+}
+
+// deforestationNarrativeFeatureCollection converts a DeforestationNarrative
+// into a FeatureCollection, one Point per hotspot cluster.
+func deforestationNarrativeFeatureCollection(n DeforestationNarrative) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	fc.ExtraMembers = geojson.Properties{
+		"park_id":   n.ParkID,
+		"park_name": n.ParkName,
+		"summary":   n.Summary,
+	}
+
+	for _, h := range n.Hotspots {
+		f := geojson.NewFeature(orb.Point{h.Lon, h.Lat})
+		f.Properties["year"] = h.Year
+		f.Properties["area_km2"] = h.AreaKm2
+		f.Properties["pattern_type"] = h.PatternType
+		if h.DriverLabel != "" {
+			f.Properties["driver_label"] = h.DriverLabel
+			f.Properties["driver_confidence"] = h.DriverConfidence
+		}
+		if h.ForestClass != "" {
+			f.Properties["forest_class"] = h.ForestClass
+			f.Properties["stand_age_yr"] = h.StandAgeYr
+		}
+		f.Properties["description"] = h.Description
+		fc.Append(f)
+	}
+
+	return fc
+}
+
+// settlementNarrativeFeatureCollection converts a SettlementNarrative
+// into a FeatureCollection, one Point per settlement.
+func settlementNarrativeFeatureCollection(n SettlementNarrative) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	fc.ExtraMembers = geojson.Properties{
+		"park_id":   n.ParkID,
+		"park_name": n.ParkName,
+		"summary":   n.Summary,
+	}
+
+	for _, s := range n.LargestSettlements {
+		f := geojson.NewFeature(orb.Point{s.Lon, s.Lat})
+		f.Properties["name"] = s.Name
+		f.Properties["area_m2"] = s.AreaM2
+		f.Properties["direction"] = s.Direction
+		fc.Append(f)
+	}
+
+	return fc
+}
@@ -1,6 +1,7 @@
 package srv
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/paulmach/orb/geojson"
+
+	"srv.exe.dev/srv/geocluster"
+	"srv.exe.dev/srv/places"
+	"srv.exe.dev/srv/socio"
+	"srv.exe.dev/srv/statstrend"
 )
 
 // OSMPlace represents a place from the osm_places table
@@ -25,33 +33,49 @@ type OSMPlace struct {
 
 // FireNarrative contains rich textual description of fire movements
 type FireNarrative struct {
-	ParkID       string            `json:"park_id"`
-	ParkName     string            `json:"park_name"`
-	Year         int               `json:"year"`
-	Summary      string            `json:"summary"`
-	Narratives   []FireGroupStory  `json:"narratives"`
-	KeyPlaces    []OSMPlace        `json:"key_places"`
-	Hotspots     []FireHotspot     `json:"hotspots,omitempty"`
+	ParkID       string             `json:"park_id"`
+	ParkName     string             `json:"park_name"`
+	Year         int                `json:"year"`
+	Summary      string             `json:"summary"`
+	Narratives   []FireGroupStory   `json:"narratives"`
+	KeyPlaces    []OSMPlace         `json:"key_places"`
+	Hotspots     []FireHotspot      `json:"hotspots,omitempty"`
 	Trend        *FireTrendAnalysis `json:"trend,omitempty"`
-	ResponseRate float64           `json:"response_rate"`
-	TotalFires   int               `json:"total_fires"`
-	PeakMonth    string            `json:"peak_month,omitempty"`
+	ResponseRate float64            `json:"response_rate"`
+	TotalFires   int                `json:"total_fires"`
+	PeakMonth    string             `json:"peak_month,omitempty"`
+	Points       []ClusteredPoint   `json:"points,omitempty"`
+}
+
+// ClusteredPoint is one raw detection or event annotated with the
+// DBSCAN cluster label (see srv/geocluster) it was assigned — NoiseLabel
+// (-1) if it wasn't part of any hotspot. Only populated, via
+// ?include_points=1, so a caller that wants to color-code the
+// underlying points doesn't pay for them by default.
+type ClusteredPoint struct {
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Cluster int     `json:"cluster"`
 }
 
 // FireHotspot represents a geographic concentration of fire activity
 type FireHotspot struct {
-	Lat          float64  `json:"lat"`
-	Lon          float64  `json:"lon"`
-	FireCount    int      `json:"fire_count"`
-	Percentage   float64  `json:"percentage"`
-	Description  string   `json:"description"`
-	NearbyPlaces []string `json:"nearby_places"`
+	Lat          float64          `json:"lat"`
+	Lon          float64          `json:"lon"`
+	FireCount    int              `json:"fire_count"`
+	Percentage   float64          `json:"percentage"`
+	Description  string           `json:"description"`
+	NearbyPlaces []string         `json:"nearby_places"`
+	SocioFactors *socio.Breakdown `json:"socio_factors,omitempty"` // gridded socioeconomic pressure, see srv/socio
 }
 
 // FireTrendAnalysis provides multi-year trend information
 type FireTrendAnalysis struct {
 	Years           []FireYearSummary `json:"years"`
-	TrendDirection  string            `json:"trend_direction"` // increasing, decreasing, stable
+	TrendDirection  string            `json:"trend_direction"` // improving, worsening, stable, insufficient — see srv/statstrend
+	TrendPValue     float64           `json:"trend_p_value"`
+	TrendSenSlope   float64           `json:"trend_sen_slope_groups_per_year"`
+	WorstYearPct    float64           `json:"worst_year_percentile"`
 	AvgResponseRate float64           `json:"avg_response_rate"`
 	WorstYear       int               `json:"worst_year"`
 	WorstYearGroups int               `json:"worst_year_groups"`
@@ -62,20 +86,24 @@ type FireTrendAnalysis struct {
 
 // FireYearSummary provides per-year fire statistics
 type FireYearSummary struct {
-	Year            int     `json:"year"`
-	TotalGroups     int     `json:"total_groups"`
-	StoppedInside   int     `json:"stopped_inside"`
-	Transited       int     `json:"transited"`
-	ResponseRate    float64 `json:"response_rate"`
-	TotalFires      int     `json:"total_fires"`
-	AvgDaysBurning  float64 `json:"avg_days_burning"`
+	Year           int     `json:"year"`
+	TotalGroups    int     `json:"total_groups"`
+	StoppedInside  int     `json:"stopped_inside"`
+	Transited      int     `json:"transited"`
+	ResponseRate   float64 `json:"response_rate"`
+	TotalFires     int     `json:"total_fires"`
+	AvgDaysBurning float64 `json:"avg_days_burning"`
 }
 
 // FireGroupStory describes a single fire group's movement
 type FireGroupStory struct {
 	GroupNum      int      `json:"group_num"`
 	OriginDesc    string   `json:"origin_desc"`
+	OriginLat     float64  `json:"origin_lat"`
+	OriginLon     float64  `json:"origin_lon"`
 	DestDesc      string   `json:"dest_desc"`
+	DestLat       float64  `json:"dest_lat"`
+	DestLon       float64  `json:"dest_lon"`
 	EntryDate     string   `json:"entry_date"`
 	LastInside    string   `json:"last_inside"`
 	DaysInside    int      `json:"days_inside"`
@@ -88,52 +116,73 @@ type FireGroupStory struct {
 
 // DeforestationNarrative contains rich textual description of forest loss
 type DeforestationNarrative struct {
-	ParkID            string                    `json:"park_id"`
-	ParkName          string                    `json:"park_name"`
-	Summary           string                    `json:"summary"`
-	YearlyStory       []DeforestationYearStory  `json:"yearly_stories"`
-	TotalLoss         float64                   `json:"total_loss_km2"`
-	WorstYear         int                       `json:"worst_year"`
-	TrendDirection    string                    `json:"trend_direction"`       // "improving", "worsening", "stable"
-	TrendPercentChange float64                  `json:"trend_percent_change"`  // percentage change between periods
-	FiveYearAvgEarly  float64                   `json:"five_year_avg_early"`   // earliest 5-year average
-	FiveYearAvgRecent float64                   `json:"five_year_avg_recent"`  // most recent 5-year average
-	Hotspots          []DeforestationHotspot    `json:"hotspots,omitempty"`    // worst cluster hotspots
+	ParkID         string                   `json:"park_id"`
+	ParkName       string                   `json:"park_name"`
+	Summary        string                   `json:"summary"`
+	YearlyStory    []DeforestationYearStory `json:"yearly_stories"`
+	TotalLoss      float64                  `json:"total_loss_km2"`
+	WorstYear      int                      `json:"worst_year"`
+	WorstYearPct   float64                  `json:"worst_year_percentile"`        // worst year's percentile in the park's own empirical CDF of yearly loss
+	TrendDirection string                   `json:"trend_direction"`              // "improving", "worsening", "stable", "insufficient" — see srv/statstrend
+	TrendPValue    float64                  `json:"trend_p_value"`                // two-sided Mann-Kendall p-value
+	TrendSenSlope  float64                  `json:"trend_sen_slope_km2_per_year"` // Theil-Sen robust rate of change
+	Hotspots       []DeforestationHotspot   `json:"hotspots,omitempty"`           // worst cluster hotspots
+	Points         []ClusteredPoint         `json:"points,omitempty"`
+
+	// Primary vs. secondary/regrowth split, see forest_class.go. The
+	// slices above stay the combined view; these are filtered/resummed
+	// views over the same data by ForestClass.
+	TotalLossPrimary     float64                  `json:"total_loss_primary_km2"`
+	TotalLossSecondary   float64                  `json:"total_loss_secondary_km2"`
+	YearlyStoryPrimary   []DeforestationYearStory `json:"yearly_stories_primary"`
+	YearlyStorySecondary []DeforestationYearStory `json:"yearly_stories_secondary"`
+	HotspotsPrimary      []DeforestationHotspot   `json:"hotspots_primary,omitempty"`
+	HotspotsSecondary    []DeforestationHotspot   `json:"hotspots_secondary,omitempty"`
+	RegimeChange         string                   `json:"regime_change,omitempty"` // flags a primary→secondary transition, see detectRegimeChange
 }
 
 // DeforestationYearStory describes forest loss for a single year
 type DeforestationYearStory struct {
-	Year         int      `json:"year"`
-	AreaKm2      float64  `json:"area_km2"`
-	PatternType  string   `json:"pattern_type"`
-	Narrative    string   `json:"narrative"`
-	NearbyPlaces []string `json:"nearby_places"`
+	Year             int      `json:"year"`
+	AreaKm2          float64  `json:"area_km2"`
+	PatternType      string   `json:"pattern_type"`
+	DriverLabel      string   `json:"driver_label,omitempty"`      // likely human driver, see srv/drivers
+	DriverConfidence float64  `json:"driver_confidence,omitempty"` // classifier's training-set confidence for DriverLabel
+	ForestClass      string   `json:"forest_class,omitempty"`      // primary|secondary, see forest_class.go
+	StandAgeYr       int      `json:"stand_age_yr,omitempty"`      // years of regrowth before this loss, if ForestClass is secondary
+	Narrative        string   `json:"narrative"`
+	NearbyPlaces     []string `json:"nearby_places"`
 }
 
 // DeforestationHotspot describes a significant cluster of deforestation
 type DeforestationHotspot struct {
-	Year        int     `json:"year"`
-	ClusterID   int     `json:"cluster_id"`
-	AreaKm2     float64 `json:"area_km2"`
-	Lat         float64 `json:"lat"`
-	Lon         float64 `json:"lon"`
-	PatternType string  `json:"pattern_type"`
-	Description string  `json:"description"`
+	Year             int     `json:"year"`
+	ClusterID        int     `json:"cluster_id"`
+	AreaKm2          float64 `json:"area_km2"`
+	Lat              float64 `json:"lat"`
+	Lon              float64 `json:"lon"`
+	PatternType      string  `json:"pattern_type"`
+	DriverLabel      string  `json:"driver_label,omitempty"`      // likely human driver, see srv/drivers
+	DriverConfidence float64 `json:"driver_confidence,omitempty"` // classifier's training-set confidence for DriverLabel
+	ForestClass      string  `json:"forest_class,omitempty"`      // primary|secondary, see forest_class.go
+	StandAgeYr       int     `json:"stand_age_yr,omitempty"`      // years of regrowth before this loss, if ForestClass is secondary
+	Description      string  `json:"description"`
 }
 
 // SettlementNarrative contains description of settlements and human-wildlife interface
 type SettlementNarrative struct {
-	ParkID              string               `json:"park_id"`
-	ParkName            string               `json:"park_name"`
-	Summary             string               `json:"summary"`
-	Status              string               `json:"status"`
-	SettlementCount     int                  `json:"settlement_count"`
-	TotalPopulation     int64                `json:"total_population"`
-	PopulationDensity   float64              `json:"population_density_per_km2"`
-	ParkAreaKm2         float64              `json:"park_area_km2"`
-	ConflictRisk        string               `json:"conflict_risk"`
-	LargestSettlements  []SettlementDetail   `json:"largest_settlements"`
-	RegionalBreakdown   []RegionSettlement   `json:"regional_breakdown,omitempty"`
+	ParkID             string             `json:"park_id"`
+	ParkName           string             `json:"park_name"`
+	Summary            string             `json:"summary"`
+	Status             string             `json:"status"`
+	SettlementCount    int                `json:"settlement_count"`
+	TotalPopulation    int64              `json:"total_population"`
+	PopulationDensity  float64            `json:"population_density_per_km2"`
+	ParkAreaKm2        float64            `json:"park_area_km2"`
+	ConflictRisk       string             `json:"conflict_risk"`
+	ConflictFactors    *socio.Breakdown   `json:"conflict_factors,omitempty"` // per-factor radar-chart breakdown, see srv/socio
+	LargestSettlements []SettlementDetail `json:"largest_settlements"`
+	RegionalBreakdown  []RegionSettlement `json:"regional_breakdown,omitempty"`
 }
 
 // SettlementDetail describes a single settlement
@@ -148,9 +197,9 @@ type SettlementDetail struct {
 
 // RegionSettlement groups settlements by geographic region within the park
 type RegionSettlement struct {
-	Region         string `json:"region"`
-	SettlementCount int   `json:"settlement_count"`
-	Population     int64  `json:"population"`
+	Region          string `json:"region"`
+	SettlementCount int    `json:"settlement_count"`
+	Population      int64  `json:"population"`
 }
 
 // haversineDistance calculates distance between two lat/lon points in km
@@ -204,74 +253,46 @@ func bearingToCardinalWithDegrees(bearing float64) string {
 func formatPlaceWithDirection(placeName, placeType string, distKm, refLat, refLon, placeLat, placeLon float64) string {
 	bearing := bearingTo(refLat, refLon, placeLat, placeLon)
 	direction := bearingToCardinal(bearing)
-	
+
 	if placeType == "river" || placeType == "stream" {
 		return fmt.Sprintf("%.0fkm %s of %s", distKm, direction, placeName)
 	}
 	return fmt.Sprintf("%.0fkm %s of %s", distKm, direction, placeName)
 }
 
-// findNearestPlaces finds the nearest OSM places to a given coordinate
+// findNearestPlaces finds the nearest OSM places to a given coordinate,
+// via s.PlaceIndex (see srv/places — a SQLite bounding-box scan by
+// default, or PostGIS's ST_DWithin/ST_Distance if configured).
 func (s *Server) findNearestPlaces(parkID string, lat, lon float64, limit int, placeTypes []string) ([]OSMPlace, error) {
-	var places []OSMPlace
-	
-	// Build query - search within park and nearby (expand search area)
-	query := `
-		SELECT id, park_id, place_type, name, lat, lon
-		FROM osm_places
-		WHERE park_id = ?
-		  AND lat BETWEEN ? AND ?
-		  AND lon BETWEEN ? AND ?
-	`
-	args := []interface{}{parkID, lat - 1.0, lat + 1.0, lon - 1.0, lon + 1.0}
-	
-	if len(placeTypes) > 0 {
-		placeholders := make([]string, len(placeTypes))
-		for i := range placeTypes {
-			placeholders[i] = "?"
-			args = append(args, placeTypes[i])
-		}
-		query += " AND place_type IN (" + strings.Join(placeholders, ",") + ")"
-	}
-	
-	rows, err := s.DB.Query(query, args...)
+	found, err := s.PlaceIndex.Nearest(context.Background(), parkID, lat, lon, places.DefaultRadiusKm, limit, placeTypes)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	
-	for rows.Next() {
-		var p OSMPlace
-		if err := rows.Scan(&p.ID, &p.ParkID, &p.PlaceType, &p.Name, &p.Lat, &p.Lon); err != nil {
-			continue
+	result := make([]OSMPlace, len(found))
+	for i, p := range found {
+		result[i] = OSMPlace{
+			ID:        p.ID,
+			ParkID:    p.ParkID,
+			PlaceType: p.PlaceType,
+			Name:      p.Name,
+			Lat:       p.Lat,
+			Lon:       p.Lon,
+			Distance:  p.DistanceKm,
 		}
-		p.Distance = haversineDistance(lat, lon, p.Lat, p.Lon)
-		places = append(places, p)
 	}
-	
-	// Sort by distance
-	sort.Slice(places, func(i, j int) bool {
-		return places[i].Distance < places[j].Distance
-	})
-	
-	// Limit results
-	if len(places) > limit {
-		places = places[:limit]
-	}
-	
-	return places, nil
+	return result, nil
 }
 
 // describeLocation returns a human-readable description of a location
 func (s *Server) describeLocation(parkID string, lat, lon float64) string {
 	// Find nearest settlement
 	settlements, _ := s.findNearestPlaces(parkID, lat, lon, 1, []string{"village", "hamlet", "town", "city"})
-	
+
 	// Find nearest river
 	rivers, _ := s.findNearestPlaces(parkID, lat, lon, 1, []string{"river", "stream"})
-	
+
 	var parts []string
-	
+
 	if len(settlements) > 0 && settlements[0].Distance < 30 {
 		p := settlements[0]
 		if p.Distance < 5 {
@@ -283,7 +304,7 @@ func (s *Server) describeLocation(parkID string, lat, lon float64) string {
 			parts = append(parts, fmt.Sprintf("%.0f km %s of %s", p.Distance, direction, p.Name))
 		}
 	}
-	
+
 	if len(rivers) > 0 && rivers[0].Distance < 20 {
 		p := rivers[0]
 		if p.Distance < 3 {
@@ -294,11 +315,26 @@ func (s *Server) describeLocation(parkID string, lat, lon float64) string {
 			parts = append(parts, fmt.Sprintf("%.0f km %s of the %s", p.Distance, direction, p.Name))
 		}
 	}
-	
+
 	if len(parts) == 0 {
+		// Nothing within the tight 30km/20km cutoffs above — rather than
+		// fall through to raw coordinates, fall back to whichever named
+		// place findNearestPlaces did return (s.PlaceIndex already
+		// searches out to places.DefaultRadiusKm), however far it is.
+		// PlaceMatcher's fuzzy text search doesn't apply here since
+		// there's no free-text query at this callsite, only lat/lon; it
+		// backs the /api/places/search endpoint and resolveParkID instead.
+		if len(settlements) > 0 {
+			p := settlements[0]
+			return fmt.Sprintf("a remote area, %.0f km from %s", p.Distance, p.Name)
+		}
+		if len(rivers) > 0 {
+			p := rivers[0]
+			return fmt.Sprintf("a remote area, %.0f km from the %s", p.Distance, p.Name)
+		}
 		return fmt.Sprintf("at coordinates (%.3f°, %.3f°)", lat, lon)
 	}
-	
+
 	return strings.Join(parts, ", ")
 }
 
@@ -310,28 +346,22 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
-	
-	// Map WDPA ID to internal park_id if needed
-	internalID := parkID
-	parkName := parkID
-	if s.AreaStore != nil {
-		for _, area := range s.AreaStore.Areas {
-			if area.WDPAID == parkID || area.ID == parkID {
-				internalID = area.ID
-				parkName = area.Name
-				break
-			}
-		}
-	}
-	
+
+	internalID, parkName := s.resolveParkID(parkID)
+
 	// Parse time filter parameters - support multi-year ranges
 	yearStr := r.URL.Query().Get("year")
 	fromStr := r.URL.Query().Get("from")
 	toStr := r.URL.Query().Get("to")
-	
+
+	parserName, parseDate, ok := s.resolveDateParser(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown date_parser %q", parserName), http.StatusBadRequest)
+		return
+	}
+
 	var fromYear, toYear int
-	now := time.Now()
-	
+
 	if yearStr != "" {
 		if y, err := strconv.Atoi(yearStr); err == nil {
 			fromYear = y
@@ -339,25 +369,42 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		}
 	} else {
 		// Default: all available years
-		fromYear = 2000
-		toYear = now.Year()
+		fromYear, toYear = DefaultFireNarrativeYearRange()
 		if fromStr != "" {
-			if t, err := time.Parse("2006-01-02", fromStr); err == nil {
-				fromYear = t.Year()
+			t, err := parseDate(fromStr)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid from=%q for date_parser %q: %v", fromStr, parserName, err), http.StatusBadRequest)
+				return
 			}
+			fromYear = t.Year()
 		}
 		if toStr != "" {
-			if t, err := time.Parse("2006-01-02", toStr); err == nil {
-				toYear = t.Year()
+			t, err := parseDate(toStr)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid to=%q for date_parser %q: %v", toStr, parserName, err), http.StatusBadRequest)
+				return
 			}
+			toYear = t.Year()
+		}
+	}
+
+	epsKm, minPts, includePoints, usingDefaultClusterParams := s.resolveClusterParams(r, 3.0, 5)
+
+	if r.URL.Query().Get("refresh") != "1" && usingDefaultClusterParams {
+		var cached FireNarrative
+		if generatedAt, ok := s.loadCachedNarrative("fire", internalID, fromYear, toYear, &cached); ok {
+			writeNarrativeResponse(w, r, generatedAt, cached, func() *geojson.FeatureCollection {
+				return fireNarrativeFeatureCollection(cached)
+			})
+			return
 		}
 	}
-	
+
 	// Get aggregated fire data across year range
 	var totalGroups, stoppedInside, transited int
 	var avgDaysBurning float64
 	var yearCount int
-	
+
 	err := s.DB.QueryRow(`
 		SELECT 
 			COUNT(DISTINCT year) as year_count,
@@ -368,39 +415,40 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		FROM park_group_infractions 
 		WHERE park_id = ? AND year >= ? AND year <= ? AND total_groups > 0
 	`, internalID, fromYear, toYear).Scan(&yearCount, &totalGroups, &stoppedInside, &transited, &avgDaysBurning)
-	
+
 	// Use toYear as the "display year" for single-year or latest in range
 	displayYear := toYear
 	if fromYear == toYear {
 		displayYear = fromYear
 	}
-	
+
 	narrative := FireNarrative{
 		ParkID:   internalID,
 		ParkName: parkName,
 		Year:     displayYear,
 	}
-	
+
 	if err == sql.ErrNoRows || totalGroups == 0 {
 		periodDesc := fmt.Sprintf("%d", fromYear)
 		if fromYear != toYear {
 			periodDesc = fmt.Sprintf("%d-%d", fromYear, toYear)
 		}
 		narrative.Summary = fmt.Sprintf("No significant fire group incursions recorded for %s in %s.", parkName, periodDesc)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(narrative)
+		writeNarrativeResponse(w, r, time.Time{}, narrative, func() *geojson.FeatureCollection {
+			return fireNarrativeFeatureCollection(narrative)
+		})
 		return
 	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	
+
 	// Calculate response rate
 	if totalGroups > 0 {
 		narrative.ResponseRate = float64(stoppedInside) / float64(totalGroups) * 100
 	}
-	
+
 	// Get total fire count for the year range
 	var totalFires int
 	s.DB.QueryRow(`
@@ -410,7 +458,7 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		  AND CAST(strftime('%Y', acq_date) AS INTEGER) <= ?
 	`, internalID, fromYear, toYear).Scan(&totalFires)
 	narrative.TotalFires = totalFires
-	
+
 	// Get peak month across the range
 	var peakMonth string
 	var peakCount int
@@ -428,7 +476,7 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		"09": "September", "10": "October", "11": "November", "12": "December",
 	}
 	narrative.PeakMonth = monthNames[peakMonth]
-	
+
 	// Build enhanced summary
 	var summaryParts []string
 	periodDesc := fmt.Sprintf("%d", fromYear)
@@ -443,7 +491,7 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 			periodDesc, parkName, totalFires, totalGroups))
 	}
 	if stoppedInside > 0 {
-		summaryParts = append(summaryParts, fmt.Sprintf("%d group(s) (%.0f%%) were stopped inside the park, suggesting effective ranger intervention.", 
+		summaryParts = append(summaryParts, fmt.Sprintf("%d group(s) (%.0f%%) were stopped inside the park, suggesting effective ranger intervention.",
 			stoppedInside, narrative.ResponseRate))
 	}
 	if transited > 0 {
@@ -456,7 +504,7 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		summaryParts = append(summaryParts, fmt.Sprintf("Fire groups burned inside the park for an average of %.1f days.", avgDaysBurning))
 	}
 	narrative.Summary = strings.Join(summaryParts, " ")
-	
+
 	// Query trajectories from the most recent year in range for detailed stories
 	var trajJSON sql.NullString
 	s.DB.QueryRow(`
@@ -464,7 +512,7 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 		WHERE park_id = ? AND year >= ? AND year <= ? AND trajectories_json IS NOT NULL
 		ORDER BY year DESC LIMIT 1
 	`, internalID, fromYear, toYear).Scan(&trajJSON)
-	
+
 	// Parse trajectories and build detailed stories
 	if trajJSON.Valid && trajJSON.String != "" {
 		var trajs []FireGroupTrajectory
@@ -472,20 +520,24 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 			for i, t := range trajs {
 				story := FireGroupStory{
 					GroupNum:    i + 1,
+					OriginLat:   t.Origin.Lat,
+					OriginLon:   t.Origin.Lon,
+					DestLat:     t.Destination.Lat,
+					DestLon:     t.Destination.Lon,
 					EntryDate:   t.EntryDate,
 					LastInside:  t.LastInside,
 					DaysInside:  t.DaysInside,
 					FiresInside: t.FiresInside,
 					Outcome:     t.Outcome,
 				}
-				
+
 				// Calculate trajectory bearing (azimuth) from origin to destination
 				trajBearing := bearingTo(t.Origin.Lat, t.Origin.Lon, t.Destination.Lat, t.Destination.Lon)
 				movementDesc := fmt.Sprintf("moving %s", bearingToCardinalWithDegrees(trajBearing))
-				
+
 				// Describe origin location
 				story.OriginDesc = s.describeLocation(internalID, t.Origin.Lat, t.Origin.Lon)
-				
+
 				// If no nearby place found, include coordinates with movement direction
 				if strings.HasPrefix(story.OriginDesc, "at coordinates") {
 					story.OriginDesc = fmt.Sprintf("(%.3f°, %.3f°), %s",
@@ -494,26 +546,26 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 					// Add movement direction to location description
 					story.OriginDesc = fmt.Sprintf("%s, %s", story.OriginDesc, movementDesc)
 				}
-				
+
 				// Describe destination location
 				story.DestDesc = s.describeLocation(internalID, t.Destination.Lat, t.Destination.Lon)
-				
+
 				// Find rivers that might have been crossed
-				rivers, _ := s.findNearestPlaces(internalID, 
-					(t.Origin.Lat+t.Destination.Lat)/2, 
-					(t.Origin.Lon+t.Destination.Lon)/2, 
+				rivers, _ := s.findNearestPlaces(internalID,
+					(t.Origin.Lat+t.Destination.Lat)/2,
+					(t.Origin.Lon+t.Destination.Lon)/2,
 					3, []string{"river"})
 				for _, r := range rivers {
 					if r.Distance < 15 {
 						story.RiversCrossed = append(story.RiversCrossed, r.Name)
 					}
 				}
-				
+
 				// Build narrative text
 				var narr strings.Builder
-				narr.WriteString(fmt.Sprintf("Fire group %d originated %s on %s. ", 
+				narr.WriteString(fmt.Sprintf("Fire group %d originated %s on %s. ",
 					i+1, story.OriginDesc, t.EntryDate))
-				
+
 				if len(story.RiversCrossed) > 0 {
 					unique := uniqueStrings(story.RiversCrossed)
 					if len(unique) == 1 {
@@ -522,37 +574,37 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 						narr.WriteString(fmt.Sprintf("The group crossed near the %s. ", strings.Join(unique, " and ")))
 					}
 				}
-				
+
 				daysWord := "days"
 				if t.DaysInside == 1 {
 					daysWord = "day"
 				}
-				narr.WriteString(fmt.Sprintf("Burned inside the park for %d %s (%d fire detections). ", 
+				narr.WriteString(fmt.Sprintf("Burned inside the park for %d %s (%d fire detections). ",
 					t.DaysInside, daysWord, t.FiresInside))
-				
+
 				switch t.Outcome {
 				case "STOPPED_INSIDE":
-					narr.WriteString(fmt.Sprintf("Last detected %s - fire stopped, possibly due to ranger intervention.", 
+					narr.WriteString(fmt.Sprintf("Last detected %s - fire stopped, possibly due to ranger intervention.",
 						story.DestDesc))
 				case "TRANSITED":
-					narr.WriteString(fmt.Sprintf("Exited the park %s on %s - transited without being stopped.", 
+					narr.WriteString(fmt.Sprintf("Exited the park %s on %s - transited without being stopped.",
 						story.DestDesc, t.LastInside))
 				default:
 					narr.WriteString(fmt.Sprintf("Last detected %s.", story.DestDesc))
 				}
-				
+
 				story.Narrative = narr.String()
 				narrative.Narratives = append(narrative.Narratives, story)
 			}
 		}
 	}
-	
+
 	// Generate hotspot analysis from fire_detections (works without trajectory JSON)
-	narrative.Hotspots = s.analyzeFireHotspots(internalID, displayYear, totalFires)
-	
+	narrative.Hotspots, narrative.Points = s.analyzeFireHotspots(internalID, displayYear, totalFires, epsKm, minPts, includePoints)
+
 	// Generate multi-year trend analysis
 	narrative.Trend = s.analyzeFireTrend(internalID, displayYear)
-	
+
 	// If no trajectory-based narratives, generate hotspot-based narratives
 	if len(narrative.Narratives) == 0 && len(narrative.Hotspots) > 0 {
 		for i, hs := range narrative.Hotspots {
@@ -560,16 +612,16 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 				break
 			}
 			story := FireGroupStory{
-				GroupNum:    i + 1,
-				FiresInside: hs.FireCount,
-				Outcome:     "HOTSPOT",
-				Narrative:   hs.Description,
+				GroupNum:     i + 1,
+				FiresInside:  hs.FireCount,
+				Outcome:      "HOTSPOT",
+				Narrative:    hs.Description,
 				NearbyPlaces: hs.NearbyPlaces,
 			}
 			narrative.Narratives = append(narrative.Narratives, story)
 		}
 	}
-	
+
 	// Get key places in the park for context
 	keyPlaces, _ := s.findNearestPlaces(internalID, 0, 0, 0, nil)
 	if len(keyPlaces) == 0 {
@@ -587,9 +639,10 @@ func (s *Server) HandleAPIFireNarrative(w http.ResponseWriter, r *http.Request)
 			}
 		}
 	}
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(narrative)
+
+	writeNarrativeResponse(w, r, time.Time{}, narrative, func() *geojson.FeatureCollection {
+		return fireNarrativeFeatureCollection(narrative)
+	})
 }
 
 // HandleAPIDeforestationNarrative returns rich textual description of forest loss
@@ -600,25 +653,20 @@ func (s *Server) HandleAPIDeforestationNarrative(w http.ResponseWriter, r *http.
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
-	
-	// Map WDPA ID to internal park_id if needed
-	internalID := parkID
-	parkName := parkID
-	if s.AreaStore != nil {
-		for _, area := range s.AreaStore.Areas {
-			if area.WDPAID == parkID || area.ID == parkID {
-				internalID = area.ID
-				parkName = area.Name
-				break
-			}
-		}
-	}
-	
+
+	internalID, parkName := s.resolveParkID(parkID)
+
 	// Parse time filter parameters
 	yearStr := r.URL.Query().Get("year")
 	fromStr := r.URL.Query().Get("from")
 	toStr := r.URL.Query().Get("to")
-	
+
+	parserName, parseDate, ok := s.resolveDateParser(r)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown date_parser %q", parserName), http.StatusBadRequest)
+		return
+	}
+
 	var fromYear, toYear int
 	if yearStr != "" {
 		if y, err := strconv.Atoi(yearStr); err == nil {
@@ -627,25 +675,42 @@ func (s *Server) HandleAPIDeforestationNarrative(w http.ResponseWriter, r *http.
 		}
 	} else {
 		// Default to all years if no filter
-		fromYear = 1900
-		toYear = 2100
+		fromYear, toYear = DefaultDeforestationNarrativeYearRange()
 		if fromStr != "" {
-			if t, err := time.Parse("2006-01-02", fromStr); err == nil {
-				fromYear = t.Year()
+			t, err := parseDate(fromStr)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid from=%q for date_parser %q: %v", fromStr, parserName, err), http.StatusBadRequest)
+				return
 			}
+			fromYear = t.Year()
 		}
 		if toStr != "" {
-			if t, err := time.Parse("2006-01-02", toStr); err == nil {
-				toYear = t.Year()
+			t, err := parseDate(toStr)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid to=%q for date_parser %q: %v", toStr, parserName, err), http.StatusBadRequest)
+				return
 			}
+			toYear = t.Year()
 		}
 	}
-	
+
+	epsKm, minPts, includePoints, usingDefaultClusterParams := s.resolveClusterParams(r, 1.0, 3)
+
+	if r.URL.Query().Get("refresh") != "1" && usingDefaultClusterParams {
+		var cached DeforestationNarrative
+		if generatedAt, ok := s.loadCachedNarrative("deforestation", internalID, fromYear, toYear, &cached); ok {
+			writeNarrativeResponse(w, r, generatedAt, cached, func() *geojson.FeatureCollection {
+				return deforestationNarrativeFeatureCollection(cached)
+			})
+			return
+		}
+	}
+
 	narrative := DeforestationNarrative{
 		ParkID:   internalID,
 		ParkName: parkName,
 	}
-	
+
 	// Query deforestation events with time filter
 	rows, err := s.DB.Query(`
 		SELECT year, area_km2, pattern_type, lat, lon, description
@@ -658,7 +723,7 @@ func (s *Server) HandleAPIDeforestationNarrative(w http.ResponseWriter, r *http.
 		return
 	}
 	defer rows.Close()
-	
+
 	var totalLoss float64
 	var worstYear int
 	var worstLoss float64
@@ -666,42 +731,67 @@ func (s *Server) HandleAPIDeforestationNarrative(w http.ResponseWriter, r *http.
 		year int
 		area float64
 	}
-	
+	var totalLossPrimary, totalLossSecondary float64
+	var classOrder []string
+	var classAreas []float64
+
 	for rows.Next() {
 		var year int
 		var area float64
 		var patternType sql.NullString
 		var lat, lon float64
 		var description sql.NullString
-		
+
 		if err := rows.Scan(&year, &area, &patternType, &lat, &lon, &description); err != nil {
 			continue
 		}
-		
+
 		yearlyAreas = append(yearlyAreas, struct {
 			year int
 			area float64
 		}{year, area})
-		
+
 		totalLoss += area
 		if area > worstLoss {
 			worstLoss = area
 			worstYear = year
 		}
-		
+
 		// Determine actual pattern type from cluster data for this year
 		actualPattern := s.determinePatternType(internalID, year, patternType.String)
-		
+
+		// Classify the likely human driver behind this year's loss (see
+		// srv/drivers) and persist it back onto deforestation_clusters
+		// the same way determinePatternType reads pattern_type from it.
+		driverLabel, driverConfidence := s.classifyCluster(internalID, lat, lon, area, aspectRatioFromPattern(actualPattern), year)
+		s.persistClusterDriver(internalID, year, driverLabel, driverConfidence)
+
+		// Apply the cumulative prior-loss mask (see forest_class.go) to
+		// tell primary forest loss apart from secondary/regrowth loss.
+		forestClass, standAgeYr := s.classifyForestClass(internalID, lat, lon, year)
+		s.persistForestClass(internalID, year, forestClass, standAgeYr)
+		classOrder = append(classOrder, forestClass)
+		classAreas = append(classAreas, area)
+		if forestClass == ForestClassSecondary {
+			totalLossSecondary += area
+		} else {
+			totalLossPrimary += area
+		}
+
 		story := DeforestationYearStory{
-			Year:        year,
-			AreaKm2:     area,
-			PatternType: actualPattern,
+			Year:             year,
+			AreaKm2:          area,
+			PatternType:      actualPattern,
+			DriverLabel:      driverLabel,
+			DriverConfidence: driverConfidence,
+			ForestClass:      forestClass,
+			StandAgeYr:       standAgeYr,
 		}
-		
+
 		// Find nearby places for context (settlements and rivers)
 		settlements, _ := s.findNearestPlaces(internalID, lat, lon, 3, []string{"village", "hamlet", "town", "city"})
 		rivers, _ := s.findNearestPlaces(internalID, lat, lon, 3, []string{"river", "stream"})
-		
+
 		seen := make(map[string]bool)
 		for _, p := range settlements {
 			key := p.Name
@@ -719,43 +809,157 @@ func (s *Server) HandleAPIDeforestationNarrative(w http.ResponseWriter, r *http.
 				story.NearbyPlaces = append(story.NearbyPlaces, desc)
 			}
 		}
-		
+
 		// Build narrative with varied pattern description
 		locationDesc := s.describeLocation(internalID, lat, lon)
 		patternDesc := describePatternVaried(actualPattern, area, year)
-		
+
 		story.Narrative = fmt.Sprintf("In %d, %.2f km² of forest was lost %s. %s",
 			year, area, locationDesc, patternDesc)
-		
+		if driverLabel != "" {
+			story.Narrative += fmt.Sprintf(" The likely driver is %s (%.0f%% confidence).",
+				describeDriver(driverLabel), driverConfidence*100)
+		}
+		if forestClass == ForestClassSecondary {
+			story.Narrative += fmt.Sprintf(" This is secondary/regrowth loss — the same ground was cleared %d year(s) earlier.", standAgeYr)
+		}
+
 		narrative.YearlyStory = append(narrative.YearlyStory, story)
 	}
-	
+
 	// Reverse to show most recent first
 	for i, j := 0, len(narrative.YearlyStory)-1; i < j; i, j = i+1, j-1 {
 		narrative.YearlyStory[i], narrative.YearlyStory[j] = narrative.YearlyStory[j], narrative.YearlyStory[i]
 	}
-	
+
+	for _, story := range narrative.YearlyStory {
+		if story.ForestClass == ForestClassSecondary {
+			narrative.YearlyStorySecondary = append(narrative.YearlyStorySecondary, story)
+		} else {
+			narrative.YearlyStoryPrimary = append(narrative.YearlyStoryPrimary, story)
+		}
+	}
+
 	narrative.TotalLoss = totalLoss
+	narrative.TotalLossPrimary = totalLossPrimary
+	narrative.TotalLossSecondary = totalLossSecondary
 	narrative.WorstYear = worstYear
-	
-	// Calculate 5-year rolling average trend
-	narrative.TrendDirection, narrative.TrendPercentChange, 
-		narrative.FiveYearAvgEarly, narrative.FiveYearAvgRecent = calculateTrend(yearlyAreas)
-	
-	// Fetch worst hotspots from clusters table
-	narrative.Hotspots = s.fetchHotspots(internalID, 5)
-	
+
+	if changed, desc := detectRegimeChange(classOrder, classAreas); changed {
+		narrative.RegimeChange = desc
+	}
+
+	// Mann-Kendall trend test + Theil-Sen slope (see srv/statstrend) in
+	// place of a fixed 5-year rolling average, which misfires on short
+	// or noisy series.
+	obs := make([]statstrend.Observation, len(yearlyAreas))
+	for i, ya := range yearlyAreas {
+		obs[i] = statstrend.Observation{Year: ya.year, Value: ya.area}
+	}
+	trend := statstrend.Analyze(obs, true, statstrend.DefaultAlpha)
+	narrative.TrendDirection = trend.Direction
+	narrative.TrendPValue = trend.PValue
+	narrative.TrendSenSlope = trend.SenSlope
+	narrative.WorstYearPct = trend.WorstYearPercentile
+
+	// Cluster deforestation events into hotspots via DBSCAN
+	narrative.Hotspots, narrative.Points = s.fetchHotspots(internalID, fromYear, toYear, 5, epsKm, minPts, includePoints)
+	for _, h := range narrative.Hotspots {
+		if h.ForestClass == ForestClassSecondary {
+			narrative.HotspotsSecondary = append(narrative.HotspotsSecondary, h)
+		} else {
+			narrative.HotspotsPrimary = append(narrative.HotspotsPrimary, h)
+		}
+	}
+
 	// Build summary with trend information
 	if totalLoss == 0 {
 		narrative.Summary = fmt.Sprintf("No significant deforestation events recorded for %s.", parkName)
 	} else {
-		trendDesc := describeTrend(narrative.TrendDirection, narrative.TrendPercentChange)
-		narrative.Summary = fmt.Sprintf("%s has experienced %.2f km² of forest loss across %d recorded years. The worst year was %d with %.2f km² lost. %s",
-			parkName, totalLoss, len(narrative.YearlyStory), worstYear, worstLoss, trendDesc)
+		trendDesc := describeTrend(trend)
+		narrative.Summary = fmt.Sprintf("%s has experienced %.2f km² of forest loss across %d recorded years. The worst year was %d with %.2f km² lost (%.0fth percentile of the park's history). %s",
+			parkName, totalLoss, len(narrative.YearlyStory), worstYear, worstLoss, trend.WorstYearPercentile, trendDesc)
+		if narrative.RegimeChange != "" {
+			narrative.Summary += " " + narrative.RegimeChange
+		}
+	}
+
+	writeNarrativeResponse(w, r, time.Time{}, narrative, func() *geojson.FeatureCollection {
+		return deforestationNarrativeFeatureCollection(narrative)
+	})
+}
+
+// DeforestationDriverMix is the per-park response of
+// HandleAPIDeforestationDrivers: the mix of classified deforestation
+// drivers (see srv/drivers), one entry per year that has any.
+type DeforestationDriverMix struct {
+	ParkID   string                    `json:"park_id"`
+	ParkName string                    `json:"park_name"`
+	Years    []DeforestationDriverYear `json:"years"`
+}
+
+// DeforestationDriverYear is one year's driver mix.
+type DeforestationDriverYear struct {
+	Year int              `json:"year"`
+	Mix  []DriverMixEntry `json:"mix"`
+}
+
+// DriverMixEntry is one driver label's share of a year's classified
+// clusters.
+type DriverMixEntry struct {
+	Driver       string  `json:"driver"`
+	ClusterCount int     `json:"cluster_count"`
+	AreaKm2      float64 `json:"area_km2"`
+}
+
+// HandleAPIDeforestationDrivers returns parkID's mix of classified
+// deforestation drivers by year, read back from the driver_label
+// column HandleAPIDeforestationNarrative and fetchHotspots populate on
+// deforestation_clusters (see srv/drivers and driver_classifier.go).
+// GET /api/parks/{id}/deforestation-drivers
+func (s *Server) HandleAPIDeforestationDrivers(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	if parkID == "" {
+		http.Error(w, "Park ID required", http.StatusBadRequest)
+		return
+	}
+	internalID, parkName := s.resolveParkID(parkID)
+
+	rows, err := s.DB.Query(`
+		SELECT year, driver_label, COUNT(*), COALESCE(SUM(area_km2), 0)
+		FROM deforestation_clusters
+		WHERE park_id = ? AND driver_label IS NOT NULL AND driver_label != ''
+		GROUP BY year, driver_label
+		ORDER BY year, driver_label
+	`, internalID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	
+	defer rows.Close()
+
+	byYear := make(map[int][]DriverMixEntry)
+	var yearOrder []int
+	for rows.Next() {
+		var year, count int
+		var label string
+		var area float64
+		if err := rows.Scan(&year, &label, &count, &area); err != nil {
+			continue
+		}
+		if _, seen := byYear[year]; !seen {
+			yearOrder = append(yearOrder, year)
+		}
+		byYear[year] = append(byYear[year], DriverMixEntry{Driver: label, ClusterCount: count, AreaKm2: area})
+	}
+
+	result := DeforestationDriverMix{ParkID: internalID, ParkName: parkName}
+	for _, year := range yearOrder {
+		result.Years = append(result.Years, DeforestationDriverYear{Year: year, Mix: byYear[year]})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(narrative)
+	json.NewEncoder(w).Encode(result)
 }
 
 // HandleAPISettlementNarrative returns comprehensive narrative about settlements and human-wildlife interface
@@ -766,7 +970,7 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
-	
+
 	// Map WDPA ID to internal park_id if needed
 	internalID := parkID
 	parkName := parkID
@@ -781,13 +985,23 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 			}
 		}
 	}
-	
+
+	if r.URL.Query().Get("refresh") != "1" {
+		var cached SettlementNarrative
+		if generatedAt, ok := s.loadCachedNarrative("settlement", internalID, 0, 0, &cached); ok {
+			writeNarrativeResponse(w, r, generatedAt, cached, func() *geojson.FeatureCollection {
+				return settlementNarrativeFeatureCollection(cached)
+			})
+			return
+		}
+	}
+
 	narrative := SettlementNarrative{
 		ParkID:      internalID,
 		ParkName:    parkName,
 		ParkAreaKm2: parkAreaKm2,
 	}
-	
+
 	// Get settlement statistics from park_settlements table
 	var settlementCount int
 	var totalPopulation sql.NullFloat64
@@ -796,36 +1010,38 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 		FROM park_settlements
 		WHERE park_id = ?
 	`, internalID).Scan(&settlementCount, &totalPopulation)
-	
+
 	if err != nil {
 		narrative.Status = "error"
 		narrative.Summary = "Error retrieving settlement data."
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(narrative)
+		writeNarrativeResponse(w, r, time.Time{}, narrative, func() *geojson.FeatureCollection {
+			return settlementNarrativeFeatureCollection(narrative)
+		})
 		return
 	}
-	
+
 	narrative.SettlementCount = settlementCount
 	narrative.TotalPopulation = int64(totalPopulation.Float64)
-	
+
 	// Calculate population density
 	if parkAreaKm2 > 0 {
 		narrative.PopulationDensity = totalPopulation.Float64 / parkAreaKm2
 	}
-	
+
 	// Assess human-wildlife conflict risk
-	narrative.ConflictRisk = assessConflictRisk(settlementCount, narrative.PopulationDensity)
-	
+	narrative.ConflictRisk, narrative.ConflictFactors = s.assessConflictRisk(internalID, settlementCount, narrative.PopulationDensity)
+
 	// Handle zero settlements case (pristine areas)
 	if settlementCount == 0 {
 		narrative.Status = "complete"
 		narrative.ConflictRisk = "minimal"
 		narrative.Summary = generatePristineNarrative(parkName, parkAreaKm2)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(narrative)
+		writeNarrativeResponse(w, r, time.Time{}, narrative, func() *geojson.FeatureCollection {
+			return settlementNarrativeFeatureCollection(narrative)
+		})
 		return
 	}
-	
+
 	// Get largest settlements
 	largestRows, err := s.DB.Query(`
 		SELECT 
@@ -838,7 +1054,7 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 		WHERE park_id = ?
 		ORDER BY area_m2 DESC
 	`, internalID)
-	
+
 	if err == nil {
 		defer largestRows.Close()
 		for largestRows.Next() {
@@ -850,7 +1066,7 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 			}
 		}
 	}
-	
+
 	// Get regional breakdown by quadrant
 	regionRows, err := s.DB.Query(`
 		WITH park_center AS (
@@ -871,7 +1087,7 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 		GROUP BY region
 		ORDER BY population DESC
 	`, internalID, internalID)
-	
+
 	if err == nil {
 		defer regionRows.Close()
 		for regionRows.Next() {
@@ -881,18 +1097,38 @@ func (s *Server) HandleAPISettlementNarrative(w http.ResponseWriter, r *http.Req
 			}
 		}
 	}
-	
+
 	// Generate comprehensive narrative
 	narrative.Status = "complete"
-	narrative.Summary = generateSettlementNarrative(parkName, settlementCount, narrative.TotalPopulation, 
+	narrative.Summary = generateSettlementNarrative(parkName, settlementCount, narrative.TotalPopulation,
 		narrative.PopulationDensity, narrative.ConflictRisk, narrative.LargestSettlements, narrative.RegionalBreakdown)
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(narrative)
+
+	writeNarrativeResponse(w, r, time.Time{}, narrative, func() *geojson.FeatureCollection {
+		return settlementNarrativeFeatureCollection(narrative)
+	})
+}
+
+// assessConflictRisk determines human-wildlife conflict risk level. If
+// s.SocioStore has gridded socioeconomic indicators for parkID (see
+// srv/socio), it returns a multi-factor breakdown blending settlement
+// density with GDP per capita, cropland pressure, and road density;
+// otherwise it degrades to assessConflictRiskSimple's settlement-only
+// thresholds, same as before this package existed.
+func (s *Server) assessConflictRisk(parkID string, settlementCount int, densityPerKm2 float64) (string, *socio.Breakdown) {
+	if settlementCount == 0 {
+		return "minimal", nil
+	}
+	ind, ok := s.SocioStore.Get(parkID)
+	if !ok {
+		return assessConflictRiskSimple(settlementCount, densityPerKm2), nil
+	}
+	b := socio.Score(densityPerKm2, ind, s.SocioWeights)
+	return b.Level, &b
 }
 
-// assessConflictRisk determines human-wildlife conflict risk level
-func assessConflictRisk(settlementCount int, density float64) string {
+// assessConflictRiskSimple is the settlement-density-only fallback
+// used when no gridded socioeconomic indicators are loaded for a park.
+func assessConflictRiskSimple(settlementCount int, density float64) string {
 	if settlementCount == 0 {
 		return "minimal"
 	}
@@ -912,14 +1148,14 @@ func assessConflictRisk(settlementCount int, density float64) string {
 func generatePristineNarrative(parkName string, areaKm2 float64) string {
 	var narrative strings.Builder
 	narrative.WriteString(fmt.Sprintf("%s shows no detectable human settlements within park boundaries. ", parkName))
-	
+
 	if areaKm2 > 0 {
 		narrative.WriteString(fmt.Sprintf("This %.0f km² protected area represents a pristine wilderness corridor with minimal direct human-wildlife interface. ", areaKm2))
 	}
-	
+
 	narrative.WriteString("Conservation priority: Maintain buffer zones and monitor boundary areas for encroachment. ")
 	narrative.WriteString("This intact habitat status is rare in the region and critical for wildlife movement corridors.")
-	
+
 	return narrative.String()
 }
 
@@ -935,17 +1171,17 @@ func formatArea(m2 float64) string {
 }
 
 // generateSettlementNarrative creates a concise narrative for populated parks
-func generateSettlementNarrative(parkName string, count int, totalPop int64, density float64, risk string, 
+func generateSettlementNarrative(parkName string, count int, totalPop int64, density float64, risk string,
 	largest []SettlementDetail, regions []RegionSettlement) string {
-	
+
 	// Calculate total built-up area
 	var totalArea float64
 	for _, s := range largest {
 		totalArea += s.AreaM2
 	}
-	
+
 	// Simple summary: count and total built-up area
-	return fmt.Sprintf("%s contains %d settlements with %s total built-up area.", 
+	return fmt.Sprintf("%s contains %d settlements with %s total built-up area.",
 		parkName, count, formatArea(totalArea))
 }
 
@@ -985,29 +1221,29 @@ func describePatternVaried(pattern string, areaKm2 float64, year int) string {
 		"The diffuse pattern indicates gradual encroachment from various points.",
 		"Multiple small clearings are typical of subsistence agriculture.",
 	}
-	
+
 	clusterPhrases := []string{
 		"The clustered pattern may indicate mining activity or localized clearing.",
 		"Concentrated loss suggests organized clearing for commercial purposes.",
 		"The tight cluster pattern is consistent with settlement expansion.",
 		"Focused deforestation indicates a single major clearing event.",
 	}
-	
+
 	stripPhrases := []string{
 		"The linear pattern suggests road construction or logging track expansion.",
 		"Linear clearing indicates infrastructure development or logging access.",
 		"The strip pattern is consistent with road-building or utility corridors.",
 	}
-	
+
 	edgePhrases := []string{
 		"Loss concentrated along park boundaries indicates agricultural encroachment from surrounding communities.",
 		"Edge-focused clearing reflects pressure from adjacent farming areas.",
 		"Boundary-adjacent loss suggests expansion of neighboring settlements.",
 	}
-	
+
 	// Use year as seed for deterministic variety
 	index := year % 4
-	
+
 	switch pattern {
 	case "strip":
 		return stripPhrases[index%len(stripPhrases)]
@@ -1026,108 +1262,173 @@ func describePatternVaried(pattern string, areaKm2 float64, year int) string {
 	}
 }
 
-// calculateTrend computes the 5-year rolling average trend
-func calculateTrend(yearlyAreas []struct {
-	year int
-	area float64
-}) (direction string, percentChange, earlyAvg, recentAvg float64) {
-	if len(yearlyAreas) < 5 {
-		return "insufficient_data", 0, 0, 0
-	}
-	
-	// Calculate early 5-year average (first 5 years)
-	earlyYears := 5
-	if len(yearlyAreas) < 10 {
-		earlyYears = len(yearlyAreas) / 2
-	}
-	if earlyYears < 2 {
-		earlyYears = 2
-	}
-	
-	var earlySum float64
-	for i := 0; i < earlyYears; i++ {
-		earlySum += yearlyAreas[i].area
-	}
-	earlyAvg = earlySum / float64(earlyYears)
-	
-	// Calculate recent 5-year average (last 5 years)
-	recentYears := 5
-	if len(yearlyAreas) < 10 {
-		recentYears = len(yearlyAreas) - earlyYears
-	}
-	if recentYears < 2 {
-		recentYears = 2
-	}
-	
-	var recentSum float64
-	for i := len(yearlyAreas) - recentYears; i < len(yearlyAreas); i++ {
-		recentSum += yearlyAreas[i].area
-	}
-	recentAvg = recentSum / float64(recentYears)
-	
-	// Calculate percent change
-	if earlyAvg > 0 {
-		percentChange = ((recentAvg - earlyAvg) / earlyAvg) * 100
-	}
-	
-	// Determine trend direction (10% threshold for "stable")
-	if percentChange > 10 {
-		direction = "worsening"
-	} else if percentChange < -10 {
-		direction = "improving"
-	} else {
-		direction = "stable"
-	}
-	
-	return direction, percentChange, earlyAvg, recentAvg
-}
-
-// describeTrend generates human-readable trend description
-func describeTrend(direction string, percentChange float64) string {
-	switch direction {
+// describeTrend generates a human-readable description of a
+// statstrend.Trend for the deforestation narrative summary.
+func describeTrend(trend statstrend.Trend) string {
+	switch trend.Direction {
 	case "worsening":
-		return fmt.Sprintf("⚠️ TREND ALERT: Deforestation has increased by %.0f%% comparing recent years to earlier periods.", percentChange)
+		return fmt.Sprintf("⚠️ TREND ALERT: Deforestation is worsening (p=%.3f), losing an estimated %.2f km² more per year.", trend.PValue, trend.SenSlope)
 	case "improving":
-		return fmt.Sprintf("✅ POSITIVE TREND: Deforestation has decreased by %.0f%% comparing recent years to earlier periods.", -percentChange)
+		return fmt.Sprintf("✅ POSITIVE TREND: Deforestation is improving (p=%.3f), losing an estimated %.2f km² less per year.", trend.PValue, -trend.SenSlope)
 	case "stable":
-		return "Deforestation rates have remained relatively stable over the monitoring period."
+		return fmt.Sprintf("Deforestation rates have remained statistically stable over the monitoring period (p=%.3f).", trend.PValue)
 	default:
-		return "Insufficient data to determine long-term trend."
+		return "Insufficient data to determine a statistically significant long-term trend."
 	}
 }
 
 // fetchHotspots retrieves the worst deforestation clusters for a park
-func (s *Server) fetchHotspots(parkID string, limit int) []DeforestationHotspot {
-	var hotspots []DeforestationHotspot
-	
+// resolveClusterParams reads the DBSCAN ?eps= (kilometers), ?min_pts=,
+// and ?include_points= query params a hotspot-bearing narrative
+// endpoint accepts, falling back to defaultEpsKm/defaultMinPts when
+// unset. usingDefaults is false as soon as the caller asks for anything
+// other than the defaults, so callers know not to serve a
+// park_narratives cache row that was materialized under the defaults.
+func (s *Server) resolveClusterParams(r *http.Request, defaultEpsKm float64, defaultMinPts int) (epsKm float64, minPts int, includePoints, usingDefaults bool) {
+	epsKm, minPts, usingDefaults = defaultEpsKm, defaultMinPts, true
+
+	if v := r.URL.Query().Get("eps"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			epsKm = f
+			usingDefaults = false
+		}
+	}
+	if v := r.URL.Query().Get("min_pts"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			minPts = n
+			usingDefaults = false
+		}
+	}
+	if r.URL.Query().Get("include_points") == "1" {
+		includePoints = true
+		usingDefaults = false
+	}
+
+	return epsKm, minPts, includePoints, usingDefaults
+}
+
+// fetchHotspots clusters deforestation_events recorded for parkID
+// within [fromYear, toYear] using DBSCAN (see srv/geocluster) instead
+// of reading a precomputed deforestation_clusters table, so
+// irregularly shaped concentrations that don't line up with that
+// table's fixed cluster boundaries still surface. ClusterID is the
+// cluster's position in DBSCAN's own output, not a stored identifier.
+// When includePoints is true, the second return value carries every
+// event annotated with the cluster it was assigned to (NoiseLabel for
+// events that weren't part of any hotspot).
+func (s *Server) fetchHotspots(parkID string, fromYear, toYear, limit int, epsKm float64, minPts int, includePoints bool) ([]DeforestationHotspot, []ClusteredPoint) {
 	rows, err := s.DB.Query(`
-		SELECT year, cluster_id, area_km2, lat, lon, COALESCE(pattern_type, 'unknown'), COALESCE(description, '')
-		FROM deforestation_clusters
-		WHERE park_id = ?
-		ORDER BY area_km2 DESC
-		LIMIT ?
-	`, parkID, limit)
+		SELECT year, area_km2, lat, lon, COALESCE(pattern_type, 'unknown')
+		FROM deforestation_events
+		WHERE park_id = ? AND year >= ? AND year <= ?
+	`, parkID, fromYear, toYear)
 	if err != nil {
-		return hotspots
+		return nil, nil
 	}
 	defer rows.Close()
-	
+
+	var points []geocluster.Point
+	var years []int
+	var areas []float64
+	var patterns []string
 	for rows.Next() {
-		var h DeforestationHotspot
-		if err := rows.Scan(&h.Year, &h.ClusterID, &h.AreaKm2, &h.Lat, &h.Lon, &h.PatternType, &h.Description); err != nil {
+		var year int
+		var area, lat, lon float64
+		var pattern string
+		if err := rows.Scan(&year, &area, &lat, &lon, &pattern); err != nil {
 			continue
 		}
-		
-		// Generate description if empty
-		if h.Description == "" {
-			locationDesc := s.describeLocation(parkID, h.Lat, h.Lon)
-			h.Description = fmt.Sprintf("%.2f km² lost in %d %s", h.AreaKm2, h.Year, locationDesc)
+		points = append(points, geocluster.Point{Index: len(points), Lat: lat, Lon: lon})
+		years = append(years, year)
+		areas = append(areas, area)
+		patterns = append(patterns, pattern)
+	}
+
+	clusters, labels := geocluster.DBSCAN(points, epsKm, minPts)
+
+	var clusterPoints []ClusteredPoint
+	if includePoints {
+		for i, p := range points {
+			clusterPoints = append(clusterPoints, ClusteredPoint{Lat: p.Lat, Lon: p.Lon, Cluster: labels[i]})
 		}
-		
-		hotspots = append(hotspots, h)
 	}
-	
-	return hotspots
+
+	var hotspots []DeforestationHotspot
+	for clusterID, c := range clusters {
+		if len(c.Points) == 0 {
+			continue
+		}
+
+		var totalArea float64
+		worstYear := years[c.Points[0].Index]
+		patternCounts := make(map[string]int)
+		for _, p := range c.Points {
+			totalArea += areas[p.Index]
+			if years[p.Index] > worstYear {
+				worstYear = years[p.Index]
+			}
+			patternCounts[patterns[p.Index]]++
+		}
+
+		patternType := patterns[c.Points[0].Index]
+		best := 0
+		for pat, n := range patternCounts {
+			if n > best {
+				best, patternType = n, pat
+			}
+		}
+
+		// Unlike determinePatternType's single aggregate point per
+		// year, a DBSCAN cluster carries every member event's lat/lon,
+		// so its aspect ratio comes from their actual spread rather
+		// than the pattern-label proxy aspectRatioFromPattern uses.
+		latMin, latMax := c.Points[0].Lat, c.Points[0].Lat
+		lonMin, lonMax := c.Points[0].Lon, c.Points[0].Lon
+		for _, p := range c.Points {
+			latMin, latMax = math.Min(latMin, p.Lat), math.Max(latMax, p.Lat)
+			lonMin, lonMax = math.Min(lonMin, p.Lon), math.Max(lonMax, p.Lon)
+		}
+		aspectRatio := 1.0
+		if latSpread, lonSpread := latMax-latMin, lonMax-lonMin; latSpread > 0 && lonSpread > 0 {
+			if latSpread > lonSpread {
+				aspectRatio = latSpread / lonSpread
+			} else {
+				aspectRatio = lonSpread / latSpread
+			}
+		}
+
+		driverLabel, driverConfidence := s.classifyCluster(parkID, c.CentroidLat, c.CentroidLon, totalArea, aspectRatio, worstYear)
+		forestClass, standAgeYr := s.classifyForestClass(parkID, c.CentroidLat, c.CentroidLon, worstYear)
+
+		locationDesc := s.describeLocation(parkID, c.CentroidLat, c.CentroidLon)
+		description := fmt.Sprintf("%.2f km² lost across %d events %s", totalArea, len(c.Points), locationDesc)
+		if driverLabel != "" {
+			description += fmt.Sprintf(" — likely %s (%.0f%% confidence)", describeDriver(driverLabel), driverConfidence*100)
+		}
+		if forestClass == ForestClassSecondary {
+			description += fmt.Sprintf(", secondary/regrowth loss (%d yr stand)", standAgeYr)
+		}
+		hotspots = append(hotspots, DeforestationHotspot{
+			Year:             worstYear,
+			ClusterID:        clusterID,
+			AreaKm2:          totalArea,
+			Lat:              c.CentroidLat,
+			Lon:              c.CentroidLon,
+			PatternType:      patternType,
+			DriverLabel:      driverLabel,
+			DriverConfidence: driverConfidence,
+			ForestClass:      forestClass,
+			StandAgeYr:       standAgeYr,
+			Description:      description,
+		})
+	}
+
+	sort.Slice(hotspots, func(i, j int) bool { return hotspots[i].AreaKm2 > hotspots[j].AreaKm2 })
+	if len(hotspots) > limit {
+		hotspots = hotspots[:limit]
+	}
+
+	return hotspots, clusterPoints
 }
 
 // determinePatternType analyzes cluster data to determine actual pattern type
@@ -1136,18 +1437,18 @@ func (s *Server) determinePatternType(parkID string, year int, defaultPattern st
 	var clusterCount int
 	var totalArea float64
 	var latMin, latMax, lonMin, lonMax sql.NullFloat64
-	
+
 	err := s.DB.QueryRow(`
 		SELECT COUNT(*), COALESCE(SUM(area_km2), 0),
 		       MIN(lat), MAX(lat), MIN(lon), MAX(lon)
 		FROM deforestation_clusters
 		WHERE park_id = ? AND year = ?
 	`, parkID, year).Scan(&clusterCount, &totalArea, &latMin, &latMax, &lonMin, &lonMax)
-	
+
 	if err != nil || clusterCount == 0 {
 		return defaultPattern
 	}
-	
+
 	// Calculate geographic spread
 	latSpread := 0.0
 	lonSpread := 0.0
@@ -1157,12 +1458,12 @@ func (s *Server) determinePatternType(parkID string, year int, defaultPattern st
 	if lonMin.Valid && lonMax.Valid {
 		lonSpread = lonMax.Float64 - lonMin.Float64
 	}
-	
+
 	// Determine pattern based on cluster analysis
 	if clusterCount == 1 {
 		return "cluster" // Single concentrated area
 	}
-	
+
 	// Check for linear (strip) pattern - one dimension much larger than other
 	aspectRatio := 0.0
 	if latSpread > 0 && lonSpread > 0 {
@@ -1172,11 +1473,11 @@ func (s *Server) determinePatternType(parkID string, year int, defaultPattern st
 			aspectRatio = lonSpread / latSpread
 		}
 	}
-	
+
 	if aspectRatio > 3.0 {
 		return "strip" // Linear pattern
 	}
-	
+
 	// Check for cluster vs scattered based on density
 	spreadArea := latSpread * lonSpread * 111 * 111 // Rough km² conversion
 	if spreadArea > 0 {
@@ -1185,12 +1486,12 @@ func (s *Server) determinePatternType(parkID string, year int, defaultPattern st
 			return "cluster"
 		}
 	}
-	
+
 	// If many small clusters spread out
 	if clusterCount > 5 {
 		return "scattered"
 	}
-	
+
 	// Check if clusters are from database with explicit pattern
 	var clusterPattern sql.NullString
 	s.DB.QueryRow(`
@@ -1200,11 +1501,11 @@ func (s *Server) determinePatternType(parkID string, year int, defaultPattern st
 		ORDER BY COUNT(*) DESC
 		LIMIT 1
 	`, parkID, year).Scan(&clusterPattern)
-	
+
 	if clusterPattern.Valid && clusterPattern.String != "" {
 		return clusterPattern.String
 	}
-	
+
 	return defaultPattern
 }
 
@@ -1221,50 +1522,59 @@ func uniqueStrings(input []string) []string {
 	return result
 }
 
-// analyzeFireHotspots identifies geographic concentrations of fire activity
-func (s *Server) analyzeFireHotspots(parkID string, year int, totalFires int) []FireHotspot {
-	var hotspots []FireHotspot
-	
-	// Query fire clusters by 0.1 degree grid cells
+// analyzeFireHotspots clusters fire_detections for parkID/year using
+// DBSCAN (see srv/geocluster) instead of a fixed 0.1° grid, so
+// irregularly shaped concentrations aren't split across bucket
+// boundaries. When includePoints is true, the second return value
+// carries every detection annotated with the cluster it was assigned
+// to (NoiseLabel for detections that weren't part of any hotspot).
+func (s *Server) analyzeFireHotspots(parkID string, year int, totalFires int, epsKm float64, minPts int, includePoints bool) ([]FireHotspot, []ClusteredPoint) {
 	rows, err := s.DB.Query(`
-		SELECT 
-			ROUND(latitude, 1) as lat_bucket,
-			ROUND(longitude, 1) as lon_bucket,
-			AVG(latitude) as avg_lat,
-			AVG(longitude) as avg_lon,
-			COUNT(*) as fire_count
-		FROM fire_detections 
+		SELECT latitude, longitude
+		FROM fire_detections
 		WHERE protected_area_id = ? AND strftime('%Y', acq_date) = ?
-		GROUP BY lat_bucket, lon_bucket
-		HAVING fire_count >= 10
-		ORDER BY fire_count DESC
-		LIMIT 10
 	`, parkID, fmt.Sprintf("%d", year))
 	if err != nil {
-		return hotspots
+		return nil, nil
 	}
 	defer rows.Close()
-	
+
+	var points []geocluster.Point
 	for rows.Next() {
-		var latBucket, lonBucket, avgLat, avgLon float64
-		var fireCount int
-		if err := rows.Scan(&latBucket, &lonBucket, &avgLat, &avgLon, &fireCount); err != nil {
+		var lat, lon float64
+		if err := rows.Scan(&lat, &lon); err != nil {
 			continue
 		}
-		
+		points = append(points, geocluster.Point{Index: len(points), Lat: lat, Lon: lon})
+	}
+
+	clusters, labels := geocluster.DBSCAN(points, epsKm, minPts)
+
+	var clusterPoints []ClusteredPoint
+	if includePoints {
+		for i, p := range points {
+			clusterPoints = append(clusterPoints, ClusteredPoint{Lat: p.Lat, Lon: p.Lon, Cluster: labels[i]})
+		}
+	}
+
+	var hotspots []FireHotspot
+	for _, c := range clusters {
+		fireCount := len(c.Points)
+		lat, lon := c.CentroidLat, c.CentroidLon
+
 		hs := FireHotspot{
-			Lat:       avgLat,
-			Lon:       avgLon,
+			Lat:       lat,
+			Lon:       lon,
 			FireCount: fireCount,
 		}
 		if totalFires > 0 {
 			hs.Percentage = float64(fireCount) / float64(totalFires) * 100
 		}
-		
+
 		// Find nearby places for context
-		settlements, _ := s.findNearestPlaces(parkID, avgLat, avgLon, 2, []string{"village", "hamlet", "town", "city"})
-		rivers, _ := s.findNearestPlaces(parkID, avgLat, avgLon, 1, []string{"river", "stream"})
-		
+		settlements, _ := s.findNearestPlaces(parkID, lat, lon, 2, []string{"village", "hamlet", "town", "city"})
+		rivers, _ := s.findNearestPlaces(parkID, lat, lon, 1, []string{"river", "stream"})
+
 		var nearbyNames []string
 		for _, p := range settlements {
 			if p.Distance < 30 {
@@ -1277,25 +1587,41 @@ func (s *Server) analyzeFireHotspots(parkID string, year int, totalFires int) []
 			}
 		}
 		hs.NearbyPlaces = nearbyNames
-		
+
 		// Build description
-		locationDesc := s.describeLocation(parkID, avgLat, avgLon)
+		locationDesc := s.describeLocation(parkID, lat, lon)
 		hs.Description = fmt.Sprintf("Fire hotspot %s with %d detections (%.1f%% of park total). ",
 			locationDesc, fireCount, hs.Percentage)
 		if len(nearbyNames) > 0 {
 			hs.Description += fmt.Sprintf("Nearby: %s.", strings.Join(nearbyNames, ", "))
 		}
-		
+
+		// Note gridded socioeconomic pressure (see srv/socio), if loaded
+		// for this park, so a reader can connect fire activity to its
+		// likely land-use driver (e.g. slash-and-burn agriculture).
+		if ind, ok := s.SocioStore.Get(parkID); ok {
+			b := socio.Score(0, ind, s.SocioWeights)
+			hs.SocioFactors = &b
+			if b.CroplandPressure > 0.6 && b.GDPPerCapita > 0.6 {
+				hs.Description += " High cropland pressure and low GDP per capita here are consistent with slash-and-burn agriculture."
+			}
+		}
+
 		hotspots = append(hotspots, hs)
 	}
-	
-	return hotspots
+
+	sort.Slice(hotspots, func(i, j int) bool { return hotspots[i].FireCount > hotspots[j].FireCount })
+	if len(hotspots) > 10 {
+		hotspots = hotspots[:10]
+	}
+
+	return hotspots, clusterPoints
 }
 
 // analyzeFireTrend provides multi-year trend analysis
 func (s *Server) analyzeFireTrend(parkID string, currentYear int) *FireTrendAnalysis {
 	trend := &FireTrendAnalysis{}
-	
+
 	// Get all years of data
 	rows, err := s.DB.Query(`
 		SELECT 
@@ -1321,12 +1647,12 @@ func (s *Server) analyzeFireTrend(parkID string, currentYear int) *FireTrendAnal
 		return nil
 	}
 	defer rows.Close()
-	
+
 	var totalResponseRate float64
 	var yearCount int
 	var worstGroups int
 	var bestRate float64 = -1
-	
+
 	for rows.Next() {
 		var ys FireYearSummary
 		if err := rows.Scan(&ys.Year, &ys.TotalGroups, &ys.StoppedInside, &ys.Transited, &ys.AvgDaysBurning, &ys.TotalFires); err != nil {
@@ -1336,7 +1662,7 @@ func (s *Server) analyzeFireTrend(parkID string, currentYear int) *FireTrendAnal
 			ys.ResponseRate = float64(ys.StoppedInside) / float64(ys.TotalGroups) * 100
 			totalResponseRate += ys.ResponseRate
 			yearCount++
-			
+
 			if ys.TotalGroups > worstGroups {
 				worstGroups = ys.TotalGroups
 				trend.WorstYear = ys.Year
@@ -1350,50 +1676,41 @@ func (s *Server) analyzeFireTrend(parkID string, currentYear int) *FireTrendAnal
 		}
 		trend.Years = append(trend.Years, ys)
 	}
-	
+
 	if yearCount > 0 {
 		trend.AvgResponseRate = totalResponseRate / float64(yearCount)
 	}
-	
-	// Determine trend direction
-	if len(trend.Years) >= 3 {
-		recentAvg := 0.0
-		earlyAvg := 0.0
-		mid := len(trend.Years) / 2
-		for i, y := range trend.Years {
-			if i < mid {
-				earlyAvg += float64(y.TotalGroups)
-			} else {
-				recentAvg += float64(y.TotalGroups)
-			}
-		}
-		earlyAvg /= float64(mid)
-		recentAvg /= float64(len(trend.Years) - mid)
-		
-		if recentAvg > earlyAvg*1.2 {
-			trend.TrendDirection = "increasing"
-		} else if recentAvg < earlyAvg*0.8 {
-			trend.TrendDirection = "decreasing"
-		} else {
-			trend.TrendDirection = "stable"
-		}
-	}
-	
+
+	// Mann-Kendall trend test + Theil-Sen slope (see srv/statstrend) in
+	// place of a fixed early/recent-half average, which misfires on
+	// short or noisy series.
+	obs := make([]statstrend.Observation, len(trend.Years))
+	for i, y := range trend.Years {
+		obs[i] = statstrend.Observation{Year: y.Year, Value: float64(y.TotalGroups)}
+	}
+	mk := statstrend.Analyze(obs, true, statstrend.DefaultAlpha)
+	trend.TrendDirection = mk.Direction
+	trend.TrendPValue = mk.PValue
+	trend.TrendSenSlope = mk.SenSlope
+	trend.WorstYearPct = mk.WorstYearPercentile
+
 	// Build trend narrative
 	if len(trend.Years) > 1 {
 		var narr strings.Builder
 		narr.WriteString(fmt.Sprintf("Analysis of %d years of fire data (%d-%d). ",
 			len(trend.Years), trend.Years[0].Year, trend.Years[len(trend.Years)-1].Year))
-		
+
 		switch trend.TrendDirection {
-		case "increasing":
-			narr.WriteString("⚠️ Fire pressure is INCREASING - enhanced monitoring recommended. ")
-		case "decreasing":
-			narr.WriteString("✓ Fire pressure is DECREASING - conservation efforts may be working. ")
+		case "worsening":
+			narr.WriteString(fmt.Sprintf("⚠️ Fire pressure is WORSENING (p=%.3f, +%.1f groups/year) - enhanced monitoring recommended. ", trend.TrendPValue, trend.TrendSenSlope))
+		case "improving":
+			narr.WriteString(fmt.Sprintf("✓ Fire pressure is IMPROVING (p=%.3f, %.1f groups/year) - conservation efforts may be working. ", trend.TrendPValue, trend.TrendSenSlope))
 		case "stable":
-			narr.WriteString("Fire pressure remains relatively stable over the analysis period. ")
+			narr.WriteString(fmt.Sprintf("Fire pressure remains statistically stable over the analysis period (p=%.3f). ", trend.TrendPValue))
+		default:
+			narr.WriteString("Too few years of data for a statistically significant trend. ")
 		}
-		
+
 		narr.WriteString(fmt.Sprintf("Average response rate: %.0f%%. ", trend.AvgResponseRate))
 		if trend.WorstYear > 0 {
 			narr.WriteString(fmt.Sprintf("Worst year: %d with %d fire groups. ", trend.WorstYear, trend.WorstYearGroups))
@@ -1403,6 +1720,6 @@ func (s *Server) analyzeFireTrend(parkID string, currentYear int) *FireTrendAnal
 		}
 		trend.Narrative = narr.String()
 	}
-	
+
 	return trend
 }
@@ -0,0 +1,55 @@
+package srv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// osmRoadsCacheDir holds HandleParkRoads' cached FeatureCollection
+// responses, one file per (park ID, bbox, threshold) combination —
+// see clip_track.go and srv/tus for the same "data/<thing>" convention.
+const osmRoadsCacheDir = "data/osmroads"
+
+// osmRoadsCacheTTL is how long a cached road FeatureCollection is
+// served before a request re-fetches from Overpass. Roads change slowly
+// enough in OSM that a week-old cache is still useful, and it keeps
+// repeat page loads from hammering a public Overpass instance.
+const osmRoadsCacheTTL = 7 * 24 * time.Hour
+
+// osmRoadsCachePath returns the on-disk cache path for one park's road
+// data, keyed by park ID plus a hash of its bbox and roadless
+// threshold so either changing invalidates the cache instead of
+// serving stale geometry or percentages.
+func osmRoadsCachePath(parkID string, bbox []float64, thresholdKm float64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%v:%v", parkID, bbox, thresholdKm)))
+	return filepath.Join(osmRoadsCacheDir, parkID+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadOSMRoadsCache returns the cached response body at path if it
+// exists and is fresher than osmRoadsCacheTTL.
+func loadOSMRoadsCache(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > osmRoadsCacheTTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// saveOSMRoadsCache writes data to path, creating osmRoadsCacheDir if
+// needed. Failures are non-fatal: the response was already computed
+// and served, so a cache write failure just means the next request
+// re-fetches from Overpass.
+func saveOSMRoadsCache(path string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,271 @@
+// Package osmroads builds and executes the Overpass QL query behind
+// HandleParkRoads: fetch every major-class highway way inside a
+// bounding box, parse Overpass's "out geom" JSON into plain Go
+// structs, and derive the two numbers rangers actually want — total
+// road length and how much of the park lies more than a configurable
+// distance from the nearest road.
+package osmroads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the public Overpass instance used when no
+// endpoint is configured (see Server.OverpassAPIURL).
+const DefaultEndpoint = "https://overpass-api.de/api/interpreter"
+
+// highwayClasses are the OSM highway values HandleParkRoads cares
+// about; footpaths/paths/cycleways are deliberately excluded since
+// they don't meaningfully affect wildlife disturbance the way a
+// vehicle-capable road does.
+const highwayClasses = "motorway|trunk|primary|secondary|tertiary|unclassified|track"
+
+const (
+	maxFetchAttempts  = 4
+	baseBackoff       = 2 * time.Second
+	maxResponseBytes  = 16 << 20 // 16 MiB
+	requestTimeoutSec = 60
+)
+
+// Point is a bare lat/lon pair, kept independent of srv/areas so this
+// package has no dependency beyond the standard library.
+type Point struct {
+	Lat, Lon float64
+}
+
+// Way is one OSM way returned by Overpass, with its tag and geometry
+// resolved (not just node IDs) thanks to the query's "out geom".
+type Way struct {
+	ID      int64
+	Highway string
+	Points  []Point
+}
+
+// BuildQuery returns the Overpass QL query for every highwayClasses way
+// intersecting bbox (minLon, minLat, maxLon, maxLat). Overpass's own
+// bbox filter takes (south, west, north, east), so the coordinates are
+// reordered when substituted in.
+func BuildQuery(bbox [4]float64) string {
+	return fmt.Sprintf(`[out:json][timeout:60];
+(
+  way["highway"~"^(%s)$"](%f,%f,%f,%f);
+);
+out geom;`, highwayClasses, bbox[1], bbox[0], bbox[3], bbox[2])
+}
+
+// HTTPDoer is the subset of *http.Client (and srv.HTTPClient) Fetch
+// needs. Taking an interface rather than *http.Client directly keeps
+// this package's only dependency the standard library, while still
+// letting callers plug in a rate-limited, connection-pooled client.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Fetch posts query to endpoint (DefaultEndpoint if empty) via client
+// and returns the raw Overpass JSON response. Public Overpass instances
+// routinely answer busy periods with 429 (rate limited) or 504 (gateway
+// timeout), so both are retried with exponential backoff; any other
+// non-200 status is returned as a terminal error. ctx bounds the whole
+// call, including retries — a caller whose own request was cancelled
+// (e.g. a closed browser tab) stops the in-flight Overpass query
+// instead of letting it run to completion.
+func Fetch(ctx context.Context, client HTTPDoer, endpoint, query string) ([]byte, error) {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeoutSec * time.Second}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxFetchAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(baseBackoff * time.Duration(uint(1)<<uint(attempt-1))):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(url.Values{"data": {query}}.Encode()))
+		if err != nil {
+			return nil, fmt.Errorf("build overpass request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = fmt.Errorf("overpass request: %w", err)
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("read overpass response: %w", err)
+			continue
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return body, nil
+		case http.StatusTooManyRequests, http.StatusGatewayTimeout:
+			lastErr = fmt.Errorf("overpass returned %s", resp.Status)
+			continue
+		default:
+			return nil, fmt.Errorf("overpass returned %s: %s", resp.Status, body)
+		}
+	}
+	return nil, fmt.Errorf("overpass request failed after %d attempts: %w", maxFetchAttempts, lastErr)
+}
+
+// overpassResponse mirrors the subset of Overpass's "out geom" JSON
+// format this package cares about.
+type overpassResponse struct {
+	Elements []struct {
+		Type string `json:"type"`
+		ID   int64  `json:"id"`
+		Tags struct {
+			Highway string `json:"highway"`
+		} `json:"tags"`
+		Geometry []struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"geometry"`
+	} `json:"elements"`
+}
+
+// ParseResponse decodes an Overpass "out geom" JSON response into Ways,
+// skipping elements that aren't ways, have no highway tag, or (e.g. a
+// way Overpass couldn't resolve geometry for) have fewer than two
+// points.
+func ParseResponse(data []byte) ([]Way, error) {
+	var parsed overpassResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("parse overpass response: %w", err)
+	}
+
+	var ways []Way
+	for _, el := range parsed.Elements {
+		if el.Type != "way" || el.Tags.Highway == "" || len(el.Geometry) < 2 {
+			continue
+		}
+		pts := make([]Point, len(el.Geometry))
+		for i, g := range el.Geometry {
+			pts[i] = Point{Lat: g.Lat, Lon: g.Lon}
+		}
+		ways = append(ways, Way{ID: el.ID, Highway: el.Tags.Highway, Points: pts})
+	}
+	return ways, nil
+}
+
+const earthRadiusKm = 6371.0
+
+// haversineKm returns the great-circle distance in km between two
+// points.
+func haversineKm(a, b Point) float64 {
+	phi1, phi2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dPhi := (b.Lat - a.Lat) * math.Pi / 180
+	dLambda := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
+
+// LengthKm sums the haversine distance between consecutive points,
+// accurate enough for a road-density estimate without pulling in a
+// full geodesic library.
+func (w Way) LengthKm() float64 {
+	var total float64
+	for i := 1; i < len(w.Points); i++ {
+		total += haversineKm(w.Points[i-1], w.Points[i])
+	}
+	return total
+}
+
+// maxRoadlessSamples bounds the sampling grid RoadlessPercent builds,
+// so a large park's bbox can't turn one request into millions of
+// distance checks; resolution is coarsened (not the area skipped) once
+// the cap would otherwise be exceeded.
+const maxRoadlessSamples = 10000
+
+// RoadlessPercent estimates the fraction of a park's area that is more
+// than thresholdKm from the nearest road, by laying a grid of sample
+// points over bbox at roughly thresholdKm resolution, keeping the ones
+// inside (as reported by the inside predicate — typically
+// ProtectedArea.ContainsPoint), and checking each kept sample's
+// distance to the nearest way vertex.
+//
+// This is an approximation in two ways: distance is measured to the
+// nearest way *vertex* rather than the nearest point on a way segment
+// (a long straight stretch between sparse nodes can make a point look
+// farther from the road than it is), and the grid itself is a regular
+// sample, not an exact area integral. Good enough for a roadless
+// indicator; not a surveying tool.
+func RoadlessPercent(bbox [4]float64, ways []Way, thresholdKm float64, inside func(lat, lon float64) bool) float64 {
+	if thresholdKm <= 0 {
+		thresholdKm = 1.0
+	}
+
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	latStep := thresholdKm / 111.0
+	lonStep := latStep
+	if cos := math.Cos((minLat + maxLat) / 2 * math.Pi / 180); cos > 0.01 {
+		lonStep = latStep / cos
+	}
+
+	latCount := int((maxLat-minLat)/latStep) + 1
+	lonCount := int((maxLon-minLon)/lonStep) + 1
+	for latCount*lonCount > maxRoadlessSamples {
+		latStep *= 1.25
+		lonStep *= 1.25
+		latCount = int((maxLat-minLat)/latStep) + 1
+		lonCount = int((maxLon-minLon)/lonStep) + 1
+	}
+
+	var total, roaded int
+	for i := 0; i < latCount; i++ {
+		lat := minLat + float64(i)*latStep
+		for j := 0; j < lonCount; j++ {
+			lon := minLon + float64(j)*lonStep
+			if !inside(lat, lon) {
+				continue
+			}
+			total++
+			if nearestRoadKm(lat, lon, ways) <= thresholdKm {
+				roaded++
+			}
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(total-roaded) / float64(total)
+}
+
+// nearestRoadKm returns the distance from (lat, lon) to the nearest
+// vertex among all ways' points.
+func nearestRoadKm(lat, lon float64, ways []Way) float64 {
+	best := math.Inf(1)
+	p := Point{Lat: lat, Lon: lon}
+	for _, w := range ways {
+		for _, pt := range w.Points {
+			if d := haversineKm(p, pt); d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,88 @@
+package osmroads
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildQueryReordersBBoxToSouthWestNorthEast(t *testing.T) {
+	query := BuildQuery([4]float64{36.0, -1.2, 36.5, -0.8})
+	// Overpass wants (south, west, north, east); bbox is (minLon,
+	// minLat, maxLon, maxLat), so the query should read -1.2, 36.0,
+	// -0.8, 36.5 in that order.
+	want := "(-1.200000,36.000000,-0.800000,36.500000)"
+	if !containsSubstring(query, want) {
+		t.Errorf("query %q missing reordered bbox %q", query, want)
+	}
+}
+
+func containsSubstring(s, sub string) bool {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if s[i:i+len(sub)] == sub {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseResponseSkipsNonRoadElements(t *testing.T) {
+	data := []byte(`{
+		"elements": [
+			{"type": "way", "id": 1, "tags": {"highway": "primary"}, "geometry": [{"lat": -1.0, "lon": 36.0}, {"lat": -1.01, "lon": 36.0}]},
+			{"type": "way", "id": 2, "tags": {}, "geometry": [{"lat": -1.0, "lon": 36.0}, {"lat": -1.01, "lon": 36.0}]},
+			{"type": "node", "id": 3, "tags": {"highway": "primary"}},
+			{"type": "way", "id": 4, "tags": {"highway": "track"}, "geometry": [{"lat": -1.0, "lon": 36.0}]}
+		]
+	}`)
+
+	ways, err := ParseResponse(data)
+	if err != nil {
+		t.Fatalf("ParseResponse: %v", err)
+	}
+	if len(ways) != 1 {
+		t.Fatalf("expected 1 way, got %d", len(ways))
+	}
+	if ways[0].ID != 1 || ways[0].Highway != "primary" {
+		t.Errorf("unexpected way: %+v", ways[0])
+	}
+}
+
+func TestLengthKmMatchesKnownDistance(t *testing.T) {
+	// Roughly 1 degree of latitude is ~111km.
+	w := Way{Points: []Point{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 0}}}
+	got := w.LengthKm()
+	if math.Abs(got-111.19) > 1 {
+		t.Errorf("expected ~111km, got %v", got)
+	}
+}
+
+func TestRoadlessPercentAllInsideNearRoad(t *testing.T) {
+	bbox := [4]float64{36.0, -1.0, 36.1, -0.9}
+	ways := []Way{{Points: []Point{{Lat: -0.95, Lon: 36.05}}}}
+	inside := func(lat, lon float64) bool { return true }
+
+	got := RoadlessPercent(bbox, ways, 1000, inside) // huge threshold: everything is "near" the road
+	if got != 0 {
+		t.Errorf("expected 0%% roadless with a huge threshold, got %v", got)
+	}
+}
+
+func TestRoadlessPercentNoRoadsIsFullyRoadless(t *testing.T) {
+	bbox := [4]float64{36.0, -1.0, 36.1, -0.9}
+	inside := func(lat, lon float64) bool { return true }
+
+	got := RoadlessPercent(bbox, nil, 1, inside)
+	if got != 100 {
+		t.Errorf("expected 100%% roadless with no roads, got %v", got)
+	}
+}
+
+func TestRoadlessPercentNothingInsideIsZero(t *testing.T) {
+	bbox := [4]float64{36.0, -1.0, 36.1, -0.9}
+	inside := func(lat, lon float64) bool { return false }
+
+	got := RoadlessPercent(bbox, nil, 1, inside)
+	if got != 0 {
+		t.Errorf("expected 0 with nothing inside the park, got %v", got)
+	}
+}
@@ -0,0 +1,300 @@
+// Package store materializes protected-area records into a
+// protected_areas table instead of the one-shot data/keystones.json
+// artifact cmd/fetchpas used to produce: upserts are idempotent on
+// wdpa_id, carry provenance (source, etag, fetched_at), and Sync skips
+// re-fetching rows still within a freshness TTL, so re-running it is
+// cheap and doesn't hammer whichever PAFetcher backs it.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/protectedplanet"
+)
+
+// DefaultTTL is how long a row is considered fresh enough that Sync
+// won't re-fetch it.
+const DefaultTTL = 30 * 24 * time.Hour
+
+// PAFetcher is anything that can look up protected areas by name or
+// WDPA ID — satisfied by both protectedplanet.Client and wfs.Client, so
+// Sync doesn't care which source fed it.
+type PAFetcher interface {
+	SearchByName(ctx context.Context, name, countryISO3 string) ([]protectedplanet.PA, error)
+	GetByWDPAID(ctx context.Context, wdpaID int) (*protectedplanet.PA, error)
+}
+
+// KeystoneInput names one protected area to resolve against a
+// PAFetcher, matching cmd/fetchpas's input list shape.
+type KeystoneInput struct {
+	Country string `json:"country"`
+	Name    string `json:"name"`
+}
+
+// Row is a materialized protected_areas record.
+type Row struct {
+	WDPAID      int
+	Name        string
+	CountryISO3 string
+	IUCN        string
+	AreaKm2     float64
+	GeomGeoJSON json.RawMessage
+	Source      string
+	ETag        string
+	FetchedAt   time.Time
+}
+
+// Store is a SQLite-backed materialization of protected area records,
+// keyed by wdpa_id.
+type Store struct {
+	db      *sql.DB
+	version atomic.Int64
+}
+
+// New creates a Store backed by db. The protected_areas table is
+// created by this package's migration (see db/migrations), same as
+// every other table this module owns.
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Version returns a counter bumped every time Sync upserts at least one
+// row, so callers that cache derived data (e.g. srv/tiles' encoded MVT
+// tiles) can key their cache on it instead of re-deriving on every
+// request.
+func (s *Store) Version() int64 {
+	return s.version.Load()
+}
+
+// Sync resolves every entry in list against fetcher and upserts the
+// result tagged with source (e.g. "protectedplanet" or "wfs", whichever
+// fetcher the caller built), skipping the network call entirely for any
+// WDPA ID already in the store with a fetched_at within ttl and a
+// non-empty geometry. Up to workers entries are resolved concurrently
+// (the underlying fetcher's own rate limiter, not this count, is what
+// actually bounds request pace against the upstream API); workers <= 1
+// runs sequentially. It returns the count of rows actually fetched (vs.
+// served from the TTL cache) and the first error encountered,
+// continuing past individual lookup failures so one bad keystone
+// doesn't abort the whole batch.
+func (s *Store) Sync(ctx context.Context, fetcher PAFetcher, source string, list []KeystoneInput, ttl time.Duration, workers int) (fetched int, err error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	q := dbgen.New(s.db)
+
+	type resolved struct {
+		ks  KeystoneInput
+		pa  *protectedplanet.PA
+		err error
+	}
+
+	in := make(chan KeystoneInput)
+	out := make(chan resolved)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for ks := range in {
+				pa, rerr := s.resolveOne(ctx, fetcher, q, ks, ttl)
+				out <- resolved{ks: ks, pa: pa, err: rerr}
+			}
+		}()
+	}
+	go func() {
+		defer close(in)
+		for _, ks := range list {
+			select {
+			case in <- ks:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	for r := range out {
+		if r.err != nil {
+			if err == nil {
+				err = r.err
+			}
+			continue
+		}
+		if r.pa == nil {
+			continue // already fresh in the store; nothing to upsert
+		}
+		fetched++
+
+		var geomJSON json.RawMessage
+		if r.pa.Geometry != nil && r.pa.Geometry.Geometry != nil {
+			geomJSON, _ = json.Marshal(r.pa.Geometry.Geometry)
+		}
+		if upsertErr := q.UpsertProtectedArea(ctx, dbgen.UpsertProtectedAreaParams{
+			WdpaID:      int64(r.pa.WDPAID),
+			Name:        r.pa.Name,
+			CountryIso3: r.ks.Country,
+			Iucn:        r.pa.IUCNCategory,
+			AreaKm2:     r.pa.AreaKm2,
+			GeomGeojson: geomJSON,
+			Source:      source,
+			FetchedAt:   time.Now(),
+		}); upsertErr != nil && err == nil {
+			err = fmt.Errorf("upsert wdpa %d: %w", r.pa.WDPAID, upsertErr)
+		} else if upsertErr == nil {
+			s.version.Add(1)
+		}
+	}
+
+	return fetched, err
+}
+
+// resolveOne does the network side of syncing one keystone (search,
+// freshness check, fetch), leaving the upsert to Sync's single
+// consumer goroutine since dbgen's sqlite connection isn't meant to be
+// hit concurrently from every worker.
+func (s *Store) resolveOne(ctx context.Context, fetcher PAFetcher, q *dbgen.Queries, ks KeystoneInput, ttl time.Duration) (*protectedplanet.PA, error) {
+	matches, err := fetcher.SearchByName(ctx, ks.Name, ks.Country)
+	if err != nil {
+		return nil, fmt.Errorf("search %q in %s: %w", ks.Name, ks.Country, err)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	wdpaID := matches[0].WDPAID
+
+	if existing, getErr := q.GetProtectedAreaByWDPAID(ctx, int64(wdpaID)); getErr == nil {
+		if len(existing.GeomGeojson) > 0 && time.Since(existing.FetchedAt) < ttl {
+			return nil, nil
+		}
+	}
+
+	pa, err := fetcher.GetByWDPAID(ctx, wdpaID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch wdpa %d: %w", wdpaID, err)
+	}
+	return pa, nil
+}
+
+// Get returns the stored row for wdpaID.
+func (s *Store) Get(ctx context.Context, wdpaID int) (*Row, error) {
+	row, err := dbgen.New(s.db).GetProtectedAreaByWDPAID(ctx, int64(wdpaID))
+	if err != nil {
+		return nil, err
+	}
+	return rowFromDB(row), nil
+}
+
+// List returns stored rows matching the given country/IUCN filters;
+// either may be empty to mean "any".
+func (s *Store) List(ctx context.Context, countryISO3, iucn string) ([]Row, error) {
+	rows, err := dbgen.New(s.db).ListProtectedAreas(ctx, dbgen.ListProtectedAreasParams{
+		CountryIso3: countryISO3,
+		Iucn:        iucn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Row, len(rows))
+	for i, r := range rows {
+		out[i] = *rowFromDB(r)
+	}
+	return out, nil
+}
+
+// Export returns every stored row in the same shape cmd/fetchpas used
+// to write to data/keystones.json, for deployments still relying on
+// that file (e.g. srv/areas's LoadKeystones) until they migrate to
+// reading the store directly.
+func (s *Store) Export(ctx context.Context) ([]ExportedPA, error) {
+	rows, err := s.List(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ExportedPA, len(rows))
+	for i, r := range rows {
+		out[i] = ExportedPA{
+			WDPAID:       r.WDPAID,
+			Name:         r.Name,
+			Country:      r.CountryISO3,
+			AreaKm2:      r.AreaKm2,
+			IUCNCategory: r.IUCN,
+			Geometry:     r.GeomGeoJSON,
+		}
+	}
+	return out, nil
+}
+
+// ExportedPA matches cmd/fetchpas's historical KeystoneOutput shape.
+type ExportedPA struct {
+	WDPAID       int             `json:"wdpa_id"`
+	Name         string          `json:"name"`
+	Country      string          `json:"country"`
+	AreaKm2      float64         `json:"area_km2"`
+	IUCNCategory string          `json:"iucn_category"`
+	Geometry     json.RawMessage `json:"geometry"`
+}
+
+// StaleWDPAIDs returns the WDPA IDs of rows whose fetched_at is older
+// than olderThan or whose geometry is missing, for cmd/fetchpas
+// -refresh to target instead of re-fetching everything.
+func (s *Store) StaleWDPAIDs(ctx context.Context, olderThan time.Duration) ([]int, error) {
+	rows, err := dbgen.New(s.db).ListStaleProtectedAreas(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(rows))
+	for i, id := range rows {
+		ids[i] = int(id)
+	}
+	return ids, nil
+}
+
+// RefreshStale re-fetches (via fetcher, tagging rows with source) every
+// row whose fetched_at is older than maxAge or whose geometry is
+// missing, instead of the full keystone list — the shared logic behind
+// cmd/fetchpas -refresh and the scheduler's PA-refresh job.
+func (s *Store) RefreshStale(ctx context.Context, fetcher PAFetcher, source string, maxAge time.Duration, workers int) (fetched int, err error) {
+	ids, err := s.StaleWDPAIDs(ctx, maxAge)
+	if err != nil {
+		return 0, fmt.Errorf("list stale rows: %w", err)
+	}
+
+	keystones := make([]KeystoneInput, 0, len(ids))
+	for _, id := range ids {
+		row, getErr := s.Get(ctx, id)
+		if getErr != nil {
+			continue
+		}
+		keystones = append(keystones, KeystoneInput{Country: row.CountryISO3, Name: row.Name})
+	}
+
+	return s.Sync(ctx, fetcher, source, keystones, maxAge, workers)
+}
+
+func rowFromDB(r dbgen.ProtectedArea) *Row {
+	return &Row{
+		WDPAID:      int(r.WdpaID),
+		Name:        r.Name,
+		CountryISO3: r.CountryIso3,
+		IUCN:        r.Iucn,
+		AreaKm2:     r.AreaKm2,
+		GeomGeoJSON: r.GeomGeojson,
+		Source:      r.Source,
+		FetchedAt:   r.FetchedAt,
+	}
+}
@@ -0,0 +1,364 @@
+// Package wfs fetches protected-area boundaries from an OGC Web
+// Feature Service, as a drop-in alternative to protectedplanet for
+// sources behind Protected Planet's rate limits or that publish richer
+// national datasets. It normalizes every feature it reads into
+// protectedplanet.PA so callers (cmd/fetchpas, srv/pa/store) don't need
+// a second shape to understand.
+package wfs
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"srv.exe.dev/srv/protectedplanet"
+)
+
+// ErrGMLUnsupported is returned when a server only offers GML output
+// (no application/json) for a feature type. Parsing full GML geometry
+// is a substantially larger undertaking than this client's GeoJSON
+// path; rather than ship a partial/incorrect GML parser, sources that
+// need it should publish an OutputFormat of application/json, which
+// every major WFS server (GeoServer, MapServer, deegree) supports
+// alongside GML.
+var ErrGMLUnsupported = errors.New("wfs: server returned GML and no GeoJSON output format is available")
+
+// Config configures a Client against one WFS endpoint and feature type.
+type Config struct {
+	// BaseURL is the WFS endpoint, e.g. "https://wdpa.example.org/wfs".
+	BaseURL string
+	// FeatureType is the typeName/typeNames WFS parameter, e.g.
+	// "wdpa:protected_areas".
+	FeatureType string
+	// CRS is the srsName requested for returned geometries. Empty means
+	// let the server pick its default (usually EPSG:4326).
+	CRS string
+	// Filter is an optional CQL filter string, passed through as the
+	// "cql_filter" parameter (e.g. "ISO3='TZA'").
+	Filter string
+	// PageSize bounds how many features are requested per page. Defaults
+	// to 1000 if zero.
+	PageSize int
+}
+
+// Client fetches and normalizes features from one WFS endpoint. It
+// satisfies the same PAFetcher shape as protectedplanet.Client
+// (SearchByName/GetByWDPAID), so cmd/fetchpas can use either
+// interchangeably.
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+}
+
+// NewClient creates a WFS client for cfg.
+func NewClient(cfg Config) *Client {
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 1000
+	}
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// capabilities is the small slice of GetCapabilities we actually need:
+// confirming the feature type exists and discovering its native CRS.
+type capabilities struct {
+	XMLName      xml.Name `xml:"WFS_Capabilities"`
+	FeatureTypes []struct {
+		Name       string `xml:"Name"`
+		DefaultCRS string `xml:"DefaultCRS"`
+		DefaultSRS string `xml:"DefaultSRS"`
+	} `xml:"FeatureTypeList>FeatureType"`
+}
+
+// GetCapabilities fetches and parses the server's GetCapabilities
+// document, returning an error if cfg.FeatureType isn't advertised.
+func (c *Client) GetCapabilities(ctx context.Context) error {
+	u := c.requestURL(map[string]string{
+		"service": "WFS",
+		"version": "2.0.0",
+		"request": "GetCapabilities",
+	})
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return fmt.Errorf("wfs GetCapabilities: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wfs GetCapabilities: unexpected status %d", resp.StatusCode)
+	}
+
+	var caps capabilities
+	if err := xml.NewDecoder(resp.Body).Decode(&caps); err != nil {
+		return fmt.Errorf("wfs GetCapabilities: parse response: %w", err)
+	}
+	for _, ft := range caps.FeatureTypes {
+		if ft.Name == c.cfg.FeatureType || strings.HasSuffix(ft.Name, ":"+c.cfg.FeatureType) {
+			return nil
+		}
+	}
+	return fmt.Errorf("wfs GetCapabilities: feature type %q not advertised by %s", c.cfg.FeatureType, c.cfg.BaseURL)
+}
+
+// hitCount asks the server for resultType=hits, the cheap way to learn
+// the total feature count before paging through the actual results.
+func (c *Client) hitCount(ctx context.Context) (int, error) {
+	u := c.requestURL(map[string]string{
+		"service":    "WFS",
+		"version":    "2.0.0",
+		"request":    "GetFeature",
+		"typeNames":  c.cfg.FeatureType,
+		"resultType": "hits",
+	})
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return 0, fmt.Errorf("wfs hits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var hits struct {
+		XMLName       xml.Name `xml:"FeatureCollection"`
+		NumberMatched int      `xml:"numberMatched,attr"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&hits); err != nil {
+		// Not every server answers resultType=hits with the attribute
+		// parsed this cleanly; a failure here just means we page until
+		// a short page tells us we're done, so it's not fatal.
+		return -1, nil
+	}
+	return hits.NumberMatched, nil
+}
+
+// featureCollection is the GeoJSON shape WFS 2.0's outputFormat=
+// application/json returns.
+type featureCollection struct {
+	Type     string           `json:"type"`
+	Features []geojsonFeature `json:"features"`
+}
+
+type geojsonFeature struct {
+	Type       string          `json:"type"`
+	Properties map[string]any  `json:"properties"`
+	Geometry   json.RawMessage `json:"geometry"`
+}
+
+// fetchPage retrieves one page of features starting at startIndex.
+func (c *Client) fetchPage(ctx context.Context, startIndex int) (*featureCollection, error) {
+	params := map[string]string{
+		"service":      "WFS",
+		"version":      "2.0.0",
+		"request":      "GetFeature",
+		"typeNames":    c.cfg.FeatureType,
+		"outputFormat": "application/json",
+		"startIndex":   strconv.Itoa(startIndex),
+		"count":        strconv.Itoa(c.cfg.PageSize),
+	}
+	if c.cfg.CRS != "" {
+		params["srsName"] = c.cfg.CRS
+	}
+	if c.cfg.Filter != "" {
+		params["cql_filter"] = c.cfg.Filter
+	}
+
+	resp, err := c.get(ctx, c.requestURL(params))
+	if err != nil {
+		return nil, fmt.Errorf("wfs GetFeature: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wfs GetFeature: unexpected status %d", resp.StatusCode)
+	}
+
+	ct := resp.Header.Get("Content-Type")
+	if !strings.Contains(ct, "json") {
+		return nil, ErrGMLUnsupported
+	}
+
+	var fc featureCollection
+	if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("wfs GetFeature: parse response: %w", err)
+	}
+	return &fc, nil
+}
+
+// get issues a GET request with ctx so callers can cancel a page fetch
+// mid-flight instead of blocking until the http.Client timeout.
+func (c *Client) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
+
+// fetchAll pages through every feature matching cfg.Filter.
+func (c *Client) fetchAll(ctx context.Context) ([]protectedplanet.PA, error) {
+	total, _ := c.hitCount(ctx)
+
+	var results []protectedplanet.PA
+	for start := 0; ; start += c.cfg.PageSize {
+		page, err := c.fetchPage(ctx, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range page.Features {
+			results = append(results, normalizeFeature(f))
+		}
+		if len(page.Features) < c.cfg.PageSize {
+			break
+		}
+		if total > 0 && start+len(page.Features) >= total {
+			break
+		}
+	}
+	return results, nil
+}
+
+// SearchByName returns every feature whose properties contain a
+// case-insensitive match for name, optionally narrowed to countryISO3.
+// It satisfies the same signature as protectedplanet.Client.SearchByName
+// so cmd/fetchpas can treat the two interchangeably.
+func (c *Client) SearchByName(ctx context.Context, name string, countryISO3 string) ([]protectedplanet.PA, error) {
+	all, err := c.fetchAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]protectedplanet.PA, 0, len(all))
+	for _, pa := range all {
+		if countryISO3 != "" && !strings.EqualFold(pa.Country, countryISO3) {
+			continue
+		}
+		if name != "" && !strings.Contains(strings.ToLower(pa.Name), strings.ToLower(name)) {
+			continue
+		}
+		results = append(results, pa)
+	}
+	return results, nil
+}
+
+// GetByWDPAID fetches the single feature whose WDPA_PID matches wdpaID,
+// via a cql_filter scoped request rather than paging through everything.
+func (c *Client) GetByWDPAID(ctx context.Context, wdpaID int) (*protectedplanet.PA, error) {
+	scoped := *c
+	if scoped.cfg.Filter != "" {
+		scoped.cfg.Filter = fmt.Sprintf("(%s) AND WDPA_PID=%d", c.cfg.Filter, wdpaID)
+	} else {
+		scoped.cfg.Filter = fmt.Sprintf("WDPA_PID=%d", wdpaID)
+	}
+
+	page, err := scoped.fetchPage(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Features) == 0 {
+		return nil, protectedplanet.ErrNotFound
+	}
+	pa := normalizeFeature(page.Features[0])
+	return &pa, nil
+}
+
+func (c *Client) requestURL(params map[string]string) string {
+	q := url.Values{}
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	sep := "?"
+	if strings.Contains(c.cfg.BaseURL, "?") {
+		sep = "&"
+	}
+	return c.cfg.BaseURL + sep + q.Encode()
+}
+
+// normalizeFeature maps a GeoJSON WFS feature's properties onto
+// protectedplanet.PA: WDPA_PID becomes WDPAID, ISO3 becomes Country,
+// IUCN_CAT becomes IUCNCategory, and GIS_AREA/AREA_KM2 becomes AreaKm2.
+// Property name casing varies by publisher, so lookups are
+// case-insensitive.
+func normalizeFeature(f geojsonFeature) protectedplanet.PA {
+	props := lowerKeys(f.Properties)
+
+	pa := protectedplanet.PA{
+		Name:         stringProp(props, "name", "orig_name"),
+		Country:      stringProp(props, "iso3", "parent_iso3", "iso3_list"),
+		IUCNCategory: stringProp(props, "iucn_cat", "iucn_category"),
+	}
+	if id := stringProp(props, "wdpa_pid", "wdpaid", "wdpa_id"); id != "" {
+		// WDPA_PID can carry a suffix like "555_B" for multi-part sites;
+		// only the leading numeric portion maps onto WDPAID.
+		digits := leadingDigits(id)
+		if n, err := strconv.Atoi(digits); err == nil {
+			pa.WDPAID = n
+		}
+	}
+	pa.AreaKm2 = floatProp(props, "gis_area", "area_km2", "rep_area")
+
+	if len(f.Geometry) > 0 {
+		var geom protectedplanet.GeoJSON
+		if err := json.Unmarshal(f.Geometry, &geom); err == nil {
+			pa.Geometry = &protectedplanet.GeoJSONFeature{
+				Type:     "Feature",
+				Geometry: &geom,
+			}
+		}
+	}
+
+	return pa
+}
+
+func lowerKeys(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+func stringProp(props map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := props[k]; ok {
+			switch t := v.(type) {
+			case string:
+				if t != "" {
+					return t
+				}
+			case float64:
+				return strconv.FormatFloat(t, 'f', -1, 64)
+			}
+		}
+	}
+	return ""
+}
+
+func floatProp(props map[string]any, keys ...string) float64 {
+	for _, k := range keys {
+		if v, ok := props[k]; ok {
+			switch t := v.(type) {
+			case float64:
+				return t
+			case string:
+				if f, err := strconv.ParseFloat(t, 64); err == nil {
+					return f
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
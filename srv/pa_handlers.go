@@ -0,0 +1,71 @@
+package srv
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// HandleAPIPAGet returns one protected area from PAStore by WDPA ID.
+func (s *Server) HandleAPIPAGet(w http.ResponseWriter, r *http.Request) {
+	wdpaID, err := strconv.Atoi(r.PathValue("wdpa"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid wdpa id")
+		return
+	}
+
+	row, err := s.PAStore.Get(r.Context(), wdpaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "protected area not found")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to load protected area")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(row)
+}
+
+// HandleAPIPAList returns PAStore rows matching the optional ?country=
+// (ISO3) and ?iucn= query filters.
+func (s *Server) HandleAPIPAList(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.PAStore.List(r.Context(), r.URL.Query().Get("country"), r.URL.Query().Get("iucn"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to list protected areas")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
+
+// HandleAPIPAGeometry returns just the stored GeoJSON geometry for a
+// protected area, for callers that don't need the rest of the row.
+func (s *Server) HandleAPIPAGeometry(w http.ResponseWriter, r *http.Request) {
+	wdpaID, err := strconv.Atoi(r.PathValue("wdpa"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid wdpa id")
+		return
+	}
+
+	row, err := s.PAStore.Get(r.Context(), wdpaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(w, http.StatusNotFound, "protected area not found")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to load protected area")
+		return
+	}
+	if len(row.GeomGeoJSON) == 0 {
+		writeJSONError(w, http.StatusNotFound, "no geometry stored for this protected area")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(row.GeomGeoJSON)
+}
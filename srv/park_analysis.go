@@ -2,8 +2,13 @@ package srv
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
+
+	"srv.exe.dev/srv/areas"
+	"srv.exe.dev/srv/osmroads"
 )
 
 // ParkAnalysisData is passed to the park_analysis template
@@ -149,63 +154,145 @@ func (s *Server) HandleParkBoundary(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Park not found", http.StatusNotFound)
 }
 
-// HandleParkRoads returns road data for a park from OSM
+// defaultRoadlessThresholdKm is how far from a road a point must be to
+// count as "roadless" when HandleParkRoads isn't given a
+// ?threshold_km= override.
+const defaultRoadlessThresholdKm = 1.0
+
+// HandleParkRoads returns OSM road geometry and road-density metrics
+// for a park: every major-class highway clipped to the park's polygon
+// (plus buffer), its total length, a per-highway-class breakdown, and
+// the percentage of the park more than ?threshold_km= (default
+// defaultRoadlessThresholdKm) from the nearest road. Results are cached
+// on disk (see osmRoadsCachePath) since a public Overpass instance is
+// too fragile and too rate-limited to query on every page load.
+// GET /api/park/{id}/roads?threshold_km=1
 func (s *Server) HandleParkRoads(w http.ResponseWriter, r *http.Request) {
 	parkID := r.PathValue("id")
 	if parkID == "" {
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
+	if s.AreaStore == nil {
+		http.Error(w, "Area store not initialized", http.StatusInternalServerError)
+		return
+	}
 
-	// Find park bbox
+	var area *areas.ProtectedArea
 	var bbox []float64
-	if s.AreaStore != nil {
-		for _, area := range s.AreaStore.Areas {
-			if area.ID == parkID {
-				var coords interface{}
-				if err := json.Unmarshal(area.Geometry.Coordinates, &coords); err == nil {
-					bbox = extractBBox(area.Geometry.Type, coords)
-				}
-				break
+	for i := range s.AreaStore.Areas {
+		if s.AreaStore.Areas[i].ID == parkID {
+			area = &s.AreaStore.Areas[i]
+			var coords interface{}
+			if err := json.Unmarshal(area.Geometry.Coordinates, &coords); err == nil {
+				bbox = extractBBox(area.Geometry.Type, coords)
 			}
+			break
 		}
 	}
-
-	if len(bbox) != 4 {
+	if area == nil || len(bbox) != 4 {
 		http.Error(w, "Park bbox not found", http.StatusNotFound)
 		return
 	}
 
-	// TODO: Query Overpass API for roads in bbox
+	thresholdKm := defaultRoadlessThresholdKm
+	if v := r.URL.Query().Get("threshold_km"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid threshold_km", http.StatusBadRequest)
+			return
+		}
+		thresholdKm = parsed
+	}
+
+	cachePath := osmRoadsCachePath(parkID, bbox, thresholdKm)
+	if cached, ok := loadOSMRoadsCache(cachePath); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(cached)
+		return
+	}
+
+	bboxArr := [4]float64{bbox[0], bbox[1], bbox[2], bbox[3]}
+	body, err := osmroads.Fetch(r.Context(), s.OverpassHTTP, s.OverpassAPIURL, osmroads.BuildQuery(bboxArr))
+	if err != nil {
+		slog.Error("fetch osm roads", "park_id", parkID, "error", err)
+		http.Error(w, "failed to fetch road data from Overpass", http.StatusBadGateway)
+		return
+	}
+	ways, err := osmroads.ParseResponse(body)
+	if err != nil {
+		slog.Error("parse osm roads", "park_id", parkID, "error", err)
+		http.Error(w, "failed to parse Overpass response", http.StatusInternalServerError)
+		return
+	}
+
+	limiter := s.AreaStore.NewLimiter([]string{parkID})
+	var clipped []osmroads.Way
+	for _, way := range ways {
+		pts := make([]areas.Point, len(way.Points))
+		for i, p := range way.Points {
+			pts[i] = areas.Point{Lat: p.Lat, Lon: p.Lon}
+		}
+		for _, piece := range limiter.ClipLineString(pts) {
+			if len(piece) < 2 {
+				continue
+			}
+			clippedPts := make([]osmroads.Point, len(piece))
+			for i, p := range piece {
+				clippedPts[i] = osmroads.Point{Lat: p.Lat, Lon: p.Lon}
+			}
+			clipped = append(clipped, osmroads.Way{ID: way.ID, Highway: way.Highway, Points: clippedPts})
+		}
+	}
+
+	var totalKm float64
+	byHighway := map[string]float64{}
+	features := make([]interface{}, 0, len(clipped))
+	for _, way := range clipped {
+		length := way.LengthKm()
+		totalKm += length
+		byHighway[way.Highway] += length
+
+		coords := make([][]float64, len(way.Points))
+		for i, p := range way.Points {
+			coords[i] = []float64{p.Lon, p.Lat}
+		}
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"properties": map[string]interface{}{
+				"highway":    way.Highway,
+				"length_km":  length,
+				"osm_way_id": way.ID,
+			},
+			"geometry": map[string]interface{}{
+				"type":        "LineString",
+				"coordinates": coords,
+			},
+		})
+	}
+
+	roadlessPercent := osmroads.RoadlessPercent(bboxArr, clipped, thresholdKm, area.ContainsPoint)
+
 	result := map[string]interface{}{
 		"type":     "FeatureCollection",
-		"features": []interface{}{},
+		"features": features,
 		"properties": map[string]interface{}{
-			"roadless_percent": 0.0,
-			"total_road_km":    0.0,
-			"data_source":      "osm",
-			"note":             "Road data pending - Overpass API integration needed",
+			"roadless_percent":    roadlessPercent,
+			"roadless_threshold":  thresholdKm,
+			"total_road_km":       totalKm,
+			"road_km_by_highway":  byHighway,
+			"data_source":         "osm",
+			"overpass_fetched_at": time.Now().UTC().Format(time.RFC3339),
 		},
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
-}
-
-// FetchOSMRoads fetches road data from Overpass API
-func FetchOSMRoads(bbox []float64) ([]byte, error) {
-	if len(bbox) != 4 {
-		return nil, fmt.Errorf("invalid bbox")
+	data, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, "failed to encode road data", http.StatusInternalServerError)
+		return
 	}
+	saveOSMRoadsCache(cachePath, data)
 
-	query := fmt.Sprintf(`
-		[out:json][timeout:60];
-		(
-		  way["highway"~"^(motorway|trunk|primary|secondary|tertiary|unclassified|track)$"](%f,%f,%f,%f);
-		);
-		out geom;
-	`, bbox[1], bbox[0], bbox[3], bbox[2])
-
-	_ = query
-	return nil, fmt.Errorf("not implemented")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
 }
@@ -0,0 +1,304 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ParkRankingMetric identifies one of the peer-comparison metrics
+// HandleAPIParkCompare ranks parks on.
+type ParkRankingMetric string
+
+const (
+	MetricFireResponseRate        ParkRankingMetric = "fire_response_rate"
+	MetricRoadlessPercentage      ParkRankingMetric = "roadless_percentage"
+	MetricDeforestationPerAreaKm2 ParkRankingMetric = "deforestation_km2_per_km2"
+	MetricSettlementsPerAreaKm2   ParkRankingMetric = "settlements_per_km2"
+)
+
+// higherIsBetter reports whether a larger value of metric is the more
+// favorable outcome for a park. Fire response rate and roadless
+// percentage are "more is better"; deforestation and settlement density
+// are "less is better".
+func (m ParkRankingMetric) higherIsBetter() bool {
+	switch m {
+	case MetricFireResponseRate, MetricRoadlessPercentage:
+		return true
+	default:
+		return false
+	}
+}
+
+// MetricRank is one park's standing on a single ParkRankingMetric among
+// its peer group: its raw value, its rank (1 = best outcome), and its
+// percentile (100 = best outcome, regardless of whether the metric is
+// higher-is-better or lower-is-better).
+type MetricRank struct {
+	Value      float64 `json:"value"`
+	Rank       int     `json:"rank"`
+	Percentile float64 `json:"percentile"`
+}
+
+// ParkRanking is one park's MetricRank for every metric
+// HandleAPIParkCompare computes, keyed by ParkRankingMetric.
+type ParkRanking struct {
+	ParkID  string                           `json:"park_id"`
+	Metrics map[ParkRankingMetric]MetricRank `json:"metrics"`
+}
+
+// ParkCompareResponse is the body HandleAPIParkCompare returns: the full
+// ParkStats for every requested park, its peer-group ranking, and any
+// cross-park outlier insights worth surfacing first.
+type ParkCompareResponse struct {
+	Parks    []ParkStats   `json:"parks"`
+	Rankings []ParkRanking `json:"rankings"`
+	Insights []string      `json:"insights,omitempty"`
+}
+
+// HandleAPIParkCompare returns side-by-side ParkStats for a set of
+// parks, plus a peer-group ranking across fire response rate, roadless
+// percentage, deforestation, and settlement density.
+// GET /api/parks/compare?ids=a,b,c&year=2024
+// GET /api/parks/compare?region=Amazon&year=2024
+func (s *Server) HandleAPIParkCompare(w http.ResponseWriter, r *http.Request) {
+	internalIDs := s.resolveCompareParkIDs(r)
+	if len(internalIDs) == 0 {
+		http.Error(w, "ids or region required", http.StatusBadRequest)
+		return
+	}
+
+	fromYear, toYear := parseStatsYearRange(r)
+
+	areaKm2 := make(map[string]float64, len(internalIDs))
+	if s.AreaStore != nil {
+		for i := range s.AreaStore.Areas {
+			areaKm2[s.AreaStore.Areas[i].ID] = s.AreaStore.Areas[i].AreaKm2
+		}
+	}
+
+	parks := make([]ParkStats, 0, len(internalIDs))
+	for _, id := range internalIDs {
+		stats, err := s.computeParkStats(r.Context(), id, fromYear, toYear)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		parks = append(parks, stats)
+	}
+
+	rankings, insights := rankParks(parks, areaKm2)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ParkCompareResponse{
+		Parks:    parks,
+		Rankings: rankings,
+		Insights: insights,
+	})
+}
+
+// resolveCompareParkIDs turns the ?ids= or ?region= query parameters
+// into internal park_ids. ?ids= is a comma-separated list of WDPA or
+// internal IDs, resolved the same way HandleAPIParkStats resolves its
+// path parameter. ?region= has no true counterpart in this codebase -
+// ProtectedArea carries a Country/CountryCode but no biome or GADM
+// region reference - so it's approximated as a case-insensitive
+// substring match against each park's Country, which is the closest
+// available grouping.
+func (s *Server) resolveCompareParkIDs(r *http.Request) []string {
+	if s.AreaStore == nil {
+		return nil
+	}
+
+	if idsParam := r.URL.Query().Get("ids"); idsParam != "" {
+		var ids []string
+		for _, raw := range strings.Split(idsParam, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			ids = append(ids, s.resolveInternalParkID(raw))
+		}
+		return ids
+	}
+
+	region := strings.TrimSpace(r.URL.Query().Get("region"))
+	if region == "" {
+		return nil
+	}
+	var ids []string
+	for i := range s.AreaStore.Areas {
+		area := &s.AreaStore.Areas[i]
+		if strings.Contains(strings.ToLower(area.Country), strings.ToLower(region)) {
+			ids = append(ids, area.ID)
+		}
+	}
+	return ids
+}
+
+// rankParks computes a ParkRanking per park across every
+// ParkRankingMetric, and generates cross-park insights for parks that
+// fall more than 1.5 IQR below (for higher-is-better metrics) or above
+// (for lower-is-better metrics) the peer-group median - the standard
+// Tukey outlier fence.
+func rankParks(parks []ParkStats, areaKm2 map[string]float64) ([]ParkRanking, []string) {
+	metrics := []ParkRankingMetric{
+		MetricFireResponseRate,
+		MetricRoadlessPercentage,
+		MetricDeforestationPerAreaKm2,
+		MetricSettlementsPerAreaKm2,
+	}
+
+	rankings := make(map[string]*ParkRanking, len(parks))
+	for _, p := range parks {
+		rankings[p.ParkID] = &ParkRanking{ParkID: p.ParkID, Metrics: map[ParkRankingMetric]MetricRank{}}
+	}
+
+	var insights []string
+	for _, metric := range metrics {
+		values := make(map[string]float64, len(parks))
+		for _, p := range parks {
+			if v, ok := metricValue(metric, p, areaKm2[p.ParkID]); ok {
+				values[p.ParkID] = v
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		order := make([]string, 0, len(values))
+		for id := range values {
+			order = append(order, id)
+		}
+		sort.Slice(order, func(i, j int) bool {
+			if metric.higherIsBetter() {
+				return values[order[i]] > values[order[j]]
+			}
+			return values[order[i]] < values[order[j]]
+		})
+
+		n := len(order)
+		for rank, id := range order {
+			percentile := 100.0
+			if n > 1 {
+				percentile = 100.0 * float64(n-1-rank) / float64(n-1)
+			}
+			rankings[id].Metrics[metric] = MetricRank{
+				Value:      values[id],
+				Rank:       rank + 1,
+				Percentile: percentile,
+			}
+		}
+
+		median, iqr := medianAndIQR(values)
+		if iqr == 0 {
+			continue
+		}
+		fence := median - 1.5*iqr
+		if !metric.higherIsBetter() {
+			fence = median + 1.5*iqr
+		}
+		for id, v := range values {
+			isOutlier := metric.higherIsBetter() && v < fence
+			isOutlier = isOutlier || (!metric.higherIsBetter() && v > fence)
+			if isOutlier {
+				insights = append(insights, outlierInsight(metric, id, v, median, n))
+			}
+		}
+	}
+
+	out := make([]ParkRanking, 0, len(parks))
+	for _, p := range parks {
+		out = append(out, *rankings[p.ParkID])
+	}
+	return out, insights
+}
+
+// metricValue extracts metric's raw value from a single park's stats,
+// returning ok=false if the underlying data isn't available for that
+// park (e.g. no fire infractions recorded, or no known park area for a
+// per-km2 metric).
+func metricValue(metric ParkRankingMetric, p ParkStats, parkAreaKm2 float64) (float64, bool) {
+	switch metric {
+	case MetricFireResponseRate:
+		if p.Fire == nil || p.Fire.GroupsEntered == 0 {
+			return 0, false
+		}
+		return p.Fire.ResponseRate, true
+	case MetricRoadlessPercentage:
+		if p.Roadless == nil {
+			return 0, false
+		}
+		return p.Roadless.RoadlessPercentage, true
+	case MetricDeforestationPerAreaKm2:
+		if p.Deforestation == nil || parkAreaKm2 <= 0 {
+			return 0, false
+		}
+		return p.Deforestation.TotalLossKm2 / parkAreaKm2, true
+	case MetricSettlementsPerAreaKm2:
+		if p.Settlement == nil || parkAreaKm2 <= 0 {
+			return 0, false
+		}
+		return float64(p.Settlement.SettlementCount) / parkAreaKm2, true
+	default:
+		return 0, false
+	}
+}
+
+// medianAndIQR returns the median and interquartile range (Q3 - Q1) of
+// values, using the nearest-rank method (no interpolation) to stay
+// consistent with how this codebase computes percentiles elsewhere
+// (see gpx.percentileKmh).
+func medianAndIQR(values map[string]float64) (median, iqr float64) {
+	sorted := make([]float64, 0, len(values))
+	for _, v := range values {
+		sorted = append(sorted, v)
+	}
+	sort.Float64s(sorted)
+
+	median = nearestRank(sorted, 0.5)
+	q1 := nearestRank(sorted, 0.25)
+	q3 := nearestRank(sorted, 0.75)
+	return median, q3 - q1
+}
+
+// nearestRank returns the value at percentile p (0-1) of sorted, which
+// must already be ascending.
+func nearestRank(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// outlierInsight formats a human-readable flag for a park that fell
+// outside the peer-group's Tukey fence on metric.
+func outlierInsight(metric ParkRankingMetric, parkID string, value, peerMedian float64, peerCount int) string {
+	var label, format string
+	switch metric {
+	case MetricFireResponseRate:
+		label, format = "fire response rate", "%.0f%%"
+	case MetricRoadlessPercentage:
+		label, format = "roadless percentage", "%.0f%%"
+	case MetricDeforestationPerAreaKm2:
+		label, format = "deforestation per km²", "%.3f km²/km²"
+	case MetricSettlementsPerAreaKm2:
+		label, format = "settlements per km²", "%.3f/km²"
+	}
+	direction := "lowest"
+	if !metric.higherIsBetter() {
+		direction = "highest"
+	}
+	return fmt.Sprintf("Park %s has the %s %s among %d compared parks (%s vs. peer median %s).",
+		parkID, direction, label, peerCount, fmt.Sprintf(format, value), fmt.Sprintf(format, peerMedian))
+}
@@ -4,10 +4,34 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/auth"
 )
 
+// requireDocumentRead gates GET /api/parks/{id}/documents: if
+// AllowAnonymousDocumentReads is set, the read is public, matching the
+// static-download bypass this endpoint replaces; otherwise it falls
+// back to the normal viewer-permission check like any other read.
+func (s *Server) requireDocumentRead(next http.HandlerFunc) http.HandlerFunc {
+	if s.AllowAnonymousDocumentReads {
+		return next
+	}
+	return s.RequirePermission(auth.PermissionViewer)(next)
+}
+
+// documentCreateRequest is the request body for HandleAPIParkDocumentCreate.
+type documentCreateRequest struct {
+	Category    string  `json:"category"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	URL         *string `json:"url,omitempty"`
+	FileType    *string `json:"file_type,omitempty"`
+	Year        *int64  `json:"year,omitempty"`
+	Summary     *string `json:"summary,omitempty"`
+}
+
 // Document category constants
 const (
 	DocCategoryManagementPlan = "management_plan"
@@ -28,6 +52,15 @@ type DocumentResponse struct {
 	FileType    *string `json:"file_type,omitempty"`
 	Year        *int64  `json:"year,omitempty"`
 	Summary     *string `json:"summary,omitempty"`
+
+	// SizeBytes, HumanSize, and ModTime are computed by stat-ing the
+	// document's file on disk (see document_browse.go's documentFileStat)
+	// rather than stored on the row, so they're only populated by the
+	// /parks/{id}/documents/ browse view - plain HandleAPIParkDocuments
+	// leaves them nil/empty.
+	SizeBytes *int64     `json:"size_bytes,omitempty"`
+	HumanSize string     `json:"human_size,omitempty"`
+	ModTime   *time.Time `json:"mod_time,omitempty"`
 }
 
 // HandleAPIParkDocuments returns all documents for a protected area.
@@ -91,6 +124,67 @@ func (s *Server) HandleAPIParkDocuments(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// HandleAPIParkDocumentCreate records a new document for a protected
+// area. POST /api/parks/{id}/documents, gated by RequirePermission(auth.PermissionEditor) -
+// anonymous read of the same path is allowed when AllowAnonymousDocumentReads
+// is set, but every write needs an editor (or admin) account.
+func (s *Server) HandleAPIParkDocumentCreate(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	if paID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "missing park ID"})
+		return
+	}
+
+	var req documentCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Category == "" || req.Title == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "category and title are required"})
+		return
+	}
+
+	ctx := r.Context()
+	doc, err := dbgen.New(s.DB).CreateParkDocument(ctx, dbgen.CreateParkDocumentParams{
+		PaID:        paID,
+		Category:    req.Category,
+		Title:       req.Title,
+		Description: req.Description,
+		FileUrl:     req.URL,
+		FileType:    req.FileType,
+		Year:        req.Year,
+		Summary:     req.Summary,
+	})
+	if err != nil {
+		slog.Error("failed to create park document", "pa_id", paID, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(DocumentResponse{
+		ID:          doc.ID,
+		PaID:        doc.PaID,
+		Category:    doc.Category,
+		Title:       doc.Title,
+		Description: doc.Description,
+		URL:         doc.FileUrl,
+		FileType:    doc.FileType,
+		Year:        doc.Year,
+		Summary:     doc.Summary,
+	})
+}
+
 // HandleAPIParkManagementPlans returns management plans for a protected area.
 // GET /api/parks/{id}/management-plans
 // This is a convenience endpoint that filters by management_plan category.
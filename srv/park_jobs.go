@@ -0,0 +1,302 @@
+package srv
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ParkJobSource identifies which per-park data pipeline a job runs,
+// matching ParkDataStatus's fields (see HandleAPIParkDataStatus).
+type ParkJobSource string
+
+const (
+	ParkJobSourceFireAnalysis     ParkJobSource = "fire_analysis"
+	ParkJobSourceGroupInfractions ParkJobSource = "group_infractions"
+	ParkJobSourcePublications     ParkJobSource = "publications"
+	ParkJobSourceGHSL             ParkJobSource = "ghsl"
+	ParkJobSourceRoadless         ParkJobSource = "roadless"
+)
+
+// ParkJobState is a park job's lifecycle stage, persisted in
+// park_jobs.state.
+type ParkJobState string
+
+const (
+	ParkJobQueued    ParkJobState = "queued"
+	ParkJobRunning   ParkJobState = "running"
+	ParkJobSucceeded ParkJobState = "succeeded"
+	ParkJobFailed    ParkJobState = "failed"
+	ParkJobCancelled ParkJobState = "cancelled"
+)
+
+// ParkJob is one row of park_jobs, the shape GET /api/jobs/{id} reports
+// and that HandleAPIParkDataStatus attaches to a DataSourceStatus.
+type ParkJob struct {
+	JobID      string        `json:"job_id"`
+	ParkID     string        `json:"park_id"`
+	Source     ParkJobSource `json:"source"`
+	State      ParkJobState  `json:"state"`
+	Progress   int           `json:"progress"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	ETag       string        `json:"etag,omitempty"`
+}
+
+// ETASeconds extrapolates time remaining from elapsed time and Progress,
+// the same linear-rate idea srv/jobs uses for byte throughput, just
+// against a percent counter instead. Zero unless the job is actively
+// running and has reported progress.
+func (j ParkJob) ETASeconds() float64 {
+	if j.State != ParkJobRunning || j.Progress <= 0 {
+		return 0
+	}
+	elapsed := time.Since(j.StartedAt).Seconds()
+	return elapsed*100/float64(j.Progress) - elapsed
+}
+
+// ParkJobWorker computes one source's data for one park. It should call
+// report with 0-100 as it makes progress, so ParkJobRunner can persist
+// it for GET /api/jobs/{id} polling; a worker that can't usefully report
+// partial progress may skip calling report and let the runner record 100
+// once it returns successfully.
+type ParkJobWorker func(ctx context.Context, parkID string, report func(pct int)) error
+
+// errNoParkJobWorker is returned by Enqueue for a source with no
+// registered worker, e.g. ghsl/roadless until this repo grows a Go-side
+// pipeline for them.
+var errNoParkJobWorker = errors.New("no worker registered for this source")
+
+// parkJobRunnerWorkers caps concurrent per-park analysis jobs, the same
+// rationale as jobRunnerWorkers in job_runner.go.
+const parkJobRunnerWorkers = 3
+
+// ParkJobRunner dispatches per-park analysis jobs (fire analysis, group
+// infractions, publications, GHSL, roadless) to pluggable ParkJobWorkers,
+// persisting state to park_jobs so HandleAPIParkDataStatus and
+// GET /api/jobs/{id} can report live progress instead of ParkDataStatus's
+// old row-count inference.
+type ParkJobRunner struct {
+	db      *sql.DB
+	workers map[ParkJobSource]ParkJobWorker
+	sem     chan struct{}
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+
+	// onChange, if set, is called with a job's park ID once it reaches a
+	// terminal state, so the server can publish an updated ParkDataStatus
+	// to ParkStatusHub without ParkJobRunner needing to import srv's
+	// HTTP/hub types itself. Set via SetOnChange.
+	onChange func(parkID string)
+}
+
+// NewParkJobRunner creates a runner backed by db for persistence. Call
+// RegisterWorker for each source before Enqueue can start it.
+func NewParkJobRunner(db *sql.DB) *ParkJobRunner {
+	return &ParkJobRunner{
+		db:      db,
+		workers: make(map[ParkJobSource]ParkJobWorker),
+		sem:     make(chan struct{}, parkJobRunnerWorkers),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// SetOnChange registers fn to be called with a job's park ID every time
+// a job reaches a terminal state (succeeded, failed, or cancelled).
+func (r *ParkJobRunner) SetOnChange(fn func(parkID string)) {
+	r.onChange = fn
+}
+
+// RegisterWorker wires source to worker. A source left unregistered
+// still appears in ParkDataStatus (see HandleAPIParkDataStatus); Enqueue
+// just rejects it with errNoParkJobWorker.
+func (r *ParkJobRunner) RegisterWorker(source ParkJobSource, worker ParkJobWorker) {
+	r.workers[source] = worker
+}
+
+// inputsHash derives park_jobs.inputs_hash for dedup. A park's inputs
+// for a given source don't vary by request today, so this reduces to
+// park_id+source, but extra is kept as a separate argument so a future
+// source that depends on e.g. a date range can fold its own params in
+// without changing Enqueue's dedup logic.
+func inputsHash(parkID string, source ParkJobSource, extra string) string {
+	sum := sha256.Sum256([]byte(string(source) + ":" + parkID + ":" + extra))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Enqueue starts a job computing source for parkID, or returns the ID of
+// an already-queued/running job with the same park_id+source+inputs_hash
+// instead of starting a duplicate.
+func (r *ParkJobRunner) Enqueue(ctx context.Context, parkID string, source ParkJobSource) (jobID string, deduped bool, err error) {
+	worker, ok := r.workers[source]
+	if !ok {
+		return "", false, errNoParkJobWorker
+	}
+
+	hash := inputsHash(parkID, source, "")
+	if existing, ok, err := r.findActive(ctx, parkID, source, hash); err != nil {
+		return "", false, err
+	} else if ok {
+		return existing, true, nil
+	}
+
+	jobID = fmt.Sprintf("pjob-%s-%s-%d", source, parkID, time.Now().UnixNano())
+	now := time.Now()
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO park_jobs (job_id, park_id, source, state, progress, started_at, inputs_hash)
+		VALUES (?, ?, ?, ?, 0, ?, ?)
+	`, jobID, parkID, string(source), string(ParkJobQueued), now, hash); err != nil {
+		return "", false, fmt.Errorf("enqueue park job: %w", err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	r.cancels[jobID] = cancel
+	r.mu.Unlock()
+
+	go r.run(runCtx, jobID, parkID, worker, cancel)
+
+	return jobID, false, nil
+}
+
+func (r *ParkJobRunner) findActive(ctx context.Context, parkID string, source ParkJobSource, hash string) (string, bool, error) {
+	var jobID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT job_id FROM park_jobs
+		WHERE park_id = ? AND source = ? AND inputs_hash = ? AND state IN ('queued', 'running')
+		ORDER BY started_at DESC LIMIT 1
+	`, parkID, string(source), hash).Scan(&jobID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return jobID, true, nil
+}
+
+func (r *ParkJobRunner) run(ctx context.Context, jobID, parkID string, worker ParkJobWorker, cancel context.CancelFunc) {
+	r.sem <- struct{}{}
+	defer func() { <-r.sem }()
+
+	r.setState(jobID, ParkJobRunning, "")
+
+	err := worker(ctx, parkID, func(pct int) { r.setProgress(jobID, pct) })
+	wasCancelled := ctx.Err() != nil
+
+	r.mu.Lock()
+	delete(r.cancels, jobID)
+	r.mu.Unlock()
+	cancel()
+
+	switch {
+	case wasCancelled:
+		r.setState(jobID, ParkJobCancelled, "")
+	case err != nil:
+		r.setState(jobID, ParkJobFailed, err.Error())
+	default:
+		r.setProgress(jobID, 100)
+		r.setState(jobID, ParkJobSucceeded, "")
+	}
+
+	if r.onChange != nil {
+		r.onChange(parkID)
+	}
+}
+
+func (r *ParkJobRunner) setState(jobID string, state ParkJobState, errMsg string) {
+	var finishedAt interface{}
+	if state == ParkJobSucceeded || state == ParkJobFailed || state == ParkJobCancelled {
+		finishedAt = time.Now()
+	}
+	if _, err := r.db.Exec(`
+		UPDATE park_jobs SET state = ?, error = ?, finished_at = ? WHERE job_id = ?
+	`, string(state), errMsg, finishedAt, jobID); err != nil {
+		slog.Warn("failed to update park job state", "job_id", jobID, "state", state, "error", err)
+	}
+}
+
+func (r *ParkJobRunner) setProgress(jobID string, pct int) {
+	if _, err := r.db.Exec(`UPDATE park_jobs SET progress = ? WHERE job_id = ?`, pct, jobID); err != nil {
+		slog.Warn("failed to update park job progress", "job_id", jobID, "error", err)
+	}
+}
+
+// Get returns one park job by ID, for GET /api/jobs/{id}.
+func (r *ParkJobRunner) Get(ctx context.Context, jobID string) (ParkJob, bool, error) {
+	return scanParkJob(r.db.QueryRowContext(ctx, `
+		SELECT job_id, park_id, source, state, progress, started_at, finished_at, error, etag
+		FROM park_jobs WHERE job_id = ?
+	`, jobID))
+}
+
+// LatestForSource returns the most recently started job for
+// parkID+source, regardless of state, so HandleAPIParkDataStatus can
+// attach in-flight or historical job info to a DataSourceStatus.
+func (r *ParkJobRunner) LatestForSource(ctx context.Context, parkID string, source ParkJobSource) (ParkJob, bool, error) {
+	return scanParkJob(r.db.QueryRowContext(ctx, `
+		SELECT job_id, park_id, source, state, progress, started_at, finished_at, error, etag
+		FROM park_jobs WHERE park_id = ? AND source = ?
+		ORDER BY started_at DESC LIMIT 1
+	`, parkID, string(source)))
+}
+
+func scanParkJob(row *sql.Row) (ParkJob, bool, error) {
+	var pj ParkJob
+	var finishedAt sql.NullTime
+	var etag sql.NullString
+	err := row.Scan(&pj.JobID, &pj.ParkID, &pj.Source, &pj.State, &pj.Progress, &pj.StartedAt, &finishedAt, &pj.Error, &etag)
+	if err == sql.ErrNoRows {
+		return ParkJob{}, false, nil
+	}
+	if err != nil {
+		return ParkJob{}, false, err
+	}
+	if finishedAt.Valid {
+		pj.FinishedAt = &finishedAt.Time
+	}
+	if etag.Valid {
+		pj.ETag = etag.String
+	}
+	return pj, true, nil
+}
+
+// Cancel requests that jobID stop, the same idiom as JobRunner.Cancel.
+// It reports whether a running/queued job with that ID was found.
+func (r *ParkJobRunner) Cancel(jobID string) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[jobID]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// runPublicationsJob is the "publications" source's ParkJobWorker,
+// wrapping the same fetchPublicationsForPA call runResearchSync uses for
+// its periodic sweep (see research.go), so an on-demand enqueue for one
+// park reuses the real OpenAlex sync logic instead of a parallel copy.
+func (s *Server) runPublicationsJob(ctx context.Context, parkID string, report func(pct int)) error {
+	name, country := parkID, ""
+	if s.AreaStore != nil {
+		for _, area := range s.AreaStore.Areas {
+			if area.WDPAID == parkID || area.ID == parkID {
+				name, country = area.Name, area.Country
+				break
+			}
+		}
+	}
+	report(10)
+	_, err := s.fetchPublicationsForPA(ctx, parkID, name, country)
+	return err
+}
@@ -0,0 +1,74 @@
+package srv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HandleAPIParkJobEnqueue starts (or returns the ID of an existing
+// in-flight) job computing {source} for park {id}.
+// POST /api/parks/{id}/jobs/{source}
+func (s *Server) HandleAPIParkJobEnqueue(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	source := ParkJobSource(r.PathValue("source"))
+	if parkID == "" {
+		http.Error(w, "park ID required", http.StatusBadRequest)
+		return
+	}
+
+	jobID, deduped, err := s.ParkJobs.Enqueue(r.Context(), parkID, source)
+	if errors.Is(err, errNoParkJobWorker) {
+		http.Error(w, fmt.Sprintf("no worker registered for source %q", source), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to enqueue job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if deduped {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"job_id": jobID, "deduped": deduped})
+}
+
+// HandleAPIJobGet returns one park job's state for polling.
+// GET /api/jobs/{id}
+func (s *Server) HandleAPIJobGet(w http.ResponseWriter, r *http.Request) {
+	job, ok, err := s.ParkJobs.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "failed to read job", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id":      job.JobID,
+		"park_id":     job.ParkID,
+		"source":      job.Source,
+		"state":       job.State,
+		"progress":    job.Progress,
+		"started_at":  job.StartedAt,
+		"finished_at": job.FinishedAt,
+		"error":       job.Error,
+		"eta_seconds": job.ETASeconds(),
+	})
+}
+
+// HandleAPIJobCancel cancels a running or queued park job.
+// DELETE /api/jobs/{id}
+func (s *Server) HandleAPIJobCancel(w http.ResponseWriter, r *http.Request) {
+	if !s.ParkJobs.Cancel(r.PathValue("id")) {
+		http.Error(w, "job not running", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,188 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// parkMetricsLockName identifies the advisory lock acquireAggregateLock
+// takes while materializing park_metrics_daily/park_metrics_current, so
+// two pods (or a manual "5mp aggregate parks" run racing the scheduled
+// job) don't aggregate the same snapshot concurrently.
+const parkMetricsLockName = "park_metrics"
+
+// parkMetricsLockTTL bounds how long a held lock is honored before
+// another pod is allowed to take over, in case the holder crashed
+// mid-run instead of releasing it.
+const parkMetricsLockTTL = 30 * time.Minute
+
+// acquireAggregateLock tries to take the named advisory lock, returning
+// true if this call won it. It's a plain table row under the hood
+// (aggregate_locks: name, holder, acquired_at, expires_at) rather than a
+// database-native advisory lock, since sqlite has no such primitive;
+// the INSERT ... ON CONFLICT ... WHERE clause below only overwrites an
+// existing row once it's expired, which is what makes this safe to call
+// from multiple pods at once.
+func (s *Server) acquireAggregateLock(name string, ttl time.Duration) (bool, error) {
+	holder := lockHolderID()
+	now := time.Now()
+	res, err := s.DB.Exec(`
+		INSERT INTO aggregate_locks (name, holder, acquired_at, expires_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			holder = excluded.holder,
+			acquired_at = excluded.acquired_at,
+			expires_at = excluded.expires_at
+		WHERE aggregate_locks.expires_at < excluded.acquired_at
+	`, name, holder, now, now.Add(ttl))
+	if err != nil {
+		return false, fmt.Errorf("acquire aggregate lock %q: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquire aggregate lock %q: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// releaseAggregateLock drops name's lock row so the next scheduled run
+// doesn't have to wait out parkMetricsLockTTL.
+func (s *Server) releaseAggregateLock(name string) {
+	if _, err := s.DB.Exec(`DELETE FROM aggregate_locks WHERE name = ?`, name); err != nil {
+		slog.Warn("release aggregate lock", "name", name, "error", err)
+	}
+}
+
+func lockHolderID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// MaterializeParkMetrics computes one ParkExportRow per protected area
+// (the same four GROUP BY queries HandleAPIExportParks used to run
+// live, via exportRowSeq) and upserts them into park_metrics_daily,
+// keyed by (park_id, snapshot_date), and park_metrics_current, keyed by
+// park_id alone. snapshotDate is truncated to a calendar day.
+//
+// It's called both by cmd/5mp's "aggregate parks" subcommand and by the
+// park_metrics_refresh scheduled job (see scheduler_jobs.go); either
+// caller skips the run entirely (ok=false, err=nil) if another pod
+// currently holds the lock.
+func (s *Server) MaterializeParkMetrics(ctx context.Context, snapshotDate time.Time) (rowCount int, ok bool, err error) {
+	got, err := s.acquireAggregateLock(parkMetricsLockName, parkMetricsLockTTL)
+	if err != nil {
+		return 0, false, err
+	}
+	if !got {
+		return 0, false, nil
+	}
+	defer s.releaseAggregateLock(parkMetricsLockName)
+
+	start := time.Now()
+	date := snapshotDate.UTC().Format("2006-01-02")
+	generatedAt := time.Now()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, true, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for row := range s.exportRowSeq(includeSet{all: true}) {
+		if err := upsertParkMetricsDaily(tx, row, date, generatedAt); err != nil {
+			return rowCount, true, fmt.Errorf("upsert park_metrics_daily %s: %w", row.ParkID, err)
+		}
+		if err := upsertParkMetricsCurrent(tx, row, generatedAt); err != nil {
+			return rowCount, true, fmt.Errorf("upsert park_metrics_current %s: %w", row.ParkID, err)
+		}
+		rowCount++
+	}
+
+	runErr := recordAggregateRun(tx, parkMetricsLockName, generatedAt, rowCount, time.Since(start))
+
+	if err := tx.Commit(); err != nil {
+		return rowCount, true, fmt.Errorf("commit: %w", err)
+	}
+	return rowCount, true, runErr
+}
+
+func upsertParkMetricsDaily(tx *sql.Tx, row ParkExportRow, snapshotDate string, generatedAt time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO park_metrics_daily
+			(park_id, snapshot_date, name, country, area_km2, fire_count, settlement_count, deforestation_km2, roadless_pct, generated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (park_id, snapshot_date) DO UPDATE SET
+			name = excluded.name, country = excluded.country, area_km2 = excluded.area_km2,
+			fire_count = excluded.fire_count, settlement_count = excluded.settlement_count,
+			deforestation_km2 = excluded.deforestation_km2, roadless_pct = excluded.roadless_pct,
+			generated_at = excluded.generated_at
+	`, row.ParkID, snapshotDate, row.Name, row.Country, row.AreaKm2, row.FireCount, row.SettlementCount, row.DeforestationKm2, row.RoadlessPct, generatedAt)
+	return err
+}
+
+func upsertParkMetricsCurrent(tx *sql.Tx, row ParkExportRow, generatedAt time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO park_metrics_current
+			(park_id, name, country, area_km2, fire_count, settlement_count, deforestation_km2, roadless_pct, generated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (park_id) DO UPDATE SET
+			name = excluded.name, country = excluded.country, area_km2 = excluded.area_km2,
+			fire_count = excluded.fire_count, settlement_count = excluded.settlement_count,
+			deforestation_km2 = excluded.deforestation_km2, roadless_pct = excluded.roadless_pct,
+			generated_at = excluded.generated_at
+	`, row.ParkID, row.Name, row.Country, row.AreaKm2, row.FireCount, row.SettlementCount, row.DeforestationKm2, row.RoadlessPct, generatedAt)
+	return err
+}
+
+func recordAggregateRun(tx *sql.Tx, name string, ranAt time.Time, rowCount int, took time.Duration) error {
+	_, err := tx.Exec(`
+		INSERT INTO aggregate_runs (name, last_run_at, row_count, duration_ms, error)
+		VALUES (?, ?, ?, ?, '')
+		ON CONFLICT (name) DO UPDATE SET
+			last_run_at = excluded.last_run_at, row_count = excluded.row_count,
+			duration_ms = excluded.duration_ms, error = excluded.error
+	`, name, ranAt, rowCount, took.Milliseconds())
+	return err
+}
+
+// AggregateRunStatus is one row of /api/aggregate/status.
+type AggregateRunStatus struct {
+	Name       string    `json:"name"`
+	LastRunAt  time.Time `json:"lastRunAt"`
+	RowCount   int       `json:"rowCount"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// HandleAPIAggregateStatus reports the last run (time, row count,
+// duration, error if any) of every named aggregation job recorded in
+// aggregate_runs — currently just "park_metrics", but the table's
+// shape accommodates future materialized-snapshot jobs without a schema
+// change.
+// GET /api/aggregate/status
+func (s *Server) HandleAPIAggregateStatus(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.DB.Query(`SELECT name, last_run_at, row_count, duration_ms, error FROM aggregate_runs ORDER BY name`)
+	if err != nil {
+		http.Error(w, "failed to read aggregate status", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var statuses []AggregateRunStatus
+	for rows.Next() {
+		var st AggregateRunStatus
+		if err := rows.Scan(&st.Name, &st.LastRunAt, &st.RowCount, &st.DurationMs, &st.Error); err != nil {
+			continue
+		}
+		statuses = append(statuses, st)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,169 @@
+package srv
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/areas"
+)
+
+// patrolStatsLookbackDays bounds "recent" patrol activity (visit days,
+// the coverage insight text) to the same rough window fetchPublicationsForPA
+// and the fire timeline already use for "recent" data.
+const patrolStatsLookbackDays = 90
+
+// patrolStatsMaxGridSamples caps how many 0.1°-grid cells a single
+// park's bbox can expand to before computePatrolStats gives up on
+// per-cell precision, mirroring maxRoadlessSamples in the osmroads
+// package for the same reason: a large park's bbox shouldn't turn one
+// request into an unbounded number of ContainsPoint/effort-data checks.
+const patrolStatsMaxGridSamples = 10000
+
+// PatrolStats summarizes ranger patrol coverage for a park over the
+// last patrolStatsLookbackDays: how much ground was covered, how many
+// distinct days rangers were active, and what fraction of the park's
+// effort-tracking grid (see gridCellIDForPoint) was actually visited.
+type PatrolStats struct {
+	TotalPatrolKm       float64 `json:"total_patrol_km"`
+	VisitDays           int     `json:"visit_days"`
+	GridCellsTotal      int     `json:"grid_cells_total"`
+	GridCellsVisited    int     `json:"grid_cells_visited"`
+	GridCoveragePercent float64 `json:"grid_coverage_percent"`
+	MeanNearestPatrolKm float64 `json:"mean_nearest_patrol_km"`
+	PeriodDays          int     `json:"period_days"`
+}
+
+// patrolGridCell is one 0.1°-grid cell (see gridCellIDForPoint) whose
+// center falls inside a park's polygon.
+type patrolGridCell struct {
+	id       string
+	lat, lon float64
+}
+
+// computePatrolStats intersects the park's effort-tracking grid cells
+// with its polygon (the same ContainsPoint check HandleParkRoads uses
+// for its roadless sampling grid) and aggregates recorded patrol effort
+// over those cells: total distance, visit days, and the fraction of
+// cells visited at all. A cell counts as "visited" if any patrol
+// distance was ever recorded in it in the last two calendar years -
+// effort_data is month-granular, not day-granular, so that's the
+// closest available proxy for "recently".
+func (s *Server) computePatrolStats(ctx context.Context, area *areas.ProtectedArea) (*PatrolStats, error) {
+	latMin, latMax, lonMin, lonMax := area.GetBoundingBox()
+
+	var cells []patrolGridCell
+	for lat := math.Floor(latMin/gridCellSize) * gridCellSize; lat <= latMax; lat += gridCellSize {
+		for lon := math.Floor(lonMin/gridCellSize) * gridCellSize; lon <= lonMax; lon += gridCellSize {
+			if len(cells) >= patrolStatsMaxGridSamples {
+				break
+			}
+			latCenter, lonCenter := lat+gridCellSize/2, lon+gridCellSize/2
+			if area.ContainsPoint(latCenter, lonCenter) {
+				cells = append(cells, patrolGridCell{
+					id:  gridCellIDForPoint(latCenter, lonCenter),
+					lat: latCenter,
+					lon: lonCenter,
+				})
+			}
+		}
+	}
+
+	stats := &PatrolStats{GridCellsTotal: len(cells), PeriodDays: patrolStatsLookbackDays}
+	if len(cells) == 0 {
+		return stats, nil
+	}
+
+	cellIDs := make([]string, len(cells))
+	for i, c := range cells {
+		cellIDs[i] = c.id
+	}
+
+	q := dbgen.New(s.DB)
+	now := time.Now()
+
+	rows, err := q.GetEffortDataWithMonthCountsForCells(ctx, dbgen.GetEffortDataWithMonthCountsForCellsParams{
+		Year:        int64(now.Year() - 1),
+		Year_2:      int64(now.Year()),
+		GridCellIds: cellIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query effort data for patrol stats: %w", err)
+	}
+
+	visited := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if row.TotalDistanceKm == nil {
+			continue
+		}
+		stats.TotalPatrolKm += *row.TotalDistanceKm
+		if *row.TotalDistanceKm > 0 {
+			visited[row.GridCellID] = true
+		}
+	}
+	stats.GridCellsVisited = len(visited)
+	stats.GridCoveragePercent = 100 * float64(stats.GridCellsVisited) / float64(stats.GridCellsTotal)
+
+	since := now.AddDate(0, 0, -patrolStatsLookbackDays)
+	visitDays, err := q.CountDistinctVisitDaysForCells(ctx, dbgen.CountDistinctVisitDaysForCellsParams{
+		GridCellIds: cellIDs,
+		SinceDate:   since,
+	})
+	if err != nil {
+		slog.Warn("count patrol visit days", "error", err)
+	} else {
+		stats.VisitDays = int(visitDays)
+	}
+
+	if stats.GridCellsVisited > 0 {
+		var visitedCells []patrolGridCell
+		for _, c := range cells {
+			if visited[c.id] {
+				visitedCells = append(visitedCells, c)
+			}
+		}
+		var totalDistKm float64
+		for _, c := range cells {
+			nearest := math.Inf(1)
+			for _, v := range visitedCells {
+				if d := haversineDistanceKm(c.lat, c.lon, v.lat, v.lon); d < nearest {
+					nearest = d
+				}
+			}
+			totalDistKm += nearest
+		}
+		stats.MeanNearestPatrolKm = totalDistKm / float64(len(cells))
+	}
+
+	s.savePatrolStatsSnapshot(ctx, area.ID, now, stats)
+
+	return stats, nil
+}
+
+// savePatrolStatsSnapshot upserts the just-computed PatrolStats into
+// park_patrol_stats keyed by (park_id, year, month), the same
+// one-row-per-period persistence style as ghsl_data and
+// deforestation_events, so a park's coverage trend can be charted over
+// time instead of only ever reflecting "right now". Best-effort: a
+// write failure here shouldn't fail the stats response that's already
+// been computed.
+func (s *Server) savePatrolStatsSnapshot(ctx context.Context, parkID string, at time.Time, stats *PatrolStats) {
+	q := dbgen.New(s.DB)
+	err := q.UpsertParkPatrolStats(ctx, dbgen.UpsertParkPatrolStatsParams{
+		ParkID:              parkID,
+		Year:                int64(at.Year()),
+		Month:               int64(at.Month()),
+		TotalPatrolKm:       stats.TotalPatrolKm,
+		VisitDays:           int64(stats.VisitDays),
+		GridCellsTotal:      int64(stats.GridCellsTotal),
+		GridCellsVisited:    int64(stats.GridCellsVisited),
+		GridCoveragePercent: stats.GridCoveragePercent,
+		MeanNearestPatrolKm: stats.MeanNearestPatrolKm,
+	})
+	if err != nil {
+		slog.Warn("save patrol stats snapshot", "park_id", parkID, "error", err)
+	}
+}
@@ -1,61 +1,73 @@
 package srv
 
 import (
+	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"srv.exe.dev/srv/areas"
 )
 
 // ParkStats combines fire, settlement, and roadless data for a park
 type ParkStats struct {
 	ParkID string `json:"park_id"`
-	
+
 	// Fire infraction data
 	Fire *FireStats `json:"fire,omitempty"`
-	
+
 	// Settlement/GHSL data
 	Settlement *SettlementStats `json:"settlement,omitempty"`
-	
+
 	// Roadless data
 	Roadless *RoadlessStats `json:"roadless,omitempty"`
-	
+
 	// Deforestation data
 	Deforestation *DeforestationStats `json:"deforestation,omitempty"`
-	
+
+	// Ranger patrol coverage data
+	Patrol *PatrolStats `json:"patrol,omitempty"`
+
+	// Ranger-reported incidents (SOS activations, inReach messages)
+	Incidents []ReportedIncident `json:"incidents,omitempty"`
+
 	// Narrative insights
 	Insights []string `json:"insights,omitempty"`
-	
+
 	// Fire timeline for charts
 	FireTimeline []FireDayCount `json:"fire_timeline,omitempty"`
-	
+
 	// Multi-year fire trends
 	FireTrend []YearlyFireSummary `json:"fire_trend,omitempty"`
 }
 
 type FireStats struct {
-	Year              int     `json:"year"`
-	GroupsEntered     int     `json:"groups_entered"`
-	GroupsStoppedInside int   `json:"groups_stopped_inside"`
-	GroupsTransited   int     `json:"groups_transited"`
-	ResponseRate      float64 `json:"response_rate"`
-	AvgDaysInside     float64 `json:"avg_days_inside"`
-	TotalFires        int     `json:"total_fires"`
-	PeakMonth         string  `json:"peak_month,omitempty"`
-	Trajectories      []FireGroupTrajectory `json:"trajectories,omitempty"`
+	Year                int                   `json:"year"`
+	GroupsEntered       int                   `json:"groups_entered"`
+	GroupsStoppedInside int                   `json:"groups_stopped_inside"`
+	GroupsTransited     int                   `json:"groups_transited"`
+	ResponseRate        float64               `json:"response_rate"`
+	AvgDaysInside       float64               `json:"avg_days_inside"`
+	TotalFires          int                   `json:"total_fires"`
+	PeakMonth           string                `json:"peak_month,omitempty"`
+	Trajectories        []FireGroupTrajectory `json:"trajectories,omitempty"`
 }
 
 type FireGroupTrajectory struct {
-	Origin      GeoPoint `json:"origin"`
-	Destination GeoPoint `json:"dest"`
-	EntryDate   string   `json:"entry_date"`
-	LastInside  string   `json:"last_inside"`
-	DaysInside  int      `json:"days_inside"`
-	FiresInside int      `json:"fires_inside"`
-	Outcome     string   `json:"outcome"`
+	Origin      GeoPoint           `json:"origin"`
+	Destination GeoPoint           `json:"dest"`
+	EntryDate   string             `json:"entry_date"`
+	LastInside  string             `json:"last_inside"`
+	DaysInside  int                `json:"days_inside"`
+	FiresInside int                `json:"fires_inside"`
+	Outcome     string             `json:"outcome"`
 	Path        []GeoPointWithDate `json:"path,omitempty"`
 }
 
@@ -82,11 +94,58 @@ type RoadlessStats struct {
 	TotalRoadKm        float64 `json:"total_road_km"`
 }
 
+// ReportedIncident is a ranger-reported SOS activation or inReach
+// message recorded from a patrol GPX upload's waypoints (see
+// gpx.ExtractIncidents and persistIncidents), surfaced in ParkStats
+// alongside fire and settlement signals.
+type ReportedIncident struct {
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+	Kind       string     `json:"kind"`
+	Lat        float64    `json:"lat"`
+	Lon        float64    `json:"lon"`
+	Message    string     `json:"message,omitempty"`
+}
+
+// recentIncidentsLimit bounds how many patrol_incidents rows
+// HandleAPIParkStats will pull per park, the same "reasonable default
+// list size" idiom as researchJobsDefaultLimit.
+const recentIncidentsLimit = 20
+
+// queryRecentIncidents returns a park's most recent ranger-reported
+// incidents, newest first.
+func (s *Server) queryRecentIncidents(ctx context.Context, parkID string) ([]ReportedIncident, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT kind, lat, lon, message, occurred_at
+		FROM patrol_incidents
+		WHERE protected_area_id = ?
+		ORDER BY occurred_at DESC
+		LIMIT ?
+	`, parkID, recentIncidentsLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []ReportedIncident
+	for rows.Next() {
+		var inc ReportedIncident
+		var occurredAt sql.NullTime
+		if err := rows.Scan(&inc.Kind, &inc.Lat, &inc.Lon, &inc.Message, &occurredAt); err != nil {
+			return nil, err
+		}
+		if occurredAt.Valid {
+			inc.OccurredAt = &occurredAt.Time
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, rows.Err()
+}
+
 type DeforestationStats struct {
-	TotalLossKm2 float64 `json:"total_loss_km2"`
-	WorstYear    int     `json:"worst_year"`
-	WorstYearKm2 float64 `json:"worst_year_km2"`
-	Trend        string  `json:"trend"` // "improving", "worsening", "stable"
+	TotalLossKm2 float64               `json:"total_loss_km2"`
+	WorstYear    int                   `json:"worst_year"`
+	WorstYearKm2 float64               `json:"worst_year_km2"`
+	Trend        string                `json:"trend"` // "improving", "worsening", "stable"
 	YearlyData   []YearlyDeforestation `json:"yearly_data,omitempty"`
 }
 
@@ -114,52 +173,97 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
-	
-	// Map WDPA ID to internal park_id if needed
-	internalID := parkID
-	parkName := parkID
+
+	internalID := s.resolveInternalParkID(parkID)
+	fromYear, toYear := parseStatsYearRange(r)
+
+	stats, err := s.computeParkStats(r.Context(), internalID, fromYear, toYear)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stats.ParkID = parkID
+
+	if wantsCSV(r) {
+		writeParkStatsCSV(w, stats)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// resolveInternalParkID maps a path-supplied park identifier, which may
+// be either a WDPA ID or the internal park_id, to the internal park_id
+// every stats query is keyed on. Falls back to returning id unchanged
+// if it's not found in AreaStore (the caller's queries will then simply
+// return no rows).
+func (s *Server) resolveInternalParkID(id string) string {
 	if s.AreaStore != nil {
-		for _, area := range s.AreaStore.Areas {
-			if area.WDPAID == parkID || area.ID == parkID {
-				internalID = area.ID
-				parkName = area.Name
-				break
+		for i := range s.AreaStore.Areas {
+			area := &s.AreaStore.Areas[i]
+			if area.WDPAID == id || area.ID == id {
+				return area.ID
 			}
 		}
 	}
-	
-	// Parse time filter parameters
+	return id
+}
+
+// parseStatsYearRange reads the ?year= or ?from=/&to= query parameters
+// HandleAPIParkStats and HandleAPIParkCompare both accept, defaulting to
+// every available year (2000 through the current year).
+func parseStatsYearRange(r *http.Request) (fromYear, toYear int) {
 	yearStr := r.URL.Query().Get("year")
 	fromStr := r.URL.Query().Get("from")
 	toStr := r.URL.Query().Get("to")
-	
-	var fromYear, toYear int
+
 	now := time.Now()
-	
+
 	if yearStr != "" {
 		if y, err := strconv.Atoi(yearStr); err == nil {
-			fromYear = y
-			toYear = y
+			return y, y
 		}
-	} else {
-		// Default: all available years
-		fromYear = 2000
-		toYear = now.Year()
-		if fromStr != "" {
-			if t, err := time.Parse("2006-01-02", fromStr); err == nil {
-				fromYear = t.Year()
-			}
+	}
+
+	fromYear = 2000
+	toYear = now.Year()
+	if fromStr != "" {
+		if t, err := time.Parse("2006-01-02", fromStr); err == nil {
+			fromYear = t.Year()
+		}
+	}
+	if toStr != "" {
+		if t, err := time.Parse("2006-01-02", toStr); err == nil {
+			toYear = t.Year()
 		}
-		if toStr != "" {
-			if t, err := time.Parse("2006-01-02", toStr); err == nil {
-				toYear = t.Year()
+	}
+	return fromYear, toYear
+}
+
+// computeParkStats runs every query HandleAPIParkStats needs for a
+// single park - fire infractions, settlement/roadless/deforestation
+// data, patrol coverage, and ranger-reported incidents - and returns
+// them as one ParkStats with its narrative Insights populated. internalID
+// must already be the canonical park_id (see resolveInternalParkID);
+// HandleAPIParkCompare shares this exact path so a park's comparison
+// numbers never drift from what its own /stats page shows.
+func (s *Server) computeParkStats(ctx context.Context, internalID string, fromYear, toYear int) (ParkStats, error) {
+	parkName := internalID
+	var matchedArea *areas.ProtectedArea
+	if s.AreaStore != nil {
+		for i := range s.AreaStore.Areas {
+			if s.AreaStore.Areas[i].ID == internalID {
+				matchedArea = &s.AreaStore.Areas[i]
+				parkName = matchedArea.Name
+				break
 			}
 		}
 	}
-	
-	stats := ParkStats{ParkID: parkID}
+
+	stats := ParkStats{ParkID: internalID}
 	var insights []string
-	
+
 	// Query aggregated fire infraction data across year range
 	var fire FireStats
 	var trajJSON sql.NullString
@@ -174,7 +278,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 		FROM park_group_infractions 
 		WHERE park_id = ? AND year >= ? AND year <= ?
 	`, internalID, internalID, fromYear, toYear).Scan(&fire.Year, &fire.GroupsEntered, &fire.GroupsStoppedInside, &fire.GroupsTransited, &fire.AvgDaysInside, &trajJSON)
-	
+
 	if err == nil && fire.GroupsEntered > 0 {
 		// Parse trajectory JSON if available
 		if trajJSON.Valid && trajJSON.String != "" {
@@ -185,7 +289,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 		}
 		fire.ResponseRate = float64(fire.GroupsStoppedInside) / float64(fire.GroupsEntered) * 100
 		stats.Fire = &fire
-		
+
 		// Generate fire insights with trajectory details
 		if fire.GroupsTransited > 0 {
 			// Find example of transited group with origin/destination
@@ -230,7 +334,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 			insights = append(insights, "⚠️ Low response rate may indicate gaps in patrol coverage or resources.")
 		}
 	}
-	
+
 	// Get total fire count and peak month
 	var totalFires int
 	var peakMonth string
@@ -240,7 +344,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 	if err == nil && stats.Fire != nil {
 		stats.Fire.TotalFires = totalFires
 	}
-	
+
 	// Find peak month
 	err = s.DB.QueryRow(`
 		SELECT strftime('%m', acq_date) as month, COUNT(*) as cnt
@@ -263,7 +367,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 				stats.Fire.PeakMonth))
 		}
 	}
-	
+
 	// Get fire timeline (last 90 days with data)
 	rows, err := s.DB.Query(`
 		SELECT acq_date, COUNT(*) as cnt
@@ -282,7 +386,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	
+
 	// Get multi-year fire trend with total fires per year
 	rows, err = s.DB.Query(`
 		SELECT 
@@ -310,7 +414,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	
+
 	// Query GHSL settlement data
 	var settlement SettlementStats
 	err = s.DB.QueryRow(`
@@ -318,7 +422,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 		FROM ghsl_data
 		WHERE park_id = ?
 	`, internalID).Scan(&settlement.BuiltUpKm2, &settlement.SettlementCount)
-	
+
 	if err == nil {
 		stats.Settlement = &settlement
 		if settlement.SettlementCount > 0 {
@@ -329,7 +433,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 			insights = append(insights, "✓ No permanent settlements detected inside park boundaries.")
 		}
 	}
-	
+
 	// Query OSM roadless data
 	var roadless RoadlessStats
 	err = s.DB.QueryRow(`
@@ -337,7 +441,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 		FROM osm_roadless_data
 		WHERE park_id = ?
 	`, internalID).Scan(&roadless.RoadlessPercentage, &roadless.TotalRoadKm)
-	
+
 	if err == nil {
 		stats.Roadless = &roadless
 		if roadless.RoadlessPercentage >= 90 {
@@ -354,7 +458,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 				roadless.RoadlessPercentage))
 		}
 	}
-	
+
 	// Query deforestation data
 	var deforestation DeforestationStats
 	rows, err = s.DB.Query(`
@@ -373,7 +477,7 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 		var olderYearsTotal float64
 		var recentYearsCount int
 		var olderYearsCount int
-		
+
 		for rows.Next() {
 			var year int
 			var areaKm2 float64
@@ -394,13 +498,13 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
-		
+
 		if len(yearlyData) > 0 {
 			deforestation.TotalLossKm2 = totalLoss
 			deforestation.WorstYear = worstYear
 			deforestation.WorstYearKm2 = worstYearKm2
 			deforestation.YearlyData = yearlyData
-			
+
 			// Calculate trend based on average loss per year
 			if recentYearsCount > 0 && olderYearsCount > 0 {
 				recentAvg := recentYearsTotal / float64(recentYearsCount)
@@ -415,14 +519,14 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 			} else {
 				deforestation.Trend = "insufficient_data"
 			}
-			
+
 			stats.Deforestation = &deforestation
-			
+
 			// Generate deforestation insights
 			insights = append(insights, fmt.Sprintf(
 				"🌳 Total forest loss: %.1f km² since 2001. Worst year was %d (%.1f km²).",
 				totalLoss, worstYear, worstYearKm2))
-			
+
 			if deforestation.Trend == "worsening" {
 				insights = append(insights, "⚠️ Deforestation trend is worsening - recent years show higher loss than 2015-2019.")
 			} else if deforestation.Trend == "improving" {
@@ -430,14 +534,127 @@ func (s *Server) HandleAPIParkStats(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	
+
+	// Query ranger patrol coverage data
+	if matchedArea != nil {
+		patrol, err := s.computePatrolStats(ctx, matchedArea)
+		if err != nil {
+			slog.Error("compute patrol stats", "park_id", internalID, "error", err)
+		} else {
+			stats.Patrol = patrol
+			if patrol.GridCellsTotal > 0 {
+				if patrol.GridCoveragePercent < 25 {
+					insights = append(insights, fmt.Sprintf(
+						"🚶 Only %.0f%% of park grid cells visited in the last %d days - consider rebalancing patrol routes.",
+						patrol.GridCoveragePercent, patrol.PeriodDays))
+				} else if patrol.GridCoveragePercent >= 80 {
+					insights = append(insights, fmt.Sprintf(
+						"✓ %.0f%% of park grid cells patrolled in the last %d days - strong coverage.",
+						patrol.GridCoveragePercent, patrol.PeriodDays))
+				}
+			}
+		}
+	}
+
+	// Fold in ranger-reported incidents (SOS activations, inReach
+	// messages) alongside the fire/settlement/patrol signals above.
+	if incidents, err := s.queryRecentIncidents(ctx, internalID); err != nil {
+		slog.Error("query recent incidents", "park_id", internalID, "error", err)
+	} else if len(incidents) > 0 {
+		stats.Incidents = incidents
+		for _, inc := range incidents {
+			if inc.Kind != "sos" {
+				continue
+			}
+			insights = append(insights, fmt.Sprintf(
+				"🆘 SOS reported at (%.2f°, %.2f°) - verify ranger status and response.",
+				inc.Lat, inc.Lon))
+		}
+	}
+
 	stats.Insights = insights
-	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+
+	return stats, nil
+}
+
+// wantsCSV reports whether r asked for CSV instead of the handler's
+// default JSON, either via a ".csv" path suffix (so a link can be
+// dropped straight into a spreadsheet's "import from URL") or an
+// `Accept: text/csv` header.
+func wantsCSV(r *http.Request) bool {
+	if strings.HasSuffix(r.URL.Path, ".csv") {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if strings.TrimSpace(strings.SplitN(accept, ";", 2)[0]) == "text/csv" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeParkStatsCSV streams ParkStats' two year-keyed time series
+// (FireTrend and Deforestation.YearlyData) as a single flat table, one
+// row per year, so an analyst can chart fires-vs-forest-loss in a
+// spreadsheet without round-tripping through the nested JSON shape.
+func writeParkStatsCSV(w http.ResponseWriter, stats ParkStats) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-stats.csv", stats.ParkID))
+
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	deforestationByYear := make(map[int]float64)
+	if stats.Deforestation != nil {
+		for _, yd := range stats.Deforestation.YearlyData {
+			deforestationByYear[yd.Year] = yd.LossKm2
+		}
+	}
+
+	years := make(map[int]bool)
+	for _, yr := range stats.FireTrend {
+		years[yr.Year] = true
+	}
+	for year := range deforestationByYear {
+		years[year] = true
+	}
+	sortedYears := make([]int, 0, len(years))
+	for year := range years {
+		sortedYears = append(sortedYears, year)
+	}
+	sort.Ints(sortedYears)
+
+	fireByYear := make(map[int]YearlyFireSummary)
+	for _, yr := range stats.FireTrend {
+		fireByYear[yr.Year] = yr
+	}
+
+	cw.Write([]string{"year", "total_fires", "fire_groups", "deforestation_km2"})
+	for _, year := range sortedYears {
+		fire := fireByYear[year]
+		cw.Write([]string{
+			strconv.Itoa(year),
+			strconv.Itoa(fire.TotalFires),
+			strconv.Itoa(fire.Groups),
+			fmt.Sprintf("%.4f", deforestationByYear[year]),
+		})
+	}
+}
+
+// HandleAPIParkFireLog returns detailed fire event log for a park.
+// Accepts the same CSV negotiation as HandleAPIParkStats (see
+// wantsCSV), plus ?format=geojson, which drops the narrative log and
+// instead returns a FeatureCollection: one MultiPoint Feature per day
+// of fire detections (frp/date properties) and one LineString Feature
+// per recorded fire-group trajectory, for dropping straight into
+// QGIS/Leaflet.
+type FireEvent struct {
+	Date   string  `json:"date"`
+	Fires  int     `json:"fires"`
+	AvgFRP float64 `json:"avg_frp"`
+	MaxFRP float64 `json:"max_frp"`
 }
 
-// HandleAPIParkFireLog returns detailed fire event log for a park
 // GET /api/parks/{id}/fire-log
 func (s *Server) HandleAPIParkFireLog(w http.ResponseWriter, r *http.Request) {
 	parkID := r.PathValue("id")
@@ -445,7 +662,7 @@ func (s *Server) HandleAPIParkFireLog(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Park ID required", http.StatusBadRequest)
 		return
 	}
-	
+
 	// Map to internal ID
 	internalID := parkID
 	if s.AreaStore != nil {
@@ -456,29 +673,27 @@ func (s *Server) HandleAPIParkFireLog(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	
-	type FireEvent struct {
-		Date      string  `json:"date"`
-		Fires     int     `json:"fires"`
-		AvgFRP    float64 `json:"avg_frp"`
-		MaxFRP    float64 `json:"max_frp"`
+
+	if r.URL.Query().Get("format") == "geojson" {
+		s.writeFireLogGeoJSON(w, r.Context(), internalID)
+		return
 	}
-	
+
 	rows, err := s.DB.Query(`
 		SELECT acq_date, COUNT(*) as fires, AVG(frp) as avg_frp, MAX(frp) as max_frp
-		FROM fire_detections 
+		FROM fire_detections
 		WHERE protected_area_id = ?
 		GROUP BY acq_date
 		ORDER BY acq_date DESC
 		LIMIT 365
 	`, internalID)
-	
+
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
-	
+
 	var events []FireEvent
 	for rows.Next() {
 		var e FireEvent
@@ -489,19 +704,24 @@ func (s *Server) HandleAPIParkFireLog(w http.ResponseWriter, r *http.Request) {
 			events = append(events, e)
 		}
 	}
-	
+
+	if wantsCSV(r) {
+		writeFireEventsCSV(w, internalID, events)
+		return
+	}
+
 	// Generate narrative log entries
 	type LogEntry struct {
 		Date    string `json:"date"`
 		Message string `json:"message"`
 		Level   string `json:"level"` // info, warning, critical
 	}
-	
+
 	var log []LogEntry
 	for _, e := range events {
 		level := "info"
 		var msg string
-		
+
 		if e.Fires >= 100 {
 			level = "critical"
 			msg = fmt.Sprintf("🔥 Major fire event: %d active fires detected (avg intensity: %.1f MW)", e.Fires, e.AvgFRP)
@@ -516,7 +736,7 @@ func (s *Server) HandleAPIParkFireLog(w http.ResponseWriter, r *http.Request) {
 		} else {
 			msg = fmt.Sprintf("%d fire detections", e.Fires)
 		}
-		
+
 		// Format date nicely
 		dateParts := strings.Split(e.Date, "-")
 		if len(dateParts) == 3 {
@@ -527,13 +747,139 @@ func (s *Server) HandleAPIParkFireLog(w http.ResponseWriter, r *http.Request) {
 				e.Date = fmt.Sprintf("%s %s, %s", months[monthNum], dateParts[2], dateParts[0])
 			}
 		}
-		
+
 		log = append(log, LogEntry{Date: e.Date, Message: msg, Level: level})
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"events": events,
 		"log":    log,
 	})
 }
+
+// writeFireEventsCSV streams a park's daily fire event log as CSV,
+// the flat counterpart to the events slice HandleAPIParkFireLog's JSON
+// mode already computed.
+func writeFireEventsCSV(w http.ResponseWriter, parkID string, events []FireEvent) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-fire-log.csv", parkID))
+
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	cw.Write([]string{"date", "fires", "avg_frp", "max_frp"})
+	for _, e := range events {
+		cw.Write([]string{
+			e.Date,
+			strconv.Itoa(e.Fires),
+			fmt.Sprintf("%.2f", e.AvgFRP),
+			fmt.Sprintf("%.2f", e.MaxFRP),
+		})
+	}
+}
+
+// fireLogGeoJSONDetectionLimit bounds how many individual fire
+// detections writeFireLogGeoJSON will plot, the same rationale as the
+// 365-row LIMIT on the JSON event log: a park with years of daily
+// detections shouldn't turn one export into an unbounded response.
+const fireLogGeoJSONDetectionLimit = 5000
+
+// writeFireLogGeoJSON emits a FeatureCollection combining one MultiPoint
+// Feature per day of fire detections (coordinates are each detection's
+// lat/lon, with per-point frp and the day's date as properties) and one
+// LineString Feature per recorded fire-group trajectory (see
+// FireGroupTrajectory in HandleAPIParkStats), so the result can be
+// dropped straight into QGIS/Leaflet without post-processing the plain
+// JSON event log.
+func (s *Server) writeFireLogGeoJSON(w http.ResponseWriter, ctx context.Context, parkID string) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT acq_date, latitude, longitude, frp
+		FROM fire_detections
+		WHERE protected_area_id = ?
+		ORDER BY acq_date DESC
+		LIMIT ?
+	`, parkID, fireLogGeoJSONDetectionLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	type dayPoints struct {
+		coords [][]float64
+		frps   []float64
+	}
+	byDate := make(map[string]*dayPoints)
+	var dateOrder []string
+	for rows.Next() {
+		var date string
+		var lat, lon float64
+		var frp sql.NullFloat64
+		if err := rows.Scan(&date, &lat, &lon, &frp); err != nil {
+			continue
+		}
+		dp, ok := byDate[date]
+		if !ok {
+			dp = &dayPoints{}
+			byDate[date] = dp
+			dateOrder = append(dateOrder, date)
+		}
+		dp.coords = append(dp.coords, []float64{lon, lat})
+		dp.frps = append(dp.frps, frp.Float64)
+	}
+
+	features := make([]GeoJSONFeature, 0, len(dateOrder))
+	for _, date := range dateOrder {
+		dp := byDate[date]
+		features = append(features, GeoJSONFeature{
+			Type: "Feature",
+			Geometry: GeoJSONGeometry{
+				Type:        "MultiPoint",
+				Coordinates: dp.coords,
+			},
+			Properties: map[string]interface{}{
+				"date":  date,
+				"count": len(dp.coords),
+				"frp":   dp.frps,
+			},
+		})
+	}
+
+	var trajJSON sql.NullString
+	err = s.DB.QueryRowContext(ctx, `
+		SELECT trajectories_json FROM park_group_infractions
+		WHERE park_id = ? ORDER BY year DESC LIMIT 1
+	`, parkID).Scan(&trajJSON)
+	if err == nil && trajJSON.Valid && trajJSON.String != "" {
+		var trajs []FireGroupTrajectory
+		if json.Unmarshal([]byte(trajJSON.String), &trajs) == nil {
+			for _, t := range trajs {
+				coords := make([][]float64, 0, len(t.Path))
+				for _, p := range t.Path {
+					coords = append(coords, []float64{p.Lon, p.Lat})
+				}
+				if len(coords) < 2 {
+					coords = [][]float64{{t.Origin.Lon, t.Origin.Lat}, {t.Destination.Lon, t.Destination.Lat}}
+				}
+				features = append(features, GeoJSONFeature{
+					Type: "Feature",
+					Geometry: GeoJSONGeometry{
+						Type:        "LineString",
+						Coordinates: coords,
+					},
+					Properties: map[string]interface{}{
+						"entry_date":   t.EntryDate,
+						"last_inside":  t.LastInside,
+						"days_inside":  t.DaysInside,
+						"fires_inside": t.FiresInside,
+						"outcome":      t.Outcome,
+					},
+				})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	json.NewEncoder(w).Encode(GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features})
+}
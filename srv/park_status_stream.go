@@ -0,0 +1,138 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ParkStatusHub fans out ParkDataStatus snapshots to subscribers of a
+// given park, keyed by topic = park ID, so HandleAPIParkStatusStream can
+// push updates instead of making clients poll HandleAPIParkDataStatus.
+type ParkStatusHub struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan ParkDataStatus
+}
+
+// NewParkStatusHub creates an empty hub.
+func NewParkStatusHub() *ParkStatusHub {
+	return &ParkStatusHub{subscribers: make(map[string][]chan ParkDataStatus)}
+}
+
+// Subscribe returns a channel that receives a status snapshot every time
+// Publish(parkID, ...) is called, and an unsubscribe function to release
+// it. The channel is buffered so Publish never blocks on a slow
+// consumer; a subscriber that falls behind just misses intermediate
+// snapshots, the same non-blocking-send idiom UploadQueue uses for its
+// own SSE subscribers.
+func (h *ParkStatusHub) Subscribe(parkID string) (<-chan ParkDataStatus, func()) {
+	ch := make(chan ParkDataStatus, 4)
+	h.mu.Lock()
+	h.subscribers[parkID] = append(h.subscribers[parkID], ch)
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		subs := h.subscribers[parkID]
+		for i, c := range subs {
+			if c == ch {
+				h.subscribers[parkID] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(h.subscribers[parkID]) == 0 {
+			delete(h.subscribers, parkID)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends status to every current subscriber of parkID, dropping
+// it for any subscriber whose buffer is already full instead of
+// blocking the publisher.
+func (h *ParkStatusHub) Publish(parkID string, status ParkDataStatus) {
+	h.mu.Lock()
+	subs := append([]chan ParkDataStatus(nil), h.subscribers[parkID]...)
+	h.mu.Unlock()
+	for _, ch := range subs {
+		select {
+		case ch <- status:
+		default:
+		}
+	}
+}
+
+// statusFingerprint derives an SSE event ID from the latest timestamp
+// recorded across a park's data sources, so HandleAPIParkStatusStream
+// can tell a resuming client (via Last-Event-ID) whether anything
+// changed since the event it already has.
+func statusFingerprint(status ParkDataStatus) string {
+	latest := ""
+	for _, ds := range []*DataSourceStatus{status.FireAnalysis, status.GroupInfractions, status.Publications, status.GHSL, status.Roadless} {
+		if ds != nil && ds.LastUpdate > latest {
+			latest = ds.LastUpdate
+		}
+	}
+	return latest
+}
+
+// HandleAPIParkStatusStream upgrades to text/event-stream and pushes a
+// ParkDataStatus snapshot for {id} whenever s.ParkStatusHub.Publish is
+// called for it — from a ParkJobs job reaching a terminal state, or from
+// a successful publications sync (see ParkJobRunner.SetOnChange and
+// fetchPublicationsForPA). A reconnecting client that sends
+// Last-Event-ID matching the park's current fingerprint skips the
+// redundant initial resend.
+func (s *Server) HandleAPIParkStatusStream(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	if parkID == "" {
+		http.Error(w, "park ID required", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	updates, unsubscribe := s.ParkStatusHub.Subscribe(parkID)
+	defer unsubscribe()
+
+	write := func(status ParkDataStatus) bool {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", statusFingerprint(status), data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	initial := s.computeParkDataStatus(r.Context(), parkID, nil)
+	if r.Header.Get("Last-Event-ID") != statusFingerprint(initial) {
+		if !write(initial) {
+			return
+		}
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case status := <-updates:
+			if !write(status) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package srv
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/gpx"
+	"srv.exe.dev/srv/pdfexport"
+)
+
+// HandlePatrolSegmentMapPDF renders a printable PDF map for one patrol
+// upload: a basemap tile mosaic covering its track, the track itself
+// colored by movement type, a scale bar, and a legend with the park name
+// and patrol date range. There's no separately addressable per-Segment
+// row (see HandleActivityPubOutbox for the same limitation elsewhere), so
+// {segID} names a gpx_uploads row and the whole upload is rendered as one
+// segment.
+// GET /api/parks/{id}/patrols/{segID}/map.pdf?paper=A4&orientation=portrait&dpi=150
+func (s *Server) HandlePatrolSegmentMapPDF(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("id")
+	segID, err := strconv.ParseInt(r.PathValue("segID"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid patrol segment id")
+		return
+	}
+
+	q := dbgen.New(s.DB)
+	ctx := r.Context()
+
+	upload, err := q.GetGPXUpload(ctx, segID)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "patrol segment not found")
+		return
+	}
+	points, err := q.GetTrackPointsByUpload(ctx, segID)
+	if err != nil {
+		slog.Error("load track points for patrol map", "upload_id", segID, "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "database error")
+		return
+	}
+	if len(points) < 2 {
+		writeJSONError(w, http.StatusUnprocessableEntity, "patrol segment has too few points to map")
+		return
+	}
+
+	gpxPoints := make([]gpx.Point, len(points))
+	for i, pt := range points {
+		gpxPoints[i] = gpx.Point{Lat: pt.Lat, Lon: pt.Lon, Elevation: pt.Elevation, Time: pt.Timestamp}
+	}
+	segment := gpx.Segment{
+		Points:       gpxPoints,
+		StartTime:    upload.StartTime,
+		EndTime:      upload.EndTime,
+		MovementType: upload.MovementType,
+	}
+
+	opts, err := patrolMapOptionsFromQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	urlTemplate := s.BasemapTileURLTemplate
+	if urlTemplate == "" {
+		urlTemplate = pdfexport.DefaultTileURLTemplate
+	}
+	fetcher := pdfexport.HTTPTileFetcher{URLTemplate: urlTemplate}
+
+	pdfBytes, err := pdfexport.Render(ctx, []gpx.Segment{segment}, opts, fetcher, pdfexport.Legend{
+		ParkName: s.parkName(paID),
+		DateFrom: upload.StartTime,
+		DateTo:   upload.EndTime,
+	})
+	if err != nil {
+		slog.Error("render patrol map pdf", "upload_id", segID, "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to render map")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=patrol-%d-map.pdf", segID))
+	w.Write(pdfBytes)
+}
+
+// patrolMapOptionsFromQuery builds pdfexport.Options from the optional
+// ?paper=/?orientation=/?dpi= query params, starting from
+// pdfexport.DefaultOptions() for whatever isn't given.
+func patrolMapOptionsFromQuery(r *http.Request) (pdfexport.Options, error) {
+	opts := pdfexport.DefaultOptions()
+
+	if v := r.URL.Query().Get("paper"); v != "" {
+		opts.Paper = pdfexport.Paper(v)
+	}
+	if v := r.URL.Query().Get("orientation"); v != "" {
+		switch v {
+		case "portrait":
+			opts.Orientation = pdfexport.Portrait
+		case "landscape":
+			opts.Orientation = pdfexport.Landscape
+		default:
+			return opts, fmt.Errorf("invalid orientation %q; want portrait or landscape", v)
+		}
+	}
+	if v := r.URL.Query().Get("dpi"); v != "" {
+		dpi, err := strconv.Atoi(v)
+		if err != nil || dpi <= 0 {
+			return opts, fmt.Errorf("invalid dpi %q", v)
+		}
+		opts.DPI = dpi
+	}
+
+	return opts, nil
+}
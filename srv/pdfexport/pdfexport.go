@@ -0,0 +1,464 @@
+// Package pdfexport renders patrol GPX segments onto a printable PDF map:
+// a basemap raster covering the segments' bounding box, the track
+// overlaid and colored by movement type, a scale bar, and a legend. It
+// exists for rangers who need a paper map to carry into the field, where
+// the GeoJSON/tile-based views elsewhere in this server aren't usable.
+package pdfexport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder most tile servers use
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// Paper is a supported paper size, named by its short ISO/US label.
+type Paper string
+
+const (
+	PaperA4     Paper = "A4"
+	PaperA3     Paper = "A3"
+	PaperLetter Paper = "Letter"
+)
+
+// paperSizesMM holds each paper's portrait width/height in millimeters.
+var paperSizesMM = map[Paper][2]float64{
+	PaperA4:     {210, 297},
+	PaperA3:     {297, 420},
+	PaperLetter: {215.9, 279.4},
+}
+
+// Orientation is the page orientation.
+type Orientation string
+
+const (
+	Portrait  Orientation = "portrait"
+	Landscape Orientation = "landscape"
+)
+
+// DimensionsMM returns paper's width/height in millimeters for the given
+// orientation, swapping them for Landscape. An unrecognized paper falls
+// back to A4, the same "unknown size" default gofpdf.New itself uses.
+func DimensionsMM(paper Paper, orientation Orientation) (width, height float64) {
+	dims, ok := paperSizesMM[paper]
+	if !ok {
+		dims = paperSizesMM[PaperA4]
+	}
+	width, height = dims[0], dims[1]
+	if orientation == Landscape {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// PixelsForMM converts a physical length in millimeters to pixels at the
+// given resolution: pixels = dpi * mm / 25.4 (25.4mm per inch).
+func PixelsForMM(dpi int, mm float64) int {
+	return int(math.Round(float64(dpi) * mm / 25.4))
+}
+
+// Options configures one map export.
+type Options struct {
+	Paper       Paper
+	Orientation Orientation
+	DPI         int
+}
+
+// DefaultOptions is a reasonable field-print default: A4 portrait at
+// 150dpi, crisp enough to read a GPS track without producing an
+// unreasonably large basemap fetch.
+func DefaultOptions() Options {
+	return Options{Paper: PaperA4, Orientation: Portrait, DPI: 150}
+}
+
+// Legend is the descriptive text printed below the map.
+type Legend struct {
+	ParkName string
+	DateFrom *time.Time
+	DateTo   *time.Time
+}
+
+// BBox is a geographic bounding box in degrees (EPSG:4326).
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// segmentsBBox returns the bounding box covering every point across
+// segments, padded by paddingFrac of its own width/height on each side so
+// the track isn't drawn flush against the map edge.
+func segmentsBBox(segments []gpx.Segment, paddingFrac float64) (BBox, error) {
+	var bbox BBox
+	first := true
+	for _, seg := range segments {
+		for _, pt := range seg.Points {
+			if first {
+				bbox = BBox{MinLon: pt.Lon, MaxLon: pt.Lon, MinLat: pt.Lat, MaxLat: pt.Lat}
+				first = false
+				continue
+			}
+			bbox.MinLon = math.Min(bbox.MinLon, pt.Lon)
+			bbox.MaxLon = math.Max(bbox.MaxLon, pt.Lon)
+			bbox.MinLat = math.Min(bbox.MinLat, pt.Lat)
+			bbox.MaxLat = math.Max(bbox.MaxLat, pt.Lat)
+		}
+	}
+	if first {
+		return BBox{}, fmt.Errorf("pdfexport: segments have no points")
+	}
+
+	lonPad := (bbox.MaxLon - bbox.MinLon) * paddingFrac
+	latPad := (bbox.MaxLat - bbox.MinLat) * paddingFrac
+	if lonPad == 0 {
+		lonPad = 0.001
+	}
+	if latPad == 0 {
+		latPad = 0.001
+	}
+	bbox.MinLon -= lonPad
+	bbox.MaxLon += lonPad
+	bbox.MinLat -= latPad
+	bbox.MaxLat += latPad
+	return bbox, nil
+}
+
+// mercatorTileSize is the pixel dimension of one Web Mercator tile at any
+// zoom level, the standard slippy-map convention.
+const mercatorTileSize = 256
+
+// lonLatToGlobalPixel projects lon/lat onto the global Web Mercator pixel
+// plane at zoom - the same projection srv/tiles.go's tileToBBox runs in
+// reverse for effort tiles, duplicated here since this package can't
+// import srv (srv imports this package, not the other way around).
+func lonLatToGlobalPixel(lon, lat float64, zoom int) (x, y float64) {
+	n := math.Exp2(float64(zoom)) * mercatorTileSize
+	x = (lon + 180.0) / 360.0 * n
+	latRad := lat * math.Pi / 180.0
+	y = (1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n
+	return x, y
+}
+
+// zoomForExtent returns the highest zoom level at which bbox's projected
+// pixel extent still fits within pixelsWide x pixelsHigh, so the basemap
+// is fetched at native tile resolution instead of being upsampled and
+// blurry to fill a larger canvas.
+func zoomForExtent(bbox BBox, pixelsWide, pixelsHigh int) int {
+	for zoom := 18; zoom >= 0; zoom-- {
+		x0, y0 := lonLatToGlobalPixel(bbox.MinLon, bbox.MaxLat, zoom)
+		x1, y1 := lonLatToGlobalPixel(bbox.MaxLon, bbox.MinLat, zoom)
+		if (x1-x0) <= float64(pixelsWide) && (y1-y0) <= float64(pixelsHigh) {
+			return zoom
+		}
+	}
+	return 0
+}
+
+// metersPerPixel returns the Web Mercator ground resolution at the given
+// latitude (degrees) and zoom: the standard
+// earthCircumference*cos(lat)/2^(zoom+8) formula (256px tiles, so the
+// +8 folds in the /256).
+func metersPerPixel(lat float64, zoom int) float64 {
+	const earthCircumferenceM = 40075016.686
+	return earthCircumferenceM * math.Cos(lat*math.Pi/180) / math.Exp2(float64(zoom)+8)
+}
+
+// TileFetcher fetches a single raster basemap tile at a z/x/y Web
+// Mercator coordinate. Pluggable so a deployment can point at its own
+// tile server, or a test can supply a fake that never hits the network.
+type TileFetcher interface {
+	FetchTile(ctx context.Context, z, x, y int) (image.Image, error)
+}
+
+// DefaultTileURLTemplate is used when a deployment hasn't configured its
+// own basemap tile server.
+const DefaultTileURLTemplate = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+
+// HTTPTileFetcher fetches tiles from a templated XYZ tile server URL
+// (e.g. DefaultTileURLTemplate), the conventional slippy-map scheme.
+type HTTPTileFetcher struct {
+	URLTemplate string
+	Client      *http.Client
+}
+
+// FetchTile implements TileFetcher.
+func (f HTTPTileFetcher) FetchTile(ctx context.Context, z, x, y int) (image.Image, error) {
+	url := strings.NewReplacer(
+		"{z}", strconv.Itoa(z),
+		"{x}", strconv.Itoa(x),
+		"{y}", strconv.Itoa(y),
+	).Replace(f.URLTemplate)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "5mp-pdfexport/1.0")
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch tile %d/%d/%d: %s", z, x, y, resp.Status)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
+
+// renderBasemap fetches every tile overlapping bbox at zoom and
+// composites them into a single pixelsWide x pixelsHigh canvas anchored
+// so bbox's top-left corner sits at the canvas origin. A tile that fails
+// to fetch (out of coverage, rate-limited, network error) just leaves
+// that patch of the canvas blank rather than failing the whole export -
+// a field map with one gray square beats no map at all.
+func renderBasemap(ctx context.Context, fetcher TileFetcher, bbox BBox, zoom, pixelsWide, pixelsHigh int) *image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, pixelsWide, pixelsHigh))
+
+	originX, originY := lonLatToGlobalPixel(bbox.MinLon, bbox.MaxLat, zoom)
+
+	tileMinX := int(math.Floor(originX / mercatorTileSize))
+	tileMaxX := int(math.Floor((originX + float64(pixelsWide)) / mercatorTileSize))
+	tileMinY := int(math.Floor(originY / mercatorTileSize))
+	tileMaxY := int(math.Floor((originY + float64(pixelsHigh)) / mercatorTileSize))
+
+	for ty := tileMinY; ty <= tileMaxY; ty++ {
+		for tx := tileMinX; tx <= tileMaxX; tx++ {
+			tile, err := fetcher.FetchTile(ctx, zoom, tx, ty)
+			if err != nil {
+				continue
+			}
+			destX := tx*mercatorTileSize - int(math.Round(originX))
+			destY := ty*mercatorTileSize - int(math.Round(originY))
+			draw.Draw(canvas, image.Rect(destX, destY, destX+mercatorTileSize, destY+mercatorTileSize), tile, image.Point{}, draw.Over)
+		}
+	}
+
+	return canvas
+}
+
+// movementColors assigns each HMM/threshold movement state a distinct
+// overlay color - a discrete categorical legend, unlike the continuous
+// red intensity scale srv/tiles.go's effort heatmap uses.
+var movementColors = map[string]color.RGBA{
+	gpx.StateFoot:       {R: 37, G: 99, B: 235, A: 255},   // blue
+	gpx.StateVehicle:    {R: 234, G: 88, B: 12, A: 255},   // orange
+	gpx.StateAircraft:   {R: 220, G: 38, B: 38, A: 255},   // red
+	gpx.StateStationary: {R: 107, G: 114, B: 128, A: 255}, // gray
+}
+
+// movementLegendOrder is the fixed order movement types are listed in the
+// legend, independent of map iteration order.
+var movementLegendOrder = []string{gpx.StateFoot, gpx.StateVehicle, gpx.StateAircraft, gpx.StateStationary}
+
+// colorForMovementType returns movementColors[mt], or a near-black
+// default for an unrecognized/legacy movement type string.
+func colorForMovementType(mt string) color.RGBA {
+	if c, ok := movementColors[mt]; ok {
+		return c
+	}
+	return color.RGBA{R: 17, G: 24, B: 39, A: 255}
+}
+
+// drawTrack draws each segment's points as a connected polyline onto
+// canvas, projected into the same pixel space renderBasemap used,
+// colored by the segment's MovementType.
+func drawTrack(canvas *image.RGBA, segments []gpx.Segment, bbox BBox, zoom int) {
+	originX, originY := lonLatToGlobalPixel(bbox.MinLon, bbox.MaxLat, zoom)
+	project := func(p gpx.Point) (int, int) {
+		x, y := lonLatToGlobalPixel(p.Lon, p.Lat, zoom)
+		return int(math.Round(x - originX)), int(math.Round(y - originY))
+	}
+
+	for _, seg := range segments {
+		col := colorForMovementType(seg.MovementType)
+		for i := 1; i < len(seg.Points); i++ {
+			x0, y0 := project(seg.Points[i-1])
+			x1, y1 := project(seg.Points[i])
+			drawLine(canvas, x0, y0, x1, y1, col)
+		}
+	}
+}
+
+// drawLine draws a line between (x0,y0) and (x1,y1) via Bresenham's
+// algorithm, thickened by also painting the pixel immediately right and
+// below each step so the track stays visible at typical print DPIs.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, col color.RGBA) {
+	bounds := img.Bounds()
+	set := func(x, y int) {
+		if x >= bounds.Min.X && x < bounds.Max.X && y >= bounds.Min.Y && y < bounds.Max.Y {
+			img.Set(x, y, col)
+		}
+	}
+
+	dx := int(math.Abs(float64(x1 - x0)))
+	dy := -int(math.Abs(float64(y1 - y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	errTerm := dx + dy
+
+	for {
+		set(x0, y0)
+		set(x0+1, y0)
+		set(x0, y0+1)
+		if x0 == x1 && y0 == y1 {
+			break
+		}
+		e2 := 2 * errTerm
+		if e2 >= dy {
+			errTerm += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			errTerm += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawScaleBar draws a horizontal scale bar in canvas's bottom-left
+// corner, sized to a round ground distance, and returns the map's scale
+// denominator (e.g. 25000 for a 1:25000 map) per
+// scaleDenominator = 1000 * pixelsPerMapMillimeter * metersPerPixel.
+func drawScaleBar(canvas *image.RGBA, dpi int, lat float64, zoom int) float64 {
+	pixelsPerMM := float64(dpi) / 25.4
+	mpp := metersPerPixel(lat, zoom)
+	scaleDenominator := 1000 * pixelsPerMM * mpp
+
+	maxBarPx := float64(canvas.Bounds().Dx()) / 4
+	barM := roundScaleLength(maxBarPx * mpp)
+	barPx := int(barM / mpp)
+
+	const margin = 20
+	y := canvas.Bounds().Dy() - margin
+	black := color.RGBA{A: 255}
+	for x := margin; x < margin+barPx; x++ {
+		canvas.Set(x, y, black)
+		canvas.Set(x, y-1, black)
+		canvas.Set(x, y+1, black)
+	}
+	for _, x := range [2]int{margin, margin + barPx} {
+		for dy := -5; dy <= 5; dy++ {
+			canvas.Set(x, y+dy, black)
+		}
+	}
+
+	return scaleDenominator
+}
+
+// roundScaleLength rounds maxM down to the nearest "nice" 1/2/5 * 10^n
+// meters value, the convention map scale bars use.
+func roundScaleLength(maxM float64) float64 {
+	if maxM <= 0 {
+		return 1
+	}
+	base := math.Pow(10, math.Floor(math.Log10(maxM)))
+	for _, mult := range []float64{5, 2, 1} {
+		if mult*base <= maxM {
+			return mult * base
+		}
+	}
+	return base
+}
+
+// legendSwatchIndex returns mt's position in movementLegendOrder, used to
+// lay the legend's color key out left to right.
+func legendSwatchIndex(mt string) int {
+	for i, o := range movementLegendOrder {
+		if o == mt {
+			return i
+		}
+	}
+	return 0
+}
+
+// Render composes segments into a complete PDF map document: a basemap
+// (fetched via fetcher) covering their bounding box, the track overlaid
+// and colored by movement type, a scale bar, and legend below it.
+func Render(ctx context.Context, segments []gpx.Segment, opts Options, fetcher TileFetcher, legend Legend) ([]byte, error) {
+	bbox, err := segmentsBBox(segments, 0.1)
+	if err != nil {
+		return nil, err
+	}
+
+	widthMM, heightMM := DimensionsMM(opts.Paper, opts.Orientation)
+	const legendHeightMM = 30
+	mapHeightMM := heightMM - legendHeightMM
+
+	pixelsWide := PixelsForMM(opts.DPI, widthMM)
+	pixelsHigh := PixelsForMM(opts.DPI, mapHeightMM)
+
+	zoom := zoomForExtent(bbox, pixelsWide, pixelsHigh)
+
+	canvas := renderBasemap(ctx, fetcher, bbox, zoom, pixelsWide, pixelsHigh)
+	drawTrack(canvas, segments, bbox, zoom)
+	centerLat := (bbox.MinLat + bbox.MaxLat) / 2
+	scaleDenominator := drawScaleBar(canvas, opts.DPI, centerLat, zoom)
+
+	var jpegBuf bytes.Buffer
+	if err := jpeg.Encode(&jpegBuf, canvas, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("encode map jpeg: %w", err)
+	}
+
+	orientationCode := "P"
+	if opts.Orientation == Landscape {
+		orientationCode = "L"
+	}
+	pdf := gofpdf.New(orientationCode, "mm", string(opts.Paper), "")
+	pdf.AddPage()
+
+	imgOpts := gofpdf.ImageOptions{ImageType: "JPG"}
+	pdf.RegisterImageOptionsReader("map", imgOpts, &jpegBuf)
+	pdf.ImageOptions("map", 0, 0, widthMM, mapHeightMM, false, imgOpts, 0, "")
+
+	pdf.SetXY(5, mapHeightMM+3)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 6, legend.ParkName)
+	pdf.Ln(6)
+
+	dateRange := "date range unknown"
+	if legend.DateFrom != nil && legend.DateTo != nil {
+		dateRange = fmt.Sprintf("%s - %s", legend.DateFrom.Format("2006-01-02"), legend.DateTo.Format("2006-01-02"))
+	}
+	pdf.SetFont("Arial", "", 9)
+	pdf.Cell(0, 5, fmt.Sprintf("Patrol dates: %s   Scale: 1:%.0f", dateRange, scaleDenominator))
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "", 8)
+	for _, mt := range movementLegendOrder {
+		x := 5 + float64(legendSwatchIndex(mt))*35
+		c := movementColors[mt]
+		pdf.SetFillColor(int(c.R), int(c.G), int(c.B))
+		pdf.Rect(x, mapHeightMM+16, 4, 4, "F")
+		pdf.SetXY(x+5, mapHeightMM+15)
+		pdf.Cell(30, 5, mt)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package pdfexport
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+func TestDimensionsMMSwapsForLandscape(t *testing.T) {
+	pw, ph := DimensionsMM(PaperA4, Portrait)
+	lw, lh := DimensionsMM(PaperA4, Landscape)
+	if pw != lh || ph != lw {
+		t.Errorf("expected landscape to swap portrait's dimensions, got portrait %vx%v, landscape %vx%v", pw, ph, lw, lh)
+	}
+}
+
+func TestDimensionsMMUnknownPaperFallsBackToA4(t *testing.T) {
+	w, h := DimensionsMM("Tabloid", Portrait)
+	wantW, wantH := DimensionsMM(PaperA4, Portrait)
+	if w != wantW || h != wantH {
+		t.Errorf("expected unknown paper to fall back to A4 (%vx%v), got %vx%v", wantW, wantH, w, h)
+	}
+}
+
+func TestPixelsForMM(t *testing.T) {
+	// 300dpi over a 25.4mm (1 inch) length should be exactly 300px.
+	if got := PixelsForMM(300, 25.4); got != 300 {
+		t.Errorf("expected 300px, got %d", got)
+	}
+}
+
+func TestZoomForExtentFitsCanvas(t *testing.T) {
+	bbox := BBox{MinLon: 36.0, MinLat: -1.2, MaxLon: 36.2, MaxLat: -1.0}
+	zoom := zoomForExtent(bbox, 1000, 1000)
+
+	x0, y0 := lonLatToGlobalPixel(bbox.MinLon, bbox.MaxLat, zoom)
+	x1, y1 := lonLatToGlobalPixel(bbox.MaxLon, bbox.MinLat, zoom)
+	if x1-x0 > 1000 || y1-y0 > 1000 {
+		t.Errorf("zoom %d: bbox extent %vx%v exceeds 1000x1000 canvas", zoom, x1-x0, y1-y0)
+	}
+}
+
+func TestRoundScaleLength(t *testing.T) {
+	cases := map[float64]float64{
+		950:   500,
+		4999:  2000,
+		10000: 10000,
+	}
+	for in, want := range cases {
+		if got := roundScaleLength(in); got != want {
+			t.Errorf("roundScaleLength(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+// solidColorFetcher is a TileFetcher stub returning a uniformly-colored
+// tile without touching the network, for exercising Render end to end.
+type solidColorFetcher struct{ col color.Color }
+
+func (f solidColorFetcher) FetchTile(ctx context.Context, z, x, y int) (image.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, mercatorTileSize, mercatorTileSize))
+	for py := 0; py < mercatorTileSize; py++ {
+		for px := 0; px < mercatorTileSize; px++ {
+			img.Set(px, py, f.col)
+		}
+	}
+	return img, nil
+}
+
+func TestRenderProducesPDF(t *testing.T) {
+	start := time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)
+	end := start.Add(20 * time.Minute)
+	points := []gpx.Point{
+		{Lat: -1.10, Lon: 36.05, Time: &start},
+		{Lat: -1.12, Lon: 36.07},
+		{Lat: -1.15, Lon: 36.09, Time: &end},
+	}
+	segments := []gpx.Segment{{Points: points, MovementType: gpx.StateVehicle, StartTime: &start, EndTime: &end}}
+
+	pdfBytes, err := Render(context.Background(), segments, DefaultOptions(), solidColorFetcher{col: color.Gray{Y: 200}}, Legend{
+		ParkName: "Test Park",
+		DateFrom: &start,
+		DateTo:   &end,
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !bytes.HasPrefix(pdfBytes, []byte("%PDF")) {
+		n := len(pdfBytes)
+		if n > 16 {
+			n = 16
+		}
+		t.Errorf("expected output to start with the PDF magic bytes, got %q", pdfBytes[:n])
+	}
+}
+
+func TestRenderRejectsEmptySegments(t *testing.T) {
+	_, err := Render(context.Background(), nil, DefaultOptions(), solidColorFetcher{col: color.Gray{Y: 200}}, Legend{})
+	if err == nil {
+		t.Error("expected an error for segments with no points")
+	}
+}
@@ -0,0 +1,359 @@
+package srv
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"srv.exe.dev/srv/areas"
+)
+
+// MatchCandidate is one name PlaceMatcher can resolve a query against:
+// either a park (from AreaStore) or an OSM place within a park.
+type MatchCandidate struct {
+	Kind      string // "park" or "place"
+	ID        int64  // osm_places.id; zero for "park" candidates
+	ParkID    string
+	Name      string
+	PlaceType string // only set for "place" candidates
+	Lat, Lon  float64
+}
+
+// MatchResult is a MatchCandidate ranked against a query.
+type MatchResult struct {
+	MatchCandidate
+	Score float64
+}
+
+// PlaceMatcher is an in-memory fuzzy-name index built once at server
+// start from osm_places.name and AreaStore.Areas, so free-text lookups
+// like "virunga natl park" or "Virungas" can resolve against the exact
+// WDPAID/name matching HandleAPIFireNarrative and
+// HandleAPIDeforestationNarrative otherwise require. It's a simple
+// FuzzySet-style index: character 2-grams and 3-grams of each
+// candidate's normalized name go into posting lists; a query is
+// shortlisted by summed IDF-weighted posting hits, then the shortlist
+// is re-ranked by Sørensen-Dice coefficient over the full gram sets
+// (Levenshtein distance breaks ties).
+type PlaceMatcher struct {
+	candidates []MatchCandidate
+	grams      [][]string // candidates[i]'s grams, parallel to candidates
+	postings   map[string][]int
+	idf        map[string]float64
+}
+
+// NewPlaceMatcher builds a PlaceMatcher from every park in areaStore
+// and every named place in db's osm_places table. Either may be nil/empty.
+func NewPlaceMatcher(db *sql.DB, areaStore *areas.AreaStore) (*PlaceMatcher, error) {
+	var candidates []MatchCandidate
+
+	if areaStore != nil {
+		for _, a := range areaStore.Areas {
+			latMin, latMax, lonMin, lonMax := a.GetBoundingBox()
+			candidates = append(candidates, MatchCandidate{
+				Kind:   "park",
+				ParkID: a.ID,
+				Name:   a.Name,
+				Lat:    (latMin + latMax) / 2,
+				Lon:    (lonMin + lonMax) / 2,
+			})
+		}
+	}
+
+	if db != nil {
+		rows, err := db.Query(`SELECT id, park_id, name, place_type, lat, lon FROM osm_places WHERE name != ''`)
+		if err != nil {
+			return nil, fmt.Errorf("place matcher: load osm_places: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var c MatchCandidate
+			c.Kind = "place"
+			if err := rows.Scan(&c.ID, &c.ParkID, &c.Name, &c.PlaceType, &c.Lat, &c.Lon); err != nil {
+				continue
+			}
+			candidates = append(candidates, c)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("place matcher: read osm_places: %w", err)
+		}
+	}
+
+	return buildPlaceMatcher(candidates), nil
+}
+
+func buildPlaceMatcher(candidates []MatchCandidate) *PlaceMatcher {
+	pm := &PlaceMatcher{
+		candidates: candidates,
+		grams:      make([][]string, len(candidates)),
+		postings:   make(map[string][]int),
+	}
+
+	df := make(map[string]int)
+	for i, c := range candidates {
+		g := nameGrams(c.Name)
+		pm.grams[i] = g
+		seen := make(map[string]bool, len(g))
+		for _, gram := range g {
+			pm.postings[gram] = append(pm.postings[gram], i)
+			if !seen[gram] {
+				df[gram]++
+				seen[gram] = true
+			}
+		}
+	}
+
+	n := float64(len(candidates))
+	pm.idf = make(map[string]float64, len(df))
+	for gram, count := range df {
+		pm.idf[gram] = 1 + n/float64(count) // unlogged IDF is enough here; monotonic, cheaper
+	}
+
+	return pm
+}
+
+// Len returns the number of candidates indexed.
+func (pm *PlaceMatcher) Len() int {
+	if pm == nil {
+		return 0
+	}
+	return len(pm.candidates)
+}
+
+// shortlistSize bounds how many IDF-scored candidates go on to the
+// exact Dice/Levenshtein re-rank, so a query matching common short
+// grams (e.g. "na") doesn't force a full Dice pass over every
+// candidate in the index.
+const shortlistSize = 50
+
+// Search ranks candidates against query, returning up to limit results
+// scoring at least minScore (a Sørensen-Dice coefficient in [0,1]).
+func (pm *PlaceMatcher) Search(query string, limit int, minScore float64) []MatchResult {
+	if pm == nil || limit <= 0 {
+		return nil
+	}
+	queryGrams := nameGrams(query)
+	if len(queryGrams) == 0 {
+		return nil
+	}
+
+	weighted := make(map[int]float64)
+	for _, g := range queryGrams {
+		w := pm.idf[g]
+		for _, idx := range pm.postings[g] {
+			weighted[idx] += w
+		}
+	}
+
+	type scored struct {
+		idx   int
+		score float64
+	}
+	shortlist := make([]scored, 0, len(weighted))
+	for idx, w := range weighted {
+		shortlist = append(shortlist, scored{idx, w})
+	}
+	sort.Slice(shortlist, func(i, j int) bool { return shortlist[i].score > shortlist[j].score })
+	if len(shortlist) > shortlistSize {
+		shortlist = shortlist[:shortlistSize]
+	}
+
+	querySet := gramSet(queryGrams)
+	normQuery := normalizeForMatch(query)
+
+	results := make([]MatchResult, 0, len(shortlist))
+	for _, sl := range shortlist {
+		dice := diceCoefficient(querySet, gramSet(pm.grams[sl.idx]))
+		if dice < minScore {
+			continue
+		}
+		results = append(results, MatchResult{MatchCandidate: pm.candidates[sl.idx], Score: dice})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return levenshtein(normQuery, normalizeForMatch(results[i].Name)) <
+			levenshtein(normQuery, normalizeForMatch(results[j].Name))
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// nameGrams normalizes s and returns its padded 2-gram and 3-gram
+// character shingles (padding with a leading/trailing space so a
+// name's first/last letters get grams of their own, the way FuzzySet
+// indexes typically do).
+func nameGrams(s string) []string {
+	norm := normalizeForMatch(s)
+	if norm == "" {
+		return nil
+	}
+	padded := " " + norm + " "
+	var grams []string
+	for n := 2; n <= 3; n++ {
+		for i := 0; i+n <= len(padded); i++ {
+			grams = append(grams, padded[i:i+n])
+		}
+	}
+	return grams
+}
+
+// normalizeForMatch lowercases s and collapses runs of non-alphanumeric
+// characters to a single space, so "Virunga Natl. Park" and
+// "virunga-natl-park" gram the same way.
+func normalizeForMatch(s string) string {
+	var b strings.Builder
+	lastSpace := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastSpace = false
+		} else if !lastSpace {
+			b.WriteRune(' ')
+			lastSpace = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func gramSet(grams []string) map[string]bool {
+	set := make(map[string]bool, len(grams))
+	for _, g := range grams {
+		set[g] = true
+	}
+	return set
+}
+
+// diceCoefficient is the Sørensen-Dice coefficient between two gram
+// sets: 2*|intersection| / (|a|+|b|).
+func diceCoefficient(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for g := range a {
+		if b[g] {
+			intersection++
+		}
+	}
+	return 2 * float64(intersection) / float64(len(a)+len(b))
+}
+
+// levenshtein is the classic edit distance, used only to break ties
+// between candidates with an equal Dice score.
+func levenshtein(a, b string) int {
+	if a == b {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// resolveParkID maps a user-supplied park identifier — WDPA ID,
+// internal ID, or free-text name — to the matching area's internal ID
+// and display name. An exact WDPAID/ID match always wins; failing
+// that, s.PlaceMatcher (if built) fuzzy-matches parkID against park
+// names, so a misspelled or partial name still resolves. Falls back to
+// echoing parkID back unchanged if nothing matches.
+func (s *Server) resolveParkID(parkID string) (internalID, name string) {
+	internalID, name = parkID, parkID
+	if s.AreaStore != nil {
+		for _, area := range s.AreaStore.Areas {
+			if area.WDPAID == parkID || area.ID == parkID {
+				return area.ID, area.Name
+			}
+		}
+	}
+	if matches := s.PlaceMatcher.Search(parkID, 1, 0.5); len(matches) > 0 && matches[0].Kind == "park" {
+		return matches[0].ParkID, matches[0].Name
+	}
+	return internalID, name
+}
+
+// HandleAPIPlacesSearch fuzzy-matches q against OSM place names within
+// park (or every park if park is omitted), for free-text/misspelled
+// queries a map search box would otherwise have to match exactly.
+// GET /api/places/search?park={id}&q=...&limit=N&min_score=0.5
+func (s *Server) HandleAPIPlacesSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	park := r.URL.Query().Get("park")
+
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	minScore := 0.3
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			minScore = f
+		}
+	}
+
+	type placeResult struct {
+		OSMPlace
+		Score float64 `json:"score"`
+	}
+	results := make([]placeResult, 0, limit)
+
+	// Over-fetch before filtering to "place" candidates in park, so
+	// that filter doesn't starve the result set below limit.
+	for _, m := range s.PlaceMatcher.Search(query, limit*4, minScore) {
+		if m.Kind != "place" {
+			continue
+		}
+		if park != "" && m.ParkID != park {
+			continue
+		}
+		results = append(results, placeResult{
+			OSMPlace: OSMPlace{ID: m.ID, ParkID: m.ParkID, PlaceType: m.PlaceType, Name: m.Name, Lat: m.Lat, Lon: m.Lon},
+			Score:    m.Score,
+		})
+		if len(results) >= limit {
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
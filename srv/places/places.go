@@ -0,0 +1,39 @@
+// Package places looks up OSM places (settlements, rivers, etc.) near a
+// coordinate for the narrative handlers (describeLocation,
+// analyzeFireHotspots, the deforestation event enrichment). It's
+// pluggable: SQLiteIndex does a bounding-box prefilter plus a Go-side
+// haversine sort, which is fine for the handful of places most parks
+// have, but degrades for parks with 10k+ OSM features since every call
+// re-scans and re-sorts in Go. PostGISIndex pushes the same query down
+// to ST_DWithin/ST_Distance on an indexed geography column instead, for
+// deployments backing osm_places with Postgres/PostGIS rather than
+// SQLite.
+package places
+
+import "context"
+
+// Place is one OSM place, distance-annotated against whatever
+// coordinate Index.Nearest was called with.
+type Place struct {
+	ID         int64
+	ParkID     string
+	PlaceType  string
+	Name       string
+	Lat        float64
+	Lon        float64
+	DistanceKm float64
+}
+
+// DefaultRadiusKm matches the ~1-degree bounding box the original
+// Go-side haversine scan used, for callers that don't have a more
+// specific radius in mind.
+const DefaultRadiusKm = 111.0
+
+// Index finds the nearest places to a coordinate, optionally restricted
+// to a set of place types (e.g. "village", "river"). Results are sorted
+// by distance ascending and capped at limit; limit <= 0 returns no
+// rows, matching the historical behavior of the lat/lon/limit helper
+// this interface replaces.
+type Index interface {
+	Nearest(ctx context.Context, parkID string, lat, lon, radiusKm float64, limit int, placeTypes []string) ([]Place, error)
+}
@@ -0,0 +1,62 @@
+package places
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PostGISIndex pushes the nearest-place query down to Postgres/PostGIS:
+// ST_DWithin on a geography column for the radius search (using the
+// geography cast so the distance argument is meters, not degrees, and
+// short-circuiting via the column's GiST index rather than scanning
+// every row), then ST_Distance for the final ordering. It expects
+// osm_places to carry a `geom geography(Point,4326)` column with a GiST
+// index — see this request's migration (adds the column and backfills
+// it from lat/lon) alongside the rest of db/migrations.
+type PostGISIndex struct {
+	db *sql.DB
+}
+
+// NewPostGISIndex wraps db, which should be a *sql.DB opened against
+// the Postgres/PostGIS deployment backing osm_places (not necessarily
+// the same connection as the rest of the app's SQLite database).
+func NewPostGISIndex(db *sql.DB) *PostGISIndex {
+	return &PostGISIndex{db: db}
+}
+
+func (idx *PostGISIndex) Nearest(ctx context.Context, parkID string, lat, lon, radiusKm float64, limit int, placeTypes []string) ([]Place, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, park_id, place_type, name, lat, lon,
+		       ST_Distance(geom, ST_MakePoint($2, $3)::geography) / 1000.0 AS distance_km
+		FROM osm_places
+		WHERE park_id = $1
+		  AND ST_DWithin(geom, ST_MakePoint($2, $3)::geography, $4)
+		  AND ($5::text[] IS NULL OR place_type = ANY($5::text[]))
+		ORDER BY distance_km ASC
+		LIMIT $6
+	`
+	var typesArg interface{}
+	if len(placeTypes) > 0 {
+		typesArg = placeTypes
+	}
+
+	rows, err := idx.db.QueryContext(ctx, query, parkID, lon, lat, radiusKm*1000.0, typesArg, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Place
+	for rows.Next() {
+		var p Place
+		if err := rows.Scan(&p.ID, &p.ParkID, &p.PlaceType, &p.Name, &p.Lat, &p.Lon, &p.DistanceKm); err != nil {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,93 @@
+package places
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SQLiteIndex is the original bounding-box-prefilter-plus-Go-haversine
+// implementation of Index, for deployments whose osm_places table lives
+// in the same SQLite database as everything else (see db.Open). It's
+// quadratic-ish under repeated calls against a dense table — PostGISIndex
+// exists for parks where that starts to matter.
+type SQLiteIndex struct {
+	db *sql.DB
+}
+
+// NewSQLiteIndex wraps db.
+func NewSQLiteIndex(db *sql.DB) *SQLiteIndex {
+	return &SQLiteIndex{db: db}
+}
+
+func (idx *SQLiteIndex) Nearest(ctx context.Context, parkID string, lat, lon, radiusKm float64, limit int, placeTypes []string) ([]Place, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	// A degree of latitude is ~111km everywhere; longitude shrinks with
+	// cos(lat), but this is just a prefilter ahead of the exact
+	// haversine sort below, so the fixed 111km/degree approximation is
+	// fine even though it over-fetches near the poles (never an issue
+	// for this app's African parks).
+	degrees := radiusKm / 111.0
+
+	query := `
+		SELECT id, park_id, place_type, name, lat, lon
+		FROM osm_places
+		WHERE park_id = ?
+		  AND lat BETWEEN ? AND ?
+		  AND lon BETWEEN ? AND ?
+	`
+	args := []interface{}{parkID, lat - degrees, lat + degrees, lon - degrees, lon + degrees}
+
+	if len(placeTypes) > 0 {
+		placeholders := make([]string, len(placeTypes))
+		for i := range placeTypes {
+			placeholders[i] = "?"
+			args = append(args, placeTypes[i])
+		}
+		query += " AND place_type IN (" + strings.Join(placeholders, ",") + ")"
+	}
+
+	rows, err := idx.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Place
+	for rows.Next() {
+		var p Place
+		if err := rows.Scan(&p.ID, &p.ParkID, &p.PlaceType, &p.Name, &p.Lat, &p.Lon); err != nil {
+			continue
+		}
+		p.DistanceKm = haversineKm(lat, lon, p.Lat, p.Lon)
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DistanceKm < result[j].DistanceKm
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// haversineKm calculates great-circle distance in kilometers; kept as
+// a private copy here rather than importing package srv (which would
+// be an import cycle — srv is what calls into this package) since it's
+// a two-line formula.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const r = 6371.0
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return r * c
+}
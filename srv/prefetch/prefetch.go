@@ -0,0 +1,193 @@
+// Package prefetch holds a small in-memory cache of encoded
+// HandleAPIGrid responses, plus the traffic sampler that tells the
+// scheduled rebuild job which query shapes are actually worth
+// precomputing. It's deliberately data-shape-agnostic (callers hand it
+// []byte + an ETag under a string key) so it isn't tied to the grid
+// endpoint's response type.
+package prefetch
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GridKey builds the canonical cache/sampler key for a HandleAPIGrid
+// query shape, so the live handler, the sampler, and the rebuild job
+// all agree on what counts as "the same" request.
+func GridKey(fromYear, toYear int64, monthStr string) string {
+	if monthStr == "" {
+		return fmt.Sprintf("grid:%d:%d", fromYear, toYear)
+	}
+	return fmt.Sprintf("grid:%d:%d:%s", fromYear, toYear, monthStr)
+}
+
+// Entry is one precomputed response.
+type Entry struct {
+	Data       []byte
+	ETag       string
+	ComputedAt time.Time
+}
+
+// RebuildStats summarizes the most recent rebuild, for /debug/prefetch.
+type RebuildStats struct {
+	At   time.Time
+	Took time.Duration
+	Keys []string
+}
+
+type cacheItem struct {
+	key   string
+	value Entry
+}
+
+// Cache is a fixed-capacity in-memory LRU of Entry keyed by a query's
+// canonical string form, modeled on srv/tiles.Cache.
+type Cache struct {
+	capacity int
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+
+	hits, misses atomic.Int64
+	lastRebuild  atomic.Pointer[RebuildStats]
+}
+
+// NewCache creates a Cache holding up to capacity entries.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the entry stored for key, recording a hit or miss.
+func (c *Cache) Get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return Entry{}, false
+	}
+	c.hits.Add(1)
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheItem).value, true
+}
+
+// Put stores value under key, evicting the least-recently-used entry if
+// the cache is at capacity.
+func (c *Cache) Put(key string, value Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheItem).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&cacheItem{key: key, value: value})
+	c.items[key] = el
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheItem).key)
+		}
+	}
+}
+
+// RecordRebuild stores stats about the rebuild cycle that just
+// populated keys, for /debug/prefetch to report.
+func (c *Cache) RecordRebuild(keys []string, took time.Duration, at time.Time) {
+	c.lastRebuild.Store(&RebuildStats{At: at, Took: took, Keys: keys})
+}
+
+// Stats is a snapshot of the cache's hit rate and last rebuild, for
+// /debug/prefetch.
+type Stats struct {
+	Size        int
+	Capacity    int
+	Hits        int64
+	Misses      int64
+	LastRebuild *RebuildStats
+}
+
+// Stats returns a snapshot of the cache's current state.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Size:        size,
+		Capacity:    c.capacity,
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		LastRebuild: c.lastRebuild.Load(),
+	}
+}
+
+// Sampler records incoming query fingerprints between rebuild cycles, so
+// the next cycle can learn which shapes callers are actually requesting
+// instead of only refreshing a fixed canonical set.
+type Sampler struct {
+	counts sync.Map // key (string) -> *atomic.Int64
+}
+
+// NewSampler creates an empty Sampler.
+func NewSampler() *Sampler {
+	return &Sampler{}
+}
+
+// Record increments key's count.
+func (s *Sampler) Record(key string) {
+	v, _ := s.counts.LoadOrStore(key, new(atomic.Int64))
+	v.(*atomic.Int64).Add(1)
+}
+
+type keyCount struct {
+	key   string
+	count int64
+}
+
+// Peek returns the n keys with the highest recorded counts, without
+// resetting them, for /debug/prefetch's introspection view.
+func (s *Sampler) Peek(n int) []string {
+	return s.top(n, false)
+}
+
+// Drain returns the n keys with the highest recorded counts and resets
+// every count, so the rebuild job learns from a fresh window each cycle
+// instead of an ever-growing history dominated by early traffic.
+func (s *Sampler) Drain(n int) []string {
+	return s.top(n, true)
+}
+
+func (s *Sampler) top(n int, reset bool) []string {
+	var all []keyCount
+	s.counts.Range(func(k, v any) bool {
+		all = append(all, keyCount{key: k.(string), count: v.(*atomic.Int64).Load()})
+		if reset {
+			s.counts.Delete(k)
+		}
+		return true
+	})
+	sort.Slice(all, func(i, j int) bool { return all[i].count > all[j].count })
+	if len(all) > n {
+		all = all[:n]
+	}
+	out := make([]string, len(all))
+	for i, kc := range all {
+		out[i] = kc.key
+	}
+	return out
+}
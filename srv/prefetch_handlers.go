@@ -0,0 +1,107 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"srv.exe.dev/srv/prefetch"
+)
+
+// prefetchCacheCapacity bounds how many encoded /api/grid responses
+// s.PrefetchCache keeps warm; the rebuild job only ever populates a
+// handful of canonical shapes plus whatever the sampler reports, so
+// this is generous headroom rather than a tight budget.
+const prefetchCacheCapacity = 64
+
+// prefetchSampleSize is how many sampler-reported query shapes the
+// rebuild job refreshes each cycle, on top of the fixed canonical set.
+const prefetchSampleSize = 8
+
+// RebuildPrefetchCache recomputes and stores the encoded /api/grid
+// response for a fixed set of canonical query shapes (current year,
+// current year+month, last 12 months) plus whatever shapes
+// s.PrefetchSampler has seen requested since the last rebuild, so the
+// handler can serve a warm cache hit instead of recomputing on demand.
+//
+// It does not yet cover the request's "per-loaded-PA bbox" shape:
+// HandleAPIGrid has no bbox parameter today, so there is no per-PA
+// query to replay. That lands once HandleAPIGrid gains bbox filtering.
+func (s *Server) RebuildPrefetchCache(ctx context.Context) error {
+	start := time.Now()
+	now := time.Now()
+
+	type shape struct {
+		fromYear, toYear int64
+		month            string
+	}
+	shapes := []shape{
+		{fromYear: int64(now.Year()), toYear: int64(now.Year())},
+		{fromYear: int64(now.Year()), toYear: int64(now.Year()), month: fmt.Sprint(int(now.Month()))},
+		{fromYear: int64(now.Year() - 1), toYear: int64(now.Year())},
+	}
+	seen := map[string]bool{prefetch.GridKey(shapes[0].fromYear, shapes[0].toYear, shapes[0].month): true}
+	for _, k := range s.PrefetchSampler.Drain(prefetchSampleSize) {
+		var fromYear, toYear int64
+		var month string
+		if _, err := fmt.Sscanf(k, "grid:%d:%d:%s", &fromYear, &toYear, &month); err != nil {
+			if _, err := fmt.Sscanf(k, "grid:%d:%d", &fromYear, &toYear); err != nil {
+				continue // not a grid key the sampler should have produced
+			}
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		shapes = append(shapes, shape{fromYear: fromYear, toYear: toYear, month: month})
+	}
+
+	var rebuilt []string
+	for _, sh := range shapes {
+		features, qerr := s.computeGridFeatures(ctx, sh.fromYear, sh.toYear, sh.month, nil)
+		if qerr != nil {
+			slog.Warn("prefetch rebuild: skipping shape", "fromYear", sh.fromYear, "toYear", sh.toYear, "month", sh.month, "error", qerr)
+			continue
+		}
+
+		fc := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features}
+		data, err := json.Marshal(fc)
+		if err != nil {
+			return fmt.Errorf("marshal prefetch shape %d-%d/%s: %w", sh.fromYear, sh.toYear, sh.month, err)
+		}
+
+		sum := fnv.New64a()
+		sum.Write(data)
+		etag := fmt.Sprintf(`"%x"`, sum.Sum64())
+
+		key := prefetch.GridKey(sh.fromYear, sh.toYear, sh.month)
+		s.PrefetchCache.Put(key, prefetch.Entry{Data: data, ETag: etag, ComputedAt: now})
+		rebuilt = append(rebuilt, key)
+	}
+
+	s.PrefetchCache.RecordRebuild(rebuilt, time.Since(start), now)
+	return nil
+}
+
+// HandleDebugPrefetch reports the prefetch cache's hit rate and the
+// keys populated by the most recent rebuild, for operators checking
+// whether the precompute schedule is keeping up with traffic.
+func (s *Server) HandleDebugPrefetch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.PrefetchCache.Stats())
+}
+
+// HandleAdminPrefetchRebuild triggers an immediate prefetch rebuild, so
+// an operator doesn't have to wait for the next scheduled cycle after a
+// data import.
+func (s *Server) HandleAdminPrefetchRebuild(w http.ResponseWriter, r *http.Request) {
+	if err := s.RebuildPrefetchCache(r.Context()); err != nil {
+		http.Error(w, "rebuild failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package srv
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// dropPrivileges switches the process to the given user/group (by name
+// or numeric ID), in gid-then-uid order since changing the uid first
+// would leave the process without permission to change its gid. Either
+// argument may be empty to leave that half unchanged.
+func dropPrivileges(userName, groupName string) error {
+	if groupName != "" {
+		gid, err := lookupGID(groupName)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("setgid(%d): %w", gid, err)
+		}
+	}
+	if userName != "" {
+		uid, err := lookupUID(userName)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setuid(uid); err != nil {
+			return fmt.Errorf("setuid(%d): %w", uid, err)
+		}
+	}
+	return nil
+}
+
+func lookupUID(userName string) (int, error) {
+	if uid, err := strconv.Atoi(userName); err == nil {
+		return uid, nil
+	}
+	u, err := user.Lookup(userName)
+	if err != nil {
+		return 0, fmt.Errorf("lookup user %q: %w", userName, err)
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+func lookupGID(groupName string) (int, error) {
+	if gid, err := strconv.Atoi(groupName); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(groupName)
+	if err != nil {
+		return 0, fmt.Errorf("lookup group %q: %w", groupName, err)
+	}
+	return strconv.Atoi(g.Gid)
+}
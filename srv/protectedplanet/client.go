@@ -3,18 +3,42 @@
 package protectedplanet
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 const (
 	baseURL    = "https://api.protectedplanet.net/v3"
 	apiKey     = "dea58ea0389007e386776c4f583f4425"
 	timeoutSec = 30
+
+	// maxResponseBytes bounds how much of a single response doRequest
+	// will read, so a misbehaving response can't exhaust memory.
+	maxResponseBytes = 32 << 20 // 32 MiB
+
+	defaultMaxAttempts = 5
+	defaultBackoffBase = 500 * time.Millisecond
+	defaultBackoffCap  = 30 * time.Second
+
+	// defaultQPS is deliberately conservative: Protected Planet doesn't
+	// publish a documented rate limit, and this is shared across every
+	// goroutine using one Client so concurrent fetchpas workers don't
+	// collectively exceed it.
+	defaultQPS = 2.0
 )
 
 // Common errors
@@ -41,22 +65,36 @@ type GeoJSONFeature struct {
 
 // PA represents a Protected Area.
 type PA struct {
-	WDPAID       int            `json:"wdpa_id"`
-	Name         string         `json:"name"`
-	Country      string         `json:"country"`
+	WDPAID       int             `json:"wdpa_id"`
+	Name         string          `json:"name"`
+	Country      string          `json:"country"`
 	Geometry     *GeoJSONFeature `json:"geometry,omitempty"`
-	AreaKm2      float64        `json:"area_km2"`
-	IUCNCategory string         `json:"iucn_category"`
+	AreaKm2      float64         `json:"area_km2"`
+	IUCNCategory string          `json:"iucn_category"`
 }
 
-// Client is a Protected Planet API client.
+// Client is a Protected Planet API client. It's safe for concurrent
+// use: every request goes through the shared rate limiter, so N
+// goroutines fetching different WDPA IDs through one Client still
+// respect a single global QPS.
 type Client struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	limiter    *rate.Limiter
+
+	// CacheDir, if set, caches response bodies on disk keyed by request
+	// URL and revalidates them with If-None-Match/If-Modified-Since
+	// instead of re-downloading unchanged data.
+	CacheDir string
+
+	// MaxAttempts bounds how many times doRequest retries a 429 or 5xx
+	// response before giving up. Defaults to defaultMaxAttempts.
+	MaxAttempts int
 }
 
-// NewClient creates a new Protected Planet API client.
+// NewClient creates a new Protected Planet API client with the default
+// rate limit and no on-disk cache.
 func NewClient() *Client {
 	return &Client{
 		baseURL: baseURL,
@@ -64,6 +102,8 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: timeoutSec * time.Second,
 		},
+		limiter:     rate.NewLimiter(rate.Limit(defaultQPS), 1),
+		MaxAttempts: defaultMaxAttempts,
 	}
 }
 
@@ -74,6 +114,16 @@ func NewClientWithKey(apiKey string) *Client {
 	return c
 }
 
+// NewClientWithTransport creates a new client that sends requests
+// through rt instead of the default transport. Tests use this with a
+// ReplayTransport (or a RecordingTransport, when refreshing fixtures)
+// so they don't depend on Protected Planet being reachable.
+func NewClientWithTransport(rt Transport) *Client {
+	c := NewClient()
+	c.httpClient.Transport = rt
+	return c
+}
+
 // apiResponse wraps the common API response structure.
 type searchResponse struct {
 	ProtectedAreas []apiPA `json:"protected_areas"`
@@ -127,53 +177,190 @@ func (a *apiPA) toPA() *PA {
 	return pa
 }
 
-// doRequest performs an HTTP request and handles common errors.
-func (c *Client) doRequest(endpoint string) ([]byte, error) {
-	resp, err := c.httpClient.Get(endpoint)
+// cacheEntry is what's persisted per cached URL: the validators needed
+// to revalidate with a conditional GET, plus the body they validate.
+type cacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+func (c *Client) cachePath(endpoint string) string {
+	if c.CacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(endpoint))
+	return filepath.Join(c.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Client) loadCache(endpoint string) *cacheEntry {
+	path := c.cachePath(endpoint)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func (c *Client) saveCache(endpoint string, entry cacheEntry) {
+	path := c.cachePath(endpoint)
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(entry)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
+
+// doRequest performs an HTTP GET against endpoint, honoring ctx
+// cancellation, the client's shared rate limit, and ETag/Last-Modified
+// revalidation against CacheDir. It retries 429s and 5xxs with
+// exponential backoff and jitter, preferring a server-supplied
+// Retry-After when present, up to MaxAttempts.
+func (c *Client) doRequest(ctx context.Context, endpoint string) ([]byte, error) {
+	maxAttempts := c.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	cached := c.loadCache(endpoint)
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := backoffDelay(attempt)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request failed: %w", err)
+			continue
+		}
+
+		body, retryAfter, retriable, err := readResponse(resp, cached)
+		resp.Body.Close()
+		if err != nil {
+			if !retriable || attempt == maxAttempts-1 {
+				return nil, err
+			}
+			lastErr = err
+			if retryAfter > 0 {
+				time.Sleep(retryAfter)
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusNotModified {
+			c.saveCache(endpoint, cacheEntry{
+				ETag:         resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"),
+				Body:         body,
+			})
+		}
+		return body, nil
 	}
-	defer resp.Body.Close()
 
-	// Handle HTTP errors
+	return nil, lastErr
+}
+
+// readResponse classifies resp's status, returning the body on success
+// (or the cached body on a 304), and reporting whether the caller
+// should retry the request.
+func readResponse(resp *http.Response, cached *cacheEntry) (body []byte, retryAfter time.Duration, retriable bool, err error) {
 	switch resp.StatusCode {
 	case http.StatusOK:
-		// Continue to parse body
+		// fall through to read body below
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, 0, false, errors.New("protectedplanet: got 304 with no cached body")
+		}
+		return cached.Body, 0, false, nil
 	case http.StatusNotFound:
-		return nil, ErrNotFound
+		return nil, 0, false, ErrNotFound
 	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
+		return nil, 0, false, ErrUnauthorized
 	case http.StatusTooManyRequests:
-		return nil, ErrRateLimited
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), true, ErrRateLimited
 	case http.StatusBadRequest:
-		return nil, fmt.Errorf("bad request: check parameters")
+		return nil, 0, false, fmt.Errorf("bad request: check parameters")
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
-		return nil, ErrServerError
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), true, ErrServerError
 	default:
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, 0, false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var body []byte
-	body = make([]byte, 0, 1024*1024) // Pre-allocate 1MB
-	buf := make([]byte, 32*1024)
-	for {
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			body = append(body, buf[:n]...)
-		}
-		if err != nil {
-			break
-		}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, 0, true, fmt.Errorf("read response body: %w", err)
+	}
+	return data, 0, false, nil
+}
+
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
 	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
 
-	return body, nil
+// backoffDelay computes the delay before the given retry attempt
+// (1-indexed), doubling from defaultBackoffBase and capped at
+// defaultBackoffCap, with up to 20% jitter so concurrent retries don't
+// all land on the same instant.
+func backoffDelay(attempt int) time.Duration {
+	delay := defaultBackoffBase << uint(attempt-1)
+	if delay > defaultBackoffCap || delay <= 0 {
+		delay = defaultBackoffCap
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 5))
+	return delay + jitter
 }
 
 // SearchByName searches for protected areas by name within a country.
 // The Protected Planet API requires at least one filter (country is most common).
 // countryISO3 is the 3-letter ISO country code (e.g., "TZA" for Tanzania).
 // If countryISO3 is empty, it returns an error.
-func (c *Client) SearchByName(name string, countryISO3 string) ([]PA, error) {
+func (c *Client) SearchByName(ctx context.Context, name string, countryISO3 string) ([]PA, error) {
 	if countryISO3 == "" {
 		return nil, errors.New("country ISO3 code is required for search")
 	}
@@ -181,7 +368,7 @@ func (c *Client) SearchByName(name string, countryISO3 string) ([]PA, error) {
 	endpoint := fmt.Sprintf("%s/protected_areas/search?token=%s&country=%s",
 		c.baseURL, c.apiKey, url.QueryEscape(countryISO3))
 
-	body, err := c.doRequest(endpoint)
+	body, err := c.doRequest(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -203,13 +390,13 @@ func (c *Client) SearchByName(name string, countryISO3 string) ([]PA, error) {
 }
 
 // SearchByCountry returns all protected areas in a country.
-func (c *Client) SearchByCountry(countryISO3 string) ([]PA, error) {
-	return c.SearchByName("", countryISO3)
+func (c *Client) SearchByCountry(ctx context.Context, countryISO3 string) ([]PA, error) {
+	return c.SearchByName(ctx, "", countryISO3)
 }
 
 // GetByWDPAID retrieves a protected area by its WDPA ID.
 // The geometry is included in the response.
-func (c *Client) GetByWDPAID(wdpaID int) (*PA, error) {
+func (c *Client) GetByWDPAID(ctx context.Context, wdpaID int) (*PA, error) {
 	if wdpaID <= 0 {
 		return nil, ErrInvalidWDPAID
 	}
@@ -217,7 +404,7 @@ func (c *Client) GetByWDPAID(wdpaID int) (*PA, error) {
 	endpoint := fmt.Sprintf("%s/protected_areas/%d?token=%s",
 		c.baseURL, wdpaID, c.apiKey)
 
-	body, err := c.doRequest(endpoint)
+	body, err := c.doRequest(ctx, endpoint)
 	if err != nil {
 		return nil, err
 	}
@@ -232,8 +419,8 @@ func (c *Client) GetByWDPAID(wdpaID int) (*PA, error) {
 
 // GetGeometry retrieves just the geometry for a protected area.
 // This is a convenience method that calls GetByWDPAID and extracts the geometry.
-func (c *Client) GetGeometry(wdpaID int) (*GeoJSONFeature, error) {
-	pa, err := c.GetByWDPAID(wdpaID)
+func (c *Client) GetGeometry(ctx context.Context, wdpaID int) (*GeoJSONFeature, error) {
+	pa, err := c.GetByWDPAID(ctx, wdpaID)
 	if err != nil {
 		return nil, err
 	}
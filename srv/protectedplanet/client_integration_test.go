@@ -0,0 +1,60 @@
+//go:build integration
+
+package protectedplanet
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// TestRefreshFixtures hits the real Protected Planet API and overwrites
+// testdata/fixtures with what it gets back — this is what `make
+// refresh-fixtures` runs (see the integration build tag: it's excluded
+// from a plain `go test` so CI never depends on the network). It
+// doubles as a schema-drift check: toPA's field extraction is asserted
+// against the live response, so a response shape change that would
+// silently zero out a field in production fails here first instead of
+// only showing up as a quietly wrong fixture.
+func TestRefreshFixtures(t *testing.T) {
+	client := NewClientWithTransport(&RecordingTransport{Dir: fixtureDir, Next: http.DefaultTransport})
+	ctx := context.Background()
+
+	if _, err := client.SearchByName(ctx, "Serengeti", "TZA"); err != nil {
+		t.Fatalf("refresh search_tza fixture: %v", err)
+	}
+
+	pa, err := client.GetByWDPAID(ctx, serengetiWDPAID)
+	if err != nil {
+		t.Fatalf("refresh %d fixture: %v", serengetiWDPAID, err)
+	}
+	assertNoDrift(t, pa, true)
+
+	if pa, err = client.GetByWDPAID(ctx, missingGeometryWDPAID); err != nil {
+		t.Fatalf("refresh %d fixture: %v", missingGeometryWDPAID, err)
+	} else {
+		assertNoDrift(t, pa, false)
+	}
+
+	if pa, err = client.GetByWDPAID(ctx, multipolygonWDPAID); err != nil {
+		t.Fatalf("refresh %d fixture: %v", multipolygonWDPAID, err)
+	} else {
+		assertNoDrift(t, pa, true)
+	}
+}
+
+// assertNoDrift fails if a required field toPA is supposed to populate
+// came back empty, which is what an undocumented Protected Planet
+// response shape change would look like.
+func assertNoDrift(t *testing.T, pa *PA, wantGeometry bool) {
+	t.Helper()
+	if pa.Name == "" {
+		t.Error("schema drift: PA.Name is empty")
+	}
+	if pa.Country == "" {
+		t.Error("schema drift: PA.Country is empty")
+	}
+	if wantGeometry && pa.Geometry == nil {
+		t.Error("schema drift: PA.Geometry is nil for a protected area expected to have one")
+	}
+}
@@ -0,0 +1,70 @@
+package protectedplanet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"srv.exe.dev/srv/filter"
+)
+
+// searchParamsSchema describes the srv/filter DSL fields SearchFiltered
+// can push down as Protected Planet API query parameters. Only "country"
+// is a real query param the API accepts for /protected_areas/search; iucn
+// isn't (the API has no server-side IUCN category filter), so it's left
+// out here and handled entirely by the post-fetch predicate instead.
+var searchParamsSchema = filter.Schema{
+	Columns: map[string]string{"country": "country"},
+}
+
+// Field implements filter.FieldGetter so a parsed filter.Expr can be
+// evaluated against search results Protected Planet returns — used by
+// SearchFiltered for every DSL field the API itself can't filter on
+// server-side (area_km2, iucn, name).
+func (pa PA) Field(name string) (interface{}, bool) {
+	switch name {
+	case "country":
+		return pa.Country, true
+	case "name":
+		return pa.Name, true
+	case "area_km2":
+		return pa.AreaKm2, true
+	case "iucn":
+		return pa.IUCNCategory, true
+	default:
+		return nil, false
+	}
+}
+
+// SearchFiltered searches for protected areas using a srv/filter DSL
+// expression instead of SearchByName's separate name/countryISO3
+// arguments. f must contain a top-level "country:XYZ" term — like
+// SearchByName, the underlying API call requires a country — which is
+// compiled into the request's country query parameter; every other term
+// (area_km2, iucn, name, boolean combinations) is evaluated in-memory
+// against the country's full result set, since the API has no
+// server-side filter for them.
+func (c *Client) SearchFiltered(ctx context.Context, f filter.Expr) ([]PA, error) {
+	params, err := searchParamsSchema.CompileParams(f)
+	if err != nil {
+		return nil, fmt.Errorf("filter not usable for Protected Planet search: %w", err)
+	}
+	country := params.Get("country")
+	if country == "" {
+		return nil, errors.New("filter must include a top-level country:XYZ term for Protected Planet search")
+	}
+
+	results, err := c.SearchByCountry(ctx, country)
+	if err != nil {
+		return nil, err
+	}
+
+	pred := filter.CompilePredicate(f)
+	out := make([]PA, 0, len(results))
+	for _, pa := range results {
+		if pred(pa) {
+			out = append(out, pa)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package protectedplanet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Transport is the Client's HTTP extension point: anything satisfying
+// http.RoundTripper (it's the same interface, aliased here so callers
+// don't need to import net/http just to pass one to
+// NewClientWithTransport). Tests use RecordingTransport/ReplayTransport
+// instead of the real network; production code leaves it unset, which
+// falls back to http.DefaultTransport same as any other http.Client.
+type Transport = http.RoundTripper
+
+// fixtureDir is where RecordingTransport writes and ReplayTransport
+// reads VCR-style fixtures, relative to the package directory.
+const fixtureDir = "testdata/fixtures"
+
+// recordedResponse is what RecordingTransport persists per fixture:
+// enough of the real response to reconstruct an *http.Response without
+// needing the original request.
+type recordedResponse struct {
+	StatusCode int    `json:"statusCode"`
+	Body       []byte `json:"body"`
+}
+
+// RecordingTransport forwards requests to Next (http.DefaultTransport
+// if nil) and writes each response to Dir/<fixtureName>.json, so a
+// later ReplayTransport pointed at the same Dir can serve it without
+// touching the network. It's meant for `make refresh-fixtures`, not
+// for `go test` itself — recording silently overwrites whatever
+// fixture already exists for that request.
+type RecordingTransport struct {
+	Dir  string
+	Next http.RoundTripper
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("protectedplanet: read response to record: %w", err)
+	}
+
+	name, err := fixtureName(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFixture(t.dir(), name, recordedResponse{StatusCode: resp.StatusCode, Body: body}); err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (t *RecordingTransport) dir() string {
+	if t.Dir != "" {
+		return t.Dir
+	}
+	return fixtureDir
+}
+
+// ReplayTransport serves recorded fixtures instead of making real HTTP
+// requests. It's what `go test` uses by default (see client_test.go),
+// so CI doesn't depend on Protected Planet being reachable or rate
+// limit test runs.
+type ReplayTransport struct {
+	Dir string
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name, err := fixtureName(req)
+	if err != nil {
+		return nil, err
+	}
+	dir := t.Dir
+	if dir == "" {
+		dir = fixtureDir
+	}
+	recorded, err := readFixture(dir, name)
+	if err != nil {
+		return nil, fmt.Errorf("protectedplanet: no fixture for %s (run `make refresh-fixtures`?): %w", req.URL, err)
+	}
+	return &http.Response{
+		StatusCode: recorded.StatusCode,
+		Status:     http.StatusText(recorded.StatusCode),
+		Body:       io.NopCloser(bytes.NewReader(recorded.Body)),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+// fixtureName derives a stable, readable fixture filename (sans
+// extension) from a request: the WDPA ID for GET
+// /protected_areas/{id}, or "search_<country>" for a country search,
+// matching doRequest's two endpoint shapes. Anything else is an error
+// rather than a guess, since a silently-wrong fixture name would just
+// look like a missing fixture later.
+func fixtureName(req *http.Request) (string, error) {
+	base := filepath.Base(req.URL.Path)
+	switch {
+	case base == "search":
+		country := strings.ToLower(req.URL.Query().Get("country"))
+		if country == "" {
+			return "", fmt.Errorf("protectedplanet: search request has no country param: %s", req.URL)
+		}
+		return "search_" + country, nil
+	case base != "" && base != "." && base != "/":
+		return base, nil
+	default:
+		return "", fmt.Errorf("protectedplanet: can't derive a fixture name from %s", req.URL)
+	}
+}
+
+func writeFixture(dir, name string, r recordedResponse) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("protectedplanet: create fixture dir: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("protectedplanet: marshal fixture: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), data, 0644); err != nil {
+		return fmt.Errorf("protectedplanet: write fixture: %w", err)
+	}
+	return nil
+}
+
+func readFixture(dir, name string) (*recordedResponse, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var r recordedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse fixture: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,398 @@
+package protectedplanet
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedCRS is returned when a WFS feature collection is in a
+// coordinate reference system DownloadFeatures doesn't know how to
+// reproject to EPSG:4326. Only EPSG:4326 (identity) and EPSG:3857 (Web
+// Mercator, the other CRS most public WFS endpoints default to) are
+// supported today; anything else needs a real projection library this
+// package doesn't depend on.
+var ErrUnsupportedCRS = errors.New("protectedplanet: unsupported CRS for reprojection")
+
+// FeatureType describes one layer advertised by a WFS 2.0 /
+// OGC API - Features GetCapabilities document.
+type FeatureType struct {
+	Name  string // qualified type name, e.g. "cdda:nationalparks"
+	Title string
+	CRS   string     // normalized "EPSG:<code>"
+	BBox  [4]float64 // minLon, minLat, maxLon, maxLat, WGS84
+}
+
+// WFSCapabilities is the parsed result of a GetCapabilities request.
+type WFSCapabilities struct {
+	FeatureTypes []FeatureType
+}
+
+// WFSClient talks to a WFS 2.0 or OGC API - Features server. Unlike
+// Client, it isn't tied to a single API's auth/rate-limit scheme —
+// national WFS endpoints vary widely — so it trades the shared
+// limiter/cache for a minimal retrying GET, matching what
+// DownloadFeatures actually needs: a handful of large paged requests,
+// not many small ones.
+type WFSClient struct {
+	capsURL     string
+	httpClient  *http.Client
+	MaxAttempts int
+}
+
+// NewWFSClient creates a client against the server whose capabilities
+// document is at capsURL (a GetCapabilities URL, or the bare OGC API -
+// Features landing page — Capabilities fetches /collections itself
+// when capsURL doesn't look like a WFS GetCapabilities request).
+func NewWFSClient(capsURL string) *WFSClient {
+	return &WFSClient{
+		capsURL: capsURL,
+		httpClient: &http.Client{
+			Timeout: timeoutSec * time.Second,
+		},
+		MaxAttempts: defaultMaxAttempts,
+	}
+}
+
+// wfsCapabilitiesXML mirrors the subset of a WFS 2.0 GetCapabilities
+// response DownloadFeatures needs: each advertised FeatureType's name,
+// title, default CRS, and WGS84 bounding box.
+type wfsCapabilitiesXML struct {
+	XMLName      xml.Name `xml:"WFS_Capabilities"`
+	FeatureTypes []struct {
+		Name       string `xml:"Name"`
+		Title      string `xml:"Title"`
+		DefaultCRS string `xml:"DefaultCRS"`
+		WGS84BBox  struct {
+			LowerCorner string `xml:"LowerCorner"`
+			UpperCorner string `xml:"UpperCorner"`
+		} `xml:"WGS84BoundingBox"`
+	} `xml:"FeatureTypeList>FeatureType"`
+}
+
+// Capabilities fetches and parses the server's GetCapabilities
+// document into typed FeatureTypes.
+func (c *WFSClient) Capabilities(ctx context.Context) (*WFSCapabilities, error) {
+	body, err := c.get(ctx, c.capabilitiesURL())
+	if err != nil {
+		return nil, err
+	}
+
+	var raw wfsCapabilitiesXML
+	if err := xml.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("parse GetCapabilities: %w", err)
+	}
+
+	caps := &WFSCapabilities{}
+	for _, ft := range raw.FeatureTypes {
+		entry := FeatureType{
+			Name:  ft.Name,
+			Title: ft.Title,
+			CRS:   normalizeCRS(ft.DefaultCRS),
+		}
+		if lon, lat, ok := parseCorner(ft.WGS84BBox.LowerCorner); ok {
+			entry.BBox[0], entry.BBox[1] = lon, lat
+		}
+		if lon, lat, ok := parseCorner(ft.WGS84BBox.UpperCorner); ok {
+			entry.BBox[2], entry.BBox[3] = lon, lat
+		}
+		caps.FeatureTypes = append(caps.FeatureTypes, entry)
+	}
+	return caps, nil
+}
+
+// capabilitiesURL appends the GetCapabilities query params to capsURL
+// if it doesn't already look like a WFS request.
+func (c *WFSClient) capabilitiesURL() string {
+	if strings.Contains(strings.ToLower(c.capsURL), "request=getcapabilities") {
+		return c.capsURL
+	}
+	sep := "?"
+	if strings.Contains(c.capsURL, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%sservice=WFS&version=2.0.0&request=GetCapabilities", c.capsURL, sep)
+}
+
+func parseCorner(s string) (lon, lat float64, ok bool) {
+	parts := strings.Fields(s)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lon, err1 := strconv.ParseFloat(parts[0], 64)
+	lat, err2 := strconv.ParseFloat(parts[1], 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return lon, lat, true
+}
+
+// normalizeCRS reduces the several ways a WFS/OGC API response names a
+// CRS ("EPSG:4326", "urn:ogc:def:crs:EPSG::4326",
+// "http://www.opengis.net/def/crs/EPSG/0/4326") down to "EPSG:<code>".
+// An unrecognized form is returned unchanged so callers can still
+// detect and report it.
+func normalizeCRS(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ':' || r == '/'
+	})
+	for i := len(fields) - 1; i >= 0; i-- {
+		if strings.EqualFold(fields[i], "EPSG") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[i]); err == nil {
+			// Walk back to find the preceding "EPSG" token.
+			for j := i - 1; j >= 0; j-- {
+				if strings.EqualFold(fields[j], "EPSG") {
+					return "EPSG:" + fields[i]
+				}
+			}
+		}
+	}
+	return raw
+}
+
+// DownloadFeatures pages through typeName's features within bbox
+// (minLon, minLat, maxLon, maxLat, WGS84), applying an optional
+// OGC filter expression (CQL or Filter Encoding, passed through
+// verbatim — it's server-specific), and calls cb once per feature
+// converted into the same PA struct GetByWDPAID returns. Paging uses
+// WFS 2.0's startIndex/count; if the server ignores them and returns
+// everything in one response, DownloadFeatures detects that (response
+// size stops shrinking) and stops after the first page.
+func (c *WFSClient) DownloadFeatures(ctx context.Context, typeName string, bbox [4]float64, filter string, cb func(PA) error) error {
+	const pageSize = 1000
+	startIndex := 0
+	for {
+		endpoint := c.featureRequestURL(typeName, bbox, filter, startIndex, pageSize)
+		body, err := c.get(ctx, endpoint)
+		if err != nil {
+			return fmt.Errorf("download features (startIndex=%d): %w", startIndex, err)
+		}
+
+		pas, count, err := decodeFeatureCollection(body)
+		if err != nil {
+			return fmt.Errorf("decode feature collection (startIndex=%d): %w", startIndex, err)
+		}
+
+		for _, pa := range pas {
+			if err := cb(pa); err != nil {
+				return err
+			}
+		}
+
+		if count < pageSize {
+			return nil
+		}
+		startIndex += pageSize
+	}
+}
+
+func (c *WFSClient) featureRequestURL(typeName string, bbox [4]float64, filter string, startIndex, count int) string {
+	sep := "?"
+	if strings.Contains(c.capsURL, "?") {
+		sep = "&"
+	}
+	u := fmt.Sprintf("%s%sservice=WFS&version=2.0.0&request=GetFeature&typeNames=%s&outputFormat=application/json&srsName=EPSG:4326&startIndex=%d&count=%d",
+		c.capsURL, sep, url.QueryEscape(typeName), startIndex, count)
+	if bbox != [4]float64{} {
+		u += fmt.Sprintf("&bbox=%f,%f,%f,%f,EPSG:4326", bbox[0], bbox[1], bbox[2], bbox[3])
+	}
+	if filter != "" {
+		u += "&CQL_FILTER=" + url.QueryEscape(filter)
+	}
+	return u
+}
+
+// geoJSONFeatureCollection is the GeoJSON shape WFS 2.0/OGC API -
+// Features servers return for outputFormat=application/json.
+type geoJSONFeatureCollection struct {
+	Type string `json:"type"`
+	CRS  *struct {
+		Properties struct {
+			Name string `json:"name"`
+		} `json:"properties"`
+	} `json:"crs,omitempty"`
+	Features []struct {
+		Type       string          `json:"type"`
+		Properties json.RawMessage `json:"properties"`
+		Geometry   *GeoJSON        `json:"geometry"`
+	} `json:"features"`
+	NumberReturned int `json:"numberReturned"`
+}
+
+// featureProperties covers the handful of property names national WFS
+// endpoints commonly use for a protected area's name/ID/area — enough
+// to populate PA without requiring a per-source mapping config.
+type featureProperties struct {
+	Name     string  `json:"name"`
+	SiteName string  `json:"site_name"`
+	WDPAID   int     `json:"wdpaid"`
+	AreaKm2  float64 `json:"area_km2"`
+}
+
+func decodeFeatureCollection(body []byte) (pas []PA, count int, err error) {
+	var fc geoJSONFeatureCollection
+	if err := json.Unmarshal(body, &fc); err != nil {
+		return nil, 0, err
+	}
+
+	srcCRS := "EPSG:4326"
+	if fc.CRS != nil && fc.CRS.Properties.Name != "" {
+		srcCRS = normalizeCRS(fc.CRS.Properties.Name)
+	}
+
+	for _, f := range fc.Features {
+		var props featureProperties
+		_ = json.Unmarshal(f.Properties, &props)
+
+		geom := f.Geometry
+		if geom != nil && srcCRS != "EPSG:4326" {
+			reprojected, err := reprojectGeoJSON(geom, srcCRS)
+			if err != nil {
+				return nil, 0, err
+			}
+			geom = reprojected
+		}
+
+		name := props.Name
+		if name == "" {
+			name = props.SiteName
+		}
+		pa := PA{
+			WDPAID:  props.WDPAID,
+			Name:    name,
+			AreaKm2: props.AreaKm2,
+			Geometry: &GeoJSONFeature{
+				Type:     "Feature",
+				Geometry: geom,
+			},
+		}
+		pas = append(pas, pa)
+	}
+
+	n := fc.NumberReturned
+	if n == 0 {
+		n = len(fc.Features)
+	}
+	return pas, n, nil
+}
+
+// reprojectGeoJSON reprojects geom's coordinates from srcCRS to
+// EPSG:4326. See ErrUnsupportedCRS for which source CRSes are handled.
+func reprojectGeoJSON(geom *GeoJSON, srcCRS string) (*GeoJSON, error) {
+	if srcCRS != "EPSG:3857" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCRS, srcCRS)
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(geom.Coordinates, &raw); err != nil {
+		return nil, fmt.Errorf("parse geometry coordinates: %w", err)
+	}
+	reprojectCoordsInPlace(raw, webMercatorToWGS84)
+
+	coords, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &GeoJSON{Type: geom.Type, Coordinates: coords}, nil
+}
+
+// reprojectCoordsInPlace walks a GeoJSON coordinates tree (arbitrarily
+// nested []interface{} for Polygon/MultiPolygon/etc.) and applies proj
+// to every [x, y] leaf pair it finds.
+func reprojectCoordsInPlace(node interface{}, proj func(x, y float64) (float64, float64)) {
+	arr, ok := node.([]interface{})
+	if !ok || len(arr) == 0 {
+		return
+	}
+	if isCoordPair(arr) {
+		x, _ := arr[0].(float64)
+		y, _ := arr[1].(float64)
+		lon, lat := proj(x, y)
+		arr[0], arr[1] = lon, lat
+		return
+	}
+	for _, child := range arr {
+		reprojectCoordsInPlace(child, proj)
+	}
+}
+
+func isCoordPair(arr []interface{}) bool {
+	if len(arr) < 2 || len(arr) > 3 {
+		return false
+	}
+	for _, v := range arr {
+		if _, ok := v.(float64); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// webMercatorEarthRadiusM is the sphere radius EPSG:3857 assumes,
+// matching the spherical (not ellipsoidal) Web Mercator definition
+// every major tile server uses.
+const webMercatorEarthRadiusM = 6378137.0
+
+func webMercatorToWGS84(x, y float64) (lon, lat float64) {
+	lon = x / webMercatorEarthRadiusM * 180 / math.Pi
+	lat = (2*math.Atan(math.Exp(y/webMercatorEarthRadiusM)) - math.Pi/2) * 180 / math.Pi
+	return lon, lat
+}
+
+// get performs a plain retrying GET, without Client.doRequest's
+// per-instance rate limiter or disk cache — WFS endpoints are queried
+// rarely (a handful of large paged bulk-import requests), not the
+// steady per-PA trickle Client is tuned for.
+func (c *WFSClient) get(ctx context.Context, endpoint string) ([]byte, error) {
+	maxAttempts := c.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoffDelay(attempt)):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request failed: %w", err)
+			continue
+		}
+
+		body, _, retriable, err := readResponse(resp, nil)
+		resp.Body.Close()
+		if err != nil {
+			if !retriable || attempt == maxAttempts-1 {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		return body, nil
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,63 @@
+package protectedplanet
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeCRS(t *testing.T) {
+	cases := map[string]string{
+		"EPSG:4326":                  "EPSG:4326",
+		"urn:ogc:def:crs:EPSG::4326": "EPSG:4326",
+		"http://www.opengis.net/def/crs/EPSG/0/3857": "EPSG:3857",
+		"urn:ogc:def:crs:OGC:1.3:CRS84":              "urn:ogc:def:crs:OGC:1.3:CRS84",
+	}
+	for raw, want := range cases {
+		if got := normalizeCRS(raw); got != want {
+			t.Errorf("normalizeCRS(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestWebMercatorToWGS84(t *testing.T) {
+	// Origin of Web Mercator is (0, 0) in both systems.
+	lon, lat := webMercatorToWGS84(0, 0)
+	if lon != 0 || lat != 0 {
+		t.Errorf("webMercatorToWGS84(0, 0) = (%f, %f), want (0, 0)", lon, lat)
+	}
+
+	// Roughly Nairobi, reprojected back should land within a fraction
+	// of a degree of its known WGS84 coordinates.
+	lon, lat = webMercatorToWGS84(4098416.3, -142868.2)
+	if math.Abs(lon-36.8167) > 0.01 {
+		t.Errorf("longitude = %f, want ~36.8167", lon)
+	}
+	if math.Abs(lat-(-1.2833)) > 0.01 {
+		t.Errorf("latitude = %f, want ~-1.2833", lat)
+	}
+}
+
+func TestDecodeFeatureCollection(t *testing.T) {
+	body := []byte(`{
+		"type": "FeatureCollection",
+		"numberReturned": 1,
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {"name": "Test Reserve", "wdpaid": 12345, "area_km2": 10.5},
+				"geometry": {"type": "Point", "coordinates": [36.8, -1.3]}
+			}
+		]
+	}`)
+
+	pas, count, err := decodeFeatureCollection(body)
+	if err != nil {
+		t.Fatalf("decodeFeatureCollection failed: %v", err)
+	}
+	if count != 1 || len(pas) != 1 {
+		t.Fatalf("expected 1 feature, got count=%d len=%d", count, len(pas))
+	}
+	if pas[0].Name != "Test Reserve" || pas[0].WDPAID != 12345 {
+		t.Errorf("unexpected PA: %+v", pas[0])
+	}
+}
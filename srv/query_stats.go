@@ -0,0 +1,52 @@
+package srv
+
+import (
+	"net/http"
+	"time"
+
+	"srv.exe.dev/srv/metrics"
+)
+
+// QueryStats collects how many dbgen calls a single request made, how
+// long they took in total, and how many rows they returned, so a handler
+// can hand it back to the caller under ?stats=1 (see statsFromRequest)
+// without reaching for external tracing.
+type QueryStats struct {
+	Queries int   `json:"db_queries"`
+	TimeMs  int64 `json:"db_time_ms"`
+	Rows    int   `json:"rows"`
+}
+
+// statsFromRequest returns a fresh *QueryStats if the caller passed
+// ?stats=1, nil otherwise. Handlers thread the result through
+// recordQuery; a nil stats makes recordQuery's bookkeeping a no-op, so
+// the common case (no ?stats=1) pays for nothing beyond the query itself.
+func statsFromRequest(r *http.Request) *QueryStats {
+	if r.URL.Query().Get("stats") == "1" {
+		return &QueryStats{}
+	}
+	return nil
+}
+
+// recordQuery times a single dbgen call, always recording it against
+// metrics.DBQueryDurationSeconds under name (the same convention
+// computeGridFeatures uses), and additionally against stats if the
+// caller opted in with ?stats=1.
+func recordQuery(stats *QueryStats, name string, rows int, start time.Time) {
+	took := time.Since(start)
+	metrics.DBQueryDurationSeconds.WithLabelValues(name).Observe(took.Seconds())
+	if stats != nil {
+		stats.Queries++
+		stats.TimeMs += took.Milliseconds()
+		stats.Rows += rows
+	}
+}
+
+// withStats adds a "stats" entry to result when stats is non-nil, for
+// handlers whose response is already built as a map[string]interface{}.
+func withStats(result map[string]interface{}, stats *QueryStats) map[string]interface{} {
+	if stats != nil {
+		result["stats"] = stats
+	}
+	return result
+}
@@ -0,0 +1,322 @@
+package srv
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// ReportFormat is the ?format= value for GET /api/parks/{id}/report.
+type ReportFormat string
+
+const (
+	ReportFormatJSON ReportFormat = "json"
+	ReportFormatCSV  ReportFormat = "csv"
+	ReportFormatPDF  ReportFormat = "pdf"
+	ReportFormatZip  ReportFormat = "zip"
+)
+
+// ReportData composes everything a per-park dossier covers, drawn from
+// the same sources HandleAPIPublications, HandleAPIParkInfractionSummary,
+// and HandleAPIParkDataStatus already expose over their own endpoints.
+type ReportData struct {
+	ParkID       string                   `json:"park_id"`
+	GeneratedAt  time.Time                `json:"generated_at"`
+	Status       ParkDataStatus           `json:"status"`
+	Publications []map[string]interface{} `json:"publications"`
+	Infractions  InfractionSummary        `json:"infractions"`
+}
+
+// ReportRenderer turns a ReportData into one downloadable format — the
+// same pluggable-by-format idiom as Exporter (see exporters.go), just
+// over a single composed document instead of a streamed row cursor.
+type ReportRenderer interface {
+	ContentType() string
+	Extension() string
+	Render(data ReportData) ([]byte, error)
+}
+
+// reportRenderers is the ?format= registry; add a new format by
+// registering it here.
+var reportRenderers = map[ReportFormat]ReportRenderer{
+	ReportFormatJSON: jsonReportRenderer{},
+	ReportFormatCSV:  csvReportRenderer{},
+	ReportFormatPDF:  pdfReportRenderer{},
+	ReportFormatZip:  zipReportRenderer{},
+}
+
+type jsonReportRenderer struct{}
+
+func (jsonReportRenderer) ContentType() string { return "application/json" }
+func (jsonReportRenderer) Extension() string   { return "json" }
+func (jsonReportRenderer) Render(data ReportData) ([]byte, error) {
+	return json.MarshalIndent(data, "", "  ")
+}
+
+type csvReportRenderer struct{}
+
+func (csvReportRenderer) ContentType() string { return "text/csv" }
+func (csvReportRenderer) Extension() string   { return "csv" }
+
+// Render flattens ReportData into a single CSV: a handful of
+// field/value summary rows (data source readiness, infraction totals)
+// followed by one row per publication, since the publications list is
+// the only part of a dossier that's naturally tabular.
+func (csvReportRenderer) Render(data ReportData) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+
+	rows := [][]string{
+		{"section", "field", "value"},
+		{"summary", "park_id", data.ParkID},
+		{"summary", "generated_at", data.GeneratedAt.Format(time.RFC3339)},
+		{"summary", "fire_analysis_ready", fmt.Sprintf("%t", data.Status.FireAnalysis != nil && data.Status.FireAnalysis.Ready)},
+		{"summary", "group_infractions_ready", fmt.Sprintf("%t", data.Status.GroupInfractions != nil && data.Status.GroupInfractions.Ready)},
+		{"summary", "publications_ready", fmt.Sprintf("%t", data.Status.Publications != nil && data.Status.Publications.Ready)},
+		{"summary", "ghsl_ready", fmt.Sprintf("%t", data.Status.GHSL != nil && data.Status.GHSL.Ready)},
+		{"summary", "roadless_ready", fmt.Sprintf("%t", data.Status.Roadless != nil && data.Status.Roadless.Ready)},
+		{"infractions", "year", fmt.Sprintf("%d", data.Infractions.Year)},
+		{"infractions", "total_groups", fmt.Sprintf("%d", data.Infractions.TotalGroups)},
+		{"infractions", "response_rate", fmt.Sprintf("%.2f", data.Infractions.ResponseRate)},
+		{"publication_title", "publication_year", "publication_doi"},
+	}
+	for _, r := range rows {
+		if err := cw.Write(r); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range data.Publications {
+		title, _ := p["title"].(string)
+		doi, _ := p["doi"].(string)
+		year := ""
+		if y, ok := p["year"]; ok {
+			year = fmt.Sprintf("%v", y)
+		}
+		if err := cw.Write([]string{title, year, doi}); err != nil {
+			return nil, err
+		}
+	}
+
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+type pdfReportRenderer struct{}
+
+func (pdfReportRenderer) ContentType() string { return "application/pdf" }
+func (pdfReportRenderer) Extension() string   { return "pdf" }
+
+// Render lays the dossier out as a simple single-column document: a
+// title, a data-source readiness checklist, the infractions summary,
+// then a publications list — gofpdf's Cell/Ln/MultiCell helpers, no
+// custom typesetting.
+func (pdfReportRenderer) Render(data ReportData) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, fmt.Sprintf("Park Dossier: %s", data.ParkID))
+	pdf.Ln(12)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Generated: %s", data.GeneratedAt.Format(time.RFC3339)))
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "Data Sources")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 10)
+	for _, entry := range []struct {
+		label string
+		ds    *DataSourceStatus
+	}{
+		{"Fire analysis", data.Status.FireAnalysis},
+		{"Group infractions", data.Status.GroupInfractions},
+		{"Publications", data.Status.Publications},
+		{"GHSL", data.Status.GHSL},
+		{"Roadless", data.Status.Roadless},
+	} {
+		state := "pending"
+		if entry.ds != nil && entry.ds.Ready {
+			state = "ready"
+		}
+		pdf.Cell(0, 6, fmt.Sprintf("%s: %s", entry.label, state))
+		pdf.Ln(6)
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, "Group Infractions")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Year %d: %d groups, %.1f%% stopped inside", data.Infractions.Year, data.Infractions.TotalGroups, data.Infractions.ResponseRate))
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(0, 8, fmt.Sprintf("Publications (%d)", len(data.Publications)))
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 9)
+	for _, p := range data.Publications {
+		title, _ := p["title"].(string)
+		pdf.MultiCell(0, 5, title, "", "", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type zipReportRenderer struct{}
+
+func (zipReportRenderer) ContentType() string { return "application/zip" }
+func (zipReportRenderer) Extension() string   { return "zip" }
+
+// Render bundles the CSV and JSON renderings alongside a short README
+// explaining the dossier's contents, so a caller who wants everything
+// machine- and human-readable in one download doesn't have to hit the
+// endpoint three times.
+func (zipReportRenderer) Render(data ReportData) ([]byte, error) {
+	csvBytes, err := (csvReportRenderer{}).Render(data)
+	if err != nil {
+		return nil, err
+	}
+	jsonBytes, err := (jsonReportRenderer{}).Render(data)
+	if err != nil {
+		return nil, err
+	}
+	readme := fmt.Sprintf(
+		"Park dossier for %s, generated %s.\n\ndata.csv  - summary and publications in tabular form\ndata.json - the full composed report\n",
+		data.ParkID, data.GeneratedAt.Format(time.RFC3339))
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{
+		{"README.txt", []byte(readme)},
+		{"data.csv", csvBytes},
+		{"data.json", jsonBytes},
+	} {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildReportData composes a dossier's contents from the same queries
+// HandleAPIPublications and computeInfractionSummary/
+// computeParkDataStatus already run, so the report endpoint can't drift
+// from what the live endpoints show for the same park.
+func (s *Server) buildReportData(ctx context.Context, parkID string) (ReportData, error) {
+	status := s.computeParkDataStatus(ctx, parkID, nil)
+	infractions := s.computeInfractionSummary(ctx, parkID, "2023", nil)
+
+	q := dbgen.New(s.DB)
+	pubs, err := q.GetPublicationsByPA(ctx, parkID)
+	if err != nil {
+		return ReportData{}, fmt.Errorf("load publications: %w", err)
+	}
+	pubItems := make([]map[string]interface{}, 0, len(pubs))
+	for _, p := range pubs {
+		item := map[string]interface{}{"id": p.ID, "title": p.Title}
+		if p.Year != nil {
+			item["year"] = *p.Year
+		}
+		if p.Doi != nil {
+			item["doi"] = *p.Doi
+		}
+		if p.CitedByCount != nil {
+			item["cited_by_count"] = *p.CitedByCount
+		}
+		if p.Source != nil {
+			item["source"] = *p.Source
+		}
+		pubItems = append(pubItems, item)
+	}
+
+	return ReportData{
+		ParkID:       parkID,
+		GeneratedAt:  time.Now(),
+		Status:       status,
+		Publications: pubItems,
+		Infractions:  infractions,
+	}, nil
+}
+
+// reportDataHash derives a cache key from everything in data except
+// GeneratedAt, so regenerating a report for unchanged underlying data
+// hits the reports table instead of re-rendering (particularly the PDF
+// path, by far the most expensive renderer).
+func reportDataHash(data ReportData) string {
+	cacheable := data
+	cacheable.GeneratedAt = time.Time{}
+	b, _ := json.Marshal(cacheable)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// BuildReport renders (or returns a cached rendering of) parkID's
+// dossier in format, caching by (park_id, format, data_hash) in the
+// reports table. It returns the rendered bytes and the data hash, which
+// HandleAPIParkReport uses as the response ETag.
+func (s *Server) BuildReport(ctx context.Context, parkID string, format ReportFormat) ([]byte, string, error) {
+	renderer, ok := reportRenderers[format]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported report format %q", format)
+	}
+
+	data, err := s.buildReportData(ctx, parkID)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := reportDataHash(data)
+
+	var cached []byte
+	err = s.DB.QueryRowContext(ctx, `
+		SELECT content FROM reports WHERE park_id = ? AND format = ? AND data_hash = ?
+	`, parkID, string(format), hash).Scan(&cached)
+	if err == nil {
+		return cached, hash, nil
+	}
+	if err != sql.ErrNoRows {
+		slog.Warn("failed to read report cache", "park_id", parkID, "format", format, "error", err)
+	}
+
+	rendered, err := renderer.Render(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("render %s report: %w", format, err)
+	}
+
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO reports (park_id, format, data_hash, content, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (park_id, format, data_hash) DO NOTHING
+	`, parkID, string(format), hash, rendered, time.Now()); err != nil {
+		slog.Warn("failed to cache report", "park_id", parkID, "format", format, "error", err)
+	}
+
+	return rendered, hash, nil
+}
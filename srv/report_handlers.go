@@ -0,0 +1,76 @@
+package srv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HandleAPIParkReport composes and streams a per-park dossier bundling
+// publications, group infractions, and data-source status into one
+// downloadable file.
+// GET /api/parks/{id}/report?format=json|csv|pdf|zip (default json)
+func (s *Server) HandleAPIParkReport(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	if parkID == "" {
+		http.Error(w, "park ID required", http.StatusBadRequest)
+		return
+	}
+
+	format := ReportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = ReportFormatJSON
+	}
+	renderer, ok := reportRenderers[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported format %q; supported: json, csv, pdf, zip", format), http.StatusBadRequest)
+		return
+	}
+
+	content, hash, err := s.BuildReport(r.Context(), parkID, format)
+	if err != nil {
+		http.Error(w, "failed to build report", http.StatusInternalServerError)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s"`, hash)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-report.%s", parkID, renderer.Extension())
+	w.Header().Set("Content-Type", renderer.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Write(content)
+}
+
+// HandleAPIParkReportManifest reports which dossier sections have data
+// ready, driven by ParkDataStatus.Ready, and which output formats are
+// available, so a client can build a report picker before calling
+// HandleAPIParkReport.
+// GET /api/parks/{id}/report/manifest
+func (s *Server) HandleAPIParkReportManifest(w http.ResponseWriter, r *http.Request) {
+	parkID := r.PathValue("id")
+	if parkID == "" {
+		http.Error(w, "park ID required", http.StatusBadRequest)
+		return
+	}
+
+	status := s.computeParkDataStatus(r.Context(), parkID, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"park_id": parkID,
+		"formats": []string{string(ReportFormatJSON), string(ReportFormatCSV), string(ReportFormatPDF), string(ReportFormatZip)},
+		"sections": map[string]bool{
+			"fire_analysis":     status.FireAnalysis != nil && status.FireAnalysis.Ready,
+			"group_infractions": status.GroupInfractions != nil && status.GroupInfractions.Ready,
+			"publications":      status.Publications != nil && status.Publications.Ready,
+			"ghsl":              status.GHSL != nil && status.GHSL.Ready,
+			"roadless":          status.Roadless != nil && status.Roadless.Ready,
+		},
+	})
+}
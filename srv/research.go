@@ -13,39 +13,87 @@ import (
 	"srv.exe.dev/db/dbgen"
 )
 
-// OpenAlexWork represents a work from the OpenAlex API.
-type OpenAlexWork struct {
-	ID           string `json:"id"`
-	Title        string `json:"title"`
-	PublicationYear int `json:"publication_year"`
-	DOI          string `json:"doi"`
-	CitedByCount int    `json:"cited_by_count"`
-	Authorships  []struct {
-		Author struct {
-			DisplayName string `json:"display_name"`
-		} `json:"author"`
-	} `json:"authorships"`
-	PrimaryLocation struct {
-		LandingPageURL string `json:"landing_page_url"`
-	} `json:"primary_location"`
-	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+// Work is a single candidate publication, normalized enough that
+// fetchPublicationsForPA can dedup and merge results across sources
+// regardless of which bibliographic index produced them.
+type Work struct {
+	Source       string // Name() of the PublicationSource that returned this work
+	SourceID     string // the source's own ID for the work (OpenAlex work ID, Crossref/Semantic Scholar DOI, etc.)
+	Title        string
+	Year         int
+	DOI          string
+	Authors      []string
+	URL          string
+	Abstract     string
+	CitedByCount int
 }
 
-// OpenAlexResponse is the API response wrapper.
-type OpenAlexResponse struct {
-	Results []OpenAlexWork `json:"results"`
-	Meta    struct {
-		Count int `json:"count"`
-	} `json:"meta"`
+// PublicationSource is one bibliographic index StartResearchWorker can
+// query for papers mentioning a protected area. Search should return
+// candidate works for query without itself filtering by whether the
+// park name actually appears in the text — fetchPublicationsForPA
+// applies that filter uniformly across every source so none of them
+// need to agree on what counts as a match.
+type PublicationSource interface {
+	// Name identifies the source for the publications.source column
+	// and for config.Config.DisabledResearchSources.
+	Name() string
+	Search(ctx context.Context, client *HTTPClient, query, country string) ([]Work, error)
+}
+
+// DefaultResearchSources is every PublicationSource StartResearchWorker
+// knows about, before config.Config.DisabledResearchSources filters it
+// down. Order doesn't affect results (fetchPublicationsForPA dedupes
+// across all of them), only which source "wins" a dedup on a tie.
+func DefaultResearchSources() []PublicationSource {
+	return []PublicationSource{
+		openAlexSource{},
+		crossrefSource{},
+		semanticScholarSource{},
+	}
+}
+
+// filterResearchSources drops any source whose Name() appears in
+// disabled, so config.Config.DisabledResearchSources can turn off an
+// index an operator's network can't reach.
+func filterResearchSources(sources []PublicationSource, disabled []string) []PublicationSource {
+	if len(disabled) == 0 {
+		return sources
+	}
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		skip[strings.TrimSpace(name)] = true
+	}
+
+	kept := make([]PublicationSource, 0, len(sources))
+	for _, src := range sources {
+		if !skip[src.Name()] {
+			kept = append(kept, src)
+		}
+	}
+	return kept
 }
 
-// StartResearchWorker starts the background job for fetching publications.
+// researchSeedInterval/researchSeedBatch control how often
+// StartResearchWorker looks for new or stale PAs to enqueue into
+// s.ResearchJobs; actual fetch pacing comes from ResearchJobRunner's
+// worker pool and the ResearchHTTP rate limiter, not this interval, so
+// it can run far more often than the old 24h/3-PA ticker did.
+const (
+	researchSeedInterval = 10 * time.Minute
+	researchSeedBatch    = 3
+)
+
+// StartResearchWorker periodically seeds s.ResearchJobs with PAs that
+// haven't been synced yet (or are stale); ResearchJobRunner's own
+// worker pool and retry/backoff logic do the actual fetching (see
+// research_jobs.go), which replaces the old fixed-batch runResearchSync
+// ticker with a persistent, retrying, independently-visible job queue.
 func (s *Server) StartResearchWorker(ctx context.Context) {
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := time.NewTicker(researchSeedInterval)
 	defer ticker.Stop()
 
-	// Run immediately on startup, then every 24 hours
-	s.runResearchSync(ctx)
+	s.ResearchJobs.SeedDueSync(ctx, researchSeedBatch)
 
 	for {
 		select {
@@ -53,114 +101,83 @@ func (s *Server) StartResearchWorker(ctx context.Context) {
 			slog.Info("research worker shutting down")
 			return
 		case <-ticker.C:
-			s.runResearchSync(ctx)
+			s.ResearchJobs.SeedDueSync(ctx, researchSeedBatch)
 		}
 	}
 }
 
-// runResearchSync processes a batch of PAs.
-func (s *Server) runResearchSync(ctx context.Context) {
-	if s.AreaStore == nil {
-		return
-	}
-
-	q := dbgen.New(s.DB)
-
-	// Get PAs that haven't been synced yet
-	syncedPAs, _ := q.GetAllSyncedPAIDs(ctx)
-	syncedSet := make(map[string]bool)
-	for _, id := range syncedPAs {
-		syncedSet[id] = true
-	}
-
-	// paInfo stores ID, name, and country for sync
-	type paInfo struct {
-		ID      string
-		Name    string
-		Country string
+// isPASynced reports whether paID already has a publications_sync row,
+// i.e. fetchPublicationsForPA has run for it at least once.
+func (s *Server) isPASynced(ctx context.Context, paID string) bool {
+	synced, err := dbgen.New(s.DB).GetAllSyncedPAIDs(ctx)
+	if err != nil {
+		return false
 	}
-
-	// Find unsynced PAs first, then stale ones
-	var toSync []paInfo
-	for _, area := range s.AreaStore.Areas {
-		paID := area.WDPAID
-		if paID == "" {
-			paID = area.ID
-		}
-		if !syncedSet[paID] {
-			toSync = append(toSync, paInfo{ID: paID, Name: area.Name, Country: area.Country})
-			if len(toSync) >= 3 { // Process 3 new PAs per run
-				break
-			}
+	for _, id := range synced {
+		if id == paID {
+			return true
 		}
 	}
+	return false
+}
 
-	// If no new PAs, check for stale ones
-	if len(toSync) == 0 {
-		stale, _ := q.GetPAsNeedingPublicationSync(ctx, 3)
-		for _, id := range stale {
-			// Find name and country for this PA
-			for _, area := range s.AreaStore.Areas {
-				paID := area.WDPAID
-				if paID == "" {
-					paID = area.ID
-				}
-				if paID == id {
-					toSync = append(toSync, paInfo{ID: paID, Name: area.Name, Country: area.Country})
-					break
-				}
-			}
-		}
-	}
-
-	for _, pa := range toSync {
-		count, err := s.fetchPublicationsForPA(ctx, pa.ID, pa.Name, pa.Country)
-		if err != nil {
-			slog.Error("failed to fetch publications", "pa_id", pa.ID, "name", pa.Name, "error", err)
-			continue
-		}
-		slog.Info("fetched publications", "pa_id", pa.ID, "name", pa.Name, "count", count)
-
-		// Rate limit: wait between requests
-		time.Sleep(2 * time.Second)
+// staleSyncedPAIDs returns up to limit PA IDs whose publications_sync
+// row is old enough to refetch (see GetPAsNeedingPublicationSync).
+func (s *Server) staleSyncedPAIDs(ctx context.Context, limit int) []string {
+	stale, err := dbgen.New(s.DB).GetPAsNeedingPublicationSync(ctx, limit)
+	if err != nil {
+		slog.Warn("failed to list PAs needing publication sync", "error", err)
+		return nil
 	}
+	return stale
 }
 
-// fetchPublicationsForPA fetches research papers for a protected area.
+// fetchPublicationsForPA fans out to every source in s.ResearchSources,
+// merges the results (deduping by DOI, falling back to normalized
+// title for works without one, and keeping the highest cited_by_count
+// seen for a duplicate), filters to works that actually mention the
+// park by name, and inserts the survivors.
 func (s *Server) fetchPublicationsForPA(ctx context.Context, paID, name, country string) (int, error) {
-	// Use quoted name for exact phrase matching, combined with conservation terms
-	// This ensures we get papers that actually mention the park name
-	quotedName := `"` + name + `"`
-	searchQuery := url.QueryEscape(quotedName)
-	apiURL := fmt.Sprintf(
-		"https://api.openalex.org/works?search=%s&filter=type:article&per_page=50&sort=cited_by_count:desc",
-		searchQuery,
-	)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return 0, err
+	sources := s.ResearchSources
+	if sources == nil {
+		sources = DefaultResearchSources()
 	}
-	req.Header.Set("User-Agent", "5mp-conservation-app/1.0 (mailto:admin@example.org)")
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
+	// Use quoted name for exact phrase matching so sources don't return
+	// every paper that happens to share a word with the park's name.
+	query := `"` + name + `"`
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("OpenAlex API returned status %d", resp.StatusCode)
+	var resultCount int
+	var merged []Work
+	seen := make(map[string]int) // dedup key -> index into merged
+	client := s.ResearchHTTP
+	if client == nil {
+		client = NewHTTPClient(HTTPClientConfig{})
 	}
-
-	var data OpenAlexResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return 0, err
+	for _, src := range sources {
+		works, err := src.Search(ctx, client, query, country)
+		if err != nil {
+			slog.Error("publication source search failed", "source", src.Name(), "pa_id", paID, "error", err)
+			continue
+		}
+		resultCount += len(works)
+		for _, w := range works {
+			w.Source = src.Name()
+			key := dedupKey(w)
+			if i, ok := seen[key]; ok {
+				if w.CitedByCount > merged[i].CitedByCount {
+					merged[i].CitedByCount = w.CitedByCount
+				}
+				continue
+			}
+			seen[key] = len(merged)
+			merged = append(merged, w)
+		}
 	}
 
 	q := dbgen.New(s.DB)
 	count := 0
-	
+
 	// Normalize park name for matching (lowercase, no extra spaces)
 	nameNormalized := strings.ToLower(strings.TrimSpace(name))
 	// Also try without common suffixes for matching
@@ -168,53 +185,35 @@ func (s *Server) fetchPublicationsForPA(ctx context.Context, paID, name, country
 	nameShort = strings.TrimSuffix(nameShort, " game reserve")
 	nameShort = strings.TrimSuffix(nameShort, " reserve")
 
-	for _, work := range data.Results {
-		// Reconstruct abstract from inverted index
-		abstract := reconstructAbstract(work.AbstractInvertedIndex)
-		
-		// Filter: park name must appear in title or abstract
-		titleLower := strings.ToLower(work.Title)
-		abstractLower := strings.ToLower(abstract)
-		
+	for _, w := range merged {
+		titleLower := strings.ToLower(w.Title)
+		abstractLower := strings.ToLower(w.Abstract)
+
 		nameInTitle := strings.Contains(titleLower, nameNormalized) || strings.Contains(titleLower, nameShort)
 		nameInAbstract := strings.Contains(abstractLower, nameNormalized) || strings.Contains(abstractLower, nameShort)
-		
 		if !nameInTitle && !nameInAbstract {
 			// Skip papers that don't mention the park name
 			continue
 		}
-		
-		// Extract authors
-		authors := make([]string, 0, len(work.Authorships))
-		for _, a := range work.Authorships {
-			if a.Author.DisplayName != "" {
-				authors = append(authors, a.Author.DisplayName)
-			}
-		}
-		authorsJSON, _ := json.Marshal(authors)
 
-		// Get URL
-		workURL := work.PrimaryLocation.LandingPageURL
-		if workURL == "" && work.DOI != "" {
-			workURL = work.DOI
-		}
+		authorsJSON, _ := json.Marshal(w.Authors)
 
-		// Extract OpenAlex ID (just the ID part)
-		openalexID := work.ID
-		if idx := strings.LastIndex(work.ID, "/"); idx >= 0 {
-			openalexID = work.ID[idx+1:]
+		workURL := w.URL
+		if workURL == "" && w.DOI != "" {
+			workURL = w.DOI
 		}
 
 		err := q.InsertPublication(ctx, dbgen.InsertPublicationParams{
 			PaID:         paID,
-			OpenalexID:   openalexID,
-			Title:        work.Title,
+			Source:       w.Source,
+			SourceID:     w.SourceID,
+			Title:        w.Title,
 			Authors:      ptr(string(authorsJSON)),
-			Year:         ptr(int64(work.PublicationYear)),
-			Doi:          ptrIfNotEmpty(work.DOI),
+			Year:         ptr(int64(w.Year)),
+			Doi:          ptrIfNotEmpty(w.DOI),
 			Url:          ptrIfNotEmpty(workURL),
-			Abstract:     ptrIfNotEmpty(abstract),
-			CitedByCount: ptr(int64(work.CitedByCount)),
+			Abstract:     ptrIfNotEmpty(w.Abstract),
+			CitedByCount: ptr(int64(w.CitedByCount)),
 		})
 		if err == nil {
 			count++
@@ -224,12 +223,126 @@ func (s *Server) fetchPublicationsForPA(ctx context.Context, paID, name, country
 	// Update sync status
 	q.UpsertPAPublicationSync(ctx, dbgen.UpsertPAPublicationSyncParams{
 		PaID:        paID,
-		ResultCount: int64(len(data.Results)),
+		ResultCount: int64(resultCount),
 	})
 
+	if s.ParkStatusHub != nil {
+		s.ParkStatusHub.Publish(paID, s.computeParkDataStatus(ctx, paID, nil))
+	}
+
 	return count, nil
 }
 
+// dedupKey identifies a Work across sources: a normalized DOI when one
+// is present (the same paper's DOI doesn't vary by index), otherwise a
+// normalized title (for sources/records that omit a DOI).
+func dedupKey(w Work) string {
+	if w.DOI != "" {
+		return "doi:" + normalizeDOI(w.DOI)
+	}
+	return "title:" + normalizeTitle(w.Title)
+}
+
+func normalizeDOI(doi string) string {
+	doi = strings.ToLower(strings.TrimSpace(doi))
+	doi = strings.TrimPrefix(doi, "https://doi.org/")
+	doi = strings.TrimPrefix(doi, "http://doi.org/")
+	doi = strings.TrimPrefix(doi, "doi:")
+	return doi
+}
+
+func normalizeTitle(title string) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// openAlexSource queries the OpenAlex works API, the original (and
+// still default) publication source.
+type openAlexSource struct{}
+
+func (openAlexSource) Name() string { return "openalex" }
+
+// openAlexWork represents a work from the OpenAlex API.
+type openAlexWork struct {
+	ID              string `json:"id"`
+	Title           string `json:"title"`
+	PublicationYear int    `json:"publication_year"`
+	DOI             string `json:"doi"`
+	CitedByCount    int    `json:"cited_by_count"`
+	Authorships     []struct {
+		Author struct {
+			DisplayName string `json:"display_name"`
+		} `json:"author"`
+	} `json:"authorships"`
+	PrimaryLocation struct {
+		LandingPageURL string `json:"landing_page_url"`
+	} `json:"primary_location"`
+	AbstractInvertedIndex map[string][]int `json:"abstract_inverted_index"`
+}
+
+// openAlexResponse is the API response wrapper.
+type openAlexResponse struct {
+	Results []openAlexWork `json:"results"`
+	Meta    struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+}
+
+func (openAlexSource) Search(ctx context.Context, client *HTTPClient, query, country string) ([]Work, error) {
+	apiURL := fmt.Sprintf(
+		"https://api.openalex.org/works?search=%s&filter=type:article&per_page=50&sort=cited_by_count:desc",
+		url.QueryEscape(query),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "5mp-conservation-app/1.0 (mailto:admin@example.org)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAlex API returned status %d", resp.StatusCode)
+	}
+
+	var data openAlexResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	works := make([]Work, 0, len(data.Results))
+	for _, w := range data.Results {
+		authors := make([]string, 0, len(w.Authorships))
+		for _, a := range w.Authorships {
+			if a.Author.DisplayName != "" {
+				authors = append(authors, a.Author.DisplayName)
+			}
+		}
+
+		openalexID := w.ID
+		if idx := strings.LastIndex(w.ID, "/"); idx >= 0 {
+			openalexID = w.ID[idx+1:]
+		}
+
+		works = append(works, Work{
+			SourceID:     openalexID,
+			Title:        w.Title,
+			Year:         w.PublicationYear,
+			DOI:          w.DOI,
+			Authors:      authors,
+			URL:          w.PrimaryLocation.LandingPageURL,
+			Abstract:     reconstructAbstract(w.AbstractInvertedIndex),
+			CitedByCount: w.CitedByCount,
+		})
+	}
+	return works, nil
+}
+
 // reconstructAbstract rebuilds abstract from OpenAlex inverted index format.
 func reconstructAbstract(inverted map[string][]int) string {
 	if len(inverted) == 0 {
@@ -262,6 +375,166 @@ func reconstructAbstract(inverted map[string][]int) string {
 	return abstract
 }
 
+// crossrefSource queries Crossref's /works endpoint, which indexes
+// member-deposited metadata (mostly journal articles) and often covers
+// papers OpenAlex hasn't ingested yet.
+type crossrefSource struct{}
+
+func (crossrefSource) Name() string { return "crossref" }
+
+type crossrefResponse struct {
+	Message struct {
+		Items []struct {
+			DOI    string   `json:"DOI"`
+			Title  []string `json:"title"`
+			URL    string   `json:"URL"`
+			Author []struct {
+				Given  string `json:"given"`
+				Family string `json:"family"`
+			} `json:"author"`
+			IsReferencedByCount int    `json:"is-referenced-by-count"`
+			Abstract            string `json:"abstract"`
+			Published           struct {
+				DateParts [][]int `json:"date-parts"`
+			} `json:"published"`
+		} `json:"items"`
+	} `json:"message"`
+}
+
+func (crossrefSource) Search(ctx context.Context, client *HTTPClient, query, country string) ([]Work, error) {
+	apiURL := fmt.Sprintf(
+		"https://api.crossref.org/works?query.bibliographic=%s&rows=50&select=DOI,title,URL,author,is-referenced-by-count,abstract,published",
+		url.QueryEscape(query),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "5mp-conservation-app/1.0 (mailto:admin@example.org)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Crossref API returned status %d", resp.StatusCode)
+	}
+
+	var data crossrefResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	works := make([]Work, 0, len(data.Message.Items))
+	for _, item := range data.Message.Items {
+		title := ""
+		if len(item.Title) > 0 {
+			title = item.Title[0]
+		}
+
+		year := 0
+		if len(item.Published.DateParts) > 0 && len(item.Published.DateParts[0]) > 0 {
+			year = item.Published.DateParts[0][0]
+		}
+
+		authors := make([]string, 0, len(item.Author))
+		for _, a := range item.Author {
+			name := strings.TrimSpace(a.Given + " " + a.Family)
+			if name != "" {
+				authors = append(authors, name)
+			}
+		}
+
+		works = append(works, Work{
+			SourceID:     item.DOI,
+			Title:        title,
+			Year:         year,
+			DOI:          item.DOI,
+			Authors:      authors,
+			URL:          item.URL,
+			Abstract:     item.Abstract,
+			CitedByCount: item.IsReferencedByCount,
+		})
+	}
+	return works, nil
+}
+
+// semanticScholarSource queries the Semantic Scholar Graph API, which
+// layers its own citation graph (and abstracts for many papers
+// Crossref only has metadata for) over a similar corpus.
+type semanticScholarSource struct{}
+
+func (semanticScholarSource) Name() string { return "semanticscholar" }
+
+type semanticScholarResponse struct {
+	Data []struct {
+		PaperID       string `json:"paperId"`
+		Title         string `json:"title"`
+		Year          int    `json:"year"`
+		Abstract      string `json:"abstract"`
+		URL           string `json:"url"`
+		CitationCount int    `json:"citationCount"`
+		ExternalIDs   struct {
+			DOI string `json:"DOI"`
+		} `json:"externalIds"`
+		Authors []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+	} `json:"data"`
+}
+
+func (semanticScholarSource) Search(ctx context.Context, client *HTTPClient, query, country string) ([]Work, error) {
+	apiURL := fmt.Sprintf(
+		"https://api.semanticscholar.org/graph/v1/paper/search?query=%s&limit=50&fields=title,year,externalIds,citationCount,authors,abstract,url",
+		url.QueryEscape(query),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Semantic Scholar API returned status %d", resp.StatusCode)
+	}
+
+	var data semanticScholarResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	works := make([]Work, 0, len(data.Data))
+	for _, p := range data.Data {
+		authors := make([]string, 0, len(p.Authors))
+		for _, a := range p.Authors {
+			if a.Name != "" {
+				authors = append(authors, a.Name)
+			}
+		}
+
+		works = append(works, Work{
+			SourceID:     p.PaperID,
+			Title:        p.Title,
+			Year:         p.Year,
+			DOI:          p.ExternalIDs.DOI,
+			Authors:      authors,
+			URL:          p.URL,
+			Abstract:     p.Abstract,
+			CitedByCount: p.CitationCount,
+		})
+	}
+	return works, nil
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
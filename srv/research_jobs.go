@@ -0,0 +1,276 @@
+package srv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// ResearchJobState is a research sync job's lifecycle stage, persisted
+// in research_jobs.state.
+type ResearchJobState string
+
+const (
+	ResearchJobQueued    ResearchJobState = "queued"
+	ResearchJobRunning   ResearchJobState = "running"
+	ResearchJobSucceeded ResearchJobState = "succeeded"
+	ResearchJobFailed    ResearchJobState = "failed"
+)
+
+// ResearchJob is one row of research_jobs, the shape GET
+// /api/research/jobs and GET /api/research/jobs/{id} report.
+type ResearchJob struct {
+	JobID       string           `json:"job_id"`
+	PAID        string           `json:"pa_id"`
+	Source      string           `json:"source,omitempty"`
+	State       ResearchJobState `json:"state"`
+	Attempts    int              `json:"attempts"`
+	LastError   string           `json:"last_error,omitempty"`
+	ScheduledAt time.Time        `json:"scheduled_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
+
+// researchJobWorkers caps how many fetchPublicationsForPA calls run at
+// once, the same rationale as parkJobRunnerWorkers/jobRunnerWorkers.
+const researchJobWorkers = 3
+
+// researchJobBaseBackoff/researchJobMaxBackoff bound a failed job's
+// requeue delay: the first retry waits researchJobBaseBackoff, doubling
+// on every subsequent failure, capped at researchJobMaxBackoff so a
+// persistently-failing PA (upstream outage, say) is retried at most
+// once a day rather than being abandoned.
+const (
+	researchJobBaseBackoff = 5 * time.Minute
+	researchJobMaxBackoff  = 24 * time.Hour
+)
+
+// ResearchJobRunner is a persistent, retrying job queue for
+// fetchPublicationsForPA, replacing the old runResearchSync fixed
+// ticker (see research.go) with a research_jobs table, a worker pool
+// draining it, on-demand enqueue via HandleAPIResearchSync, and
+// HandleAPIResearchJobs/HandleAPIResearchJob for operators to see why a
+// given park has no publications yet (rate-limited? filtered out?
+// upstream 5xx?) instead of guessing from logs.
+type ResearchJobRunner struct {
+	db *sql.DB
+	s  *Server
+
+	sem chan struct{}
+}
+
+// NewResearchJobRunner creates a runner backed by db for persistence,
+// calling back into s.fetchPublicationsForPA to do the actual work.
+func NewResearchJobRunner(db *sql.DB, s *Server) *ResearchJobRunner {
+	return &ResearchJobRunner{
+		db:  db,
+		s:   s,
+		sem: make(chan struct{}, researchJobWorkers),
+	}
+}
+
+// Enqueue inserts a queued job for paID scheduled to run immediately,
+// or returns the ID of an already-queued/running job for paID instead
+// of starting a duplicate (the same dedup idiom as ParkJobRunner).
+func (r *ResearchJobRunner) Enqueue(ctx context.Context, paID string) (jobID string, deduped bool, err error) {
+	if existing, ok, err := r.findActive(ctx, paID); err != nil {
+		return "", false, err
+	} else if ok {
+		return existing, true, nil
+	}
+
+	jobID = fmt.Sprintf("rjob-%s-%d", paID, time.Now().UnixNano())
+	now := time.Now()
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO research_jobs (job_id, pa_id, source, state, attempts, scheduled_at)
+		VALUES (?, ?, '', ?, 0, ?)
+	`, jobID, paID, string(ResearchJobQueued), now); err != nil {
+		return "", false, fmt.Errorf("enqueue research job: %w", err)
+	}
+
+	go r.run(jobID, paID)
+
+	return jobID, false, nil
+}
+
+func (r *ResearchJobRunner) findActive(ctx context.Context, paID string) (string, bool, error) {
+	var jobID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT job_id FROM research_jobs
+		WHERE pa_id = ? AND state IN ('queued', 'running')
+		ORDER BY scheduled_at DESC LIMIT 1
+	`, paID).Scan(&jobID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return jobID, true, nil
+}
+
+// run executes jobID for paID, retrying with backoff on failure. Each
+// attempt (including retries) goes through r.sem, so a backlog of
+// retrying jobs can't exceed researchJobWorkers concurrent
+// fetchPublicationsForPA calls.
+func (r *ResearchJobRunner) run(jobID, paID string) {
+	r.sem <- struct{}{}
+	defer func() { <-r.sem }()
+
+	ctx := context.Background()
+	attempts := r.setRunning(ctx, jobID)
+
+	name, country := paID, ""
+	if r.s.AreaStore != nil {
+		for _, area := range r.s.AreaStore.Areas {
+			if area.WDPAID == paID || area.ID == paID {
+				name, country = area.Name, area.Country
+				break
+			}
+		}
+	}
+
+	count, err := r.s.fetchPublicationsForPA(ctx, paID, name, country)
+	if err != nil {
+		r.scheduleRetry(ctx, jobID, paID, attempts, err)
+		return
+	}
+
+	r.setSucceeded(ctx, jobID)
+	slog.Info("research job succeeded", "job_id", jobID, "pa_id", paID, "count", count)
+}
+
+func (r *ResearchJobRunner) setRunning(ctx context.Context, jobID string) int {
+	var attempts int
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE research_jobs SET state = ?, attempts = attempts + 1 WHERE job_id = ?
+	`, string(ResearchJobRunning), jobID); err != nil {
+		slog.Warn("failed to mark research job running", "job_id", jobID, "error", err)
+	}
+	_ = r.db.QueryRowContext(ctx, `SELECT attempts FROM research_jobs WHERE job_id = ?`, jobID).Scan(&attempts)
+	return attempts
+}
+
+func (r *ResearchJobRunner) setSucceeded(ctx context.Context, jobID string) {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE research_jobs SET state = ?, last_error = '', completed_at = ? WHERE job_id = ?
+	`, string(ResearchJobSucceeded), time.Now(), jobID); err != nil {
+		slog.Warn("failed to mark research job succeeded", "job_id", jobID, "error", err)
+	}
+}
+
+// scheduleRetry marks jobID failed and, after an exponential backoff
+// (see researchJobBaseBackoff/researchJobMaxBackoff), requeues it under
+// the same job_id so GET /api/research/jobs/{id} shows a single growing
+// attempts count rather than a new row per retry.
+func (r *ResearchJobRunner) scheduleRetry(ctx context.Context, jobID, paID string, attempts int, cause error) {
+	backoff := time.Duration(math.Pow(2, float64(attempts-1))) * researchJobBaseBackoff
+	if backoff > researchJobMaxBackoff || backoff <= 0 {
+		backoff = researchJobMaxBackoff
+	}
+	nextRun := time.Now().Add(backoff)
+
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE research_jobs SET state = ?, last_error = ?, scheduled_at = ? WHERE job_id = ?
+	`, string(ResearchJobFailed), cause.Error(), nextRun, jobID); err != nil {
+		slog.Warn("failed to mark research job failed", "job_id", jobID, "error", err)
+	}
+	slog.Error("research job failed, will retry", "job_id", jobID, "pa_id", paID, "attempts", attempts, "retry_in", backoff, "error", cause)
+
+	time.AfterFunc(backoff, func() { r.run(jobID, paID) })
+}
+
+// Get returns one research job by ID, for GET /api/research/jobs/{id}.
+func (r *ResearchJobRunner) Get(ctx context.Context, jobID string) (ResearchJob, bool, error) {
+	return scanResearchJob(r.db.QueryRowContext(ctx, `
+		SELECT job_id, pa_id, source, state, attempts, last_error, scheduled_at, completed_at
+		FROM research_jobs WHERE job_id = ?
+	`, jobID))
+}
+
+// List returns the most recently scheduled research jobs (newest
+// first), for GET /api/research/jobs.
+func (r *ResearchJobRunner) List(ctx context.Context, limit int) ([]ResearchJob, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT job_id, pa_id, source, state, attempts, last_error, scheduled_at, completed_at
+		FROM research_jobs ORDER BY scheduled_at DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list research jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobsList []ResearchJob
+	for rows.Next() {
+		var rj ResearchJob
+		var lastError sql.NullString
+		var completedAt sql.NullTime
+		if err := rows.Scan(&rj.JobID, &rj.PAID, &rj.Source, &rj.State, &rj.Attempts, &lastError, &rj.ScheduledAt, &completedAt); err != nil {
+			return nil, fmt.Errorf("scan research job: %w", err)
+		}
+		if lastError.Valid {
+			rj.LastError = lastError.String
+		}
+		if completedAt.Valid {
+			rj.CompletedAt = &completedAt.Time
+		}
+		jobsList = append(jobsList, rj)
+	}
+	return jobsList, rows.Err()
+}
+
+func scanResearchJob(row *sql.Row) (ResearchJob, bool, error) {
+	var rj ResearchJob
+	var lastError sql.NullString
+	var completedAt sql.NullTime
+	err := row.Scan(&rj.JobID, &rj.PAID, &rj.Source, &rj.State, &rj.Attempts, &lastError, &rj.ScheduledAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return ResearchJob{}, false, nil
+	}
+	if err != nil {
+		return ResearchJob{}, false, err
+	}
+	if lastError.Valid {
+		rj.LastError = lastError.String
+	}
+	if completedAt.Valid {
+		rj.CompletedAt = &completedAt.Time
+	}
+	return rj, true, nil
+}
+
+// SeedDueSync enqueues up to limit unsynced or stale PAs from
+// s.AreaStore, the same candidate selection the old runResearchSync
+// ticker used, so StartResearchWorker keeps discovering new/stale PAs
+// to sync without an operator having to enqueue each one by hand.
+func (r *ResearchJobRunner) SeedDueSync(ctx context.Context, limit int) {
+	if r.s.AreaStore == nil {
+		return
+	}
+
+	var toSync []string
+	for _, area := range r.s.AreaStore.Areas {
+		paID := area.WDPAID
+		if paID == "" {
+			paID = area.ID
+		}
+		if !r.s.isPASynced(ctx, paID) {
+			toSync = append(toSync, paID)
+			if len(toSync) >= limit {
+				break
+			}
+		}
+	}
+
+	if len(toSync) == 0 {
+		toSync = r.s.staleSyncedPAIDs(ctx, limit)
+	}
+
+	for _, paID := range toSync {
+		if _, _, err := r.Enqueue(ctx, paID); err != nil {
+			slog.Warn("failed to enqueue research job", "pa_id", paID, "error", err)
+		}
+	}
+}
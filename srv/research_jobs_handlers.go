@@ -0,0 +1,70 @@
+package srv
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// researchJobsDefaultLimit bounds GET /api/research/jobs when ?limit=
+// isn't given, the same "reasonable default list size" idiom as
+// publicationsDefaultLimit.
+const researchJobsDefaultLimit = 50
+
+// HandleAPIResearchSync enqueues (or returns the ID of an existing
+// in-flight) research job for a PA, for on-demand re-syncs from the UI
+// instead of waiting for StartResearchWorker's periodic seeding.
+// POST /api/research/sync/{pa_id}
+func (s *Server) HandleAPIResearchSync(w http.ResponseWriter, r *http.Request) {
+	paID := r.PathValue("pa_id")
+	if paID == "" {
+		http.Error(w, "pa_id required", http.StatusBadRequest)
+		return
+	}
+
+	jobID, deduped, err := s.ResearchJobs.Enqueue(r.Context(), paID)
+	if err != nil {
+		http.Error(w, "failed to enqueue research job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if deduped {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"job_id": jobID, "deduped": deduped})
+}
+
+// HandleAPIResearchJobs lists recent research jobs with their state,
+// so an operator can see which PAs are queued, retrying, or failed
+// without grepping logs.
+// GET /api/research/jobs
+func (s *Server) HandleAPIResearchJobs(w http.ResponseWriter, r *http.Request) {
+	limit := researchJobsDefaultLimit
+	jobsList, err := s.ResearchJobs.List(r.Context(), limit)
+	if err != nil {
+		http.Error(w, "failed to list research jobs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobsList)
+}
+
+// HandleAPIResearchJob returns one research job's full state,
+// including last_error and attempts, for diagnosing why a specific
+// park has no publications (rate-limited? filtered out? upstream 5xx?).
+// GET /api/research/jobs/{id}
+func (s *Server) HandleAPIResearchJob(w http.ResponseWriter, r *http.Request) {
+	job, ok, err := s.ResearchJobs.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "failed to read research job", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "research job not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,125 @@
+// Package scheduler runs a small set of periodic maintenance jobs (PA
+// store refresh, fire data reload, GADM index rebuild) on cron
+// schedules, replacing the manual "redeploy to pick up new data"
+// workflow and the hand-rolled time.Sleep pacing in cmd/fetchpas.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job is one periodic task. Schedule is a standard 5-field cron
+// expression (minute hour day month weekday), parsed with
+// robfig/cron's standard parser.
+type Job interface {
+	Name() string
+	Schedule() string
+	Run(ctx context.Context) error
+}
+
+// Status is a snapshot of one job's last run, for /admin/scheduler/jobs.
+type Status struct {
+	Name        string        `json:"name"`
+	Schedule    string        `json:"schedule"`
+	NextRun     time.Time     `json:"nextRun"`
+	LastRun     time.Time     `json:"lastRun,omitempty"`
+	LastErr     string        `json:"lastError,omitempty"`
+	LastRunTook time.Duration `json:"lastRunTookMs"`
+}
+
+// Scheduler runs a fixed set of Jobs, each on its own schedule, until
+// its context is cancelled.
+type Scheduler struct {
+	parser cron.Parser
+
+	mu       sync.Mutex
+	statuses map[string]*Status
+}
+
+// New creates an empty Scheduler. Jobs are added with Register before
+// calling Start.
+func New() *Scheduler {
+	return &Scheduler{
+		parser:   cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
+		statuses: make(map[string]*Status),
+	}
+}
+
+// Start launches one goroutine per job that sleeps until the job's next
+// scheduled run, jittered by up to maxJitter so that multiple
+// instances of this process (or multiple deployments hitting the same
+// upstream API, like Protected Planet) don't all wake at the same
+// instant. It returns immediately; jobs keep running until ctx is
+// cancelled.
+func (s *Scheduler) Start(ctx context.Context, jobs []Job, maxJitter time.Duration) error {
+	for _, j := range jobs {
+		schedule, err := s.parser.Parse(j.Schedule())
+		if err != nil {
+			return fmt.Errorf("scheduler: job %q: parse schedule %q: %w", j.Name(), j.Schedule(), err)
+		}
+		s.mu.Lock()
+		s.statuses[j.Name()] = &Status{Name: j.Name(), Schedule: j.Schedule(), NextRun: schedule.Next(time.Now())}
+		s.mu.Unlock()
+
+		go s.runLoop(ctx, j, schedule, maxJitter)
+	}
+	return nil
+}
+
+func (s *Scheduler) runLoop(ctx context.Context, j Job, schedule cron.Schedule, maxJitter time.Duration) {
+	for {
+		next := schedule.Next(time.Now())
+		if maxJitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(maxJitter))))
+		}
+		s.mu.Lock()
+		s.statuses[j.Name()].NextRun = next
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		start := time.Now()
+		err := j.Run(ctx)
+		took := time.Since(start)
+
+		s.mu.Lock()
+		st := s.statuses[j.Name()]
+		st.LastRun = start
+		st.LastRunTook = took
+		if err != nil {
+			st.LastErr = err.Error()
+		} else {
+			st.LastErr = ""
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			slog.Error("scheduled job failed", "job", j.Name(), "error", err, "took", took)
+		} else {
+			slog.Info("scheduled job completed", "job", j.Name(), "took", took)
+		}
+	}
+}
+
+// Status returns a snapshot of every registered job's schedule and
+// last-run state, for the admin status endpoint.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Status, 0, len(s.statuses))
+	for _, st := range s.statuses {
+		out = append(out, *st)
+	}
+	return out
+}
@@ -0,0 +1,210 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"srv.exe.dev/srv/geoip"
+	"srv.exe.dev/srv/pa/store"
+	"srv.exe.dev/srv/scheduler"
+)
+
+// paRefreshJob re-fetches any PAStore row that's gone stale, instead of
+// requiring an operator to re-run cmd/fetchpas by hand.
+type paRefreshJob struct {
+	store   *store.Store
+	fetcher store.PAFetcher
+	source  string
+	maxAge  time.Duration
+}
+
+func (j *paRefreshJob) Name() string     { return "pa_refresh" }
+func (j *paRefreshJob) Schedule() string { return "0 */6 * * *" }
+
+func (j *paRefreshJob) Run(ctx context.Context) error {
+	fetched, err := j.store.RefreshStale(ctx, j.fetcher, j.source, j.maxAge, 4)
+	slog.Info("pa_refresh job", "fetched", fetched, "error", err)
+	return err
+}
+
+// fireReloadJob re-reads the fire JSON blobs into s.FireCache, so an
+// admin's upload shows up without a restart.
+type fireReloadJob struct {
+	cache *FireCache
+	paths []string
+}
+
+func (j *fireReloadJob) Name() string     { return "fire_reload" }
+func (j *fireReloadJob) Schedule() string { return "*/10 * * * *" }
+
+func (j *fireReloadJob) Run(ctx context.Context) error {
+	changed, err := j.cache.Reload(j.paths)
+	if changed > 0 {
+		slog.Info("fire_reload job: data changed", "changedFiles", changed)
+	}
+	return err
+}
+
+// gadmRebuildJob re-reads the GADM country/region index from disk and
+// swaps the Server's pointer to it, so an updated GADM export doesn't
+// need a restart to take effect.
+type gadmRebuildJob struct {
+	srv *Server
+}
+
+func (j *gadmRebuildJob) Name() string     { return "gadm_rebuild" }
+func (j *gadmRebuildJob) Schedule() string { return "0 3 * * *" }
+
+func (j *gadmRebuildJob) Run(ctx context.Context) error {
+	if j.srv.GADMPath == "" {
+		return nil
+	}
+	newStore, err := LoadGADMStore(j.srv.GADMPath)
+	if err != nil {
+		return err
+	}
+	j.srv.GADMStore = newStore
+	return nil
+}
+
+// geoipRefreshJob redownloads the GeoLite2-City database and atomically
+// swaps it into s.GeoIP, so an expiring MaxMind export doesn't need a
+// restart to replace. Skips the run (instead of erroring) if GeoIP
+// wasn't configured at startup, so a deployment without MaxMind
+// credentials doesn't spam the job log every run.
+type geoipRefreshJob struct {
+	geoip                       *geoip.Lookup
+	accountID, licenseKey, path string
+}
+
+func (j *geoipRefreshJob) Name() string     { return "geoip_refresh" }
+func (j *geoipRefreshJob) Schedule() string { return "0 4 * * 0" }
+
+func (j *geoipRefreshJob) Run(ctx context.Context) error {
+	if j.geoip == nil || j.accountID == "" || j.licenseKey == "" {
+		return nil
+	}
+	return j.geoip.Refresh(j.accountID, j.licenseKey, j.path)
+}
+
+// parkMetricsRefreshJob periodically re-runs MaterializeParkMetrics so
+// /api/export/parks's park_metrics_current/park_metrics_daily tables
+// don't go stale between operator-triggered "5mp aggregate parks" runs.
+// See srv/park_metrics.go for the advisory-lock mechanics that let this
+// job and a manual CLI run race safely.
+type parkMetricsRefreshJob struct {
+	srv *Server
+}
+
+func (j *parkMetricsRefreshJob) Name() string     { return "park_metrics_refresh" }
+func (j *parkMetricsRefreshJob) Schedule() string { return "0 2 * * *" }
+
+func (j *parkMetricsRefreshJob) Run(ctx context.Context) error {
+	rowCount, ok, err := j.srv.MaterializeParkMetrics(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Info("park_metrics_refresh job: another pod holds the lock, skipped")
+		return nil
+	}
+	slog.Info("park_metrics_refresh job completed", "rows", rowCount)
+	return nil
+}
+
+// prefetchRefreshJob rebuilds s.PrefetchCache so a popular /api/grid
+// query shape is already warm by the time its previous response's 60s
+// Cache-Control expires. Registered twice under different names/
+// schedules (see RegisterDefaultJobs) to cover both a steady cadence
+// and a run timed just ahead of the cache expiry.
+type prefetchRefreshJob struct {
+	srv      *Server
+	name     string
+	schedule string
+}
+
+func (j *prefetchRefreshJob) Name() string     { return j.name }
+func (j *prefetchRefreshJob) Schedule() string { return j.schedule }
+
+func (j *prefetchRefreshJob) Run(ctx context.Context) error {
+	return j.srv.RebuildPrefetchCache(ctx)
+}
+
+// effortRollupsRefreshJob periodically re-runs MaterializeEffortRollups
+// so daily_effort_stats/daily_fire_stats/monthly_effort_stats/
+// pa_monthly_rollup don't go stale between operator-triggered
+// "5mp aggregate stats" runs or POSTs to /admin/aggregate/run. Recomputes
+// from the last successful run (see srv/effort_rollups.go), falling back
+// to effortRollupsDefaultLookback if this is the first run.
+type effortRollupsRefreshJob struct {
+	srv *Server
+}
+
+func (j *effortRollupsRefreshJob) Name() string     { return "effort_rollups_refresh" }
+func (j *effortRollupsRefreshJob) Schedule() string { return "30 2 * * *" }
+
+func (j *effortRollupsRefreshJob) Run(ctx context.Context) error {
+	since := time.Now().Add(-effortRollupsDefaultLookback)
+	if lastRun, ok, err := j.srv.LastEffortRollupRun(ctx); err == nil && ok {
+		since = lastRun
+	}
+
+	rowCount, ok, err := j.srv.MaterializeEffortRollups(ctx, since)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Info("effort_rollups_refresh job: another pod holds the lock, skipped")
+		return nil
+	}
+	slog.Info("effort_rollups_refresh job completed", "rows", rowCount)
+	return nil
+}
+
+// spatialIndexRebuildJob rebuilds s.SpatialIndex's grid-cell and
+// protected-area R-trees, so newly-ingested grid cells and newly-loaded
+// areas become eligible for bbox= query filtering without a restart.
+type spatialIndexRebuildJob struct {
+	srv *Server
+}
+
+func (j *spatialIndexRebuildJob) Name() string     { return "spatial_index_rebuild" }
+func (j *spatialIndexRebuildJob) Schedule() string { return "*/20 * * * *" }
+
+func (j *spatialIndexRebuildJob) Run(ctx context.Context) error {
+	return j.srv.RebuildSpatialIndex(ctx)
+}
+
+// RegisterDefaultJobs builds the PA-refresh, fire-reload, GADM-rebuild,
+// and GeoIP-refresh jobs and registers them with s.Scheduler, starting
+// them with up to a minute of startup jitter so multiple instances of
+// this process don't all hit Protected Planet at the same moment.
+// fetcher and source configure how the PA-refresh job fetches stale
+// rows (see cmd/fetchpas's -source flag for the same choice made from
+// the CLI); geoipAccountID/geoipLicenseKey/geoipPath configure the
+// GeoIP-refresh job and may be left empty to disable it (see
+// config.Config.GeoIPAccountID).
+func (s *Server) RegisterDefaultJobs(ctx context.Context, fetcher store.PAFetcher, source string, geoipAccountID, geoipLicenseKey, geoipPath string) error {
+	jobs := []scheduler.Job{
+		&paRefreshJob{store: s.PAStore, fetcher: fetcher, source: source, maxAge: store.DefaultTTL},
+		&fireReloadJob{cache: s.FireCache, paths: FireDataPaths},
+		&gadmRebuildJob{srv: s},
+		&geoipRefreshJob{geoip: s.GeoIP, accountID: geoipAccountID, licenseKey: geoipLicenseKey, path: geoipPath},
+		&parkMetricsRefreshJob{srv: s},
+		&prefetchRefreshJob{srv: s, name: "prefetch_refresh_30m", schedule: "*/30 * * * *"},
+		&prefetchRefreshJob{srv: s, name: "prefetch_refresh_pretop", schedule: "55 * * * *"},
+		&spatialIndexRebuildJob{srv: s},
+		&effortRollupsRefreshJob{srv: s},
+	}
+	return s.Scheduler.Start(ctx, jobs, time.Minute)
+}
+
+// HandleSchedulerStatus returns the last-run status of every scheduled
+// job, for the admin console.
+func (s *Server) HandleSchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Scheduler.Status())
+}
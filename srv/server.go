@@ -1,19 +1,58 @@
 package srv
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	_ "github.com/lib/pq" // registers the "postgres" driver for PlacesPostGISDSN
 
 	"srv.exe.dev/db"
+	"srv.exe.dev/srv/activitypub"
 	"srv.exe.dev/srv/areas"
 	"srv.exe.dev/srv/auth"
+	"srv.exe.dev/srv/auth/oidc"
+	"srv.exe.dev/srv/auth/tokens"
+	"srv.exe.dev/srv/auth/webauthn"
+	"srv.exe.dev/srv/config"
+	"srv.exe.dev/srv/drivers"
+	"srv.exe.dev/srv/geoip"
+	"srv.exe.dev/srv/ghsl"
+	"srv.exe.dev/srv/jobs"
+	"srv.exe.dev/srv/mailer"
+	"srv.exe.dev/srv/metrics"
+	"srv.exe.dev/srv/pa/store"
+	"srv.exe.dev/srv/places"
+	"srv.exe.dev/srv/prefetch"
+	"srv.exe.dev/srv/scheduler"
+	"srv.exe.dev/srv/socio"
+	"srv.exe.dev/srv/spatial"
+	"srv.exe.dev/srv/tiles"
+	"srv.exe.dev/srv/tus"
+	"srv.exe.dev/srv/vault"
 )
 
+// vaultSaltPath is where the at-rest encryption vault's (non-secret,
+// per-deployment) scrypt salt is created on first use.
+const vaultSaltPath = "data/vault-salt"
+
 type Server struct {
 	DB           *sql.DB
 	Hostname     string
@@ -24,6 +63,213 @@ type Server struct {
 	Auth         *auth.Manager
 	LegalStore   *LegalStore
 	GADMStore    *GADMStore
+	UploadQueue  *UploadQueue
+
+	// PAStore is the SQLite-backed protected_areas materialization (see
+	// srv/pa/store) behind /api/pa/...; it replaces the one-shot
+	// data/keystones.json artifact cmd/fetchpas used to produce.
+	PAStore *store.Store
+
+	// FireCache keeps the fire JSON blobs served under /api/fire/... in
+	// memory; see srv/fire_cache.go. Populated on startup and refreshed
+	// by the scheduler's fire-reload job.
+	FireCache *FireCache
+
+	// GADMPath is where the GADM country/region index was loaded from,
+	// so the scheduler's GADM-rebuild job can re-read it without a
+	// restart. Empty if GADMStore wasn't loaded from a file.
+	GADMPath string
+
+	// Scheduler runs the periodic maintenance jobs registered in
+	// scheduler_jobs.go (PA refresh, fire reload, GADM rebuild). Started
+	// by cmd/srv/main.go once deployment-specific wiring is in place.
+	Scheduler *scheduler.Scheduler
+
+	// GeoIP resolves a caller's IP to a country/coordinates for the
+	// ?near=me area search (see srv/geoip). Nil if cfg.GeoIPDBPath wasn't
+	// set or the database failed to open, in which case handlers fall
+	// back to requiring an explicit ?country= param.
+	GeoIP *geoip.Lookup
+
+	// TileCache holds encoded MVT tiles for /tiles/pa/..., /tiles/fire/...,
+	// and the bbox-scoped /api/tiles/{layer}/..., keyed by (layer, z, x,
+	// y, dataset version); see srv/tiles and tile_handlers.go.
+	TileCache *tiles.Cache
+
+	// PrefetchCache holds precomputed /api/grid responses keyed by a
+	// canonical query shape (see srv/prefetch), populated on a schedule
+	// by the prefetch-refresh jobs in scheduler_jobs.go rather than
+	// lazily on request, so a popular query shape is already warm by
+	// the time the 60s Cache-Control on the previous response expires.
+	PrefetchCache *prefetch.Cache
+
+	// PrefetchSampler records which /api/grid query shapes callers
+	// actually request between rebuild cycles, so RebuildPrefetchCache
+	// can refresh the shapes that matter instead of only a fixed set.
+	PrefetchSampler *prefetch.Sampler
+
+	// SpatialIndex holds in-memory R-trees over grid-cell centers and
+	// protected-area bounding boxes (see srv/spatial), letting handlers
+	// that accept a bbox= query param resolve candidate IDs without
+	// scanning the requested year range. Starts empty; populated by the
+	// spatial index rebuild job in scheduler_jobs.go, so a bbox query
+	// made before that job's first run falls back to the unfiltered path.
+	SpatialIndex *spatial.Index
+
+	// PlaceIndex answers the narrative handlers' nearest-OSM-place
+	// queries (see srv/places). Defaults to a SQLite-backed index over
+	// srv.DB; set cfg.PlacesPostGISDSN to back it with PostGIS instead.
+	PlaceIndex places.Index
+
+	// PlaceMatcher fuzzy-matches free-text park/place names against
+	// AreaStore and osm_places (see place_matcher.go). Nil until
+	// cmd/srv/main.go builds it once AreaStore has loaded; resolveParkID
+	// and HandleAPIPlacesSearch both fall back to exact matching when nil.
+	PlaceMatcher *PlaceMatcher
+
+	// DriverModel classifies a deforestation cluster's likely human
+	// driver (see srv/drivers and driver_classifier.go). Nil until
+	// cmd/srv/main.go loads the fitted tree from data dir; classifyCluster
+	// returns ("", 0) when nil, so callers degrade to no driver label.
+	DriverModel *drivers.Model
+
+	// SocioStore holds gridded socioeconomic indicators (GDP per capita,
+	// agricultural land fraction, road density, cropland pressure; see
+	// srv/socio) keyed by park ID. Nil until cmd/srv/main.go loads it
+	// from data dir; assessConflictRisk degrades to settlement-density-
+	// only scoring when a park has no entry.
+	SocioStore *socio.Store
+
+	// SocioWeights controls how much each factor in SocioStore
+	// contributes to assessConflictRisk's conflict index. Defaults to
+	// socio.DefaultWeights; cmd/srv/main.go overrides it if a weights
+	// file is configured, letting an operator retune the model without
+	// recompiling.
+	SocioWeights socio.Weights
+
+	// DateParsers is the named registry of `from`/`to` parsers the
+	// narrative handlers accept via ?date_parser= (see
+	// date_parsers.go). Populated by New() with the "dateonly", "year",
+	// "yearmonth", "rfc3339", and "flexible" built-ins.
+	DateParsers map[string]func(string) (time.Time, error)
+
+	WebAuthnRP         *webauthn.RelyingParty
+	WebAuthnChallenges *webauthn.ChallengeStore
+
+	// SSOProviders maps a short provider name (e.g. "google", "partner-hydra")
+	// used in /auth/{provider}/... routes to its configuration. Populated by
+	// cmd/srv/main.go from config, not New, since it's deployment-specific.
+	SSOProviders map[string]*oidc.Provider
+
+	// Tokens mints and verifies personal access tokens for the /api/*
+	// surface. TokenRateLimiter throttles requests per token so a leaked
+	// token can't hammer the API at the rate of the underlying connection.
+	Tokens           *tokens.Manager
+	TokenRateLimiter *tokens.RateLimiter
+
+	// Jobs tracks background processing tasks (fire CSV/GHSL tile
+	// ingestion, etc.) for the admin console's live progress view.
+	Jobs *jobs.Manager
+
+	// Tus persists in-progress resumable uploads (GHSL tiles, fire
+	// CSVs) so a dropped connection or server restart can resume
+	// instead of starting over.
+	Tus *tus.Store
+
+	// GHSLFetcher downloads missing GHSL tiles directly from JRC,
+	// reporting progress through Jobs the same way an uploaded tile
+	// does once extracted.
+	GHSLFetcher *ghsl.Fetcher
+
+	// JobRunner bounds concurrent fire/GHSL processing tasks, supports
+	// cancelling one mid-run, and persists the queue so it survives a
+	// restart. Jobs is still the source of truth for live progress;
+	// JobRunner only adds scheduling, cancellation, and persistence.
+	JobRunner *JobRunner
+
+	// ParkJobs dispatches per-park analysis jobs (fire analysis, group
+	// infractions, publications, GHSL, roadless) started on demand via
+	// POST /api/parks/{id}/jobs/{source}, polled via GET /api/jobs/{id}.
+	// It's a separate type from JobRunner/Jobs above: those track
+	// byte-oriented file ingest (an uploaded CSV or tile), while
+	// ParkJobs tracks percent-complete park/source analysis work, which
+	// doesn't fit the same shape.
+	ParkJobs *ParkJobRunner
+
+	// ResearchJobs is the persistent, retrying job queue behind
+	// StartResearchWorker, POST /api/research/sync/{pa_id}, and
+	// GET /api/research/jobs(/{id}) (see research_jobs.go). It's
+	// separate from ParkJobs because it retries on failure with
+	// exponential backoff instead of reporting a single pass/fail.
+	ResearchJobs *ResearchJobRunner
+
+	// ParkStatusHub fans out ParkDataStatus snapshots to clients of
+	// GET /api/parks/{id}/status/stream, published to whenever ParkJobs
+	// finishes a job or the publications sync completes (see
+	// ParkJobRunner.SetOnChange and fetchPublicationsForPA).
+	ParkStatusHub *ParkStatusHub
+
+	// Vault encrypts fire/GHSL raw data at rest when the operator has
+	// configured a passphrase (see srv/vault and config.VaultPassphraseFile).
+	// It's nil if no passphrase is configured, in which case uploads are
+	// written and read as plaintext.
+	Vault *vault.Vault
+
+	// Deployment settings carried over from config.Config; see that
+	// type's doc comments for what each one does. Serve reads these to
+	// decide how to listen and when to drop privileges.
+	Addr                  string
+	TLSCertFile           string
+	TLSKeyFile            string
+	ACMEDomains           []string
+	ACMECacheDir          string
+	EnableClientCertAuth  bool
+	DropUser              string
+	DropGroup             string
+	ShutdownGrace         time.Duration
+	DisableAuthentication bool
+	TrustedProxies        []*net.IPNet
+
+	// AllowAnonymousDocumentReads lets GET /api/parks/{id}/documents
+	// serve without authentication, for deployments that publish their
+	// park documents openly. Writes to that path always require
+	// auth.PermissionEditor regardless of this setting.
+	AllowAnonymousDocumentReads bool
+
+	// APKeys and APFollowers back the /ap/parks/{id} ActivityPub actor,
+	// outbox, and inbox (see activitypub_handlers.go and srv/activitypub).
+	APKeys      *activitypub.KeyStore
+	APFollowers *activitypub.FollowerStore
+
+	// DocumentThumbnailer renders preview images for the
+	// /parks/{id}/documents/ browse listing (see document_browse.go).
+	// Defaults to noThumbnailRenderer, which never produces one.
+	DocumentThumbnailer ThumbnailRenderer
+
+	// BasemapTileURLTemplate is the XYZ tile server used to render the
+	// basemap for patrol PDF map exports (see patrol_map_handlers.go and
+	// srv/pdfexport). Empty uses pdfexport.DefaultTileURLTemplate.
+	BasemapTileURLTemplate string
+
+	// OverpassAPIURL is the Overpass endpoint HandleParkRoads queries
+	// (see srv/osmroads). Empty uses osmroads.DefaultEndpoint.
+	OverpassAPIURL string
+
+	// ResearchSources is the set of bibliographic indexes
+	// fetchPublicationsForPA fans out to (see research.go). Nil uses
+	// DefaultResearchSources(); set by New() from
+	// config.Config.DisabledResearchSources.
+	ResearchSources []PublicationSource
+
+	// ResearchHTTP is the rate-limited HTTPClient every PublicationSource
+	// shares for its outbound requests; set by New() from
+	// config.Config's ResearchHTTP* settings (see httpclient.go).
+	ResearchHTTP *HTTPClient
+
+	// OverpassHTTP is the rate-limited HTTPClient HandleParkRoads' fetch
+	// uses against OverpassAPIURL; set by New() from config.Config's
+	// OverpassHTTP* settings (see httpclient.go).
+	OverpassHTTP *HTTPClient
 }
 
 type pageData struct {
@@ -31,21 +277,293 @@ type pageData struct {
 	User     *auth.User
 }
 
-func New(dbPath, hostname string) (*Server, error) {
+// New builds a Server from cfg. It wires up the simple, in-process
+// components (DB, auth, WebAuthn, tokens); file/env-driven components
+// like AreaStore, LegalStore, UploadQueue, and SSOProviders are left
+// for cmd/srv/main.go to populate since loading them is deployment
+// logic, not server construction.
+func New(cfg *config.Config) (*Server, error) {
 	_, thisFile, _, _ := runtime.Caller(0)
 	baseDir := filepath.Dir(thisFile)
+
+	templatesDir := cfg.TemplatesDir
+	if templatesDir == "" {
+		templatesDir = filepath.Join(baseDir, "templates")
+	}
+	staticDir := cfg.StaticDir
+	if staticDir == "" {
+		staticDir = filepath.Join(baseDir, "static")
+	}
+
+	trustedProxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("parse trusted proxies: %w", err)
+	}
+
 	srv := &Server{
-		Hostname:     hostname,
-		TemplatesDir: filepath.Join(baseDir, "templates"),
-		StaticDir:    filepath.Join(baseDir, "static"),
+		Hostname:     cfg.Hostname,
+		TemplatesDir: templatesDir,
+		StaticDir:    staticDir,
+
+		Addr:                        cfg.Addr,
+		TLSCertFile:                 cfg.TLSCertFile,
+		TLSKeyFile:                  cfg.TLSKeyFile,
+		ACMEDomains:                 cfg.ACMEDomains,
+		ACMECacheDir:                cfg.ACMECacheDir,
+		EnableClientCertAuth:        cfg.EnableClientCertAuth,
+		DropUser:                    cfg.User,
+		DropGroup:                   cfg.Group,
+		ShutdownGrace:               cfg.ShutdownGrace,
+		DisableAuthentication:       cfg.DisableAuthentication,
+		TrustedProxies:              trustedProxies,
+		AllowAnonymousDocumentReads: cfg.AllowAnonymousDocumentReads,
+		APKeys:                      activitypub.NewKeyStore(),
+		APFollowers:                 activitypub.NewFollowerStore(),
+		DocumentThumbnailer:         noThumbnailRenderer{},
+		BasemapTileURLTemplate:      cfg.BasemapTileURLTemplate,
+		OverpassAPIURL:              cfg.OverpassAPIURL,
+		ResearchSources:             filterResearchSources(DefaultResearchSources(), cfg.DisabledResearchSources),
+		ResearchHTTP:                NewResearchHTTPClient(cfg),
+		OverpassHTTP:                NewOverpassHTTPClient(cfg),
+
+		DateParsers:  DefaultDateParsers(),
+		SocioWeights: socio.DefaultWeights,
 	}
-	if err := srv.setUpDatabase(dbPath); err != nil {
+	if err := srv.setUpDatabase(cfg.DBPath); err != nil {
 		return nil, err
 	}
 	srv.Auth = auth.NewManager(srv.DB)
+	if cfg.SMTPAddr == "" {
+		srv.Auth.Mailer = mailer.NoopMailer{}
+		slog.Warn("no SMTP relay configured (set SRV_SMTP_ADDR) — password reset and email verification messages will be dropped")
+	} else {
+		srv.Auth.Mailer = mailer.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	srv.WebAuthnRP = webauthn.NewRelyingParty(cfg.Hostname)
+	srv.WebAuthnChallenges = webauthn.NewChallengeStore()
+	srv.SSOProviders = make(map[string]*oidc.Provider)
+	srv.Tokens = tokens.NewManager(srv.DB)
+	srv.TokenRateLimiter = tokens.NewRateLimiter(cfg.RateLimit.PerSecond, cfg.RateLimit.Burst)
+	srv.Jobs = jobs.NewManager()
+	srv.Tus, err = tus.NewStore(filepath.Join("data", "uploads"))
+	if err != nil {
+		return nil, fmt.Errorf("create tus upload store: %w", err)
+	}
+	srv.GHSLFetcher = ghsl.NewFetcher(ghslDataDir, 3, srv.Jobs)
+	srv.JobRunner = NewJobRunner(srv.DB, srv.Jobs)
+	srv.ParkJobs = NewParkJobRunner(srv.DB)
+	srv.ParkJobs.RegisterWorker(ParkJobSourcePublications, srv.runPublicationsJob)
+	srv.ResearchJobs = NewResearchJobRunner(srv.DB, srv)
+	srv.ParkStatusHub = NewParkStatusHub()
+	srv.ParkJobs.SetOnChange(func(parkID string) {
+		srv.ParkStatusHub.Publish(parkID, srv.computeParkDataStatus(context.Background(), parkID, nil))
+	})
+	srv.PAStore = store.New(srv.DB)
+	srv.FireCache = NewFireCache()
+	srv.Scheduler = scheduler.New()
+	srv.TileCache = tiles.NewCache(tileCacheCapacity)
+	srv.PrefetchCache = prefetch.NewCache(prefetchCacheCapacity)
+	srv.PrefetchSampler = prefetch.NewSampler()
+	srv.SpatialIndex = spatial.New()
+	if cfg.GeoIPDBPath == "" {
+		slog.Warn("no GeoIP database configured (set SRV_GEOIP_DB_PATH) — ?near=me area search will require an explicit country")
+	} else if lookup, err := geoip.Open(cfg.GeoIPDBPath); err != nil {
+		slog.Warn("open GeoIP database, ?near=me will be disabled", "path", cfg.GeoIPDBPath, "error", err)
+	} else {
+		srv.GeoIP = lookup
+	}
+
+	srv.PlaceIndex = places.NewSQLiteIndex(srv.DB)
+	if cfg.PlacesPostGISDSN != "" {
+		pgdb, err := sql.Open("postgres", cfg.PlacesPostGISDSN)
+		if err != nil {
+			slog.Warn("open PostGIS connection, falling back to SQLite place index", "error", err)
+		} else {
+			srv.PlaceIndex = places.NewPostGISIndex(pgdb)
+		}
+	}
+
+	vaultPassphrase, err := vault.ResolvePassphrase(cfg.VaultPassphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("resolve vault passphrase: %w", err)
+	}
+	if vaultPassphrase == "" {
+		slog.Warn("no vault passphrase configured (set SRV_VAULT_PASSPHRASE or VaultPassphraseFile) — uploaded fire/GHSL data will be stored unencrypted")
+	} else {
+		srv.Vault, err = vault.New(vaultPassphrase, vaultSaltPath)
+		if err != nil {
+			return nil, fmt.Errorf("init vault: %w", err)
+		}
+	}
+
+	if srv.DisableAuthentication {
+		slog.Warn("AUTHENTICATION IS DISABLED — every request is treated as an admin. Do not use this outside local dev.")
+	}
+
 	return srv, nil
 }
 
+// parseTrustedProxies parses each entry as a CIDR, treating a bare IP
+// as a /32 (or /128), so config can list either "10.0.0.5" or
+// "10.0.0.0/24".
+func parseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, e := range entries {
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", e)
+			}
+			if ip.To4() != nil {
+				e += "/32"
+			} else {
+				e += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", e, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// GetUserFromRequest resolves the acting user from, in order: an mTLS
+// client certificate presented during the TLS handshake, an
+// "Authorization: Bearer cpt_..." personal access token, or, failing
+// those, the session cookie. Bearer requests are also subject to
+// TokenRateLimiter; RequireScope (not this function) enforces that a
+// bearer token actually carries the scope a handler needs.
+func (s *Server) GetUserFromRequest(r *http.Request) *auth.User {
+	if s.DisableAuthentication {
+		return devBypassUser
+	}
+	if user := s.clientCertUser(r); user != nil {
+		return user
+	}
+	if user := s.bearerTokenUser(r); user != nil {
+		return user
+	}
+	return s.Auth.GetUserFromRequest(r)
+}
+
+// RefreshUserFromRequest is GetUserFromRequest's cookie-refreshing
+// counterpart: handlers that can write the response (middleware, not
+// read-only page renders) should call this instead so an active
+// session's sliding expiration actually reaches the browser as a
+// renewed cookie. mTLS and bearer-token auth have no cookie to refresh,
+// so those branches behave identically to GetUserFromRequest.
+func (s *Server) RefreshUserFromRequest(w http.ResponseWriter, r *http.Request) *auth.User {
+	if s.DisableAuthentication {
+		return devBypassUser
+	}
+	if user := s.clientCertUser(r); user != nil {
+		return user
+	}
+	if user := s.bearerTokenUser(r); user != nil {
+		return user
+	}
+	return s.Auth.RefreshSessionCookie(w, r, s.IsSecureRequest(r))
+}
+
+// clientCertUser resolves r's mTLS client certificate, if any, to a
+// User. It's nil whenever the server isn't started with
+// EnableClientCertAuth (r.TLS.PeerCertificates is then always empty,
+// since the TLS listener never asked for a certificate) or the
+// connecting client didn't present one, which is the common case for
+// browser traffic — this is meant for server-to-server callers.
+func (s *Server) clientCertUser(r *http.Request) *auth.User {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	user, err := s.Auth.GetUserFromClientCert(r.Context(), r.TLS.PeerCertificates[0])
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// bearerTokenUser resolves r's "Authorization: Bearer cpt_..." personal
+// access token, if any, to a User.
+func (s *Server) bearerTokenUser(r *http.Request) *auth.User {
+	plaintext, ok := bearerToken(r)
+	if !ok {
+		return nil
+	}
+	userID, _, err := s.Tokens.Verify(r.Context(), plaintext)
+	if err != nil {
+		return nil
+	}
+	user, err := s.Auth.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return nil
+	}
+	s.Auth.LogEvent(r.Context(), "", user.ID, "token_use", map[string]any{"path": r.URL.Path})
+	return user
+}
+
+// IsSecureRequest reports whether r was made over TLS, either directly
+// or (if r comes from a TrustedProxy) via "X-Forwarded-Proto: https".
+// Handlers use this instead of r.TLS != nil to set the cookie Secure
+// flag correctly behind a reverse proxy.
+func (s *Server) IsSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !s.isTrustedProxy(r.RemoteAddr) {
+		return false
+	}
+	return strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func (s *Server) isTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range s.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the connecting client's address, preferring
+// X-Forwarded-For over r.RemoteAddr when r comes from a TrustedProxy
+// (same trust rule as IsSecureRequest). It's used to key login rate
+// limiting, so a reverse proxy in front of the server doesn't collapse
+// every client onto the proxy's own address.
+func (s *Server) ClientIP(r *http.Request) string {
+	if s.isTrustedProxy(r.RemoteAddr) {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			if idx := strings.IndexByte(fwd, ','); idx != -1 {
+				return strings.TrimSpace(fwd[:idx])
+			}
+			return strings.TrimSpace(fwd)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(h, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(h, prefix), true
+}
+
 func (s *Server) HandleRoot(w http.ResponseWriter, r *http.Request) {
 	user := s.Auth.GetUserFromRequest(r)
 
@@ -72,8 +590,6 @@ func (s *Server) renderTemplate(w http.ResponseWriter, name string, data any) er
 	return nil
 }
 
-
-
 // SetupDatabase initializes the database connection and runs migrations
 func (s *Server) setUpDatabase(dbPath string) error {
 	wdb, err := db.Open(dbPath)
@@ -87,10 +603,102 @@ func (s *Server) setUpDatabase(dbPath string) error {
 	return nil
 }
 
-// Serve starts the HTTP server with the configured routes
-func (s *Server) Serve(addr string) error {
+// Serve builds the routes and serves them on s.Addr, choosing TLS,
+// ACME, or plaintext per the TLS* config, dropping privileges to
+// DropUser/DropGroup once listening, and shutting down gracefully
+// (ShutdownGrace) on SIGINT/SIGTERM.
+func (s *Server) Serve() error {
+	mux := s.routes()
+
+	httpServer := &http.Server{
+		Addr:              s.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	usingACME := len(s.ACMEDomains) > 0
+	if usingACME {
+		cacheDir := s.ACMECacheDir
+		if cacheDir == "" {
+			cacheDir = "autocert-cache"
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.ACMEDomains...),
+			Cache:      autocert.DirCache(cacheDir),
+		}
+		httpServer.TLSConfig = m.TLSConfig()
+	}
+	if s.EnableClientCertAuth {
+		if httpServer.TLSConfig == nil {
+			httpServer.TLSConfig = &tls.Config{}
+		}
+		// RequestClientCert, not VerifyClientCertIfGiven: clientCertUser
+		// validates a presented certificate against the client_certs
+		// fingerprint table itself (see auth.GetUserFromClientCert), so
+		// there's no CA chain for crypto/tls to verify against - any
+		// certificate, self-signed or not, needs to reach the handler.
+		httpServer.TLSConfig.ClientAuth = tls.RequestClientCert
+	}
+
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.Addr, err)
+	}
+
+	if s.DropUser != "" || s.DropGroup != "" {
+		if err := dropPrivileges(s.DropUser, s.DropGroup); err != nil {
+			return fmt.Errorf("drop privileges: %w", err)
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		switch {
+		case s.TLSCertFile != "" && s.TLSKeyFile != "":
+			slog.Info("starting server", "addr", s.Addr, "tls", "static cert")
+			serveErr <- httpServer.ServeTLS(listener, s.TLSCertFile, s.TLSKeyFile)
+		case usingACME:
+			slog.Info("starting server", "addr", s.Addr, "tls", "autocert", "domains", s.ACMEDomains)
+			serveErr <- httpServer.ServeTLS(listener, "", "")
+		default:
+			slog.Info("starting server", "addr", s.Addr, "tls", "disabled")
+			serveErr <- httpServer.Serve(listener)
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case sig := <-sigCh:
+		grace := s.ShutdownGrace
+		if grace <= 0 {
+			grace = 10 * time.Second
+		}
+		slog.Info("shutting down", "signal", sig, "grace", grace)
+		ctx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			slog.Warn("graceful shutdown did not complete in time", "error", err)
+		}
+		if err := s.DB.Close(); err != nil {
+			slog.Warn("error closing database", "error", err)
+		}
+		return nil
+	}
+}
+
+// routes registers every handler on a fresh ServeMux.
+func (s *Server) routes() *http.ServeMux {
 	mux := http.NewServeMux()
-	
+
 	// Public routes
 	mux.HandleFunc("GET /{$}", s.HandleRoot)
 	mux.HandleFunc("GET /login", s.HandleLoginPage)
@@ -98,65 +706,155 @@ func (s *Server) Serve(addr string) error {
 	mux.HandleFunc("GET /logout", s.HandleLogout)
 	mux.HandleFunc("GET /register", s.HandleRegisterPage)
 	mux.HandleFunc("POST /register", s.HandleRegister)
-	
+
+	// WebAuthn (passkey) endpoints
+	mux.HandleFunc("POST /api/webauthn/register/begin", metrics.Instrument("api_webauthn_register_begin", s.RequireAuth(s.HandleAPIWebAuthnRegisterBegin)))
+	mux.HandleFunc("POST /api/webauthn/register/finish", metrics.Instrument("api_webauthn_register_finish", s.RequireAuth(s.HandleAPIWebAuthnRegisterFinish)))
+	mux.HandleFunc("POST /api/webauthn/login/begin", metrics.Instrument("api_webauthn_login_begin", s.HandleAPIWebAuthnLoginBegin))
+	mux.HandleFunc("POST /api/webauthn/login/finish", metrics.Instrument("api_webauthn_login_finish", s.HandleAPIWebAuthnLoginFinish))
+	mux.HandleFunc("GET /api/webauthn/credentials", metrics.Instrument("api_webauthn_list_credentials", s.RequireAuth(s.HandleAPIWebAuthnListCredentials)))
+	mux.HandleFunc("DELETE /api/webauthn/credentials/{id}", metrics.Instrument("api_webauthn_delete_credential", s.RequireAuth(s.HandleAPIWebAuthnDeleteCredential)))
+
+	// OIDC/OAuth2 SSO endpoints
+	mux.HandleFunc("GET /auth/{provider}/start", s.HandleSSOStart)
+	mux.HandleFunc("GET /auth/{provider}/callback", s.HandleSSOCallback)
+
 	// Protected routes (require auth)
 	mux.HandleFunc("GET /upload", s.RequireAuth(s.HandleUploadPage))
 	mux.HandleFunc("POST /upload", s.RequireAuth(s.HandleUpload))
-	
+	mux.HandleFunc("GET /settings/tokens", s.RequireAuth(s.HandleTokensPage))
+	mux.HandleFunc("POST /settings/tokens", s.RequireAuth(s.RequireCSRF(s.HandleTokensCreate)))
+	mux.HandleFunc("POST /settings/tokens/revoke", s.RequireAuth(s.RequireCSRF(s.HandleTokensRevoke)))
+
 	// Admin routes (require admin role)
 	mux.HandleFunc("GET /admin", s.RequireAdmin(s.HandleAdminPage))
-	mux.HandleFunc("POST /admin/approve", s.RequireAdmin(s.HandleApproveUser))
-	mux.HandleFunc("POST /admin/reject", s.RequireAdmin(s.HandleRejectUser))
+	mux.HandleFunc("POST /admin/approve", s.RequireAdmin(s.RequireCSRF(s.HandleApproveUser)))
+	mux.HandleFunc("POST /admin/reject", s.RequireAdmin(s.RequireCSRF(s.HandleRejectUser)))
 	mux.HandleFunc("POST /admin/upload/fire", s.RequireAdmin(s.HandleUploadFire))
 	mux.HandleFunc("POST /admin/upload/ghsl", s.RequireAdmin(s.HandleUploadGHSL))
-	mux.HandleFunc("GET /admin/status", s.RequireAdmin(s.HandleProcessingStatus))
-	
+	mux.HandleFunc("POST /admin/ghsl/fetch", s.RequireAdmin(s.RequireCSRF(s.HandleFetchGHSLTiles)))
+	mux.HandleFunc("OPTIONS /admin/uploads/tus", s.RequireAdmin(s.HandleTusOptions))
+	mux.HandleFunc("POST /admin/uploads/tus", s.RequireAdmin(s.HandleTusCreate))
+	mux.HandleFunc("HEAD /admin/uploads/tus/{id}", s.RequireAdmin(s.HandleTusHead))
+	mux.HandleFunc("PATCH /admin/uploads/tus/{id}", s.RequireAdmin(s.HandleTusPatch))
+	mux.HandleFunc("GET /admin/jobs", s.RequireAdmin(s.HandleJobsList))
+	mux.HandleFunc("GET /admin/jobs/stream", s.RequireAdmin(s.HandleJobsStream))
+	mux.HandleFunc("GET /admin/jobs/{id}", s.RequireAdmin(s.HandleJobDetail))
+	mux.HandleFunc("POST /admin/jobs/{id}/cancel", s.RequireAdmin(s.RequireCSRF(s.HandleJobCancel)))
+	mux.HandleFunc("POST /admin/tokens/revoke", s.RequireAdmin(s.RequireCSRF(s.HandleAdminTokensRevoke)))
+	mux.HandleFunc("GET /admin/users", s.RequireAdmin(s.HandleAdminUsersPage))
+	mux.HandleFunc("GET /admin/users/{id}", s.RequireAdmin(s.HandleAdminUserDetail))
+	mux.HandleFunc("POST /admin/users/{id}/action", s.RequireAdmin(s.RequireCSRF(s.HandleAdminUserAction)))
+	mux.HandleFunc("GET /admin/audit", s.RequireAdmin(s.HandleAdminAudit))
+	mux.HandleFunc("GET /admin/scheduler/jobs", s.RequireAdmin(s.HandleSchedulerStatus))
+	mux.HandleFunc("GET /debug/prefetch", s.RequireAdmin(s.HandleDebugPrefetch))
+	mux.HandleFunc("POST /admin/prefetch/rebuild", s.RequireAdmin(s.RequireCSRF(s.HandleAdminPrefetchRebuild)))
+	mux.HandleFunc("GET /debug/spatial", s.RequireAdmin(s.HandleDebugSpatial))
+	mux.HandleFunc("POST /admin/spatial/rebuild", s.RequireAdmin(s.RequireCSRF(s.HandleAdminSpatialRebuild)))
+	mux.HandleFunc("POST /admin/aggregate/run", s.RequireAdmin(s.RequireCSRF(s.HandleAdminAggregateRun)))
+
 	// API routes
-	mux.HandleFunc("GET /api/grid", s.HandleAPIGrid)
-	mux.HandleFunc("GET /api/areas", s.HandleAPIAreas)
-	mux.HandleFunc("GET /api/areas/search", s.HandleAPIAreasSearch)
-	mux.HandleFunc("GET /api/wdpa/search", s.HandleAPIWDPASearch)
-	
+	mux.HandleFunc("GET /api/grid", metrics.Instrument("api_grid", s.HandleAPIGrid))
+	mux.HandleFunc("GET /api/areas", metrics.Instrument("api_areas", s.HandleAPIAreas))
+	mux.HandleFunc("GET /api/areas/search", metrics.Instrument("api_areas_search", s.HandleAPIAreasSearch))
+	mux.HandleFunc("POST /api/areas/clip-track", metrics.Instrument("api_areas_clip_track", s.HandleAPIClipTrack))
+	mux.HandleFunc("GET /api/wdpa/search", metrics.Instrument("api_wdpa_search", s.HandleAPIWDPASearch))
+	mux.HandleFunc("GET /api/pa", metrics.Instrument("api_pa_list", s.HandleAPIPAList))
+	mux.HandleFunc("GET /api/pa/{wdpa}", metrics.Instrument("api_pa_get", s.HandleAPIPAGet))
+	mux.HandleFunc("GET /api/pa/{wdpa}/geometry", metrics.Instrument("api_pa_geometry", s.HandleAPIPAGeometry))
+	mux.HandleFunc("GET /api/pa/search", metrics.Instrument("api_pa_search", s.HandleAPIPASearch))
+	mux.HandleFunc("GET /api/places/search", metrics.Instrument("api_places_search", s.HandleAPIPlacesSearch))
+
+	// Tile / WMS endpoints
+	mux.HandleFunc("GET /tiles/effort/{z}/{x}/{y}", s.HandleTile)
+	mux.HandleFunc("GET /tiles/pa/{z}/{x}/{y}", s.HandleTilePA)
+	mux.HandleFunc("GET /tiles/fire/{z}/{x}/{y}", s.HandleTileFire)
+	mux.HandleFunc("GET /api/tiles/{layer}/{z}/{x}/{y}", metrics.Instrument("api_tile", s.HandleAPITile))
+	mux.HandleFunc("GET /wms", s.HandleWMS)
+	mux.HandleFunc("GET /wfs", s.HandleWFS)
+	mux.HandleFunc("GET /api/export/effort.nc", metrics.Instrument("api_export_effort_netcdf", s.HandleAPIExportEffortNetCDF))
+	mux.HandleFunc("GET /api/export/parks", metrics.Instrument("api_export_parks", s.HandleAPIExportParks))
+	mux.HandleFunc("GET /api/aggregate/status", metrics.Instrument("api_aggregate_status", s.HandleAPIAggregateStatus))
+
+	// Per-user KPI dashboard endpoints
+	mux.HandleFunc("GET /api/users/{id}/kpi", metrics.Instrument("api_user_kpi", s.HandleAPIUserKPI))
+	mux.HandleFunc("GET /api/kpi/leaderboard", metrics.Instrument("api_kpi_leaderboard", s.HandleAPIKPILeaderboard))
+
 	// API auth endpoints
-	mux.HandleFunc("POST /api/login", s.HandleAPILogin)
-	mux.HandleFunc("POST /api/register", s.HandleAPIRegister)
-	mux.HandleFunc("POST /api/logout", s.HandleAPILogout)
-	mux.HandleFunc("POST /api/upload", s.HandleAPIUpload)
-	mux.HandleFunc("GET /api/stats", s.HandleAPIStats)
-	mux.HandleFunc("GET /api/activity", s.HandleAPIActivity)
+	mux.HandleFunc("POST /api/login", metrics.Instrument("api_login", s.HandleAPILogin))
+	mux.HandleFunc("POST /api/register", metrics.Instrument("api_register", s.HandleAPIRegister))
+	mux.HandleFunc("POST /api/logout", metrics.Instrument("api_logout", s.HandleAPILogout))
+	mux.HandleFunc("POST /api/upload", metrics.Instrument("api_upload", s.RequireScope(tokens.ScopeUploadWrite)(s.HandleAPIUpload)))
+	mux.HandleFunc("POST /api/uploads/async", metrics.Instrument("api_upload_async", s.RequireAuth(s.HandleAPIUploadAsync)))
+	mux.HandleFunc("GET /api/uploads/jobs/{id}", metrics.Instrument("api_upload_job", s.HandleAPIUploadJob))
+	mux.HandleFunc("GET /api/uploads/jobs/{id}/stream", metrics.Instrument("api_upload_job_stream", s.HandleAPIUploadJobStream))
+	mux.HandleFunc("GET /api/uploads/jobs", metrics.Instrument("api_upload_jobs_list", s.HandleAPIUploadJobsList))
+	mux.HandleFunc("GET /api/stats", metrics.Instrument("api_stats", s.HandleAPIStats))
+	mux.HandleFunc("GET /api/activity", metrics.Instrument("api_activity", s.HandleAPIActivity))
 
 	// Fire data endpoints
-	mux.HandleFunc("GET /api/fire/chinko/daily", s.handleFireDailyData)
-	mux.HandleFunc("GET /api/fire/chinko/boundary", s.handleFireBoundary)
-	mux.HandleFunc("GET /api/fire/daily-geojson", s.handleFireDailyGeoJSON)
+	mux.HandleFunc("GET /api/fire/chinko/daily", metrics.Instrument("api_fire_daily_data", s.handleFireDailyData))
+	mux.HandleFunc("GET /api/fire/chinko/boundary", metrics.Instrument("api_fire_boundary", s.handleFireBoundary))
+	mux.HandleFunc("GET /api/fire/daily-geojson", metrics.Instrument("api_fire_daily_geojson", s.handleFireDailyGeoJSON))
 	mux.HandleFunc("GET /fire", s.handleFireAnalysis)
 	mux.HandleFunc("GET /fire/animation", s.handleFireAnimation)
-	mux.HandleFunc("GET /api/park/{id}/fire-analysis", s.handleParkFireAnalysis)
-	mux.HandleFunc("GET /api/park/{id}/boundary", s.HandleParkBoundary)
-	mux.HandleFunc("GET /api/park/{id}/roads", s.HandleParkRoads)
+	mux.HandleFunc("GET /api/park/{id}/fire-analysis", metrics.Instrument("api_park_fire_analysis", s.handleParkFireAnalysis))
+	mux.HandleFunc("GET /api/park/{id}/boundary", metrics.Instrument("api_park_boundary", s.HandleParkBoundary))
+	mux.HandleFunc("GET /api/park/{id}/roads", metrics.Instrument("api_park_roads", s.HandleParkRoads))
 	mux.HandleFunc("GET /park/{id}", s.HandleParkAnalysis)
+	mux.HandleFunc("GET /api/parks/{id}/fire-risk-forecast", metrics.Instrument("api_fire_risk_forecast", s.HandleAPIFireRiskForecast))
 
 	// Legal framework endpoints
-	mux.HandleFunc("GET /api/legal/pa/", s.HandleAPILegalByPA)
-	mux.HandleFunc("GET /api/legal/", s.HandleAPILegalByCountry)
+	mux.HandleFunc("GET /api/legal/pa/", metrics.Instrument("api_legal_by_pa", s.HandleAPILegalByPA))
+	mux.HandleFunc("GET /api/legal/", metrics.Instrument("api_legal_by_country", s.HandleAPILegalByCountry))
 
 	// Checklist endpoints
-	mux.HandleFunc("GET /api/checklist/schema", s.HandleAPIChecklistSchema)
-	mux.HandleFunc("POST /api/checklist/update", s.HandleAPIUpdateChecklistItem)
+	mux.HandleFunc("GET /api/checklist/schema", metrics.Instrument("api_checklist_schema", s.HandleAPIChecklistSchema))
+	mux.HandleFunc("POST /api/checklist/update", metrics.Instrument("api_update_checklist_item", s.RequireScope(tokens.ScopeChecklistWrite)(s.HandleAPIUpdateChecklistItem)))
 
 	// Publications endpoints (more specific routes first)
-	mux.HandleFunc("GET /api/parks/{id}/publications/count", s.HandleAPIPublicationCount)
-	mux.HandleFunc("GET /api/parks/{id}/data-status", s.HandleAPIParkDataStatus)
-	mux.HandleFunc("GET /api/parks/{id}/infractions", s.HandleAPIParkInfractionSummary)
-	mux.HandleFunc("GET /api/parks/{id}/publications", s.HandleAPIPublications)
-	mux.HandleFunc("GET /api/parks/{id}/checklist", s.HandleAPIGetParkChecklist)
-	mux.HandleFunc("GET /api/parks/{id}/stats", s.HandleAPIParkStats)
+	mux.HandleFunc("GET /api/parks/{id}/publications/count", metrics.Instrument("api_publication_count", s.HandleAPIPublicationCount))
+	mux.HandleFunc("GET /api/parks/{id}/publications/aggregates", metrics.Instrument("api_publications_aggregates", s.HandleAPIPublicationsAggregates))
+	mux.HandleFunc("GET /api/parks/{id}/data-status", metrics.Instrument("api_park_data_status", s.HandleAPIParkDataStatus))
+	mux.HandleFunc("GET /api/parks/{id}/status/stream", metrics.Instrument("api_park_status_stream", s.HandleAPIParkStatusStream))
+	mux.HandleFunc("GET /api/parks/{id}/infractions", metrics.Instrument("api_park_infraction_summary", s.HandleAPIParkInfractionSummary))
+	mux.HandleFunc("GET /api/parks/{id}/publications", metrics.Instrument("api_publications", s.HandleAPIPublications))
+	mux.HandleFunc("GET /api/parks/{id}/checklist", metrics.Instrument("api_get_park_checklist", s.HandleAPIGetParkChecklist))
+	mux.HandleFunc("GET /api/parks/{id}/stats", metrics.Instrument("api_park_stats", s.HandleAPIParkStats))
+	mux.HandleFunc("GET /api/parks/{id}/stats.csv", metrics.Instrument("api_park_stats", s.HandleAPIParkStats))
+	mux.HandleFunc("GET /api/parks/compare", metrics.Instrument("api_parks_compare", s.HandleAPIParkCompare))
+	mux.HandleFunc("GET /api/parks/{id}/fire-log", metrics.Instrument("api_park_fire_log", s.HandleAPIParkFireLog))
+	mux.HandleFunc("GET /api/parks/{id}/fire-log.csv", metrics.Instrument("api_park_fire_log", s.HandleAPIParkFireLog))
+	mux.HandleFunc("GET /api/parks/{id}/deforestation-drivers", metrics.Instrument("api_deforestation_drivers", s.HandleAPIDeforestationDrivers))
+	mux.HandleFunc("GET /api/parks/{id}/report/manifest", metrics.Instrument("api_park_report_manifest", s.HandleAPIParkReportManifest))
+	mux.HandleFunc("GET /api/parks/{id}/report", metrics.Instrument("api_park_report", s.HandleAPIParkReport))
+	mux.HandleFunc("GET /api/parks/{id}/patrols/{segID}/map.pdf", metrics.Instrument("api_patrol_segment_map_pdf", s.HandlePatrolSegmentMapPDF))
+	mux.HandleFunc("GET /api/parks/{id}/documents", metrics.Instrument("api_park_documents", s.requireDocumentRead(s.HandleAPIParkDocuments)))
+	mux.HandleFunc("POST /api/parks/{id}/documents", metrics.Instrument("api_park_document_create", s.RequirePermission(auth.PermissionEditor)(s.HandleAPIParkDocumentCreate)))
+	mux.HandleFunc("GET /api/parks/{id}/management-plans", metrics.Instrument("api_park_management_plans", s.requireDocumentRead(s.HandleAPIParkManagementPlans)))
+
+	// ActivityPub federation: one actor per protected area, publishing its
+	// document library as an outbox of Create activities.
+	mux.HandleFunc("GET /ap/parks/{id}", metrics.Instrument("ap_park_actor", s.HandleActivityPubActor))
+	mux.HandleFunc("GET /ap/parks/{id}/outbox", metrics.Instrument("ap_park_outbox", s.HandleActivityPubOutbox))
+	mux.HandleFunc("POST /ap/parks/{id}/inbox", metrics.Instrument("ap_park_inbox", s.HandleActivityPubInbox))
+
+	mux.HandleFunc("GET /parks/{id}/documents/", metrics.Instrument("park_documents_browse", s.requireDocumentRead(s.HandleParkDocumentsBrowse)))
+
+	// Park analysis job endpoints (fire analysis, group infractions,
+	// publications, GHSL, roadless) — enqueue, poll, cancel.
+	mux.HandleFunc("POST /api/parks/{id}/jobs/{source}", metrics.Instrument("api_park_job_enqueue", s.RequireAdmin(s.RequireCSRF(s.HandleAPIParkJobEnqueue))))
+	mux.HandleFunc("GET /api/jobs/{id}", metrics.Instrument("api_job_get", s.HandleAPIJobGet))
+	mux.HandleFunc("DELETE /api/jobs/{id}", metrics.Instrument("api_job_cancel", s.RequireAdmin(s.RequireCSRF(s.HandleAPIJobCancel))))
+	mux.HandleFunc("POST /api/research/sync/{pa_id}", metrics.Instrument("api_research_sync", s.RequireAdmin(s.RequireCSRF(s.HandleAPIResearchSync))))
+	mux.HandleFunc("GET /api/research/jobs", metrics.Instrument("api_research_jobs", s.RequireAdmin(s.HandleAPIResearchJobs)))
+	mux.HandleFunc("GET /api/research/jobs/{id}", metrics.Instrument("api_research_job_get", s.RequireAdmin(s.HandleAPIResearchJob)))
+
+	// Metrics
+	mux.Handle("GET /metrics", promhttp.Handler())
 
 	// Static files
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(s.StaticDir))))
-	
-	slog.Info("starting server", "addr", addr)
-	return http.ListenAndServe(addr, mux)
-}
-
 
+	return mux
+}
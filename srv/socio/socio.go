@@ -0,0 +1,171 @@
+// Package socio scores human-wildlife conflict risk and land-use
+// pressure around a protected area from gridded socioeconomic
+// indicators — GDP per capita, agricultural land fraction, road
+// density, and a derived cropland pressure index — the way srv/drivers
+// classifies a deforestation cluster's likely driver from a fitted
+// tree: indicators are precomputed offline (from user-supplied rasters
+// averaged over each park's footprint) and shipped as JSON, not
+// recomputed from raw raster data at request time.
+package socio
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Indicators are the gridded socioeconomic values for one park,
+// averaged over the park's footprint (or, once available, an
+// intersecting admin unit) from an offline raster extract.
+type Indicators struct {
+	GDPPerCapita        float64 `json:"gdp_per_capita"`
+	AgriculturalLandPct float64 `json:"agricultural_land_pct"` // fraction 0-1 of surrounding land in agricultural use
+	RoadDensityKmKm2    float64 `json:"road_density_km_per_km2"`
+	CroplandPressure    float64 `json:"cropland_pressure_index"` // 0-1, higher means more pressure from nearby cropland expansion
+}
+
+// Store maps a park's internal ID to its Indicators, loaded once at
+// startup the way areas.LoadWDPAIndex loads its built index.
+type Store struct {
+	byParkID map[string]Indicators
+}
+
+// LoadStore reads a JSON object of park_id -> Indicators from path.
+func LoadStore(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var byParkID map[string]Indicators
+	if err := json.Unmarshal(data, &byParkID); err != nil {
+		return nil, err
+	}
+	return &Store{byParkID: byParkID}, nil
+}
+
+// Get returns the Indicators for parkID, if any were loaded for it.
+func (s *Store) Get(parkID string) (Indicators, bool) {
+	if s == nil {
+		return Indicators{}, false
+	}
+	ind, ok := s.byParkID[parkID]
+	return ind, ok
+}
+
+// Weights controls how much each factor contributes to the conflict
+// index (see Score). They're loaded from a separate JSON file so an
+// operator can retune the model without recompiling.
+type Weights struct {
+	SettlementDensity float64 `json:"settlement_density"`
+	GDPPerCapita      float64 `json:"gdp_per_capita"` // inverse: lower GDP raises the index
+	CroplandPressure  float64 `json:"cropland_pressure"`
+	RoadDensity       float64 `json:"road_density"`
+}
+
+// DefaultWeights is used when no weights file is configured or it
+// fails to load, split evenly across the four factors.
+var DefaultWeights = Weights{
+	SettlementDensity: 0.35,
+	GDPPerCapita:      0.25,
+	CroplandPressure:  0.25,
+	RoadDensity:       0.15,
+}
+
+// LoadWeights reads operator-supplied factor weights from path.
+func LoadWeights(path string) (Weights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Weights{}, err
+	}
+	w := DefaultWeights
+	if err := json.Unmarshal(data, &w); err != nil {
+		return Weights{}, err
+	}
+	return w, nil
+}
+
+// Reference bounds used to normalize raw indicator values into 0-1
+// before weighting. These are rough global reference points (a
+// settlement density considered "saturated" for conflict purposes,
+// a GDP per capita below which subsistence-ag pressure is assumed
+// maximal) rather than anything fitted — tune via Weights first, these
+// only need to change if an indicator's raw units change.
+const (
+	settlementDensitySaturationPerKm2 = 50.0
+	gdpPerCapitaFloor                 = 500.0
+	gdpPerCapitaCeiling               = 20000.0
+	roadDensitySaturationKmKm2        = 2.0
+)
+
+// Breakdown is the per-factor contribution to a Score, exposed so
+// clients can render a radar chart instead of just the bucketed level.
+type Breakdown struct {
+	SettlementDensity float64 `json:"settlement_density"`
+	GDPPerCapita      float64 `json:"gdp_per_capita"`
+	CroplandPressure  float64 `json:"cropland_pressure"`
+	RoadDensity       float64 `json:"road_density"`
+	Index             float64 `json:"index"` // 0-100 weighted sum of the factors above
+	Level             string  `json:"level"` // minimal|low|moderate|high|critical
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func normalizeGDPPerCapita(gdp float64) float64 {
+	if gdp <= gdpPerCapitaFloor {
+		return 1
+	}
+	if gdp >= gdpPerCapitaCeiling {
+		return 0
+	}
+	return 1 - (gdp-gdpPerCapitaFloor)/(gdpPerCapitaCeiling-gdpPerCapitaFloor)
+}
+
+// Score combines settlement density with the gridded indicators into a
+// 0-100 conflict index. Any factor without data (zero-value Indicators,
+// e.g. no Store entry for the park) simply contributes nothing, so the
+// index degrades to the settlement-only signal assessConflictRisk used
+// before this package existed.
+func Score(settlementDensityPerKm2 float64, ind Indicators, w Weights) Breakdown {
+	b := Breakdown{
+		SettlementDensity: clamp01(settlementDensityPerKm2 / settlementDensitySaturationPerKm2),
+		GDPPerCapita:      normalizeGDPPerCapita(ind.GDPPerCapita),
+		CroplandPressure:  clamp01(ind.CroplandPressure),
+		RoadDensity:       clamp01(ind.RoadDensityKmKm2 / roadDensitySaturationKmKm2),
+	}
+	if ind.GDPPerCapita == 0 {
+		b.GDPPerCapita = 0
+	}
+
+	weightSum := w.SettlementDensity + w.GDPPerCapita + w.CroplandPressure + w.RoadDensity
+	if weightSum == 0 {
+		weightSum = 1
+	}
+	b.Index = 100 * (w.SettlementDensity*b.SettlementDensity +
+		w.GDPPerCapita*b.GDPPerCapita +
+		w.CroplandPressure*b.CroplandPressure +
+		w.RoadDensity*b.RoadDensity) / weightSum
+	b.Level = bucketIndex(b.Index)
+	return b
+}
+
+func bucketIndex(index float64) string {
+	switch {
+	case index <= 0:
+		return "minimal"
+	case index < 20:
+		return "low"
+	case index < 40:
+		return "moderate"
+	case index < 65:
+		return "high"
+	default:
+		return "critical"
+	}
+}
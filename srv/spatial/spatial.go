@@ -0,0 +1,109 @@
+// Package spatial indexes grid-cell centers and protected-area bounding
+// boxes in in-memory R-trees, so a handler that accepts a bbox query
+// parameter can narrow to candidate IDs before touching the database
+// instead of scanning every row for the requested year range.
+package spatial
+
+import (
+	"sync"
+
+	"github.com/tidwall/rtree"
+)
+
+// GridCell is one entry in the grid-cell tree: a grid_cell_id and the
+// coordinate it was indexed at (its cell center).
+type GridCell struct {
+	ID  string
+	Lat float64
+	Lon float64
+}
+
+// Area is one entry in the protected-area tree: an area ID and its
+// bounding box, as returned by areas.ProtectedArea.GetBoundingBox.
+type Area struct {
+	ID     string
+	LatMin float64
+	LatMax float64
+	LonMin float64
+	LonMax float64
+}
+
+// Index holds two independently-rebuildable R-trees: one over grid-cell
+// centers (for /api/grid and /api/stats) and one over protected-area
+// bounding boxes (for /api/areas and /api/areas/search). Load swaps a
+// tree's contents atomically, the same pointer-swap-under-a-lock
+// approach gadmRebuildJob and prefetch.Cache use elsewhere in srv, so
+// readers never see a half-built tree.
+type Index struct {
+	mu    sync.RWMutex
+	cells rtree.RTreeG[string]
+	areas rtree.RTreeG[string]
+}
+
+// New returns an empty Index. It's populated by LoadGridCells/LoadAreas,
+// typically from the spatial index rebuild job.
+func New() *Index {
+	return &Index{}
+}
+
+// LoadGridCells replaces the grid-cell tree's contents.
+func (idx *Index) LoadGridCells(cells []GridCell) {
+	var next rtree.RTreeG[string]
+	for _, c := range cells {
+		next.Insert([2]float64{c.Lon, c.Lat}, [2]float64{c.Lon, c.Lat}, c.ID)
+	}
+	idx.mu.Lock()
+	idx.cells = next
+	idx.mu.Unlock()
+}
+
+// LoadAreas replaces the protected-area tree's contents.
+func (idx *Index) LoadAreas(areas []Area) {
+	var next rtree.RTreeG[string]
+	for _, a := range areas {
+		next.Insert([2]float64{a.LonMin, a.LatMin}, [2]float64{a.LonMax, a.LatMax}, a.ID)
+	}
+	idx.mu.Lock()
+	idx.areas = next
+	idx.mu.Unlock()
+}
+
+// QueryGridCells returns the IDs of grid cells whose center falls
+// within [minLon,minLat]-[maxLon,maxLat].
+func (idx *Index) QueryGridCells(minLon, minLat, maxLon, maxLat float64) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var ids []string
+	idx.cells.Search([2]float64{minLon, minLat}, [2]float64{maxLon, maxLat}, func(_, _ [2]float64, id string) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids
+}
+
+// QueryAreas returns the IDs of protected areas whose bounding box
+// intersects [minLon,minLat]-[maxLon,maxLat].
+func (idx *Index) QueryAreas(minLon, minLat, maxLon, maxLat float64) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var ids []string
+	idx.areas.Search([2]float64{minLon, minLat}, [2]float64{maxLon, maxLat}, func(_, _ [2]float64, id string) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids
+}
+
+// GridCellCount and AreaCount report each tree's current size, for the
+// spatial index's debug introspection endpoint.
+func (idx *Index) GridCellCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.cells.Len()
+}
+
+func (idx *Index) AreaCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.areas.Len()
+}
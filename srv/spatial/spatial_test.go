@@ -0,0 +1,90 @@
+package spatial
+
+import (
+	"fmt"
+	"testing"
+)
+
+func seedGridCells(n int) []GridCell {
+	cells := make([]GridCell, 0, n)
+	side := 1
+	for side*side < n {
+		side++
+	}
+	for i := 0; i < side; i++ {
+		for j := 0; j < side; j++ {
+			if len(cells) >= n {
+				break
+			}
+			cells = append(cells, GridCell{
+				ID:  fmt.Sprintf("%d_%d", i, j),
+				Lat: -10 + float64(i)*0.1,
+				Lon: 20 + float64(j)*0.1,
+			})
+		}
+	}
+	return cells
+}
+
+func TestQueryGridCells(t *testing.T) {
+	idx := New()
+	idx.LoadGridCells(seedGridCells(2500)) // ~50x50 degree grid at 0.1deg resolution
+
+	// City-level viewport: a few hundredths of a degree, a handful of cells.
+	ids := idx.QueryGridCells(20.0, -10.0, 20.3, -9.7)
+	if len(ids) == 0 {
+		t.Fatal("expected at least one grid cell in viewport, got none")
+	}
+	if len(ids) > 16 {
+		t.Errorf("expected a small viewport to match a handful of cells, got %d", len(ids))
+	}
+
+	if got := idx.GridCellCount(); got != 2500 {
+		t.Errorf("GridCellCount() = %d, want 2500", got)
+	}
+}
+
+func TestQueryAreas(t *testing.T) {
+	idx := New()
+	idx.LoadAreas([]Area{
+		{ID: "pa-1", LatMin: -2, LatMax: -1, LonMin: 34, LonMax: 35},
+		{ID: "pa-2", LatMin: 10, LatMax: 11, LonMin: 50, LonMax: 51},
+	})
+
+	ids := idx.QueryAreas(33, -3, 36, 0)
+	if len(ids) != 1 || ids[0] != "pa-1" {
+		t.Errorf("QueryAreas() = %v, want [pa-1]", ids)
+	}
+}
+
+// BenchmarkQueryGridCells_CityViewport times a city-scale bbox query
+// against the R-tree, for comparison with the full-dataset scan every
+// year-range loop in HandleAPIGrid/HandleAPIStats used to do.
+func BenchmarkQueryGridCells_CityViewport(b *testing.B) {
+	idx := New()
+	idx.LoadGridCells(seedGridCells(250000)) // continent-scale dataset
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.QueryGridCells(20.0, -10.0, 20.3, -9.7)
+	}
+}
+
+// BenchmarkScanAllCells_FullDataset times what the pre-bbox code path
+// did unconditionally: iterate every indexed cell regardless of
+// viewport. The gap between this and BenchmarkQueryGridCells_CityViewport
+// is the saving a real city-level bbox request gets from the R-tree.
+func BenchmarkScanAllCells_FullDataset(b *testing.B) {
+	cells := seedGridCells(250000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var matched int
+		for _, c := range cells {
+			if c.Lon >= 20.0 && c.Lon <= 20.3 && c.Lat >= -10.0 && c.Lat <= -9.7 {
+				matched++
+			}
+		}
+		_ = matched
+	}
+}
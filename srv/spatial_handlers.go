@@ -0,0 +1,66 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/spatial"
+)
+
+// bboxMaxCandidates bounds how many grid cell IDs a bbox query is
+// allowed to narrow to before a handler gives up on the IN (...) filter
+// and falls back to its unfiltered year-scan path. A viewport this wide
+// wouldn't save anything over scanning by year, and a multi-thousand-
+// value IN clause is its own liability.
+const bboxMaxCandidates = 2000
+
+// RebuildSpatialIndex reloads s.SpatialIndex's grid-cell tree from the
+// distinct grid cells with recorded effort data, and its area tree from
+// s.AreaStore's currently-loaded protected areas.
+func (s *Server) RebuildSpatialIndex(ctx context.Context) error {
+	q := dbgen.New(s.DB)
+
+	rows, err := q.ListDistinctGridCells(ctx)
+	if err != nil {
+		return err
+	}
+	cells := make([]spatial.GridCell, 0, len(rows))
+	for _, row := range rows {
+		cells = append(cells, spatial.GridCell{ID: row.GridCellID, Lat: row.LatCenter, Lon: row.LonCenter})
+	}
+	s.SpatialIndex.LoadGridCells(cells)
+
+	if s.AreaStore != nil {
+		areaList := make([]spatial.Area, 0, len(s.AreaStore.Areas))
+		for _, area := range s.AreaStore.Areas {
+			latMin, latMax, lonMin, lonMax := area.GetBoundingBox()
+			areaList = append(areaList, spatial.Area{ID: area.ID, LatMin: latMin, LatMax: latMax, LonMin: lonMin, LonMax: lonMax})
+		}
+		s.SpatialIndex.LoadAreas(areaList)
+	}
+
+	return nil
+}
+
+// HandleDebugSpatial reports how many grid cells and areas are
+// currently indexed, for checking whether the rebuild job is keeping up.
+func (s *Server) HandleDebugSpatial(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{
+		"grid_cells": s.SpatialIndex.GridCellCount(),
+		"areas":      s.SpatialIndex.AreaCount(),
+	})
+}
+
+// HandleAdminSpatialRebuild triggers an immediate spatial index rebuild,
+// so an operator doesn't have to wait for the next scheduled cycle
+// after a data import.
+func (s *Server) HandleAdminSpatialRebuild(w http.ResponseWriter, r *http.Request) {
+	if err := s.RebuildSpatialIndex(r.Context()); err != nil {
+		http.Error(w, "rebuild failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
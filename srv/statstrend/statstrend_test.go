@@ -0,0 +1,123 @@
+package statstrend
+
+import (
+	"math"
+	"testing"
+)
+
+func series(years []int, values []float64) []Observation {
+	obs := make([]Observation, len(years))
+	for i := range years {
+		obs[i] = Observation{Year: years[i], Value: values[i]}
+	}
+	return obs
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	trend := Analyze(nil, true, DefaultAlpha)
+	if trend.Direction != "insufficient" {
+		t.Errorf("Direction = %q, want insufficient", trend.Direction)
+	}
+	if trend.PValue != 1 {
+		t.Errorf("PValue = %v, want 1", trend.PValue)
+	}
+}
+
+func TestAnalyzeFewerThanFourObservations(t *testing.T) {
+	obs := series([]int{2020, 2021, 2022}, []float64{1, 2, 3})
+	trend := Analyze(obs, true, DefaultAlpha)
+	if trend.Direction != "insufficient" {
+		t.Errorf("Direction = %q, want insufficient with n=3", trend.Direction)
+	}
+	// SenSlope is still computed once n>=2, even though Direction stays
+	// "insufficient" for the Mann-Kendall call.
+	if trend.SenSlope != 1 {
+		t.Errorf("SenSlope = %v, want 1", trend.SenSlope)
+	}
+}
+
+func TestAnalyzeMonotonicIncreasingHigherIsWorse(t *testing.T) {
+	obs := series([]int{2018, 2019, 2020, 2021, 2022}, []float64{10, 20, 30, 40, 50})
+	trend := Analyze(obs, true, DefaultAlpha)
+
+	if trend.Direction != "worsening" {
+		t.Errorf("Direction = %q, want worsening", trend.Direction)
+	}
+	if trend.PValue >= DefaultAlpha {
+		t.Errorf("PValue = %v, want < %v for a strictly monotonic series", trend.PValue, DefaultAlpha)
+	}
+	if trend.SenSlope != 10 {
+		t.Errorf("SenSlope = %v, want 10", trend.SenSlope)
+	}
+}
+
+func TestAnalyzeMonotonicIncreasingHigherIsBetter(t *testing.T) {
+	obs := series([]int{2018, 2019, 2020, 2021, 2022}, []float64{10, 20, 30, 40, 50})
+	trend := Analyze(obs, false, DefaultAlpha)
+
+	if trend.Direction != "improving" {
+		t.Errorf("Direction = %q, want improving", trend.Direction)
+	}
+}
+
+func TestAnalyzeMonotonicDecreasing(t *testing.T) {
+	obs := series([]int{2018, 2019, 2020, 2021, 2022}, []float64{50, 40, 30, 20, 10})
+	trend := Analyze(obs, true, DefaultAlpha)
+
+	if trend.Direction != "improving" {
+		t.Errorf("Direction = %q, want improving for a falling series with higherIsWorse", trend.Direction)
+	}
+	if trend.SenSlope != -10 {
+		t.Errorf("SenSlope = %v, want -10", trend.SenSlope)
+	}
+}
+
+func TestAnalyzeFlatSeriesIsStableOrInsufficient(t *testing.T) {
+	// Every value tied means Mann-Kendall's S statistic is exactly 0 and
+	// the tie-correction term cancels the whole variance to 0, so Analyze
+	// bails out before computing a Z/p-value rather than dividing by zero.
+	obs := series([]int{2018, 2019, 2020, 2021, 2022}, []float64{5, 5, 5, 5, 5})
+	trend := Analyze(obs, true, DefaultAlpha)
+
+	if trend.Direction == "worsening" || trend.Direction == "improving" {
+		t.Errorf("Direction = %q, want stable/insufficient for a flat series", trend.Direction)
+	}
+	if trend.SenSlope != 0 {
+		t.Errorf("SenSlope = %v, want 0 for a flat series", trend.SenSlope)
+	}
+}
+
+func TestAnalyzeNoisySeriesWithTiesStaysStable(t *testing.T) {
+	// Values bounce around with no consistent direction and several ties;
+	// should not be reported as a significant trend either way.
+	obs := series(
+		[]int{2015, 2016, 2017, 2018, 2019, 2020},
+		[]float64{10, 12, 10, 11, 10, 12},
+	)
+	trend := Analyze(obs, true, DefaultAlpha)
+
+	if trend.Direction == "worsening" || trend.Direction == "improving" {
+		t.Errorf("Direction = %q, want stable for a series with no consistent trend, p=%v", trend.Direction, trend.PValue)
+	}
+}
+
+func TestAnalyzeWorstYearAndPercentile(t *testing.T) {
+	obs := series([]int{2019, 2020, 2021, 2022}, []float64{1, 4, 2, 3})
+	trend := Analyze(obs, true, DefaultAlpha)
+
+	if trend.WorstYear != 2020 || trend.WorstValue != 4 {
+		t.Errorf("worst = (%d, %v), want (2020, 4)", trend.WorstYear, trend.WorstValue)
+	}
+	if trend.WorstYearPercentile != 100 {
+		t.Errorf("WorstYearPercentile = %v, want 100 for the series maximum", trend.WorstYearPercentile)
+	}
+}
+
+func TestStdNormalCDFKnownValues(t *testing.T) {
+	if math.Abs(stdNormalCDF(0)-0.5) > 1e-9 {
+		t.Errorf("stdNormalCDF(0) = %v, want 0.5", stdNormalCDF(0))
+	}
+	if got := stdNormalCDF(1.96); math.Abs(got-0.975) > 1e-3 {
+		t.Errorf("stdNormalCDF(1.96) = %v, want ~0.975", got)
+	}
+}
@@ -0,0 +1,430 @@
+package srv
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/tiles"
+)
+
+// tileCacheCapacity bounds how many encoded tiles HandleTilePA and
+// HandleTileFire keep warm; at the default 4096-point MVT extent each
+// entry is small, so this trades a modest amount of memory for not
+// re-encoding a tile a map pans back over.
+const tileCacheCapacity = 2048
+
+// HandleTilePA serves the "pa" MVT layer from s.PAStore.
+// Route: GET /tiles/pa/{z}/{x}/{y}
+func (s *Server) HandleTilePA(w http.ResponseWriter, r *http.Request) {
+	z, x, y, ok := parseTileCoords(w, r)
+	if !ok {
+		return
+	}
+
+	key := tiles.Key{Layer: "pa", Z: uint32(z), X: uint32(x), Y: uint32(y), Version: s.PAStore.Version()}
+	if data, hit := s.TileCache.Get(key); hit {
+		writeTile(w, data)
+		return
+	}
+
+	rows, err := s.PAStore.List(r.Context(), "", "")
+	if err != nil {
+		http.Error(w, "failed to list protected areas", http.StatusInternalServerError)
+		return
+	}
+
+	bbox := tileToBBox(z, x, y)
+	tileBound := orb.Bound{Min: orb.Point{bbox.MinLon, bbox.MinLat}, Max: orb.Point{bbox.MaxLon, bbox.MaxLat}}
+
+	features := make([]tiles.PAFeature, 0, len(rows))
+	for _, row := range rows {
+		if len(row.GeomGeoJSON) == 0 {
+			continue
+		}
+		geom, err := geojson.UnmarshalGeometry(row.GeomGeoJSON)
+		if err != nil {
+			continue
+		}
+		if !geom.Geometry().Bound().Intersects(tileBound) {
+			continue
+		}
+		features = append(features, tiles.PAFeature{
+			WDPAID:   row.WDPAID,
+			Name:     row.Name,
+			IUCN:     row.IUCN,
+			AreaKm2:  row.AreaKm2,
+			Geometry: geom.Geometry(),
+		})
+	}
+
+	data, err := tiles.EncodePATile(features, uint32(z), uint32(x), uint32(y))
+	if err != nil {
+		http.Error(w, "failed to encode tile", http.StatusInternalServerError)
+		return
+	}
+	s.TileCache.Put(key, data)
+	writeTile(w, data)
+}
+
+// HandleTileFire serves the "fire" MVT layer from the fire daily
+// GeoJSON blob kept warm in s.FireCache.
+// Route: GET /tiles/fire/{z}/{x}/{y}
+func (s *Server) HandleTileFire(w http.ResponseWriter, r *http.Request) {
+	z, x, y, ok := parseTileCoords(w, r)
+	if !ok {
+		return
+	}
+
+	key := tiles.Key{Layer: "fire", Z: uint32(z), X: uint32(x), Y: uint32(y), Version: s.FireCache.Version()}
+	if data, hit := s.TileCache.Get(key); hit {
+		writeTile(w, data)
+		return
+	}
+
+	raw, err := s.FireCache.Get(fireDailyGeoJSONPath)
+	if err != nil {
+		http.Error(w, "fire data not found", http.StatusNotFound)
+		return
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(raw)
+	if err != nil {
+		http.Error(w, "failed to parse fire data", http.StatusInternalServerError)
+		return
+	}
+
+	bbox := tileToBBox(z, x, y)
+	tileBound := orb.Bound{Min: orb.Point{bbox.MinLon, bbox.MinLat}, Max: orb.Point{bbox.MaxLon, bbox.MaxLat}}
+
+	clipped := geojson.NewFeatureCollection()
+	for _, feat := range fc.Features {
+		if feat.Geometry != nil && feat.Geometry.Bound().Intersects(tileBound) {
+			clipped.Append(feat)
+		}
+	}
+
+	data, err := tiles.EncodeFireTile(clipped, uint32(z), uint32(x), uint32(y))
+	if err != nil {
+		http.Error(w, "failed to encode tile", http.StatusInternalServerError)
+		return
+	}
+	s.TileCache.Put(key, data)
+	writeTile(w, data)
+}
+
+// parseTileCoords extracts z/x/y from the request path, accepting an
+// optional ".mvt" suffix on y (e.g. "/tiles/pa/4/8/6.mvt"), matching
+// the "%d.ext" convention HandleTile already uses for PNG tiles.
+func parseTileCoords(w http.ResponseWriter, r *http.Request) (z, x, y int, ok bool) {
+	z, errZ := strconv.Atoi(r.PathValue("z"))
+	x, errX := strconv.Atoi(r.PathValue("x"))
+	if _, err := fmt.Sscanf(r.PathValue("y"), "%d.mvt", &y); err != nil {
+		if _, err := fmt.Sscanf(r.PathValue("y"), "%d", &y); err != nil {
+			http.Error(w, "invalid tile y coordinate", http.StatusBadRequest)
+			return 0, 0, 0, false
+		}
+	}
+	if errZ != nil || errX != nil {
+		http.Error(w, "invalid tile coordinates", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return z, x, y, true
+}
+
+func writeTile(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Write(data)
+}
+
+// HandleAPITile serves MVT-encoded tiles for the grid, areas, fires,
+// and deforestation layers from a single bbox-scoped route, unlike
+// HandleTilePA/HandleTileFire (which stay as-is for existing callers
+// and always return the "pa"/"fire" layers in full). Route:
+// GET /api/tiles/{layer}/{z}/{x}/{y}.mvt[.gz]
+//
+// An explicit ".mvt.gz" suffix always gzips the response; otherwise
+// Content-Encoding follows the request's Accept-Encoding header, same
+// as HandleAPIExportParks.
+func (s *Server) HandleAPITile(w http.ResponseWriter, r *http.Request) {
+	layer := r.PathValue("layer")
+	z, x, y, forceGzip, ok := parseAPITileCoords(w, r)
+	if !ok {
+		return
+	}
+
+	var data []byte
+	var err error
+	switch layer {
+	case "grid":
+		data, err = s.encodeGridTile(r.Context(), z, x, y)
+	case "areas":
+		data, err = s.encodeAreasTile(r.Context(), z, x, y)
+	case "fires":
+		data, err = s.encodeFiresTile(z, x, y)
+	case "deforestation":
+		data, err = s.encodeDeforestationTile(r.Context(), z, x, y)
+	default:
+		http.Error(w, fmt.Sprintf("unknown tile layer %q (want grid, areas, fires, or deforestation)", layer), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode tile: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeAPITile(w, r, data, forceGzip)
+}
+
+// dataVersionBucket buckets time into 5-minute windows, used as the
+// tiles.Key.Version for layers (grid, deforestation) that read
+// straight from SQL tables with no in-memory store to ask for a
+// version number — it gives cached tiles for those layers a 5-minute
+// effective TTL instead of caching stale data forever.
+func dataVersionBucket() int64 {
+	return time.Now().Unix() / 300
+}
+
+// encodeGridTile renders the "grid" layer for the current year,
+// looking up each 0.1° cell the tile overlaps individually (same
+// per-cell query HandleTile already uses for the PNG raster tiles)
+// instead of scanning the whole effort_data table.
+func (s *Server) encodeGridTile(ctx context.Context, z, x, y uint32) ([]byte, error) {
+	key := tiles.Key{Layer: "grid", Z: z, X: x, Y: y, Version: dataVersionBucket()}
+	if data, hit := s.TileCache.Get(key); hit {
+		return data, nil
+	}
+
+	bbox := tileToBBox(int(z), int(x), int(y))
+	q := dbgen.New(s.DB)
+	year := int64(time.Now().Year())
+
+	var features []tiles.GridFeature
+	for _, cell := range gridCellBBoxes(bbox) {
+		lat, lon := (cell.MinLat+cell.MaxLat)/2, (cell.MinLon+cell.MaxLon)/2
+		cellID := gridCellIDForPoint(lat, lon)
+		row, err := q.GetEffortDataForCellWithMonthCounts(ctx, dbgen.GetEffortDataForCellWithMonthCountsParams{
+			GridCellID: cellID,
+			Year:       year,
+			Year_2:     year,
+		})
+		if err != nil {
+			continue
+		}
+		intensity := (float64(row.DryMonths) + float64(row.RainyMonths)*0.3) / 6.0
+		if intensity > 1.5 {
+			intensity = 1.5
+		}
+		features = append(features, tiles.GridFeature{
+			GridCellID:  cellID,
+			Lat:         lat,
+			Lon:         lon,
+			Intensity:   intensity,
+			DryMonths:   row.DryMonths,
+			RainyMonths: row.RainyMonths,
+		})
+	}
+
+	data, err := tiles.EncodeGridTile(features, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	s.TileCache.Put(key, data)
+	return data, nil
+}
+
+// encodeAreasTile renders the "areas" layer — the bbox-scoped sibling
+// of HandleTilePA, which always serves the whole PAStore.
+func (s *Server) encodeAreasTile(ctx context.Context, z, x, y uint32) ([]byte, error) {
+	key := tiles.Key{Layer: "areas", Z: z, X: x, Y: y, Version: s.PAStore.Version()}
+	if data, hit := s.TileCache.Get(key); hit {
+		return data, nil
+	}
+
+	rows, err := s.PAStore.List(ctx, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("list protected areas: %w", err)
+	}
+
+	bbox := tileToBBox(int(z), int(x), int(y))
+	tileBound := orb.Bound{Min: orb.Point{bbox.MinLon, bbox.MinLat}, Max: orb.Point{bbox.MaxLon, bbox.MaxLat}}
+
+	features := make([]tiles.PAFeature, 0, len(rows))
+	for _, row := range rows {
+		if len(row.GeomGeoJSON) == 0 {
+			continue
+		}
+		geom, err := geojson.UnmarshalGeometry(row.GeomGeoJSON)
+		if err != nil {
+			continue
+		}
+		if !geom.Geometry().Bound().Intersects(tileBound) {
+			continue
+		}
+		features = append(features, tiles.PAFeature{
+			WDPAID:   row.WDPAID,
+			Name:     row.Name,
+			IUCN:     row.IUCN,
+			AreaKm2:  row.AreaKm2,
+			Geometry: geom.Geometry(),
+		})
+	}
+
+	data, err := tiles.EncodePATile(features, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	s.TileCache.Put(key, data)
+	return data, nil
+}
+
+// encodeFiresTile renders the "fires" layer — the bbox-scoped sibling
+// of HandleTileFire, which always serves the whole fire daily blob.
+func (s *Server) encodeFiresTile(z, x, y uint32) ([]byte, error) {
+	key := tiles.Key{Layer: "fires", Z: z, X: x, Y: y, Version: s.FireCache.Version()}
+	if data, hit := s.TileCache.Get(key); hit {
+		return data, nil
+	}
+
+	raw, err := s.FireCache.Get(fireDailyGeoJSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("fire data not found: %w", err)
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse fire data: %w", err)
+	}
+
+	bbox := tileToBBox(int(z), int(x), int(y))
+	tileBound := orb.Bound{Min: orb.Point{bbox.MinLon, bbox.MinLat}, Max: orb.Point{bbox.MaxLon, bbox.MaxLat}}
+
+	clipped := geojson.NewFeatureCollection()
+	for _, feat := range fc.Features {
+		if feat.Geometry != nil && feat.Geometry.Bound().Intersects(tileBound) {
+			clipped.Append(feat)
+		}
+	}
+
+	data, err := tiles.EncodeFireTile(clipped, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	s.TileCache.Put(key, data)
+	return data, nil
+}
+
+// encodeDeforestationTile renders the "deforestation" layer for the
+// current year, pushing the tile's bbox straight into the SQL WHERE
+// clause (deforestation_events stores per-event lat/lon, unlike the
+// polygon-only sources the other three layers read from).
+func (s *Server) encodeDeforestationTile(ctx context.Context, z, x, y uint32) ([]byte, error) {
+	key := tiles.Key{Layer: "deforestation", Z: z, X: x, Y: y, Version: dataVersionBucket()}
+	if data, hit := s.TileCache.Get(key); hit {
+		return data, nil
+	}
+
+	bbox := tileToBBox(int(z), int(x), int(y))
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT park_id, area_km2, lat, lon
+		FROM deforestation_events
+		WHERE year = ? AND lat BETWEEN ? AND ? AND lon BETWEEN ? AND ?
+	`, time.Now().Year(), bbox.MinLat, bbox.MaxLat, bbox.MinLon, bbox.MaxLon)
+	if err != nil {
+		return nil, fmt.Errorf("query deforestation events: %w", err)
+	}
+	defer rows.Close()
+
+	var features []tiles.DeforestationFeature
+	for rows.Next() {
+		var parkID string
+		var areaKm2, lat, lon float64
+		if err := rows.Scan(&parkID, &areaKm2, &lat, &lon); err != nil {
+			continue
+		}
+		features = append(features, tiles.DeforestationFeature{
+			ParkID:   parkID,
+			AreaKm2:  areaKm2,
+			Geometry: orb.Point{lon, lat},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := tiles.EncodeDeforestationTile(features, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	s.TileCache.Put(key, data)
+	return data, nil
+}
+
+// parseAPITileCoords extracts layer/z/x/y from the request path,
+// accepting ".mvt" or ".mvt.gz" on y; the latter also reports
+// forceGzip so the caller gzips the response unconditionally instead
+// of negotiating off Accept-Encoding.
+func parseAPITileCoords(w http.ResponseWriter, r *http.Request) (z, x, y uint32, forceGzip bool, ok bool) {
+	zi, errZ := strconv.Atoi(r.PathValue("z"))
+	xi, errX := strconv.Atoi(r.PathValue("x"))
+
+	yRaw := r.PathValue("y")
+	var yi int
+	var errY error
+	switch {
+	case strings.HasSuffix(yRaw, ".mvt.gz"):
+		forceGzip = true
+		yi, errY = strconv.Atoi(strings.TrimSuffix(yRaw, ".mvt.gz"))
+	case strings.HasSuffix(yRaw, ".mvt"):
+		yi, errY = strconv.Atoi(strings.TrimSuffix(yRaw, ".mvt"))
+	default:
+		yi, errY = strconv.Atoi(yRaw)
+	}
+
+	if errZ != nil || errX != nil || errY != nil {
+		http.Error(w, "invalid tile coordinates", http.StatusBadRequest)
+		return 0, 0, 0, false, false
+	}
+	return uint32(zi), uint32(xi), uint32(yi), forceGzip, true
+}
+
+// writeAPITile sets an ETag derived from the tile's own bytes (so
+// callers get a free 304 without this package tracking a version per
+// layer), a Cache-Control, and the negotiated or forced
+// Content-Encoding, then writes data.
+func writeAPITile(w http.ResponseWriter, r *http.Request, data []byte, forceGzip bool) {
+	sum := fnv.New64a()
+	sum.Write(data)
+	etag := fmt.Sprintf(`"%x"`, sum.Sum64())
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	if forceGzip {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+		gw.Write(data)
+		return
+	}
+	if encoding, enc := negotiateEncoding(r, w); enc != nil {
+		w.Header().Set("Content-Encoding", encoding)
+		defer enc.Close()
+		enc.Write(data)
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,259 @@
+package srv
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// tileSize is the pixel dimension of a single effort tile (OSM/Google convention).
+const tileSize = 256
+
+// BBox is a geographic bounding box in degrees (minLon, minLat, maxLon, maxLat).
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// WKT renders the bounding box as a WKT POLYGON string.
+func (b BBox) WKT() string {
+	return fmt.Sprintf("POLYGON((%f %f, %f %f, %f %f, %f %f, %f %f))",
+		b.MinLon, b.MinLat, b.MaxLon, b.MinLat, b.MaxLon, b.MaxLat,
+		b.MinLon, b.MaxLat, b.MinLon, b.MinLat)
+}
+
+// tileToBBox converts a Web Mercator z/x/y tile coordinate into a lon/lat BBox (EPSG:4326).
+func tileToBBox(z, x, y int) BBox {
+	n := math.Exp2(float64(z))
+	lonLeft := float64(x)/n*360.0 - 180.0
+	lonRight := float64(x+1)/n*360.0 - 180.0
+	latTop := mercatorTileLat(y, n)
+	latBottom := mercatorTileLat(y+1, n)
+	return BBox{MinLon: lonLeft, MinLat: latBottom, MaxLon: lonRight, MaxLat: latTop}
+}
+
+func mercatorTileLat(y int, n float64) float64 {
+	yFrac := math.Pi * (1 - 2*float64(y)/n)
+	return 180.0 / math.Pi * math.Atan(math.Sinh(yFrac))
+}
+
+// gridCellBBoxes splits bbox into the existing 0.1° grid cells it overlaps,
+// so a single tile request never has to scan the whole effort_data table.
+func gridCellBBoxes(bbox BBox) []BBox {
+	var cells []BBox
+	latStart := math.Floor(bbox.MinLat/gridCellSize) * gridCellSize
+	lonStart := math.Floor(bbox.MinLon/gridCellSize) * gridCellSize
+	for lat := latStart; lat < bbox.MaxLat; lat += gridCellSize {
+		for lon := lonStart; lon < bbox.MaxLon; lon += gridCellSize {
+			cells = append(cells, BBox{MinLon: lon, MinLat: lat, MaxLon: lon + gridCellSize, MaxLat: lat + gridCellSize})
+		}
+	}
+	return cells
+}
+
+// HandleTile rasterizes the effort grid into a 256x256 PNG tile.
+// Routes: GET /tiles/effort/{z}/{x}/{y}.png
+// Query params: movement_type, year, month, user (all optional filters).
+func (s *Server) HandleTile(w http.ResponseWriter, r *http.Request) {
+	z, errZ := strconv.Atoi(r.PathValue("z"))
+	x, errX := strconv.Atoi(r.PathValue("x"))
+	var yInt int
+	if _, err := fmt.Sscanf(r.PathValue("y"), "%d.png", &yInt); err != nil {
+		http.Error(w, "invalid tile y coordinate", http.StatusBadRequest)
+		return
+	}
+	if errZ != nil || errX != nil {
+		http.Error(w, "invalid tile coordinates", http.StatusBadRequest)
+		return
+	}
+
+	bbox := tileToBBox(z, x, yInt)
+	movementType := r.URL.Query().Get("movement_type")
+	if movementType == "" {
+		movementType = "all"
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+
+	ctx := r.Context()
+	q := dbgen.New(s.DB)
+
+	for _, cell := range gridCellBBoxes(bbox) {
+		cellID := gridCellIDForPoint((cell.MinLat+cell.MaxLat)/2, (cell.MinLon+cell.MaxLon)/2)
+		row, err := q.GetEffortDataForCell(ctx, dbgen.GetEffortDataForCellParams{
+			GridCellID:   cellID,
+			MovementType: movementType,
+		})
+		if err != nil {
+			continue
+		}
+		intensity := row.TotalDistanceKm / 80.0
+		if intensity > 1.5 {
+			intensity = 1.5
+		}
+		paintCell(img, bbox, cell, intensity)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		http.Error(w, "failed to encode tile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(buf.Bytes())
+}
+
+// paintCell fills the pixels of img corresponding to cell (within the tile's
+// overall bbox) with a color whose alpha reflects intensity.
+func paintCell(img *image.RGBA, tileBBox, cell BBox, intensity float64) {
+	alpha := uint8(math.Min(intensity, 1.0) * 200)
+	col := color.RGBA{R: 239, G: 68, B: 68, A: alpha}
+
+	toPx := func(lon, lat float64) (int, int) {
+		px := int((lon - tileBBox.MinLon) / (tileBBox.MaxLon - tileBBox.MinLon) * tileSize)
+		py := int((tileBBox.MaxLat - lat) / (tileBBox.MaxLat - tileBBox.MinLat) * tileSize)
+		return px, py
+	}
+
+	x0, y0 := toPx(cell.MinLon, cell.MaxLat)
+	x1, y1 := toPx(cell.MaxLon, cell.MinLat)
+	for px := x0; px < x1; px++ {
+		for py := y0; py < y1; py++ {
+			if px >= 0 && px < tileSize && py >= 0 && py < tileSize {
+				img.Set(px, py, col)
+			}
+		}
+	}
+}
+
+// HandleWMS implements a minimal OGC WMS GetMap/GetCapabilities interface
+// on top of HandleTile so standard clients (QGIS, Leaflet.WMS) can consume
+// the effort grid without knowing about the z/x/y tile scheme.
+// Route: GET /wms?SERVICE=WMS&REQUEST=GetMap|GetCapabilities&BBOX=...&CRS=...
+func (s *Server) HandleWMS(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	request := q.Get("REQUEST")
+	if request == "" {
+		request = q.Get("request")
+	}
+
+	switch request {
+	case "GetCapabilities":
+		s.handleWMSGetCapabilities(w, r)
+	case "GetMap":
+		s.handleWMSGetMap(w, r)
+	default:
+		http.Error(w, "unsupported WMS request", http.StatusBadRequest)
+	}
+}
+
+func (s *Server) handleWMSGetMap(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	bboxStr := firstNonEmpty(q.Get("BBOX"), q.Get("bbox"))
+	crs := firstNonEmpty(q.Get("CRS"), q.Get("SRS"), "EPSG:4326")
+
+	var minX, minY, maxX, maxY float64
+	if _, err := fmt.Sscanf(bboxStr, "%f,%f,%f,%f", &minX, &minY, &maxX, &maxY); err != nil {
+		http.Error(w, "invalid BBOX parameter", http.StatusBadRequest)
+		return
+	}
+
+	bbox := BBox{MinLon: minX, MinLat: minY, MaxLon: maxX, MaxLat: maxY}
+	if crs == "EPSG:3857" {
+		bbox = webMercatorToLonLat(bbox)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	ctx := r.Context()
+	dbQ := dbgen.New(s.DB)
+	movementType := firstNonEmpty(q.Get("movement_type"), "all")
+
+	for _, cell := range gridCellBBoxes(bbox) {
+		cellID := gridCellIDForPoint((cell.MinLat+cell.MaxLat)/2, (cell.MinLon+cell.MaxLon)/2)
+		row, err := dbQ.GetEffortDataForCell(ctx, dbgen.GetEffortDataForCellParams{
+			GridCellID:   cellID,
+			MovementType: movementType,
+		})
+		if err != nil {
+			continue
+		}
+		intensity := row.TotalDistanceKm / 80.0
+		paintCell(img, bbox, cell, intensity)
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+// webMercatorToLonLat converts an EPSG:3857 bbox to EPSG:4326 degrees.
+func webMercatorToLonLat(b BBox) BBox {
+	const earthRadius = 6378137.0
+	toLonLat := func(x, y float64) (float64, float64) {
+		lon := x / earthRadius * 180.0 / math.Pi
+		lat := (2*math.Atan(math.Exp(y/earthRadius)) - math.Pi/2) * 180.0 / math.Pi
+		return lon, lat
+	}
+	minLon, minLat := toLonLat(b.MinLon, b.MinLat)
+	maxLon, maxLat := toLonLat(b.MaxLon, b.MaxLat)
+	return BBox{MinLon: minLon, MinLat: minLat, MaxLon: maxLon, MaxLat: maxLat}
+}
+
+// wmsCapabilities is a minimal GetCapabilities XML document listing the
+// layers this server exposes.
+type wmsCapabilities struct {
+	XMLName xml.Name      `xml:"WMT_MS_Capabilities"`
+	Version string        `xml:"version,attr"`
+	Service wmsService    `xml:"Service"`
+	Layers  []wmsLayer    `xml:"Capability>Layer>Layer"`
+}
+
+type wmsService struct {
+	Name  string `xml:"Name"`
+	Title string `xml:"Title"`
+}
+
+type wmsLayer struct {
+	Name  string `xml:"Name"`
+	Title string `xml:"Title"`
+	CRS   []string `xml:"CRS"`
+}
+
+func (s *Server) handleWMSGetCapabilities(w http.ResponseWriter, r *http.Request) {
+	caps := wmsCapabilities{
+		Version: "1.3.0",
+		Service: wmsService{Name: "WMS", Title: "5MP Effort Grid"},
+		Layers: []wmsLayer{
+			{Name: "effort_all", Title: "Patrol effort (all movement types)", CRS: []string{"EPSG:4326", "EPSG:3857"}},
+			{Name: "effort_foot", Title: "Patrol effort (foot)", CRS: []string{"EPSG:4326", "EPSG:3857"}},
+			{Name: "effort_vehicle", Title: "Patrol effort (vehicle)", CRS: []string{"EPSG:4326", "EPSG:3857"}},
+			{Name: "subcell_coverage", Title: "Subcell spatial coverage", CRS: []string{"EPSG:4326", "EPSG:3857"}},
+			{Name: "pa_overlay", Title: "Protected areas (WDPA)", CRS: []string{"EPSG:4326", "EPSG:3857"}},
+		},
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(caps)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package tiles
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// Key identifies one encoded tile. Version lets a caller invalidate
+// every cached tile for a layer at once (e.g. after a PAStore.Sync or
+// FireCache.Reload changes the underlying data) just by bumping a
+// counter, instead of tracking which z/x/y tiles the change touched.
+type Key struct {
+	Layer   string
+	Z, X, Y uint32
+	Version int64
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%d/%d/%d@%d", k.Layer, k.Z, k.X, k.Y, k.Version)
+}
+
+// Cache is a fixed-size, in-memory LRU of encoded tile bytes. A stale
+// Version simply never hits (old entries age out on their own rather
+// than being swept), so callers don't need an explicit invalidation
+// pass when the dataset version bumps.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// NewCache returns a Cache holding at most capacity tiles, evicting the
+// least-recently-used entry once full.
+func NewCache(capacity int) *Cache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Cache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached bytes for key, if present.
+func (c *Cache) Get(key Key) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key.String()
+	el, ok := c.items[k]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).data, true
+}
+
+// Put stores data under key, evicting the least-recently-used entry if
+// the cache is at capacity.
+func (c *Cache) Put(key Key, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key.String()
+	if el, ok := c.items[k]; ok {
+		el.Value.(*cacheEntry).data = data
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{key: k, data: data})
+	c.items[k] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).key)
+		}
+	}
+}
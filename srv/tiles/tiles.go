@@ -0,0 +1,131 @@
+// Package tiles encodes Mapbox Vector Tiles (MVT) from the PA store and
+// the fire GeoJSON blobs, so the map fetches only what's visible at a
+// given z/x/y instead of the full-resolution GeoJSON for the whole
+// dataset. Geometries are clipped to the tile (plus a small buffer so
+// strokes don't visibly break at tile edges) and simplified with
+// Douglas-Peucker at a tolerance that scales with zoom.
+package tiles
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/paulmach/orb/simplify"
+)
+
+// PAFeature is the subset of a store.Row this package needs to encode
+// a protected area into the "pa" layer — callers build this from
+// store.Row rather than this package importing srv/pa/store directly,
+// so tiles stays usable against any geometry source.
+type PAFeature struct {
+	WDPAID   int
+	Name     string
+	IUCN     string
+	AreaKm2  float64
+	Geometry orb.Geometry
+}
+
+// EncodePATile renders features as the "pa" layer of tile z/x/y.
+func EncodePATile(features []PAFeature, z uint32, x, y uint32) ([]byte, error) {
+	fc := geojson.NewFeatureCollection()
+	for _, f := range features {
+		if f.Geometry == nil {
+			continue
+		}
+		feat := geojson.NewFeature(f.Geometry)
+		feat.Properties["wdpa_id"] = f.WDPAID
+		feat.Properties["name"] = f.Name
+		feat.Properties["iucn"] = f.IUCN
+		feat.Properties["area_km2"] = f.AreaKm2
+		fc.Append(feat)
+	}
+	return encodeTile(map[string]*geojson.FeatureCollection{"pa": fc}, z, x, y)
+}
+
+// EncodeFireTile renders fc — as parsed from the fire daily GeoJSON
+// blob FireCache already keeps warm — as the "fire" layer of tile z/x/y.
+// Feature properties are passed through unchanged.
+func EncodeFireTile(fc *geojson.FeatureCollection, z uint32, x, y uint32) ([]byte, error) {
+	return encodeTile(map[string]*geojson.FeatureCollection{"fire": fc}, z, x, y)
+}
+
+// GridFeature is one grid cell's effort aggregate, rendered as a point
+// at the cell's center so the map can style it the same way it styles
+// the GeoJSON HandleAPIGrid already returns (circle radius/color from
+// intensity).
+type GridFeature struct {
+	GridCellID             string
+	Lat, Lon               float64
+	Intensity              float64
+	DryMonths, RainyMonths int64
+}
+
+// EncodeGridTile renders features as the "grid" layer of tile z/x/y.
+func EncodeGridTile(features []GridFeature, z, x, y uint32) ([]byte, error) {
+	fc := geojson.NewFeatureCollection()
+	for _, f := range features {
+		feat := geojson.NewFeature(orb.Point{f.Lon, f.Lat})
+		feat.Properties["id"] = f.GridCellID
+		feat.Properties["intensity"] = f.Intensity
+		feat.Properties["dry_months"] = f.DryMonths
+		feat.Properties["rainy_months"] = f.RainyMonths
+		fc.Append(feat)
+	}
+	return encodeTile(map[string]*geojson.FeatureCollection{"grid": fc}, z, x, y)
+}
+
+// DeforestationFeature is one deforestation_events row, rendered as a
+// point (the table records event centroids, not polygons).
+type DeforestationFeature struct {
+	ParkID   string
+	AreaKm2  float64
+	Geometry orb.Geometry
+}
+
+// EncodeDeforestationTile renders features as the "deforestation" layer
+// of tile z/x/y.
+func EncodeDeforestationTile(features []DeforestationFeature, z, x, y uint32) ([]byte, error) {
+	fc := geojson.NewFeatureCollection()
+	for _, f := range features {
+		if f.Geometry == nil {
+			continue
+		}
+		feat := geojson.NewFeature(f.Geometry)
+		feat.Properties["park_id"] = f.ParkID
+		feat.Properties["area_km2"] = f.AreaKm2
+		fc.Append(feat)
+	}
+	return encodeTile(map[string]*geojson.FeatureCollection{"deforestation": fc}, z, x, y)
+}
+
+// encodeTile projects collections into tile z/x/y's local coordinate
+// space, clips to the tile bound (with a small buffer), simplifies, and
+// marshals the result as a single MVT blob.
+func encodeTile(collections map[string]*geojson.FeatureCollection, z uint32, x, y uint32) ([]byte, error) {
+	tile := maptile.New(x, y, maptile.Zoom(z))
+
+	layers := mvt.NewLayers(collections)
+	layers.ProjectToTile(tile)
+	layers.Clip(mvt.MapboxGLDefaultExtentBound)
+	layers.Simplify(simplify.DouglasPeucker(toleranceForZoom(z, tile.Bound().Center().Lat())))
+	layers.RemoveEmpty(1.0, 1.0)
+
+	data, err := mvt.Marshal(layers)
+	if err != nil {
+		return nil, fmt.Errorf("tiles: encode mvt %d/%d/%d: %w", z, x, y, err)
+	}
+	return data, nil
+}
+
+// toleranceForZoom scales the Douglas-Peucker simplification tolerance
+// (in the tile's projected pixel units) down as zoom increases, using
+// the standard Web Mercator meters-per-pixel formula so high-zoom tiles
+// of areas far from the equator aren't over-simplified.
+func toleranceForZoom(z uint32, lat float64) float64 {
+	const k = 1.0
+	return 156543.03 * math.Cos(lat*math.Pi/180) / math.Exp2(float64(z)) * k
+}
@@ -0,0 +1,193 @@
+package track
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// fitParser decodes Garmin's binary FIT protocol well enough to recover a
+// track: it walks the definition/data message stream and extracts
+// "record" messages (global message number 20), reading the
+// position_lat/position_long/altitude/timestamp fields every Garmin
+// device emits in semicircles, decimeters and the FIT epoch
+// respectively. Messages this parser doesn't care about (laps, sessions,
+// device info, ...) are skipped using their declared size so the decoder
+// stays in sync without needing a full field dictionary.
+type fitParser struct{}
+
+func (fitParser) Name() string { return "fit" }
+
+func (fitParser) CanParse(filename string, magicBytes []byte) bool {
+	if strings.HasSuffix(strings.ToLower(filename), ".fit") {
+		return true
+	}
+	// The FIT header's byte 8-11 is always the ASCII string ".FIT".
+	return len(magicBytes) >= 12 && string(magicBytes[8:12]) == ".FIT"
+}
+
+// fitEpoch is the FIT protocol's epoch (1989-12-31T00:00:00Z UTC),
+// offset from Unix time; timestamps in FIT messages are seconds since
+// this epoch.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+const fitMsgRecord = 20
+
+type fitFieldDef struct {
+	num      byte
+	size     byte
+	baseType byte
+}
+
+type fitDefinition struct {
+	globalMsgNum uint16
+	littleEndian bool
+	fields       []fitFieldDef
+}
+
+func (fitParser) Parse(r io.Reader) (*gpx.GPXData, error) {
+	br := bufio.NewReader(r)
+
+	var header [12]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("read fit header: %w", err)
+	}
+	headerSize := int(header[0])
+	if string(header[8:12]) != ".FIT" {
+		return nil, fmt.Errorf("not a FIT file")
+	}
+	// Skip any header bytes beyond the 12 we already consumed (e.g. a
+	// 14-byte header with a trailing CRC).
+	if extra := headerSize - 12; extra > 0 {
+		if _, err := io.CopyN(io.Discard, br, int64(extra)); err != nil {
+			return nil, fmt.Errorf("skip fit header tail: %w", err)
+		}
+	}
+
+	defs := make(map[byte]*fitDefinition)
+	var points []gpx.Point
+
+	for {
+		recHeader, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read record header: %w", err)
+		}
+
+		// Top bit set = compressed timestamp header, which this parser
+		// doesn't need to decode (the record message carries its own
+		// timestamp field); the low 5 bits still select the definition.
+		localType := recHeader & 0x0f
+		isDefinition := recHeader&0x40 != 0
+
+		if isDefinition {
+			def, err := readFitDefinition(br)
+			if err != nil {
+				return nil, fmt.Errorf("read fit definition: %w", err)
+			}
+			defs[localType] = def
+			continue
+		}
+
+		def := defs[localType]
+		if def == nil {
+			return nil, fmt.Errorf("fit data message for undefined local type %d", localType)
+		}
+
+		raw := make(map[byte][]byte)
+		for _, f := range def.fields {
+			buf := make([]byte, f.size)
+			if _, err := io.ReadFull(br, buf); err != nil {
+				return nil, fmt.Errorf("read field data: %w", err)
+			}
+			raw[f.num] = buf
+		}
+
+		if def.globalMsgNum != fitMsgRecord {
+			continue
+		}
+
+		order := binary.ByteOrder(binary.BigEndian)
+		if def.littleEndian {
+			order = binary.LittleEndian
+		}
+
+		point := gpx.Point{}
+		haveLat, haveLon := false, false
+		for num, b := range raw {
+			switch num {
+			case 0: // position_lat, semicircles
+				if len(b) == 4 {
+					point.Lat = semicirclesToDegrees(int32(order.Uint32(b)))
+					haveLat = true
+				}
+			case 1: // position_long, semicircles
+				if len(b) == 4 {
+					point.Lon = semicirclesToDegrees(int32(order.Uint32(b)))
+					haveLon = true
+				}
+			case 2: // altitude, (value/5)-500 meters
+				if len(b) == 2 {
+					raw16 := order.Uint16(b)
+					if raw16 != 0xFFFF {
+						ele := float64(raw16)/5 - 500
+						point.Elevation = &ele
+					}
+				}
+			case 253: // timestamp, seconds since the FIT epoch
+				if len(b) == 4 {
+					t := fitEpoch.Add(time.Duration(order.Uint32(b)) * time.Second)
+					point.Time = &t
+				}
+			}
+		}
+
+		if haveLat && haveLon {
+			points = append(points, point)
+		}
+	}
+
+	data := &gpx.GPXData{Name: "fit"}
+	if len(points) > 0 {
+		data.Tracks = append(data.Tracks, gpx.Track{Name: "fit", Segments: [][]gpx.Point{points}})
+	}
+	return data, nil
+}
+
+// readFitDefinition parses a definition message: architecture byte,
+// global message number, field count, then that many (num, size,
+// base_type) triples, followed by an optional developer-field section.
+func readFitDefinition(br *bufio.Reader) (*fitDefinition, error) {
+	var fixed [5]byte
+	if _, err := io.ReadFull(br, fixed[:]); err != nil {
+		return nil, err
+	}
+	def := &fitDefinition{littleEndian: fixed[1] == 0}
+	order := binary.ByteOrder(binary.BigEndian)
+	if def.littleEndian {
+		order = binary.LittleEndian
+	}
+	def.globalMsgNum = order.Uint16(fixed[2:4])
+	fieldCount := int(fixed[4])
+
+	for i := 0; i < fieldCount; i++ {
+		var fb [3]byte
+		if _, err := io.ReadFull(br, fb[:]); err != nil {
+			return nil, err
+		}
+		def.fields = append(def.fields, fitFieldDef{num: fb[0], size: fb[1], baseType: fb[2]})
+	}
+
+	return def, nil
+}
+
+func semicirclesToDegrees(v int32) float64 {
+	return float64(v) * (180.0 / (1 << 31))
+}
@@ -0,0 +1,106 @@
+package track
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// geoJSONParser decodes RFC 7946 GeoJSON tracks as exported by research
+// tooling: a FeatureCollection of LineString (or MultiLineString)
+// features, each geometry's coordinates in [lon, lat] order. Per-point
+// timestamps, when present, are read from a parallel "coordTimes"
+// property (the convention used by GPX-to-GeoJSON converters such as
+// togeojson and gpx.studio).
+type geoJSONParser struct{}
+
+func (geoJSONParser) Name() string { return "geojson" }
+
+func (geoJSONParser) CanParse(filename string, magicBytes []byte) bool {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".geojson") || strings.HasSuffix(lower, ".json") {
+		return true
+	}
+	return containsCI(magicBytes, "featurecollection")
+}
+
+type geoJSONFile struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Properties geoJSONProps `json:"properties"`
+	Geometry   geoJSONGeom  `json:"geometry"`
+}
+
+type geoJSONProps struct {
+	Name       string   `json:"name"`
+	CoordTimes []string `json:"coordTimes"`
+}
+
+type geoJSONGeom struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (geoJSONParser) Parse(r io.Reader) (*gpx.GPXData, error) {
+	var f geoJSONFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	data := &gpx.GPXData{Name: "geojson"}
+	for _, feat := range f.Features {
+		switch feat.Geometry.Type {
+		case "LineString":
+			var coords [][]float64
+			if err := json.Unmarshal(feat.Geometry.Coordinates, &coords); err != nil {
+				continue
+			}
+			points := coordsToPoints(coords, feat.Properties.CoordTimes)
+			if len(points) > 0 {
+				data.Tracks = append(data.Tracks, gpx.Track{Name: feat.Properties.Name, Segments: [][]gpx.Point{points}})
+			}
+		case "MultiLineString":
+			var lines [][][]float64
+			if err := json.Unmarshal(feat.Geometry.Coordinates, &lines); err != nil {
+				continue
+			}
+			var segments [][]gpx.Point
+			for _, coords := range lines {
+				if points := coordsToPoints(coords, nil); len(points) > 0 {
+					segments = append(segments, points)
+				}
+			}
+			if len(segments) > 0 {
+				data.Tracks = append(data.Tracks, gpx.Track{Name: feat.Properties.Name, Segments: segments})
+			}
+		}
+	}
+	return data, nil
+}
+
+func coordsToPoints(coords [][]float64, coordTimes []string) []gpx.Point {
+	points := make([]gpx.Point, 0, len(coords))
+	for i, c := range coords {
+		if len(c) < 2 {
+			continue
+		}
+		point := gpx.Point{Lon: c[0], Lat: c[1]}
+		if len(c) >= 3 {
+			ele := c[2]
+			point.Elevation = &ele
+		}
+		if i < len(coordTimes) {
+			if t, err := time.Parse(time.RFC3339, coordTimes[i]); err == nil {
+				point.Time = &t
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
@@ -0,0 +1,25 @@
+package track
+
+import (
+	"io"
+	"strings"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// gpxParser wraps the existing gpx.ParseGPX so GPX keeps working as just
+// another registered format.
+type gpxParser struct{}
+
+func (gpxParser) Name() string { return "gpx" }
+
+func (gpxParser) CanParse(filename string, magicBytes []byte) bool {
+	if strings.HasSuffix(strings.ToLower(filename), ".gpx") {
+		return true
+	}
+	return containsCI(magicBytes, "<gpx")
+}
+
+func (gpxParser) Parse(r io.Reader) (*gpx.GPXData, error) {
+	return gpx.ParseGPX(r)
+}
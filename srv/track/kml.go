@@ -0,0 +1,155 @@
+package track
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// kmlParser decodes Google Earth KML, and KMZ (a zip containing a
+// doc.kml), tracks exported by field apps. It supports both a plain
+// <LineString><coordinates> path and the gx:Track extension used by
+// Google Earth/Timeline exports, which carries a <when> timestamp per
+// coordinate.
+type kmlParser struct{}
+
+func (kmlParser) Name() string { return "kml" }
+
+func (kmlParser) CanParse(filename string, magicBytes []byte) bool {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".kml") || strings.HasSuffix(lower, ".kmz") {
+		return true
+	}
+	return containsCI(magicBytes, "<kml")
+}
+
+type kmlFile struct {
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name       string      `xml:"name"`
+	LineString *kmlLine    `xml:"LineString"`
+	GxTrack    *kmlGxTrack `xml:"Track"`
+}
+
+type kmlLine struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlGxTrack struct {
+	When  []string `xml:"when"`
+	Coord []string `xml:"coord"`
+}
+
+func (p kmlParser) Parse(r io.Reader) (*gpx.GPXData, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// KMZ is a zip archive with a doc.kml (or similarly named) entry at
+	// its root; unwrap it before handing bytes to the KML XML decoder.
+	if zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw))); err == nil {
+		for _, f := range zr.File {
+			if strings.HasSuffix(strings.ToLower(f.Name), ".kml") {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, fmt.Errorf("open %s in kmz: %w", f.Name, err)
+				}
+				raw, err = io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	var kf kmlFile
+	if err := xml.Unmarshal(raw, &kf); err != nil {
+		return nil, err
+	}
+
+	data := &gpx.GPXData{Name: "kml"}
+	for _, pm := range kf.Document.Placemarks {
+		var points []gpx.Point
+		switch {
+		case pm.GxTrack != nil:
+			points = parseGxTrack(pm.GxTrack)
+		case pm.LineString != nil:
+			points = parseKMLCoordinates(pm.LineString.Coordinates)
+		}
+		if len(points) > 0 {
+			data.Tracks = append(data.Tracks, gpx.Track{Name: pm.Name, Segments: [][]gpx.Point{points}})
+		}
+	}
+	return data, nil
+}
+
+// parseKMLCoordinates parses a KML <coordinates> blob: whitespace
+// separated "lon,lat[,elevation]" tuples, with no per-point timestamp.
+func parseKMLCoordinates(raw string) []gpx.Point {
+	var points []gpx.Point
+	for _, tuple := range strings.Fields(raw) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		lon, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		point := gpx.Point{Lat: lat, Lon: lon}
+		if len(parts) >= 3 {
+			if ele, err := strconv.ParseFloat(parts[2], 64); err == nil {
+				point.Elevation = &ele
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// parseGxTrack parses a gx:Track's parallel <when>/<coord> lists into
+// timestamped points. <coord> entries are "lon lat [altitude]".
+func parseGxTrack(trk *kmlGxTrack) []gpx.Point {
+	points := make([]gpx.Point, 0, len(trk.Coord))
+	for i, coord := range trk.Coord {
+		parts := strings.Fields(coord)
+		if len(parts) < 2 {
+			continue
+		}
+		lon, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		point := gpx.Point{Lat: lat, Lon: lon}
+		if len(parts) >= 3 {
+			if ele, err := strconv.ParseFloat(parts[2], 64); err == nil {
+				point.Elevation = &ele
+			}
+		}
+		if i < len(trk.When) {
+			if t, err := time.Parse(time.RFC3339, trk.When[i]); err == nil {
+				point.Time = &t
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
@@ -0,0 +1,80 @@
+package track
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// tcxParser decodes Garmin Training Center XML (.tcx), as exported by
+// Strava and Suunto. TCX nests points ("Trackpoint") under Lap under
+// Activity rather than GPX's Track/Segment, but otherwise carries the
+// same lat/lon/time/elevation a Segment needs.
+type tcxParser struct{}
+
+func (tcxParser) Name() string { return "tcx" }
+
+func (tcxParser) CanParse(filename string, magicBytes []byte) bool {
+	if strings.HasSuffix(strings.ToLower(filename), ".tcx") {
+		return true
+	}
+	return containsCI(magicBytes, "trainingcenterdatabase")
+}
+
+type tcxFile struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Laps []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	Tracks []tcxTrack `xml:"Track"`
+}
+
+type tcxTrack struct {
+	Points []tcxPoint `xml:"Trackpoint"`
+}
+
+type tcxPoint struct {
+	Time          string   `xml:"Time"`
+	Lat           float64  `xml:"Position>LatitudeDegrees"`
+	Lon           float64  `xml:"Position>LongitudeDegrees"`
+	AltitudeMeter *float64 `xml:"AltitudeMeters"`
+}
+
+func (tcxParser) Parse(r io.Reader) (*gpx.GPXData, error) {
+	var f tcxFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	data := &gpx.GPXData{Name: "tcx"}
+	for _, act := range f.Activities {
+		for _, lap := range act.Laps {
+			for _, trk := range lap.Tracks {
+				points := make([]gpx.Point, 0, len(trk.Points))
+				for _, pt := range trk.Points {
+					if pt.Lat == 0 && pt.Lon == 0 {
+						continue
+					}
+					point := gpx.Point{Lat: pt.Lat, Lon: pt.Lon, Elevation: pt.AltitudeMeter}
+					if pt.Time != "" {
+						if t, err := time.Parse(time.RFC3339, pt.Time); err == nil {
+							point.Time = &t
+						}
+					}
+					points = append(points, point)
+				}
+				if len(points) > 0 {
+					data.Tracks = append(data.Tracks, gpx.Track{Name: "lap", Segments: [][]gpx.Point{points}})
+				}
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,85 @@
+// Package track parses GPS track files in multiple formats into the
+// common gpx.GPXData shape the upload pipeline already knows how to
+// segment and persist. Support for a new format is added by implementing
+// Parser and registering it with Register; callers pick a parser by
+// sniffing the filename and the first bytes of the file via Lookup.
+package track
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"srv.exe.dev/srv/gpx"
+)
+
+// Parser recognizes and decodes one track file format.
+type Parser interface {
+	// Name identifies the format for logging, e.g. "gpx", "tcx".
+	Name() string
+	// CanParse reports whether this parser should handle a file, based on
+	// its filename (for the extension) and a short prefix of its bytes
+	// (for magic-number/XML-root sniffing). Either argument may be empty.
+	CanParse(filename string, magicBytes []byte) bool
+	// Parse decodes r into the common track data shape.
+	Parse(r io.Reader) (*gpx.GPXData, error)
+}
+
+// sniffLen is how many leading bytes are buffered and handed to each
+// parser's CanParse for magic-number/XML sniffing.
+const sniffLen = 512
+
+var registry []Parser
+
+// Register adds a parser to the registry consulted by Lookup. Parsers are
+// tried in registration order, so init() in this package registers the
+// built-in formats before any parser a caller adds of their own.
+func Register(p Parser) {
+	registry = append(registry, p)
+}
+
+func init() {
+	Register(gpxParser{})
+	Register(tcxParser{})
+	Register(kmlParser{})
+	Register(geoJSONParser{})
+	Register(fitParser{})
+}
+
+// Lookup returns the first registered parser willing to handle filename,
+// sniffing up to sniffLen bytes from r for parsers that need to inspect
+// content. It returns the chosen parser and an io.Reader positioned at the
+// start of the stream (the sniffed bytes are replayed ahead of the rest).
+func Lookup(filename string, r io.Reader) (Parser, io.Reader, error) {
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, nil, fmt.Errorf("read magic bytes: %w", err)
+	}
+	magic := buf[:n]
+	full := io.MultiReader(bytes.NewReader(magic), r)
+
+	for _, p := range registry {
+		if p.CanParse(filename, magic) {
+			return p, full, nil
+		}
+	}
+	return nil, full, fmt.Errorf("no parser registered for %q", filename)
+}
+
+// Parse sniffs filename/r and decodes it with the first matching
+// registered parser.
+func Parse(filename string, r io.Reader) (*gpx.GPXData, error) {
+	p, reader, err := Lookup(filename, r)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse(reader)
+}
+
+// containsCI reports whether s (case-insensitive) appears in b, used by
+// format parsers to sniff magic bytes such as an XML root element.
+func containsCI(b []byte, s string) bool {
+	return strings.Contains(strings.ToLower(string(b)), s)
+}
@@ -0,0 +1,261 @@
+// Package tus implements enough of the tus resumable upload protocol
+// (https://tus.io/protocols/resumable-upload) — creation, offset
+// querying, and checksummed chunked PATCH — to let multi-gigabyte GHSL
+// tiles and fire CSVs upload over flaky connections without restarting
+// from zero. Each upload is persisted as a `<id>.part` file plus a
+// `<id>.json` metadata sidecar, so Store.Load can resume in-progress
+// uploads across a server restart.
+package tus
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Version is the tus protocol version this package implements.
+const Version = "1.0.0"
+
+var (
+	// ErrNotFound means no upload exists for the given ID.
+	ErrNotFound = errors.New("tus: upload not found")
+	// ErrOffsetMismatch means the PATCH request's Upload-Offset didn't
+	// match the server's recorded offset (the client is out of sync,
+	// e.g. after a previous PATCH partially failed).
+	ErrOffsetMismatch = errors.New("tus: offset mismatch")
+	// ErrChecksumMismatch means the checksum extension header didn't
+	// match the bytes actually written for this chunk.
+	ErrChecksumMismatch = errors.New("tus: checksum mismatch")
+)
+
+// Upload is one resumable upload's metadata. Offset is the number of
+// bytes successfully persisted so far; the upload is complete once
+// Offset == TotalSize.
+type Upload struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	Kind      string    `json:"kind"` // "fire" or "ghsl"
+	TotalSize int64     `json:"totalSize"`
+	Offset    int64     `json:"offset"`
+	SHA256    string    `json:"sha256,omitempty"` // expected hash of the complete file, optional
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Done reports whether every byte has been received.
+func (u Upload) Done() bool {
+	return u.Offset >= u.TotalSize
+}
+
+type trackedUpload struct {
+	Upload
+	mu sync.Mutex
+}
+
+// Store persists uploads under dir as "<id>.part" (the raw bytes) and
+// "<id>.json" (the Upload metadata), and serializes concurrent access
+// to a given upload's offset.
+type Store struct {
+	dir string
+
+	mu      sync.Mutex
+	uploads map[string]*trackedUpload
+}
+
+// NewStore creates dir if needed and rehydrates any uploads left behind
+// by a previous run (e.g. the process restarted mid-upload).
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create upload dir: %w", err)
+	}
+	s := &Store{dir: dir, uploads: make(map[string]*trackedUpload)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("read upload dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("read upload metadata %s: %w", entry.Name(), err)
+		}
+		var u Upload
+		if err := json.Unmarshal(data, &u); err != nil {
+			return fmt.Errorf("parse upload metadata %s: %w", entry.Name(), err)
+		}
+		s.uploads[u.ID] = &trackedUpload{Upload: u}
+	}
+	return nil
+}
+
+// Create registers a new upload and its empty backing file, returning
+// its initial (zero-offset) state.
+func (s *Store) Create(filename, kind string, totalSize int64, sha256Hex string) (Upload, error) {
+	id, err := newID()
+	if err != nil {
+		return Upload{}, err
+	}
+	u := Upload{
+		ID:        id,
+		Filename:  filename,
+		Kind:      kind,
+		TotalSize: totalSize,
+		SHA256:    sha256Hex,
+		CreatedAt: time.Now(),
+	}
+
+	f, err := os.Create(s.partPath(id))
+	if err != nil {
+		return Upload{}, fmt.Errorf("create upload file: %w", err)
+	}
+	f.Close()
+
+	if err := s.persist(u); err != nil {
+		os.Remove(s.partPath(id))
+		return Upload{}, err
+	}
+
+	s.mu.Lock()
+	s.uploads[id] = &trackedUpload{Upload: u}
+	s.mu.Unlock()
+
+	return u, nil
+}
+
+// Get returns one upload's current state.
+func (s *Store) Get(id string) (Upload, bool) {
+	s.mu.Lock()
+	tu, ok := s.uploads[id]
+	s.mu.Unlock()
+	if !ok {
+		return Upload{}, false
+	}
+	tu.mu.Lock()
+	defer tu.mu.Unlock()
+	return tu.Upload, true
+}
+
+// WriteChunk appends body to the upload's part file starting at offset,
+// which must match the upload's current recorded offset. If
+// checksumSHA256 is non-empty, the chunk is rejected (and not
+// persisted) if its hash doesn't match. It returns the new offset after
+// the chunk is written.
+func (s *Store) WriteChunk(id string, offset int64, body io.Reader, checksumSHA256 string) (int64, error) {
+	s.mu.Lock()
+	tu, ok := s.uploads[id]
+	s.mu.Unlock()
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	tu.mu.Lock()
+	defer tu.mu.Unlock()
+
+	if offset != tu.Offset {
+		return tu.Offset, ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.partPath(id), os.O_WRONLY, 0o644)
+	if err != nil {
+		return tu.Offset, fmt.Errorf("open upload file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return tu.Offset, fmt.Errorf("seek upload file: %w", err)
+	}
+
+	var h interface {
+		io.Writer
+		Sum([]byte) []byte
+	}
+	reader := body
+	if checksumSHA256 != "" {
+		sum := sha256.New()
+		h = sum
+		reader = io.TeeReader(body, sum)
+	}
+
+	n, err := io.Copy(f, reader)
+	if err != nil {
+		return tu.Offset, fmt.Errorf("write upload chunk: %w", err)
+	}
+	if h != nil && hex.EncodeToString(h.Sum(nil)) != checksumSHA256 {
+		// Truncate back to the pre-chunk offset so a retried PATCH
+		// with the same Upload-Offset starts clean.
+		f.Truncate(offset)
+		return tu.Offset, ErrChecksumMismatch
+	}
+
+	tu.Offset += n
+	if err := s.persist(tu.Upload); err != nil {
+		return tu.Offset, err
+	}
+	return tu.Offset, nil
+}
+
+// Path returns the backing file's path for a (presumably complete)
+// upload, for handing off to the next processing stage.
+func (s *Store) Path(id string) string {
+	return s.partPath(id)
+}
+
+// Remove deletes an upload's part file and metadata sidecar, e.g. once
+// it has been handed off to processing.
+func (s *Store) Remove(id string) error {
+	s.mu.Lock()
+	delete(s.uploads, id)
+	s.mu.Unlock()
+
+	err1 := os.Remove(s.partPath(id))
+	err2 := os.Remove(s.metaPath(id))
+	if err1 != nil && !os.IsNotExist(err1) {
+		return err1
+	}
+	if err2 != nil && !os.IsNotExist(err2) {
+		return err2
+	}
+	return nil
+}
+
+func (s *Store) partPath(id string) string {
+	return filepath.Join(s.dir, id+".part")
+}
+
+func (s *Store) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) persist(u Upload) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return fmt.Errorf("marshal upload metadata: %w", err)
+	}
+	if err := os.WriteFile(s.metaPath(u.ID), data, 0o644); err != nil {
+		return fmt.Errorf("write upload metadata: %w", err)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
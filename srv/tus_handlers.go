@@ -0,0 +1,209 @@
+package srv
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"srv.exe.dev/srv/jobs"
+	"srv.exe.dev/srv/tus"
+)
+
+// tusExtensions lists the tus protocol extensions this server
+// implements, advertised in the Tus-Extension header.
+const tusExtensions = "creation,checksum"
+
+// tusStatusChecksumMismatch is the non-standard status code the tus
+// checksum extension defines for a chunk that fails verification; it
+// isn't one of the constants in net/http.
+const tusStatusChecksumMismatch = 460
+
+// HandleTusOptions answers the tus discovery request: protocol version,
+// supported extensions, and the checksum algorithm we accept.
+func (s *Server) HandleTusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tus.Version)
+	w.Header().Set("Tus-Version", tus.Version)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Checksum-Algorithm", "sha256")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTusCreate implements the tus "creation" extension: it registers
+// a new resumable upload for a GHSL tile or fire CSV and returns its
+// location for subsequent PATCH/HEAD requests.
+func (s *Server) HandleTusCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Tus-Resumable") != tus.Version {
+		writeJSONError(w, http.StatusPreconditionFailed, "unsupported Tus-Resumable version")
+		return
+	}
+
+	totalSize, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || totalSize <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "missing or invalid Upload-Length")
+		return
+	}
+
+	meta := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	kind := meta["kind"]
+	if kind != "fire" && kind != "ghsl" {
+		writeJSONError(w, http.StatusBadRequest, `Upload-Metadata must include kind="fire" or kind="ghsl"`)
+		return
+	}
+	filename := meta["filename"]
+	if filename == "" {
+		writeJSONError(w, http.StatusBadRequest, `Upload-Metadata must include filename`)
+		return
+	}
+
+	upload, err := s.Tus.Create(filename, kind, totalSize, meta["sha256"])
+	if err != nil {
+		slog.Error("failed to create tus upload", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to create upload")
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tus.Version)
+	w.Header().Set("Location", "/admin/uploads/tus/"+upload.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleTusHead implements tus offset querying: a client reconnecting
+// after a dropped connection calls this to find out where to resume.
+func (s *Server) HandleTusHead(w http.ResponseWriter, r *http.Request) {
+	upload, ok := s.Tus.Get(r.PathValue("id"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Tus-Resumable", tus.Version)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.TotalSize, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleTusPatch implements tus chunk upload: it appends the request
+// body at Upload-Offset, optionally verifying the chunk against the
+// "checksum" extension's Upload-Checksum header, and hands the file off
+// to the fire/GHSL processing pipeline once the upload is complete.
+func (s *Server) HandleTusPatch(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Tus-Resumable") != tus.Version {
+		writeJSONError(w, http.StatusPreconditionFailed, "unsupported Tus-Resumable version")
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+		return
+	}
+
+	id := r.PathValue("id")
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "missing or invalid Upload-Offset")
+		return
+	}
+
+	checksum := parseChecksumHeader(r.Header.Get("Upload-Checksum"))
+
+	newOffset, err := s.Tus.WriteChunk(id, offset, r.Body, checksum)
+	switch {
+	case errors.Is(err, tus.ErrNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, tus.ErrOffsetMismatch):
+		w.Header().Set("Upload-Offset", strconv.FormatInt(newOffset, 10))
+		writeJSONError(w, http.StatusConflict, "offset does not match upload state")
+		return
+	case errors.Is(err, tus.ErrChecksumMismatch):
+		writeJSONError(w, tusStatusChecksumMismatch, "chunk checksum mismatch")
+		return
+	case err != nil:
+		slog.Error("tus chunk write failed", "upload_id", id, "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to write chunk")
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tus.Version)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	w.WriteHeader(http.StatusNoContent)
+
+	upload, ok := s.Tus.Get(id)
+	if ok && upload.Done() {
+		go s.finishTusUpload(upload)
+	}
+}
+
+// finishTusUpload hands a completed tus upload off to the existing
+// fire/GHSL processing pipeline, the same one used by the direct
+// multipart-form upload handlers.
+func (s *Server) finishTusUpload(upload tus.Upload) {
+	path := s.Tus.Path(upload.ID)
+
+	switch upload.Kind {
+	case "fire":
+		// Submit runs the processor in its own goroutine, so the tus
+		// entry (and its backing file) can only be removed once that
+		// goroutine is done reading path, not as soon as Submit returns.
+		// encrypted is always false here: tus writes chunks at
+		// arbitrary offsets to support resuming a dropped upload, which
+		// the vault's chunked AEAD framing can't be written through.
+		s.JobRunner.Submit(jobs.KindFireCSV, upload.Filename, upload.TotalSize, func(ctx context.Context, jobID string) error {
+			defer s.Tus.Remove(upload.ID)
+			return s.runFireProcessor(ctx, jobID, path, upload.Filename, false)
+		})
+	case "ghsl":
+		// extractGHSLTile reads path synchronously, so it's safe to
+		// remove the tus entry as soon as it returns.
+		defer s.Tus.Remove(upload.ID)
+		tileID, destPath, written, err := s.extractGHSLTile(path)
+		if err != nil {
+			slog.Error("failed to extract GHSL tile from tus upload", "upload_id", upload.ID, "error", err)
+			return
+		}
+		s.JobRunner.Submit(jobs.KindGHSLTile, tileID, written, func(ctx context.Context, jobID string) error {
+			return s.runGHSLProcessor(ctx, jobID, tileID, destPath)
+		})
+	default:
+		slog.Error("tus upload finished with unknown kind", "upload_id", upload.ID, "kind", upload.Kind)
+	}
+}
+
+// parseUploadMetadata parses the tus Upload-Metadata header: a
+// comma-separated list of "key base64value" pairs.
+func parseUploadMetadata(header string) map[string]string {
+	out := make(map[string]string)
+	if header == "" {
+		return out
+	}
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(strings.TrimSpace(pair))
+		if len(fields) != 2 {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			continue
+		}
+		out[fields[0]] = string(decoded)
+	}
+	return out
+}
+
+// parseChecksumHeader parses the tus checksum extension's
+// "Upload-Checksum: sha256 <base64>" header into a hex-encoded digest,
+// or "" if absent or using an algorithm we don't support.
+func parseChecksumHeader(header string) string {
+	fields := strings.Fields(header)
+	if len(fields) != 2 || fields[0] != "sha256" {
+		return ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", decoded)
+}
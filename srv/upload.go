@@ -16,10 +16,26 @@ import (
 
 	"srv.exe.dev/db/dbgen"
 	"srv.exe.dev/srv/gpx"
+	"srv.exe.dev/srv/track"
 )
 
 const maxUploadSize = 100 << 20 // 100MB (increased for zip files)
 
+// supportedTrackExtensions lists the file extensions processGPX will
+// attempt to parse, via the format parsers registered in srv/track.
+var supportedTrackExtensions = []string{".gpx", ".fit", ".tcx", ".kml", ".kmz", ".geojson", ".json"}
+
+// isSupportedTrackFile reports whether a (lowercased) filename has an
+// extension handled by one of the registered srv/track parsers.
+func isSupportedTrackFile(lowerFilename string) bool {
+	for _, ext := range supportedTrackExtensions {
+		if strings.HasSuffix(lowerFilename, ext) {
+			return true
+		}
+	}
+	return false
+}
+
 // UploadResponse is the JSON response for file uploads.
 type UploadResponse struct {
 	FilesProcessed  int              `json:"files_processed"`
@@ -31,13 +47,13 @@ type UploadResponse struct {
 
 // SegmentSummary represents a processed segment in the upload response.
 type SegmentSummary struct {
-	StartTime    *time.Time `json:"start_time,omitempty"`
-	EndTime      *time.Time `json:"end_time,omitempty"`
-	MovementType string     `json:"movement_type,omitempty"`
-	DistanceKm   float64    `json:"distance_km"`
-	Points       int        `json:"points"`
-	Area         string     `json:"area"`
-	GridCellIDs  []string   `json:"grid_cells,omitempty"`
+	StartTime    *time.Time   `json:"start_time,omitempty"`
+	EndTime      *time.Time   `json:"end_time,omitempty"`
+	MovementType string       `json:"movement_type,omitempty"`
+	DistanceKm   float64      `json:"distance_km"`
+	Points       int          `json:"points"`
+	Area         string       `json:"area"`
+	GridCellIDs  []string     `json:"grid_cells,omitempty"`
 	Analysis     *GPXAnalysis `json:"analysis,omitempty"`
 }
 
@@ -97,9 +113,10 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	// Helper to process a single GPX file
+	// Helper to process a single track file, in any format registered
+	// with the srv/track package (GPX, FIT, TCX, KML/KMZ, GeoJSON).
 	processGPX := func(filename string, reader io.Reader) error {
-		gpxData, err := gpx.ParseGPX(reader)
+		gpxData, err := track.Parse(filename, reader)
 		if err != nil {
 			return err
 		}
@@ -107,8 +124,8 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		filesProcessed++
 
 		// Count points
-		for _, track := range gpxData.Tracks {
-			for _, seg := range track.Segments {
+		for _, trk := range gpxData.Tracks {
+			for _, seg := range trk.Segments {
 				totalPoints += len(seg)
 			}
 		}
@@ -178,7 +195,8 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 
 		// Persist upload to database
 		if s.DB != nil {
-			if err := s.persistUpload(ctx, userID, userEmail, filename, segments); err != nil {
+			incidents := gpx.ExtractIncidents(gpxData)
+			if err := s.persistUpload(ctx, userID, userEmail, filename, segments, incidents); err != nil {
 				slog.Warn("failed to persist upload", "error", err, "filename", filename)
 			} else {
 				slog.Info("persisted upload", "filename", filename, "segments", len(segments))
@@ -187,6 +205,109 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		return nil
 	}
 
+	// processGPXStreaming is processGPX's path for raw, non-zipped .gpx
+	// files: track.Parse's multi-format abstraction (FIT/TCX/KML/etc) fully
+	// materializes its input regardless, so there's nothing to stream there,
+	// but a plain .gpx upload can be parsed and persisted segment-by-segment
+	// via gpx.SplitIntoSegmentsStream/streamingUploadWriter as it's read off
+	// the wire, rather than only after the whole file has been buffered.
+	processGPXStreaming := func(filename string, reader io.Reader) error {
+		filesProcessed++
+
+		var writer *streamingUploadWriter
+		if s.DB != nil {
+			w, err := newStreamingUploadWriter(ctx, s, userID, userEmail, filename)
+			if err != nil {
+				slog.Warn("failed to start streaming upload", "error", err, "filename", filename)
+			} else {
+				writer = w
+			}
+		}
+
+		segCh := make(chan gpx.Segment)
+		errCh := make(chan error, 1)
+		go gpx.SplitIntoSegmentsStream(reader, 0, segCh, errCh)
+
+		segmentCount := 0
+		for seg := range segCh {
+			totalPoints += len(seg.Points)
+
+			if len(seg.Points) < 2 || seg.DistanceKm < 0.001 {
+				continue
+			}
+			segmentCount++
+			totalDistanceKm += seg.DistanceKm
+
+			areaName := "outside"
+			if s.AreaStore != nil {
+				if area := s.AreaStore.FindArea(seg.Points[0].Lat, seg.Points[0].Lon); area != nil {
+					areaName = area.Name
+				}
+			}
+
+			cellSet := make(map[string]bool)
+			for _, pt := range seg.Points {
+				cellSet[gridCellIDForPoint(pt.Lat, pt.Lon)] = true
+			}
+			gridCells := make([]string, 0, len(cellSet))
+			for cell := range cellSet {
+				gridCells = append(gridCells, cell)
+			}
+
+			analysisPoints := make([]struct {
+				Lat, Lon  float64
+				Time      *time.Time
+				Elevation *float64
+				Desc      string
+			}, len(seg.Points))
+			for i, pt := range seg.Points {
+				analysisPoints[i].Lat = pt.Lat
+				analysisPoints[i].Lon = pt.Lon
+				analysisPoints[i].Time = pt.Time
+				analysisPoints[i].Elevation = pt.Elevation
+				analysisPoints[i].Desc = pt.Desc
+			}
+			analysis := AnalyzeGPXSegment(analysisPoints)
+
+			movementType := seg.MovementType
+			if analysis.MovementType != "" {
+				movementType = analysis.MovementType
+			}
+
+			allSegments = append(allSegments, SegmentSummary{
+				StartTime:    seg.StartTime,
+				EndTime:      seg.EndTime,
+				MovementType: movementType,
+				DistanceKm:   seg.DistanceKm,
+				Points:       len(seg.Points),
+				Area:         areaName,
+				GridCellIDs:  gridCells,
+				Analysis:     &analysis,
+			})
+
+			if writer != nil {
+				if err := writer.Add(seg); err != nil {
+					slog.Warn("failed to persist streaming segment", "error", err, "filename", filename)
+				}
+			}
+		}
+
+		select {
+		case err := <-errCh:
+			return err
+		default:
+		}
+
+		if writer != nil {
+			if err := writer.Finish(); err != nil {
+				slog.Warn("failed to finalize streaming upload", "error", err, "filename", filename)
+			} else {
+				slog.Info("persisted streaming upload", "filename", filename, "segments", segmentCount)
+			}
+		}
+		return nil
+	}
+
 	// Process each uploaded file
 	for _, fileHeader := range files {
 		file, err := fileHeader.Open()
@@ -213,11 +334,10 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
-			// Process each GPX file in the zip
+			// Process each recognized track file in the zip
 			for _, zf := range zipReader.File {
 				zfName := strings.ToLower(zf.Name)
-				// Skip Mac OS X metadata and non-GPX files
-				if strings.Contains(zfName, "__macosx") || !strings.HasSuffix(zfName, ".gpx") {
+				if strings.Contains(zfName, "__macosx") || !isSupportedTrackFile(zfName) {
 					continue
 				}
 
@@ -227,21 +347,24 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 				}
 
 				if err := processGPX(zf.Name, zfReader); err != nil {
-					slog.Debug("failed to parse GPX from zip", "file", zf.Name, "error", err)
+					slog.Debug("failed to parse track file from zip", "file", zf.Name, "error", err)
 				}
 				zfReader.Close()
 			}
 			continue
 		}
 
-		// Regular GPX file
-		if !strings.HasSuffix(filename, ".gpx") {
+		if !isSupportedTrackFile(filename) {
 			file.Close()
 			continue
 		}
 
-		if err := processGPX(fileHeader.Filename, file); err != nil {
-			slog.Debug("failed to parse GPX", "file", fileHeader.Filename, "error", err)
+		if strings.HasSuffix(filename, ".gpx") {
+			if err := processGPXStreaming(fileHeader.Filename, file); err != nil {
+				slog.Debug("failed to parse track file", "file", fileHeader.Filename, "error", err)
+			}
+		} else if err := processGPX(fileHeader.Filename, file); err != nil {
+			slog.Debug("failed to parse track file", "file", fileHeader.Filename, "error", err)
 		}
 		file.Close()
 	}
@@ -286,7 +409,8 @@ const (
 // - gpx_uploads record for metadata
 // - track_points (sampled if > maxTrackPointsPerUpload)
 // - effort_data grid cell aggregates
-func (s *Server) persistUpload(ctx context.Context, userID, userEmail, filename string, segments []gpx.Segment) error {
+// - patrol_incidents (SOS/message waypoints, if any were parsed)
+func (s *Server) persistUpload(ctx context.Context, userID, userEmail, filename string, segments []gpx.Segment, incidents []gpx.Incident) error {
 	if len(segments) == 0 {
 		return nil
 	}
@@ -402,9 +526,209 @@ func (s *Server) persistUpload(ctx context.Context, userID, userEmail, filename
 		return fmt.Errorf("update effort data: %w", err)
 	}
 
+	// Update per-user KPI rollups (user_kpi_daily/user_kpi_monthly)
+	if err := s.updateUserKPIs(ctx, q, userID, segments, uploadID); err != nil {
+		return fmt.Errorf("update user KPIs: %w", err)
+	}
+
+	// Record any ranger-reported incidents (SOS activations, inReach
+	// messages) so the park-stats handler can surface them without
+	// re-parsing every upload's GPX on every stats request.
+	s.persistIncidents(ctx, q, uploadID, incidents)
+
 	return nil
 }
 
+// persistIncidents writes each parsed Incident to patrol_incidents,
+// attributing it to a protected area the same approximate way
+// updateUserKPIs attributes a segment: by checking which park's polygon
+// contains the incident's point. Best-effort like savePatrolStatsSnapshot
+// - a failure to record an incident shouldn't fail the whole upload.
+func (s *Server) persistIncidents(ctx context.Context, q *dbgen.Queries, uploadID int64, incidents []gpx.Incident) {
+	for _, inc := range incidents {
+		var parkID *string
+		if s.AreaStore != nil {
+			if area := s.AreaStore.FindArea(inc.Lat, inc.Lon); area != nil {
+				parkID = &area.ID
+			}
+		}
+		err := q.CreatePatrolIncident(ctx, dbgen.CreatePatrolIncidentParams{
+			UploadID:        uploadID,
+			ProtectedAreaID: parkID,
+			Kind:            inc.Kind,
+			Lat:             inc.Lat,
+			Lon:             inc.Lon,
+			Message:         inc.Message,
+			OccurredAt:      inc.Time,
+		})
+		if err != nil {
+			slog.Warn("create patrol incident", "upload_id", uploadID, "error", err)
+		}
+	}
+}
+
+// streamingUploadWriter is persistUpload's incremental counterpart: instead
+// of taking a single []gpx.Segment once the whole file has been parsed, its
+// Add method is called once per segment as gpx.SplitIntoSegmentsStream
+// produces them, writing each one's track points and effort_data/KPI
+// rollups immediately. This is what lets the raw-.gpx path in HandleUpload
+// start hitting the database while a large multi-day patrol track is still
+// being read off the wire, instead of only after every segment has been
+// collected. It's a stateful helper rather than a single function taking a
+// channel because HandleUpload needs to also build its JSON response
+// (SegmentSummary per segment) from the very same segments as they arrive,
+// and a channel can only be consumed once.
+//
+// Two aggregates persistUpload computes up front aren't available until
+// Finish is called, so this takes a different approach for each:
+//
+//   - The gpx_uploads row's totals (total points/distance, start/end time,
+//     movement type) aren't known until every segment has arrived, so the
+//     row is created with zero/placeholder values on the first Add call (to
+//     get an uploadID to attach track points to) and updated in place by
+//     Finish once the totals are final.
+//   - samplePoints' even-distribution sampling needs the full point count
+//     up front to pick its stride, which streaming doesn't have. Instead
+//     this stores points greedily, first-come-first-served, up to
+//     maxTrackPointsPerUpload total - a documented simplification versus
+//     persistUpload's evenly-spread sample, traded for not having to
+//     buffer the whole track.
+type streamingUploadWriter struct {
+	s        *Server
+	q        *dbgen.Queries
+	ctx      context.Context
+	userID   string
+	filename string
+
+	uploadID        int64
+	haveUpload      bool
+	totalPoints     int
+	totalDistanceKm float64
+	startTime       *time.Time
+	endTime         *time.Time
+	movementType    string
+	storedPoints    int
+}
+
+// newStreamingUploadWriter ensures userID exists (creating them with the
+// same "approved" default role persistUpload uses) and returns a writer
+// ready to accept segments via Add.
+func newStreamingUploadWriter(ctx context.Context, s *Server, userID, userEmail, filename string) (*streamingUploadWriter, error) {
+	q := dbgen.New(s.DB)
+	if _, err := q.GetUser(ctx, userID); err != nil {
+		err = q.CreateUser(ctx, dbgen.CreateUserParams{
+			ID:               userID,
+			Email:            userEmail,
+			Name:             "",
+			Organization:     "",
+			OrganizationType: "",
+			Role:             "approved",
+			CreatedAt:        time.Now(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create user: %w", err)
+		}
+	}
+	return &streamingUploadWriter{
+		s: s, q: q, ctx: ctx, userID: userID, filename: filename,
+		movementType: "foot", // default, matches persistUpload
+	}, nil
+}
+
+// Add persists one newly-completed segment: its track points (up to the
+// shared maxTrackPointsPerUpload budget) and its effort_data/KPI rollups.
+func (w *streamingUploadWriter) Add(seg gpx.Segment) error {
+	if !w.haveUpload {
+		id, err := w.q.CreateGPXUpload(w.ctx, dbgen.CreateGPXUploadParams{
+			UserID:          w.userID,
+			Filename:        w.filename,
+			MovementType:    w.movementType,
+			ProtectedAreaID: nil,
+			UploadDate:      time.Now(),
+			StartTime:       nil,
+			EndTime:         nil,
+			TotalDistanceKm: 0,
+			TotalPoints:     0,
+		})
+		if err != nil {
+			return fmt.Errorf("create gpx upload: %w", err)
+		}
+		w.uploadID = id
+		w.haveUpload = true
+	}
+
+	w.totalPoints += len(seg.Points)
+	w.totalDistanceKm += seg.DistanceKm
+	if seg.StartTime != nil && (w.startTime == nil || seg.StartTime.Before(*w.startTime)) {
+		w.startTime = seg.StartTime
+	}
+	if seg.EndTime != nil && (w.endTime == nil || seg.EndTime.After(*w.endTime)) {
+		w.endTime = seg.EndTime
+	}
+	if seg.MovementType != "" {
+		w.movementType = seg.MovementType
+	}
+
+	for _, pt := range seg.Points {
+		if w.storedPoints >= maxTrackPointsPerUpload {
+			break
+		}
+		gridCellID := gridCellIDForPoint(pt.Lat, pt.Lon)
+		latCenter, lonCenter := gridCellCenter(pt.Lat, pt.Lon)
+		latMin, latMax, lonMin, lonMax := gridCellBounds(pt.Lat, pt.Lon)
+		_, err := w.q.GetOrCreateGridCell(w.ctx, dbgen.GetOrCreateGridCellParams{
+			ID:        gridCellID,
+			LatCenter: latCenter,
+			LonCenter: lonCenter,
+			LatMin:    latMin,
+			LatMax:    latMax,
+			LonMin:    lonMin,
+			LonMax:    lonMax,
+		})
+		if err != nil {
+			return fmt.Errorf("create grid cell: %w", err)
+		}
+
+		gridCellIDPtr := &gridCellID
+		err = w.q.CreateTrackPoint(w.ctx, dbgen.CreateTrackPointParams{
+			UploadID:   w.uploadID,
+			Lat:        pt.Lat,
+			Lon:        pt.Lon,
+			Elevation:  pt.Elevation,
+			Timestamp:  pt.Time,
+			GridCellID: gridCellIDPtr,
+		})
+		if err != nil {
+			return fmt.Errorf("create track point: %w", err)
+		}
+		w.storedPoints++
+	}
+
+	if err := w.s.updateEffortData(w.ctx, w.q, []gpx.Segment{seg}, w.uploadID); err != nil {
+		return fmt.Errorf("update effort data: %w", err)
+	}
+	if err := w.s.updateUserKPIs(w.ctx, w.q, w.userID, []gpx.Segment{seg}, w.uploadID); err != nil {
+		return fmt.Errorf("update user KPIs: %w", err)
+	}
+	return nil
+}
+
+// Finish writes the gpx_uploads row's final totals now that every segment
+// has been seen. A no-op if Add was never called (e.g. an empty track).
+func (w *streamingUploadWriter) Finish() error {
+	if !w.haveUpload {
+		return nil
+	}
+	return w.q.UpdateGPXUploadStats(w.ctx, dbgen.UpdateGPXUploadStatsParams{
+		ID:              w.uploadID,
+		TotalPoints:     int64(w.totalPoints),
+		TotalDistanceKm: w.totalDistanceKm,
+		StartTime:       w.startTime,
+		EndTime:         w.endTime,
+		MovementType:    w.movementType,
+	})
+}
+
 // samplePoints returns a subset of points, evenly distributed across the input.
 // If len(points) <= maxPoints, returns all points.
 func samplePoints(points []gpx.Point, maxPoints int) []gpx.Point {
@@ -612,21 +936,29 @@ func haversineDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
 func subcellIDForPoint(lat, lon float64) string {
 	// Get the grid cell bounds
 	latMin, _, lonMin, _ := gridCellBounds(lat, lon)
-	
+
 	// Calculate position within the cell (0-1 range)
 	latPos := (lat - latMin) / gridCellSize
 	lonPos := (lon - lonMin) / gridCellSize
-	
+
 	// Convert to subcell index (0-9)
 	row := int(latPos * 10)
 	col := int(lonPos * 10)
-	
+
 	// Clamp to valid range
-	if row < 0 { row = 0 }
-	if row > 9 { row = 9 }
-	if col < 0 { col = 0 }
-	if col > 9 { col = 9 }
-	
+	if row < 0 {
+		row = 0
+	}
+	if row > 9 {
+		row = 9
+	}
+	if col < 0 {
+		col = 0
+	}
+	if col > 9 {
+		col = 9
+	}
+
 	return fmt.Sprintf("%d_%d", row, col)
 }
 
@@ -636,25 +968,25 @@ func (s *Server) trackSubcellVisits(ctx context.Context, q *dbgen.Queries, segme
 	// Track visited subcells per grid cell per day
 	// Key: "gridCellID:subcellID:date" -> true
 	visitedSubcells := make(map[string]bool)
-	
+
 	defaultDate := time.Date(int(defaultYear), time.Month(defaultMonth), 1, 0, 0, 0, 0, time.UTC)
-	
+
 	for _, seg := range segments {
 		for _, pt := range seg.Points {
 			gridCellID := gridCellIDForPoint(pt.Lat, pt.Lon)
 			subcellID := subcellIDForPoint(pt.Lat, pt.Lon)
-			
+
 			// Use point timestamp if available, otherwise default date
 			visitDate := defaultDate
 			if pt.Time != nil {
 				visitDate = time.Date(pt.Time.Year(), pt.Time.Month(), pt.Time.Day(), 0, 0, 0, 0, time.UTC)
 			}
-			
+
 			key := fmt.Sprintf("%s:%s:%s", gridCellID, subcellID, visitDate.Format("2006-01-02"))
 			visitedSubcells[key] = true
 		}
 	}
-	
+
 	// Store subcell visits with day granularity
 	for key := range visitedSubcells {
 		parts := strings.Split(key, ":")
@@ -664,12 +996,12 @@ func (s *Server) trackSubcellVisits(ctx context.Context, q *dbgen.Queries, segme
 		gridCellID := parts[0]
 		subcellID := parts[1]
 		visitDateStr := parts[2]
-		
+
 		visitDate, err := time.Parse("2006-01-02", visitDateStr)
 		if err != nil {
 			continue
 		}
-		
+
 		err = q.UpsertSubcellVisit(ctx, dbgen.UpsertSubcellVisitParams{
 			GridCellID: gridCellID,
 			SubcellID:  subcellID,
@@ -679,6 +1011,6 @@ func (s *Server) trackSubcellVisits(ctx context.Context, q *dbgen.Queries, segme
 			return fmt.Errorf("upsert subcell visit: %w", err)
 		}
 	}
-	
+
 	return nil
 }
@@ -0,0 +1,623 @@
+package srv
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/gpx"
+	"srv.exe.dev/srv/metrics"
+	"srv.exe.dev/srv/track"
+)
+
+// uploadJobWorkers caps the number of goroutines processing uploads concurrently.
+const uploadJobWorkers = 3
+
+// maxConcurrentUploadsPerUser caps how many of one user's jobs the pool will
+// run at once, so a single uploader submitting a batch of large files can't
+// starve every other user's jobs behind them in the queue.
+const maxConcurrentUploadsPerUser = 2
+
+// uploadJobRequeueDelay is how long a job that lost the per-user cap check
+// waits before it's put back on the queue, so the worker that picked it up
+// doesn't just spin re-reading it.
+const uploadJobRequeueDelay = 250 * time.Millisecond
+
+// UploadJob tracks the state of an asynchronously processed upload.
+type UploadJob struct {
+	ID          int64           `json:"id"`
+	UserID      string          `json:"user_id"`
+	Status      string          `json:"status"` // pending, processing, done, error
+	SubmittedAt time.Time       `json:"submitted_at"`
+	ProgressPct int             `json:"progress_pct"`
+	Error       string          `json:"error,omitempty"`
+	Result      *UploadResponse `json:"result,omitempty"`
+}
+
+// UploadQueue spools uploaded files to disk and processes them in the background
+// so HandleAPIUploadAsync can return immediately instead of blocking on the
+// full parse/analyze/persist pipeline.
+type UploadQueue struct {
+	srv      *Server
+	spoolDir string
+
+	mu           sync.Mutex
+	jobs         map[int64]*UploadJob
+	userInFlight map[string]int
+	subscribers  map[int64][]chan UploadJob
+
+	items chan int64
+}
+
+// NewUploadQueue creates an upload queue rooted at spoolDir and starts its
+// worker pool. Call RehydratePending after construction to resume jobs that
+// were left pending across a restart.
+func NewUploadQueue(srv *Server, spoolDir string) (*UploadQueue, error) {
+	if err := os.MkdirAll(spoolDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+	q := &UploadQueue{
+		srv:          srv,
+		spoolDir:     spoolDir,
+		jobs:         make(map[int64]*UploadJob),
+		userInFlight: make(map[string]int),
+		subscribers:  make(map[int64][]chan UploadJob),
+		items:        make(chan int64, 256),
+	}
+	for i := 0; i < uploadJobWorkers; i++ {
+		go q.worker()
+	}
+	return q, nil
+}
+
+// Enqueue spools the given multipart files to disk, creates an UploadJob row,
+// and schedules it for processing. It returns the new job's ID.
+func (q *UploadQueue) Enqueue(ctx context.Context, userID string, files []*multipartFile) (int64, error) {
+	dbQ := dbgen.New(q.srv.DB)
+
+	jobDir := filepath.Join(q.spoolDir, fmt.Sprintf("%s-%d", userID, time.Now().UnixNano()))
+	if err := os.MkdirAll(jobDir, 0o755); err != nil {
+		return 0, fmt.Errorf("create job dir: %w", err)
+	}
+
+	spooledNames := make([]string, 0, len(files))
+	for _, f := range files {
+		dest := filepath.Join(jobDir, filepath.Base(f.Filename))
+		if err := os.WriteFile(dest, f.Data, 0o644); err != nil {
+			return 0, fmt.Errorf("spool file %q: %w", f.Filename, err)
+		}
+		spooledNames = append(spooledNames, dest)
+	}
+
+	now := time.Now()
+	jobID, err := dbQ.CreateUploadJob(ctx, dbgen.CreateUploadJobParams{
+		UserID:      userID,
+		Status:      "pending",
+		SubmittedAt: now,
+		ProgressPct: 0,
+		SpoolDir:    jobDir,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("create upload job: %w", err)
+	}
+
+	job := &UploadJob{ID: jobID, UserID: userID, Status: "pending", SubmittedAt: now}
+	q.mu.Lock()
+	q.jobs[jobID] = job
+	q.mu.Unlock()
+	q.reportIngestJobMetrics()
+
+	q.items <- jobID
+	return jobID, nil
+}
+
+// multipartFile is a spooled upload's filename and raw bytes.
+type multipartFile struct {
+	Filename string
+	Data     []byte
+}
+
+// RehydratePending reloads jobs that were left in "pending" or "processing"
+// state in the database (e.g. because the process restarted) and re-queues
+// them for processing.
+func (q *UploadQueue) RehydratePending(ctx context.Context) error {
+	dbQ := dbgen.New(q.srv.DB)
+	rows, err := dbQ.ListPendingUploadJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("list pending upload jobs: %w", err)
+	}
+	for _, row := range rows {
+		q.mu.Lock()
+		q.jobs[row.ID] = &UploadJob{
+			ID:          row.ID,
+			UserID:      row.UserID,
+			Status:      "pending",
+			SubmittedAt: row.SubmittedAt,
+		}
+		q.mu.Unlock()
+		q.items <- row.ID
+	}
+	if len(rows) > 0 {
+		slog.Info("rehydrated pending upload jobs", "count", len(rows))
+		q.reportIngestJobMetrics()
+	}
+	return nil
+}
+
+// worker pulls job IDs off the queue and runs the processing pipeline.
+func (q *UploadQueue) worker() {
+	for jobID := range q.items {
+		q.process(jobID)
+	}
+}
+
+func (q *UploadQueue) process(jobID int64) {
+	ctx := context.Background()
+	dbQ := dbgen.New(q.srv.DB)
+
+	q.mu.Lock()
+	job := q.jobs[jobID]
+	q.mu.Unlock()
+	if job == nil {
+		slog.Error("upload job missing from memory", "job_id", jobID)
+		return
+	}
+
+	if !q.acquireUserSlot(job.UserID) {
+		go func() {
+			time.Sleep(uploadJobRequeueDelay)
+			q.items <- jobID
+		}()
+		return
+	}
+	defer q.releaseUserSlot(job.UserID)
+
+	q.setStatus(ctx, dbQ, jobID, "processing", 0, "")
+
+	row, err := dbQ.GetUploadJob(ctx, jobID)
+	if err != nil {
+		q.setStatus(ctx, dbQ, jobID, "error", 0, err.Error())
+		return
+	}
+
+	entries, err := os.ReadDir(row.SpoolDir)
+	if err != nil {
+		q.setStatus(ctx, dbQ, jobID, "error", 0, err.Error())
+		return
+	}
+
+	var (
+		totalPoints     int
+		totalDistanceKm float64
+		allSegments     []SegmentSummary
+		filesProcessed  int
+	)
+
+	for i, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(row.SpoolDir, entry.Name())
+		if err := q.processSpooledFile(ctx, path, row.UserID, &totalPoints, &totalDistanceKm, &allSegments, &filesProcessed); err != nil {
+			slog.Warn("failed to process spooled upload file", "path", path, "error", err)
+		}
+		pct := int(float64(i+1) / float64(len(entries)) * 100)
+		q.setStatus(ctx, dbQ, jobID, "processing", pct, "")
+	}
+
+	result := UploadResponse{
+		FilesProcessed:  filesProcessed,
+		TotalPoints:     totalPoints,
+		TotalDistanceKm: totalDistanceKm,
+		Segments:        allSegments,
+	}
+	resultJSON, _ := json.Marshal(result)
+
+	if err := dbQ.CompleteUploadJob(ctx, dbgen.CompleteUploadJobParams{
+		ID:     jobID,
+		Result: string(resultJSON),
+	}); err != nil {
+		slog.Error("failed to mark upload job complete", "job_id", jobID, "error", err)
+	}
+
+	q.mu.Lock()
+	job.Status = "done"
+	job.ProgressPct = 100
+	job.Result = &result
+	subs := q.subscribers[jobID]
+	snapshot := *job
+	q.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- snapshot:
+		default:
+		}
+	}
+	q.reportIngestJobMetrics()
+
+	os.RemoveAll(row.SpoolDir)
+}
+
+// processSpooledFile handles a single spooled file (or zip of files) using
+// the same parse/analyze/persist pipeline as the synchronous handler:
+// track.Parse, gpx.SplitIntoSegments, then persistUpload (which itself runs
+// updateEffortData and trackSubcellVisits). track.Parse dispatches by
+// filename/content to whichever registered format parser matches (GPX,
+// FIT, TCX, KML/KMZ, GeoJSON).
+func (q *UploadQueue) processSpooledFile(ctx context.Context, path, userID string, totalPoints *int, totalDistanceKm *float64, allSegments *[]SegmentSummary, filesProcessed *int) error {
+	s := q.srv
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(filepath.Base(path))
+	process := func(filename string, r io.Reader) error {
+		gpxData, err := track.Parse(filename, r)
+		if err != nil {
+			return err
+		}
+		*filesProcessed++
+
+		for _, trk := range gpxData.Tracks {
+			for _, trackSeg := range trk.Segments {
+				*totalPoints += len(trackSeg)
+			}
+		}
+
+		segments := gpx.SplitIntoSegments(gpxData, 0)
+		for _, seg := range segments {
+			if len(seg.Points) < 2 || seg.DistanceKm < 0.001 {
+				continue
+			}
+			*totalDistanceKm += seg.DistanceKm
+			*allSegments = append(*allSegments, SegmentSummary{
+				StartTime:    seg.StartTime,
+				EndTime:      seg.EndTime,
+				MovementType: seg.MovementType,
+				DistanceKm:   seg.DistanceKm,
+				Points:       len(seg.Points),
+			})
+		}
+
+		if s.DB != nil {
+			incidents := gpx.ExtractIncidents(gpxData)
+			if err := s.persistUpload(ctx, userID, userID, filename, segments, incidents); err != nil {
+				slog.Warn("failed to persist async upload", "error", err, "filename", filename)
+			}
+		}
+		return nil
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		for _, zf := range zr.File {
+			zfName := strings.ToLower(zf.Name)
+			if strings.Contains(zfName, "__macosx") || !isSupportedTrackFile(zfName) {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				continue
+			}
+			if err := process(zf.Name, rc); err != nil {
+				slog.Debug("failed to parse track file from zip", "file", zf.Name, "error", err)
+			}
+			rc.Close()
+		}
+		return nil
+	}
+
+	if !isSupportedTrackFile(name) {
+		return fmt.Errorf("unsupported spooled file type: %s", name)
+	}
+	return process(filepath.Base(path), bytes.NewReader(data))
+}
+
+func (q *UploadQueue) setStatus(ctx context.Context, dbQ *dbgen.Queries, jobID int64, status string, pct int, errMsg string) {
+	if err := dbQ.UpdateUploadJobStatus(ctx, dbgen.UpdateUploadJobStatusParams{
+		ID:          jobID,
+		Status:      status,
+		ProgressPct: int64(pct),
+		Error:       errMsg,
+	}); err != nil {
+		slog.Warn("failed to update upload job status", "job_id", jobID, "error", err)
+	}
+
+	q.mu.Lock()
+	job := q.jobs[jobID]
+	if job != nil {
+		job.Status = status
+		job.ProgressPct = pct
+		job.Error = errMsg
+	}
+	subs := q.subscribers[jobID]
+	q.mu.Unlock()
+
+	if job == nil {
+		return
+	}
+	snapshot := *job
+	for _, ch := range subs {
+		select {
+		case ch <- snapshot:
+		default:
+		}
+	}
+
+	q.reportIngestJobMetrics()
+}
+
+// reportIngestJobMetrics recomputes the ingest_jobs gauge for every
+// known status by scanning q.jobs, so a status transition always
+// increments the new bucket and decrements the old one together
+// instead of drifting if one side of an increment/decrement pair were
+// ever missed.
+func (q *UploadQueue) reportIngestJobMetrics() {
+	counts := map[string]int{"pending": 0, "processing": 0, "done": 0, "error": 0}
+	q.mu.Lock()
+	for _, j := range q.jobs {
+		counts[j.Status]++
+	}
+	q.mu.Unlock()
+	for status, count := range counts {
+		metrics.IngestJobs.WithLabelValues(status).Set(float64(count))
+	}
+}
+
+// acquireUserSlot reports whether userID has room under
+// maxConcurrentUploadsPerUser, reserving a slot if so.
+func (q *UploadQueue) acquireUserSlot(userID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.userInFlight[userID] >= maxConcurrentUploadsPerUser {
+		return false
+	}
+	q.userInFlight[userID]++
+	return true
+}
+
+// releaseUserSlot frees the slot a matching acquireUserSlot reserved.
+func (q *UploadQueue) releaseUserSlot(userID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.userInFlight[userID]--
+	if q.userInFlight[userID] <= 0 {
+		delete(q.userInFlight, userID)
+	}
+}
+
+// Subscribe returns a channel that receives a snapshot of jobID's state on
+// every status change, for HandleAPIUploadJobStream's SSE feed. Call the
+// returned function to unsubscribe and release the channel.
+func (q *UploadQueue) Subscribe(jobID int64) (<-chan UploadJob, func()) {
+	ch := make(chan UploadJob, 8)
+	q.mu.Lock()
+	q.subscribers[jobID] = append(q.subscribers[jobID], ch)
+	q.mu.Unlock()
+
+	unsubscribe := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		subs := q.subscribers[jobID]
+		for i, c := range subs {
+			if c == ch {
+				q.subscribers[jobID] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(q.subscribers[jobID]) == 0 {
+			delete(q.subscribers, jobID)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// HandleAPIUploadAsync spools the uploaded files and enqueues them for
+// background processing, returning 202 with the job ID instead of blocking
+// on the full pipeline like HandleAPIUpload.
+func (s *Server) HandleAPIUploadAsync(w http.ResponseWriter, r *http.Request) {
+	user := s.Auth.GetUserFromRequest(r)
+	if user == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+		return
+	}
+
+	if s.UploadQueue == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "upload queue not configured"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to parse form: " + err.Error()})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	headers := r.MultipartForm.File["gpx"]
+	if len(headers) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no GPX files provided"})
+		return
+	}
+
+	files := make([]*multipartFile, 0, len(headers))
+	for _, h := range headers {
+		f, err := h.Open()
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		files = append(files, &multipartFile{Filename: h.Filename, Data: data})
+	}
+
+	jobID, err := s.UploadQueue.Enqueue(r.Context(), user.ID, files)
+	if err != nil {
+		slog.Error("failed to enqueue upload job", "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to enqueue upload"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"job_id": jobID, "status": "pending"})
+}
+
+// HandleAPIUploadJob returns the status (and, once complete, the result) of
+// a previously enqueued upload job.
+func (s *Server) HandleAPIUploadJob(w http.ResponseWriter, r *http.Request) {
+	if s.UploadQueue == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "upload queue not configured"})
+		return
+	}
+
+	idStr := r.PathValue("id")
+	var jobID int64
+	if _, err := fmt.Sscanf(idStr, "%d", &jobID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid job id"})
+		return
+	}
+
+	s.UploadQueue.mu.Lock()
+	job := s.UploadQueue.jobs[jobID]
+	s.UploadQueue.mu.Unlock()
+	if job == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "job not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleAPIUploadJobStream streams a job's status as Server-Sent Events so
+// the upload page can show live progress instead of polling HandleAPIUploadJob.
+func (s *Server) HandleAPIUploadJobStream(w http.ResponseWriter, r *http.Request) {
+	if s.UploadQueue == nil {
+		http.Error(w, "upload queue not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	var jobID int64
+	if _, err := fmt.Sscanf(idStr, "%d", &jobID); err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	s.UploadQueue.mu.Lock()
+	job := s.UploadQueue.jobs[jobID]
+	s.UploadQueue.mu.Unlock()
+	if job == nil {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	updates, unsubscribe := s.UploadQueue.Subscribe(jobID)
+	defer unsubscribe()
+
+	write := func(j UploadJob) bool {
+		data, err := json.Marshal(j)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return j.Status != "done" && j.Status != "error"
+	}
+
+	if !write(*job) {
+		return
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-updates:
+			if !write(j) {
+				return
+			}
+		}
+	}
+}
+
+// HandleAPIUploadJobsList lists upload jobs for a given user.
+// Query params:
+//   - user: user ID to filter by (required)
+func (s *Server) HandleAPIUploadJobsList(w http.ResponseWriter, r *http.Request) {
+	if s.UploadQueue == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "upload queue not configured"})
+		return
+	}
+
+	userID := r.URL.Query().Get("user")
+	if userID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "missing user parameter"})
+		return
+	}
+
+	s.UploadQueue.mu.Lock()
+	jobs := make([]*UploadJob, 0)
+	for _, job := range s.UploadQueue.jobs {
+		if job.UserID == userID {
+			jobs = append(jobs, job)
+		}
+	}
+	s.UploadQueue.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
@@ -0,0 +1,245 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/gpx"
+)
+
+// updateUserKPIs incrementally upserts user_kpi_daily/user_kpi_monthly rows
+// for the segments in an upload. It is called from persistUpload alongside
+// updateEffortData so per-user analytics stay in sync with the effort grid
+// without a separate full-table pass.
+func (s *Server) updateUserKPIs(ctx context.Context, q *dbgen.Queries, userID string, segments []gpx.Segment, uploadID int64) error {
+	type dayKey struct {
+		Date         string
+		MovementType string
+	}
+	perDay := make(map[dayKey]*userKPIDelta)
+
+	for _, seg := range segments {
+		if len(seg.Points) == 0 {
+			continue
+		}
+		day := "unknown"
+		if seg.StartTime != nil {
+			day = seg.StartTime.Format("2006-01-02")
+		}
+		key := dayKey{Date: day, MovementType: seg.MovementType}
+		delta := perDay[key]
+		if delta == nil {
+			delta = &userKPIDelta{gridCells: make(map[string]bool), subcells: make(map[string]bool), paDistance: make(map[string]float64)}
+			perDay[key] = delta
+		}
+		delta.distanceKm += seg.DistanceKm
+
+		areaID := "outside"
+		if len(seg.Points) > 0 && s.AreaStore != nil {
+			if area := s.AreaStore.FindArea(seg.Points[0].Lat, seg.Points[0].Lon); area != nil {
+				areaID = area.ID
+			}
+		}
+		delta.paDistance[areaID] += seg.DistanceKm
+
+		for _, pt := range seg.Points {
+			delta.gridCells[gridCellIDForPoint(pt.Lat, pt.Lon)] = true
+			delta.subcells[gridCellIDForPoint(pt.Lat, pt.Lon)+":"+subcellIDForPoint(pt.Lat, pt.Lon)] = true
+		}
+	}
+
+	for key, delta := range perDay {
+		paJSON, _ := json.Marshal(delta.paDistance)
+		if err := q.UpsertUserKPIDaily(ctx, dbgen.UpsertUserKPIDailyParams{
+			UserID:          userID,
+			Date:            key.Date,
+			MovementType:    key.MovementType,
+			DistanceKm:      delta.distanceKm,
+			GridCells:       int64(len(delta.gridCells)),
+			Subcells:        int64(len(delta.subcells)),
+			PaDistanceJson:  string(paJSON),
+		}); err != nil {
+			return fmt.Errorf("upsert user_kpi_daily for %s/%s: %w", userID, key.Date, err)
+		}
+
+		month := key.Date
+		if t, err := time.Parse("2006-01-02", key.Date); err == nil {
+			month = t.Format("2006-01")
+		}
+		if err := q.UpsertUserKPIMonthly(ctx, dbgen.UpsertUserKPIMonthlyParams{
+			UserID:       userID,
+			Month:        month,
+			MovementType: key.MovementType,
+			DistanceKm:   delta.distanceKm,
+			GridCells:    int64(len(delta.gridCells)),
+			Subcells:     int64(len(delta.subcells)),
+		}); err != nil {
+			return fmt.Errorf("upsert user_kpi_monthly for %s/%s: %w", userID, month, err)
+		}
+	}
+
+	return nil
+}
+
+// userKPIDelta accumulates the per-day increments contributed by a single
+// upload before they are upserted into the rollup tables.
+type userKPIDelta struct {
+	distanceKm float64
+	gridCells  map[string]bool
+	subcells   map[string]bool
+	paDistance map[string]float64
+}
+
+// ReconcileUserKPIs recomputes user_kpi_daily/user_kpi_monthly from
+// track_points, correcting any drift accumulated by the incremental
+// upserts in updateUserKPIs. Intended to run nightly via a background
+// goroutine, mirroring the cadence of StartResearchWorker.
+func (s *Server) ReconcileUserKPIs(ctx context.Context) error {
+	q := dbgen.New(s.DB)
+
+	rows, err := q.ListTrackPointsForReconciliation(ctx)
+	if err != nil {
+		return fmt.Errorf("list track points: %w", err)
+	}
+
+	type key struct {
+		UserID, Date, MovementType string
+	}
+	totals := make(map[key]*userKPIDelta)
+
+	for _, row := range rows {
+		if row.Timestamp == nil {
+			continue
+		}
+		k := key{UserID: row.UserID, Date: row.Timestamp.Format("2006-01-02"), MovementType: row.MovementType}
+		d := totals[k]
+		if d == nil {
+			d = &userKPIDelta{gridCells: make(map[string]bool), subcells: make(map[string]bool), paDistance: make(map[string]float64)}
+			totals[k] = d
+		}
+		if row.GridCellID != nil {
+			d.gridCells[*row.GridCellID] = true
+		}
+		d.subcells[subcellIDForPoint(row.Lat, row.Lon)] = true
+	}
+
+	for k, d := range totals {
+		paJSON, _ := json.Marshal(d.paDistance)
+		if err := q.UpsertUserKPIDaily(ctx, dbgen.UpsertUserKPIDailyParams{
+			UserID:         k.UserID,
+			Date:           k.Date,
+			MovementType:   k.MovementType,
+			DistanceKm:     d.distanceKm,
+			GridCells:      int64(len(d.gridCells)),
+			Subcells:       int64(len(d.subcells)),
+			PaDistanceJson: string(paJSON),
+		}); err != nil {
+			slog.Warn("reconcile: failed to upsert user_kpi_daily", "user_id", k.UserID, "date", k.Date, "error", err)
+		}
+	}
+
+	slog.Info("reconciled user KPIs", "rows", len(rows), "groups", len(totals))
+	return nil
+}
+
+// StartUserKPIReconciler runs ReconcileUserKPIs once a day.
+func (s *Server) StartUserKPIReconciler(ctx context.Context) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ReconcileUserKPIs(ctx); err != nil {
+				slog.Error("user KPI reconciliation failed", "error", err)
+			}
+		}
+	}
+}
+
+// HandleAPIUserKPI returns a user's KPI rollup for a date range at the
+// requested granularity.
+// GET /api/users/{id}/kpi?from=2024-01-01&to=2024-12-31&granularity=day|month
+func (s *Server) HandleAPIUserKPI(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = "month"
+	}
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+
+	ctx := r.Context()
+	q := dbgen.New(s.DB)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if granularity == "day" {
+		rows, err := q.GetUserKPIDaily(ctx, dbgen.GetUserKPIDailyParams{UserID: userID, FromDate: from, ToDate: to})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+			return
+		}
+		json.NewEncoder(w).Encode(rows)
+		return
+	}
+
+	rows, err := q.GetUserKPIMonthly(ctx, dbgen.GetUserKPIMonthlyParams{UserID: userID, FromMonth: from, ToMonth: to})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+	json.NewEncoder(w).Encode(rows)
+}
+
+// HandleAPIKPILeaderboard ranks approved, non-private users within a
+// protected area by the requested metric.
+// GET /api/kpi/leaderboard?metric=coverage_subcells&area=serengeti
+func (s *Server) HandleAPIKPILeaderboard(w http.ResponseWriter, r *http.Request) {
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = "distance_km"
+	}
+	area := r.URL.Query().Get("area")
+
+	validMetrics := map[string]bool{"distance_km": true, "coverage_subcells": true, "coverage_grid_cells": true, "active_days": true}
+	if !validMetrics[metric] {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unknown metric: " + metric})
+		return
+	}
+
+	ctx := r.Context()
+	q := dbgen.New(s.DB)
+
+	// Leaderboard rows only cover approved users and exclude anyone who
+	// has opted their uploads out of public visibility, matching the
+	// role model already enforced on login (auth.Manager.Login rejects
+	// "pending" accounts).
+	rows, err := q.GetKPILeaderboard(ctx, dbgen.GetKPILeaderboardParams{
+		Metric: metric,
+		AreaID: area,
+		Role:   "approved",
+	})
+	if err != nil {
+		slog.Error("failed to compute KPI leaderboard", "metric", metric, "area", area, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "database error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(rows)
+}
@@ -0,0 +1,337 @@
+// Package vault encrypts uploaded fire/GHSL raw data at rest. Fire CSVs
+// and extracted GHSL tiles carry sensitive location/observation data,
+// so once an operator configures a passphrase, everything this package
+// writes to disk is AES-256-GCM ciphertext and the plaintext key never
+// touches disk itself.
+//
+// A Vault's key is derived from an operator-supplied passphrase via
+// scrypt, salted by a per-deployment file created on first use. Because
+// files processed here (GHSL tiles especially) can run to gigabytes,
+// encryption is chunked rather than a single GCM seal over the whole
+// file: EncryptWriter/DecryptReader implement a STREAM-style framing
+// (a random per-file base nonce followed by length-prefixed,
+// individually-sealed chunks, the final one tagged as such in its
+// associated data) so a truncated or reordered ciphertext fails to
+// decrypt instead of silently returning partial plaintext.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 12
+	chunkSize = 1 << 20 // 1MiB plaintext per sealed chunk
+
+	// lastChunkFlag marks a chunk's length prefix (and is folded into
+	// its AEAD associated data) as the final chunk of the stream, so a
+	// truncation attack can't pass off an earlier chunk as the end.
+	lastChunkFlag = 1 << 31
+)
+
+// saltSize is the size of the per-deployment scrypt salt persisted
+// alongside the vault (not secret, just unique per install).
+const saltSize = 16
+
+// Vault wraps writers/readers with AES-256-GCM encryption using a key
+// derived once from an operator passphrase.
+type Vault struct {
+	aead cipher.AEAD
+}
+
+// New derives a Vault's key via scrypt from passphrase and the salt
+// stored (or created, on first run) at saltPath.
+func New(passphrase, saltPath string) (*Vault, error) {
+	salt, err := loadOrCreateSalt(saltPath)
+	if err != nil {
+		return nil, fmt.Errorf("load vault salt: %w", err)
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive vault key: %w", err)
+	}
+	return fromKey(key)
+}
+
+func fromKey(key []byte) (*Vault, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("init vault cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("init vault GCM: %w", err)
+	}
+	return &Vault{aead: aead}, nil
+}
+
+func loadOrCreateSalt(path string) ([]byte, error) {
+	salt, err := os.ReadFile(path)
+	if err == nil {
+		return salt, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	salt = make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, salt, 0o600); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// EncryptWriter wraps w so every Write is buffered into chunkSize
+// plaintext chunks and flushed as sealed ciphertext. The caller must
+// Close it to flush the final (possibly short) chunk; the returned
+// writer is not safe for concurrent use.
+func (v *Vault) EncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate vault nonce: %w", err)
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return nil, fmt.Errorf("write vault nonce header: %w", err)
+	}
+	return &encryptWriter{w: w, aead: v.aead, baseNonce: nonce, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+type encryptWriter struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	baseNonce []byte
+	buf       []byte
+	chunkNum  uint32
+	closed    bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+		if len(e.buf) == cap(e.buf) {
+			if err := e.flushChunk(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) flushChunk(last bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.baseNonce, e.chunkNum), e.buf, chunkAAD(last))
+
+	lenField := uint32(len(sealed))
+	if last {
+		lenField |= lastChunkFlag
+	}
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], lenField)
+	if _, err := e.w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+
+	e.chunkNum++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// Close flushes the final chunk. Writing nothing at all still produces
+// a valid (empty-plaintext) final chunk, so a zero-byte source file
+// round-trips correctly.
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flushChunk(true)
+}
+
+// DecryptReader wraps r, returning a reader over the plaintext that
+// EncryptWriter produced. It returns an error if the stream ends before
+// a chunk tagged "last" is read, rejecting truncated ciphertext.
+func (v *Vault) DecryptReader(r io.Reader) (io.Reader, error) {
+	baseNonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(r, baseNonce); err != nil {
+		return nil, fmt.Errorf("read vault nonce header: %w", err)
+	}
+	return &decryptReader{r: r, aead: v.aead, baseNonce: baseNonce}, nil
+}
+
+type decryptReader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	baseNonce []byte
+	chunkNum  uint32
+	buf       []byte
+	done      bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+
+		var header [4]byte
+		if _, err := io.ReadFull(d.r, header[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return 0, fmt.Errorf("vault: ciphertext truncated before final chunk")
+			}
+			return 0, err
+		}
+		lenField := binary.BigEndian.Uint32(header[:])
+		last := lenField&lastChunkFlag != 0
+		sealed := make([]byte, lenField&^lastChunkFlag)
+		if _, err := io.ReadFull(d.r, sealed); err != nil {
+			return 0, fmt.Errorf("vault: read chunk %d: %w", d.chunkNum, err)
+		}
+
+		plain, err := d.aead.Open(nil, chunkNonce(d.baseNonce, d.chunkNum), sealed, chunkAAD(last))
+		if err != nil {
+			return 0, fmt.Errorf("vault: decrypt chunk %d: %w", d.chunkNum, err)
+		}
+
+		d.buf = plain
+		d.chunkNum++
+		d.done = last
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// chunkNonce derives a unique per-chunk nonce from the file's random
+// base nonce by XORing in the chunk counter, avoiding the need for a
+// fresh random nonce (and its own header) per chunk.
+func chunkNonce(base []byte, chunkNum uint32) []byte {
+	nonce := make([]byte, len(base))
+	copy(nonce, base)
+	last := len(nonce) - 4
+	binary.BigEndian.PutUint32(nonce[last:], binary.BigEndian.Uint32(nonce[last:])^chunkNum)
+	return nonce
+}
+
+// chunkAAD binds the "is this the final chunk" flag to the AEAD tag so
+// an attacker can't flip it (or strip trailing chunks) without the
+// decryption failing.
+func chunkAAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// wipeChunkSize bounds how much zero-fill Wipe writes per pass so it
+// doesn't need to hold a whole large file's size in memory at once.
+const wipeChunkSize = 1 << 20
+
+// Wipe best-effort overwrites path with zeros before removing it. It's
+// "best-effort" because on most filesystems (copy-on-write, SSD wear
+// leveling, journaling) an overwrite isn't a cryptographic guarantee
+// the old bytes are gone — it's a defense-in-depth step on top of the
+// data being ciphertext in the first place, not a substitute for it.
+func Wipe(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return os.RemoveAll(path)
+	}
+
+	if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		zeros := make([]byte, wipeChunkSize)
+		remaining := info.Size()
+		for remaining > 0 {
+			n := int64(len(zeros))
+			if remaining < n {
+				n = remaining
+			}
+			if _, err := f.Write(zeros[:n]); err != nil {
+				break
+			}
+			remaining -= n
+		}
+		f.Sync()
+		f.Close()
+	}
+
+	return os.Remove(path)
+}
+
+// WipeDir best-effort wipes every regular file under dir (as Wipe does)
+// before removing dir itself.
+func WipeDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		path := dir + string(os.PathSeparator) + entry.Name()
+		if entry.IsDir() {
+			if err := WipeDir(path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := Wipe(path); err != nil {
+			return err
+		}
+	}
+	return os.Remove(dir)
+}
+
+// ResolvePassphrase reads the vault passphrase an operator configured,
+// preferring an explicit locked file (permissions checked to reject
+// anything group/world readable) over the SRV_VAULT_PASSPHRASE
+// environment variable. It returns "" with no error if neither is set,
+// meaning at-rest encryption is disabled.
+func ResolvePassphrase(passphraseFile string) (string, error) {
+	if passphraseFile == "" {
+		return os.Getenv("SRV_VAULT_PASSPHRASE"), nil
+	}
+
+	info, err := os.Stat(passphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("stat vault passphrase file: %w", err)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("vault passphrase file %s must not be readable by group/other (mode %o)", passphraseFile, info.Mode().Perm())
+	}
+	data, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return "", fmt.Errorf("read vault passphrase file: %w", err)
+	}
+	return string(bytesTrimNewline(data)), nil
+}
+
+func bytesTrimNewline(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,172 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func testVault(t *testing.T) *Vault {
+	t.Helper()
+	key := bytes.Repeat([]byte{0x42}, keySize)
+	v, err := fromKey(key)
+	if err != nil {
+		t.Fatalf("fromKey: %v", err)
+	}
+	return v
+}
+
+func encrypt(t *testing.T, v *Vault, plaintext []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := v.EncryptWriter(&buf)
+	if err != nil {
+		t.Fatalf("EncryptWriter: %v", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTripSmall(t *testing.T) {
+	v := testVault(t)
+	plaintext := []byte("fire detections for park abc123")
+
+	ciphertext := encrypt(t, v, plaintext)
+
+	r, err := v.DecryptReader(bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("round trip mismatch: got %q, want %q", got, plaintext)
+	}
+}
+
+func TestRoundTripEmpty(t *testing.T) {
+	v := testVault(t)
+
+	ciphertext := encrypt(t, v, nil)
+
+	r, err := v.DecryptReader(bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected empty plaintext, got %d bytes", len(got))
+	}
+}
+
+func TestRoundTripMultipleChunks(t *testing.T) {
+	v := testVault(t)
+	// Larger than chunkSize so EncryptWriter seals more than one chunk,
+	// exercising chunkNonce's per-chunk counter and the final chunk's
+	// "last" flag landing on a chunk boundary rather than a short tail.
+	plaintext := bytes.Repeat([]byte("x"), chunkSize*2+17)
+
+	ciphertext := encrypt(t, v, plaintext)
+
+	r, err := v.DecryptReader(bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("round trip mismatch over %d bytes", len(plaintext))
+	}
+}
+
+func TestDecryptRejectsTruncatedStream(t *testing.T) {
+	v := testVault(t)
+	plaintext := bytes.Repeat([]byte("y"), chunkSize*2+17)
+	ciphertext := encrypt(t, v, plaintext)
+
+	// Cut the stream off partway through the second chunk, well before
+	// the final chunk ever arrives.
+	truncated := ciphertext[:len(ciphertext)-100]
+
+	r, err := v.DecryptReader(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected truncated ciphertext to be rejected, got nil error")
+	}
+}
+
+func TestDecryptRejectsFlippedLastChunkFlag(t *testing.T) {
+	v := testVault(t)
+	plaintext := bytes.Repeat([]byte("z"), chunkSize+1) // forces 2 chunks
+	ciphertext := encrypt(t, v, plaintext)
+
+	// The first chunk's 4-byte length header starts at offset nonceSize;
+	// flip its lastChunkFlag bit to claim it's the final chunk, which
+	// changes the associated data VerifyRegistration's AEAD tag was
+	// computed over and must make decryption of that chunk fail.
+	tampered := append([]byte{}, ciphertext...)
+	firstHeader := tampered[nonceSize : nonceSize+4]
+	lenField := binary.BigEndian.Uint32(firstHeader)
+	binary.BigEndian.PutUint32(firstHeader, lenField|lastChunkFlag)
+
+	r, err := v.DecryptReader(bytes.NewReader(tampered))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected flipped last-chunk flag to be rejected, got nil error")
+	}
+}
+
+func TestDecryptRejectsTamperedCiphertext(t *testing.T) {
+	v := testVault(t)
+	plaintext := []byte("ranger patrol coverage grid cell 42")
+	ciphertext := encrypt(t, v, plaintext)
+
+	tampered := append([]byte{}, ciphertext...)
+	// Flip a byte inside the sealed chunk (past the nonce + length
+	// header), which should fail the GCM tag check.
+	tampered[len(tampered)-1] ^= 0xff
+
+	r, err := v.DecryptReader(bytes.NewReader(tampered))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected tampered ciphertext to be rejected, got nil error")
+	}
+}
+
+func TestDecryptRejectsWrongKey(t *testing.T) {
+	v := testVault(t)
+	plaintext := []byte("should not decrypt with another vault's key")
+	ciphertext := encrypt(t, v, plaintext)
+
+	other, err := fromKey(bytes.Repeat([]byte{0x99}, keySize))
+	if err != nil {
+		t.Fatalf("fromKey: %v", err)
+	}
+
+	r, err := other.DecryptReader(bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatalf("DecryptReader: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected decryption under the wrong key to fail, got nil error")
+	}
+}
@@ -0,0 +1,375 @@
+package srv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"srv.exe.dev/db/dbgen"
+	"srv.exe.dev/srv/auth"
+	"srv.exe.dev/srv/auth/webauthn"
+)
+
+// publicKeyCredParam mirrors the WebAuthn PublicKeyCredentialParameters
+// dictionary; this server only ever offers ES256/RS256 since those are
+// the two algorithms VerifyAssertion knows how to check.
+type publicKeyCredParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+var webauthnPubKeyCredParams = []publicKeyCredParam{
+	{Type: "public-key", Alg: webauthn.COSEAlgES256},
+	{Type: "public-key", Alg: webauthn.COSEAlgRS256},
+}
+
+type credentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"` // base64url
+}
+
+// registrationOptions is the PublicKeyCredentialCreationOptions sent to
+// the browser from /api/webauthn/register/begin.
+type registrationOptions struct {
+	Handle    string `json:"handle"` // opaque ceremony handle, echoed back on finish
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []publicKeyCredParam   `json:"pubKeyCredParams"`
+	ExcludeCredentials     []credentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection struct {
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+}
+
+// HandleAPIWebAuthnRegisterBegin starts a credential-registration
+// ceremony for the logged-in user.
+// POST /api/webauthn/register/begin
+func (s *Server) HandleAPIWebAuthnRegisterBegin(w http.ResponseWriter, r *http.Request) {
+	user := s.Auth.GetUserFromRequest(r)
+	w.Header().Set("Content-Type", "application/json")
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+		return
+	}
+
+	handle, challenge, err := s.WebAuthnChallenges.NewChallenge(user.ID, user.Email)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to start registration"})
+		return
+	}
+
+	existing, err := dbgen.New(s.DB).ListWebAuthnCredentialsByUser(r.Context(), user.ID)
+	if err != nil {
+		existing = nil
+	}
+	exclude := make([]credentialDescriptor, 0, len(existing))
+	for _, c := range existing {
+		exclude = append(exclude, credentialDescriptor{Type: "public-key", ID: base64.RawURLEncoding.EncodeToString([]byte(c.CredentialID))})
+	}
+
+	opts := registrationOptions{
+		Handle:             handle,
+		Challenge:          base64.RawURLEncoding.EncodeToString(challenge),
+		PubKeyCredParams:   webauthnPubKeyCredParams,
+		ExcludeCredentials: exclude,
+	}
+	opts.RP.ID = s.WebAuthnRP.ID
+	opts.RP.Name = s.WebAuthnRP.Name
+	opts.User.ID = base64.RawURLEncoding.EncodeToString([]byte(user.ID))
+	opts.User.Name = user.Email
+	opts.User.DisplayName = user.Name
+	opts.AuthenticatorSelection.UserVerification = "preferred"
+
+	json.NewEncoder(w).Encode(opts)
+}
+
+type registerFinishRequest struct {
+	Handle            string `json:"handle"`
+	ClientDataJSON    string `json:"client_data_json"`
+	AttestationObject string `json:"attestation_object"`
+	Transports        string `json:"transports"`
+}
+
+// HandleAPIWebAuthnRegisterFinish verifies and stores a newly created
+// credential.
+// POST /api/webauthn/register/finish
+func (s *Server) HandleAPIWebAuthnRegisterFinish(w http.ResponseWriter, r *http.Request) {
+	user := s.Auth.GetUserFromRequest(r)
+	w.Header().Set("Content-Type", "application/json")
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+		return
+	}
+
+	var req registerFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	challenge, challengeUserID, _, err := s.WebAuthnChallenges.Take(req.Handle)
+	if err != nil || challengeUserID != user.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ceremony expired or invalid"})
+		return
+	}
+
+	clientDataJSON, err1 := base64.RawURLEncoding.DecodeString(req.ClientDataJSON)
+	attestationObject, err2 := base64.RawURLEncoding.DecodeString(req.AttestationObject)
+	if err1 != nil || err2 != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid base64url encoding"})
+		return
+	}
+
+	cred, err := s.WebAuthnRP.VerifyRegistration(clientDataJSON, attestationObject, challenge, user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "verification failed: " + err.Error()})
+		return
+	}
+	cred.Transports = req.Transports
+
+	if err := dbgen.New(s.DB).CreateWebAuthnCredential(r.Context(), dbgen.CreateWebAuthnCredentialParams{
+		CredentialID: string(cred.ID),
+		UserID:       cred.UserID,
+		PublicKey:    cred.PublicKey,
+		SignCount:    int64(cred.SignCount),
+		Transports:   cred.Transports,
+		Aaguid:       cred.AAGUID,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to store credential"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+}
+
+// assertionOptions is the PublicKeyCredentialRequestOptions sent from
+// /api/webauthn/login/begin.
+type assertionOptions struct {
+	Handle           string                 `json:"handle"`
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []credentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// HandleAPIWebAuthnLoginBegin starts an assertion ceremony for the email
+// address supplied in the request body, listing only that user's
+// registered credentials in allowCredentials.
+// POST /api/webauthn/login/begin
+func (s *Server) HandleAPIWebAuthnLoginBegin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	email := strings.TrimSpace(req.Email)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	q := dbgen.New(s.DB)
+	u, err := q.GetUserByEmail(r.Context(), email)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no such account"})
+		return
+	}
+
+	creds, err := q.ListWebAuthnCredentialsByUser(r.Context(), u.ID)
+	if err != nil || len(creds) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "no passkeys registered for this account"})
+		return
+	}
+
+	handle, challenge, err := s.WebAuthnChallenges.NewChallenge(u.ID, email)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to start login"})
+		return
+	}
+
+	allow := make([]credentialDescriptor, 0, len(creds))
+	for _, c := range creds {
+		allow = append(allow, credentialDescriptor{Type: "public-key", ID: base64.RawURLEncoding.EncodeToString([]byte(c.CredentialID))})
+	}
+
+	json.NewEncoder(w).Encode(assertionOptions{
+		Handle:           handle,
+		Challenge:        base64.RawURLEncoding.EncodeToString(challenge),
+		RPID:             s.WebAuthnRP.ID,
+		AllowCredentials: allow,
+		UserVerification: "preferred",
+	})
+}
+
+type loginFinishRequest struct {
+	Handle            string `json:"handle"`
+	CredentialID      string `json:"credential_id"`
+	ClientDataJSON    string `json:"client_data_json"`
+	AuthenticatorData string `json:"authenticator_data"`
+	Signature         string `json:"signature"`
+}
+
+// HandleAPIWebAuthnLoginFinish verifies an assertion and, on success,
+// issues a session the same way password login does so the rest of the
+// auth middleware stack is unaffected.
+// POST /api/webauthn/login/finish
+func (s *Server) HandleAPIWebAuthnLoginFinish(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req loginFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid request body"})
+		return
+	}
+
+	challenge, _, email, err := s.WebAuthnChallenges.Take(req.Handle)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ceremony expired or invalid"})
+		return
+	}
+
+	credIDBytes, err := base64.RawURLEncoding.DecodeString(req.CredentialID)
+	clientDataJSON, err2 := base64.RawURLEncoding.DecodeString(req.ClientDataJSON)
+	authData, err3 := base64.RawURLEncoding.DecodeString(req.AuthenticatorData)
+	sig, err4 := base64.RawURLEncoding.DecodeString(req.Signature)
+	if err != nil || err2 != nil || err3 != nil || err4 != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid base64url encoding"})
+		return
+	}
+
+	q := dbgen.New(s.DB)
+	row, err := q.GetWebAuthnCredential(r.Context(), string(credIDBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unknown credential"})
+		return
+	}
+
+	cred := &webauthn.Credential{
+		ID:        []byte(row.CredentialID),
+		UserID:    row.UserID,
+		PublicKey: row.PublicKey,
+		SignCount: uint32(row.SignCount),
+	}
+
+	newCount, err := s.WebAuthnRP.VerifyAssertion(clientDataJSON, authData, sig, challenge, cred)
+	if err != nil {
+		// A sign-count regression (or any other verification failure)
+		// marks the credential compromised so it can no longer be used,
+		// rather than silently accepting a possibly cloned key.
+		_ = q.MarkWebAuthnCredentialCompromised(r.Context(), string(credIDBytes))
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "verification failed: " + err.Error()})
+		return
+	}
+
+	if err := q.UpdateWebAuthnCredentialSignCount(r.Context(), dbgen.UpdateWebAuthnCredentialSignCountParams{
+		CredentialID: string(credIDBytes),
+		SignCount:    int64(newCount),
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to update credential"})
+		return
+	}
+
+	sessionID, _, loginErr := s.Auth.LoginWithoutPassword(r.Context(), email)
+	if loginErr != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": loginErr.Error()})
+		return
+	}
+
+	auth.SetSessionCookie(w, sessionID, s.Auth.InitialSessionMaxAge(), s.IsSecureRequest(r))
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+}
+
+// webauthnCredentialSummary is what HandleAPIWebAuthnListCredentials
+// exposes for one registered passkey - enough for an account page to
+// show "which key is this" and let the user revoke it, without leaking
+// the public key or sign count.
+type webauthnCredentialSummary struct {
+	ID         string `json:"id"` // base64url credential_id
+	Transports string `json:"transports,omitempty"`
+}
+
+// HandleAPIWebAuthnListCredentials lists the logged-in user's
+// registered passkeys, so an account settings page can offer to revoke
+// one without needing a fresh assertion first.
+// GET /api/webauthn/credentials
+func (s *Server) HandleAPIWebAuthnListCredentials(w http.ResponseWriter, r *http.Request) {
+	user := s.Auth.GetUserFromRequest(r)
+	w.Header().Set("Content-Type", "application/json")
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+		return
+	}
+
+	creds, err := dbgen.New(s.DB).ListWebAuthnCredentialsByUser(r.Context(), user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to list credentials"})
+		return
+	}
+
+	out := make([]webauthnCredentialSummary, 0, len(creds))
+	for _, c := range creds {
+		out = append(out, webauthnCredentialSummary{
+			ID:         base64.RawURLEncoding.EncodeToString([]byte(c.CredentialID)),
+			Transports: c.Transports,
+		})
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// HandleAPIWebAuthnDeleteCredential revokes one of the logged-in user's
+// registered passkeys. Scoped to the caller's own user_id so one account
+// can't delete another's credential by guessing its id.
+// DELETE /api/webauthn/credentials/{id}
+func (s *Server) HandleAPIWebAuthnDeleteCredential(w http.ResponseWriter, r *http.Request) {
+	user := s.Auth.GetUserFromRequest(r)
+	w.Header().Set("Content-Type", "application/json")
+	if user == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+		return
+	}
+
+	credIDBytes, err := base64.RawURLEncoding.DecodeString(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid credential id"})
+		return
+	}
+
+	if err := dbgen.New(s.DB).DeleteWebAuthnCredential(r.Context(), dbgen.DeleteWebAuthnCredentialParams{
+		CredentialID: string(credIDBytes),
+		UserID:       user.ID,
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to delete credential"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+}
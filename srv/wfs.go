@@ -0,0 +1,566 @@
+package srv
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	"srv.exe.dev/db/dbgen"
+)
+
+// wfsFeatureTypes lists the feature types GetCapabilities and
+// DescribeFeatureType advertise, and that GetFeature knows how to serve.
+// Each mirrors an existing API/tile data source rather than a new one —
+// WFS is just another way for GIS clients (QGIS, ArcGIS) to pull the
+// same effort grid, protected areas, fires and deforestation events the
+// map UI already renders.
+var wfsFeatureTypes = []string{"grid_cells", "protected_areas", "fire_detections", "deforestation_events"}
+
+// defaultWFSCount and maxWFSCount bound how many features a single
+// GetFeature request returns absent (or abusing) a "count" parameter —
+// QGIS defaults to requesting everything, which for grid_cells would
+// otherwise mean one dbgen query per grid cell in the whole dataset.
+const (
+	defaultWFSCount = 1000
+	maxWFSCount     = 10000
+)
+
+// HandleWFS implements a minimal OGC WFS 2.0 GetCapabilities/
+// DescribeFeatureType/GetFeature interface over the same data the map
+// UI and /api endpoints already serve, so conservation partners can
+// load it into QGIS or ArcGIS instead of scripting against the JSON API.
+// Route: GET /wfs?SERVICE=WFS&REQUEST=GetCapabilities|DescribeFeatureType|GetFeature
+func (s *Server) HandleWFS(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	request := firstNonEmpty(q.Get("REQUEST"), q.Get("request"))
+
+	switch request {
+	case "GetCapabilities":
+		s.handleWFSGetCapabilities(w, r)
+	case "DescribeFeatureType":
+		s.handleWFSDescribeFeatureType(w, r)
+	case "GetFeature":
+		s.handleWFSGetFeature(w, r)
+	default:
+		http.Error(w, "unsupported WFS request", http.StatusBadRequest)
+	}
+}
+
+// wfsCapabilities is a minimal GetCapabilities XML document listing the
+// feature types this server exposes.
+type wfsCapabilities struct {
+	XMLName      xml.Name            `xml:"wfs:WFS_Capabilities"`
+	XmlnsWFS     string              `xml:"xmlns:wfs,attr"`
+	XmlnsOWS     string              `xml:"xmlns:ows,attr"`
+	Version      string              `xml:"version,attr"`
+	ServiceIdent wfsServiceIdent     `xml:"ows:ServiceIdentification"`
+	FeatureTypes []wfsFeatureTypeXML `xml:"FeatureTypeList>FeatureType"`
+}
+
+type wfsServiceIdent struct {
+	Title       string `xml:"ows:Title"`
+	ServiceType string `xml:"ows:ServiceType"`
+}
+
+type wfsFeatureTypeXML struct {
+	Name          string   `xml:"Name"`
+	Title         string   `xml:"Title"`
+	CRS           string   `xml:"DefaultCRS"`
+	OutputFormats []string `xml:"OutputFormats>Format"`
+}
+
+func (s *Server) handleWFSGetCapabilities(w http.ResponseWriter, r *http.Request) {
+	caps := wfsCapabilities{
+		XmlnsWFS:     "http://www.opengis.net/wfs/2.0",
+		XmlnsOWS:     "http://www.opengis.net/ows/1.1",
+		Version:      "2.0.0",
+		ServiceIdent: wfsServiceIdent{Title: "5MP WFS", ServiceType: "WFS"},
+	}
+	for _, name := range wfsFeatureTypes {
+		caps.FeatureTypes = append(caps.FeatureTypes, wfsFeatureTypeXML{
+			Name:          "app:" + name,
+			Title:         name,
+			CRS:           "urn:ogc:def:crs:EPSG::4326",
+			OutputFormats: []string{"application/gml+xml; version=3.2", "application/json"},
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(caps)
+}
+
+// handleWFSDescribeFeatureType writes a minimal XSD schema for the
+// requested typeNames (defaulting to every type this server knows about
+// if none is given).
+func (s *Server) handleWFSDescribeFeatureType(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	requested := wfsTypeNameList(firstNonEmpty(q.Get("typeNames"), q.Get("typeName")))
+	if len(requested) == 0 {
+		requested = wfsFeatureTypes
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	fmt.Fprint(w, `<xsd:schema xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:gml="http://www.opengis.net/gml/3.2" xmlns:app="http://5mp.example/wfs" targetNamespace="http://5mp.example/wfs" elementFormDefault="qualified">`+"\n")
+	for _, name := range requested {
+		fields, ok := wfsTypeFields[name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "  <xsd:complexType name=%q>\n    <xsd:complexContent>\n      <xsd:extension base=\"gml:AbstractFeatureType\">\n        <xsd:sequence>\n          <xsd:element name=\"geometry\" type=\"gml:GeometryPropertyType\"/>\n", name+"Type")
+		for _, f := range fields {
+			fmt.Fprintf(w, "          <xsd:element name=%q type=%q/>\n", f.Name, f.Type)
+		}
+		fmt.Fprint(w, "        </xsd:sequence>\n      </xsd:extension>\n    </xsd:complexContent>\n  </xsd:complexType>\n")
+		fmt.Fprintf(w, "  <xsd:element name=%q type=%q substitutionGroup=\"gml:AbstractFeature\"/>\n", name, "app:"+name+"Type")
+	}
+	fmt.Fprint(w, "</xsd:schema>\n")
+}
+
+// wfsField is one property DescribeFeatureType exposes for a feature
+// type, besides its geometry.
+type wfsField struct {
+	Name, Type string
+}
+
+// wfsTypeFields names the non-geometry properties of each feature type,
+// matching what the equivalent GeoJSON endpoint already puts in
+// "properties" (HandleAPIGrid, HandleAPIAreas) or the columns
+// deforestation_events stores.
+var wfsTypeFields = map[string][]wfsField{
+	"grid_cells": {
+		{"id", "xsd:string"},
+		{"intensity", "xsd:double"},
+		{"dry_months", "xsd:integer"},
+		{"rainy_months", "xsd:integer"},
+	},
+	"protected_areas": {
+		{"wdpa_id", "xsd:string"},
+		{"name", "xsd:string"},
+		{"country_code", "xsd:string"},
+		{"area_km2", "xsd:double"},
+	},
+	"fire_detections": {
+		{"confidence", "xsd:string"},
+		{"brightness", "xsd:double"},
+		{"acq_date", "xsd:string"},
+	},
+	"deforestation_events": {
+		{"park_id", "xsd:string"},
+		{"area_km2", "xsd:double"},
+	},
+}
+
+// wfsFeature is a generic WFS feature — a geometry plus name/value
+// properties — used so GetFeature can render GML or GeoJSON the same
+// way regardless of which underlying table or cache it came from.
+type wfsFeature struct {
+	ID         string
+	Geometry   orb.Geometry
+	Properties map[string]interface{}
+}
+
+// handleWFSGetFeature serves one feature type, applying bbox/year/month/
+// country_code filters and startIndex/count paging.
+func (s *Server) handleWFSGetFeature(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	typeNames := wfsTypeNameList(firstNonEmpty(q.Get("typeNames"), q.Get("typeName")))
+	if len(typeNames) != 1 {
+		http.Error(w, "GetFeature requires exactly one typeNames value", http.StatusBadRequest)
+		return
+	}
+	typeName := typeNames[0]
+
+	filter, err := parseWFSFilter(firstNonEmpty(q.Get("CQL_FILTER"), q.Get("cql_filter")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	year := firstNonEmpty(filter["year"], q.Get("year"))
+	bboxStr := q.Get("bbox")
+	countryCode := firstNonEmpty(filter["country_code"], q.Get("country_code"))
+
+	count := defaultWFSCount
+	if v, err := strconv.Atoi(q.Get("count")); err == nil && v > 0 {
+		count = v
+	}
+	if count > maxWFSCount {
+		count = maxWFSCount
+	}
+	startIndex := 0
+	if v, err := strconv.Atoi(q.Get("startIndex")); err == nil && v > 0 {
+		startIndex = v
+	}
+
+	ctx := r.Context()
+	var all []wfsFeature
+	switch typeName {
+	case "grid_cells":
+		all, err = s.wfsGridFeatures(ctx, bboxStr, year)
+	case "protected_areas":
+		all, err = s.wfsAreaFeatures(countryCode)
+	case "fire_detections":
+		all, err = s.wfsFireFeatures(bboxStr)
+	case "deforestation_events":
+		all, err = s.wfsDeforestationFeatures(ctx, bboxStr, year)
+	default:
+		http.Error(w, "unknown typeNames: "+typeName, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	numberMatched := len(all)
+	if startIndex > numberMatched {
+		startIndex = numberMatched
+	}
+	end := startIndex + count
+	if end > numberMatched {
+		end = numberMatched
+	}
+	page := all[startIndex:end]
+
+	outputFormat := firstNonEmpty(q.Get("outputFormat"), "application/gml+xml; version=3.2")
+	if strings.Contains(outputFormat, "json") {
+		s.writeWFSGeoJSON(w, page, numberMatched)
+		return
+	}
+	s.writeWFSGML(w, typeName, page, numberMatched)
+}
+
+// wfsTypeNameList splits a typeNames param on commas and strips the
+// "app:" prefix GetCapabilities advertises.
+func wfsTypeNameList(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(part), "app:"))
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseWFSFilter maps a small subset of OGC CQL ("year = 2024 AND
+// country_code = 'KEN'") to the same year/month/country_code values the
+// JSON API already takes as query params. It doesn't attempt full CQL —
+// just the AND-joined equality clauses GIS clients generate for simple
+// attribute filters.
+func parseWFSFilter(cql string) (map[string]string, error) {
+	out := map[string]string{}
+	if cql == "" {
+		return out, nil
+	}
+	for _, clause := range strings.Split(cql, " AND ") {
+		parts := strings.SplitN(clause, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unsupported CQL_FILTER clause: %q", clause)
+		}
+		field := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), "'\"")
+		switch field {
+		case "year", "month", "country_code":
+			out[field] = value
+		default:
+			return nil, fmt.Errorf("unsupported CQL_FILTER field: %q", field)
+		}
+	}
+	return out, nil
+}
+
+// wfsGridFeatures gathers grid_cells features over bboxStr (required —
+// without it this would mean one dbgen query per grid cell on Earth),
+// reusing the same per-cell query encodeGridTile uses for map tiles.
+func (s *Server) wfsGridFeatures(ctx context.Context, bboxStr, yearStr string) ([]wfsFeature, error) {
+	if bboxStr == "" {
+		return nil, fmt.Errorf("grid_cells requires a bbox parameter")
+	}
+	bbox, err := parseBBoxParam(bboxStr)
+	if err != nil {
+		return nil, err
+	}
+	year := int64(time.Now().Year())
+	if y, err := strconv.ParseInt(yearStr, 10, 64); err == nil {
+		year = y
+	}
+
+	q := dbgen.New(s.DB)
+	var out []wfsFeature
+	for _, cell := range gridCellBBoxes(bbox) {
+		lat, lon := (cell.MinLat+cell.MaxLat)/2, (cell.MinLon+cell.MaxLon)/2
+		cellID := gridCellIDForPoint(lat, lon)
+		row, err := q.GetEffortDataForCellWithMonthCounts(ctx, dbgen.GetEffortDataForCellWithMonthCountsParams{
+			GridCellID: cellID,
+			Year:       year,
+			Year_2:     year,
+		})
+		if err != nil {
+			continue
+		}
+		intensity := (float64(row.DryMonths) + float64(row.RainyMonths)*0.3) / 6.0
+		if intensity > 1.5 {
+			intensity = 1.5
+		}
+		out = append(out, wfsFeature{
+			ID:       cellID,
+			Geometry: orb.Point{lon, lat},
+			Properties: map[string]interface{}{
+				"id":           cellID,
+				"intensity":    intensity,
+				"dry_months":   row.DryMonths,
+				"rainy_months": row.RainyMonths,
+			},
+		})
+	}
+	return out, nil
+}
+
+// wfsAreaFeatures gathers protected_areas features from AreaStore,
+// optionally filtered by country_code, mirroring HandleAPIAreas.
+func (s *Server) wfsAreaFeatures(countryCode string) ([]wfsFeature, error) {
+	if s.AreaStore == nil {
+		return nil, fmt.Errorf("area store not configured")
+	}
+	var out []wfsFeature
+	for _, area := range s.AreaStore.Areas {
+		if countryCode != "" && !strings.EqualFold(area.CountryCode, countryCode) {
+			continue
+		}
+		raw, err := json.Marshal(map[string]interface{}{
+			"type":        area.Geometry.Type,
+			"coordinates": area.Geometry.Coordinates,
+		})
+		if err != nil {
+			continue
+		}
+		geom, err := geojson.UnmarshalGeometry(raw)
+		if err != nil {
+			continue
+		}
+		out = append(out, wfsFeature{
+			ID:       area.ID,
+			Geometry: geom.Geometry(),
+			Properties: map[string]interface{}{
+				"wdpa_id":      area.WDPAID,
+				"name":         area.Name,
+				"country_code": area.CountryCode,
+				"area_km2":     area.AreaKm2,
+			},
+		})
+	}
+	return out, nil
+}
+
+// wfsFireFeatures gathers fire_detections features from the fire daily
+// GeoJSON blob FireCache keeps warm, optionally clipped to bboxStr.
+func (s *Server) wfsFireFeatures(bboxStr string) ([]wfsFeature, error) {
+	raw, err := s.FireCache.Get(fireDailyGeoJSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("fire data not found: %w", err)
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse fire data: %w", err)
+	}
+
+	var tileBound orb.Bound
+	haveBound := false
+	if bboxStr != "" {
+		bbox, err := parseBBoxParam(bboxStr)
+		if err != nil {
+			return nil, err
+		}
+		tileBound = orb.Bound{Min: orb.Point{bbox.MinLon, bbox.MinLat}, Max: orb.Point{bbox.MaxLon, bbox.MaxLat}}
+		haveBound = true
+	}
+
+	var out []wfsFeature
+	for i, feat := range fc.Features {
+		if feat.Geometry == nil {
+			continue
+		}
+		if haveBound && !feat.Geometry.Bound().Intersects(tileBound) {
+			continue
+		}
+		props := map[string]interface{}{
+			"confidence": feat.Properties["confidence"],
+			"brightness": feat.Properties["brightness"],
+			"acq_date":   feat.Properties["acq_date"],
+		}
+		out = append(out, wfsFeature{ID: fmt.Sprintf("fire.%d", i), Geometry: feat.Geometry, Properties: props})
+	}
+	return out, nil
+}
+
+// wfsDeforestationFeatures gathers deforestation_events features for
+// yearStr (defaulting to the current year), pushing bboxStr into the SQL
+// WHERE clause when given — mirroring encodeDeforestationTile.
+func (s *Server) wfsDeforestationFeatures(ctx context.Context, bboxStr, yearStr string) ([]wfsFeature, error) {
+	year := time.Now().Year()
+	if y, err := strconv.Atoi(yearStr); err == nil {
+		year = y
+	}
+
+	query := `SELECT park_id, area_km2, lat, lon FROM deforestation_events WHERE year = ?`
+	args := []interface{}{year}
+	if bboxStr != "" {
+		bbox, err := parseBBoxParam(bboxStr)
+		if err != nil {
+			return nil, err
+		}
+		query += ` AND lat BETWEEN ? AND ? AND lon BETWEEN ? AND ?`
+		args = append(args, bbox.MinLat, bbox.MaxLat, bbox.MinLon, bbox.MaxLon)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query deforestation events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []wfsFeature
+	for rows.Next() {
+		var parkID string
+		var areaKm2, lat, lon float64
+		if err := rows.Scan(&parkID, &areaKm2, &lat, &lon); err != nil {
+			continue
+		}
+		out = append(out, wfsFeature{
+			ID:       parkID,
+			Geometry: orb.Point{lon, lat},
+			Properties: map[string]interface{}{
+				"park_id":  parkID,
+				"area_km2": areaKm2,
+			},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseBBoxParam parses a "minLon,minLat,maxLon,maxLat" bbox query param,
+// the order WFS and the map UI both already use.
+func parseBBoxParam(s string) (BBox, error) {
+	var minLon, minLat, maxLon, maxLat float64
+	if _, err := fmt.Sscanf(s, "%f,%f,%f,%f", &minLon, &minLat, &maxLon, &maxLat); err != nil {
+		return BBox{}, fmt.Errorf("invalid bbox parameter: %q", s)
+	}
+	return BBox{MinLon: minLon, MinLat: minLat, MaxLon: maxLon, MaxLat: maxLat}, nil
+}
+
+// writeWFSGeoJSON writes page as a GeoJSON FeatureCollection, the
+// outputFormat=application/json GetFeature clients ask for instead of GML.
+func (s *Server) writeWFSGeoJSON(w http.ResponseWriter, page []wfsFeature, numberMatched int) {
+	fc := geojson.NewFeatureCollection()
+	for _, f := range page {
+		feat := geojson.NewFeature(f.Geometry)
+		feat.ID = f.ID
+		for k, v := range f.Properties {
+			feat.Properties[k] = v
+		}
+		fc.Append(feat)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Number-Matched", strconv.Itoa(numberMatched))
+	w.Header().Set("X-Number-Returned", strconv.Itoa(len(page)))
+	json.NewEncoder(w).Encode(fc)
+}
+
+// writeWFSGML writes page as a GML 3.2 wfs:FeatureCollection. Feature
+// properties come from dynamic maps (not fixed structs), so — like
+// DescribeFeatureType's XSD — this is built by hand rather than via
+// encoding/xml struct tags.
+func (s *Server) writeWFSGML(w http.ResponseWriter, typeName string, page []wfsFeature, numberMatched int) {
+	w.Header().Set("Content-Type", "application/gml+xml; version=3.2")
+	w.Write([]byte(xml.Header))
+	fmt.Fprintf(w, `<wfs:FeatureCollection xmlns:wfs="http://www.opengis.net/wfs/2.0" xmlns:gml="http://www.opengis.net/gml/3.2" xmlns:app="http://5mp.example/wfs" numberMatched=%q numberReturned="%d">`+"\n",
+		strconv.Itoa(numberMatched), len(page))
+	for _, f := range page {
+		geomXML, err := gmlGeometryXML(f.Geometry)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "  <wfs:member>\n    <app:%s gml:id=%q>\n      <app:geometry>%s</app:geometry>\n", typeName, typeName+"."+f.ID, geomXML)
+		for _, k := range sortedKeys(f.Properties) {
+			fmt.Fprintf(w, "      <app:%s>%v</app:%s>\n", k, f.Properties[k], k)
+		}
+		fmt.Fprintf(w, "    </app:%s>\n  </wfs:member>\n", typeName)
+	}
+	fmt.Fprint(w, "</wfs:FeatureCollection>\n")
+}
+
+// sortedKeys returns m's keys in a stable order, so repeated GetFeature
+// calls render properties in the same sequence.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// gmlGeometryXML renders g as a GML 3.2 geometry element. Only the
+// geometry types this server's feature types actually use are
+// supported; anything else is an error rather than a silently wrong tile.
+func gmlGeometryXML(g orb.Geometry) (string, error) {
+	switch t := g.(type) {
+	case orb.Point:
+		return fmt.Sprintf(`<gml:Point srsName="EPSG:4326"><gml:pos>%f %f</gml:pos></gml:Point>`, t[0], t[1]), nil
+	case orb.Polygon:
+		return gmlPolygonXML(t), nil
+	case orb.MultiPolygon:
+		var sb strings.Builder
+		sb.WriteString(`<gml:MultiSurface srsName="EPSG:4326">`)
+		for _, poly := range t {
+			sb.WriteString(`<gml:surfaceMember>`)
+			sb.WriteString(gmlPolygonXML(poly))
+			sb.WriteString(`</gml:surfaceMember>`)
+		}
+		sb.WriteString(`</gml:MultiSurface>`)
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("wfs: unsupported geometry type %T", g)
+	}
+}
+
+func gmlPolygonXML(p orb.Polygon) string {
+	var sb strings.Builder
+	sb.WriteString(`<gml:Polygon srsName="EPSG:4326">`)
+	for i, ring := range p {
+		tag := "exterior"
+		if i > 0 {
+			tag = "interior"
+		}
+		fmt.Fprintf(&sb, `<gml:%s><gml:LinearRing><gml:posList>`, tag)
+		for j, pt := range ring {
+			if j > 0 {
+				sb.WriteString(" ")
+			}
+			fmt.Fprintf(&sb, "%f %f", pt[0], pt[1])
+		}
+		fmt.Fprintf(&sb, `</gml:posList></gml:LinearRing></gml:%s>`, tag)
+	}
+	sb.WriteString(`</gml:Polygon>`)
+	return sb.String()
+}